@@ -0,0 +1,109 @@
+package auth
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// DeviceCookieName is the cookie identifying a browser across logins
+const DeviceCookieName = "device_id"
+
+// KnownDevice is a browser that has logged in before
+type KnownDevice struct {
+	ID        string `json:"id"`
+	Username  string `json:"username"`
+	UserAgent string `json:"user_agent"`
+	IP        string `json:"ip"`         // address of the latest login
+	FirstSeen int64  `json:"first_seen"` // unix timestamp
+	LastSeen  int64  `json:"last_seen"`  // unix timestamp
+}
+
+// DeviceStore remembers the devices users logged in from, so logins from new
+// devices can be reported
+type DeviceStore struct {
+	path    string
+	mu      sync.Mutex
+	devices map[string]KnownDevice // device ID -> device
+}
+
+// OpenDeviceStore loads the store at path, creating it on first write
+func OpenDeviceStore(path string) (*DeviceStore, error) {
+	store := &DeviceStore{path: path, devices: make(map[string]KnownDevice)}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return store, nil
+		}
+		return nil, fmt.Errorf("failed to read known devices: %w", err)
+	}
+	var devices []KnownDevice
+	if err := json.Unmarshal(data, &devices); err != nil {
+		return nil, fmt.Errorf("failed to parse known devices: %w", err)
+	}
+	for _, device := range devices {
+		store.devices[device.ID] = device
+	}
+	return store, nil
+}
+
+// RecordLogin records a login by username from the device with the given ID.
+// A device is new when the ID is empty, unknown or belongs to another user;
+// it then gets a fresh ID, which the caller should store in the device
+// cookie.
+func (s *DeviceStore) RecordLogin(deviceID, username, userAgent, ip string, now time.Time) (device KnownDevice, isNew bool, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	device, known := s.devices[deviceID]
+	if !known || device.Username != username {
+		id, err := randomHex(16)
+		if err != nil {
+			return KnownDevice{}, false, err
+		}
+		device = KnownDevice{ID: id, Username: username, FirstSeen: now.Unix()}
+		isNew = true
+	}
+	device.UserAgent = userAgent
+	device.IP = ip
+	device.LastSeen = now.Unix()
+	s.devices[device.ID] = device
+	return device, isNew, s.saveLocked()
+}
+
+// saveLocked writes the store atomically. Must be called with s.mu held.
+func (s *DeviceStore) saveLocked() error {
+	devices := make([]KnownDevice, 0, len(s.devices))
+	for _, device := range s.devices {
+		devices = append(devices, device)
+	}
+	data, err := json.MarshalIndent(devices, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0700); err != nil {
+		return fmt.Errorf("failed to create known devices directory: %w", err)
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("failed to write known devices: %w", err)
+	}
+	return os.Rename(tmp, s.path)
+}
+
+// GetDeviceStorePathFromEnv returns TERMINAL_HUB_KNOWN_DEVICES, defaulting to
+// ~/.terminal-hub/known_devices.json
+func GetDeviceStorePathFromEnv() string {
+	if path := os.Getenv("TERMINAL_HUB_KNOWN_DEVICES"); path != "" {
+		return path
+	}
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "known_devices.json"
+	}
+	return filepath.Join(homeDir, ".terminal-hub", "known_devices.json")
+}
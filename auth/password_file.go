@@ -2,58 +2,90 @@ package auth
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
+	"sync"
 	"time"
-
-	"golang.org/x/crypto/bcrypt"
+	"unicode"
+	"unicode/utf8"
 )
 
-// PasswordFile represents the structure of the credentials file
+// PasswordFile represents the structure of the credentials file. Version 1
+// files hold a single user in Username and PasswordHash; version 2 files
+// list every user in Users; version 3 files may hold Argon2id hashes.
 type PasswordFile struct {
-	Username     string `json:"username"`
-	PasswordHash string `json:"password_hash,omitempty"`
-	Password     string `json:"password,omitempty"` // Legacy: plain text for auto-migration
-	Version      int    `json:"version"`
-	UpdatedAt    string `json:"updated_at,omitempty"`
+	Username     string           `json:"username,omitempty"`
+	PasswordHash string           `json:"password_hash,omitempty"`
+	Password     string           `json:"password,omitempty"` // Legacy: plain text for auto-migration
+	Users        []UserCredential `json:"users,omitempty"`
+	Version      int              `json:"version"`
+	UpdatedAt    string           `json:"updated_at,omitempty"`
 }
 
-// currentPasswordFileVersion is the current version of the password file format
-const currentPasswordFileVersion = 1
+// UserCredential is a user allowed to log in
+type UserCredential struct {
+	Username           string `json:"username"`
+	PasswordHash       string `json:"password_hash"`        // Argon2id, or legacy bcrypt
+	UpdatedAt          string `json:"updated_at,omitempty"` // when the password was last changed, RFC 3339
+	MustChangePassword bool   `json:"must_change_password,omitempty"`
+}
 
-// isBcryptHash checks if a string is a bcrypt hash
-// bcrypt hashes start with $2a$, $2b$, or $2y$
-func isBcryptHash(s string) bool {
-	return strings.HasPrefix(s, "$2a$") ||
-		strings.HasPrefix(s, "$2b$") ||
-		strings.HasPrefix(s, "$2y$")
+// PasswordChangedAt returns when the password was last changed, the zero
+// time when unknown
+func (u UserCredential) PasswordChangedAt() time.Time {
+	t, err := time.Parse(time.RFC3339, u.UpdatedAt)
+	if err != nil {
+		return time.Time{}
+	}
+	return t
 }
 
-// LoadCredentials loads credentials from a password file
-// If the file contains a plain text password, it automatically hashes it and saves back
-// Returns username, password hash, and error
-func LoadCredentials(filePath string) (username, passwordHash string, err error) {
+// currentPasswordFileVersion is the current version of the password file format
+const currentPasswordFileVersion = 3
+
+// maxUsernameLength is the most characters in a username
+const maxUsernameLength = 64
+
+// ErrUserExists is returned when adding a user that is already in the file
+var ErrUserExists = errors.New("user already exists")
+
+// ErrUserNotFound is returned when changing a user that is not in the file
+var ErrUserNotFound = errors.New("user not found")
+
+// ErrLastUser is returned when removing the only user, which would turn
+// authentication off
+var ErrLastUser = errors.New("cannot remove the last user; delete the password file to disable authentication")
+
+// passwordFileMu serializes changes to password files, as logins re-hash
+// legacy entries while the password-change API may be saving the same file
+var passwordFileMu sync.Mutex
+
+// LoadUsers loads the users from a password file. A plain text password in
+// a version 1 file is hashed and saved back.
+func LoadUsers(filePath string) ([]UserCredential, error) {
 	// Ensure directory exists with secure permissions
 	dir := filepath.Dir(filePath)
 	if err := os.MkdirAll(dir, 0700); err != nil {
-		return "", "", fmt.Errorf("failed to create credentials directory: %w", err)
+		return nil, fmt.Errorf("failed to create credentials directory: %w", err)
 	}
 
 	// Read the password file
 	data, err := os.ReadFile(filePath)
 	if err != nil {
 		if os.IsNotExist(err) {
-			return "", "", fmt.Errorf("password file not found: %s", filePath)
+			return nil, fmt.Errorf("password file not found: %s", filePath)
 		}
-		return "", "", fmt.Errorf("failed to read password file: %w", err)
+		return nil, fmt.Errorf("failed to read password file: %w", err)
 	}
 
 	// Check file permissions (should be 0600)
 	info, err := os.Stat(filePath)
 	if err != nil {
-		return "", "", fmt.Errorf("failed to stat password file: %w", err)
+		return nil, fmt.Errorf("failed to stat password file: %w", err)
 	}
 	// Warn if permissions are too open
 	if info.Mode().Perm()&0077 != 0 {
@@ -64,50 +96,194 @@ func LoadCredentials(filePath string) (username, passwordHash string, err error)
 
 	var pwFile PasswordFile
 	if err := json.Unmarshal(data, &pwFile); err != nil {
-		return "", "", fmt.Errorf("failed to parse password file: %w", err)
+		return nil, fmt.Errorf("failed to parse password file: %w", err)
 	}
 
-	// Validate username
-	if pwFile.Username == "" {
-		return "", "", fmt.Errorf("password file missing username")
+	migrating := pwFile.Password != ""
+	users, err := pwFile.users()
+	if err != nil {
+		return nil, err
+	}
+	if len(users) == 0 {
+		return nil, fmt.Errorf("password file has no users")
+	}
+
+	if migrating {
+		passwordFileMu.Lock()
+		defer passwordFileMu.Unlock()
+		if err := savePasswordFile(filePath, &PasswordFile{Users: users}); err != nil {
+			return nil, fmt.Errorf("failed to save hashed password: %w", err)
+		}
+		fmt.Printf("Password file auto-migrated: plain text password hashed and saved\n")
+	}
+	return users, nil
+}
+
+// users returns the users of the file in either version, hashing a legacy
+// plain text password
+func (f *PasswordFile) users() ([]UserCredential, error) {
+	if len(f.Users) > 0 {
+		for _, user := range f.Users {
+			if user.Username == "" {
+				return nil, fmt.Errorf("password file has a user without a username")
+			}
+			if !isPasswordHash(user.PasswordHash) {
+				return nil, fmt.Errorf("password_hash of user %q is not a valid Argon2id or bcrypt hash", user.Username)
+			}
+		}
+		return f.Users, nil
+	}
+
+	// Version 1: a single user, or no users at all
+	if f.Username == "" {
+		if f.PasswordHash != "" || f.Password != "" {
+			return nil, fmt.Errorf("password file missing username")
+		}
+		return nil, nil
 	}
 
 	// Check if we have a password hash or plain text password
-	if pwFile.PasswordHash != "" && isBcryptHash(pwFile.PasswordHash) {
-		// Already hashed, return as-is
-		return pwFile.Username, pwFile.PasswordHash, nil
+	if f.PasswordHash != "" && isPasswordHash(f.PasswordHash) {
+		return []UserCredential{{Username: f.Username, PasswordHash: f.PasswordHash, UpdatedAt: f.UpdatedAt}}, nil
 	}
 
 	// Check for legacy plain text password
-	if pwFile.Password != "" {
+	if f.Password != "" {
 		// Auto-migrate: hash the plain text password
-		hash, err := bcrypt.GenerateFromPassword([]byte(pwFile.Password), bcrypt.DefaultCost)
+		hash, err := HashPassword(f.Password)
 		if err != nil {
-			return "", "", fmt.Errorf("failed to hash password: %w", err)
+			return nil, err
 		}
+		return []UserCredential{{Username: f.Username, PasswordHash: hash, UpdatedAt: time.Now().UTC().Format(time.RFC3339)}}, nil
+	}
 
-		// Update the file with the hashed password
-		updatedFile := PasswordFile{
-			Username:     pwFile.Username,
-			PasswordHash: string(hash),
-			Version:      currentPasswordFileVersion,
-			UpdatedAt:    time.Now().UTC().Format(time.RFC3339),
-		}
+	// Check for password_hash field that's not a valid hash
+	if f.PasswordHash != "" {
+		return nil, fmt.Errorf("password_hash is not a valid Argon2id or bcrypt hash")
+	}
 
-		if err := savePasswordFile(filePath, &updatedFile); err != nil {
-			return "", "", fmt.Errorf("failed to save hashed password: %w", err)
-		}
+	return nil, fmt.Errorf("password file missing password or password_hash field")
+}
 
-		fmt.Printf("Password file auto-migrated: plain text password hashed and saved\n")
-		return updatedFile.Username, updatedFile.PasswordHash, nil
+// ValidateUsername checks that a username is at most maxUsernameLength
+// characters without spaces or control characters
+func ValidateUsername(username string) error {
+	if username == "" {
+		return errors.New("username is required")
+	}
+	if !utf8.ValidString(username) || utf8.RuneCountInString(username) > maxUsernameLength {
+		return fmt.Errorf("username must be at most %d characters of valid UTF-8", maxUsernameLength)
+	}
+	if strings.IndexFunc(username, func(r rune) bool { return unicode.IsSpace(r) || unicode.IsControl(r) }) >= 0 {
+		return errors.New("username must not contain spaces or control characters")
+	}
+	return nil
+}
+
+// ListUsers returns the users in the password file at filePath, none when
+// it does not exist
+func ListUsers(filePath string) ([]UserCredential, error) {
+	data, err := os.ReadFile(filePath)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read password file: %w", err)
+	}
+	var pwFile PasswordFile
+	if err := json.Unmarshal(data, &pwFile); err != nil {
+		return nil, fmt.Errorf("failed to parse password file: %w", err)
+	}
+	return pwFile.users()
+}
+
+// updateUsers applies change to the users of the password file at filePath
+// and saves it in the current version
+func updateUsers(filePath string, change func(users []UserCredential) ([]UserCredential, error)) error {
+	passwordFileMu.Lock()
+	defer passwordFileMu.Unlock()
+
+	users, err := ListUsers(filePath)
+	if err != nil {
+		return err
+	}
+	if users, err = change(users); err != nil {
+		return err
 	}
+	return savePasswordFile(filePath, &PasswordFile{Users: users})
+}
 
-	// Check for password_hash field that's not a valid bcrypt hash
-	if pwFile.PasswordHash != "" {
-		return "", "", fmt.Errorf("password_hash is not a valid bcrypt hash (must start with $2a$, $2b$, or $2y$)")
+// AddUser adds a user to the password file at filePath, creating the file
+// if needed. passwordHash must be an Argon2id or bcrypt hash, see
+// HashPassword. mustChange makes the user change it after logging in.
+func AddUser(filePath, username, passwordHash string, mustChange bool) error {
+	if err := ValidateUsername(username); err != nil {
+		return err
+	}
+	if !isPasswordHash(passwordHash) {
+		return errInvalidPasswordHash
 	}
+	return updateUsers(filePath, func(users []UserCredential) ([]UserCredential, error) {
+		for _, user := range users {
+			if user.Username == username {
+				return nil, fmt.Errorf("%w: %s", ErrUserExists, username)
+			}
+		}
+		return append(users, UserCredential{
+			Username:           username,
+			PasswordHash:       passwordHash,
+			UpdatedAt:          time.Now().UTC().Format(time.RFC3339),
+			MustChangePassword: mustChange,
+		}), nil
+	})
+}
 
-	return "", "", fmt.Errorf("password file missing password or password_hash field")
+// SetUserPassword replaces the password hash of a user in the password file
+// at filePath. mustChange makes the user change it after logging in.
+func SetUserPassword(filePath, username, passwordHash string, mustChange bool) error {
+	return updateUser(filePath, username, passwordHash, func(user *UserCredential) {
+		user.UpdatedAt = time.Now().UTC().Format(time.RFC3339)
+		user.MustChangePassword = mustChange
+	})
+}
+
+// rehashUserPassword replaces the hash of a user's password with a new hash
+// of the same password, keeping when it was last changed
+func rehashUserPassword(filePath, username, passwordHash string) error {
+	return updateUser(filePath, username, passwordHash, func(*UserCredential) {})
+}
+
+// updateUser sets the password hash of a user in the password file at
+// filePath and applies change to them
+func updateUser(filePath, username, passwordHash string, change func(user *UserCredential)) error {
+	if !isPasswordHash(passwordHash) {
+		return errInvalidPasswordHash
+	}
+	return updateUsers(filePath, func(users []UserCredential) ([]UserCredential, error) {
+		for i := range users {
+			if users[i].Username == username {
+				users[i].PasswordHash = passwordHash
+				change(&users[i])
+				return users, nil
+			}
+		}
+		return nil, fmt.Errorf("%w: %s", ErrUserNotFound, username)
+	})
+}
+
+// RemoveUser removes a user from the password file at filePath
+func RemoveUser(filePath, username string) error {
+	return updateUsers(filePath, func(users []UserCredential) ([]UserCredential, error) {
+		for i, user := range users {
+			if user.Username == username {
+				if len(users) == 1 {
+					return nil, ErrLastUser
+				}
+				return slices.Delete(users, i, i+1), nil
+			}
+		}
+		return nil, fmt.Errorf("%w: %s", ErrUserNotFound, username)
+	})
 }
 
 // savePasswordFile atomically saves the password file
@@ -118,6 +294,8 @@ func savePasswordFile(filePath string, pwFile *PasswordFile) error {
 		return fmt.Errorf("failed to create credentials directory: %w", err)
 	}
 
+	pwFile.Version = currentPasswordFileVersion
+	pwFile.UpdatedAt = time.Now().UTC().Format(time.RFC3339)
 	data, err := json.MarshalIndent(pwFile, "", "  ")
 	if err != nil {
 		return fmt.Errorf("failed to marshal password file: %w", err)
@@ -138,24 +316,8 @@ func savePasswordFile(filePath string, pwFile *PasswordFile) error {
 	return nil
 }
 
-// ValidatePassword checks if the provided password matches the hash
-// Uses bcrypt's constant-time comparison
-func ValidatePassword(password, hash string) bool {
-	err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
-	return err == nil
-}
-
-// HashPassword generates a bcrypt hash from a plain text password
-func HashPassword(password string) (string, error) {
-	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
-	if err != nil {
-		return "", fmt.Errorf("failed to hash password: %w", err)
-	}
-	return string(hash), nil
-}
-
 // CreateCredentialsFile creates a credentials file at the default location
-// with bcrypt-hashed password. Only creates if file doesn't exist.
+// with an Argon2id-hashed password. Only creates if file doesn't exist.
 // Returns the path where file was created, or empty string if skipped.
 func CreateCredentialsFile(username, password string) (string, error) {
 	defaultPath, err := DefaultPasswordFilePath()
@@ -177,12 +339,11 @@ func CreateCredentialsFile(username, password string) (string, error) {
 	}
 
 	// Create the password file structure
-	pwFile := &PasswordFile{
+	pwFile := &PasswordFile{Users: []UserCredential{{
 		Username:     username,
 		PasswordHash: passwordHash,
-		Version:      currentPasswordFileVersion,
 		UpdatedAt:    time.Now().UTC().Format(time.RFC3339),
-	}
+	}}}
 
 	if err := savePasswordFile(defaultPath, pwFile); err != nil {
 		return "", fmt.Errorf("failed to save password file: %w", err)
@@ -0,0 +1,137 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Argon2id parameters of new hashes, the second recommended option of
+// RFC 9106. Hashes made with other parameters still verify, and are
+// re-hashed on the next successful login.
+const (
+	argon2Memory  = 64 * 1024 // KiB
+	argon2Time    = 3
+	argon2Threads = 4
+	argon2SaltLen = 16
+	argon2KeyLen  = 32
+)
+
+// maxArgon2Memory bounds the memory a stored hash may ask for, so a
+// tampered password file cannot exhaust the server's memory on login
+const maxArgon2Memory = 1024 * 1024 // KiB
+
+// errInvalidPasswordHash is returned when storing something that is not a
+// password hash
+var errInvalidPasswordHash = errors.New("password hash must be an Argon2id hash ($argon2id$v=19$...) or a bcrypt hash ($2a$, $2b$ or $2y$)")
+
+// argon2Hash is a parsed Argon2id hash in the PHC string format:
+// $argon2id$v=19$m=65536,t=3,p=4$<salt>$<key>, with the salt and key in
+// unpadded base64
+type argon2Hash struct {
+	memory  uint32
+	time    uint32
+	threads uint8
+	salt    []byte
+	key     []byte
+}
+
+// String encodes the hash in the PHC string format
+func (h argon2Hash) String() string {
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s", argon2.Version, h.memory, h.time, h.threads,
+		base64.RawStdEncoding.EncodeToString(h.salt), base64.RawStdEncoding.EncodeToString(h.key))
+}
+
+// parseArgon2Hash parses an Argon2id hash in the PHC string format
+func parseArgon2Hash(s string) (argon2Hash, error) {
+	var h argon2Hash
+	parts := strings.Split(s, "$")
+	if len(parts) != 6 || parts[0] != "" || parts[1] != "argon2id" {
+		return h, errors.New("not an argon2id hash")
+	}
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return h, fmt.Errorf("unsupported argon2 version %q", parts[2])
+	}
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &h.memory, &h.time, &h.threads); err != nil {
+		return h, fmt.Errorf("invalid argon2 parameters %q", parts[3])
+	}
+	if h.time == 0 || h.threads == 0 || h.memory < 8*uint32(h.threads) || h.memory > maxArgon2Memory {
+		return h, fmt.Errorf("argon2 parameters %q out of range", parts[3])
+	}
+	var err error
+	if h.salt, err = base64.RawStdEncoding.DecodeString(parts[4]); err != nil || len(h.salt) < 8 {
+		return h, errors.New("invalid argon2 salt")
+	}
+	if h.key, err = base64.RawStdEncoding.DecodeString(parts[5]); err != nil || len(h.key) < 16 {
+		return h, errors.New("invalid argon2 key")
+	}
+	return h, nil
+}
+
+// isBcryptHash checks if a string is a bcrypt hash
+// bcrypt hashes start with $2a$, $2b$, or $2y$
+func isBcryptHash(s string) bool {
+	return strings.HasPrefix(s, "$2a$") ||
+		strings.HasPrefix(s, "$2b$") ||
+		strings.HasPrefix(s, "$2y$")
+}
+
+// isPasswordHash checks if a string is a hash ValidatePassword can verify
+func isPasswordHash(s string) bool {
+	if isBcryptHash(s) {
+		return true
+	}
+	_, err := parseArgon2Hash(s)
+	return err == nil
+}
+
+// HashPassword generates an Argon2id hash with a random salt from a plain
+// text password
+func HashPassword(password string) (string, error) {
+	salt := make([]byte, argon2SaltLen)
+	if _, err := rand.Read(salt); err != nil {
+		return "", fmt.Errorf("failed to hash password: %w", err)
+	}
+	return argon2Hash{
+		memory:  argon2Memory,
+		time:    argon2Time,
+		threads: argon2Threads,
+		salt:    salt,
+		key:     argon2.IDKey([]byte(password), salt, argon2Time, argon2Memory, argon2Threads, argon2KeyLen),
+	}.String(), nil
+}
+
+// ValidatePassword checks if the provided password matches an Argon2id or
+// bcrypt hash, in constant time
+func ValidatePassword(password, hash string) bool {
+	if isBcryptHash(hash) {
+		return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil
+	}
+	h, err := parseArgon2Hash(hash)
+	if err != nil {
+		return false
+	}
+	key := argon2.IDKey([]byte(password), h.salt, h.time, h.memory, h.threads, uint32(len(h.key)))
+	return subtle.ConstantTimeCompare(key, h.key) == 1
+}
+
+// NeedsRehash reports whether a hash should be replaced by HashPassword's:
+// bcrypt hashes, and Argon2id hashes made with other parameters
+func NeedsRehash(hash string) bool {
+	if isBcryptHash(hash) {
+		return true
+	}
+	h, err := parseArgon2Hash(hash)
+	if err != nil {
+		return false
+	}
+	return h.memory != argon2Memory || h.time != argon2Time || h.threads != argon2Threads ||
+		len(h.salt) != argon2SaltLen || len(h.key) != argon2KeyLen
+}
@@ -0,0 +1,107 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// defaultPasswordMinLength is the shortest password accepted when
+// TERMINAL_HUB_PASSWORD_MIN_LENGTH is not set
+const defaultPasswordMinLength = 8
+
+// passwordClasses is the number of character classes PasswordPolicy counts:
+// lowercase and uppercase letters, digits and everything else
+const passwordClasses = 4
+
+// ErrWeakPassword is returned for passwords that do not meet the policy
+var ErrWeakPassword = errors.New("password does not meet the password policy")
+
+// PasswordPolicy is what new passwords must meet, and how long they last.
+// It is checked when users are created and passwords are changed.
+type PasswordPolicy struct {
+	MinLength  int           `json:"min_length"`  // characters
+	MinClasses int           `json:"min_classes"` // of lowercase, uppercase, digits and symbols, 0-4
+	MaxAge     time.Duration `json:"-"`           // after which the password must be changed; 0 for never
+}
+
+// DefaultPasswordPolicy returns the policy used when none is configured
+func DefaultPasswordPolicy() PasswordPolicy {
+	return PasswordPolicy{MinLength: defaultPasswordMinLength}
+}
+
+// GetPasswordPolicyFromEnv reads the policy from
+// TERMINAL_HUB_PASSWORD_MIN_LENGTH, TERMINAL_HUB_PASSWORD_MIN_CLASSES and
+// TERMINAL_HUB_PASSWORD_MAX_AGE (a duration such as "2160h"), starting from
+// DefaultPasswordPolicy
+func GetPasswordPolicyFromEnv() (PasswordPolicy, error) {
+	policy := DefaultPasswordPolicy()
+	if value := os.Getenv("TERMINAL_HUB_PASSWORD_MIN_LENGTH"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 {
+			return policy, fmt.Errorf("TERMINAL_HUB_PASSWORD_MIN_LENGTH must be a positive number, got %q", value)
+		}
+		policy.MinLength = n
+	}
+	if value := os.Getenv("TERMINAL_HUB_PASSWORD_MIN_CLASSES"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 || n > passwordClasses {
+			return policy, fmt.Errorf("TERMINAL_HUB_PASSWORD_MIN_CLASSES must be between 0 and %d, got %q", passwordClasses, value)
+		}
+		policy.MinClasses = n
+	}
+	if value := os.Getenv("TERMINAL_HUB_PASSWORD_MAX_AGE"); value != "" {
+		d, err := time.ParseDuration(value)
+		if err != nil || d < 0 {
+			return policy, fmt.Errorf("TERMINAL_HUB_PASSWORD_MAX_AGE must be a duration such as 2160h, got %q", value)
+		}
+		policy.MaxAge = d
+	}
+	return policy, nil
+}
+
+// Validate checks a new password against the policy. Errors wrap
+// ErrWeakPassword and say what is missing.
+func (p PasswordPolicy) Validate(password string) error {
+	if utf8.RuneCountInString(password) < p.MinLength {
+		return fmt.Errorf("%w: it must be at least %d characters", ErrWeakPassword, p.MinLength)
+	}
+	if classes := passwordClassCount(password); classes < p.MinClasses {
+		return fmt.Errorf("%w: it must mix at least %d of lowercase letters, uppercase letters, digits and symbols", ErrWeakPassword, p.MinClasses)
+	}
+	return nil
+}
+
+// Expired reports whether a password last changed at changedAt is older
+// than MaxAge. An unknown change time never expires.
+func (p PasswordPolicy) Expired(changedAt time.Time) bool {
+	return p.MaxAge > 0 && !changedAt.IsZero() && time.Since(changedAt) > p.MaxAge
+}
+
+// passwordClassCount counts the character classes in password
+func passwordClassCount(password string) int {
+	var lower, upper, digit, other bool
+	for _, r := range password {
+		switch {
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsDigit(r):
+			digit = true
+		default:
+			other = true
+		}
+	}
+	count := 0
+	for _, present := range []bool{lower, upper, digit, other} {
+		if present {
+			count++
+		}
+	}
+	return count
+}
@@ -4,32 +4,95 @@ import (
 	"crypto/rand"
 	"crypto/subtle"
 	"encoding/hex"
+	"errors"
+	"log"
+	"sort"
 	"sync"
 	"time"
 )
 
 // Session represents an authenticated user session
 type Session struct {
-	ID           string
+	ID           string // cookie token, never shown to users
+	PublicID     string // identifies the session in the sessions API
 	Username     string
 	CreatedAt    time.Time
 	LastActivity time.Time
+	Client       ClientInfo
+	Persistence  Persistence
+}
+
+// Persistence is how long a login lasts, chosen when logging in
+type Persistence int
+
+const (
+	// PersistDefault keeps the cookie for the manager's TTL
+	PersistDefault Persistence = iota
+	// PersistRemember keeps the session and its cookie for the longer
+	// remember-me TTL, see SetRememberTTL
+	PersistRemember
+	// PersistBrowserSession makes the cookie last until the browser closes
+	PersistBrowserSession
+)
+
+// ClientInfo describes the browser a session is used from
+type ClientInfo struct {
+	UserAgent string
+	IP        string // address of the latest request
+	DeviceID  string // device cookie, see DeviceCookieName
+}
+
+// SessionInfo describes an active session for the sessions API
+type SessionInfo struct {
+	ID           string `json:"id"` // public ID
+	Username     string `json:"username"`
+	UserAgent    string `json:"user_agent"`
+	IP           string `json:"ip"`
+	DeviceID     string `json:"device_id,omitempty"`
+	CreatedAt    int64  `json:"created_at"`    // unix timestamp
+	LastActivity int64  `json:"last_activity"` // unix timestamp
+	Current      bool   `json:"current"`       // the session making the request
 }
 
 // SessionManager manages authenticated sessions
 type SessionManager struct {
-	sessions        map[string]*Session
-	mu              sync.RWMutex
-	ttl             time.Duration
-	username        string
-	passwordHash    string
-	usingPlaintext  bool // true if password is stored as plaintext (from env vars)
+	sessions       map[string]*Session
+	mu             sync.RWMutex
+	ttl            time.Duration
+	rememberTTL    time.Duration        // of PersistRemember sessions; the TTL when zero
+	passwords      map[string]string    // by username: password hashes, or plaintext when usingPlaintext; guarded by mu
+	usingPlaintext bool                 // true if password is stored as plaintext (from env vars)
+	passwordFile   string               // where changed and re-hashed passwords are saved, see SetPasswordFile
+	policy         PasswordPolicy       // new passwords must meet it; see SetPasswordPolicy
+	changedAt      map[string]time.Time // by username: when the password was last changed; guarded by mu
+	mustChange     map[string]bool      // by username: flagged in the file or weaker than the policy; guarded by mu
 }
 
+// ErrPasswordChangeUnsupported is returned by ChangePassword when the
+// credentials do not come from a password file
+var ErrPasswordChangeUnsupported = errors.New("passwords can only be changed when they are stored in a password file")
+
+// ErrWrongPassword is returned by ChangePassword when the current password
+// does not match
+var ErrWrongPassword = errors.New("current password is incorrect")
+
 // LoginRequest/Response types for JSON API
 type LoginRequest struct {
 	Username string `json:"username"`
 	Password string `json:"password"`
+	Remember *bool  `json:"remember,omitempty"` // true: remember-me login; false: cookie lasts until the browser closes; unset: the TTL
+}
+
+// Persistence returns the persistence the request asks for
+func (req LoginRequest) Persistence() Persistence {
+	switch {
+	case req.Remember == nil:
+		return PersistDefault
+	case *req.Remember:
+		return PersistRemember
+	default:
+		return PersistBrowserSession
+	}
 }
 
 type LoginResponse struct {
@@ -43,23 +106,38 @@ func NewSessionManager(username, password string, ttl time.Duration) *SessionMan
 	sm := &SessionManager{
 		sessions:       make(map[string]*Session),
 		ttl:            ttl,
-		username:       username,
-		passwordHash:   password, // Store as-is (plaintext for env var case)
-		usingPlaintext: true,     // Mark as plaintext for timing-safe comparison
+		passwords:      map[string]string{},
+		usingPlaintext: true, // Mark as plaintext for timing-safe comparison
+		policy:         DefaultPasswordPolicy(),
+		changedAt:      map[string]time.Time{},
+		mustChange:     map[string]bool{},
+	}
+	if username != "" && password != "" {
+		sm.passwords[username] = password // Store as-is (plaintext for env var case)
 	}
 	go sm.cleanupExpired()
 	return sm
 }
 
-// NewSessionManagerFromHash creates a new session manager with a pre-hashed password
-// Use this when loading credentials from a password file with bcrypt hashes
-func NewSessionManagerFromHash(username, passwordHash string, ttl time.Duration) *SessionManager {
+// NewSessionManagerFromUsers creates a new session manager for users with
+// pre-hashed passwords
+// Use this when loading credentials from a password file
+func NewSessionManagerFromUsers(users []UserCredential, ttl time.Duration) *SessionManager {
 	sm := &SessionManager{
 		sessions:       make(map[string]*Session),
 		ttl:            ttl,
-		username:       username,
-		passwordHash:   passwordHash,
-		usingPlaintext: false, // bcrypt hash, use bcrypt comparison
+		passwords:      make(map[string]string, len(users)),
+		usingPlaintext: false, // hashed, see ValidatePassword
+		policy:         DefaultPasswordPolicy(),
+		changedAt:      make(map[string]time.Time, len(users)),
+		mustChange:     map[string]bool{},
+	}
+	for _, user := range users {
+		sm.passwords[user.Username] = user.PasswordHash
+		sm.changedAt[user.Username] = user.PasswordChangedAt()
+		if user.MustChangePassword {
+			sm.mustChange[user.Username] = true
+		}
 	}
 	go sm.cleanupExpired()
 	return sm
@@ -67,17 +145,29 @@ func NewSessionManagerFromHash(username, passwordHash string, ttl time.Duration)
 
 // CreateSession creates a new session for a user
 func (sm *SessionManager) CreateSession(username string) (*Session, error) {
-	tokenBytes := make([]byte, 32)
-	if _, err := rand.Read(tokenBytes); err != nil {
+	return sm.CreateClientSession(username, ClientInfo{}, PersistDefault)
+}
+
+// CreateClientSession creates a new session for a user logging in from the
+// given browser
+func (sm *SessionManager) CreateClientSession(username string, client ClientInfo, persistence Persistence) (*Session, error) {
+	token, err := randomHex(32)
+	if err != nil {
+		return nil, err
+	}
+	publicID, err := randomHex(8)
+	if err != nil {
 		return nil, err
 	}
-	token := hex.EncodeToString(tokenBytes)
 
 	session := &Session{
 		ID:           token,
+		PublicID:     publicID,
 		Username:     username,
 		CreatedAt:    time.Now(),
 		LastActivity: time.Now(),
+		Client:       client,
+		Persistence:  persistence,
 	}
 
 	sm.mu.Lock()
@@ -87,13 +177,44 @@ func (sm *SessionManager) CreateSession(username string) (*Session, error) {
 	return session, nil
 }
 
+// SetRememberTTL sets how long PersistRemember sessions last without
+// activity; zero makes them last the TTL
+func (sm *SessionManager) SetRememberTTL(ttl time.Duration) {
+	sm.mu.Lock()
+	sm.rememberTTL = ttl
+	sm.mu.Unlock()
+}
+
+// SessionTTL returns how long session lasts without activity, which its
+// cookie should last too
+func (sm *SessionManager) SessionTTL(session *Session) time.Duration {
+	sm.mu.RLock()
+	defer sm.mu.RUnlock()
+	return sm.sessionTTL(session)
+}
+
+// sessionTTL is SessionTTL with sm.mu held
+func (sm *SessionManager) sessionTTL(session *Session) time.Duration {
+	if session.Persistence == PersistRemember && sm.rememberTTL > sm.ttl {
+		return sm.rememberTTL
+	}
+	return sm.ttl
+}
+
+// expired reports whether session has been idle for longer than its TTL,
+// with sm.mu held
+func (sm *SessionManager) expired(session *Session) bool {
+	return time.Since(session.LastActivity) > sm.sessionTTL(session)
+}
+
 // ValidateSession checks if a session token is valid
 func (sm *SessionManager) ValidateSession(token string) (*Session, bool) {
 	sm.mu.RLock()
 	session, exists := sm.sessions[token]
+	valid := exists && !sm.expired(session)
 	sm.mu.RUnlock()
 
-	if !exists || time.Since(session.LastActivity) > sm.ttl {
+	if !valid {
 		return nil, false
 	}
 
@@ -112,31 +233,272 @@ func (sm *SessionManager) DeleteSession(token string) {
 	sm.mu.Unlock()
 }
 
-// ValidateCredentials checks username/password using timing-safe comparison
-func (sm *SessionManager) ValidateCredentials(username, password string) bool {
-	// Early exit if not configured
-	if sm.username == "" || sm.passwordHash == "" {
-		return false
+// NoteClientIP records the address a session was last used from
+func (sm *SessionManager) NoteClientIP(token, ip string) {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	if session, ok := sm.sessions[token]; ok {
+		session.Client.IP = ip
+	}
+}
+
+// ListSessions returns the active sessions of a user, most recently used
+// first. currentToken marks the session making the request.
+func (sm *SessionManager) ListSessions(username, currentToken string) []SessionInfo {
+	sm.mu.RLock()
+	defer sm.mu.RUnlock()
+
+	infos := []SessionInfo{}
+	for token, session := range sm.sessions {
+		if session.Username != username || sm.expired(session) {
+			continue
+		}
+		infos = append(infos, SessionInfo{
+			ID:           session.PublicID,
+			Username:     session.Username,
+			UserAgent:    session.Client.UserAgent,
+			IP:           session.Client.IP,
+			DeviceID:     session.Client.DeviceID,
+			CreatedAt:    session.CreatedAt.Unix(),
+			LastActivity: session.LastActivity.Unix(),
+			Current:      token == currentToken,
+		})
+	}
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].LastActivity > infos[j].LastActivity
+	})
+	return infos
+}
+
+// RevokeSession removes the user's session with the given public ID,
+// reporting whether it existed
+func (sm *SessionManager) RevokeSession(username, publicID string) bool {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+
+	for token, session := range sm.sessions {
+		if session.PublicID == publicID && session.Username == username {
+			delete(sm.sessions, token)
+			return true
+		}
+	}
+	return false
+}
+
+// RevokeOtherSessions removes the user's sessions other than the one with
+// token keepToken, returning how many were removed
+func (sm *SessionManager) RevokeOtherSessions(username, keepToken string) int {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+
+	revoked := 0
+	for token, session := range sm.sessions {
+		if session.Username == username && token != keepToken {
+			delete(sm.sessions, token)
+			revoked++
+		}
 	}
+	return revoked
+}
+
+// Sessions returns copies of all sessions, so they can be handed to a new
+// process during an upgrade
+func (sm *SessionManager) Sessions() []Session {
+	sm.mu.RLock()
+	defer sm.mu.RUnlock()
+
+	sessions := make([]Session, 0, len(sm.sessions))
+	for _, session := range sm.sessions {
+		sessions = append(sessions, *session)
+	}
+	return sessions
+}
+
+// RestoreSessions adds sessions handed over by a previous process, dropping
+// those that have expired since
+func (sm *SessionManager) RestoreSessions(sessions []Session) {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
 
-	// Timing-safe username comparison
-	if subtle.ConstantTimeCompare([]byte(username), []byte(sm.username)) != 1 {
+	for _, session := range sessions {
+		if session.ID == "" || sm.expired(&session) {
+			continue
+		}
+		restored := session
+		sm.sessions[session.ID] = &restored
+	}
+}
+
+// SetPasswordFile sets the password file the users were loaded from, so
+// that ChangePassword and the re-hashing of legacy hashes on login persist
+func (sm *SessionManager) SetPasswordFile(path string) {
+	sm.mu.Lock()
+	sm.passwordFile = path
+	sm.mu.Unlock()
+}
+
+// SetPasswordPolicy sets the policy ChangePassword checks new passwords
+// against. Users whose password turns out weaker than it when they log in,
+// or older than its MaxAge, must change it.
+func (sm *SessionManager) SetPasswordPolicy(policy PasswordPolicy) {
+	sm.mu.Lock()
+	sm.policy = policy
+	sm.mu.Unlock()
+}
+
+// PasswordPolicy returns the policy set with SetPasswordPolicy
+func (sm *SessionManager) PasswordPolicy() PasswordPolicy {
+	sm.mu.RLock()
+	defer sm.mu.RUnlock()
+	return sm.policy
+}
+
+// MustChangePassword reports whether the user has to change their password
+// before doing anything else: the password file flags it, it was found to
+// be weaker than the policy at login, or it is older than the policy's
+// MaxAge. Passwords from environment variables cannot be changed, so never
+// have to be.
+func (sm *SessionManager) MustChangePassword(username string) bool {
+	sm.mu.RLock()
+	defer sm.mu.RUnlock()
+	if sm.usingPlaintext {
 		return false
 	}
+	return sm.mustChange[username] || sm.policy.Expired(sm.changedAt[username])
+}
+
+// ValidateCredentials checks username/password using timing-safe comparison.
+// A matching password whose hash is of a legacy format or parameters is
+// re-hashed, see NeedsRehash, and one that does not meet the password
+// policy has to be changed, see MustChangePassword.
+func (sm *SessionManager) ValidateCredentials(username, password string) bool {
+	stored, ok := sm.checkPassword(username, password)
+	if !ok || sm.usingPlaintext {
+		return ok
+	}
+	if err := sm.PasswordPolicy().Validate(password); err != nil {
+		sm.mu.Lock()
+		sm.mustChange[username] = true
+		sm.mu.Unlock()
+	}
+	if NeedsRehash(stored) {
+		if err := sm.storePassword(username, password, false); err != nil {
+			log.Printf("Warning: failed to re-hash the password of %s: %v", username, err)
+		} else {
+			log.Printf("Re-hashed the password of %s with Argon2id", username)
+		}
+	}
+	return true
+}
+
+// checkPassword reports whether password is the user's, returning the
+// stored hash or plaintext
+func (sm *SessionManager) checkPassword(username, password string) (string, bool) {
+	sm.mu.RLock()
+	stored, ok := sm.passwords[username]
+	configured := len(sm.passwords) > 0
+	sm.mu.RUnlock()
+
+	// Early exit if not configured
+	if !configured {
+		return "", false
+	}
 
 	// Password comparison depends on storage format
 	if sm.usingPlaintext {
 		// Plaintext (from env vars): use timing-safe comparison
-		return subtle.ConstantTimeCompare([]byte(password), []byte(sm.passwordHash)) == 1
+		return stored, subtle.ConstantTimeCompare([]byte(password), []byte(stored)) == 1 && ok
+	}
+
+	// Hashes compare in constant time. Unknown users are compared against a
+	// dummy hash, so the time taken does not tell which usernames exist.
+	if !ok {
+		ValidatePassword(password, unknownUserHash())
+		return "", false
+	}
+	return stored, ValidatePassword(password, stored)
+}
+
+// storePassword hashes password and makes it the user's, saving it to the
+// password file if one is set. changed tells a new password from a new hash
+// of the same one, which keeps its age and whether it must be changed.
+func (sm *SessionManager) storePassword(username, password string, changed bool) error {
+	hash, err := HashPassword(password)
+	if err != nil {
+		return err
+	}
+	sm.mu.RLock()
+	passwordFile := sm.passwordFile
+	sm.mu.RUnlock()
+	if passwordFile != "" {
+		if changed {
+			err = SetUserPassword(passwordFile, username, hash, false)
+		} else {
+			err = rehashUserPassword(passwordFile, username, hash)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	sm.mu.Lock()
+	sm.passwords[username] = hash
+	if changed {
+		sm.changedAt[username] = time.Now()
+		delete(sm.mustChange, username)
 	}
+	sm.mu.Unlock()
+	return nil
+}
 
-	// bcrypt hash: use bcrypt's built-in constant-time comparison
-	return ValidatePassword(password, sm.passwordHash)
+// ChangePassword replaces the user's password after checking the current
+// one and the new one against the password policy, saving the new hash to
+// the password file
+func (sm *SessionManager) ChangePassword(username, currentPassword, newPassword string) error {
+	sm.mu.RLock()
+	supported := !sm.usingPlaintext && sm.passwordFile != ""
+	policy := sm.policy
+	sm.mu.RUnlock()
+	if !supported {
+		return ErrPasswordChangeUnsupported
+	}
+	if _, ok := sm.checkPassword(username, currentPassword); !ok {
+		return ErrWrongPassword
+	}
+	if err := policy.Validate(newPassword); err != nil {
+		return err
+	}
+	return sm.storePassword(username, newPassword, true)
+}
+
+// unknownUserHash is compared against for users that do not exist
+var unknownUserHash = sync.OnceValue(func() string {
+	hash, _ := HashPassword(randomPassword())
+	return hash
+})
+
+// randomPassword returns a password no one knows
+func randomPassword() string {
+	password, err := randomHex(16)
+	if err != nil {
+		return "unknown-user"
+	}
+	return password
 }
 
 // IsConfigured returns true if auth is enabled
 func (sm *SessionManager) IsConfigured() bool {
-	return sm.username != "" && sm.passwordHash != ""
+	sm.mu.RLock()
+	defer sm.mu.RUnlock()
+	return len(sm.passwords) > 0
+}
+
+// randomHex returns n random bytes, hex encoded
+func randomHex(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
 }
 
 // cleanupExpired removes stale sessions periodically
@@ -145,7 +507,7 @@ func (sm *SessionManager) cleanupExpired() {
 	for range ticker.C {
 		sm.mu.Lock()
 		for token, session := range sm.sessions {
-			if time.Since(session.LastActivity) > sm.ttl {
+			if sm.expired(session) {
 				delete(sm.sessions, token)
 			}
 		}
@@ -0,0 +1,275 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// unixSocketPrefix marks a hub URL as a unix domain socket path, as in the
+// server's -listen flag
+const unixSocketPrefix = "unix:"
+
+// sessionCookieName is the cookie the hub keeps logins in, unless the hub
+// sets TERMINAL_HUB_COOKIE_NAME
+const sessionCookieName = "session_token"
+
+// userAgent identifies thctl in the hub's list of login sessions
+const userAgent = "thctl"
+
+// deviceCookieName is the cookie the hub identifies a device by
+const deviceCookieName = "device_id"
+
+// client talks to a running hub's REST API and WebSockets
+type client struct {
+	address string   // as given, which names the hub in the device file
+	baseURL *url.URL // http(s) URL including any base path, without a trailing slash
+	http    *http.Client
+	dialer  *websocket.Dialer
+	token   string // session cookie value, empty when the hub has no authentication
+	cookie  string // session cookie name, sessionCookieName unless the hub renamed it
+
+	stateFile string // where logins are kept between runs, see hubState
+}
+
+// cookieName returns the name of the hub's session cookie
+func (c *client) cookieName() string {
+	if c.cookie == "" {
+		return sessionCookieName
+	}
+	return c.cookie
+}
+
+// apiError is a non-2xx response from the hub, whose body is a JSON error
+// envelope or, from older hubs, a plain-text message
+type apiError struct {
+	StatusCode int
+	Code       string // machine-readable, such as "session_not_found"; empty for plain-text errors
+	Message    string
+}
+
+// parseAPIError reads the error in a non-2xx response body
+func parseAPIError(statusCode int, body []byte) *apiError {
+	var envelope struct {
+		Error struct {
+			Code    string `json:"code"`
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(body, &envelope); err == nil && envelope.Error.Code != "" {
+		return &apiError{StatusCode: statusCode, Code: envelope.Error.Code, Message: envelope.Error.Message}
+	}
+	return &apiError{StatusCode: statusCode, Message: strings.TrimSpace(string(body))}
+}
+
+func (e *apiError) Error() string {
+	if e.Message == "" {
+		return http.StatusText(e.StatusCode)
+	}
+	return e.Message
+}
+
+// newClient returns a client for the hub at address: an http(s) URL, with
+// the hub's base path if it has one, or unix:/path/to.sock
+func newClient(address string) (*client, error) {
+	c := &client{
+		address:   address,
+		http:      &http.Client{},
+		dialer:    &websocket.Dialer{HandshakeTimeout: 10 * time.Second},
+		stateFile: defaultStateFile(),
+	}
+
+	if path, ok := strings.CutPrefix(address, unixSocketPrefix); ok {
+		if path == "" {
+			return nil, errors.New("unix socket path is required")
+		}
+		dial := func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, "unix", path)
+		}
+		c.http.Transport = &http.Transport{DialContext: dial}
+		c.dialer.NetDialContext = dial
+		address = "http://terminal-hub"
+	}
+
+	baseURL, err := url.Parse(strings.TrimRight(address, "/"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid hub URL: %w", err)
+	}
+	if baseURL.Scheme != "http" && baseURL.Scheme != "https" {
+		return nil, fmt.Errorf("hub URL %q must start with http://, https:// or unix:", address)
+	}
+	c.baseURL = baseURL
+	return c, nil
+}
+
+// authStatus is the hub's reply to GET /api/auth/status
+type authStatus struct {
+	Authenticated bool   `json:"authenticated"`
+	Username      string `json:"username"`
+}
+
+// ensureLogin reuses the login saved for the hub while it is valid for
+// username, otherwise logs in and saves the new login
+func (c *client) ensureLogin(username, password string) error {
+	state := loadHubState(c.stateFile, c.address)
+	if state.SessionToken != "" && state.Username == username {
+		c.token, c.cookie = state.SessionToken, state.SessionCookie
+		var status authStatus
+		if err := c.do(http.MethodGet, "/api/auth/status", nil, &status); err == nil && status.Authenticated {
+			return nil
+		}
+		c.token = ""
+	}
+
+	body, err := json.Marshal(map[string]string{"username": username, "password": password})
+	if err != nil {
+		return err
+	}
+	header := http.Header{}
+	if state.DeviceID != "" {
+		header.Set("Cookie", (&http.Cookie{Name: deviceCookieName, Value: state.DeviceID}).String())
+	}
+	resp, err := c.send(http.MethodPost, "/api/auth/login", "application/json", bytes.NewReader(body), header)
+	if err != nil {
+		return fmt.Errorf("login failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	// Besides the device cookie, login sets only the session cookie, whatever
+	// the hub named it
+	for _, cookie := range resp.Cookies() {
+		if cookie.Name == deviceCookieName {
+			state.DeviceID = cookie.Value
+		} else if cookie.Value != "" {
+			c.token, c.cookie = cookie.Value, cookie.Name
+		}
+	}
+	if c.token == "" {
+		return errors.New("login failed: no session cookie in the response")
+	}
+
+	state.SessionToken, state.SessionCookie, state.Username = c.token, c.cookie, username
+	if err := saveHubState(c.stateFile, c.address, state); err != nil {
+		fmt.Fprintf(os.Stderr, "thctl: failed to save login: %v\n", err)
+	}
+	return nil
+}
+
+// logout ends the saved login session and forgets it
+func (c *client) logout() error {
+	state := loadHubState(c.stateFile, c.address)
+	if state.SessionToken == "" {
+		return nil
+	}
+	c.token, c.cookie = state.SessionToken, state.SessionCookie
+	err := c.do(http.MethodPost, "/api/auth/logout", nil, nil)
+	c.token = ""
+
+	state.SessionToken, state.SessionCookie, state.Username = "", "", ""
+	if saveErr := saveHubState(c.stateFile, c.address, state); saveErr != nil {
+		return saveErr
+	}
+	return err
+}
+
+// url returns the hub URL of an API path, which may include a query
+func (c *client) url(path string) string {
+	return c.baseURL.String() + path
+}
+
+// send makes a request and returns the response when its status is 2xx,
+// otherwise an *apiError with the hub's message
+func (c *client) send(method, path, contentType string, body io.Reader, header http.Header) (*http.Response, error) {
+	req, err := http.NewRequest(method, c.url(path), body)
+	if err != nil {
+		return nil, err
+	}
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	// A Content-Length header sizes bodies http.NewRequest cannot, such as files
+	if length, err := strconv.ParseInt(req.Header.Get("Content-Length"), 10, 64); err == nil {
+		req.ContentLength = length
+		req.Header.Del("Content-Length")
+	}
+	req.Header.Set("User-Agent", userAgent)
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	if c.token != "" {
+		req.AddCookie(&http.Cookie{Name: c.cookieName(), Value: c.token})
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return nil, parseAPIError(resp.StatusCode, body)
+	}
+	return resp, nil
+}
+
+// do sends in as JSON, when not nil, and decodes the response into out, when
+// not nil
+func (c *client) do(method, path string, in, out any) error {
+	var body io.Reader
+	contentType := ""
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body, contentType = bytes.NewReader(data), "application/json"
+	}
+
+	resp, err := c.send(method, path, contentType, body, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("invalid response from %s %s: %w", method, path, err)
+	}
+	return nil
+}
+
+// dialWebSocket connects to a hub WebSocket path such as /ws/:sessionId
+func (c *client) dialWebSocket(path string) (*websocket.Conn, error) {
+	wsURL := *c.baseURL
+	wsURL.Scheme = "ws"
+	if c.baseURL.Scheme == "https" {
+		wsURL.Scheme = "wss"
+	}
+	target := wsURL.String() + path
+
+	header := http.Header{"User-Agent": {userAgent}}
+	if c.token != "" {
+		header.Set("Cookie", (&http.Cookie{Name: c.cookieName(), Value: c.token}).String())
+	}
+	conn, resp, err := c.dialer.Dial(target, header)
+	if err != nil {
+		if resp != nil && resp.StatusCode != http.StatusSwitchingProtocols {
+			return nil, &apiError{StatusCode: resp.StatusCode}
+		}
+		return nil, err
+	}
+	return conn, nil
+}
@@ -0,0 +1,203 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/iwanhae/terminal-hub/cron"
+)
+
+func (c *cli) listCrons(args []string) error {
+	if err := parseArgs(flag.NewFlagSet("crons", flag.ContinueOnError), args, 0, 0); err != nil {
+		return err
+	}
+	var resp cron.ListCronsResponse
+	if err := c.client.do(http.MethodGet, "/api/crons", nil, &resp); err != nil {
+		return err
+	}
+	if c.json {
+		return c.printJSON(resp)
+	}
+
+	if resp.Suspended {
+		fmt.Fprintln(c.out, "Scheduling is paused.")
+	}
+	tw := tabwriter.NewWriter(c.out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tNAME\tSCHEDULE\tENABLED\tLAST RUN\tSTATUS\tNEXT RUN")
+	for _, job := range resp.Jobs {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%t\t%s\t%s\t%s\n", job.ID, job.Name, job.Schedule, job.Enabled,
+			formatUnix(job.Metadata.LastRunAt), orDash(job.Metadata.LastRunStatus), formatUnix(job.Metadata.NextRunAt))
+	}
+	return tw.Flush()
+}
+
+func (c *cli) createCron(args []string) error {
+	flags := flag.NewFlagSet("cron-create", flag.ContinueOnError)
+	name := flags.String("name", "", "")
+	schedule := flags.String("schedule", "", "")
+	command := flags.String("command", "", "")
+	workingDir := flags.String("cwd", "", "")
+	disabled := flags.Bool("disabled", false, "")
+	if err := parseArgs(flags, args, 0, 0); err != nil {
+		return err
+	}
+	if *name == "" || *schedule == "" || *command == "" {
+		return usageError{}
+	}
+
+	req := cron.CreateCronRequest{
+		Name:             *name,
+		Schedule:         *schedule,
+		Command:          *command,
+		WorkingDirectory: *workingDir,
+		Enabled:          !*disabled,
+	}
+	var resp cron.CreateCronResponse
+	if err := c.client.do(http.MethodPost, "/api/crons", req, &resp); err != nil {
+		return err
+	}
+	if c.json {
+		return c.printJSON(resp)
+	}
+	fmt.Fprintln(c.out, resp.ID)
+	return nil
+}
+
+// runCron runs a job and prints its output, failing when the command does
+func (c *cli) runCron(args []string) error {
+	id, err := c.cronArg("cron-run", args)
+	if err != nil {
+		return err
+	}
+	var result cron.CronExecutionResult
+	if err := c.client.do(http.MethodPost, cronPath(id, "run"), nil, &result); err != nil {
+		return err
+	}
+	if c.json {
+		return c.printJSON(result)
+	}
+
+	fmt.Fprint(c.out, result.Output)
+	if result.Output != "" && !strings.HasSuffix(result.Output, "\n") {
+		fmt.Fprintln(c.out)
+	}
+	if result.Error != "" {
+		return fmt.Errorf("%s (exit code %d)", result.Error, result.ExitCode)
+	}
+	if result.ExitCode != 0 {
+		return fmt.Errorf("exit code %d", result.ExitCode)
+	}
+	return nil
+}
+
+func (c *cli) enableCron(args []string) error {
+	id, err := c.cronArg("cron-enable", args)
+	if err != nil {
+		return err
+	}
+	return c.client.do(http.MethodPost, cronPath(id, "enable"), nil, nil)
+}
+
+func (c *cli) disableCron(args []string) error {
+	id, err := c.cronArg("cron-disable", args)
+	if err != nil {
+		return err
+	}
+	return c.client.do(http.MethodPost, cronPath(id, "disable"), nil, nil)
+}
+
+func (c *cli) deleteCron(args []string) error {
+	id, err := c.cronArg("cron-delete", args)
+	if err != nil {
+		return err
+	}
+	return c.client.do(http.MethodDelete, cronPath(id, ""), nil, nil)
+}
+
+func (c *cli) cronHistory(args []string) error {
+	id, err := c.cronArg("cron-history", args)
+	if err != nil {
+		return err
+	}
+	var resp cron.GetHistoryResponse
+	if err := c.client.do(http.MethodGet, cronPath(id, "history"), nil, &resp); err != nil {
+		return err
+	}
+	if c.json {
+		return c.printJSON(resp)
+	}
+
+	tw := tabwriter.NewWriter(c.out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "EXECUTION\tSTARTED\tDURATION\tEXIT CODE\tERROR")
+	for _, run := range resp.Executions {
+		duration := "-"
+		if run.FinishedAt >= run.StartedAt && run.FinishedAt > 0 {
+			duration = (time.Duration(run.FinishedAt-run.StartedAt) * time.Second).String()
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%d\t%s\n", run.ExecutionID, formatUnix(run.StartedAt), duration, run.ExitCode, orDash(run.Error))
+	}
+	return tw.Flush()
+}
+
+// cronArg parses the JOB argument of a cron command, given by ID or name
+func (c *cli) cronArg(name string, args []string) (string, error) {
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	if err := parseArgs(flags, args, 1, 1); err != nil {
+		return "", err
+	}
+	return c.resolveCron(flags.Arg(0))
+}
+
+// resolveCron returns the ID of the cron job with the given ID or name
+func (c *cli) resolveCron(ref string) (string, error) {
+	var resp cron.ListCronsResponse
+	if err := c.client.do(http.MethodGet, "/api/crons", nil, &resp); err != nil {
+		return "", err
+	}
+	var matches []string
+	for _, job := range resp.Jobs {
+		if job.ID == ref {
+			return job.ID, nil
+		}
+		if job.Name == ref {
+			matches = append(matches, job.ID)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return "", fmt.Errorf("no cron job %q", ref)
+	case 1:
+		return matches[0], nil
+	default:
+		return "", fmt.Errorf("%d cron jobs are named %q, use an ID: %s", len(matches), ref, strings.Join(matches, ", "))
+	}
+}
+
+// cronPath returns the API path of a job, or of one of its actions
+func cronPath(id, action string) string {
+	path := "/api/crons/" + url.PathEscape(id)
+	if action != "" {
+		path += "/" + action
+	}
+	return path
+}
+
+// formatUnix formats a unix timestamp for tables, or "-" when it is unset
+func formatUnix(seconds int64) string {
+	if seconds == 0 {
+		return "-"
+	}
+	return formatTime(time.Unix(seconds, 0))
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+)
+
+// Upload headers read by the hub's /api/upload handler
+const (
+	uploadPathHeader      = "X-Terminal-Hub-Upload-Path"
+	uploadFilenameHeader  = "X-Terminal-Hub-Upload-Filename"
+	uploadOverwriteHeader = "X-Terminal-Hub-Upload-Overwrite"
+)
+
+// uploadResponse is the hub's reply to an upload
+type uploadResponse struct {
+	Path        string `json:"path"`
+	Filename    string `json:"filename"`
+	Size        int64  `json:"size"`
+	Overwritten bool   `json:"overwritten"`
+}
+
+// upload sends a local file into a directory on the hub's host, which is
+// created when missing
+func (c *cli) upload(args []string) error {
+	flags := flag.NewFlagSet("upload", flag.ContinueOnError)
+	overwrite := flags.Bool("overwrite", false, "")
+	if err := parseArgs(flags, args, 2, 2); err != nil {
+		return err
+	}
+	localPath, remoteDir := flags.Arg(0), flags.Arg(1)
+
+	file, err := os.Open(localPath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+	if info.IsDir() {
+		return fmt.Errorf("%s is a directory", localPath)
+	}
+
+	header := http.Header{}
+	header.Set(uploadPathHeader, remoteDir)
+	header.Set(uploadFilenameHeader, filepath.Base(localPath))
+	header.Set(uploadOverwriteHeader, strconv.FormatBool(*overwrite))
+	header.Set("Content-Length", strconv.FormatInt(info.Size(), 10))
+	resp, err := c.client.send(http.MethodPost, "/api/upload", "application/octet-stream", file, header)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	var result uploadResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return fmt.Errorf("invalid upload response: %w", err)
+	}
+	if c.json {
+		return c.printJSON(result)
+	}
+	fmt.Fprintln(c.out, result.Path)
+	return nil
+}
+
+// download saves a file from the hub's host to localPath, by default its
+// name in the current directory, or writes it to stdout when localPath is -
+func (c *cli) download(args []string) error {
+	flags := flag.NewFlagSet("download", flag.ContinueOnError)
+	if err := parseArgs(flags, args, 1, 2); err != nil {
+		return err
+	}
+	remotePath := flags.Arg(0)
+	localPath := flags.Arg(1)
+	if localPath == "" {
+		localPath = path.Base(remotePath)
+	}
+
+	resp, err := c.client.send(http.MethodGet, "/api/download?path="+url.QueryEscape(remotePath), "", nil, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if localPath == "-" {
+		_, err := io.Copy(c.out, resp.Body)
+		return err
+	}
+	if info, err := os.Stat(localPath); err == nil && info.IsDir() {
+		localPath = filepath.Join(localPath, path.Base(remotePath))
+	}
+
+	file, err := os.OpenFile(localPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(file, resp.Body); err != nil {
+		file.Close()
+		os.Remove(localPath)
+		return err
+	}
+	return file.Close()
+}
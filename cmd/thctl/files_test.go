@@ -0,0 +1,66 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestUploadAndDownload(t *testing.T) {
+	var uploaded struct {
+		dir, name, overwrite, body string
+		length                     int64
+	}
+	hub := newFakeHub(t, map[string]http.HandlerFunc{
+		"/api/upload": func(w http.ResponseWriter, r *http.Request) {
+			body, _ := io.ReadAll(r.Body)
+			uploaded.dir = r.Header.Get(uploadPathHeader)
+			uploaded.name = r.Header.Get(uploadFilenameHeader)
+			uploaded.overwrite = r.Header.Get(uploadOverwriteHeader)
+			uploaded.body, uploaded.length = string(body), r.ContentLength
+			json.NewEncoder(w).Encode(uploadResponse{Path: filepath.Join(uploaded.dir, uploaded.name), Size: int64(len(body))})
+		},
+		"/api/download": func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Query().Get("path") != "/srv/logs/app.log" {
+				http.Error(w, "File not found", http.StatusNotFound)
+				return
+			}
+			io.WriteString(w, "log line\n")
+		},
+	})
+	setupEnv(t, hub.URL)
+
+	local := filepath.Join(t.TempDir(), "notes.txt")
+	os.WriteFile(local, []byte("hello"), 0o644)
+	code, stdout, stderr := runThctl("upload", "-overwrite", local, "/srv/in")
+	if code != 0 {
+		t.Fatalf("upload: exit %d: %s", code, stderr)
+	}
+	if strings.TrimSpace(stdout) != "/srv/in/notes.txt" {
+		t.Errorf("expected the remote path, got %q", stdout)
+	}
+	if uploaded.dir != "/srv/in" || uploaded.name != "notes.txt" || uploaded.overwrite != "true" ||
+		uploaded.body != "hello" || uploaded.length != 5 {
+		t.Errorf("unexpected upload: %+v", uploaded)
+	}
+
+	// A directory receives the file under its remote name
+	dir := t.TempDir()
+	if code, _, stderr := runThctl("download", "/srv/logs/app.log", dir); code != 0 {
+		t.Fatalf("download: exit %d: %s", code, stderr)
+	}
+	if data, _ := os.ReadFile(filepath.Join(dir, "app.log")); string(data) != "log line\n" {
+		t.Errorf("expected the downloaded file, got %q", data)
+	}
+
+	if code, stdout, _ := runThctl("download", "/srv/logs/app.log", "-"); code != 0 || stdout != "log line\n" {
+		t.Errorf("expected the file on stdout, got exit %d: %q", code, stdout)
+	}
+	if code, _, stderr := runThctl("download", "/srv/missing"); code != 1 || !strings.Contains(stderr, "File not found") {
+		t.Errorf("expected the hub's error, got exit %d: %s", code, stderr)
+	}
+}
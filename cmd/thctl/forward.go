@@ -0,0 +1,161 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/websocket"
+)
+
+// forwardBufferSize is the most local data sent in one message
+const forwardBufferSize = 32 * 1024
+
+// forward listens on a local port and tunnels each connection through the
+// hub to a target, like ssh -L. It runs until interrupted.
+func (c *cli) forward(args []string) error {
+	flags := flag.NewFlagSet("forward", flag.ContinueOnError)
+	if err := parseArgs(flags, args, 1, 1); err != nil {
+		return err
+	}
+	listenAddr, host, port, err := parseForwardSpec(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	listener, err := net.Listen("tcp", listenAddr)
+	if err != nil {
+		return err
+	}
+	defer listener.Close()
+	target := net.JoinHostPort(host, strconv.Itoa(port))
+	fmt.Fprintf(c.out, "Forwarding %s to %s through the hub\n", listener.Addr(), target)
+
+	for {
+		local, err := listener.Accept()
+		if err != nil {
+			return err
+		}
+		go func() {
+			defer local.Close()
+			if err := c.forwardConn(local, host, port); err != nil {
+				fmt.Fprintf(os.Stderr, "thctl forward: %s: %v\n", target, err)
+			}
+		}()
+	}
+}
+
+// parseForwardSpec parses [BIND_ADDRESS:]LOCAL_PORT:HOST:PORT, with IPv6
+// addresses in brackets. The local port is bound on loopback unless an
+// address is given.
+func parseForwardSpec(spec string) (listenAddr, host string, port int, err error) {
+	invalid := fmt.Errorf("invalid forward %q: use [BIND_ADDRESS:]LOCAL_PORT:HOST:PORT", spec)
+
+	rest, portText, ok := cutLast(spec)
+	if !ok {
+		return "", "", 0, invalid
+	}
+	port, err = strconv.Atoi(portText)
+	if err != nil || port < 1 || port > 65535 {
+		return "", "", 0, invalid
+	}
+	if strings.HasSuffix(rest, "]") {
+		start := strings.LastIndex(rest, "[")
+		if start < 1 || rest[start-1] != ':' {
+			return "", "", 0, invalid
+		}
+		host, rest = rest[start+1:len(rest)-1], rest[:start-1]
+	} else if rest, host, ok = cutLast(rest); !ok {
+		return "", "", 0, invalid
+	}
+
+	bind, localPort, ok := cutLast(rest)
+	if !ok {
+		bind, localPort = "127.0.0.1", rest
+	}
+	bind = strings.TrimSuffix(strings.TrimPrefix(bind, "["), "]")
+	if n, err := strconv.Atoi(localPort); err != nil || n < 0 || n > 65535 || host == "" {
+		return "", "", 0, invalid
+	}
+	return net.JoinHostPort(bind, localPort), host, port, nil
+}
+
+// cutLast splits s around its last colon
+func cutLast(s string) (before, after string, found bool) {
+	i := strings.LastIndexByte(s, ':')
+	if i < 0 {
+		return s, "", false
+	}
+	return s[:i], s[i+1:], true
+}
+
+// forwardConn opens a tunnel to host and port and copies local through it
+func (c *cli) forwardConn(local net.Conn, host string, port int) error {
+	conn, err := c.client.dialWebSocket("/ws/tunnel?host=" + url.QueryEscape(host) + "&port=" + strconv.Itoa(port))
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	return pumpTunnel(conn, local)
+}
+
+// pumpTunnel copies between local and the tunnel until either side closes.
+// Data travels as binary messages.
+func pumpTunnel(conn *websocket.Conn, local io.ReadWriteCloser) error {
+	remoteDone := make(chan error, 1)
+	go func() {
+		for {
+			messageType, reader, err := conn.NextReader()
+			if err != nil {
+				remoteDone <- err
+				return
+			}
+			if messageType != websocket.BinaryMessage {
+				continue
+			}
+			if _, err := io.Copy(local, reader); err != nil {
+				remoteDone <- err
+				return
+			}
+		}
+	}()
+
+	localDone := make(chan error, 1)
+	go func() {
+		buf := make([]byte, forwardBufferSize)
+		for {
+			n, err := local.Read(buf)
+			if n > 0 {
+				if err := conn.WriteMessage(websocket.BinaryMessage, buf[:n]); err != nil {
+					localDone <- err
+					return
+				}
+			}
+			if err != nil {
+				localDone <- err
+				return
+			}
+		}
+	}()
+
+	var err error
+	select {
+	case err = <-remoteDone:
+		_ = local.Close()
+		if websocket.IsCloseError(err, websocket.CloseNormalClosure) {
+			err = nil
+		}
+	case err = <-localDone:
+		_ = conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+		if errors.Is(err, io.EOF) || errors.Is(err, net.ErrClosed) {
+			err = nil
+		}
+	}
+	return err
+}
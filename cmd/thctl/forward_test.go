@@ -0,0 +1,89 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+func TestParseForwardSpec(t *testing.T) {
+	cases := []struct {
+		spec, listen, host string
+		port               int
+	}{
+		{"8080:db:5432", "127.0.0.1:8080", "db", 5432},
+		{"0.0.0.0:8080:10.0.0.5:80", "0.0.0.0:8080", "10.0.0.5", 80},
+		{"[::1]:8080:[fd00::1]:22", "[::1]:8080", "fd00::1", 22},
+		{"0:localhost:3000", "127.0.0.1:0", "localhost", 3000},
+	}
+	for _, c := range cases {
+		listen, host, port, err := parseForwardSpec(c.spec)
+		if err != nil || listen != c.listen || host != c.host || port != c.port {
+			t.Errorf("%s: got %q %q %d, %v", c.spec, listen, host, port, err)
+		}
+	}
+	for _, spec := range []string{"db:5432", "8080:db:0", "x:db:5432", "8080::5432", "[::1]:5432"} {
+		if _, _, _, err := parseForwardSpec(spec); err == nil {
+			t.Errorf("%s: expected an error", spec)
+		}
+	}
+}
+
+func TestForwardConn(t *testing.T) {
+	upgrader := websocket.Upgrader{}
+	targets := make(chan string, 1)
+	hub := newFakeHub(t, map[string]http.HandlerFunc{
+		"/ws/tunnel": func(w http.ResponseWriter, r *http.Request) {
+			targets <- r.URL.Query().Get("host") + ":" + r.URL.Query().Get("port")
+			conn, err := upgrader.Upgrade(w, r, nil)
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+			// Echo one message in upper case, then end the tunnel
+			_, data, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			conn.WriteMessage(websocket.BinaryMessage, bytes.ToUpper(data))
+			conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+		},
+	})
+	setupEnv(t, hub.URL)
+	c, err := newClient(hub.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.ensureLogin("admin", "secret"); err != nil {
+		t.Fatal(err)
+	}
+	cmd := &cli{client: c, out: io.Discard}
+
+	local, remote := net.Pipe()
+	result := make(chan error, 1)
+	go func() { result <- cmd.forwardConn(remote, "db", 5432) }()
+
+	if _, err := local.Write([]byte("ping")); err != nil {
+		t.Fatal(err)
+	}
+	buf := make([]byte, 4)
+	if _, err := io.ReadFull(local, buf); err != nil || string(buf) != "PING" {
+		t.Errorf("expected the echo through the tunnel, got %q, %v", buf, err)
+	}
+	if got := <-targets; got != "db:5432" {
+		t.Errorf("expected the tunnel to db:5432, got %s", got)
+	}
+	select {
+	case err := <-result:
+		if err != nil {
+			t.Errorf("expected the hub closing the tunnel to end it cleanly, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected the tunnel to end when the hub closes it")
+	}
+}
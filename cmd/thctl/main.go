@@ -0,0 +1,168 @@
+// Command thctl talks to a running terminal-hub: it lists, creates and
+// attaches to sessions, manages cron jobs, transfers files and forwards
+// ports.
+//
+// The hub is given by -url or TERMINAL_HUB_URL. When the hub requires a
+// login, TERMINAL_HUB_USERNAME and TERMINAL_HUB_PASSWORD are used, as for
+// the server itself. The login is saved in the user's config directory and
+// reused until it expires or "thctl logout" ends it.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+// defaultHubURL is the address of a hub started with the default -addr
+const defaultHubURL = "http://localhost:8081"
+
+// cli runs commands against a hub, printing results to out
+type cli struct {
+	client *client
+	out    io.Writer
+	json   bool // print JSON responses instead of tables
+}
+
+// command is a thctl subcommand
+type command struct {
+	usage   string // arguments, after the command name
+	summary string
+	run     func(c *cli, args []string) error
+}
+
+var commands = map[string]command{
+	"sessions":     {"", "List sessions", (*cli).listSessions},
+	"create":       {"[-cwd DIR] [-command CMD] [-tag TAG]... NAME", "Create a session", (*cli).createSession},
+	"attach":       {"SESSION", "Attach the terminal to a session, by ID or name (Ctrl-] detaches)", (*cli).attachSession},
+	"kill":         {"SESSION", "Close a session", (*cli).killSession},
+	"crons":        {"", "List cron jobs", (*cli).listCrons},
+	"cron-create":  {"-name NAME -schedule SPEC -command CMD [-cwd DIR] [-disabled]", "Create a cron job", (*cli).createCron},
+	"cron-run":     {"JOB", "Run a cron job now and print its output", (*cli).runCron},
+	"cron-enable":  {"JOB", "Enable a cron job", (*cli).enableCron},
+	"cron-disable": {"JOB", "Disable a cron job", (*cli).disableCron},
+	"cron-delete":  {"JOB", "Delete a cron job", (*cli).deleteCron},
+	"cron-history": {"JOB", "Show a cron job's recent runs", (*cli).cronHistory},
+	"upload":       {"[-overwrite] LOCAL_FILE REMOTE_DIR", "Upload a file", (*cli).upload},
+	"download":     {"REMOTE_FILE [LOCAL_PATH]", "Download a file (- writes to stdout)", (*cli).download},
+	"forward":      {"[BIND_ADDRESS:]LOCAL_PORT:HOST:PORT", "Forward a local port to HOST:PORT through the hub, like ssh -L", (*cli).forward},
+	"logout":       {"", "End the saved login to the hub", (*cli).logout},
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+// run executes a command line and returns the exit status
+func run(args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("thctl", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	hubURL := flags.String("url", envOrDefault("TERMINAL_HUB_URL", defaultHubURL), "hub URL, including any base path, or unix:/path/to.sock")
+	jsonOutput := flags.Bool("json", false, "print JSON responses instead of tables")
+	flags.Usage = func() { printUsage(flags, stderr) }
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() == 0 {
+		printUsage(flags, stderr)
+		return 2
+	}
+
+	name := flags.Arg(0)
+	cmd, ok := commands[name]
+	if !ok {
+		fmt.Fprintf(stderr, "thctl: unknown command %q\n", name)
+		printUsage(flags, stderr)
+		return 2
+	}
+
+	hub, err := newClient(*hubURL)
+	if err != nil {
+		fmt.Fprintf(stderr, "thctl: %v\n", err)
+		return 1
+	}
+	if username := os.Getenv("TERMINAL_HUB_USERNAME"); username != "" && name != "logout" {
+		if err := hub.ensureLogin(username, os.Getenv("TERMINAL_HUB_PASSWORD")); err != nil {
+			fmt.Fprintf(stderr, "thctl: %v\n", err)
+			return 1
+		}
+	}
+
+	c := &cli{client: hub, out: stdout, json: *jsonOutput}
+	if err := cmd.run(c, flags.Args()[1:]); err != nil {
+		var usageErr usageError
+		if errors.As(err, &usageErr) {
+			fmt.Fprintf(stderr, "usage: thctl %s %s\n", name, cmd.usage)
+			return 2
+		}
+		fmt.Fprintf(stderr, "thctl %s: %v\n", name, err)
+		return 1
+	}
+	return 0
+}
+
+func (c *cli) logout(args []string) error {
+	if err := parseArgs(flag.NewFlagSet("logout", flag.ContinueOnError), args, 0, 0); err != nil {
+		return err
+	}
+	return c.client.logout()
+}
+
+// usageError reports a command called with the wrong arguments
+type usageError struct{}
+
+func (usageError) Error() string { return "invalid arguments" }
+
+// parseArgs parses a command's flags and checks the number of positional
+// arguments is between min and max
+func parseArgs(flags *flag.FlagSet, args []string, min, max int) error {
+	flags.SetOutput(io.Discard)
+	if err := flags.Parse(args); err != nil {
+		return usageError{}
+	}
+	if n := flags.NArg(); n < min || n > max {
+		return usageError{}
+	}
+	return nil
+}
+
+func printUsage(flags *flag.FlagSet, w io.Writer) {
+	fmt.Fprintln(w, "usage: thctl [-url URL] [-json] COMMAND [ARGS]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Commands:")
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(w, "  %-13s %s\n", name, commands[name].summary)
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Flags:")
+	flags.SetOutput(w)
+	flags.PrintDefaults()
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "TERMINAL_HUB_USERNAME and TERMINAL_HUB_PASSWORD log in to hubs with authentication.")
+}
+
+func envOrDefault(name, fallback string) string {
+	if value := strings.TrimSpace(os.Getenv(name)); value != "" {
+		return value
+	}
+	return fallback
+}
+
+// stringList is a flag that may be repeated
+type stringList []string
+
+func (l *stringList) String() string { return strings.Join(*l, ",") }
+
+func (l *stringList) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/iwanhae/terminal-hub/terminal"
+)
+
+// fakeHub serves the login endpoints of a hub with authentication, and the
+// extra routes given
+type fakeHub struct {
+	*httptest.Server
+	logins atomic.Int32
+	token  string
+	cookie string // session cookie name
+}
+
+func newFakeHub(t *testing.T, routes map[string]http.HandlerFunc) *fakeHub {
+	t.Helper()
+	hub := &fakeHub{token: "token-1", cookie: sessionCookieName}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/auth/login", func(w http.ResponseWriter, r *http.Request) {
+		var req struct{ Username, Password string }
+		json.NewDecoder(r.Body).Decode(&req)
+		if req.Username != "admin" || req.Password != "secret" {
+			http.Error(w, "Invalid credentials", http.StatusUnauthorized)
+			return
+		}
+		hub.logins.Add(1)
+		http.SetCookie(w, &http.Cookie{Name: hub.cookie, Value: hub.token})
+		if _, err := r.Cookie(deviceCookieName); err != nil {
+			http.SetCookie(w, &http.Cookie{Name: deviceCookieName, Value: "device-1"})
+		}
+	})
+	mux.HandleFunc("/api/auth/status", func(w http.ResponseWriter, r *http.Request) {
+		cookie, err := r.Cookie(hub.cookie)
+		authenticated := err == nil && cookie.Value == hub.token
+		json.NewEncoder(w).Encode(authStatus{Authenticated: authenticated, Username: "admin"})
+	})
+	for pattern, handler := range routes {
+		mux.HandleFunc(pattern, func(w http.ResponseWriter, r *http.Request) {
+			if cookie, err := r.Cookie(hub.cookie); err != nil || cookie.Value != hub.token {
+				http.Error(w, "Unauthorized", http.StatusUnauthorized)
+				return
+			}
+			handler(w, r)
+		})
+	}
+	hub.Server = httptest.NewServer(mux)
+	t.Cleanup(hub.Close)
+	return hub
+}
+
+// setupEnv points thctl at hub with a fresh config directory
+func setupEnv(t *testing.T, hubURL string) {
+	t.Helper()
+	dir := t.TempDir()
+	t.Setenv("HOME", dir)
+	t.Setenv("XDG_CONFIG_HOME", dir)
+	t.Setenv("TERMINAL_HUB_URL", hubURL)
+	t.Setenv("TERMINAL_HUB_USERNAME", "admin")
+	t.Setenv("TERMINAL_HUB_PASSWORD", "secret")
+}
+
+func runThctl(args ...string) (int, string, string) {
+	var stdout, stderr bytes.Buffer
+	code := run(args, &stdout, &stderr)
+	return code, stdout.String(), stderr.String()
+}
+
+func listSessionsRoute(sessions ...terminal.SessionInfo) map[string]http.HandlerFunc {
+	return map[string]http.HandlerFunc{
+		"/api/sessions": func(w http.ResponseWriter, r *http.Request) {
+			json.NewEncoder(w).Encode(sessions)
+		},
+	}
+}
+
+func TestRunReusesSavedLogin(t *testing.T) {
+	hub := newFakeHub(t, listSessionsRoute(terminal.SessionInfo{ID: "s1", Metadata: terminal.SessionMetadata{Name: "default"}}))
+	setupEnv(t, hub.URL)
+
+	for i := 0; i < 3; i++ {
+		code, stdout, stderr := runThctl("sessions")
+		if code != 0 {
+			t.Fatalf("sessions: exit %d: %s", code, stderr)
+		}
+		if !strings.Contains(stdout, "s1") || !strings.Contains(stdout, "default") {
+			t.Fatalf("expected the session in the table, got:\n%s", stdout)
+		}
+	}
+	if logins := hub.logins.Load(); logins != 1 {
+		t.Errorf("expected one login for three runs, got %d", logins)
+	}
+
+	// An expired login is replaced, keeping the device ID
+	hub.token = "token-2"
+	if code, _, stderr := runThctl("sessions"); code != 0 {
+		t.Fatalf("sessions after expiry: exit %d: %s", code, stderr)
+	}
+	state := loadHubState(defaultStateFile(), hub.URL)
+	if hub.logins.Load() != 2 || state.SessionToken != "token-2" || state.DeviceID != "device-1" {
+		t.Errorf("expected a new login to be saved with the device ID, got %d logins and %+v", hub.logins.Load(), state)
+	}
+}
+
+func TestRunUsesRenamedSessionCookie(t *testing.T) {
+	hub := newFakeHub(t, listSessionsRoute(terminal.SessionInfo{ID: "s1"}))
+	hub.cookie = "__Secure-hub"
+	setupEnv(t, hub.URL)
+
+	for i := 0; i < 2; i++ {
+		if code, _, stderr := runThctl("sessions"); code != 0 {
+			t.Fatalf("sessions: exit %d: %s", code, stderr)
+		}
+	}
+	state := loadHubState(defaultStateFile(), hub.URL)
+	if hub.logins.Load() != 1 || state.SessionCookie != "__Secure-hub" {
+		t.Errorf("expected the renamed cookie to be saved and reused, got %d logins and %+v", hub.logins.Load(), state)
+	}
+}
+
+func TestRunReportsErrors(t *testing.T) {
+	hub := newFakeHub(t, listSessionsRoute())
+	setupEnv(t, hub.URL)
+
+	if code, _, _ := runThctl("nope"); code != 2 {
+		t.Errorf("expected exit 2 for an unknown command, got %d", code)
+	}
+	if code, _, stderr := runThctl("attach"); code != 2 || !strings.Contains(stderr, "usage: thctl attach SESSION") {
+		t.Errorf("expected the command's usage, got exit %d: %s", code, stderr)
+	}
+	if code, _, stderr := runThctl("kill", "missing"); code != 1 || !strings.Contains(stderr, `no session "missing"`) {
+		t.Errorf("expected a missing session to be reported, got exit %d: %s", code, stderr)
+	}
+
+	t.Setenv("TERMINAL_HUB_PASSWORD", "wrong")
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	if code, _, stderr := runThctl("sessions"); code != 1 || !strings.Contains(stderr, "Invalid credentials") {
+		t.Errorf("expected the hub's login error, got exit %d: %s", code, stderr)
+	}
+}
+
+func TestParseAPIError(t *testing.T) {
+	err := parseAPIError(http.StatusNotFound, []byte(`{"error":{"code":"session_not_found","message":"Session not found"}}`))
+	if err.Code != "session_not_found" || err.Error() != "Session not found" {
+		t.Errorf("expected the envelope's code and message, got %+v", err)
+	}
+	// Older hubs and non-API routes reply in plain text
+	err = parseAPIError(http.StatusForbidden, []byte("Forbidden\n"))
+	if err.Code != "" || err.Error() != "Forbidden" {
+		t.Errorf("expected the plain-text message, got %+v", err)
+	}
+}
+
+func TestNewClient(t *testing.T) {
+	c, err := newClient("https://example.com/hub/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := c.url("/api/sessions"); got != "https://example.com/hub/api/sessions" {
+		t.Errorf("expected the base path to be kept, got %s", got)
+	}
+
+	if _, err := newClient("unix:/run/terminal-hub.sock"); err != nil {
+		t.Errorf("expected a unix socket address to be accepted: %v", err)
+	}
+	if _, err := newClient("ftp://example.com"); err == nil {
+		t.Error("expected an unsupported scheme to be rejected")
+	}
+}
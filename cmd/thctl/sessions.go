@@ -0,0 +1,287 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/iwanhae/terminal-hub/terminal"
+)
+
+// detachKey ends an attach without closing the session, as in telnet
+const detachKey = 0x1d // Ctrl-]
+
+// clientName is how attached thctl clients appear to other clients
+const clientName = "thctl"
+
+// errConnectionClosed reports that the hub ended an attach, such as when the
+// session's shell exits
+var errConnectionClosed = errors.New("connection closed by the hub")
+
+// termSize is a terminal size in character cells
+type termSize struct {
+	Cols int
+	Rows int
+}
+
+func (c *cli) listSessions(args []string) error {
+	if err := parseArgs(flag.NewFlagSet("sessions", flag.ContinueOnError), args, 0, 0); err != nil {
+		return err
+	}
+	var sessions []terminal.SessionInfo
+	if err := c.client.do(http.MethodGet, "/api/sessions", nil, &sessions); err != nil {
+		return err
+	}
+	if c.json {
+		return c.printJSON(sessions)
+	}
+
+	tw := tabwriter.NewWriter(c.out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tNAME\tBACKEND\tCLIENTS\tLAST ACTIVITY\tTAGS")
+	for _, s := range sessions {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%d\t%s\t%s\n", s.ID, s.Metadata.Name, s.Metadata.Backend,
+			s.Metadata.ClientCount, formatTime(s.Metadata.LastActivityAt), strings.Join(s.Metadata.Tags, ","))
+	}
+	return tw.Flush()
+}
+
+func (c *cli) createSession(args []string) error {
+	flags := flag.NewFlagSet("create", flag.ContinueOnError)
+	workingDir := flags.String("cwd", "", "")
+	initialCommand := flags.String("command", "", "")
+	var tags stringList
+	flags.Var(&tags, "tag", "")
+	if err := parseArgs(flags, args, 1, 1); err != nil {
+		return err
+	}
+
+	req := terminal.CreateSessionRequest{
+		Name:             flags.Arg(0),
+		WorkingDirectory: *workingDir,
+		Command:          *initialCommand,
+		Tags:             tags,
+	}
+	var resp terminal.CreateSessionResponse
+	if err := c.client.do(http.MethodPost, "/api/sessions", req, &resp); err != nil {
+		return err
+	}
+	if c.json {
+		return c.printJSON(resp)
+	}
+	fmt.Fprintln(c.out, resp.ID)
+	return nil
+}
+
+func (c *cli) killSession(args []string) error {
+	flags := flag.NewFlagSet("kill", flag.ContinueOnError)
+	if err := parseArgs(flags, args, 1, 1); err != nil {
+		return err
+	}
+	id, err := c.resolveSession(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	return c.client.do(http.MethodDelete, "/api/sessions/"+url.PathEscape(id), nil, nil)
+}
+
+// resolveSession returns the ID of the session with the given ID or name
+func (c *cli) resolveSession(ref string) (string, error) {
+	var sessions []terminal.SessionInfo
+	if err := c.client.do(http.MethodGet, "/api/sessions", nil, &sessions); err != nil {
+		return "", err
+	}
+	var matches []string
+	for _, s := range sessions {
+		if s.ID == ref {
+			return s.ID, nil
+		}
+		if s.Metadata.Name == ref {
+			matches = append(matches, s.ID)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return "", fmt.Errorf("no session %q", ref)
+	case 1:
+		return matches[0], nil
+	default:
+		return "", fmt.Errorf("%d sessions are named %q, use an ID: %s", len(matches), ref, strings.Join(matches, ", "))
+	}
+}
+
+func (c *cli) attachSession(args []string) error {
+	flags := flag.NewFlagSet("attach", flag.ContinueOnError)
+	if err := parseArgs(flags, args, 1, 1); err != nil {
+		return err
+	}
+	id, err := c.resolveSession(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	conn, err := c.client.dialWebSocket("/ws/" + url.PathEscape(id) + "?name=" + clientName)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	restore, err := makeRaw(os.Stdin)
+	if err != nil {
+		return err
+	}
+	sizes, stopSizes := watchTerminalSize(os.Stdin)
+	err = pumpTerminal(conn, os.Stdin, os.Stdout, sizes)
+	stopSizes()
+	restore()
+
+	switch {
+	case err == nil:
+		fmt.Fprintln(os.Stderr, "[detached]")
+	case errors.Is(err, errConnectionClosed):
+		fmt.Fprintln(os.Stderr, "[connection closed]")
+		return nil
+	}
+	return err
+}
+
+// motdOutput returns the message of the day in a text message as lines for
+// a raw terminal, or nothing for other messages
+func motdOutput(data []byte) []byte {
+	var msg terminal.ServerMessage
+	if err := json.Unmarshal(data, &msg); err != nil || msg.Type != "motd" || msg.Data == "" {
+		return nil
+	}
+	text := strings.ReplaceAll(strings.TrimRight(msg.Data, "\n"), "\n", "\r\n")
+	return []byte("\r\n" + text + "\r\n")
+}
+
+// pumpTerminal copies in to the session as input and the session's output
+// to out, sending each size received on sizes as a resize. It returns nil
+// when the user detaches or in ends, and errConnectionClosed when the hub
+// ends the connection normally.
+func pumpTerminal(conn *websocket.Conn, in io.Reader, out io.Writer, sizes <-chan termSize) error {
+	closed := make(chan error, 1)
+	go func() {
+		for {
+			messageType, data, err := conn.ReadMessage()
+			if err != nil {
+				closed <- err
+				return
+			}
+			// Text messages carry presence, clipboard and control updates,
+			// which a plain terminal has no use for, and the message of the
+			// day, which is printed
+			if messageType == websocket.TextMessage {
+				data = motdOutput(data)
+			}
+			if _, err := out.Write(data); err != nil {
+				closed <- err
+				return
+			}
+		}
+	}()
+
+	input := make(chan []byte)
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		defer close(input)
+		buf := make([]byte, 4096)
+		for {
+			n, err := in.Read(buf)
+			if n > 0 {
+				select {
+				case input <- bytes.Clone(buf[:n]):
+				case <-done:
+					return
+				}
+			}
+			if err != nil {
+				return
+			}
+		}
+	}()
+
+	send := func(msg terminal.ClientMessage) error {
+		data, err := json.Marshal(msg)
+		if err != nil {
+			return err
+		}
+		return conn.WriteMessage(websocket.TextMessage, data)
+	}
+	detach := func() error {
+		return conn.WriteControl(websocket.CloseMessage,
+			websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""), time.Now().Add(time.Second))
+	}
+
+	for {
+		select {
+		case err := <-closed:
+			return connectionEndError(err)
+		case data, ok := <-input:
+			if !ok {
+				return detach()
+			}
+			i := bytes.IndexByte(data, detachKey)
+			if i >= 0 {
+				data = data[:i]
+			}
+			if len(data) > 0 {
+				if err := send(terminal.ClientMessage{Type: "input", Data: string(data)}); err != nil {
+					return err
+				}
+			}
+			if i >= 0 {
+				return detach()
+			}
+		case size := <-sizes:
+			if err := send(terminal.ClientMessage{Type: "resize", Cols: size.Cols, Rows: size.Rows}); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// connectionEndError returns errConnectionClosed when the hub ended the
+// connection normally, otherwise why it ended
+func connectionEndError(err error) error {
+	var closeErr *websocket.CloseError
+	if errors.As(err, &closeErr) {
+		switch closeErr.Code {
+		case websocket.CloseNormalClosure, websocket.CloseGoingAway, websocket.CloseAbnormalClosure:
+			return errConnectionClosed
+		}
+		if closeErr.Text != "" {
+			return errors.New(closeErr.Text)
+		}
+	}
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return errConnectionClosed
+	}
+	return err
+}
+
+// printJSON writes v as indented JSON
+func (c *cli) printJSON(v any) error {
+	encoder := json.NewEncoder(c.out)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
+
+// formatTime formats t for tables, or "-" when it is unset
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return t.Local().Format("2006-01-02 15:04:05")
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/iwanhae/terminal-hub/terminal"
+)
+
+// syncBuffer is a bytes.Buffer safe for the output goroutine to write to
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestPumpTerminal(t *testing.T) {
+	received := make(chan terminal.ClientMessage, 10)
+	upgrader := websocket.Upgrader{}
+	hub := newFakeHub(t, map[string]http.HandlerFunc{
+		"/ws/": func(w http.ResponseWriter, r *http.Request) {
+			conn, err := upgrader.Upgrade(w, r, nil)
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+			conn.WriteMessage(websocket.BinaryMessage, []byte("$ "))
+			conn.WriteMessage(websocket.TextMessage, []byte(`{"type":"presence"}`))
+			conn.WriteMessage(websocket.TextMessage, []byte(`{"type":"motd","data":"Authorized use only\nBe nice\n"}`))
+			for {
+				_, data, err := conn.ReadMessage()
+				if err != nil {
+					close(received)
+					return
+				}
+				var msg terminal.ClientMessage
+				json.Unmarshal(data, &msg)
+				received <- msg
+			}
+		},
+	})
+	setupEnv(t, hub.URL)
+	c, err := newClient(hub.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.ensureLogin("admin", "secret"); err != nil {
+		t.Fatal(err)
+	}
+	conn, err := c.dialWebSocket("/ws/s1")
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer conn.Close()
+
+	inReader, inWriter := io.Pipe()
+	var out syncBuffer
+	sizes := make(chan termSize, 1)
+	sizes <- termSize{Cols: 120, Rows: 40}
+	result := make(chan error, 1)
+	go func() { result <- pumpTerminal(conn, inReader, &out, sizes) }()
+
+	first := <-received
+	if first.Type != "resize" || first.Cols != 120 || first.Rows != 40 {
+		t.Errorf("expected the terminal size first, got %+v", first)
+	}
+	inWriter.Write([]byte("ls\r"))
+	if msg := <-received; msg.Type != "input" || msg.Data != "ls\r" {
+		t.Errorf("expected the typed input, got %+v", msg)
+	}
+
+	// Input before the detach key is sent, the rest is not
+	inWriter.Write([]byte("q\x1dx"))
+	if msg := <-received; msg.Type != "input" || msg.Data != "q" {
+		t.Errorf("expected the input before the detach key, got %+v", msg)
+	}
+	select {
+	case err := <-result:
+		if err != nil {
+			t.Errorf("expected a clean detach, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected the detach key to end the attach")
+	}
+	if _, ok := <-received; ok {
+		t.Error("expected nothing after the detach key")
+	}
+	if got := out.String(); got != "$ \r\nAuthorized use only\r\nBe nice\r\n" {
+		t.Errorf("expected only terminal output and the message of the day to be written, got %q", got)
+	}
+}
+
+func TestResolveSession(t *testing.T) {
+	hub := newFakeHub(t, listSessionsRoute(
+		terminal.SessionInfo{ID: "s1", Metadata: terminal.SessionMetadata{Name: "api"}},
+		terminal.SessionInfo{ID: "s2", Metadata: terminal.SessionMetadata{Name: "build"}},
+		terminal.SessionInfo{ID: "s3", Metadata: terminal.SessionMetadata{Name: "build"}},
+	))
+	setupEnv(t, hub.URL)
+	c, _ := newClient(hub.URL)
+	if err := c.ensureLogin("admin", "secret"); err != nil {
+		t.Fatal(err)
+	}
+	cmd := &cli{client: c, out: io.Discard}
+
+	for ref, want := range map[string]string{"api": "s1", "s3": "s3"} {
+		if id, err := cmd.resolveSession(ref); err != nil || id != want {
+			t.Errorf("%s: expected %s, got %q, %v", ref, want, id, err)
+		}
+	}
+	if _, err := cmd.resolveSession("build"); err == nil || !strings.Contains(err.Error(), "s2, s3") {
+		t.Errorf("expected an ambiguous name to list the IDs, got %v", err)
+	}
+}
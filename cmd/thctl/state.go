@@ -0,0 +1,80 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// stateFileName is the file in the user's config directory where thctl keeps
+// its logins, by hub address
+const stateFileName = "thctl/hubs.json"
+
+// hubState is what thctl remembers about a hub between runs. The hub limits
+// login attempts, so the login session is reused until it expires. Logins
+// without the device cookie are reported to the admin as coming from a new
+// device, so the device ID the hub assigned is kept too.
+type hubState struct {
+	SessionToken  string `json:"session_token,omitempty"`
+	SessionCookie string `json:"session_cookie,omitempty"` // name of the cookie holding SessionToken, when not the default
+	Username      string `json:"username,omitempty"`
+	DeviceID      string `json:"device_id,omitempty"`
+}
+
+// defaultStateFile returns the path of the state file, or "" when the user
+// has no config directory
+func defaultStateFile() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, stateFileName)
+}
+
+// loadHubState returns what is saved for hub, which is empty when nothing is
+func loadHubState(path, hub string) hubState {
+	states, _ := readStateFile(path)
+	return states[hub]
+}
+
+// saveHubState replaces what is saved for hub. The file holds login
+// sessions, so only the user can read it.
+func saveHubState(path, hub string, state hubState) error {
+	states, err := readStateFile(path)
+	if err != nil {
+		return err
+	}
+	if states[hub] == state {
+		return nil
+	}
+	states[hub] = state
+
+	data, err := json.MarshalIndent(states, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o600)
+}
+
+func readStateFile(path string) (map[string]hubState, error) {
+	states := map[string]hubState{}
+	if path == "" {
+		return states, errors.New("no config directory to save logins in")
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return states, nil
+	}
+	if err != nil {
+		return states, err
+	}
+	if err := json.Unmarshal(data, &states); err != nil {
+		return map[string]hubState{}, err
+	}
+	return states, nil
+}
@@ -0,0 +1,18 @@
+//go:build !(linux || darwin || dragonfly || freebsd || netbsd || openbsd)
+
+package main
+
+import (
+	"errors"
+	"os"
+)
+
+// makeRaw is not supported on this platform
+func makeRaw(f *os.File) (func(), error) {
+	return nil, errors.New("attach is not supported on this platform")
+}
+
+// watchTerminalSize never sends a size on this platform
+func watchTerminalSize(f *os.File) (sizes <-chan termSize, stop func()) {
+	return nil, func() {}
+}
@@ -0,0 +1,76 @@
+//go:build linux || darwin || dragonfly || freebsd || netbsd || openbsd
+
+package main
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// makeRaw puts the terminal on f in raw mode, so keys such as Ctrl-C reach
+// the session, and returns a function restoring its previous mode. When f
+// is not a terminal, such as a pipe, it is left alone.
+func makeRaw(f *os.File) (func(), error) {
+	fd := int(f.Fd())
+	previous, err := unix.IoctlGetTermios(fd, ioctlReadTermios)
+	if err != nil {
+		return func() {}, nil
+	}
+
+	raw := *previous
+	raw.Iflag &^= unix.IGNBRK | unix.BRKINT | unix.PARMRK | unix.ISTRIP | unix.INLCR | unix.IGNCR | unix.ICRNL | unix.IXON
+	raw.Oflag &^= unix.OPOST
+	raw.Lflag &^= unix.ECHO | unix.ECHONL | unix.ICANON | unix.ISIG | unix.IEXTEN
+	raw.Cflag &^= unix.CSIZE | unix.PARENB
+	raw.Cflag |= unix.CS8
+	raw.Cc[unix.VMIN] = 1
+	raw.Cc[unix.VTIME] = 0
+	if err := unix.IoctlSetTermios(fd, ioctlWriteTermios, &raw); err != nil {
+		return nil, err
+	}
+	return func() { _ = unix.IoctlSetTermios(fd, ioctlWriteTermios, previous) }, nil
+}
+
+// watchTerminalSize sends the size of the terminal on f, then its new size
+// each time it is resized, until stop is called. Nothing is sent when f is
+// not a terminal.
+func watchTerminalSize(f *os.File) (sizes <-chan termSize, stop func()) {
+	ch := make(chan termSize, 1)
+	current := func() (termSize, bool) {
+		ws, err := unix.IoctlGetWinsize(int(f.Fd()), unix.TIOCGWINSZ)
+		if err != nil || ws.Col == 0 || ws.Row == 0 {
+			return termSize{}, false
+		}
+		return termSize{Cols: int(ws.Col), Rows: int(ws.Row)}, true
+	}
+	if size, ok := current(); ok {
+		ch <- size
+	}
+
+	winch := make(chan os.Signal, 1)
+	signal.Notify(winch, syscall.SIGWINCH)
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-winch:
+				if size, ok := current(); ok {
+					select {
+					case ch <- size:
+					case <-done:
+						return
+					}
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+	return ch, func() {
+		signal.Stop(winch)
+		close(done)
+	}
+}
@@ -0,0 +1,383 @@
+// Package credstore keeps SSH keys and secrets encrypted at rest. Values can
+// be written and used by the server but are never returned by the API.
+package credstore
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// Credential types
+const (
+	TypeSSHKey = "ssh_key" // private key usable by the SSH backend
+	TypeSecret = "secret"  // arbitrary secret, e.g. for cron job environments
+)
+
+// Size limits
+const (
+	MaxValueSize      = 64 * 1024
+	minMasterKeyBytes = 16
+)
+
+// keyCheckPlaintext is encrypted into every store file so a wrong master key
+// is detected when the store is opened rather than on first use
+const keyCheckPlaintext = "terminal-hub credential store"
+
+var validName = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]{0,63}$`)
+
+// Errors returned by Store methods
+var (
+	ErrNotFound = errors.New("credential not found")
+	ErrExists   = errors.New("credential already exists")
+)
+
+// Credential describes a stored credential without its value
+type Credential struct {
+	Name        string `json:"name"`
+	Type        string `json:"type"`
+	Description string `json:"description,omitempty"`
+	CreatedAt   int64  `json:"created_at"` // unix timestamp
+	UpdatedAt   int64  `json:"updated_at"` // unix timestamp
+}
+
+// storedCredential is a credential as persisted, with its encrypted value
+type storedCredential struct {
+	Credential
+	Ciphertext []byte `json:"ciphertext"` // nonce followed by the AES-GCM sealed value
+}
+
+// storeFile is the on-disk format
+type storeFile struct {
+	Version     int                `json:"version"`
+	KeyCheck    []byte             `json:"key_check"`
+	Credentials []storedCredential `json:"credentials"`
+}
+
+const currentStoreFileVersion = 1
+
+// Store is an encrypted credential store backed by a JSON file
+type Store struct {
+	path string
+	aead cipher.AEAD
+
+	mu          sync.RWMutex
+	credentials map[string]*storedCredential
+}
+
+// Open loads the store at path, creating it on first use. masterKey is the
+// secret the values are encrypted with; it must be at least 16 bytes and
+// should be long and random.
+func Open(path string, masterKey []byte) (*Store, error) {
+	if len(masterKey) < minMasterKeyBytes {
+		return nil, fmt.Errorf("master key must be at least %d bytes", minMasterKeyBytes)
+	}
+	derived := sha256.Sum256(masterKey)
+	block, err := aes.NewCipher(derived[:])
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Store{
+		path:        path,
+		aead:        aead,
+		credentials: make(map[string]*storedCredential),
+	}
+	if err := s.load(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// load reads the store file, verifying the master key
+func (s *Store) load() error {
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to read credential store: %w", err)
+	}
+
+	var file storeFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return fmt.Errorf("failed to parse credential store: %w", err)
+	}
+	if len(file.KeyCheck) > 0 {
+		plaintext, err := s.open(file.KeyCheck, "key_check")
+		if err != nil || string(plaintext) != keyCheckPlaintext {
+			return errors.New("credential store master key is incorrect")
+		}
+	}
+
+	for i := range file.Credentials {
+		cred := file.Credentials[i]
+		s.credentials[cred.Name] = &cred
+	}
+	return nil
+}
+
+// saveLocked writes the store atomically. Must be called with s.mu held.
+func (s *Store) saveLocked() error {
+	file := storeFile{
+		Version:     currentStoreFileVersion,
+		KeyCheck:    s.seal([]byte(keyCheckPlaintext), "key_check"),
+		Credentials: make([]storedCredential, 0, len(s.credentials)),
+	}
+	for _, cred := range s.credentials {
+		file.Credentials = append(file.Credentials, *cred)
+	}
+	sort.Slice(file.Credentials, func(i, j int) bool {
+		return file.Credentials[i].Name < file.Credentials[j].Name
+	})
+
+	data, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0700); err != nil {
+		return fmt.Errorf("failed to create credential store directory: %w", err)
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("failed to write credential store: %w", err)
+	}
+	return os.Rename(tmp, s.path)
+}
+
+// seal encrypts plaintext, prefixing the random nonce. The additional data
+// binds the ciphertext to its credential so values cannot be swapped.
+func (s *Store) seal(plaintext []byte, additionalData string) []byte {
+	nonce := make([]byte, s.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		panic(fmt.Sprintf("credstore: failed to read random nonce: %v", err))
+	}
+	return s.aead.Seal(nonce, nonce, plaintext, []byte(additionalData))
+}
+
+// open decrypts a value produced by seal
+func (s *Store) open(sealed []byte, additionalData string) ([]byte, error) {
+	nonceSize := s.aead.NonceSize()
+	if len(sealed) < nonceSize {
+		return nil, errors.New("ciphertext too short")
+	}
+	return s.aead.Open(nil, sealed[:nonceSize], sealed[nonceSize:], []byte(additionalData))
+}
+
+// additionalData returns the AEAD additional data for a credential
+func additionalData(name, credType string) string {
+	return credType + ":" + name
+}
+
+// ValidateType checks a credential type
+func ValidateType(credType string) error {
+	switch credType {
+	case TypeSSHKey, TypeSecret:
+		return nil
+	}
+	return fmt.Errorf("type must be %q or %q", TypeSSHKey, TypeSecret)
+}
+
+// ValidateName checks a credential name
+func ValidateName(name string) error {
+	if !validName.MatchString(name) {
+		return errors.New("name must be 1-64 letters, digits, '.', '_' or '-', starting with a letter or digit")
+	}
+	return nil
+}
+
+// List returns all credentials sorted by name, without values
+func (s *Store) List() []Credential {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	creds := make([]Credential, 0, len(s.credentials))
+	for _, cred := range s.credentials {
+		creds = append(creds, cred.Credential)
+	}
+	sort.Slice(creds, func(i, j int) bool {
+		return creds[i].Name < creds[j].Name
+	})
+	return creds
+}
+
+// Get returns a credential without its value
+func (s *Store) Get(name string) (Credential, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	cred, ok := s.credentials[name]
+	if !ok {
+		return Credential{}, ErrNotFound
+	}
+	return cred.Credential, nil
+}
+
+// Create stores a new credential
+func (s *Store) Create(name, credType, description string, value []byte) (Credential, error) {
+	if err := ValidateName(name); err != nil {
+		return Credential{}, err
+	}
+	if err := ValidateType(credType); err != nil {
+		return Credential{}, err
+	}
+	if err := ValidateValue(credType, value); err != nil {
+		return Credential{}, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.credentials[name]; ok {
+		return Credential{}, ErrExists
+	}
+
+	now := time.Now().Unix()
+	cred := &storedCredential{
+		Credential: Credential{
+			Name:        name,
+			Type:        credType,
+			Description: description,
+			CreatedAt:   now,
+			UpdatedAt:   now,
+		},
+		Ciphertext: s.seal(value, additionalData(name, credType)),
+	}
+	s.credentials[name] = cred
+	if err := s.saveLocked(); err != nil {
+		delete(s.credentials, name)
+		return Credential{}, err
+	}
+	return cred.Credential, nil
+}
+
+// Update replaces a credential's description and, when value is non-nil, its value
+func (s *Store) Update(name string, description *string, value []byte) (Credential, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cred, ok := s.credentials[name]
+	if !ok {
+		return Credential{}, ErrNotFound
+	}
+	if value != nil {
+		if err := ValidateValue(cred.Type, value); err != nil {
+			return Credential{}, err
+		}
+	}
+	previous := *cred
+	if description != nil {
+		cred.Description = *description
+	}
+	if value != nil {
+		cred.Ciphertext = s.seal(value, additionalData(name, cred.Type))
+	}
+	cred.UpdatedAt = time.Now().Unix()
+
+	if err := s.saveLocked(); err != nil {
+		*cred = previous
+		return Credential{}, err
+	}
+	return cred.Credential, nil
+}
+
+// Delete removes a credential
+func (s *Store) Delete(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cred, ok := s.credentials[name]
+	if !ok {
+		return ErrNotFound
+	}
+	delete(s.credentials, name)
+	if err := s.saveLocked(); err != nil {
+		s.credentials[name] = cred
+		return err
+	}
+	return nil
+}
+
+// Value decrypts a credential's value for use by the server, checking that
+// it has the expected type
+func (s *Store) Value(name, credType string) ([]byte, error) {
+	s.mu.RLock()
+	cred, ok := s.credentials[name]
+	s.mu.RUnlock()
+
+	if !ok {
+		return nil, ErrNotFound
+	}
+	if cred.Type != credType {
+		return nil, fmt.Errorf("credential %q is not of type %s", name, credType)
+	}
+	value, err := s.open(cred.Ciphertext, additionalData(name, cred.Type))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt credential %q: %w", name, err)
+	}
+	return value, nil
+}
+
+// ValidateValue checks a credential value, requiring SSH keys to be
+// unencrypted private keys
+func ValidateValue(credType string, value []byte) error {
+	if len(value) == 0 {
+		return errors.New("value is required")
+	}
+	if len(value) > MaxValueSize {
+		return fmt.Errorf("value must be at most %d bytes", MaxValueSize)
+	}
+	if credType == TypeSSHKey {
+		if _, err := ssh.ParsePrivateKey(value); err != nil {
+			return fmt.Errorf("value is not a valid unencrypted private key: %w", err)
+		}
+	}
+	return nil
+}
+
+// GetMasterKeyFromEnv returns the master key from TERMINAL_HUB_CREDENTIALS_KEY
+// or the file named by TERMINAL_HUB_CREDENTIALS_KEY_FILE. It returns nil when
+// neither is set, which disables the credential store.
+func GetMasterKeyFromEnv() ([]byte, error) {
+	if key := os.Getenv("TERMINAL_HUB_CREDENTIALS_KEY"); key != "" {
+		return []byte(key), nil
+	}
+	if path := os.Getenv("TERMINAL_HUB_CREDENTIALS_KEY_FILE"); path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read credentials key file: %w", err)
+		}
+		return []byte(strings.TrimSpace(string(data))), nil
+	}
+	return nil, nil
+}
+
+// GetStorePathFromEnv returns TERMINAL_HUB_CREDENTIALS_STORE, defaulting to
+// ~/.terminal-hub/secrets.json
+func GetStorePathFromEnv() string {
+	if path := os.Getenv("TERMINAL_HUB_CREDENTIALS_STORE"); path != "" {
+		return path
+	}
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "secrets.json"
+	}
+	return filepath.Join(homeDir, ".terminal-hub", "secrets.json")
+}
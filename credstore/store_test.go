@@ -0,0 +1,133 @@
+package credstore
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/pem"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"golang.org/x/crypto/ssh"
+)
+
+var testMasterKey = []byte("0123456789abcdef0123456789abcdef")
+
+func newTestPrivateKey(t *testing.T) []byte {
+	t.Helper()
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("failed generating key: %v", err)
+	}
+	block, err := ssh.MarshalPrivateKey(key, "")
+	if err != nil {
+		t.Fatalf("failed marshaling key: %v", err)
+	}
+	return pem.EncodeToMemory(block)
+}
+
+func TestStoreEncryptsValuesAtRest(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "secrets.json")
+	store, err := Open(path, testMasterKey)
+	if err != nil {
+		t.Fatalf("failed opening store: %v", err)
+	}
+
+	if _, err := store.Create("api-token", TypeSecret, "deploy token", []byte("s3cret-value")); err != nil {
+		t.Fatalf("failed creating credential: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed reading store file: %v", err)
+	}
+	if bytes.Contains(data, []byte("s3cret-value")) {
+		t.Fatalf("store file contains the plaintext value")
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("failed to stat store file: %v", err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Fatalf("expected mode 0600, got %o", info.Mode().Perm())
+	}
+
+	reopened, err := Open(path, testMasterKey)
+	if err != nil {
+		t.Fatalf("failed reopening store: %v", err)
+	}
+	value, err := reopened.Value("api-token", TypeSecret)
+	if err != nil {
+		t.Fatalf("failed reading value: %v", err)
+	}
+	if string(value) != "s3cret-value" {
+		t.Fatalf("expected decrypted value, got %q", value)
+	}
+	if _, err := reopened.Value("api-token", TypeSSHKey); err == nil {
+		t.Fatalf("expected type mismatch error")
+	}
+}
+
+func TestStoreRejectsWrongMasterKey(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "secrets.json")
+	store, err := Open(path, testMasterKey)
+	if err != nil {
+		t.Fatalf("failed opening store: %v", err)
+	}
+	if _, err := store.Create("token", TypeSecret, "", []byte("value")); err != nil {
+		t.Fatalf("failed creating credential: %v", err)
+	}
+
+	if _, err := Open(path, []byte("another-master-key-entirely")); err == nil {
+		t.Fatalf("expected wrong master key to be rejected")
+	}
+	if _, err := Open(path, []byte("short")); err == nil {
+		t.Fatalf("expected short master key to be rejected")
+	}
+}
+
+func TestStoreCreateUpdateDelete(t *testing.T) {
+	store, err := Open(filepath.Join(t.TempDir(), "secrets.json"), testMasterKey)
+	if err != nil {
+		t.Fatalf("failed opening store: %v", err)
+	}
+
+	if _, err := store.Create("deploy", TypeSSHKey, "", []byte("not a key")); err == nil {
+		t.Fatalf("expected invalid ssh key to be rejected")
+	}
+	if _, err := store.Create("deploy", TypeSSHKey, "", newTestPrivateKey(t)); err != nil {
+		t.Fatalf("failed creating ssh key: %v", err)
+	}
+	if _, err := store.Create("deploy", TypeSecret, "", []byte("x")); !errors.Is(err, ErrExists) {
+		t.Fatalf("expected ErrExists, got %v", err)
+	}
+	if _, err := store.Create("../escape", TypeSecret, "", []byte("x")); err == nil {
+		t.Fatalf("expected invalid name to be rejected")
+	}
+
+	description := "production deploy key"
+	replacement := newTestPrivateKey(t)
+	cred, err := store.Update("deploy", &description, replacement)
+	if err != nil {
+		t.Fatalf("failed updating credential: %v", err)
+	}
+	if cred.Description != description || cred.Type != TypeSSHKey {
+		t.Fatalf("unexpected credential after update: %+v", cred)
+	}
+	value, err := store.Value("deploy", TypeSSHKey)
+	if err != nil || !bytes.Equal(value, replacement) {
+		t.Fatalf("expected replaced value, got err=%v", err)
+	}
+
+	if err := store.Delete("deploy"); err != nil {
+		t.Fatalf("failed deleting credential: %v", err)
+	}
+	if err := store.Delete("deploy"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+	if creds := store.List(); len(creds) != 0 {
+		t.Fatalf("expected empty store, got %+v", creds)
+	}
+}
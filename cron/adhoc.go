@@ -0,0 +1,96 @@
+package cron
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/iwanhae/terminal-hub/terminal"
+)
+
+// AdHocJobID is the job ID one-off commands are recorded under. It is not a
+// job, but its history, live executions and output streams are read through
+// the same calls as a job's.
+const AdHocJobID = "adhoc"
+
+// adHocJobName names one-off commands in logs
+const adHocJobName = "One-off command"
+
+// RunCommandRequest is a one-off command to run in a short-lived PTY
+type RunCommandRequest struct {
+	Command          string            `json:"command"`                     // Required
+	Shell            string            `json:"shell,omitempty"`             // Optional: defaults to $SHELL
+	WorkingDirectory string            `json:"working_directory,omitempty"` // Optional
+	EnvVars          map[string]string `json:"env_vars,omitempty"`          // Optional
+	Timeout          string            `json:"timeout,omitempty"`           // Optional: duration string, defaults to the executor timeout
+}
+
+// RunCommand starts a one-off command in its own PTY and returns at once.
+// The command runs like a job's, sharing the executor's concurrency limit and
+// timeouts; its output streams through the returned live execution, and the
+// result is recorded in the history under AdHocJobID. The PTY and the shell
+// are closed when the command finishes or times out.
+func (m *CronManager) RunCommand(req RunCommandRequest) (*LiveExecution, error) {
+	if req.Command == "" {
+		return nil, errors.New("command is required")
+	}
+	if err := terminal.ValidateEnvVars(req.EnvVars); err != nil {
+		return nil, err
+	}
+	if err := m.executor.ValidateTimeout(req.Timeout); err != nil {
+		return nil, err
+	}
+	m.mu.RLock()
+	err := m.allowlist.Check(req.Shell, req.WorkingDirectory)
+	m.mu.RUnlock()
+	if err != nil {
+		return nil, err
+	}
+
+	job := &CronJob{
+		ID:               AdHocJobID,
+		Name:             adHocJobName,
+		Command:          req.Command,
+		Shell:            req.Shell,
+		WorkingDirectory: req.WorkingDirectory,
+		EnvVars:          req.EnvVars,
+		Timeout:          req.Timeout,
+	}
+	m.executor.mu.Lock()
+	ptyService := &terminal.DefaultPTYService{RunAs: m.executor.runAs}
+	m.executor.mu.Unlock()
+
+	live := m.startLiveExecution(AdHocJobID)
+	go func() {
+		result, err := m.executor.ExecuteInPTYWithOptions(context.Background(), job, ptyService, ExecuteOptions{
+			ExecutionID: live.ExecutionID,
+			Output:      live,
+		})
+		if err != nil {
+			// Record the run anyway, so it shows up in the history
+			result = &CronExecutionResult{
+				JobID:       AdHocJobID,
+				ExecutionID: live.ExecutionID,
+				StartedAt:   live.StartedAt,
+				FinishedAt:  time.Now().Unix(),
+				ExitCode:    -1,
+				Error:       err.Error(),
+			}
+		}
+
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		m.addExecution(result)
+		log.Printf("[Cron] One-off command %s finished (exit code: %d)", result.ExecutionID, result.ExitCode)
+	}()
+
+	return live, nil
+}
+
+// hasJobLocked reports whether id is a job, or AdHocJobID, whose history
+// may be read. Must be called with m.mu held.
+func (m *CronManager) hasJobLocked(id string) bool {
+	_, ok := m.jobs[id]
+	return ok || id == AdHocJobID
+}
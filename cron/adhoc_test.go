@@ -0,0 +1,63 @@
+package cron
+
+import (
+	"path/filepath"
+
+	"github.com/iwanhae/terminal-hub/terminal"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("One-off commands", func() {
+	var manager *CronManager
+
+	BeforeEach(func() {
+		var err error
+		manager, err = NewCronManager(filepath.Join(GinkgoT().TempDir(), "crons.json"), 100)
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	It("should run a command in a PTY, stream its output and record it", func() {
+		live, err := manager.RunCommand(RunCommandRequest{Command: "echo one-$((40+2))", Shell: "/bin/sh"})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(live.JobID).To(Equal(AdHocJobID))
+
+		streaming, _, err := manager.GetExecution(AdHocJobID, live.ExecutionID)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(streaming).To(BeIdenticalTo(live))
+		Eventually(live.Done(), "10s").Should(BeClosed())
+
+		history, err := manager.GetHistory(AdHocJobID)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(history).To(HaveLen(1))
+		Expect(history[0].ExecutionID).To(Equal(live.ExecutionID))
+		Expect(history[0].ExitCode).To(Equal(0))
+		Expect(history[0].Output).To(ContainSubstring("one-42"))
+
+		page, err := manager.QueryHistory(HistoryQuery{})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(page.Total).To(Equal(1))
+	})
+
+	It("should record the exit code of a failing command", func() {
+		live, err := manager.RunCommand(RunCommandRequest{Command: "exit 3", Shell: "/bin/sh"})
+		Expect(err).ToNot(HaveOccurred())
+		Eventually(live.Done(), "10s").Should(BeClosed())
+
+		_, result, err := manager.GetExecution(AdHocJobID, live.ExecutionID)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result.ExitCode).To(Equal(3))
+		Expect(result.Error).ToNot(BeEmpty())
+	})
+
+	It("should check the request", func() {
+		_, err := manager.RunCommand(RunCommandRequest{})
+		Expect(err).To(MatchError("command is required"))
+		_, err = manager.RunCommand(RunCommandRequest{Command: "ls", Timeout: "soon"})
+		Expect(err).To(HaveOccurred())
+
+		manager.SetExecutionAllowlist(terminal.ExecutionAllowlist{Shells: []string{"/bin/bash"}})
+		_, err = manager.RunCommand(RunCommandRequest{Command: "ls", Shell: "/bin/sh"})
+		Expect(err).To(HaveOccurred())
+	})
+})
@@ -0,0 +1,259 @@
+package cron
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CronChainStatus describes a job's position in dependency chains
+type CronChainStatus struct {
+	Upstream   []CronChainUpstream `json:"upstream,omitempty"`
+	Downstream []string            `json:"downstream,omitempty"` // IDs of jobs that run after this one
+	Ready      bool                `json:"ready"`                // every upstream job last succeeded
+}
+
+// CronChainUpstream is the last known state of an upstream job
+type CronChainUpstream struct {
+	JobID         string `json:"job_id"`
+	Name          string `json:"name"`
+	LastRunStatus string `json:"last_run_status"`
+	LastRunAt     int64  `json:"last_run_at"`
+}
+
+// nextRunTime returns the next run of a schedule, or the zero time for
+// chain-only jobs without a schedule
+func nextRunTime(schedule string, from time.Time) time.Time {
+	if schedule == "" {
+		return time.Time{}
+	}
+	nextRun, err := GetNextRunTime(schedule, from)
+	if err != nil {
+		return time.Time{}
+	}
+	return nextRun
+}
+
+// unixOrZero converts a time to a unix timestamp, mapping the zero time to 0
+func unixOrZero(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.Unix()
+}
+
+// normalizeRunAfter trims and de-duplicates upstream job IDs
+func normalizeRunAfter(runAfter []string) []string {
+	if len(runAfter) == 0 {
+		return nil
+	}
+	seen := make(map[string]bool, len(runAfter))
+	normalized := make([]string, 0, len(runAfter))
+	for _, id := range runAfter {
+		id = strings.TrimSpace(id)
+		if id == "" || seen[id] {
+			continue
+		}
+		seen[id] = true
+		normalized = append(normalized, id)
+	}
+	return normalized
+}
+
+// validateRunAfterLocked checks that every upstream job exists and that giving
+// jobID these upstream jobs would not create a dependency cycle. batch holds
+// the upstream jobs of jobs about to be created by an import, which count as
+// existing. Must be called with m.mu already held.
+func (m *CronManager) validateRunAfterLocked(jobID string, runAfter []string, batch map[string][]string) error {
+	for _, upstreamID := range runAfter {
+		if upstreamID == jobID {
+			return errors.New("a job cannot run after itself")
+		}
+		_, exists := m.jobs[upstreamID]
+		if _, pending := batch[upstreamID]; !exists && !pending {
+			return fmt.Errorf("upstream job %s not found", upstreamID)
+		}
+	}
+
+	if jobID == "" {
+		// New jobs have no downstream jobs yet, so they cannot close a cycle
+		return nil
+	}
+
+	upstreamOf := func(id string) []string {
+		if id == jobID {
+			return runAfter
+		}
+		if job, ok := m.jobs[id]; ok {
+			return job.RunAfter
+		}
+		return batch[id]
+	}
+
+	// Depth-first search from the job along upstream edges; reaching the job
+	// again means it would (indirectly) run after itself
+	visited := make(map[string]bool)
+	var path []string
+	var visit func(id string) bool
+	visit = func(id string) bool {
+		path = append(path, id)
+		for _, upstreamID := range upstreamOf(id) {
+			if upstreamID == jobID {
+				path = append(path, upstreamID)
+				return true
+			}
+			if !visited[upstreamID] {
+				visited[upstreamID] = true
+				if visit(upstreamID) {
+					return true
+				}
+			}
+		}
+		path = path[:len(path)-1]
+		return false
+	}
+
+	if visit(jobID) {
+		return fmt.Errorf("dependency cycle: %s", strings.Join(path, " -> "))
+	}
+	return nil
+}
+
+// importRefPrefix marks a run_after entry of an export that names another job
+// of the same export by its 1-based position, e.g. "#2", instead of by ID:
+// IDs are not kept when the export is imported
+const importRefPrefix = "#"
+
+// exportRunAfter replaces the IDs of upstream jobs that are part of an export
+// with references to their position in it. positions maps job IDs to 1-based
+// positions; upstream jobs outside the export keep their IDs.
+func exportRunAfter(runAfter []string, positions map[string]int) []string {
+	if len(runAfter) == 0 {
+		return runAfter
+	}
+	exported := make([]string, 0, len(runAfter))
+	for _, id := range runAfter {
+		if position, ok := positions[id]; ok {
+			id = importRefPrefix + strconv.Itoa(position)
+		}
+		exported = append(exported, id)
+	}
+	return exported
+}
+
+// resolveImportRunAfter replaces references to jobs of an import, see
+// importRefPrefix, with the IDs the jobs will get
+func resolveImportRunAfter(runAfter []string, ids []string) ([]string, error) {
+	if len(runAfter) == 0 {
+		return runAfter, nil
+	}
+	resolved := make([]string, 0, len(runAfter))
+	for _, entry := range runAfter {
+		entry = strings.TrimSpace(entry)
+		if ref, ok := strings.CutPrefix(entry, importRefPrefix); ok {
+			position, err := strconv.Atoi(ref)
+			if err != nil || position < 1 || position > len(ids) {
+				return nil, fmt.Errorf("run_after %q does not refer to a job of the import", entry)
+			}
+			entry = ids[position-1]
+		}
+		resolved = append(resolved, entry)
+	}
+	return resolved, nil
+}
+
+// upstreamReadyLocked reports whether every upstream job last succeeded.
+// Must be called with m.mu already held.
+func (m *CronManager) upstreamReadyLocked(job *CronJob) bool {
+	for _, upstreamID := range job.RunAfter {
+		upstream, ok := m.jobs[upstreamID]
+		if !ok || upstream.Metadata.LastRunStatus != "success" {
+			return false
+		}
+	}
+	return true
+}
+
+// triggerDownstreamLocked starts enabled jobs that run after the given job
+// once all of their upstream jobs have succeeded.
+// Must be called with m.mu already held.
+func (m *CronManager) triggerDownstreamLocked(upstream *CronJob, result *CronExecutionResult) {
+	if result.ExitCode != 0 {
+		return
+	}
+
+	for _, job := range m.jobs {
+		if !job.Enabled || !containsString(job.RunAfter, upstream.ID) {
+			continue
+		}
+		if !m.upstreamReadyLocked(job) {
+			log.Printf("[Cron] Job %s waiting on other upstream jobs after %s", job.ID, upstream.ID)
+			continue
+		}
+
+		log.Printf("[Cron] Triggering job %s after upstream job %s succeeded", job.ID, upstream.ID)
+		go m.executeJob(job.ID)
+	}
+}
+
+// removeUpstreamLocked drops a deleted job from other jobs' run_after lists.
+// Must be called with m.mu already held.
+func (m *CronManager) removeUpstreamLocked(upstreamID string) {
+	for _, job := range m.jobs {
+		if !containsString(job.RunAfter, upstreamID) {
+			continue
+		}
+		runAfter := make([]string, 0, len(job.RunAfter)-1)
+		for _, id := range job.RunAfter {
+			if id != upstreamID {
+				runAfter = append(runAfter, id)
+			}
+		}
+		job.RunAfter = normalizeRunAfter(runAfter)
+		log.Printf("[Cron] Removed deleted upstream job %s from job %s", upstreamID, job.ID)
+	}
+}
+
+// chainStatusLocked builds the chain status for a job, or nil if the job is
+// not part of any chain. Must be called with m.mu already held.
+func (m *CronManager) chainStatusLocked(job *CronJob) *CronChainStatus {
+	var downstream []string
+	for _, other := range m.jobs {
+		if containsString(other.RunAfter, job.ID) {
+			downstream = append(downstream, other.ID)
+		}
+	}
+	if len(job.RunAfter) == 0 && len(downstream) == 0 {
+		return nil
+	}
+	sort.Strings(downstream)
+
+	status := &CronChainStatus{
+		Downstream: downstream,
+		Ready:      m.upstreamReadyLocked(job),
+	}
+	for _, upstreamID := range job.RunAfter {
+		upstream := CronChainUpstream{JobID: upstreamID}
+		if upstreamJob, ok := m.jobs[upstreamID]; ok {
+			upstream.Name = upstreamJob.Name
+			upstream.LastRunStatus = upstreamJob.Metadata.LastRunStatus
+			upstream.LastRunAt = upstreamJob.Metadata.LastRunAt
+		}
+		status.Upstream = append(status.Upstream, upstream)
+	}
+	return status
+}
+
+// containsString reports whether values contains value
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
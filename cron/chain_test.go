@@ -0,0 +1,138 @@
+package cron
+
+import (
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Job dependency chains", func() {
+	var (
+		tempDir  string
+		manager  *CronManager
+		mockExec *MockCommandExecutor
+	)
+
+	BeforeEach(func() {
+		var err error
+		tempDir, err = os.MkdirTemp("", "cron-chain-*")
+		Expect(err).ToNot(HaveOccurred())
+		manager, err = NewCronManager(filepath.Join(tempDir, "crons.json"), 100)
+		Expect(err).ToNot(HaveOccurred())
+
+		mockExec = NewMockCommandExecutor()
+		manager.executor = NewCronExecutorWithOptions(DefaultCronExecutorConfig(), WithMockExecutor(mockExec))
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(tempDir)
+	})
+
+	create := func(name string, runAfter ...string) *CronJob {
+		req := CreateCronRequest{Name: name, Command: "run " + name, Enabled: true, RunAfter: runAfter}
+		if len(runAfter) == 0 {
+			req.Schedule = "0 0 1 1 *"
+		}
+		job, err := manager.Create(req)
+		Expect(err).ToNot(HaveOccurred())
+		return job
+	}
+
+	historyLen := func(jobID string) func() int {
+		return func() int {
+			history, _ := manager.GetHistory(jobID)
+			return len(history)
+		}
+	}
+
+	It("should allow chain-only jobs without a schedule", func() {
+		upstream := create("extract")
+		downstream := create("load", upstream.ID)
+
+		Expect(downstream.Schedule).To(BeEmpty())
+		Expect(downstream.Metadata.NextRunAt).To(BeZero())
+
+		_, err := manager.Create(CreateCronRequest{Name: "orphan", Command: "true"})
+		Expect(err).To(MatchError(ContainSubstring("schedule is required")))
+	})
+
+	It("should run downstream jobs after the upstream job succeeds", func() {
+		upstream := create("extract")
+		downstream := create("load", upstream.ID)
+
+		_, err := manager.RunNow(upstream.ID)
+		Expect(err).ToNot(HaveOccurred())
+
+		Eventually(historyLen(downstream.ID)).Should(Equal(1))
+		Expect(mockExec.GetExecutedCommands()).To(Equal([]string{"run extract", "run load"}))
+	})
+
+	It("should not run downstream jobs after a failure", func() {
+		upstream := create("extract")
+		downstream := create("load", upstream.ID)
+
+		mockExec.SetDefaultResult(MockCommandResult{ExitCode: 1})
+		_, err := manager.RunNow(upstream.ID)
+		Expect(err).ToNot(HaveOccurred())
+
+		Consistently(historyLen(downstream.ID), "200ms").Should(Equal(0))
+	})
+
+	It("should wait until every upstream job has succeeded", func() {
+		first := create("first")
+		second := create("second")
+		downstream := create("merge", first.ID, second.ID)
+
+		_, err := manager.RunNow(first.ID)
+		Expect(err).ToNot(HaveOccurred())
+		Consistently(historyLen(downstream.ID), "200ms").Should(Equal(0))
+
+		_, err = manager.RunNow(second.ID)
+		Expect(err).ToNot(HaveOccurred())
+		Eventually(historyLen(downstream.ID)).Should(Equal(1))
+	})
+
+	It("should reject unknown upstream jobs and cycles", func() {
+		_, err := manager.Create(CreateCronRequest{Name: "bad", Command: "true", RunAfter: []string{"missing"}})
+		Expect(err).To(MatchError(ContainSubstring("not found")))
+
+		a := create("a")
+		b := create("b", a.ID)
+		c := create("c", b.ID)
+
+		runAfter := []string{c.ID}
+		_, err = manager.Update(a.ID, UpdateCronRequest{RunAfter: &runAfter})
+		Expect(err).To(MatchError(ContainSubstring("dependency cycle")))
+
+		self := []string{a.ID}
+		_, err = manager.Update(a.ID, UpdateCronRequest{RunAfter: &self})
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should surface chain status and clean up deleted upstream jobs", func() {
+		upstream := create("extract")
+		downstream := create("load", upstream.ID)
+		_, err := manager.RunNow(upstream.ID)
+		Expect(err).ToNot(HaveOccurred())
+
+		jobs, err := manager.List()
+		Expect(err).ToNot(HaveOccurred())
+		for _, job := range jobs {
+			switch job.ID {
+			case upstream.ID:
+				Expect(job.Chain.Downstream).To(Equal([]string{downstream.ID}))
+			case downstream.ID:
+				Expect(job.Chain.Upstream).To(HaveLen(1))
+				Expect(job.Chain.Upstream[0].LastRunStatus).To(Equal("success"))
+				Expect(job.Chain.Ready).To(BeTrue())
+			}
+		}
+
+		Expect(manager.Delete(upstream.ID)).To(Succeed())
+		loaded, err := manager.Get(downstream.ID)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(loaded.RunAfter).To(BeEmpty())
+	})
+})
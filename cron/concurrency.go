@@ -0,0 +1,133 @@
+package cron
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Concurrency policies control what happens when a job is triggered while a
+// previous run of the same job is still in progress
+const (
+	ConcurrencyPolicyAllow   = "allow"   // run alongside the previous run (default)
+	ConcurrencyPolicySkip    = "skip"    // skip this tick
+	ConcurrencyPolicyQueue   = "queue"   // wait for the previous run to finish
+	ConcurrencyPolicyReplace = "replace" // kill the previous run and start a new one
+)
+
+// Overlap markers recorded on execution history entries
+const (
+	OverlapSkipped  = "skipped"  // tick was skipped because a run was in progress
+	OverlapQueued   = "queued"   // run waited for the previous run to finish
+	OverlapReplaced = "replaced" // run killed the previous run before starting
+	OverlapKilled   = "killed"   // run was killed by a newer run
+)
+
+// maxQueuedRuns bounds how many ticks may wait behind a running job
+const maxQueuedRuns = 5
+
+// runningRun tracks an in-flight scheduled execution so it can be cancelled
+type runningRun struct {
+	cancel   context.CancelFunc
+	replaced bool
+}
+
+// ValidateConcurrencyPolicy checks that a policy name is known
+func ValidateConcurrencyPolicy(policy string) error {
+	switch policy {
+	case "", ConcurrencyPolicyAllow, ConcurrencyPolicySkip, ConcurrencyPolicyQueue, ConcurrencyPolicyReplace:
+		return nil
+	default:
+		return fmt.Errorf("invalid concurrency_policy %q: must be one of allow, skip, queue, replace", policy)
+	}
+}
+
+// applyConcurrencyPolicyLocked decides whether a triggered run may start,
+// returning the overlap marker to record on its result.
+// Must be called with m.mu held; may temporarily release it while queued.
+func (m *CronManager) applyConcurrencyPolicyLocked(job *CronJob) (string, bool) {
+	if job.Metadata.ConcurrentRuns == 0 {
+		return "", true
+	}
+
+	switch job.ConcurrencyPolicy {
+	case ConcurrencyPolicySkip:
+		log.Printf("[Cron] Job %s still running, skipping this run", job.ID)
+		m.recordSkippedLocked(job)
+		return "", false
+
+	case ConcurrencyPolicyQueue:
+		if m.queued[job.ID] >= maxQueuedRuns {
+			log.Printf("[Cron] Job %s queue is full, skipping this run", job.ID)
+			m.recordSkippedLocked(job)
+			return "", false
+		}
+
+		m.queued[job.ID]++
+		for job.Metadata.ConcurrentRuns > 0 {
+			m.runDone.Wait()
+		}
+		m.queued[job.ID]--
+
+		// The job may have been deleted while this run was waiting
+		if _, ok := m.jobs[job.ID]; !ok {
+			return "", false
+		}
+		return OverlapQueued, true
+
+	case ConcurrencyPolicyReplace:
+		for run := range m.running[job.ID] {
+			run.replaced = true
+			run.cancel()
+		}
+		log.Printf("[Cron] Job %s still running, killing previous run", job.ID)
+		return OverlapReplaced, true
+	}
+
+	return "", true
+}
+
+// recordSkippedLocked adds a history entry for a skipped run.
+// Must be called with m.mu held.
+func (m *CronManager) recordSkippedLocked(job *CronJob) {
+	now := time.Now().Unix()
+	m.addExecution(&CronExecutionResult{
+		JobID:       job.ID,
+		ExecutionID: "exec_" + uuid.New().String(),
+		StartedAt:   now,
+		FinishedAt:  now,
+		ExitCode:    -1,
+		Error:       "Skipped: previous run still in progress",
+		Overlap:     OverlapSkipped,
+	})
+
+	m.saveAfterRunLocked("skipped run")
+}
+
+// startRunLocked registers an in-flight run and returns its context.
+// Must be called with m.mu held.
+func (m *CronManager) startRunLocked(jobID string) (context.Context, *runningRun) {
+	ctx, cancel := context.WithCancel(context.Background())
+	run := &runningRun{cancel: cancel}
+
+	if m.running[jobID] == nil {
+		m.running[jobID] = make(map[*runningRun]struct{})
+	}
+	m.running[jobID][run] = struct{}{}
+
+	return ctx, run
+}
+
+// finishRunLocked unregisters an in-flight run and wakes queued runs.
+// Must be called with m.mu held.
+func (m *CronManager) finishRunLocked(jobID string, run *runningRun) {
+	run.cancel()
+	delete(m.running[jobID], run)
+	if len(m.running[jobID]) == 0 {
+		delete(m.running, jobID)
+	}
+	m.runDone.Broadcast()
+}
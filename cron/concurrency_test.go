@@ -0,0 +1,163 @@
+package cron
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Concurrency policy", func() {
+	var (
+		tempDir  string
+		manager  *CronManager
+		mockExec *MockCommandExecutor
+	)
+
+	BeforeEach(func() {
+		var err error
+		tempDir, err = os.MkdirTemp("", "cron-concurrency-*")
+		Expect(err).ToNot(HaveOccurred())
+		manager, err = NewCronManager(filepath.Join(tempDir, "crons.json"), 100)
+		Expect(err).ToNot(HaveOccurred())
+
+		mockExec = NewMockCommandExecutor()
+		mockExec.SetDefaultResult(MockCommandResult{Stdout: "done", Delay: 200 * time.Millisecond})
+		manager.executor = NewCronExecutorWithOptions(DefaultCronExecutorConfig(), WithMockExecutor(mockExec))
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(tempDir)
+	})
+
+	createJob := func(policy string) *CronJob {
+		job, err := manager.Create(CreateCronRequest{
+			Name: "Overlap " + policy, Schedule: "0 0 1 1 *", Command: "slow",
+			ConcurrencyPolicy: policy,
+		})
+		Expect(err).ToNot(HaveOccurred())
+		return job
+	}
+
+	// runOverlapping starts a run, waits until it is in flight, then triggers a second one
+	runOverlapping := func(jobID string) {
+		var wg sync.WaitGroup
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			manager.executeJob(jobID)
+		}()
+		Eventually(func() int {
+			job, _ := manager.Get(jobID)
+			return job.Metadata.ConcurrentRuns
+		}).Should(Equal(1))
+		go func() {
+			defer wg.Done()
+			manager.executeJob(jobID)
+		}()
+		wg.Wait()
+	}
+
+	overlapMarkers := func(jobID string) []string {
+		history, err := manager.GetHistory(jobID)
+		Expect(err).ToNot(HaveOccurred())
+		markers := make([]string, 0, len(history))
+		for _, exec := range history {
+			markers = append(markers, exec.Overlap)
+		}
+		return markers
+	}
+
+	It("should validate policy names", func() {
+		Expect(ValidateConcurrencyPolicy("")).To(Succeed())
+		Expect(ValidateConcurrencyPolicy(ConcurrencyPolicyQueue)).To(Succeed())
+		Expect(ValidateConcurrencyPolicy("parallel")).ToNot(Succeed())
+
+		_, err := manager.Create(CreateCronRequest{
+			Name: "Bad", Schedule: "* * * * *", Command: "true", ConcurrencyPolicy: "parallel",
+		})
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should run overlapping executions by default", func() {
+		job := createJob("")
+		runOverlapping(job.ID)
+
+		Expect(mockExec.GetExecutedCommands()).To(HaveLen(2))
+		Expect(overlapMarkers(job.ID)).To(ConsistOf("", ""))
+	})
+
+	It("should skip a tick while the previous run is in progress", func() {
+		job := createJob(ConcurrencyPolicySkip)
+		runOverlapping(job.ID)
+
+		Expect(mockExec.GetExecutedCommands()).To(HaveLen(1))
+		Expect(overlapMarkers(job.ID)).To(ConsistOf(OverlapSkipped, ""))
+
+		updated, err := manager.Get(job.ID)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(updated.Metadata.TotalRuns).To(Equal(1))
+	})
+
+	It("should queue a tick until the previous run finishes", func() {
+		job := createJob(ConcurrencyPolicyQueue)
+		start := time.Now()
+		runOverlapping(job.ID)
+
+		Expect(time.Since(start)).To(BeNumerically(">=", 400*time.Millisecond))
+		Expect(mockExec.GetExecutedCommands()).To(HaveLen(2))
+		Expect(overlapMarkers(job.ID)).To(Equal([]string{"", OverlapQueued}))
+	})
+
+	It("should kill the previous run when replacing", func() {
+		job := createJob(ConcurrencyPolicyReplace)
+		runOverlapping(job.ID)
+
+		history, err := manager.GetHistory(job.ID)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(history).To(HaveLen(2))
+		Expect(history[0].Overlap).To(Equal(OverlapKilled))
+		Expect(history[0].ExitCode).To(Equal(-1))
+		Expect(history[1].Overlap).To(Equal(OverlapReplaced))
+		Expect(history[1].ExitCode).To(Equal(0))
+	})
+
+	It("should mark a run replaced while waiting to retry as killed", func() {
+		var calls int32
+		mockExec.SetOnExecute(func(command string) MockCommandResult {
+			if atomic.AddInt32(&calls, 1) == 1 {
+				return MockCommandResult{ExitCode: 1}
+			}
+			return MockCommandResult{Stdout: "done"}
+		})
+		job, err := manager.Create(CreateCronRequest{
+			Name: "Replaced retry", Schedule: "0 0 1 1 *", Command: "flaky",
+			ConcurrencyPolicy: ConcurrencyPolicyReplace, MaxRetries: 2, RetryBackoff: "1h",
+		})
+		Expect(err).ToNot(HaveOccurred())
+
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			manager.executeJob(job.ID)
+		}()
+		Eventually(func() ([]CronExecutionResult, error) {
+			return manager.GetHistory(job.ID)
+		}).Should(HaveLen(1))
+
+		manager.executeJob(job.ID)
+		Eventually(done).Should(BeClosed())
+
+		history, err := manager.GetHistory(job.ID)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(history).To(HaveLen(2))
+		Expect(history[0].Attempt).To(Equal(1))
+		Expect(history[0].Overlap).To(Equal(OverlapKilled))
+		Expect(history[1].Overlap).To(Equal(OverlapReplaced))
+		Expect(history[1].ExitCode).To(Equal(0))
+	})
+})
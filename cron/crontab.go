@@ -0,0 +1,340 @@
+package cron
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Import/export formats
+const (
+	ExportFormatCrontab = "crontab"
+	ExportFormatJSON    = "json"
+)
+
+// crontabMacros maps crontab @-shortcuts to equivalent cron expressions
+var crontabMacros = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// crontabEnvPattern matches "NAME=value" environment lines
+var crontabEnvPattern = regexp.MustCompile(`^([A-Za-z_][A-Za-z0-9_]*)\s*=\s*(.*)$`)
+
+// maxDerivedNameLength bounds job names derived from commands
+const maxDerivedNameLength = 60
+
+// ImportError describes a definition that could not be imported
+type ImportError struct {
+	Line    int    `json:"line,omitempty"`  // 1-based crontab line
+	Index   int    `json:"index,omitempty"` // 1-based position in a JSON import
+	Message string `json:"message"`
+}
+
+func (e ImportError) Error() string {
+	if e.Line > 0 {
+		return fmt.Sprintf("line %d: %s", e.Line, e.Message)
+	}
+	if e.Index > 0 {
+		return fmt.Sprintf("job %d: %s", e.Index, e.Message)
+	}
+	return e.Message
+}
+
+// ImportErrors is returned when any definition in an import is invalid
+type ImportErrors []ImportError
+
+func (e ImportErrors) Error() string {
+	messages := make([]string, 0, len(e))
+	for _, err := range e {
+		messages = append(messages, err.Error())
+	}
+	return "invalid import: " + strings.Join(messages, "; ")
+}
+
+// ParseCrontab converts a standard crontab into create requests.
+// Environment lines (NAME=value) apply to the jobs that follow them, with
+// SHELL selecting the shell and CRON_TZ the timezone. A "# name: ..." comment names the next job and
+// "# enabled: false" imports it disabled; otherwise names are derived from
+// the command. Both are dropped at a "# unsupported ..." comment, which
+// FormatCrontab writes for jobs it cannot express. Schedules use the five
+// standard fields or an @-shortcut. As in cron, "\%" in a command is a
+// literal "%", and text after an unescaped "%" is the command's standard
+// input, with further "%" as line breaks; it is piped in with printf.
+func ParseCrontab(r io.Reader) ([]CreateCronRequest, error) {
+	var (
+		requests    []CreateCronRequest
+		errs        ImportErrors
+		shell       string
+		timezone    string
+		env         = map[string]string{}
+		pendingName string
+		enabled     = true
+	)
+
+	scanner := bufio.NewScanner(r)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+
+		if line == "" {
+			continue
+		}
+		if strings.HasPrefix(line, "#") {
+			comment := strings.TrimSpace(strings.TrimPrefix(line, "#"))
+			if name, ok := strings.CutPrefix(comment, "name:"); ok {
+				pendingName = strings.TrimSpace(name)
+			} else if value, ok := strings.CutPrefix(comment, "enabled:"); ok {
+				enabled = strings.TrimSpace(value) != "false"
+			} else if strings.HasPrefix(comment, "unsupported ") {
+				// The attributes belonged to a job exported as a comment
+				pendingName = ""
+				enabled = true
+			}
+			continue
+		}
+
+		if match := crontabEnvPattern.FindStringSubmatch(line); match != nil {
+			value := strings.Trim(match[2], `"'`)
+			switch match[1] {
+			case "SHELL":
+				shell = value
+			case "CRON_TZ":
+				if err := ValidateTimezone(value); err != nil {
+					errs = append(errs, ImportError{Line: lineNumber, Message: err.Error()})
+				}
+				timezone = value
+			default:
+				env[match[1]] = value
+			}
+			continue
+		}
+
+		schedule, rawCommand, err := splitCrontabLine(line)
+		command, input := splitCrontabPercent(rawCommand)
+		if err != nil {
+			errs = append(errs, ImportError{Line: lineNumber, Message: err.Error()})
+		} else if err := ValidateSchedule(schedule); err != nil {
+			errs = append(errs, ImportError{Line: lineNumber, Message: err.Error()})
+		} else {
+			name := pendingName
+			if name == "" {
+				name = deriveJobName(command)
+			}
+			if input != nil {
+				command = pipeCrontabInput(command, input)
+			}
+			req := CreateCronRequest{
+				Name:     name,
+				Schedule: schedule,
+				Command:  command,
+				Shell:    shell,
+				Timezone: timezone,
+				Enabled:  enabled,
+			}
+			if len(env) > 0 {
+				req.EnvVars = make(map[string]string, len(env))
+				for key, value := range env {
+					req.EnvVars[key] = value
+				}
+			}
+			requests = append(requests, req)
+		}
+
+		pendingName = ""
+		enabled = true
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	return requests, nil
+}
+
+// splitCrontabLine separates the schedule from the command
+func splitCrontabLine(line string) (string, string, error) {
+	if strings.HasPrefix(line, "@") {
+		macro := strings.Fields(line)[0]
+		command := strings.TrimSpace(line[len(macro):])
+		if macro == "@reboot" {
+			return "", "", fmt.Errorf("@reboot is not supported")
+		}
+		schedule, ok := crontabMacros[macro]
+		if !ok {
+			return "", "", fmt.Errorf("unknown schedule shortcut %q", macro)
+		}
+		if command == "" {
+			return "", "", fmt.Errorf("missing command")
+		}
+		return schedule, command, nil
+	}
+
+	fields := strings.Fields(line)
+	if len(fields) < 6 {
+		return "", "", fmt.Errorf("expected five schedule fields followed by a command")
+	}
+
+	// Keep the command exactly as written after the fifth field
+	rest := line
+	for i := 0; i < 5; i++ {
+		rest = strings.TrimLeft(rest, " \t")
+		rest = rest[len(fields[i]):]
+	}
+	return strings.Join(fields[:5], " "), strings.TrimSpace(rest), nil
+}
+
+// splitCrontabPercent applies cron's "%" handling to a command: it returns
+// the command before the first unescaped "%", and the lines of standard
+// input after it, nil when there is none. "\%" stands for "%"; other
+// backslashes are kept.
+func splitCrontabPercent(raw string) (string, []string) {
+	var segments []string
+	var b strings.Builder
+	escaped := false
+	for _, r := range raw {
+		switch {
+		case escaped:
+			if r != '%' {
+				b.WriteByte('\\')
+			}
+			b.WriteRune(r)
+			escaped = false
+		case r == '\\':
+			escaped = true
+		case r == '%':
+			segments = append(segments, b.String())
+			b.Reset()
+		default:
+			b.WriteRune(r)
+		}
+	}
+	if escaped {
+		b.WriteByte('\\')
+	}
+	segments = append(segments, b.String())
+	if len(segments) == 1 {
+		return strings.TrimSpace(segments[0]), nil
+	}
+	return strings.TrimSpace(segments[0]), segments[1:]
+}
+
+// pipeCrontabInput makes command read input, one line each, on standard input
+func pipeCrontabInput(command string, input []string) string {
+	quoted := make([]string, len(input))
+	for i, line := range input {
+		quoted[i] = "'" + strings.ReplaceAll(line, "'", `'\''`) + "'"
+	}
+	return fmt.Sprintf("printf '%%s\\n' %s | (%s)", strings.Join(quoted, " "), command)
+}
+
+// deriveJobName builds a job name from its command
+func deriveJobName(command string) string {
+	name := strings.Join(strings.Fields(command), " ")
+	if len(name) > maxDerivedNameLength {
+		name = name[:maxDerivedNameLength-3] + "..."
+	}
+	return name
+}
+
+// FormatCrontab renders jobs as a crontab. Only the name, schedule, timezone,
+// command and enabled state are exported: crontab environment lines apply to every job
+// that follows them, so per-job shells and variables cannot be represented.
+// "%" in commands is escaped as "\%"; jobs cron cannot express, such as
+// multi-line commands, are written as "# unsupported ..." comments. Use the
+// JSON export for full job definitions.
+func FormatCrontab(jobs []CronJob) string {
+	var b strings.Builder
+	b.WriteString("# terminal-hub cron export\n")
+
+	sortJobsByName(jobs)
+	timezone := ""
+	for _, job := range jobs {
+		b.WriteString("\n")
+		if job.Timezone != timezone && job.Schedule != "" {
+			// CRON_TZ applies to every following line, like other variables
+			fmt.Fprintf(&b, "CRON_TZ=%s\n", job.Timezone)
+			timezone = job.Timezone
+		}
+		fmt.Fprintf(&b, "# name: %s\n", strings.ReplaceAll(job.Name, "\n", " "))
+		if !job.Enabled {
+			b.WriteString("# enabled: false\n")
+		}
+		line := fmt.Sprintf("%s %s", job.Schedule, strings.NewReplacer("\r\n", " ", "\r", " ", "\n", " ").Replace(job.Command))
+		if job.RunAt != 0 {
+			fmt.Fprintf(&b, "# unsupported schedule (runs once at %s): %s\n",
+				time.Unix(job.RunAt, 0).UTC().Format(time.RFC3339), strings.TrimSpace(line))
+			continue
+		}
+		if job.Schedule == "" {
+			fmt.Fprintf(&b, "# unsupported schedule (runs after other jobs): %s\n", strings.TrimSpace(line))
+			continue
+		}
+		if len(strings.Fields(job.Schedule)) != 5 {
+			// Crontab has no seconds field; keep the job visible but inactive
+			fmt.Fprintf(&b, "# unsupported schedule (seconds field): %s\n", line)
+			continue
+		}
+		if strings.ContainsAny(job.Command, "\r\n") {
+			// A crontab entry is a single line
+			fmt.Fprintf(&b, "# unsupported command (multiple lines): %s\n", line)
+			continue
+		}
+		if strings.Contains(job.Command, `\%`) {
+			// Cron reads "\%" as "%", and has no way to write it literally
+			fmt.Fprintf(&b, "# unsupported command (backslash before %%): %s\n", line)
+			continue
+		}
+		fmt.Fprintf(&b, "%s %s\n", job.Schedule, strings.ReplaceAll(job.Command, "%", `\%`))
+	}
+
+	return b.String()
+}
+
+// sortJobsByName orders jobs by name, then ID, for stable exports
+func sortJobsByName(jobs []CronJob) {
+	sort.Slice(jobs, func(i, j int) bool {
+		if jobs[i].Name != jobs[j].Name {
+			return jobs[i].Name < jobs[j].Name
+		}
+		return jobs[i].ID < jobs[j].ID
+	})
+}
+
+// JobDefinition returns the create request that reproduces a job
+func JobDefinition(job CronJob) CreateCronRequest {
+	return CreateCronRequest{
+		Name:              job.Name,
+		Schedule:          job.Schedule,
+		Command:           job.Command,
+		Shell:             job.Shell,
+		WorkingDirectory:  job.WorkingDirectory,
+		EnvVars:           job.EnvVars,
+		Enabled:           job.Enabled,
+		Notifications:     job.Notifications,
+		MaxRetries:        job.MaxRetries,
+		RetryBackoff:      job.RetryBackoff,
+		Timeout:           job.Timeout,
+		ConcurrencyPolicy: job.ConcurrencyPolicy,
+		TargetSessionID:   job.TargetSessionID,
+		RunAfter:          job.RunAfter,
+		RunAt:             job.RunAt,
+		LogToFile:         job.LogToFile,
+		Timezone:          job.Timezone,
+		Jitter:            job.Jitter,
+		Limits:            job.Limits,
+		CatchUp:           job.CatchUp,
+	}
+}
@@ -0,0 +1,216 @@
+package cron
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Crontab import/export", func() {
+	Describe("ParseCrontab", func() {
+		It("should parse schedules, shortcuts and environment lines", func() {
+			reqs, err := ParseCrontab(strings.NewReader(`
+# m h dom mon dow command
+SHELL=/bin/bash
+PATH="/usr/local/bin:/usr/bin"
+
+# name: Nightly backup
+30 2 * * *   /usr/bin/backup --all  > /tmp/backup.log
+# enabled: false
+@hourly curl -s http://localhost/health
+`))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(reqs).To(HaveLen(2))
+
+			Expect(reqs[0].Name).To(Equal("Nightly backup"))
+			Expect(reqs[0].Schedule).To(Equal("30 2 * * *"))
+			Expect(reqs[0].Command).To(Equal("/usr/bin/backup --all  > /tmp/backup.log"))
+			Expect(reqs[0].Shell).To(Equal("/bin/bash"))
+			Expect(reqs[0].EnvVars).To(HaveKeyWithValue("PATH", "/usr/local/bin:/usr/bin"))
+			Expect(reqs[0].Enabled).To(BeTrue())
+
+			Expect(reqs[1].Name).To(Equal("curl -s http://localhost/health"))
+			Expect(reqs[1].Schedule).To(Equal("0 * * * *"))
+			Expect(reqs[1].Enabled).To(BeFalse())
+		})
+
+		It("should report every invalid line", func() {
+			_, err := ParseCrontab(strings.NewReader("@reboot /bin/start\n* * *\n61 * * * * echo bad\n"))
+			Expect(err).To(HaveOccurred())
+
+			var importErrs ImportErrors
+			Expect(err).To(BeAssignableToTypeOf(importErrs))
+			importErrs = err.(ImportErrors)
+			Expect(importErrs).To(HaveLen(3))
+			Expect(importErrs[0].Line).To(Equal(1))
+			Expect(importErrs[2].Line).To(Equal(3))
+		})
+
+		It("should read % as cron does", func() {
+			reqs, err := ParseCrontab(strings.NewReader(`0 * * * * date +\%F > /tmp/today
+0 0 * * * mail -s report root%it's done%bye
+`))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(reqs).To(HaveLen(2))
+
+			Expect(reqs[0].Command).To(Equal("date +%F > /tmp/today"))
+
+			// Text after the first unescaped % is the command's standard input
+			Expect(reqs[1].Name).To(Equal("mail -s report root"))
+			Expect(reqs[1].Command).To(Equal(`printf '%s\n' 'it'\''s done' 'bye' | (mail -s report root)`))
+		})
+	})
+
+	Describe("FormatCrontab", func() {
+		It("should round-trip through ParseCrontab", func() {
+			text := FormatCrontab([]CronJob{
+				{ID: "2", Name: "Zeta", Schedule: "*/5 * * * *", Command: "echo z", Enabled: true},
+				{ID: "1", Name: "Alpha", Schedule: "0 0 * * *", Command: "echo a", Enabled: false},
+				{ID: "3", Name: "Fast", Schedule: "*/30 * * * * *", Command: "echo fast", Enabled: true},
+			})
+
+			Expect(text).To(ContainSubstring("# unsupported schedule (seconds field): */30 * * * * * echo fast"))
+
+			reqs, err := ParseCrontab(strings.NewReader(text))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(reqs).To(HaveLen(2))
+			Expect(reqs[0]).To(Equal(CreateCronRequest{Name: "Alpha", Schedule: "0 0 * * *", Command: "echo a", Enabled: false}))
+			Expect(reqs[1]).To(Equal(CreateCronRequest{Name: "Zeta", Schedule: "*/5 * * * *", Command: "echo z", Enabled: true}))
+		})
+
+		It("should not carry attributes of unsupported jobs over to the next job", func() {
+			text := FormatCrontab([]CronJob{
+				{ID: "1", Name: "Fast", Schedule: "*/30 * * * * *", Command: "echo fast", Enabled: false},
+				{ID: "2", Name: "Once", RunAt: 1767225600, Command: "echo once", Enabled: false},
+			})
+			text += "0 * * * * echo hourly\n"
+
+			reqs, err := ParseCrontab(strings.NewReader(text))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(reqs).To(HaveLen(1))
+			Expect(reqs[0]).To(Equal(CreateCronRequest{Name: "echo hourly", Schedule: "0 * * * *", Command: "echo hourly", Enabled: true}))
+		})
+
+		It("should keep % in commands through ParseCrontab", func() {
+			jobs := []CronJob{
+				{ID: "1", Name: "Dated", Schedule: "0 * * * *", Command: "tar czf /backup/$(date +%F).tgz /data", Enabled: true},
+				{ID: "2", Name: "Piped", Schedule: "0 0 * * *", Command: `printf '%s\n' 'done' | (mail root)`, Enabled: true},
+			}
+			text := FormatCrontab(jobs)
+			Expect(text).To(ContainSubstring(`0 * * * * tar czf /backup/$(date +\%F).tgz /data`))
+
+			reqs, err := ParseCrontab(strings.NewReader(text))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(reqs).To(HaveLen(2))
+			Expect(reqs[0].Command).To(Equal(jobs[0].Command))
+			Expect(reqs[1].Command).To(Equal(jobs[1].Command))
+		})
+
+		It("should write commands cron cannot express as unsupported", func() {
+			text := FormatCrontab([]CronJob{
+				{ID: "1", Name: "Script", Schedule: "0 * * * *", Command: "cd /srv\n./deploy.sh", Enabled: true},
+				{ID: "2", Name: "Escaped", Schedule: "0 * * * *", Command: `echo 50\%`, Enabled: true},
+			})
+			text += "0 0 * * * echo daily\n"
+
+			Expect(text).To(ContainSubstring("# unsupported command (multiple lines): 0 * * * * cd /srv ./deploy.sh"))
+			Expect(text).To(ContainSubstring(`# unsupported command (backslash before %): 0 * * * * echo 50\%`))
+
+			reqs, err := ParseCrontab(strings.NewReader(text))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(reqs).To(HaveLen(1))
+			Expect(reqs[0]).To(Equal(CreateCronRequest{Name: "echo daily", Schedule: "0 0 * * *", Command: "echo daily", Enabled: true}))
+		})
+	})
+
+	Describe("Manager import", func() {
+		var (
+			tempDir string
+			manager *CronManager
+		)
+
+		BeforeEach(func() {
+			var err error
+			tempDir, err = os.MkdirTemp("", "cron-import-*")
+			Expect(err).ToNot(HaveOccurred())
+			manager, err = NewCronManager(filepath.Join(tempDir, "crons.json"), 100)
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		AfterEach(func() {
+			os.RemoveAll(tempDir)
+		})
+
+		It("should create all jobs and export them again", func() {
+			jobs, err := manager.Import([]CreateCronRequest{
+				{Name: "B", Schedule: "0 * * * *", Command: "echo b", Enabled: true, MaxRetries: 2, CatchUp: true},
+				{Name: "A", Schedule: "0 0 * * *", Command: "echo a"},
+			})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(jobs).To(HaveLen(2))
+
+			export := manager.Export()
+			Expect(export.Jobs).To(HaveLen(2))
+			Expect(export.Jobs[0].Name).To(Equal("A"))
+			Expect(export.Jobs[1].MaxRetries).To(Equal(2))
+			Expect(export.Jobs[1].CatchUp).To(BeTrue())
+			Expect(export.Jobs[0].CatchUp).To(BeFalse())
+
+			other, err := NewCronManager(filepath.Join(tempDir, "other.json"), 100)
+			Expect(err).ToNot(HaveOccurred())
+			reimported, err := other.Import(export.Jobs)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(reimported[1].CatchUp).To(BeTrue())
+		})
+
+		It("should keep chains through an export and import into another hub", func() {
+			extract, err := manager.Create(CreateCronRequest{Name: "Extract", Schedule: "0 1 * * *", Command: "extract", Enabled: true})
+			Expect(err).ToNot(HaveOccurred())
+			load, err := manager.Create(CreateCronRequest{Name: "Load", Command: "load", Enabled: true, RunAfter: []string{extract.ID}})
+			Expect(err).ToNot(HaveOccurred())
+			_, err = manager.Create(CreateCronRequest{Name: "Alert", Command: "alert", Enabled: true, RunAfter: []string{load.ID}})
+			Expect(err).ToNot(HaveOccurred())
+
+			export := manager.Export()
+			Expect(export.Jobs[0].Name).To(Equal("Alert"))
+			Expect(export.Jobs[0].RunAfter).To(Equal([]string{"#3"}))
+			Expect(export.Jobs[2].RunAfter).To(Equal([]string{"#2"}))
+
+			other, err := NewCronManager(filepath.Join(tempDir, "other.json"), 100)
+			Expect(err).ToNot(HaveOccurred())
+			imported, err := other.Import(export.Jobs)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(imported).To(HaveLen(3))
+			alert, extracted, loaded := imported[0], imported[1], imported[2]
+			Expect(extracted.Name).To(Equal("Extract"))
+			Expect(loaded.RunAfter).To(Equal([]string{extracted.ID}))
+			Expect(alert.RunAfter).To(Equal([]string{loaded.ID}))
+		})
+
+		It("should reject references outside the import and cycles within it", func() {
+			_, err := manager.Import([]CreateCronRequest{
+				{Name: "A", Command: "a", RunAfter: []string{"#2"}},
+			})
+			Expect(err).To(MatchError(ContainSubstring("does not refer to a job of the import")))
+
+			_, err = manager.Import([]CreateCronRequest{
+				{Name: "A", Command: "a", RunAfter: []string{"#2"}},
+				{Name: "B", Command: "b", RunAfter: []string{"#1"}},
+			})
+			Expect(err).To(MatchError(ContainSubstring("dependency cycle")))
+			Expect(manager.GetJobCount()).To(Equal(0))
+		})
+
+		It("should create nothing when any definition is invalid", func() {
+			_, err := manager.Import([]CreateCronRequest{
+				{Name: "Good", Schedule: "0 * * * *", Command: "echo ok"},
+				{Name: "Bad", Schedule: "nope", Command: "echo bad"},
+			})
+			Expect(err).To(MatchError(ContainSubstring("job 2")))
+			Expect(manager.GetJobCount()).To(Equal(0))
+		})
+	})
+})
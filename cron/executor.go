@@ -3,7 +3,9 @@ package cron
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"log"
 	"os"
 	"os/exec"
@@ -15,6 +17,36 @@ import (
 	"github.com/iwanhae/terminal-hub/terminal"
 )
 
+// ptyDrainTimeout is how long output left in a PTY is read after its shell exits
+const ptyDrainTimeout = time.Second
+
+// stopProcessGroup asks the processes in the group pgid to terminate and
+// kills those still running after grace
+func stopProcessGroup(pgid int, grace time.Duration) {
+	if err := terminateProcessGroup(pgid); err != nil {
+		return
+	}
+	time.AfterFunc(grace, func() {
+		_ = killProcessGroup(pgid)
+	})
+}
+
+// markTimedOut records that result's command ran out of time. Whatever it
+// exited with, the run counts as failed.
+func markTimedOut(result *CronExecutionResult, timeout time.Duration) {
+	result.TimedOut = true
+	if result.ExitCode == 0 {
+		result.ExitCode = -1
+	}
+	result.Error = fmt.Sprintf("Command timed out after %s", timeout)
+}
+
+// timedOut reports whether ctx, derived from parent with the job timeout,
+// ended because the timeout passed rather than because parent was cancelled
+func timedOut(ctx, parent context.Context) bool {
+	return errors.Is(ctx.Err(), context.DeadlineExceeded) && parent.Err() == nil
+}
+
 // CronExecutor handles the execution of cron jobs
 type CronExecutor struct {
 	config          CronExecutorConfig
@@ -23,6 +55,7 @@ type CronExecutor struct {
 	timeProvider    TimeProvider         // for testability
 	mockExecutor    *MockCommandExecutor // optional mock executor for tests
 	useMockExecutor bool                 // flag to use mock executor
+	runAs           *terminal.RunAs      // account commands run as, nil = the daemon's own
 }
 
 // CronExecutorOption is a functional option for configuring CronExecutor
@@ -65,28 +98,56 @@ func NewCronExecutorWithOptions(config CronExecutorConfig, opts ...CronExecutorO
 	return e
 }
 
+// SetRunAs makes commands started afterwards run as the given account
+func (e *CronExecutor) SetRunAs(runAs *terminal.RunAs) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.runAs = runAs
+}
+
 // Execute runs a cron job and returns the execution result
 func (e *CronExecutor) Execute(job *CronJob) (*CronExecutionResult, error) {
+	return e.ExecuteContext(context.Background(), job)
+}
+
+// ExecuteOptions customizes a single execution
+type ExecuteOptions struct {
+	ExecutionID string    // optional: pre-assigned execution ID
+	Output      io.Writer // optional: receives stdout/stderr as it is produced
+}
+
+// ExecuteContext runs a cron job that is cancelled when ctx is done
+func (e *CronExecutor) ExecuteContext(parent context.Context, job *CronJob) (*CronExecutionResult, error) {
+	return e.ExecuteWithOptions(parent, job, ExecuteOptions{})
+}
+
+// ExecuteWithOptions runs a cron job with a pre-assigned execution ID and/or live output writer
+func (e *CronExecutor) ExecuteWithOptions(parent context.Context, job *CronJob, opts ExecuteOptions) (*CronExecutionResult, error) {
 	// Acquire semaphore
 	select {
 	case e.semaphore <- struct{}{}:
 		defer func() { <-e.semaphore }()
 	case <-e.timeProvider.After(e.config.ExecutionTimeout):
 		return nil, fmt.Errorf("timeout waiting for execution slot (too many concurrent jobs)")
+	case <-parent.Done():
+		return nil, fmt.Errorf("execution cancelled before start: %w", parent.Err())
 	}
 
-	executionID := "exec_" + uuid.New().String()
+	executionID := opts.ExecutionID
+	if executionID == "" {
+		executionID = "exec_" + uuid.New().String()
+	}
 	startedAt := e.timeProvider.Now()
 
 	log.Printf("[Cron] Starting execution %s for job %s (%s)", executionID, job.ID, job.Name)
 
 	// Create context with timeout
-	ctx, cancel := context.WithTimeout(context.Background(), e.config.ExecutionTimeout)
+	ctx, cancel := context.WithTimeout(parent, e.JobTimeout(job))
 	defer cancel()
 
 	// Use mock executor if enabled
 	if e.useMockExecutor && e.mockExecutor != nil {
-		return e.executeWithMock(ctx, job, executionID, startedAt)
+		return e.executeWithMock(ctx, parent, job, executionID, startedAt, opts.Output)
 	}
 
 	// Prepare the command
@@ -96,6 +157,10 @@ func (e *CronExecutor) Execute(job *CronJob) (*CronExecutionResult, error) {
 	var stdout, stderr bytes.Buffer
 	cmd.Stdout = &stdout
 	cmd.Stderr = &stderr
+	if opts.Output != nil {
+		cmd.Stdout = io.MultiWriter(&stdout, opts.Output)
+		cmd.Stderr = io.MultiWriter(&stderr, opts.Output)
+	}
 
 	// Start the command
 	if err := cmd.Start(); err != nil {
@@ -111,26 +176,16 @@ func (e *CronExecutor) Execute(job *CronJob) (*CronExecutionResult, error) {
 		}, nil
 	}
 
+	// Move the command into its own cgroup if requested
+	if job.Limits != nil {
+		cleanup := terminal.ApplyCgroup("cron-"+executionID, cmd.Process.Pid, *job.Limits)
+		defer cleanup()
+	}
+
 	// Wait for command to complete
 	err := cmd.Wait()
 
 	finishedAt := e.timeProvider.Now()
-	output := stdout.String()
-	errOutput := stderr.String()
-
-	// Combine stdout and stderr for the output
-	if errOutput != "" {
-		if output != "" {
-			output += "\n" + errOutput
-		} else {
-			output = errOutput
-		}
-	}
-
-	// Truncate output if needed
-	if len(output) > e.config.MaxOutputSize {
-		output = output[:e.config.MaxOutputSize] + "\n... (output truncated)"
-	}
 
 	// Determine exit code
 	exitCode := 0
@@ -148,10 +203,12 @@ func (e *CronExecutor) Execute(job *CronJob) (*CronExecutionResult, error) {
 		StartedAt:   startedAt.Unix(),
 		FinishedAt:  finishedAt.Unix(),
 		ExitCode:    exitCode,
-		Output:      output,
 	}
+	e.setOutput(result, stdout.String(), stderr.String())
 
-	if exitCode != 0 {
+	if err != nil && timedOut(ctx, parent) {
+		markTimedOut(result, e.JobTimeout(job))
+	} else if exitCode != 0 {
 		result.Error = fmt.Sprintf("Command exited with code %d", exitCode)
 	}
 
@@ -161,11 +218,17 @@ func (e *CronExecutor) Execute(job *CronJob) (*CronExecutionResult, error) {
 	return result, nil
 }
 
-// executeWithMock runs the command using the mock executor
-func (e *CronExecutor) executeWithMock(ctx context.Context, job *CronJob, executionID string, startedAt time.Time) (*CronExecutionResult, error) {
-	stdout, stderr, exitCode, err := e.mockExecutor.Execute(ctx, job.Command, job.WorkingDirectory, job.EnvVars)
+// truncateOutput cuts output to limit bytes, noting that it was cut
+func truncateOutput(output string, limit int) string {
+	if len(output) > limit {
+		return output[:limit] + "\n... (output truncated)"
+	}
+	return output
+}
 
-	finishedAt := e.timeProvider.Now()
+// setOutput records a run's streams on result: each on its own, truncated
+// independently, and combined into Output with stderr after stdout
+func (e *CronExecutor) setOutput(result *CronExecutionResult, stdout, stderr string) {
 	output := stdout
 	if stderr != "" {
 		if output != "" {
@@ -175,21 +238,67 @@ func (e *CronExecutor) executeWithMock(ctx context.Context, job *CronJob, execut
 		}
 	}
 
-	// Truncate output if needed
-	if len(output) > e.config.MaxOutputSize {
-		output = output[:e.config.MaxOutputSize] + "\n... (output truncated)"
+	result.Output = truncateOutput(output, e.config.MaxOutputSize)
+	result.Stdout = truncateOutput(stdout, e.config.MaxOutputSize)
+	result.Stderr = truncateOutput(stderr, e.config.MaxOutputSize)
+}
+
+// JobTimeout returns the execution timeout for a job: its own override when
+// set and valid, otherwise the executor default
+func (e *CronExecutor) JobTimeout(job *CronJob) time.Duration {
+	if job.Timeout == "" {
+		return e.config.ExecutionTimeout
+	}
+
+	timeout, err := time.ParseDuration(job.Timeout)
+	if err != nil || timeout <= 0 {
+		return e.config.ExecutionTimeout
 	}
+	if e.config.MaxJobTimeout > 0 && timeout > e.config.MaxJobTimeout {
+		return e.config.MaxJobTimeout
+	}
+	return timeout
+}
+
+// ValidateTimeout checks a per-job timeout override against the configured maximum
+func (e *CronExecutor) ValidateTimeout(timeout string) error {
+	if timeout == "" {
+		return nil
+	}
+
+	parsed, err := time.ParseDuration(timeout)
+	if err != nil {
+		return fmt.Errorf("invalid timeout %q: %w", timeout, err)
+	}
+	if parsed <= 0 {
+		return fmt.Errorf("timeout must be positive")
+	}
+	if e.config.MaxJobTimeout > 0 && parsed > e.config.MaxJobTimeout {
+		return fmt.Errorf("timeout %s exceeds the maximum of %s", parsed, e.config.MaxJobTimeout)
+	}
+	return nil
+}
 
+// executeWithMock runs the command using the mock executor
+func (e *CronExecutor) executeWithMock(ctx, parent context.Context, job *CronJob, executionID string, startedAt time.Time, live io.Writer) (*CronExecutionResult, error) {
+	stdout, stderr, exitCode, err := e.mockExecutor.Execute(ctx, job.Command, job.WorkingDirectory, job.EnvVars)
+	if live != nil {
+		_, _ = io.WriteString(live, stdout+stderr)
+	}
+
+	finishedAt := e.timeProvider.Now()
 	result := &CronExecutionResult{
 		JobID:       job.ID,
 		ExecutionID: executionID,
 		StartedAt:   startedAt.Unix(),
 		FinishedAt:  finishedAt.Unix(),
 		ExitCode:    exitCode,
-		Output:      output,
 	}
+	e.setOutput(result, stdout, stderr)
 
-	if err != nil {
+	if err != nil && timedOut(ctx, parent) {
+		markTimedOut(result, e.JobTimeout(job))
+	} else if err != nil {
 		result.Error = err.Error()
 		result.ExitCode = -1
 	} else if exitCode != 0 {
@@ -212,7 +321,11 @@ func (e *CronExecutor) buildCommand(ctx context.Context, job *CronJob) *exec.Cmd
 
 	// Build the command with shell
 	// We use shell -c to execute the command string
-	cmd := exec.CommandContext(ctx, shell, "-c", job.Command)
+	name, args := shell, []string{"-c", job.Command}
+	if job.Limits != nil {
+		name, args = job.Limits.WrapCommand(name, args...)
+	}
+	cmd := exec.CommandContext(ctx, name, args...)
 
 	// Set working directory
 	if job.WorkingDirectory != "" {
@@ -222,9 +335,34 @@ func (e *CronExecutor) buildCommand(ctx context.Context, job *CronJob) *exec.Cmd
 	// Set environment variables
 	cmd.Env = e.buildEnvVars(job.EnvVars)
 
+	e.mu.Lock()
+	runAs := e.runAs
+	e.mu.Unlock()
+	runAs.Apply(cmd)
+
+	// Stop everything the command started, not just the shell, when it is
+	// cancelled or times out. WaitDelay bounds the wait for processes that
+	// left the group but still hold its output open.
+	setProcessGroup(cmd)
+	grace := e.config.KillGracePeriod
+	cmd.Cancel = func() error {
+		stopProcessGroup(cmd.Process.Pid, grace)
+		return nil
+	}
+	cmd.WaitDelay = grace + time.Second
+
 	return cmd
 }
 
+// normalizeLimits copies resource limits, dropping empty ones so they are not persisted
+func normalizeLimits(limits *terminal.ResourceLimits) *terminal.ResourceLimits {
+	if limits == nil || limits.IsZero() {
+		return nil
+	}
+	copied := *limits
+	return &copied
+}
+
 // buildEnvVars builds the environment variables for the command
 func (e *CronExecutor) buildEnvVars(customVars map[string]string) []string {
 	env := os.Environ()
@@ -271,12 +409,15 @@ func (e *CronExecutor) UpdateJobMetadata(job *CronJob, result *CronExecutionResu
 		job.Metadata.LastRunError = ""
 	} else {
 		job.Metadata.LastRunStatus = "failed"
+		if result.TimedOut {
+			job.Metadata.LastRunStatus = "timeout"
+		}
 		job.Metadata.LastRunOutput = result.Output
 		job.Metadata.LastRunError = result.Error
 		job.Metadata.FailureCount++
 	}
 
-	job.Metadata.NextRunAt = nextRun.Unix()
+	job.Metadata.NextRunAt = unixOrZero(nextRun)
 	job.Metadata.UpdatedAt = e.timeProvider.Now().Unix()
 }
 
@@ -320,6 +461,16 @@ func GetCronExecutorConfigFromEnv() CronExecutorConfig {
 		config.MaxConcurrent = maxConcurrent
 	}
 
+	// Max per-job timeout override (default: 24h)
+	if maxTimeout := getEnvDuration("TERMINAL_HUB_CRON_MAX_TIMEOUT"); maxTimeout > 0 {
+		config.MaxJobTimeout = maxTimeout
+	}
+
+	// Grace between SIGTERM and SIGKILL for timed-out runs (default: 10s)
+	if grace := getEnvDuration("TERMINAL_HUB_CRON_KILL_GRACE_PERIOD"); grace > 0 {
+		config.KillGracePeriod = grace
+	}
+
 	return config
 }
 
@@ -331,23 +482,39 @@ func NewCronExecutorWithEnv() *CronExecutor {
 // ExecuteInPTY runs a cron job using a PTY (for interactive commands)
 // This is an alternative execution method for jobs that require a terminal
 func (e *CronExecutor) ExecuteInPTY(job *CronJob, ptyService terminal.PTYService) (*CronExecutionResult, error) {
+	return e.ExecuteInPTYWithOptions(context.Background(), job, ptyService, ExecuteOptions{})
+}
+
+// ExecuteInPTYWithOptions runs a cron job in a PTY that is cancelled when ctx
+// is done, with a pre-assigned execution ID and/or live output writer
+func (e *CronExecutor) ExecuteInPTYWithOptions(parent context.Context, job *CronJob, ptyService terminal.PTYService, opts ExecuteOptions) (*CronExecutionResult, error) {
 	// Acquire semaphore
 	select {
 	case e.semaphore <- struct{}{}:
 		defer func() { <-e.semaphore }()
 	case <-e.timeProvider.After(e.config.ExecutionTimeout):
 		return nil, fmt.Errorf("timeout waiting for execution slot (too many concurrent jobs)")
+	case <-parent.Done():
+		return nil, fmt.Errorf("execution cancelled before start: %w", parent.Err())
 	}
 
-	executionID := "exec_" + uuid.New().String()
+	executionID := opts.ExecutionID
+	if executionID == "" {
+		executionID = "exec_" + uuid.New().String()
+	}
 	startedAt := e.timeProvider.Now()
 
 	log.Printf("[Cron] Starting PTY execution %s for job %s (%s)", executionID, job.ID, job.Name)
 
 	// Create context with timeout
-	ctx, cancel := context.WithTimeout(context.Background(), e.config.ExecutionTimeout)
+	ctx, cancel := context.WithTimeout(parent, e.JobTimeout(job))
 	defer cancel()
 
+	// Use mock executor if enabled
+	if e.useMockExecutor && e.mockExecutor != nil {
+		return e.executeWithMock(ctx, parent, job, executionID, startedAt, opts.Output)
+	}
+
 	// Prepare shell
 	shell := job.Shell
 	if shell == "" {
@@ -398,6 +565,9 @@ func (e *CronExecutor) ExecuteInPTY(job *CronJob, ptyService terminal.PTYService
 		for {
 			n, err := ptyFile.Read(buffer)
 			if n > 0 {
+				if opts.Output != nil {
+					_, _ = opts.Output.Write(buffer[:n])
+				}
 				output = append(output, buffer[:n]...)
 				if len(output) >= e.config.MaxOutputSize {
 					return
@@ -418,11 +588,18 @@ func (e *CronExecutor) ExecuteInPTY(job *CronJob, ptyService terminal.PTYService
 	var waitErr error
 	select {
 	case waitErr = <-waitDone:
-		// Process exited; close PTY to unblock reader, then wait for it
+		// Process exited; read the output it left in the PTY, unless a
+		// process it started keeps the terminal open, then close the PTY to
+		// unblock the reader
+		select {
+		case <-readDone:
+		case <-time.After(ptyDrainTimeout):
+		}
 		ptyFile.Close()
 		<-readDone
 	case <-ctx.Done():
-		cmd.Process.Kill()
+		// The shell leads the PTY's session, so its group holds the job
+		stopProcessGroup(cmd.Process.Pid, e.config.KillGracePeriod)
 		ptyFile.Close()
 		<-readDone
 		waitErr = <-waitDone
@@ -430,11 +607,8 @@ func (e *CronExecutor) ExecuteInPTY(job *CronJob, ptyService terminal.PTYService
 
 	finishedAt := e.timeProvider.Now()
 
-	// Truncate output if needed
-	outputStr := string(output)
-	if len(outputStr) > e.config.MaxOutputSize {
-		outputStr = outputStr[:e.config.MaxOutputSize] + "\n... (output truncated)"
-	}
+	// Truncate output if needed; the PTY merges stdout and stderr
+	outputStr := truncateOutput(string(output), e.config.MaxOutputSize)
 
 	// Determine exit code
 	exitCode := 0
@@ -455,7 +629,9 @@ func (e *CronExecutor) ExecuteInPTY(job *CronJob, ptyService terminal.PTYService
 		Output:      outputStr,
 	}
 
-	if exitCode != 0 {
+	if waitErr != nil && timedOut(ctx, parent) {
+		markTimedOut(result, e.JobTimeout(job))
+	} else if exitCode != 0 {
 		result.Error = fmt.Sprintf("Command exited with code %d", exitCode)
 	}
 
@@ -87,6 +87,48 @@ var _ = Describe("CronExecutor with Mock", func() {
 				Expect(elapsed).To(BeNumerically("<", 50*time.Millisecond))
 			})
 
+			It("should honor a shorter per-job timeout (mocked)", func() {
+				mockExec.SetDefaultResult(MockCommandResult{
+					Stdout: "should not see this",
+					Delay:  300 * time.Millisecond,
+				})
+
+				job := &CronJob{
+					ID:       "short-timeout-job",
+					Command:  "sleep 1",
+					Schedule: "* * * * *",
+					Timeout:  "50ms",
+				}
+
+				start := time.Now()
+				result, err := executor.Execute(job)
+				elapsed := time.Since(start)
+
+				Expect(err).ToNot(HaveOccurred())
+				Expect(result.ExitCode).To(Equal(-1))
+				Expect(elapsed).To(BeNumerically("<", 200*time.Millisecond))
+			})
+
+			It("should honor a longer per-job timeout (mocked)", func() {
+				mockExec.SetDefaultResult(MockCommandResult{
+					Stdout: "slow output",
+					Delay:  700 * time.Millisecond, // Longer than the executor default
+				})
+
+				job := &CronJob{
+					ID:       "long-timeout-job",
+					Command:  "sleep 1",
+					Schedule: "* * * * *",
+					Timeout:  "2s",
+				}
+
+				result, err := executor.Execute(job)
+
+				Expect(err).ToNot(HaveOccurred())
+				Expect(result.ExitCode).To(Equal(0))
+				Expect(result.Output).To(Equal("slow output"))
+			})
+
 			It("should handle command failure (mocked)", func() {
 				mockExec.SetDefaultResult(MockCommandResult{
 					Stderr:   "error message",
@@ -269,3 +311,34 @@ var _ = Describe("Mock Command Executor", func() {
 		Expect(exitCode).To(Equal(0)) // Back to default
 	})
 })
+
+var _ = Describe("Per-job timeout", func() {
+	var executor *CronExecutor
+
+	BeforeEach(func() {
+		executor = NewCronExecutor(CronExecutorConfig{
+			MaxOutputSize:    1024,
+			ExecutionTimeout: time.Minute,
+			MaxConcurrent:    1,
+			MaxJobTimeout:    time.Hour,
+		})
+	})
+
+	It("should fall back to the executor default", func() {
+		Expect(executor.JobTimeout(&CronJob{})).To(Equal(time.Minute))
+		Expect(executor.JobTimeout(&CronJob{Timeout: "invalid"})).To(Equal(time.Minute))
+	})
+
+	It("should use and cap the job override", func() {
+		Expect(executor.JobTimeout(&CronJob{Timeout: "90s"})).To(Equal(90 * time.Second))
+		Expect(executor.JobTimeout(&CronJob{Timeout: "5h"})).To(Equal(time.Hour))
+	})
+
+	It("should validate overrides against the configured maximum", func() {
+		Expect(executor.ValidateTimeout("")).To(Succeed())
+		Expect(executor.ValidateTimeout("30m")).To(Succeed())
+		Expect(executor.ValidateTimeout("2h")).To(MatchError(ContainSubstring("exceeds the maximum")))
+		Expect(executor.ValidateTimeout("0s")).ToNot(Succeed())
+		Expect(executor.ValidateTimeout("later")).ToNot(Succeed())
+	})
+})
@@ -3,7 +3,12 @@ package cron
 import (
 	"os"
 	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
 	"sync"
+	"syscall"
 	"time"
 
 	"github.com/iwanhae/terminal-hub/terminal"
@@ -128,6 +133,8 @@ var _ = Describe("CronExecutor", func() {
 				Expect(err).ToNot(HaveOccurred())
 				Expect(result.Output).To(ContainSubstring("out"))
 				Expect(result.Output).To(ContainSubstring("err"))
+				Expect(result.Stdout).To(Equal("out\n"))
+				Expect(result.Stderr).To(Equal("err\n"))
 			})
 
 			It("should handle commands with pipes", func() {
@@ -196,6 +203,33 @@ var _ = Describe("CronExecutor", func() {
 			})
 		})
 
+		Context("with resource limits", func() {
+			It("should run the command under the job's ulimits", func() {
+				job.Command = "ulimit -n"
+				job.Limits = &terminal.ResourceLimits{MaxOpenFiles: 128}
+				result, err := executor.Execute(job)
+
+				Expect(err).ToNot(HaveOccurred())
+				Expect(result.ExitCode).To(Equal(0))
+				Expect(strings.TrimSpace(result.Output)).To(Equal("128"))
+			})
+		})
+
+		Context("with a run-as account", func() {
+			It("should run the command as that account", func() {
+				if os.Geteuid() != 0 {
+					Skip("switching users requires root")
+				}
+				executor.SetRunAs(&terminal.RunAs{UID: 54321, GID: 54322, Home: os.TempDir()})
+				job.Command = "id -u; pwd"
+				result, err := executor.Execute(job)
+
+				Expect(err).ToNot(HaveOccurred())
+				Expect(result.ExitCode).To(Equal(0))
+				Expect(strings.Fields(result.Output)).To(Equal([]string{"54321", os.TempDir()}))
+			})
+		})
+
 		Context("with output truncation", func() {
 			It("should truncate large output", func() {
 				job.Command = "python3 -c \"print('x' * 100000)\""
@@ -211,6 +245,21 @@ var _ = Describe("CronExecutor", func() {
 				Expect(result.Output).To(ContainSubstring("truncated"))
 			})
 
+			It("should truncate stdout and stderr independently", func() {
+				job.Command = "python3 -c \"import sys; print('x' * 100000); sys.stderr.write('failed here')\""
+				truncExecutor := NewCronExecutor(CronExecutorConfig{
+					MaxOutputSize:    1024,
+					ExecutionTimeout: 10 * time.Second,
+					MaxConcurrent:    5,
+				})
+
+				result, _ := truncExecutor.Execute(job)
+				Expect(result.Stdout).To(ContainSubstring("truncated"))
+				Expect(result.Stderr).To(Equal("failed here"))
+				// The combined output loses stderr past the limit
+				Expect(result.Output).ToNot(ContainSubstring("failed here"))
+			})
+
 			It("should not truncate small output", func() {
 				job.Command = "echo 'small'"
 				config.MaxOutputSize = 1024
@@ -302,9 +351,50 @@ var _ = Describe("CronExecutor", func() {
 
 				Expect(err).ToNot(HaveOccurred())
 				Expect(result.ExitCode).ToNot(Equal(0))
+				Expect(result.TimedOut).To(BeTrue())
+				Expect(result.Error).To(ContainSubstring("timed out after 100ms"))
 				Expect(elapsed).To(BeNumerically("<", 200*time.Millisecond)) // Fast!
 			})
 
+			It("should stop background children with the shell", func() {
+				if runtime.GOOS == "windows" {
+					Skip("process groups are not available on Windows")
+				}
+				pidFile := filepath.Join(GinkgoT().TempDir(), "child.pid")
+				timeoutExecutor := NewCronExecutor(CronExecutorConfig{
+					MaxOutputSize:    config.MaxOutputSize,
+					ExecutionTimeout: 300 * time.Millisecond,
+					MaxConcurrent:    1,
+					KillGracePeriod:  100 * time.Millisecond,
+				})
+				// The child ignores SIGTERM, so only the SIGKILL after the
+				// grace period stops it
+				job.Command = "sh -c 'trap \"\" TERM; sleep 30' & echo $! > " + pidFile + "; wait"
+
+				result, err := timeoutExecutor.Execute(job)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(result.TimedOut).To(BeTrue())
+				Expect(result.ExitCode).ToNot(Equal(0))
+
+				content, err := os.ReadFile(pidFile)
+				Expect(err).ToNot(HaveOccurred())
+				pid, err := strconv.Atoi(strings.TrimSpace(string(content)))
+				Expect(err).ToNot(HaveOccurred())
+				child, err := os.FindProcess(pid)
+				Expect(err).ToNot(HaveOccurred())
+				Eventually(func() error {
+					return child.Signal(syscall.Signal(0))
+				}, 2*time.Second, 20*time.Millisecond).Should(HaveOccurred())
+			})
+
+			It("should not report a failed command as timed out", func() {
+				job.Command = "exit 3"
+				result, err := executor.Execute(job)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(result.TimedOut).To(BeFalse())
+				Expect(result.Error).To(Equal("Command exited with code 3"))
+			})
+
 			It("should complete within timeout", func() {
 				job.Command = "sleep 0.1"
 
@@ -421,6 +511,15 @@ var _ = Describe("CronExecutor", func() {
 			Expect(job.Metadata.LastRunAt).ToNot(Equal(int64(0)))
 		})
 
+		It("should record a timed-out run as timeout", func() {
+			result := &CronExecutionResult{ExitCode: -1, TimedOut: true, Error: "Command timed out after 5m0s"}
+			executor.UpdateJobMetadata(job, result, time.Time{})
+
+			Expect(job.Metadata.LastRunStatus).To(Equal("timeout"))
+			Expect(job.Metadata.FailureCount).To(Equal(1))
+			Expect(job.Metadata.LastRunError).To(ContainSubstring("timed out"))
+		})
+
 		It("should increment total runs correctly", func() {
 			result1 := &CronExecutionResult{ExitCode: 0}
 			executor.UpdateJobMetadata(job, result1, time.Time{})
@@ -540,11 +639,13 @@ var _ = Describe("CronExecutor Concurrency Control", func() {
 				ExecutionTimeout: 500 * time.Millisecond,
 			})
 
-			// Fill the slot with a slow job
+			// Fill the slot with a slow job, which outlives the wait for
+			// the slot as its own timeout is longer
 			blocker := &CronJob{
 				ID:       "blocker",
 				Command:  "sleep 2",
 				Schedule: "* * * * *",
+				Timeout:  "2s",
 			}
 			go realExecutor.Execute(blocker)
 
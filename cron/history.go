@@ -0,0 +1,245 @@
+package cron
+
+import (
+	"fmt"
+)
+
+// History query limits
+const (
+	DefaultHistoryLimit = 50
+	MaxHistoryLimit     = 1000
+)
+
+// Execution status filters for HistoryQuery
+const (
+	HistoryStatusSuccess = "success"
+	HistoryStatusFailed  = "failed" // timed-out runs included
+	HistoryStatusTimeout = "timeout"
+)
+
+// HistoryQuery selects a page of execution history
+type HistoryQuery struct {
+	JobID  string // empty for all jobs
+	Status string // "success", "failed", "timeout" or empty for any
+	Since  int64  // unix timestamp, inclusive lower bound on StartedAt (0 = unbounded)
+	Until  int64  // unix timestamp, inclusive upper bound on StartedAt (0 = unbounded)
+	Limit  int
+	Offset int
+}
+
+// Validate checks the query and fills in the default limit
+func (q *HistoryQuery) Validate() error {
+	switch q.Status {
+	case "", HistoryStatusSuccess, HistoryStatusFailed, HistoryStatusTimeout:
+	default:
+		return fmt.Errorf("invalid status %q: must be %q, %q or %q", q.Status, HistoryStatusSuccess, HistoryStatusFailed, HistoryStatusTimeout)
+	}
+	if q.Limit == 0 {
+		q.Limit = DefaultHistoryLimit
+	}
+	if q.Limit < 0 || q.Limit > MaxHistoryLimit {
+		return fmt.Errorf("limit must be between 1 and %d", MaxHistoryLimit)
+	}
+	if q.Offset < 0 {
+		return fmt.Errorf("offset must not be negative")
+	}
+	if q.Since > 0 && q.Until > 0 && q.Since > q.Until {
+		return fmt.Errorf("since must not be after until")
+	}
+	return nil
+}
+
+// matches reports whether an execution passes the status and time filters
+func (q *HistoryQuery) matches(exec *CronExecutionResult) bool {
+	switch q.Status {
+	case HistoryStatusSuccess:
+		if exec.ExitCode != 0 {
+			return false
+		}
+	case HistoryStatusFailed:
+		if exec.ExitCode == 0 {
+			return false
+		}
+	case HistoryStatusTimeout:
+		if !exec.TimedOut {
+			return false
+		}
+	}
+	if q.Since > 0 && exec.StartedAt < q.Since {
+		return false
+	}
+	if q.Until > 0 && exec.StartedAt > q.Until {
+		return false
+	}
+	return true
+}
+
+// executionStore keeps a bounded, append-only execution history indexed by
+// job and execution ID. Entries are addressed by a monotonically increasing
+// sequence number so per-job lookups never scan other jobs' executions.
+type executionStore struct {
+	maxSize  int
+	entries  []CronExecutionResult // oldest first; entries[i] has sequence firstSeq+i
+	firstSeq uint64
+	byJob    map[string][]uint64 // job id -> sequences, oldest first
+	byID     map[string]uint64   // execution id -> sequence
+}
+
+// newExecutionStore creates an empty store holding at most maxSize entries
+func newExecutionStore(maxSize int) *executionStore {
+	return &executionStore{
+		maxSize: maxSize,
+		entries: make([]CronExecutionResult, 0, maxSize),
+		byJob:   make(map[string][]uint64),
+		byID:    make(map[string]uint64),
+	}
+}
+
+// Len returns the number of stored executions
+func (s *executionStore) Len() int {
+	return len(s.entries)
+}
+
+// Add appends an execution, evicting the oldest entries beyond maxSize
+func (s *executionStore) Add(exec CronExecutionResult) {
+	seq := s.firstSeq + uint64(len(s.entries))
+	s.entries = append(s.entries, exec)
+	s.byJob[exec.JobID] = append(s.byJob[exec.JobID], seq)
+	if exec.ExecutionID != "" {
+		s.byID[exec.ExecutionID] = seq
+	}
+
+	overflow := len(s.entries) - s.maxSize
+	if overflow <= 0 {
+		return
+	}
+	for i := 0; i < overflow; i++ {
+		s.evict(&s.entries[i], s.firstSeq+uint64(i))
+	}
+	s.entries = s.entries[overflow:]
+	s.firstSeq += uint64(overflow)
+}
+
+// Update replaces the stored execution with the same ID, reporting whether
+// it was still there
+func (s *executionStore) Update(exec CronExecutionResult) bool {
+	seq, ok := s.byID[exec.ExecutionID]
+	if !ok || exec.ExecutionID == "" {
+		return false
+	}
+	s.entries[seq-s.firstSeq] = exec
+	return true
+}
+
+// evict removes the indexes of the oldest entry
+func (s *executionStore) evict(exec *CronExecutionResult, seq uint64) {
+	if seqs := s.byJob[exec.JobID]; len(seqs) > 0 && seqs[0] == seq {
+		if len(seqs) == 1 {
+			delete(s.byJob, exec.JobID)
+		} else {
+			s.byJob[exec.JobID] = seqs[1:]
+		}
+	}
+	if s.byID[exec.ExecutionID] == seq {
+		delete(s.byID, exec.ExecutionID)
+	}
+}
+
+// All returns a copy of every stored execution, oldest first
+func (s *executionStore) All() []CronExecutionResult {
+	all := make([]CronExecutionResult, len(s.entries))
+	copy(all, s.entries)
+	return all
+}
+
+// ForJob returns a copy of a job's executions, oldest first
+func (s *executionStore) ForJob(jobID string) []CronExecutionResult {
+	seqs := s.byJob[jobID]
+	history := make([]CronExecutionResult, 0, len(seqs))
+	for _, seq := range seqs {
+		history = append(history, s.entries[seq-s.firstSeq])
+	}
+	return history
+}
+
+// Get returns an execution by ID
+func (s *executionStore) Get(executionID string) (CronExecutionResult, bool) {
+	seq, ok := s.byID[executionID]
+	if !ok {
+		return CronExecutionResult{}, false
+	}
+	return s.entries[seq-s.firstSeq], true
+}
+
+// Query returns a page of matching executions, newest first, and the total
+// number of matches
+func (s *executionStore) Query(q HistoryQuery) ([]CronExecutionResult, int) {
+	var seqs []uint64
+	if q.JobID != "" {
+		seqs = s.byJob[q.JobID]
+	}
+
+	count := len(s.entries)
+	if q.JobID != "" {
+		count = len(seqs)
+	}
+
+	page := make([]CronExecutionResult, 0)
+	total := 0
+	for i := count - 1; i >= 0; i-- {
+		var exec *CronExecutionResult
+		if q.JobID != "" {
+			exec = &s.entries[seqs[i]-s.firstSeq]
+		} else {
+			exec = &s.entries[i]
+		}
+
+		// Entries are recorded in finish order, so everything older than an
+		// execution that finished before the lower bound also started before it
+		if q.Since > 0 && exec.FinishedAt < q.Since {
+			break
+		}
+		if !q.matches(exec) {
+			continue
+		}
+
+		if total >= q.Offset && len(page) < q.Limit {
+			page = append(page, *exec)
+		}
+		total++
+	}
+	return page, total
+}
+
+// Stats tallies the executions that started at or after since. Skipped
+// overlapping ticks are counted apart from the runs that succeeded or failed.
+func (s *executionStore) Stats(since int64) CronRunStats {
+	var stats CronRunStats
+	for i := len(s.entries) - 1; i >= 0; i-- {
+		exec := &s.entries[i]
+		// As in Query, older entries all finished before this one
+		if exec.FinishedAt < since {
+			break
+		}
+		if exec.StartedAt < since {
+			continue
+		}
+		switch {
+		case exec.Overlap == OverlapSkipped:
+			stats.Skipped++
+			continue
+		case exec.ExitCode == 0:
+			stats.Succeeded++
+		default:
+			stats.Failed++
+			if exec.TimedOut {
+				stats.TimedOut++
+			}
+		}
+		stats.Total++
+	}
+	if stats.Total > 0 {
+		stats.SuccessRate = float64(stats.Succeeded) / float64(stats.Total)
+	}
+	return stats
+}
@@ -0,0 +1,98 @@
+package cron
+
+import (
+	"fmt"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Execution history store", func() {
+	var store *executionStore
+
+	record := func(jobID string, n int, exitCode int) CronExecutionResult {
+		return CronExecutionResult{
+			JobID:       jobID,
+			ExecutionID: fmt.Sprintf("%s-%d", jobID, n),
+			StartedAt:   int64(n * 10),
+			FinishedAt:  int64(n*10 + 1),
+			ExitCode:    exitCode,
+		}
+	}
+
+	executionIDs := func(executions []CronExecutionResult) []string {
+		ids := make([]string, 0, len(executions))
+		for _, exec := range executions {
+			ids = append(ids, exec.ExecutionID)
+		}
+		return ids
+	}
+
+	BeforeEach(func() {
+		store = newExecutionStore(5)
+	})
+
+	It("should index executions by job and ID", func() {
+		store.Add(record("a", 1, 0))
+		store.Add(record("b", 2, 0))
+		store.Add(record("a", 3, 1))
+
+		Expect(executionIDs(store.ForJob("a"))).To(Equal([]string{"a-1", "a-3"}))
+		Expect(executionIDs(store.ForJob("b"))).To(Equal([]string{"b-2"}))
+		exec, ok := store.Get("a-3")
+		Expect(ok).To(BeTrue())
+		Expect(exec.ExitCode).To(Equal(1))
+	})
+
+	It("should evict the oldest executions and their indexes", func() {
+		for i := 1; i <= 7; i++ {
+			jobID := "a"
+			if i%2 == 0 {
+				jobID = "b"
+			}
+			store.Add(record(jobID, i, 0))
+		}
+
+		Expect(store.Len()).To(Equal(5))
+		Expect(executionIDs(store.All())).To(Equal([]string{"a-3", "b-4", "a-5", "b-6", "a-7"}))
+		Expect(executionIDs(store.ForJob("a"))).To(Equal([]string{"a-3", "a-5", "a-7"}))
+		_, ok := store.Get("a-1")
+		Expect(ok).To(BeFalse())
+	})
+
+	It("should page results newest first", func() {
+		for i := 1; i <= 5; i++ {
+			store.Add(record("a", i, 0))
+		}
+
+		page, total := store.Query(HistoryQuery{JobID: "a", Limit: 2, Offset: 1})
+		Expect(total).To(Equal(5))
+		Expect(executionIDs(page)).To(Equal([]string{"a-4", "a-3"}))
+	})
+
+	It("should filter by status and time range", func() {
+		store.Add(record("a", 1, 1))
+		store.Add(record("b", 2, 0))
+		store.Add(record("a", 3, 1))
+		store.Add(record("a", 4, 0))
+		store.Add(record("b", 5, 2))
+
+		page, total := store.Query(HistoryQuery{Status: HistoryStatusFailed, Limit: 10})
+		Expect(total).To(Equal(3))
+		Expect(executionIDs(page)).To(Equal([]string{"b-5", "a-3", "a-1"}))
+
+		page, _ = store.Query(HistoryQuery{Since: 20, Until: 40, Limit: 10})
+		Expect(executionIDs(page)).To(Equal([]string{"a-4", "a-3", "b-2"}))
+	})
+
+	It("should validate queries", func() {
+		query := HistoryQuery{}
+		Expect(query.Validate()).To(Succeed())
+		Expect(query.Limit).To(Equal(DefaultHistoryLimit))
+
+		Expect((&HistoryQuery{Status: "running"}).Validate()).ToNot(Succeed())
+		Expect((&HistoryQuery{Limit: MaxHistoryLimit + 1}).Validate()).ToNot(Succeed())
+		Expect((&HistoryQuery{Offset: -1}).Validate()).ToNot(Succeed())
+		Expect((&HistoryQuery{Since: 10, Until: 5}).Validate()).ToNot(Succeed())
+	})
+})
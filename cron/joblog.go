@@ -0,0 +1,407 @@
+package cron
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Job log defaults
+const (
+	DefaultJobLogMaxSize  = 10 * 1024 * 1024 // rotate after 10MB
+	DefaultJobLogMaxFiles = 3                // rotated files kept per job
+)
+
+// JobLogConfig configures per-job output log files
+type JobLogConfig struct {
+	Dir      string // directory holding <job id>.log files
+	MaxSize  int64  // size in bytes after which the current file is rotated
+	MaxFiles int    // rotated files kept besides the current one
+}
+
+// JobLogFile describes one log file of a job
+type JobLogFile struct {
+	Name       string `json:"name"`
+	Size       int64  `json:"size"`
+	ModifiedAt int64  `json:"modified_at"` // unix timestamp
+}
+
+// JobLogs writes the full output of jobs with log_to_file enabled to
+// per-job files, rotating them by size. Executions of the same job share
+// one open file so concurrent runs rotate consistently.
+type JobLogs struct {
+	config JobLogConfig
+
+	mu    sync.Mutex
+	files map[string]*jobLogFile // job id -> open log file
+}
+
+type jobLogFile struct {
+	file *os.File
+	size int64
+	refs int
+}
+
+// NewJobLogs creates a job log writer using config, filling in defaults
+func NewJobLogs(config JobLogConfig) *JobLogs {
+	if config.MaxSize <= 0 {
+		config.MaxSize = DefaultJobLogMaxSize
+	}
+	if config.MaxFiles < 0 {
+		config.MaxFiles = 0
+	}
+	return &JobLogs{
+		config: config,
+		files:  make(map[string]*jobLogFile),
+	}
+}
+
+// logPath returns the path of a job's current log file
+func (l *JobLogs) logPath(jobID string) string {
+	return filepath.Join(l.config.Dir, jobID+".log")
+}
+
+// rotatedPath returns the path of a job's n-th rotated log file (1 = newest)
+func (l *JobLogs) rotatedPath(jobID string, n int) string {
+	return l.logPath(jobID) + "." + strconv.Itoa(n)
+}
+
+// Open starts logging one execution of a job. The returned writer must be
+// closed with the execution result.
+func (l *JobLogs) Open(jobID, executionID string, startedAt time.Time) (*JobLogWriter, error) {
+	if err := validateJobLogID(jobID); err != nil {
+		return nil, err
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	f, ok := l.files[jobID]
+	if !ok {
+		var err error
+		f, err = l.openLocked(jobID)
+		if err != nil {
+			return nil, err
+		}
+		l.files[jobID] = f
+	}
+	f.refs++
+
+	w := &JobLogWriter{logs: l, jobID: jobID, executionID: executionID}
+	header := fmt.Sprintf("=== execution %s started %s ===\n", executionID, startedAt.UTC().Format(time.RFC3339))
+	if err := l.writeLocked(jobID, f, []byte(header)); err != nil {
+		w.logs.releaseLocked(jobID, f)
+		return nil, err
+	}
+	return w, nil
+}
+
+// openLocked opens a job's current log file for appending
+func (l *JobLogs) openLocked(jobID string) (*jobLogFile, error) {
+	if err := os.MkdirAll(l.config.Dir, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create log directory: %w", err)
+	}
+	file, err := os.OpenFile(l.logPath(jobID), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open log file: %w", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	return &jobLogFile{file: file, size: info.Size()}, nil
+}
+
+// writeLocked appends to a job's log file, rotating it first if the write
+// would exceed the maximum size
+func (l *JobLogs) writeLocked(jobID string, f *jobLogFile, p []byte) error {
+	if f.size > 0 && f.size+int64(len(p)) > l.config.MaxSize {
+		if err := l.rotateLocked(jobID, f); err != nil {
+			return err
+		}
+	}
+	n, err := f.file.Write(p)
+	f.size += int64(n)
+	return err
+}
+
+// rotateLocked shifts <id>.log to <id>.log.1, <id>.log.1 to <id>.log.2 and so
+// on, dropping the oldest file, then reopens an empty current file
+func (l *JobLogs) rotateLocked(jobID string, f *jobLogFile) error {
+	if err := f.file.Close(); err != nil {
+		return err
+	}
+
+	current := l.logPath(jobID)
+	if l.config.MaxFiles == 0 {
+		if err := os.Remove(current); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	} else {
+		for n := l.config.MaxFiles - 1; n >= 1; n-- {
+			if err := os.Rename(l.rotatedPath(jobID, n), l.rotatedPath(jobID, n+1)); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+		}
+		if err := os.Rename(current, l.rotatedPath(jobID, 1)); err != nil {
+			return err
+		}
+	}
+
+	reopened, err := l.openLocked(jobID)
+	if err != nil {
+		return err
+	}
+	f.file = reopened.file
+	f.size = reopened.size
+	return nil
+}
+
+// releaseLocked drops a writer's reference, closing the file when unused
+func (l *JobLogs) releaseLocked(jobID string, f *jobLogFile) {
+	f.refs--
+	if f.refs <= 0 {
+		f.file.Close()
+		delete(l.files, jobID)
+	}
+}
+
+// List returns a job's log files, current file first
+func (l *JobLogs) List(jobID string) ([]JobLogFile, error) {
+	if err := validateJobLogID(jobID); err != nil {
+		return nil, err
+	}
+
+	entries, err := os.ReadDir(l.config.Dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []JobLogFile{}, nil
+		}
+		return nil, err
+	}
+
+	files := make([]JobLogFile, 0)
+	for _, entry := range entries {
+		if entry.IsDir() || !isJobLogName(jobID, entry.Name()) {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		files = append(files, JobLogFile{
+			Name:       entry.Name(),
+			Size:       info.Size(),
+			ModifiedAt: info.ModTime().Unix(),
+		})
+	}
+	sort.Slice(files, func(i, j int) bool {
+		return jobLogIndex(files[i].Name) < jobLogIndex(files[j].Name)
+	})
+	return files, nil
+}
+
+// OpenFile opens one of a job's log files for reading
+func (l *JobLogs) OpenFile(jobID, name string) (*os.File, error) {
+	if err := validateJobLogID(jobID); err != nil {
+		return nil, err
+	}
+	if !isJobLogName(jobID, name) {
+		return nil, errors.New("log file not found")
+	}
+	file, err := os.Open(filepath.Join(l.config.Dir, name))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, errors.New("log file not found")
+		}
+		return nil, err
+	}
+	return file, nil
+}
+
+// Remove deletes all log files of a job
+func (l *JobLogs) Remove(jobID string) error {
+	files, err := l.List(jobID)
+	if err != nil {
+		return err
+	}
+	for _, file := range files {
+		if err := os.Remove(filepath.Join(l.config.Dir, file.Name)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateJobLogID rejects job IDs that could escape the log directory
+func validateJobLogID(jobID string) error {
+	if jobID == "" || strings.ContainsAny(jobID, `/\`) || strings.HasPrefix(jobID, ".") {
+		return fmt.Errorf("invalid job id %q", jobID)
+	}
+	return nil
+}
+
+// isJobLogName reports whether name is <id>.log or <id>.log.<n>
+func isJobLogName(jobID, name string) bool {
+	rest, ok := strings.CutPrefix(name, jobID+".log")
+	if !ok {
+		return false
+	}
+	if rest == "" {
+		return true
+	}
+	n, err := strconv.Atoi(strings.TrimPrefix(rest, "."))
+	return strings.HasPrefix(rest, ".") && err == nil && n > 0
+}
+
+// jobLogIndex returns 0 for the current file and n for <id>.log.<n>
+func jobLogIndex(name string) int {
+	if i := strings.LastIndex(name, ".log."); i >= 0 {
+		n, _ := strconv.Atoi(name[i+len(".log."):])
+		return n
+	}
+	return 0
+}
+
+// JobLogWriter appends one execution's output to its job's log file
+type JobLogWriter struct {
+	logs        *JobLogs
+	jobID       string
+	executionID string
+	closed      bool
+}
+
+// Write appends output to the log file
+func (w *JobLogWriter) Write(p []byte) (int, error) {
+	w.logs.mu.Lock()
+	defer w.logs.mu.Unlock()
+
+	f, ok := w.logs.files[w.jobID]
+	if w.closed || !ok {
+		return len(p), nil
+	}
+	if err := w.logs.writeLocked(w.jobID, f, p); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Close writes a footer with the execution result and releases the file
+func (w *JobLogWriter) Close(result *CronExecutionResult) error {
+	w.logs.mu.Lock()
+	defer w.logs.mu.Unlock()
+
+	f, ok := w.logs.files[w.jobID]
+	if w.closed || !ok {
+		return nil
+	}
+	w.closed = true
+	defer w.logs.releaseLocked(w.jobID, f)
+
+	footer := fmt.Sprintf("\n=== execution %s finished", w.executionID)
+	if result != nil {
+		footer += fmt.Sprintf(" %s (exit code %d)",
+			time.Unix(result.FinishedAt, 0).UTC().Format(time.RFC3339), result.ExitCode)
+	}
+	return w.logs.writeLocked(w.jobID, f, []byte(footer+" ===\n"))
+}
+
+// GetJobLogConfigFromEnv returns the job log settings from environment
+// variables, defaulting to a cron-logs directory next to the cron file
+func GetJobLogConfigFromEnv(cronFilePath string) JobLogConfig {
+	config := JobLogConfig{
+		Dir:      filepath.Join(filepath.Dir(cronFilePath), "cron-logs"),
+		MaxSize:  DefaultJobLogMaxSize,
+		MaxFiles: DefaultJobLogMaxFiles,
+	}
+	if dir := os.Getenv("TERMINAL_HUB_CRON_LOG_DIR"); dir != "" {
+		config.Dir = dir
+	}
+	if size := getEnvInt("TERMINAL_HUB_CRON_LOG_MAX_SIZE"); size > 0 {
+		config.MaxSize = int64(size)
+	}
+	if val := os.Getenv("TERMINAL_HUB_CRON_LOG_MAX_FILES"); val != "" {
+		if files, err := strconv.Atoi(val); err == nil && files >= 0 {
+			config.MaxFiles = files
+		}
+	}
+	return config
+}
+
+// SetJobLogs configures where jobs with log_to_file write their output.
+// Without it, log_to_file has no effect.
+func (m *CronManager) SetJobLogs(logs *JobLogs) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.jobLogs = logs
+}
+
+// executionOutput returns the writer receiving an execution's live output,
+// teeing it into the job's log file when log_to_file is enabled, and a
+// function to call with the result once the execution finishes
+func (m *CronManager) executionOutput(job *CronJob, live *LiveExecution) (io.Writer, func(*CronExecutionResult)) {
+	m.mu.RLock()
+	logToFile, logs := job.LogToFile, m.jobLogs
+	guardErr := m.checkWriteGuardLocked()
+	m.mu.RUnlock()
+
+	if !logToFile || logs == nil {
+		return live, func(*CronExecutionResult) {}
+	}
+	if guardErr != nil {
+		log.Printf("[Cron] Not writing log file for job %s: %v", job.ID, guardErr)
+		return live, func(*CronExecutionResult) {}
+	}
+
+	logWriter, err := logs.Open(job.ID, live.ExecutionID, time.Unix(live.StartedAt, 0))
+	if err != nil {
+		log.Printf("[Cron] Failed to open log file for job %s: %v", job.ID, err)
+		return live, func(*CronExecutionResult) {}
+	}
+	return io.MultiWriter(live, logWriter), func(result *CronExecutionResult) {
+		if err := logWriter.Close(result); err != nil {
+			log.Printf("[Cron] Failed to write log file for job %s: %v", job.ID, err)
+		}
+	}
+}
+
+// ListJobLogs returns the log files of a job
+func (m *CronManager) ListJobLogs(jobID string) ([]JobLogFile, error) {
+	logs, err := m.jobLogsFor(jobID)
+	if err != nil {
+		return nil, err
+	}
+	return logs.List(jobID)
+}
+
+// OpenJobLog opens one of a job's log files for reading
+func (m *CronManager) OpenJobLog(jobID, name string) (*os.File, error) {
+	logs, err := m.jobLogsFor(jobID)
+	if err != nil {
+		return nil, err
+	}
+	return logs.OpenFile(jobID, name)
+}
+
+// jobLogsFor checks that a job exists and log files are configured
+func (m *CronManager) jobLogsFor(jobID string) (*JobLogs, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if _, ok := m.jobs[jobID]; !ok {
+		return nil, errors.New("job not found")
+	}
+	if m.jobLogs == nil {
+		return nil, errors.New("job log files are not configured")
+	}
+	return m.jobLogs, nil
+}
@@ -0,0 +1,110 @@
+package cron
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Job log files", func() {
+	var (
+		tempDir string
+		logs    *JobLogs
+	)
+
+	BeforeEach(func() {
+		var err error
+		tempDir, err = os.MkdirTemp("", "cron-joblog-*")
+		Expect(err).ToNot(HaveOccurred())
+		logs = NewJobLogs(JobLogConfig{Dir: tempDir, MaxSize: 200, MaxFiles: 2})
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(tempDir)
+	})
+
+	readLog := func(name string) string {
+		file, err := logs.OpenFile("job1", name)
+		Expect(err).ToNot(HaveOccurred())
+		defer file.Close()
+		data, err := io.ReadAll(file)
+		Expect(err).ToNot(HaveOccurred())
+		return string(data)
+	}
+
+	It("should frame each execution with a header and footer", func() {
+		w, err := logs.Open("job1", "exec_1", time.Now())
+		Expect(err).ToNot(HaveOccurred())
+		_, err = w.Write([]byte("hello\n"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(w.Close(&CronExecutionResult{ExitCode: 3, FinishedAt: time.Now().Unix()})).To(Succeed())
+
+		content := readLog("job1.log")
+		Expect(content).To(ContainSubstring("=== execution exec_1 started"))
+		Expect(content).To(ContainSubstring("hello\n"))
+		Expect(content).To(ContainSubstring("(exit code 3) ==="))
+	})
+
+	It("should rotate by size and keep at most MaxFiles rotated files", func() {
+		for i := 0; i < 6; i++ {
+			w, err := logs.Open("job1", "exec", time.Now())
+			Expect(err).ToNot(HaveOccurred())
+			_, err = w.Write([]byte(strings.Repeat("x", 100) + "\n"))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(w.Close(nil)).To(Succeed())
+		}
+
+		files, err := logs.List("job1")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(files).To(HaveLen(3))
+		Expect(files[0].Name).To(Equal("job1.log"))
+		Expect(files[1].Name).To(Equal("job1.log.1"))
+		Expect(files[2].Name).To(Equal("job1.log.2"))
+		for _, file := range files {
+			Expect(file.Size).To(BeNumerically("<=", 200))
+		}
+	})
+
+	It("should only open the job's own log files", func() {
+		Expect(os.WriteFile(filepath.Join(tempDir, "other.log"), []byte("secret"), 0600)).To(Succeed())
+
+		_, err := logs.OpenFile("job1", "other.log")
+		Expect(err).To(MatchError("log file not found"))
+		_, err = logs.OpenFile("job1", "job1.log.x")
+		Expect(err).To(MatchError("log file not found"))
+		_, err = logs.Open("../job1", "exec", time.Now())
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should tee execution output of log_to_file jobs", func() {
+		manager, err := NewCronManager(filepath.Join(tempDir, "crons.json"), 100)
+		Expect(err).ToNot(HaveOccurred())
+		mockExec := NewMockCommandExecutor()
+		mockExec.SetResult("echo full", MockCommandResult{Stdout: "full output\n"})
+		manager.executor = NewCronExecutorWithOptions(DefaultCronExecutorConfig(), WithMockExecutor(mockExec))
+		manager.SetJobLogs(NewJobLogs(JobLogConfig{Dir: filepath.Join(tempDir, "logs")}))
+
+		job, err := manager.Create(CreateCronRequest{Name: "Logged", Schedule: "0 0 1 1 *", Command: "echo full", LogToFile: true})
+		Expect(err).ToNot(HaveOccurred())
+		manager.executeJob(job.ID)
+
+		files, err := manager.ListJobLogs(job.ID)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(files).To(HaveLen(1))
+
+		file, err := manager.OpenJobLog(job.ID, files[0].Name)
+		Expect(err).ToNot(HaveOccurred())
+		data, err := io.ReadAll(file)
+		file.Close()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(data)).To(ContainSubstring("full output\n"))
+
+		Expect(manager.Delete(job.ID)).To(Succeed())
+		Expect(filepath.Join(tempDir, "logs", job.ID+".log")).ToNot(BeAnExistingFile())
+	})
+})
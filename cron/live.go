@@ -0,0 +1,116 @@
+package cron
+
+import (
+	"sync"
+)
+
+// liveSubscriberBuffer is the number of output chunks buffered per subscriber
+// before it is considered too slow and disconnected
+const liveSubscriberBuffer = 64
+
+// LiveExecution buffers the output of an in-flight execution and fans it out
+// to streaming subscribers
+type LiveExecution struct {
+	JobID       string `json:"job_id"`
+	ExecutionID string `json:"execution_id"`
+	StartedAt   int64  `json:"started_at"`
+
+	mu          sync.Mutex
+	output      []byte
+	maxSize     int
+	subscribers map[chan []byte]struct{}
+	done        chan struct{}
+	finished    bool
+}
+
+// NewLiveExecution creates a live execution that keeps up to maxSize bytes of backlog
+func NewLiveExecution(jobID, executionID string, startedAt int64, maxSize int) *LiveExecution {
+	return &LiveExecution{
+		JobID:       jobID,
+		ExecutionID: executionID,
+		StartedAt:   startedAt,
+		maxSize:     maxSize,
+		subscribers: make(map[chan []byte]struct{}),
+		done:        make(chan struct{}),
+	}
+}
+
+// Write appends output to the backlog and forwards it to subscribers
+func (l *LiveExecution) Write(p []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.finished {
+		return len(p), nil
+	}
+
+	if room := l.maxSize - len(l.output); room > 0 {
+		if len(p) <= room {
+			l.output = append(l.output, p...)
+		} else {
+			l.output = append(l.output, p[:room]...)
+		}
+	}
+
+	chunk := make([]byte, len(p))
+	copy(chunk, p)
+	for ch := range l.subscribers {
+		select {
+		case ch <- chunk:
+		default:
+			// Slow subscriber: disconnect it rather than block the command
+			delete(l.subscribers, ch)
+			close(ch)
+		}
+	}
+
+	return len(p), nil
+}
+
+// Subscribe returns the output written so far and a channel receiving new output.
+// The channel is closed when the execution finishes or the subscriber falls behind.
+func (l *LiveExecution) Subscribe() ([]byte, <-chan []byte, func()) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	backlog := make([]byte, len(l.output))
+	copy(backlog, l.output)
+
+	ch := make(chan []byte, liveSubscriberBuffer)
+	if l.finished {
+		close(ch)
+		return backlog, ch, func() {}
+	}
+	l.subscribers[ch] = struct{}{}
+
+	unsubscribe := func() {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		if _, ok := l.subscribers[ch]; ok {
+			delete(l.subscribers, ch)
+			close(ch)
+		}
+	}
+	return backlog, ch, unsubscribe
+}
+
+// Done returns a channel that is closed when the execution finishes
+func (l *LiveExecution) Done() <-chan struct{} {
+	return l.done
+}
+
+// finish marks the execution as complete and closes all subscriber channels
+func (l *LiveExecution) finish() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.finished {
+		return
+	}
+	l.finished = true
+	for ch := range l.subscribers {
+		close(ch)
+	}
+	l.subscribers = nil
+	close(l.done)
+}
@@ -0,0 +1,57 @@
+package cron
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("LiveExecution", func() {
+	var live *LiveExecution
+
+	BeforeEach(func() {
+		live = NewLiveExecution("job", "exec_1", 1, 8)
+	})
+
+	It("should replay the backlog and forward new output", func() {
+		live.Write([]byte("hello "))
+
+		backlog, output, unsubscribe := live.Subscribe()
+		defer unsubscribe()
+		Expect(string(backlog)).To(Equal("hello "))
+
+		live.Write([]byte("world"))
+		Expect(output).To(Receive(Equal([]byte("world"))))
+	})
+
+	It("should cap the backlog at the maximum size", func() {
+		live.Write([]byte("0123456789"))
+
+		backlog, _, unsubscribe := live.Subscribe()
+		defer unsubscribe()
+		Expect(string(backlog)).To(Equal("01234567"))
+	})
+
+	It("should close subscriber channels when finished", func() {
+		_, output, unsubscribe := live.Subscribe()
+		defer unsubscribe()
+
+		live.finish()
+		Eventually(output).Should(BeClosed())
+		Expect(live.Done()).To(BeClosed())
+
+		_, late, _ := live.Subscribe()
+		Expect(late).To(BeClosed())
+	})
+
+	It("should disconnect subscribers that fall behind", func() {
+		_, output, unsubscribe := live.Subscribe()
+		defer unsubscribe()
+
+		for i := 0; i <= liveSubscriberBuffer; i++ {
+			live.Write([]byte("x"))
+		}
+		for range output {
+		}
+		Expect(live.Done()).ToNot(BeClosed())
+	})
+})
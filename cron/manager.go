@@ -1,56 +1,83 @@
 package cron
 
 import (
-	"encoding/json"
+	"context"
 	"errors"
 	"fmt"
 	"log"
 	"os"
 	"path/filepath"
+	"sort"
 	"strconv"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/iwanhae/terminal-hub/notify"
+	"github.com/iwanhae/terminal-hub/terminal"
 	"github.com/robfig/cron/v3"
 )
 
 // CronManager manages cron jobs with JSON file persistence
 type CronManager struct {
-	cron       *cron.Cron
-	jobs       map[string]*CronJob       // id -> job
-	jobsByID   map[cron.EntryID]*CronJob // cron entry id -> job
-	executions []CronExecutionResult     // execution history
-	filePath   string                    // path to JSON file
-	maxHistory int                       // max execution history entries
-	mu         sync.RWMutex
-	executor   *CronExecutor
-	started    bool
+	cron          *cron.Cron
+	jobs          map[string]*CronJob       // id -> job
+	jobsByID      map[cron.EntryID]*CronJob // cron entry id -> job
+	executions    *executionStore           // execution history
+	store         Store                     // persistence backend
+	maxHistory    int                       // max execution history entries
+	mu            sync.RWMutex
+	executor      *CronExecutor
+	notifier      *CronNotifier
+	onNotify      NotificationHandler // receives every failure/recovery event
+	onExecution   ExecutionHandler    // receives every finished execution
+	channels      *notify.Channels    // named channels jobs can notify
+	started       bool
+	running       map[string]map[*runningRun]struct{} // job id -> in-flight scheduled runs
+	queued        map[string]int                      // job id -> runs waiting under the queue policy
+	runDone       *sync.Cond                          // signalled whenever a scheduled run finishes
+	live          map[string]*LiveExecution           // execution id -> in-flight execution output
+	sessionLookup SessionLookup                       // resolves target_session_id for session jobs
+	secretLookup  SecretLookup                        // resolves "secret://" env var values, nil = unsupported
+	suspended     bool                                // scheduled and chained runs are paused
+	jobLogs       *JobLogs                            // per-job output files for log_to_file jobs
+	allowlist     terminal.ExecutionAllowlist         // shells and working directories jobs may use
+	writeGuard    func() error                        // non-nil error pauses writing history and log files, nil = always write
 }
 
 // NewCronManager creates a new manager and loads persisted jobs from JSON
 func NewCronManager(filePath string, maxHistory int) (*CronManager, error) {
-	if maxHistory <= 0 {
-		maxHistory = 1000 // default
+	store, err := NewJSONStore(filePath)
+	if err != nil {
+		return nil, err
 	}
+	return NewCronManagerWithStore(store, maxHistory)
+}
 
-	// Ensure directory exists
-	if err := os.MkdirAll(filepath.Dir(filePath), 0755); err != nil {
-		return nil, fmt.Errorf("failed to create cron directory: %w", err)
+// NewCronManagerWithStore creates a new manager and loads persisted jobs from the store
+func NewCronManagerWithStore(store Store, maxHistory int) (*CronManager, error) {
+	if maxHistory <= 0 {
+		maxHistory = 1000 // default
 	}
 
 	manager := &CronManager{
 		cron:       cron.New(cron.WithParser(cron.NewParser(cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow | cron.SecondOptional))),
 		jobs:       make(map[string]*CronJob),
 		jobsByID:   make(map[cron.EntryID]*CronJob),
-		executions: make([]CronExecutionResult, 0, maxHistory),
-		filePath:   filePath,
+		executions: newExecutionStore(maxHistory),
+		store:      store,
 		maxHistory: maxHistory,
 		executor:   NewCronExecutorWithEnv(),
+		notifier:   NewCronNotifier(notify.GetSMTPConfigFromEnv()),
 		started:    false,
+		running:    make(map[string]map[*runningRun]struct{}),
+		queued:     make(map[string]int),
+		live:       make(map[string]*LiveExecution),
 	}
+	manager.runDone = sync.NewCond(&manager.mu)
 
-	// Load from file if exists
+	// Load persisted state
 	if err := manager.load(); err != nil {
 		return nil, fmt.Errorf("failed to load cron data: %w", err)
 	}
@@ -58,27 +85,16 @@ func NewCronManager(filePath string, maxHistory int) (*CronManager, error) {
 	return manager, nil
 }
 
-// load reads the cron data from the JSON file
+// load reads the cron data from the store
 func (m *CronManager) load() error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	data, err := os.ReadFile(m.filePath)
+	cronData, err := m.store.Load()
 	if err != nil {
-		if os.IsNotExist(err) {
-			// File doesn't exist yet, that's OK
-			return nil
-		}
 		return err
 	}
 
-	var cronData CronData
-	if err := json.Unmarshal(data, &cronData); err != nil {
-		// Corrupt or partial JSON — start fresh
-		log.Printf("[Cron] Warning: corrupt data in %s, starting fresh: %v", m.filePath, err)
-		return nil
-	}
-
 	// Load jobs
 	for i := range cronData.Jobs {
 		job := &cronData.Jobs[i]
@@ -86,43 +102,33 @@ func (m *CronManager) load() error {
 	}
 
 	// Load executions
-	m.executions = cronData.Executions
+	for _, exec := range cronData.Executions {
+		m.executions.Add(exec)
+	}
+
+	m.suspended = cronData.Suspended
+	if m.suspended {
+		log.Printf("[Cron] Scheduling is suspended; use resume-all to restart scheduled runs")
+	}
 
-	log.Printf("[Cron] Loaded %d jobs and %d executions from %s", len(m.jobs), len(m.executions), m.filePath)
+	log.Printf("[Cron] Loaded %d jobs and %d executions from %s", len(m.jobs), m.executions.Len(), m.store)
 
 	return nil
 }
 
-// save writes current state to JSON file atomically.
+// save writes current state to the store.
 // Must be called with m.mu already held (Lock or RLock).
 func (m *CronManager) save() error {
-
 	jobs := make([]CronJob, 0, len(m.jobs))
 	for _, job := range m.jobs {
 		jobs = append(jobs, *job)
 	}
 
-	data := CronData{
+	return m.store.Save(CronData{
 		Jobs:       jobs,
-		Executions: m.executions,
-	}
-
-	jsonData, err := json.MarshalIndent(data, "", "  ")
-	if err != nil {
-		return err
-	}
-
-	// Atomic write: temp file + rename
-	tmpFile := m.filePath + ".tmp"
-	if err := os.WriteFile(tmpFile, jsonData, 0600); err != nil {
-		return fmt.Errorf("failed to write temp file: %w", err)
-	}
-
-	if err := os.Rename(tmpFile, m.filePath); err != nil {
-		return fmt.Errorf("failed to rename temp file: %w", err)
-	}
-
-	return nil
+		Executions: m.executions.All(),
+		Suspended:  m.suspended,
+	})
 }
 
 // Start starts the cron scheduler
@@ -134,11 +140,24 @@ func (m *CronManager) Start() error {
 		return errors.New("cron manager already started")
 	}
 
+	// Drop the entries of an earlier start, so the scheduler can be restarted
+	for entryID := range m.jobsByID {
+		m.cron.Remove(entryID)
+		delete(m.jobsByID, entryID)
+	}
+
 	// Reschedule enabled jobs
+	now := time.Now()
 	for _, job := range m.jobs {
 		if job.Enabled {
+			missed := missedScheduledRun(job, now)
 			if err := m.scheduleJobLocked(job); err != nil {
 				log.Printf("[Cron] Failed to schedule job %s: %v", job.ID, err)
+				continue
+			}
+			if missed {
+				log.Printf("[Cron] Job %s missed a scheduled run while the server was down, catching up", job.ID)
+				go m.executeRun(job.ID, true)
 			}
 		}
 	}
@@ -151,25 +170,43 @@ func (m *CronManager) Start() error {
 	return nil
 }
 
-// Stop stops the cron scheduler
+// Stop stops the cron scheduler, waits for scheduled runs in progress and
+// saves the state, so another process can take over the jobs
 func (m *CronManager) Stop() {
 	m.mu.Lock()
-	defer m.mu.Unlock()
-
 	if !m.started {
+		m.mu.Unlock()
 		return
 	}
-
 	ctx := m.cron.Stop()
+	m.started = false
+	m.mu.Unlock()
+
+	// Runs in progress take the lock to record their results
 	<-ctx.Done()
 
-	m.started = false
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.saveAfterRunLocked("stopping")
 	log.Printf("[Cron] Stopped cron scheduler")
 }
 
+// Close stops the scheduler and closes the store
+func (m *CronManager) Close() error {
+	m.Stop()
+	return m.store.Close()
+}
+
 // scheduleJobLocked schedules a job (caller must hold lock)
 func (m *CronManager) scheduleJobLocked(job *CronJob) error {
+	if job.RunAt != 0 {
+		return m.scheduleOneShotLocked(job)
+	}
 	if job.Schedule == "" {
+		if len(job.RunAfter) > 0 {
+			// Chain-only jobs are started by their upstream jobs
+			return nil
+		}
 		return fmt.Errorf("job %s has empty schedule", job.ID)
 	}
 
@@ -179,8 +216,9 @@ func (m *CronManager) scheduleJobLocked(job *CronJob) error {
 	}
 
 	// Add to cron scheduler
-	entryID, err := m.cron.AddFunc(job.Schedule, func() {
-		m.executeJob(job.ID)
+	spec := scheduleSpec(job.Schedule, job.Timezone)
+	entryID, err := m.cron.AddFunc(spec, func() {
+		m.runScheduled(job.ID)
 	})
 	if err != nil {
 		return fmt.Errorf("failed to add cron job: %w", err)
@@ -190,7 +228,7 @@ func (m *CronManager) scheduleJobLocked(job *CronJob) error {
 	m.jobsByID[entryID] = job
 
 	// Calculate next run time
-	nextRun, err := GetNextRunTime(job.Schedule, time.Now())
+	nextRun, err := GetNextRunTime(spec, time.Now())
 	if err != nil {
 		return err
 	}
@@ -233,8 +271,24 @@ func (m *CronManager) unscheduleJobLocked(jobID string) {
 	job.Metadata.NextRunAt = 0
 }
 
+// missedScheduledRun reports whether a recurring catch_up job had a run due
+// before now that never happened: the next run saved before the server
+// stopped has passed. One-shot jobs always run when missed.
+func missedScheduledRun(job *CronJob, now time.Time) bool {
+	if !job.CatchUp || job.RunAt != 0 || job.Schedule == "" {
+		return false
+	}
+	return job.Metadata.NextRunAt != 0 && job.Metadata.NextRunAt <= now.Unix()
+}
+
 // executeJob executes a cron job
 func (m *CronManager) executeJob(jobID string) {
+	m.executeRun(jobID, false)
+}
+
+// executeRun executes a cron job, marking the run as a catch-up of a missed
+// schedule when catchUp is set
+func (m *CronManager) executeRun(jobID string, catchUp bool) {
 	m.mu.Lock()
 	job, ok := m.jobs[jobID]
 	if !ok {
@@ -242,54 +296,189 @@ func (m *CronManager) executeJob(jobID string) {
 		log.Printf("[Cron] Job %s not found, skipping execution", jobID)
 		return
 	}
+	if m.suspended {
+		m.mu.Unlock()
+		log.Printf("[Cron] Scheduling suspended, skipping job %s", jobID)
+		return
+	}
+
+	// Apply the job's overlap policy
+	overlap, proceed := m.applyConcurrencyPolicyLocked(job)
+	if !proceed {
+		m.mu.Unlock()
+		return
+	}
 
 	// Update concurrent run count
 	job.Metadata.ConcurrentRuns++
 	m.saveJobMetadata(job)
+	ctx, run := m.startRunLocked(job.ID)
 	m.mu.Unlock()
 
-	// Execute the job
-	result, err := m.executor.Execute(job)
+	// Execute the job, retrying failed attempts with exponential backoff
+	result, recorded := m.executeWithRetries(ctx, job, catchUp)
 
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
 	job.Metadata.ConcurrentRuns--
-
-	if err != nil {
-		log.Printf("[Cron] Execution error for job %s: %v", jobID, err)
-		// Create error result
-		result = &CronExecutionResult{
-			JobID:       job.ID,
-			ExecutionID: "exec_" + uuid.New().String(),
-			StartedAt:   time.Now().Unix(),
-			FinishedAt:  time.Now().Unix(),
-			ExitCode:    -1,
-			Output:      "",
-			Error:       err.Error(),
+	m.finishRunLocked(job.ID, run)
+	switch {
+	case !recorded:
+		if run.replaced {
+			result.Overlap = OverlapKilled
+		} else if overlap != "" {
+			result.Overlap = overlap
 		}
+
+		// Add to execution history
+		m.addExecution(result)
+	case run.replaced:
+		// A run replaced while waiting to retry ends with an attempt that is
+		// already in the history; mark it killed there
+		result.Overlap = OverlapKilled
+		m.updateExecution(result)
 	}
 
-	// Add to execution history
-	m.addExecution(result)
+	// One-shot jobs never run again on their own
+	if job.RunAt != 0 {
+		m.completeOneShotLocked(job)
+	}
 
 	// Calculate next run time
-	nextRun, err := GetNextRunTime(job.Schedule, time.Now())
-	if err != nil {
-		log.Printf("[Cron] Failed to calculate next run for job %s: %v", jobID, err)
-	} else {
-		job.Metadata.NextRunAt = nextRun.Unix()
-	}
+	nextRun := jobNextRunTime(job, time.Now())
+	job.Metadata.NextRunAt = unixOrZero(nextRun)
 
 	// Update job metadata
+	previousStatus := job.Metadata.LastRunStatus
 	m.executor.UpdateJobMetadata(job, result, nextRun)
+	m.notifyLocked(job, previousStatus, result)
+	m.triggerDownstreamLocked(job, result)
 
 	// Save to file
-	if err := m.save(); err != nil {
-		log.Printf("[Cron] Failed to save after execution: %v", err)
+	m.saveAfterRunLocked("execution")
+}
+
+// executeWithRetries runs a job up to MaxRetries+1 times until it succeeds.
+// Failed attempts that will be retried are recorded in history immediately;
+// the final attempt is returned for the caller to record. When the run is
+// cancelled during a backoff, recorded reports that the returned attempt is
+// already in the history. With catchUp set, every attempt is marked as a
+// catch-up run.
+func (m *CronManager) executeWithRetries(ctx context.Context, job *CronJob, catchUp bool) (result *CronExecutionResult, recorded bool) {
+	m.mu.RLock()
+	maxRetries := job.MaxRetries
+	m.mu.RUnlock()
+
+	for attempt := 1; ; attempt++ {
+		live := m.startLiveExecution(job.ID)
+		output, closeOutput := m.executionOutput(job, live)
+		var err error
+		result, err = m.runJob(ctx, job, nil, ExecuteOptions{
+			ExecutionID: live.ExecutionID,
+			Output:      output,
+		})
+		if err != nil {
+			log.Printf("[Cron] Execution error for job %s: %v", job.ID, err)
+			// Create error result
+			result = &CronExecutionResult{
+				JobID:       job.ID,
+				ExecutionID: live.ExecutionID,
+				StartedAt:   time.Now().Unix(),
+				FinishedAt:  time.Now().Unix(),
+				ExitCode:    -1,
+				Output:      "",
+				Error:       err.Error(),
+			}
+		}
+		closeOutput(result)
+		if maxRetries > 0 {
+			result.Attempt = attempt
+		}
+		result.CatchUp = catchUp
+
+		if result.ExitCode == 0 || attempt > maxRetries || ctx.Err() != nil {
+			return result, false
+		}
+
+		delay := RetryDelay(job, attempt)
+		log.Printf("[Cron] Job %s attempt %d/%d failed, retrying in %s", job.ID, attempt, maxRetries+1, delay)
+
+		m.mu.Lock()
+		m.addExecution(result)
+		m.saveAfterRunLocked("failed attempt")
+		m.mu.Unlock()
+
+		select {
+		case <-m.executor.timeProvider.After(delay):
+		case <-ctx.Done():
+			return result, true
+		}
 	}
 }
 
+// notifyLocked reports the execution to the execution handler and sends
+// failure/recovery notifications in the background. Must be called with m.mu
+// already held; the job and result are copied.
+func (m *CronManager) notifyLocked(job *CronJob, previousStatus string, result *CronExecutionResult) {
+	jobCopy := *job
+	resultCopy := *result
+	if m.onExecution != nil {
+		go m.onExecution(jobCopy, resultCopy)
+	}
+
+	event := NotificationEvent(previousStatus, result)
+	if event == "" {
+		return
+	}
+	if m.onNotify != nil {
+		go m.onNotify(jobCopy, event, resultCopy)
+	}
+	if job.Notifications != nil && m.notifier != nil {
+		go m.notifier.Notify(jobCopy, event, resultCopy)
+	}
+	if job.Notifications != nil && len(job.Notifications.Channels) > 0 {
+		m.channels.Send(job.Notifications.Channels, PushNotification(jobCopy, event, resultCopy))
+	}
+}
+
+// SetNotificationChannels makes the named channels available to job
+// notifications
+func (m *CronManager) SetNotificationChannels(channels *notify.Channels) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.channels = channels
+}
+
+// validateNotificationsLocked checks a notification config, including that
+// its channels exist. Must be called with m.mu held.
+func (m *CronManager) validateNotificationsLocked(config *CronNotificationConfig) error {
+	if err := ValidateNotifications(config); err != nil {
+		return err
+	}
+	if config == nil {
+		return nil
+	}
+	return m.channels.Check(config.Channels)
+}
+
+// SetNotificationHandler registers a handler called in the background for
+// every failure and recovery event, whether or not the job configures its own
+// notifications
+func (m *CronManager) SetNotificationHandler(handler NotificationHandler) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.onNotify = handler
+}
+
+// SetExecutionHandler registers a handler called in the background after
+// every scheduled or manual execution
+func (m *CronManager) SetExecutionHandler(handler ExecutionHandler) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.onExecution = handler
+}
+
 // saveJobMetadata saves job metadata without full save
 func (m *CronManager) saveJobMetadata(job *CronJob) {
 	// Metadata is updated in-place, will be saved on next full save
@@ -297,14 +486,74 @@ func (m *CronManager) saveJobMetadata(job *CronJob) {
 
 // addExecution adds an execution result to history with rotation
 func (m *CronManager) addExecution(result *CronExecutionResult) {
-	m.executions = append(m.executions, *result)
+	m.executions.Add(*result)
+	m.finishLiveExecutionLocked(result.ExecutionID)
 
-	// Rotate if exceeds max history
-	if len(m.executions) > m.maxHistory {
-		// Remove oldest entries
-		overflow := len(m.executions) - m.maxHistory
-		m.executions = m.executions[overflow:]
+	if err := m.checkWriteGuardLocked(); err != nil {
+		log.Printf("[Cron] Not persisting execution %s: %v", result.ExecutionID, err)
+		return
+	}
+	if err := m.store.AppendExecution(*result, m.maxHistory); err != nil {
+		log.Printf("[Cron] Failed to persist execution %s: %v", result.ExecutionID, err)
+	}
+}
+
+// updateExecution rewrites an execution already in the history. Must be
+// called with m.mu held.
+func (m *CronManager) updateExecution(result *CronExecutionResult) {
+	if !m.executions.Update(*result) {
+		return // evicted meanwhile
+	}
+
+	if err := m.checkWriteGuardLocked(); err != nil {
+		log.Printf("[Cron] Not persisting execution %s: %v", result.ExecutionID, err)
+		return
+	}
+	if err := m.store.UpdateExecution(*result); err != nil {
+		log.Printf("[Cron] Failed to persist execution %s: %v", result.ExecutionID, err)
+	}
+}
+
+// saveAfterRunLocked saves once a run was added to the history, unless the
+// write guard pauses persistence. Must be called with m.mu held.
+func (m *CronManager) saveAfterRunLocked(what string) {
+	if m.checkWriteGuardLocked() != nil {
+		return // addExecution already logged why
+	}
+	if err := m.save(); err != nil {
+		log.Printf("[Cron] Failed to save after %s: %v", what, err)
+	}
+}
+
+// SetWriteGuard registers a check run before execution history and log
+// files are written. While it returns an error, executions are kept in memory
+// only and no log files are written.
+func (m *CronManager) SetWriteGuard(guard func() error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.writeGuard = guard
+}
+
+// checkWriteGuardLocked runs the write guard, if any. Must be called with
+// m.mu held.
+func (m *CronManager) checkWriteGuardLocked() error {
+	if m.writeGuard == nil {
+		return nil
 	}
+	return m.writeGuard()
+}
+
+// SetExecutionAllowlist restricts the shells and working directories that
+// created and updated jobs may use
+func (m *CronManager) SetExecutionAllowlist(allowlist terminal.ExecutionAllowlist) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.allowlist = allowlist
+}
+
+// SetRunAs makes jobs run as the given unprivileged account
+func (m *CronManager) SetRunAs(runAs *terminal.RunAs) {
+	m.executor.SetRunAs(runAs)
 }
 
 // Create creates a new cron job
@@ -312,42 +561,126 @@ func (m *CronManager) Create(req CreateCronRequest) (*CronJob, error) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	// Validate request
+	if err := m.validateCreateRequest("", req, nil); err != nil {
+		return nil, err
+	}
+
+	job, err := m.createLocked(newJobID(), req)
+	if err != nil {
+		return nil, err
+	}
+
+	// Save to file
+	if err := m.save(); err != nil {
+		// Rollback on save failure
+		m.removeLocked(job.ID)
+		return nil, fmt.Errorf("failed to save job: %w", err)
+	}
+
+	log.Printf("[Cron] Created job %s (%s)", job.ID, req.Name)
+
+	return job, nil
+}
+
+// validateCreateRequest checks a create request without modifying state.
+// Imports pass the ID the job will get and the upstream jobs of the other
+// jobs being imported, see validateRunAfterLocked.
+func (m *CronManager) validateCreateRequest(jobID string, req CreateCronRequest, batch map[string][]string) error {
 	if req.Name == "" {
-		return nil, errors.New("name is required")
+		return errors.New("name is required")
 	}
-	if req.Schedule == "" {
-		return nil, errors.New("schedule is required")
+	if req.Schedule == "" && len(normalizeRunAfter(req.RunAfter)) == 0 && req.RunAt == 0 {
+		return errors.New("schedule is required")
 	}
 	if req.Command == "" {
-		return nil, errors.New("command is required")
+		return errors.New("command is required")
 	}
 
 	// Validate schedule
-	if err := ValidateSchedule(req.Schedule); err != nil {
-		return nil, err
+	if req.Schedule != "" {
+		if err := ValidateSchedule(req.Schedule); err != nil {
+			return err
+		}
+	}
+	if err := m.validateRunAfterLocked(jobID, normalizeRunAfter(req.RunAfter), batch); err != nil {
+		return err
+	}
+	if err := validateRunAt(req.RunAt, req.Schedule, normalizeRunAfter(req.RunAfter)); err != nil {
+		return err
+	}
+	if req.RunAt != 0 && req.RunAt <= time.Now().Unix() {
+		return errors.New("run_at must be in the future")
+	}
+	if err := m.validateNotificationsLocked(req.Notifications); err != nil {
+		return err
+	}
+	if err := ValidateRetryPolicy(req.MaxRetries, req.RetryBackoff); err != nil {
+		return err
+	}
+	if err := ValidateTimezone(req.Timezone); err != nil {
+		return err
+	}
+	if err := ValidateJitter(req.Jitter); err != nil {
+		return err
+	}
+	if err := terminal.ValidateEnvVars(req.EnvVars); err != nil {
+		return err
+	}
+	if err := ValidateSecretRefs(req.EnvVars); err != nil {
+		return err
+	}
+	if req.Limits != nil {
+		if err := req.Limits.Validate(); err != nil {
+			return err
+		}
 	}
+	if err := m.allowlist.Check(req.Shell, req.WorkingDirectory); err != nil {
+		return err
+	}
+	if err := m.executor.ValidateTimeout(req.Timeout); err != nil {
+		return err
+	}
+	return ValidateConcurrencyPolicy(req.ConcurrencyPolicy)
+}
+
+// newJobID returns a new, unique job ID
+func newJobID() string {
+	return "cron_" + uuid.New().String()
+}
 
-	// Create job
-	jobID := "cron_" + uuid.New().String()
+// createLocked adds and schedules a job from a validated request without saving.
+// Must be called with m.mu already held.
+func (m *CronManager) createLocked(jobID string, req CreateCronRequest) (*CronJob, error) {
 	now := time.Now()
 
 	// Calculate next run time (only for enabled jobs)
 	var nextRunUnix int64
 	if req.Enabled {
-		nextRun, _ := GetNextRunTime(req.Schedule, now)
-		nextRunUnix = nextRun.Unix()
+		nextRunUnix = unixOrZero(nextRunTime(scheduleSpec(req.Schedule, req.Timezone), now))
 	}
 
 	job := &CronJob{
-		ID:               jobID,
-		Name:             req.Name,
-		Schedule:         req.Schedule,
-		Command:          req.Command,
-		Shell:            req.Shell,
-		WorkingDirectory: req.WorkingDirectory,
-		EnvVars:          req.EnvVars,
-		Enabled:          req.Enabled,
+		ID:                jobID,
+		Name:              req.Name,
+		Schedule:          req.Schedule,
+		Command:           req.Command,
+		Shell:             req.Shell,
+		WorkingDirectory:  req.WorkingDirectory,
+		EnvVars:           req.EnvVars,
+		Enabled:           req.Enabled,
+		Notifications:     req.Notifications,
+		MaxRetries:        req.MaxRetries,
+		RetryBackoff:      req.RetryBackoff,
+		Timeout:           req.Timeout,
+		ConcurrencyPolicy: req.ConcurrencyPolicy,
+		TargetSessionID:   strings.TrimSpace(req.TargetSessionID),
+		RunAfter:          normalizeRunAfter(req.RunAfter),
+		RunAt:             req.RunAt,
+		LogToFile:         req.LogToFile,
+		Timezone:          req.Timezone,
+		Jitter:            req.Jitter,
+		CatchUp:           req.CatchUp,
+		Limits:            normalizeLimits(req.Limits),
 		Metadata: CronMetadata{
 			CreatedAt:      now.Unix(),
 			UpdatedAt:      now.Unix(),
@@ -373,19 +706,104 @@ func (m *CronManager) Create(req CreateCronRequest) (*CronJob, error) {
 		}
 	}
 
-	// Save to file
-	if err := m.save(); err != nil {
-		// Rollback on save failure
-		delete(m.jobs, jobID)
-		if req.Enabled {
-			m.unscheduleJobLocked(jobID)
+	return job, nil
+}
+
+// removeLocked unschedules and forgets a job without saving.
+// Must be called with m.mu already held.
+func (m *CronManager) removeLocked(id string) {
+	m.unscheduleJobLocked(id)
+	delete(m.jobs, id)
+}
+
+// Import creates jobs from definitions. Every definition is validated first,
+// so either all jobs are created or none are. run_after may refer to other
+// jobs of the import by position, as Export writes them.
+func (m *CronManager) Import(reqs []CreateCronRequest) ([]CronJob, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	ids := make([]string, len(reqs))
+	for i := range ids {
+		ids[i] = newJobID()
+	}
+
+	var errs ImportErrors
+	batch := make(map[string][]string, len(reqs))
+	resolved := make([]CreateCronRequest, len(reqs))
+	for i, req := range reqs {
+		runAfter, err := resolveImportRunAfter(req.RunAfter, ids)
+		if err != nil {
+			errs = append(errs, ImportError{Index: i + 1, Message: err.Error()})
+			continue
 		}
-		return nil, fmt.Errorf("failed to save job: %w", err)
+		req.RunAfter = runAfter
+		resolved[i] = req
+		batch[ids[i]] = normalizeRunAfter(runAfter)
+	}
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	for i, req := range resolved {
+		if err := m.validateCreateRequest(ids[i], req, batch); err != nil {
+			errs = append(errs, ImportError{Index: i + 1, Message: err.Error()})
+		}
+	}
+	if len(errs) > 0 {
+		return nil, errs
 	}
 
-	log.Printf("[Cron] Created job %s (%s)", jobID, req.Name)
+	created := make([]CronJob, 0, len(reqs))
+	rollback := func() {
+		for _, job := range created {
+			m.removeLocked(job.ID)
+		}
+	}
 
-	return job, nil
+	for i, req := range resolved {
+		job, err := m.createLocked(ids[i], req)
+		if err != nil {
+			rollback()
+			return nil, err
+		}
+		created = append(created, *job)
+	}
+
+	if err := m.save(); err != nil {
+		rollback()
+		return nil, fmt.Errorf("failed to save imported jobs: %w", err)
+	}
+
+	log.Printf("[Cron] Imported %d jobs", len(created))
+
+	return created, nil
+}
+
+// Export returns the definitions of all jobs, ordered by name. Completed
+// one-shot jobs are left out: they never run again, and their past run_at
+// would be rejected on import. Upstream jobs in run_after are referred to by
+// position in the export, see importRefPrefix, so chains survive an import.
+func (m *CronManager) Export() CronExport {
+	jobs, _ := m.List()
+	sortJobsByName(jobs)
+
+	exported := make([]CronJob, 0, len(jobs))
+	positions := make(map[string]int, len(jobs))
+	for _, job := range jobs {
+		if job.RunAt != 0 && job.Metadata.CompletedAt != 0 {
+			continue
+		}
+		exported = append(exported, job)
+		positions[job.ID] = len(exported)
+	}
+
+	export := CronExport{Jobs: make([]CreateCronRequest, 0, len(exported))}
+	for _, job := range exported {
+		definition := JobDefinition(job)
+		definition.RunAfter = exportRunAfter(job.RunAfter, positions)
+		export.Jobs = append(export.Jobs, definition)
+	}
+	return export
 }
 
 // Get retrieves a cron job by ID
@@ -400,6 +818,7 @@ func (m *CronManager) Get(id string) (*CronJob, error) {
 
 	// Return a copy
 	jobCopy := *job
+	jobCopy.Chain = m.chainStatusLocked(job)
 	return &jobCopy, nil
 }
 
@@ -410,7 +829,9 @@ func (m *CronManager) List() ([]CronJob, error) {
 
 	jobs := make([]CronJob, 0, len(m.jobs))
 	for _, job := range m.jobs {
-		jobs = append(jobs, *job)
+		jobCopy := *job
+		jobCopy.Chain = m.chainStatusLocked(job)
+		jobs = append(jobs, jobCopy)
 	}
 
 	return jobs, nil
@@ -426,6 +847,86 @@ func (m *CronManager) Update(id string, req UpdateCronRequest) (*CronJob, error)
 		return nil, errors.New("job not found")
 	}
 
+	if err := m.validateNotificationsLocked(req.Notifications); err != nil {
+		return nil, err
+	}
+	maxRetries, retryBackoff := job.MaxRetries, job.RetryBackoff
+	if req.MaxRetries != nil {
+		maxRetries = *req.MaxRetries
+	}
+	if req.RetryBackoff != nil {
+		retryBackoff = *req.RetryBackoff
+	}
+	if err := ValidateRetryPolicy(maxRetries, retryBackoff); err != nil {
+		return nil, err
+	}
+	if req.Timeout != nil {
+		if err := m.executor.ValidateTimeout(*req.Timeout); err != nil {
+			return nil, err
+		}
+	}
+	if req.ConcurrencyPolicy != nil {
+		if err := ValidateConcurrencyPolicy(*req.ConcurrencyPolicy); err != nil {
+			return nil, err
+		}
+	}
+	if req.Timezone != nil {
+		if err := ValidateTimezone(*req.Timezone); err != nil {
+			return nil, err
+		}
+	}
+	if req.Jitter != nil {
+		if err := ValidateJitter(*req.Jitter); err != nil {
+			return nil, err
+		}
+	}
+	if req.Limits != nil {
+		if err := req.Limits.Validate(); err != nil {
+			return nil, err
+		}
+	}
+	if err := terminal.ValidateEnvVars(req.EnvVars); err != nil {
+		return nil, err
+	}
+	if err := ValidateSecretRefs(req.EnvVars); err != nil {
+		return nil, err
+	}
+	if req.Shell != nil {
+		if err := m.allowlist.CheckShell(*req.Shell); err != nil {
+			return nil, err
+		}
+	}
+	if req.WorkingDirectory != nil {
+		if err := m.allowlist.CheckWorkingDirectory(*req.WorkingDirectory); err != nil {
+			return nil, err
+		}
+	}
+	runAfter := job.RunAfter
+	if req.RunAfter != nil {
+		runAfter = normalizeRunAfter(*req.RunAfter)
+		if err := m.validateRunAfterLocked(id, runAfter, nil); err != nil {
+			return nil, err
+		}
+	}
+	schedule := job.Schedule
+	if req.Schedule != nil {
+		schedule = *req.Schedule
+	}
+	runAt := job.RunAt
+	if req.RunAt != nil {
+		runAt = *req.RunAt
+	}
+	if schedule == "" && len(runAfter) == 0 && runAt == 0 {
+		return nil, errors.New("schedule is required for jobs without run_after or run_at")
+	}
+	if err := validateRunAt(runAt, schedule, runAfter); err != nil {
+		return nil, err
+	}
+	runAtChanged := runAt != job.RunAt
+	if runAtChanged && runAt != 0 && runAt <= time.Now().Unix() {
+		return nil, errors.New("run_at must be in the future")
+	}
+
 	// Unschedule first
 	m.unscheduleJobLocked(id)
 
@@ -434,10 +935,12 @@ func (m *CronManager) Update(id string, req UpdateCronRequest) (*CronJob, error)
 		job.Name = *req.Name
 	}
 	if req.Schedule != nil {
-		if err := ValidateSchedule(*req.Schedule); err != nil {
-			// Reschedule with old settings
-			m.scheduleJobLocked(job)
-			return nil, err
+		if *req.Schedule != "" {
+			if err := ValidateSchedule(*req.Schedule); err != nil {
+				// Reschedule with old settings
+				m.scheduleJobLocked(job)
+				return nil, err
+			}
 		}
 		job.Schedule = *req.Schedule
 	}
@@ -456,12 +959,46 @@ func (m *CronManager) Update(id string, req UpdateCronRequest) (*CronJob, error)
 	if req.Enabled != nil {
 		job.Enabled = *req.Enabled
 	}
+	if req.Notifications != nil {
+		job.Notifications = req.Notifications
+	}
+	job.MaxRetries = maxRetries
+	job.RetryBackoff = retryBackoff
+	if req.Timeout != nil {
+		job.Timeout = *req.Timeout
+	}
+	if req.ConcurrencyPolicy != nil {
+		job.ConcurrencyPolicy = *req.ConcurrencyPolicy
+	}
+	if req.TargetSessionID != nil {
+		job.TargetSessionID = strings.TrimSpace(*req.TargetSessionID)
+	}
+	if req.LogToFile != nil {
+		job.LogToFile = *req.LogToFile
+	}
+	if req.Timezone != nil {
+		job.Timezone = *req.Timezone
+	}
+	if req.Jitter != nil {
+		job.Jitter = *req.Jitter
+	}
+	if req.CatchUp != nil {
+		job.CatchUp = *req.CatchUp
+	}
+	if req.Limits != nil {
+		job.Limits = normalizeLimits(req.Limits)
+	}
+	job.RunAfter = runAfter
+	if runAtChanged {
+		// A new run time makes a completed one-shot job pending again
+		job.RunAt = runAt
+		job.Metadata.CompletedAt = 0
+	}
 
 	job.Metadata.UpdatedAt = time.Now().Unix()
 
 	// Recalculate next run time
-	nextRun, _ := GetNextRunTime(job.Schedule, time.Now())
-	job.Metadata.NextRunAt = nextRun.Unix()
+	job.Metadata.NextRunAt = unixOrZero(jobNextRunTime(job, time.Now()))
 
 	// Reschedule if enabled
 	if job.Enabled {
@@ -497,6 +1034,7 @@ func (m *CronManager) Delete(id string) error {
 
 	// Remove from map
 	delete(m.jobs, id)
+	m.removeUpstreamLocked(id)
 
 	// Save to file
 	if err := m.save(); err != nil {
@@ -505,11 +1043,59 @@ func (m *CronManager) Delete(id string) error {
 		return fmt.Errorf("failed to save: %w", err)
 	}
 
+	if m.jobLogs != nil {
+		if err := m.jobLogs.Remove(id); err != nil {
+			log.Printf("[Cron] Failed to remove log files of job %s: %v", id, err)
+		}
+	}
+
 	log.Printf("[Cron] Deleted job %s", id)
 
 	return nil
 }
 
+// Suspend pauses all scheduled and chained runs without changing each job's
+// Enabled flag. Manual runs remain possible. The state survives restarts.
+func (m *CronManager) Suspend() error {
+	return m.setSuspended(true)
+}
+
+// Resume restarts scheduled runs after Suspend
+func (m *CronManager) Resume() error {
+	return m.setSuspended(false)
+}
+
+// setSuspended persists the suspended state
+func (m *CronManager) setSuspended(suspended bool) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.suspended == suspended {
+		return nil
+	}
+
+	m.suspended = suspended
+	if err := m.save(); err != nil {
+		m.suspended = !suspended
+		return fmt.Errorf("failed to save: %w", err)
+	}
+
+	if suspended {
+		log.Printf("[Cron] Suspended all scheduled runs")
+	} else {
+		log.Printf("[Cron] Resumed scheduled runs")
+		m.runMissedOneShotsLocked()
+	}
+	return nil
+}
+
+// IsSuspended returns true while scheduled runs are paused
+func (m *CronManager) IsSuspended() bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.suspended
+}
+
 // Enable enables a cron job
 func (m *CronManager) Enable(id string) error {
 	m.mu.Lock()
@@ -572,6 +1158,13 @@ func (m *CronManager) Disable(id string) error {
 
 // RunNow triggers immediate execution of a cron job
 func (m *CronManager) RunNow(id string) (*CronExecutionResult, error) {
+	return m.RunNowWithEnv(id, nil)
+}
+
+// RunNowWithEnv triggers immediate execution of a cron job with extra
+// environment variables, which override the job's own for this run only.
+// Their values are used as given, never resolved as secrets.
+func (m *CronManager) RunNowWithEnv(id string, env map[string]string) (*CronExecutionResult, error) {
 	m.mu.Lock()
 	job, ok := m.jobs[id]
 	if !ok {
@@ -581,12 +1174,19 @@ func (m *CronManager) RunNow(id string) (*CronExecutionResult, error) {
 	m.mu.Unlock()
 
 	// Execute the job
-	result, err := m.executor.Execute(job)
+	live := m.startLiveExecution(job.ID)
+	output, closeOutput := m.executionOutput(job, live)
+	result, err := m.runJob(context.Background(), job, env, ExecuteOptions{
+		ExecutionID: live.ExecutionID,
+		Output:      output,
+	})
+	closeOutput(result)
 
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
 	if err != nil {
+		m.finishLiveExecutionLocked(live.ExecutionID)
 		return nil, err
 	}
 
@@ -594,16 +1194,17 @@ func (m *CronManager) RunNow(id string) (*CronExecutionResult, error) {
 	m.addExecution(result)
 
 	// Calculate next run time
-	nextRun, _ := GetNextRunTime(job.Schedule, time.Now())
-	job.Metadata.NextRunAt = nextRun.Unix()
+	nextRun := jobNextRunTime(job, time.Now())
+	job.Metadata.NextRunAt = unixOrZero(nextRun)
 
 	// Update job metadata
+	previousStatus := job.Metadata.LastRunStatus
 	m.executor.UpdateJobMetadata(job, result, nextRun)
+	m.notifyLocked(job, previousStatus, result)
+	m.triggerDownstreamLocked(job, result)
 
 	// Save to file
-	if err := m.save(); err != nil {
-		log.Printf("[Cron] Failed to save after manual execution: %v", err)
-	}
+	m.saveAfterRunLocked("manual execution")
 
 	log.Printf("[Cron] Manual execution completed for job %s (exit code: %d)", id, result.ExitCode)
 
@@ -616,19 +1217,103 @@ func (m *CronManager) GetHistory(id string) ([]CronExecutionResult, error) {
 	defer m.mu.RUnlock()
 
 	// Check if job exists
-	if _, ok := m.jobs[id]; !ok {
+	if !m.hasJobLocked(id) {
+		return nil, errors.New("job not found")
+	}
+
+	return m.executions.ForJob(id), nil
+}
+
+// QueryHistory returns a page of execution history, newest first.
+// An empty query.JobID searches the history of all jobs.
+func (m *CronManager) QueryHistory(query HistoryQuery) (*HistoryPage, error) {
+	if err := query.Validate(); err != nil {
+		return nil, err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if query.JobID != "" {
+		if !m.hasJobLocked(query.JobID) {
+			return nil, errors.New("job not found")
+		}
+	}
+
+	executions, total := m.executions.Query(query)
+	return &HistoryPage{
+		Executions: executions,
+		Total:      total,
+		Limit:      query.Limit,
+		Offset:     query.Offset,
+	}, nil
+}
+
+// startLiveExecution registers an in-flight execution whose output can be streamed
+func (m *CronManager) startLiveExecution(jobID string) *LiveExecution {
+	live := NewLiveExecution(
+		jobID,
+		"exec_"+uuid.New().String(),
+		time.Now().Unix(),
+		m.executor.config.MaxOutputSize,
+	)
+
+	m.mu.Lock()
+	m.live[live.ExecutionID] = live
+	m.mu.Unlock()
+
+	return live
+}
+
+// finishLiveExecutionLocked closes and unregisters an in-flight execution.
+// Must be called with m.mu already held.
+func (m *CronManager) finishLiveExecutionLocked(executionID string) {
+	if live, ok := m.live[executionID]; ok {
+		live.finish()
+		delete(m.live, executionID)
+	}
+}
+
+// ListLiveExecutions returns the in-flight executions of a job
+func (m *CronManager) ListLiveExecutions(jobID string) ([]*LiveExecution, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if !m.hasJobLocked(jobID) {
 		return nil, errors.New("job not found")
 	}
 
-	// Filter executions by job ID
-	history := make([]CronExecutionResult, 0)
-	for _, exec := range m.executions {
-		if exec.JobID == id {
-			history = append(history, exec)
+	executions := make([]*LiveExecution, 0)
+	for _, live := range m.live {
+		if live.JobID == jobID {
+			executions = append(executions, live)
 		}
 	}
+	sort.Slice(executions, func(i, j int) bool {
+		return executions[i].StartedAt < executions[j].StartedAt
+	})
+	return executions, nil
+}
+
+// GetExecution returns an execution of a job: the in-flight execution if it is
+// still running, otherwise the recorded history entry
+func (m *CronManager) GetExecution(jobID, executionID string) (*LiveExecution, *CronExecutionResult, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if !m.hasJobLocked(jobID) {
+		return nil, nil, errors.New("job not found")
+	}
 
-	return history, nil
+	if live, ok := m.live[executionID]; ok && live.JobID == jobID {
+		return live, nil, nil
+	}
+
+	if result, ok := m.executions.Get(executionID); ok && result.JobID == jobID {
+		return nil, &result, nil
+	}
+
+	return nil, nil, errors.New("execution not found")
 }
 
 // GetAllHistory returns all execution history
@@ -636,10 +1321,7 @@ func (m *CronManager) GetAllHistory() []CronExecutionResult {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
 
-	history := make([]CronExecutionResult, len(m.executions))
-	copy(history, m.executions)
-
-	return history
+	return m.executions.All()
 }
 
 // GetJobCount returns the number of jobs
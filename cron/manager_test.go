@@ -8,6 +8,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/iwanhae/terminal-hub/terminal"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 )
@@ -592,6 +593,43 @@ var _ = Describe("CronManager", func() {
 			})
 		})
 
+		Describe("Execution allowlist", func() {
+			BeforeEach(func() {
+				manager.SetExecutionAllowlist(terminal.ExecutionAllowlist{
+					Shells:      []string{"/bin/sh"},
+					Directories: []string{tempDir},
+				})
+			})
+
+			It("should reject shells and directories outside the allowlist", func() {
+				_, err := manager.Create(CreateCronRequest{
+					Name: "Zsh", Schedule: "* * * * *", Command: "echo test", Shell: "/usr/bin/zsh",
+				})
+				Expect(err).To(MatchError(`shell "/usr/bin/zsh" is not allowed`))
+
+				_, err = manager.Create(CreateCronRequest{
+					Name: "Etc", Schedule: "* * * * *", Command: "echo test", WorkingDirectory: "/etc",
+				})
+				Expect(err).To(MatchError(`working directory "/etc" is not allowed`))
+				Expect(manager.GetJobCount()).To(Equal(0))
+			})
+
+			It("should accept allowed values and check updates", func() {
+				job, err := manager.Create(CreateCronRequest{
+					Name: "Allowed", Schedule: "* * * * *", Command: "echo test",
+					Shell: "/bin/sh", WorkingDirectory: tempDir,
+				})
+				Expect(err).ToNot(HaveOccurred())
+
+				_, err = manager.Update(job.ID, UpdateCronRequest{WorkingDirectory: ptr(filepath.Join(tempDir, ".."))})
+				Expect(err).To(HaveOccurred())
+
+				updated, err := manager.Update(job.ID, UpdateCronRequest{WorkingDirectory: ptr("")})
+				Expect(err).ToNot(HaveOccurred())
+				Expect(updated.WorkingDirectory).To(BeEmpty())
+			})
+		})
+
 		Describe("Delete", func() {
 			It("should delete existing job", func() {
 				req := CreateCronRequest{
@@ -773,6 +811,29 @@ var _ = Describe("CronManager", func() {
 				Expect(manager.IsStarted()).To(BeFalse())
 			})
 
+			It("should restart without scheduling jobs twice", func() {
+				_, err := manager.Create(CreateCronRequest{Name: "Hourly", Schedule: "0 * * * *", Command: "true", Enabled: true})
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(manager.Start()).To(Succeed())
+				manager.Stop()
+				Expect(manager.Start()).To(Succeed())
+				Expect(manager.cron.Entries()).To(HaveLen(1))
+			})
+
+			It("should save the jobs when stopped", func() {
+				job, err := manager.Create(CreateCronRequest{Name: "Hourly", Schedule: "0 * * * *", Command: "true", Enabled: true})
+				Expect(err).ToNot(HaveOccurred())
+				Expect(manager.Start()).To(Succeed())
+				manager.Stop()
+
+				reloaded, err := NewCronManager(cronFile, 100)
+				Expect(err).ToNot(HaveOccurred())
+				saved, err := reloaded.Get(job.ID)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(saved.Metadata.NextRunAt).ToNot(BeZero())
+			})
+
 			It("should schedule enabled jobs on start", func() {
 				req := CreateCronRequest{
 					Name:     "Scheduled",
@@ -804,6 +865,59 @@ var _ = Describe("CronManager", func() {
 				reloaded, _ := manager.Get(job.ID)
 				Expect(reloaded.Metadata.NextRunAt).To(Equal(int64(0)))
 			})
+
+			It("should catch up a run missed while stopped", func() {
+				job, err := manager.Create(CreateCronRequest{
+					Name:     "Nightly",
+					Schedule: "0 3 * * *",
+					Command:  "echo caught up",
+					Enabled:  true,
+					CatchUp:  true,
+				})
+				Expect(err).ToNot(HaveOccurred())
+
+				// The server was down when the saved next run came due
+				missed := time.Now().Add(-time.Hour).Unix()
+				manager.mu.Lock()
+				manager.jobs[job.ID].Metadata.NextRunAt = missed
+				manager.mu.Unlock()
+				Expect(manager.save()).To(Succeed())
+
+				restarted, err := NewCronManager(cronFile, 100)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(restarted.Start()).To(Succeed())
+				defer restarted.Stop()
+
+				Eventually(func() []CronExecutionResult {
+					history, _ := restarted.GetHistory(job.ID)
+					return history
+				}).Should(HaveLen(1))
+				history, _ := restarted.GetHistory(job.ID)
+				Expect(history[0].CatchUp).To(BeTrue())
+				Expect(history[0].Output).To(ContainSubstring("caught up"))
+
+				reloaded, _ := restarted.Get(job.ID)
+				Expect(reloaded.Metadata.NextRunAt).To(BeNumerically(">", time.Now().Unix()))
+			})
+
+			It("should skip missed runs without catch_up", func() {
+				job, err := manager.Create(CreateCronRequest{
+					Name:     "Nightly",
+					Schedule: "0 3 * * *",
+					Command:  "echo skipped",
+					Enabled:  true,
+				})
+				Expect(err).ToNot(HaveOccurred())
+				manager.mu.Lock()
+				manager.jobs[job.ID].Metadata.NextRunAt = time.Now().Add(-time.Hour).Unix()
+				manager.mu.Unlock()
+
+				Expect(manager.Start()).To(Succeed())
+				Consistently(func() []CronExecutionResult {
+					history, _ := manager.GetHistory(job.ID)
+					return history
+				}, 200*time.Millisecond).Should(BeEmpty())
+			})
 		})
 
 		Describe("RunNow", func() {
@@ -862,6 +976,25 @@ var _ = Describe("CronManager", func() {
 				Expect(result.ExitCode).To(Equal(1))
 			})
 
+			It("should run with extra environment variables", func() {
+				job, err := manager.Create(CreateCronRequest{
+					Name:     "Env Run",
+					Schedule: "0 0 1 1 *",
+					Command:  `echo "$GREETING $NAME"`,
+					EnvVars:  map[string]string{"NAME": "job", "GREETING": "hello"},
+					Enabled:  true,
+				})
+				Expect(err).ToNot(HaveOccurred())
+
+				result, err := manager.RunNowWithEnv(job.ID, map[string]string{"NAME": "hook"})
+				Expect(err).ToNot(HaveOccurred())
+				Expect(result.Output).To(ContainSubstring("hello hook"))
+
+				// The job keeps its own variables
+				reloaded, _ := manager.Get(job.ID)
+				Expect(reloaded.EnvVars).To(Equal(map[string]string{"NAME": "job", "GREETING": "hello"}))
+			})
+
 			It("should return error for non-existent job", func() {
 				_, err := manager.RunNow("non-existent")
 				Expect(err).To(HaveOccurred())
@@ -1251,4 +1384,52 @@ var _ = Describe("CronManager", func() {
 			Expect(manager.GetJobCount()).To(Equal(0))
 		})
 	})
+
+	Describe("SetWriteGuard", func() {
+		var (
+			tempDir  string
+			cronFile string
+			manager  *CronManager
+		)
+
+		BeforeEach(func() {
+			tempDir = GinkgoT().TempDir()
+			cronFile = filepath.Join(tempDir, "crons.json")
+			var err error
+			manager, err = NewCronManager(cronFile, 100)
+			Expect(err).ToNot(HaveOccurred())
+			mockExec := NewMockCommandExecutor()
+			mockExec.SetResult("echo guarded", MockCommandResult{Stdout: "guarded\n"})
+			manager.executor = NewCronExecutorWithOptions(DefaultCronExecutorConfig(), WithMockExecutor(mockExec))
+			manager.SetJobLogs(NewJobLogs(JobLogConfig{Dir: filepath.Join(tempDir, "logs")}))
+		})
+
+		persistedHistory := func(jobID string) []CronExecutionResult {
+			reloaded, err := NewCronManager(cronFile, 100)
+			Expect(err).ToNot(HaveOccurred())
+			history, err := reloaded.GetHistory(jobID)
+			Expect(err).ToNot(HaveOccurred())
+			return history
+		}
+
+		It("should keep executions in memory only while the guard fails", func() {
+			job, err := manager.Create(CreateCronRequest{Name: "Guarded", Schedule: "0 0 1 1 *", Command: "echo guarded", LogToFile: true})
+			Expect(err).ToNot(HaveOccurred())
+
+			manager.SetWriteGuard(func() error { return fmt.Errorf("low disk space") })
+			manager.executeJob(job.ID)
+
+			history, err := manager.GetHistory(job.ID)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(history).To(HaveLen(1))
+			Expect(persistedHistory(job.ID)).To(BeEmpty())
+			Expect(filepath.Join(tempDir, "logs", job.ID+".log")).ToNot(BeAnExistingFile())
+
+			// Once space recovers the whole in-memory history is written
+			manager.SetWriteGuard(func() error { return nil })
+			manager.executeJob(job.ID)
+			Expect(persistedHistory(job.ID)).To(HaveLen(2))
+			Expect(filepath.Join(tempDir, "logs", job.ID+".log")).To(BeAnExistingFile())
+		})
+	})
 })
@@ -0,0 +1,199 @@
+package cron
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/mail"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/iwanhae/terminal-hub/notify"
+)
+
+// Notification events emitted after a job execution
+const (
+	NotificationEventFailed    = "failed"
+	NotificationEventRecovered = "recovered"
+)
+
+// NotificationHandler receives a job's failure and recovery events
+type NotificationHandler func(job CronJob, event string, result CronExecutionResult)
+
+// ExecutionHandler receives every finished execution, successful or not
+type ExecutionHandler func(job CronJob, result CronExecutionResult)
+
+// maxPushOutput is how much output is included in a push notification
+const maxPushOutput = 512
+
+// CronNotificationPayload is the JSON body posted to notification webhooks
+type CronNotificationPayload struct {
+	Event       string `json:"event"` // "failed" or "recovered"; hub webhooks also receive "succeeded"
+	JobID       string `json:"job_id"`
+	JobName     string `json:"job_name"`
+	Command     string `json:"command"`
+	ExecutionID string `json:"execution_id"`
+	StartedAt   int64  `json:"started_at"`
+	FinishedAt  int64  `json:"finished_at"`
+	ExitCode    int    `json:"exit_code"`
+	Output      string `json:"output"`
+	Error       string `json:"error,omitempty"`
+	TimedOut    bool   `json:"timed_out,omitempty"`
+}
+
+// CronNotifier delivers failure and recovery notifications for cron jobs
+type CronNotifier struct {
+	client *http.Client
+	smtp   notify.SMTPConfig
+}
+
+// NewCronNotifier creates a notifier with the given SMTP settings
+func NewCronNotifier(smtpConfig notify.SMTPConfig) *CronNotifier {
+	return &CronNotifier{
+		client: &http.Client{Timeout: 10 * time.Second},
+		smtp:   smtpConfig,
+	}
+}
+
+// ValidateNotifications checks that a notification config is usable
+func ValidateNotifications(config *CronNotificationConfig) error {
+	if config == nil {
+		return nil
+	}
+
+	if config.WebhookURL != "" {
+		parsed, err := url.Parse(config.WebhookURL)
+		if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			return fmt.Errorf("invalid webhook URL %q: must be an absolute http(s) URL", config.WebhookURL)
+		}
+	}
+
+	for _, address := range config.EmailTo {
+		if _, err := mail.ParseAddress(address); err != nil {
+			return fmt.Errorf("invalid email address %q: %w", address, err)
+		}
+	}
+
+	return nil
+}
+
+// NotificationEvent determines which event (if any) an execution result should trigger,
+// given the job status before the execution was recorded
+func NotificationEvent(previousStatus string, result *CronExecutionResult) string {
+	if result.ExitCode != 0 {
+		return NotificationEventFailed
+	}
+	if previousStatus == "failed" || previousStatus == "timeout" {
+		return NotificationEventRecovered
+	}
+	return ""
+}
+
+// NewNotificationPayload describes an execution for webhooks
+func NewNotificationPayload(job CronJob, event string, result CronExecutionResult) CronNotificationPayload {
+	return CronNotificationPayload{
+		Event:       event,
+		JobID:       job.ID,
+		JobName:     job.Name,
+		Command:     job.Command,
+		ExecutionID: result.ExecutionID,
+		StartedAt:   result.StartedAt,
+		FinishedAt:  result.FinishedAt,
+		ExitCode:    result.ExitCode,
+		Output:      result.Output,
+		Error:       result.Error,
+		TimedOut:    result.TimedOut,
+	}
+}
+
+// Notify sends the notification for an event to every channel configured on the job
+func (n *CronNotifier) Notify(job CronJob, event string, result CronExecutionResult) {
+	config := job.Notifications
+	if config == nil || event == "" {
+		return
+	}
+
+	payload := NewNotificationPayload(job, event, result)
+
+	if config.WebhookURL != "" {
+		if err := n.postWebhook(config.WebhookURL, payload); err != nil {
+			log.Printf("[Cron] Failed to send webhook notification for job %s: %v", job.ID, err)
+		}
+	}
+
+	if len(config.EmailTo) > 0 {
+		if err := n.sendEmail(config.EmailTo, payload); err != nil {
+			log.Printf("[Cron] Failed to send email notification for job %s: %v", job.ID, err)
+		}
+	}
+}
+
+// PushNotification describes a failure or recovery for push and chat
+// notification channels
+func PushNotification(job CronJob, event string, result CronExecutionResult) notify.Notification {
+	if event == NotificationEventRecovered {
+		return notify.Notification{
+			Event:   notify.EventCronRecovered,
+			Title:   fmt.Sprintf("Cron job %q recovered", job.Name),
+			Message: fmt.Sprintf("Cron job %q succeeded again.", job.Name),
+			Tags:    []string{"white_check_mark"},
+		}
+	}
+
+	message := result.Error
+	if message == "" {
+		message = fmt.Sprintf("Exit code %d", result.ExitCode)
+	}
+	if output := strings.TrimSpace(result.Output); output != "" {
+		if len(output) > maxPushOutput {
+			output = "..." + output[len(output)-maxPushOutput:]
+		}
+		message += "\n" + output
+	}
+	return notify.Notification{
+		Event:    notify.EventCronFailed,
+		Title:    fmt.Sprintf("Cron job %q failed", job.Name),
+		Message:  message,
+		Tags:     []string{"warning"},
+		Priority: notify.PriorityHigh,
+	}
+}
+
+// postWebhook posts the payload as JSON to the webhook URL
+func (n *CronNotifier) postWebhook(webhookURL string, payload CronNotificationPayload) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	resp, err := n.client.Post(webhookURL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// sendEmail sends the payload as a plain-text email via SMTP
+func (n *CronNotifier) sendEmail(to []string, payload CronNotificationPayload) error {
+	subject := fmt.Sprintf("[terminal-hub] Cron job %q %s", payload.JobName, payload.Event)
+
+	var body strings.Builder
+	fmt.Fprintf(&body, "Job: %s (%s)\n", payload.JobName, payload.JobID)
+	fmt.Fprintf(&body, "Command: %s\n", payload.Command)
+	fmt.Fprintf(&body, "Execution: %s\n", payload.ExecutionID)
+	fmt.Fprintf(&body, "Exit code: %d\n", payload.ExitCode)
+	if payload.Error != "" {
+		fmt.Fprintf(&body, "Error: %s\n", payload.Error)
+	}
+	fmt.Fprintf(&body, "\nOutput:\n%s\n", payload.Output)
+
+	return notify.SendEmail(n.smtp, to, subject, body.String())
+}
@@ -0,0 +1,221 @@
+package cron
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/iwanhae/terminal-hub/notify"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("CronNotifier", func() {
+	Describe("NotificationEvent", func() {
+		It("should report failures", func() {
+			Expect(NotificationEvent("", &CronExecutionResult{ExitCode: 1})).To(Equal(NotificationEventFailed))
+			Expect(NotificationEvent("failed", &CronExecutionResult{ExitCode: 2})).To(Equal(NotificationEventFailed))
+		})
+
+		It("should report recovery after a failure", func() {
+			Expect(NotificationEvent("failed", &CronExecutionResult{ExitCode: 0})).To(Equal(NotificationEventRecovered))
+		})
+
+		It("should stay silent for repeated successes", func() {
+			Expect(NotificationEvent("success", &CronExecutionResult{ExitCode: 0})).To(BeEmpty())
+			Expect(NotificationEvent("", &CronExecutionResult{ExitCode: 0})).To(BeEmpty())
+		})
+	})
+
+	Describe("ValidateNotifications", func() {
+		It("should accept nil and valid configs", func() {
+			Expect(ValidateNotifications(nil)).To(Succeed())
+			Expect(ValidateNotifications(&CronNotificationConfig{
+				WebhookURL: "https://example.com/hook",
+				EmailTo:    []string{"ops@example.com"},
+			})).To(Succeed())
+		})
+
+		It("should reject non-http webhook URLs", func() {
+			Expect(ValidateNotifications(&CronNotificationConfig{WebhookURL: "ftp://example.com"})).ToNot(Succeed())
+			Expect(ValidateNotifications(&CronNotificationConfig{WebhookURL: "/relative"})).ToNot(Succeed())
+		})
+
+		It("should reject invalid email addresses", func() {
+			Expect(ValidateNotifications(&CronNotificationConfig{EmailTo: []string{"not-an-email"}})).ToNot(Succeed())
+		})
+	})
+
+	Describe("Webhook delivery", func() {
+		var (
+			server   *httptest.Server
+			mu       sync.Mutex
+			payloads []CronNotificationPayload
+			tempDir  string
+			manager  *CronManager
+			mockExec *MockCommandExecutor
+		)
+
+		BeforeEach(func() {
+			payloads = nil
+			server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				var payload CronNotificationPayload
+				Expect(json.NewDecoder(r.Body).Decode(&payload)).To(Succeed())
+				mu.Lock()
+				payloads = append(payloads, payload)
+				mu.Unlock()
+				w.WriteHeader(http.StatusNoContent)
+			}))
+
+			var err error
+			tempDir, err = os.MkdirTemp("", "cron-notify-*")
+			Expect(err).ToNot(HaveOccurred())
+			manager, err = NewCronManager(filepath.Join(tempDir, "crons.json"), 100)
+			Expect(err).ToNot(HaveOccurred())
+
+			mockExec = NewMockCommandExecutor()
+			manager.executor = NewCronExecutorWithOptions(DefaultCronExecutorConfig(), WithMockExecutor(mockExec))
+		})
+
+		AfterEach(func() {
+			server.Close()
+			os.RemoveAll(tempDir)
+		})
+
+		received := func() []CronNotificationPayload {
+			mu.Lock()
+			defer mu.Unlock()
+			return append([]CronNotificationPayload(nil), payloads...)
+		}
+
+		It("should post failure and recovery payloads", func() {
+			job, err := manager.Create(CreateCronRequest{
+				Name:          "Notify",
+				Schedule:      "0 0 1 1 *",
+				Command:       "flaky",
+				Notifications: &CronNotificationConfig{WebhookURL: server.URL},
+			})
+			Expect(err).ToNot(HaveOccurred())
+
+			mockExec.SetDefaultResult(MockCommandResult{Stdout: "boom", ExitCode: 3})
+			_, err = manager.RunNow(job.ID)
+			Expect(err).ToNot(HaveOccurred())
+			Eventually(received).Should(HaveLen(1))
+
+			first := received()[0]
+			Expect(first.Event).To(Equal(NotificationEventFailed))
+			Expect(first.JobID).To(Equal(job.ID))
+			Expect(first.ExitCode).To(Equal(3))
+			Expect(first.Output).To(Equal("boom"))
+
+			mockExec.SetDefaultResult(MockCommandResult{Stdout: "ok", ExitCode: 0})
+			_, err = manager.RunNow(job.ID)
+			Expect(err).ToNot(HaveOccurred())
+			Eventually(received).Should(HaveLen(2))
+			Expect(received()[1].Event).To(Equal(NotificationEventRecovered))
+
+			// A second success does not notify again
+			_, err = manager.RunNow(job.ID)
+			Expect(err).ToNot(HaveOccurred())
+			Consistently(received, "200ms").Should(HaveLen(2))
+		})
+
+		It("should not notify jobs without notification config", func() {
+			job, err := manager.Create(CreateCronRequest{
+				Name:     "Quiet",
+				Schedule: "0 0 1 1 *",
+				Command:  "fail",
+			})
+			Expect(err).ToNot(HaveOccurred())
+
+			mockExec.SetDefaultResult(MockCommandResult{ExitCode: 1})
+			_, err = manager.RunNow(job.ID)
+			Expect(err).ToNot(HaveOccurred())
+			Consistently(received, "200ms").Should(BeEmpty())
+		})
+
+		It("should pass events to the notification handler for every job", func() {
+			events := make(chan string, 4)
+			manager.SetNotificationHandler(func(job CronJob, event string, result CronExecutionResult) {
+				events <- job.Name + ":" + event
+			})
+			job, err := manager.Create(CreateCronRequest{
+				Name:     "Quiet",
+				Schedule: "0 0 1 1 *",
+				Command:  "fail",
+			})
+			Expect(err).ToNot(HaveOccurred())
+
+			mockExec.SetDefaultResult(MockCommandResult{ExitCode: 1})
+			_, err = manager.RunNow(job.ID)
+			Expect(err).ToNot(HaveOccurred())
+			Eventually(events).Should(Receive(Equal("Quiet:" + NotificationEventFailed)))
+			Consistently(received, "200ms").Should(BeEmpty())
+		})
+
+		It("should pass every execution to the execution handler", func() {
+			executions := make(chan string, 4)
+			manager.SetExecutionHandler(func(job CronJob, result CronExecutionResult) {
+				executions <- fmt.Sprintf("%s:%d", job.Name, result.ExitCode)
+			})
+			job, err := manager.Create(CreateCronRequest{
+				Name:     "Quiet",
+				Schedule: "0 0 1 1 *",
+				Command:  "ok",
+			})
+			Expect(err).ToNot(HaveOccurred())
+
+			_, err = manager.RunNow(job.ID)
+			Expect(err).ToNot(HaveOccurred())
+			Eventually(executions).Should(Receive(Equal("Quiet:0")))
+
+			mockExec.SetDefaultResult(MockCommandResult{ExitCode: 3})
+			_, err = manager.RunNow(job.ID)
+			Expect(err).ToNot(HaveOccurred())
+			Eventually(executions).Should(Receive(Equal("Quiet:3")))
+		})
+
+		It("should require configured channels and notify them", func() {
+			posted := make(chan string, 4)
+			chat := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				var body map[string]string
+				_ = json.NewDecoder(r.Body).Decode(&body)
+				posted <- body["content"]
+			}))
+			defer chat.Close()
+			channels, err := notify.NewChannels(map[string]notify.ChannelConfig{
+				"ops": {Type: notify.ChannelDiscord, WebhookURL: chat.URL},
+			})
+			Expect(err).ToNot(HaveOccurred())
+			manager.SetNotificationChannels(channels)
+
+			_, err = manager.Create(CreateCronRequest{
+				Name:          "Unknown",
+				Schedule:      "0 0 1 1 *",
+				Command:       "fail",
+				Notifications: &CronNotificationConfig{Channels: []string{"nope"}},
+			})
+			Expect(err).To(MatchError(ContainSubstring(`unknown notification channel "nope"`)))
+
+			job, err := manager.Create(CreateCronRequest{
+				Name:          "Backup",
+				Schedule:      "0 0 1 1 *",
+				Command:       "fail",
+				Notifications: &CronNotificationConfig{Channels: []string{"ops"}},
+			})
+			Expect(err).ToNot(HaveOccurred())
+
+			mockExec.SetDefaultResult(MockCommandResult{Stdout: "disk full", ExitCode: 2})
+			_, err = manager.RunNow(job.ID)
+			Expect(err).ToNot(HaveOccurred())
+			var content string
+			Eventually(posted).Should(Receive(&content))
+			Expect(content).To(ContainSubstring(`**Cron job "Backup" failed**`))
+			Expect(content).To(HaveSuffix("code 2\ndisk full"))
+		})
+	})
+})
@@ -0,0 +1,100 @@
+package cron
+
+import (
+	"errors"
+	"log"
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+// oneShotSchedule fires once at a fixed time and never again
+type oneShotSchedule struct {
+	at time.Time
+}
+
+// Next returns the run time until it has passed, then the zero time, which
+// the scheduler treats as "never"
+func (s oneShotSchedule) Next(t time.Time) time.Time {
+	if t.Before(s.at) {
+		return s.at
+	}
+	return time.Time{}
+}
+
+// validateRunAt checks that a one-shot run time is not combined with a
+// recurring schedule or upstream jobs
+func validateRunAt(runAt int64, schedule string, runAfter []string) error {
+	if runAt == 0 {
+		return nil
+	}
+	if runAt < 0 {
+		return errors.New("run_at must be a unix timestamp")
+	}
+	if schedule != "" {
+		return errors.New("run_at cannot be combined with schedule")
+	}
+	if len(runAfter) > 0 {
+		return errors.New("run_at cannot be combined with run_after")
+	}
+	return nil
+}
+
+// jobNextRunTime returns the next run of a job: its run_at time for pending
+// one-shot jobs, the zero time once they have completed, and otherwise the
+// next run of its schedule
+func jobNextRunTime(job *CronJob, from time.Time) time.Time {
+	if job.RunAt != 0 {
+		if job.Metadata.CompletedAt != 0 {
+			return time.Time{}
+		}
+		return time.Unix(job.RunAt, 0)
+	}
+	return nextRunTime(scheduleSpec(job.Schedule, job.Timezone), from)
+}
+
+// scheduleOneShotLocked schedules a pending one-shot job. A run time that
+// passed while the server was down or the job was disabled runs immediately.
+// Must be called with m.mu already held.
+func (m *CronManager) scheduleOneShotLocked(job *CronJob) error {
+	if job.Metadata.CompletedAt != 0 {
+		job.Metadata.NextRunAt = 0
+		return nil
+	}
+
+	job.Metadata.NextRunAt = job.RunAt
+	at := time.Unix(job.RunAt, 0)
+	if !at.After(time.Now()) {
+		log.Printf("[Cron] One-shot job %s missed its run time, running now", job.ID)
+		go m.executeJob(job.ID)
+		return nil
+	}
+
+	entryID := m.cron.Schedule(oneShotSchedule{at: at}, cron.FuncJob(func() {
+		m.runScheduled(job.ID)
+	}))
+	m.jobsByID[entryID] = job
+	return nil
+}
+
+// completeOneShotLocked marks a one-shot job as completed so it is never
+// scheduled again. Must be called with m.mu already held.
+func (m *CronManager) completeOneShotLocked(job *CronJob) {
+	m.unscheduleJobLocked(job.ID)
+	job.Metadata.CompletedAt = time.Now().Unix()
+	job.Metadata.NextRunAt = 0
+	log.Printf("[Cron] One-shot job %s completed", job.ID)
+}
+
+// runMissedOneShotsLocked starts pending one-shot jobs whose run time passed,
+// e.g. while scheduling was suspended. Must be called with m.mu already held.
+func (m *CronManager) runMissedOneShotsLocked() {
+	now := time.Now().Unix()
+	for _, job := range m.jobs {
+		if job.Enabled && job.RunAt != 0 && job.Metadata.CompletedAt == 0 &&
+			job.Metadata.ConcurrentRuns == 0 && job.RunAt <= now {
+			log.Printf("[Cron] One-shot job %s missed its run time, running now", job.ID)
+			go m.executeJob(job.ID)
+		}
+	}
+}
@@ -0,0 +1,123 @@
+package cron
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("One-shot jobs", func() {
+	var (
+		tempDir  string
+		cronFile string
+		manager  *CronManager
+		mockExec *MockCommandExecutor
+	)
+
+	BeforeEach(func() {
+		var err error
+		tempDir, err = os.MkdirTemp("", "cron-oneshot-*")
+		Expect(err).ToNot(HaveOccurred())
+		cronFile = filepath.Join(tempDir, "crons.json")
+		manager, err = NewCronManager(cronFile, 100)
+		Expect(err).ToNot(HaveOccurred())
+
+		mockExec = NewMockCommandExecutor()
+		manager.executor = NewCronExecutorWithOptions(DefaultCronExecutorConfig(), WithMockExecutor(mockExec))
+	})
+
+	AfterEach(func() {
+		manager.Stop()
+		os.RemoveAll(tempDir)
+	})
+
+	It("should validate run_at", func() {
+		future := time.Now().Add(time.Hour).Unix()
+
+		_, err := manager.Create(CreateCronRequest{Name: "Past", RunAt: time.Now().Add(-time.Hour).Unix(), Command: "echo", Enabled: true})
+		Expect(err).To(MatchError("run_at must be in the future"))
+
+		_, err = manager.Create(CreateCronRequest{Name: "Both", Schedule: "0 0 * * *", RunAt: future, Command: "echo", Enabled: true})
+		Expect(err).To(MatchError("run_at cannot be combined with schedule"))
+
+		job, err := manager.Create(CreateCronRequest{Name: "Once", RunAt: future, Command: "echo", Enabled: true})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(job.RunAt).To(Equal(future))
+		Expect(job.Metadata.NextRunAt).To(Equal(future))
+	})
+
+	It("should complete after running and never reschedule", func() {
+		job, err := manager.Create(CreateCronRequest{Name: "Once", RunAt: time.Now().Add(time.Hour).Unix(), Command: "echo once", Enabled: true})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(manager.jobsByID).To(HaveLen(1))
+
+		manager.executeJob(job.ID)
+		Expect(mockExec.GetExecutedCommands()).To(HaveLen(1))
+
+		loaded, err := manager.Get(job.ID)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(loaded.Metadata.CompletedAt).ToNot(BeZero())
+		Expect(loaded.Metadata.NextRunAt).To(BeZero())
+		Expect(loaded.Metadata.LastRunStatus).To(Equal("success"))
+		Expect(manager.jobsByID).To(BeEmpty())
+
+		// Completion survives a restart
+		reloaded, err := NewCronManager(cronFile, 100)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(reloaded.Start()).To(Succeed())
+		defer reloaded.Stop()
+		Expect(reloaded.jobsByID).To(BeEmpty())
+	})
+
+	It("should re-arm a completed job when run_at changes", func() {
+		job, err := manager.Create(CreateCronRequest{Name: "Once", RunAt: time.Now().Add(time.Hour).Unix(), Command: "echo", Enabled: true})
+		Expect(err).ToNot(HaveOccurred())
+		manager.executeJob(job.ID)
+
+		next := time.Now().Add(2 * time.Hour).Unix()
+		updated, err := manager.Update(job.ID, UpdateCronRequest{RunAt: &next})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(updated.Metadata.CompletedAt).To(BeZero())
+		Expect(updated.Metadata.NextRunAt).To(Equal(next))
+		Expect(manager.jobsByID).To(HaveLen(1))
+	})
+
+	It("should leave completed jobs out of exports so they import again", func() {
+		job, err := manager.Create(CreateCronRequest{Name: "Once", RunAt: time.Now().Add(time.Hour).Unix(), Command: "echo", Enabled: true})
+		Expect(err).ToNot(HaveOccurred())
+		_, err = manager.Create(CreateCronRequest{Name: "Pending", RunAt: time.Now().Add(time.Hour).Unix(), Command: "echo", Enabled: true})
+		Expect(err).ToNot(HaveOccurred())
+		manager.executeJob(job.ID)
+
+		export := manager.Export()
+		Expect(export.Jobs).To(HaveLen(1))
+		Expect(export.Jobs[0].Name).To(Equal("Pending"))
+
+		other, err := NewCronManager(filepath.Join(tempDir, "other.json"), 100)
+		Expect(err).ToNot(HaveOccurred())
+		imported, err := other.Import(export.Jobs)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(imported).To(HaveLen(1))
+		Expect(imported[0].RunAt).To(Equal(export.Jobs[0].RunAt))
+	})
+
+	It("should run a missed job when scheduling resumes", func() {
+		job, err := manager.Create(CreateCronRequest{Name: "Once", RunAt: time.Now().Add(time.Hour).Unix(), Command: "echo missed", Enabled: true})
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(manager.Suspend()).To(Succeed())
+		manager.mu.Lock()
+		manager.jobs[job.ID].RunAt = time.Now().Add(-time.Minute).Unix()
+		manager.mu.Unlock()
+
+		Expect(manager.Resume()).To(Succeed())
+		Eventually(mockExec.GetExecutedCommands).Should(HaveLen(1))
+		Eventually(func() int64 {
+			loaded, _ := manager.Get(job.ID)
+			return loaded.Metadata.CompletedAt
+		}).ShouldNot(BeZero())
+	})
+})
@@ -0,0 +1,132 @@
+package cron
+
+import (
+	"sort"
+	"time"
+)
+
+// Overview limits
+const (
+	OverviewWindow        = 24 * time.Hour // period the run statistics cover
+	OverviewUpcomingCount = 10             // upcoming runs listed
+)
+
+// CronOverview summarizes all jobs for the cron dashboard
+type CronOverview struct {
+	Jobs        CronJobCounts          `json:"jobs"`
+	Last24h     CronRunStats           `json:"last_24h"`
+	Running     []CronRunningExecution `json:"running"`  // in-flight executions, oldest first
+	Upcoming    []CronUpcomingRun      `json:"upcoming"` // next runs across all jobs, soonest first
+	Suspended   bool                   `json:"suspended"`
+	GeneratedAt int64                  `json:"generated_at"` // unix timestamp
+}
+
+// CronJobCounts counts jobs by state
+type CronJobCounts struct {
+	Total    int `json:"total"`
+	Enabled  int `json:"enabled"`
+	Disabled int `json:"disabled"`
+}
+
+// CronRunStats tallies recorded executions. Every attempt of a retried run
+// counts; skipped overlapping ticks are only counted in Skipped.
+type CronRunStats struct {
+	Total       int     `json:"total"`
+	Succeeded   int     `json:"succeeded"`
+	Failed      int     `json:"failed"`    // timed-out runs included
+	TimedOut    int     `json:"timed_out"` // runs stopped at their timeout
+	Skipped     int     `json:"skipped"`
+	SuccessRate float64 `json:"success_rate"` // Succeeded/Total from 0 to 1, 0 without runs
+}
+
+// CronRunningExecution is an in-flight execution in the overview
+type CronRunningExecution struct {
+	JobID       string `json:"job_id"`
+	JobName     string `json:"job_name"`
+	ExecutionID string `json:"execution_id"`
+	StartedAt   int64  `json:"started_at"` // unix timestamp
+}
+
+// CronUpcomingRun is a scheduled run in the overview
+type CronUpcomingRun struct {
+	JobID   string `json:"job_id"`
+	JobName string `json:"job_name"`
+	RunAt   int64  `json:"run_at"` // unix timestamp
+}
+
+// Overview returns job counts, statistics of the runs recorded in the last
+// 24 hours, the in-flight executions and the next runs of enabled jobs.
+// Statistics only cover the history that is kept, see
+// TERMINAL_HUB_CRON_HISTORY_SIZE.
+func (m *CronManager) Overview(now time.Time) CronOverview {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	overview := CronOverview{
+		Last24h:     m.executions.Stats(now.Add(-OverviewWindow).Unix()),
+		Running:     make([]CronRunningExecution, 0, len(m.live)),
+		Upcoming:    make([]CronUpcomingRun, 0, OverviewUpcomingCount),
+		Suspended:   m.suspended,
+		GeneratedAt: now.Unix(),
+	}
+
+	for _, job := range m.jobs {
+		overview.Jobs.Total++
+		if !job.Enabled {
+			overview.Jobs.Disabled++
+			continue
+		}
+		overview.Jobs.Enabled++
+		overview.Upcoming = append(overview.Upcoming, upcomingRuns(job, now, OverviewUpcomingCount)...)
+	}
+	sort.Slice(overview.Upcoming, func(i, j int) bool {
+		if overview.Upcoming[i].RunAt != overview.Upcoming[j].RunAt {
+			return overview.Upcoming[i].RunAt < overview.Upcoming[j].RunAt
+		}
+		return overview.Upcoming[i].JobName < overview.Upcoming[j].JobName
+	})
+	if len(overview.Upcoming) > OverviewUpcomingCount {
+		overview.Upcoming = overview.Upcoming[:OverviewUpcomingCount]
+	}
+
+	for _, live := range m.live {
+		running := CronRunningExecution{
+			JobID:       live.JobID,
+			ExecutionID: live.ExecutionID,
+			StartedAt:   live.StartedAt,
+		}
+		if job, ok := m.jobs[live.JobID]; ok {
+			running.JobName = job.Name
+		} else if live.JobID == AdHocJobID {
+			running.JobName = adHocJobName
+		}
+		overview.Running = append(overview.Running, running)
+	}
+	sort.Slice(overview.Running, func(i, j int) bool {
+		return overview.Running[i].StartedAt < overview.Running[j].StartedAt
+	})
+	return overview
+}
+
+// upcomingRuns returns up to count runs of job after now: its run_at time
+// while a one-shot job is pending, otherwise the next times of its schedule.
+// Chain-only jobs have none.
+func upcomingRuns(job *CronJob, now time.Time, count int) []CronUpcomingRun {
+	var times []time.Time
+	if job.RunAt != 0 || job.Schedule == "" {
+		if next := jobNextRunTime(job, now); !next.IsZero() {
+			times = append(times, next)
+		}
+	} else {
+		times, _ = CalculateNextRunTimes(scheduleSpec(job.Schedule, job.Timezone), now, count)
+	}
+
+	runs := make([]CronUpcomingRun, 0, len(times))
+	for _, t := range times {
+		if t.IsZero() {
+			break // the schedule never fires again
+		}
+		runs = append(runs, CronUpcomingRun{JobID: job.ID, JobName: job.Name, RunAt: t.Unix()})
+	}
+	return runs
+}
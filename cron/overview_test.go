@@ -0,0 +1,75 @@
+package cron
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Overview", func() {
+	var (
+		tempDir string
+		manager *CronManager
+	)
+
+	BeforeEach(func() {
+		var err error
+		tempDir, err = os.MkdirTemp("", "cron-overview-*")
+		Expect(err).ToNot(HaveOccurred())
+		manager, err = NewCronManager(filepath.Join(tempDir, "crons.json"), 100)
+		Expect(err).ToNot(HaveOccurred())
+		manager.executor = NewCronExecutorWithOptions(DefaultCronExecutorConfig(), WithMockExecutor(NewMockCommandExecutor()))
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(tempDir)
+	})
+
+	It("should tally the last 24 hours of history", func() {
+		now := time.Now()
+		day := int64(OverviewWindow / time.Second)
+		for _, exec := range []CronExecutionResult{
+			{JobID: "a", ExecutionID: "old", StartedAt: now.Unix() - day - 60, FinishedAt: now.Unix() - day - 59, ExitCode: 1},
+			{JobID: "a", ExecutionID: "ok", StartedAt: now.Unix() - 300, FinishedAt: now.Unix() - 299},
+			{JobID: "a", ExecutionID: "failed", StartedAt: now.Unix() - 200, FinishedAt: now.Unix() - 199, ExitCode: 2},
+			{JobID: "a", ExecutionID: "timeout", StartedAt: now.Unix() - 100, FinishedAt: now.Unix() - 40, ExitCode: -1, TimedOut: true},
+			{JobID: "a", ExecutionID: "skipped", StartedAt: now.Unix() - 60, FinishedAt: now.Unix() - 60, ExitCode: -1, Overlap: OverlapSkipped},
+			{JobID: "a", ExecutionID: "ok2", StartedAt: now.Unix() - 30, FinishedAt: now.Unix() - 29},
+		} {
+			manager.executions.Add(exec)
+		}
+
+		stats := manager.Overview(now).Last24h
+		Expect(stats).To(Equal(CronRunStats{
+			Total: 4, Succeeded: 2, Failed: 2, TimedOut: 1, Skipped: 1, SuccessRate: 0.5,
+		}))
+	})
+
+	It("should merge upcoming runs across jobs and list running executions", func() {
+		now := time.Date(2026, 5, 1, 10, 0, 30, 0, time.Local)
+		_, err := manager.Create(CreateCronRequest{Name: "Quarter", Schedule: "*/15 * * * *", Command: "echo q", Enabled: true})
+		Expect(err).ToNot(HaveOccurred())
+		_, err = manager.Create(CreateCronRequest{Name: "Hourly", Schedule: "0 * * * *", Command: "echo h", Enabled: true})
+		Expect(err).ToNot(HaveOccurred())
+		_, err = manager.Create(CreateCronRequest{Name: "Paused", Schedule: "* * * * *", Command: "echo p"})
+		Expect(err).ToNot(HaveOccurred())
+		live := manager.startLiveExecution(AdHocJobID)
+
+		overview := manager.Overview(now)
+		Expect(overview.Jobs).To(Equal(CronJobCounts{Total: 3, Enabled: 2, Disabled: 1}))
+		Expect(overview.Upcoming).To(HaveLen(OverviewUpcomingCount))
+		names := make([]string, 0, 5)
+		for _, run := range overview.Upcoming[:5] {
+			names = append(names, run.JobName)
+		}
+		// 10:15, 10:30, 10:45, then 11:00 for both jobs
+		Expect(names).To(Equal([]string{"Quarter", "Quarter", "Quarter", "Hourly", "Quarter"}))
+		Expect(overview.Upcoming[0].RunAt).To(Equal(now.Add(14*time.Minute + 30*time.Second).Unix()))
+		Expect(overview.Running).To(ConsistOf(CronRunningExecution{
+			JobID: AdHocJobID, JobName: adHocJobName, ExecutionID: live.ExecutionID, StartedAt: live.StartedAt,
+		}))
+	})
+})
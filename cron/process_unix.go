@@ -0,0 +1,27 @@
+//go:build !windows
+
+package cron
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// setProcessGroup makes cmd start as the leader of a new process group, so
+// the processes it starts, in the background too, can be stopped with it
+func setProcessGroup(cmd *exec.Cmd) {
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	cmd.SysProcAttr.Setpgid = true
+}
+
+// terminateProcessGroup sends SIGTERM to every process in the group pgid
+func terminateProcessGroup(pgid int) error {
+	return syscall.Kill(-pgid, syscall.SIGTERM)
+}
+
+// killProcessGroup sends SIGKILL to every process in the group pgid
+func killProcessGroup(pgid int) error {
+	return syscall.Kill(-pgid, syscall.SIGKILL)
+}
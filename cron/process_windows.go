@@ -0,0 +1,27 @@
+//go:build windows
+
+package cron
+
+import (
+	"os"
+	"os/exec"
+)
+
+// setProcessGroup does nothing on Windows, which has no process groups to
+// signal; stopping a command stops only its own process
+func setProcessGroup(cmd *exec.Cmd) {}
+
+// terminateProcessGroup kills the process pgid, as Windows cannot ask it to
+// terminate
+func terminateProcessGroup(pgid int) error {
+	return killProcessGroup(pgid)
+}
+
+// killProcessGroup kills the process pgid
+func killProcessGroup(pgid int) error {
+	process, err := os.FindProcess(pgid)
+	if err != nil {
+		return err
+	}
+	return process.Kill()
+}
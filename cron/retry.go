@@ -0,0 +1,54 @@
+package cron
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	// maxRetryAttempts caps MaxRetries to keep a failing job from looping indefinitely
+	maxRetryAttempts = 10
+	// defaultRetryBackoff is used when MaxRetries is set without RetryBackoff
+	defaultRetryBackoff = 10 * time.Second
+	// maxRetryDelay caps the exponential backoff between attempts
+	maxRetryDelay = time.Hour
+)
+
+// ValidateRetryPolicy checks MaxRetries and RetryBackoff values
+func ValidateRetryPolicy(maxRetries int, retryBackoff string) error {
+	if maxRetries < 0 || maxRetries > maxRetryAttempts {
+		return fmt.Errorf("max_retries must be between 0 and %d", maxRetryAttempts)
+	}
+	if retryBackoff == "" {
+		return nil
+	}
+
+	backoff, err := time.ParseDuration(retryBackoff)
+	if err != nil {
+		return fmt.Errorf("invalid retry_backoff %q: %w", retryBackoff, err)
+	}
+	if backoff <= 0 {
+		return fmt.Errorf("retry_backoff must be positive")
+	}
+	return nil
+}
+
+// RetryDelay returns the delay before the given retry attempt (1-based),
+// doubling the job's base backoff for every previous retry
+func RetryDelay(job *CronJob, retry int) time.Duration {
+	base := defaultRetryBackoff
+	if job.RetryBackoff != "" {
+		if parsed, err := time.ParseDuration(job.RetryBackoff); err == nil && parsed > 0 {
+			base = parsed
+		}
+	}
+
+	delay := base
+	for i := 1; i < retry; i++ {
+		delay *= 2
+		if delay >= maxRetryDelay {
+			return maxRetryDelay
+		}
+	}
+	return delay
+}
@@ -0,0 +1,167 @@
+package cron
+
+import (
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Retry policy", func() {
+	Describe("ValidateRetryPolicy", func() {
+		It("should accept valid policies", func() {
+			Expect(ValidateRetryPolicy(0, "")).To(Succeed())
+			Expect(ValidateRetryPolicy(3, "30s")).To(Succeed())
+		})
+
+		It("should reject out-of-range retries and bad durations", func() {
+			Expect(ValidateRetryPolicy(-1, "")).ToNot(Succeed())
+			Expect(ValidateRetryPolicy(maxRetryAttempts+1, "")).ToNot(Succeed())
+			Expect(ValidateRetryPolicy(1, "soon")).ToNot(Succeed())
+			Expect(ValidateRetryPolicy(1, "-5s")).ToNot(Succeed())
+		})
+	})
+
+	Describe("RetryDelay", func() {
+		It("should double the backoff for every retry", func() {
+			job := &CronJob{RetryBackoff: "1s"}
+			Expect(RetryDelay(job, 1)).To(Equal(time.Second))
+			Expect(RetryDelay(job, 2)).To(Equal(2 * time.Second))
+			Expect(RetryDelay(job, 3)).To(Equal(4 * time.Second))
+		})
+
+		It("should fall back to the default backoff and cap the delay", func() {
+			Expect(RetryDelay(&CronJob{}, 1)).To(Equal(defaultRetryBackoff))
+			Expect(RetryDelay(&CronJob{RetryBackoff: "30m"}, 5)).To(Equal(maxRetryDelay))
+		})
+	})
+
+	Describe("Scheduled execution", func() {
+		var (
+			tempDir  string
+			manager  *CronManager
+			mockExec *MockCommandExecutor
+		)
+
+		BeforeEach(func() {
+			var err error
+			tempDir, err = os.MkdirTemp("", "cron-retry-*")
+			Expect(err).ToNot(HaveOccurred())
+			manager, err = NewCronManager(filepath.Join(tempDir, "crons.json"), 100)
+			Expect(err).ToNot(HaveOccurred())
+
+			mockExec = NewMockCommandExecutor()
+			manager.executor = NewCronExecutorWithOptions(DefaultCronExecutorConfig(), WithMockExecutor(mockExec))
+		})
+
+		AfterEach(func() {
+			os.RemoveAll(tempDir)
+		})
+
+		It("should retry until success and record every attempt", func() {
+			var calls int32
+			mockExec.SetOnExecute(func(command string) MockCommandResult {
+				if atomic.AddInt32(&calls, 1) < 3 {
+					return MockCommandResult{ExitCode: 1}
+				}
+				return MockCommandResult{Stdout: "done"}
+			})
+
+			job, err := manager.Create(CreateCronRequest{
+				Name: "Retry", Schedule: "0 0 1 1 *", Command: "flaky",
+				MaxRetries: 3, RetryBackoff: "10ms",
+			})
+			Expect(err).ToNot(HaveOccurred())
+
+			manager.executeJob(job.ID)
+
+			history, err := manager.GetHistory(job.ID)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(history).To(HaveLen(3))
+			Expect(history[0].Attempt).To(Equal(1))
+			Expect(history[0].ExitCode).To(Equal(1))
+			Expect(history[2].Attempt).To(Equal(3))
+			Expect(history[2].ExitCode).To(Equal(0))
+
+			updated, err := manager.Get(job.ID)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(updated.Metadata.LastRunStatus).To(Equal("success"))
+			Expect(updated.Metadata.FailureCount).To(Equal(0))
+		})
+
+		It("should record the job as failed after exhausting retries", func() {
+			mockExec.SetDefaultResult(MockCommandResult{ExitCode: 2})
+
+			job, err := manager.Create(CreateCronRequest{
+				Name: "Always fails", Schedule: "0 0 1 1 *", Command: "fail",
+				MaxRetries: 2, RetryBackoff: "5ms",
+			})
+			Expect(err).ToNot(HaveOccurred())
+
+			manager.executeJob(job.ID)
+
+			history, err := manager.GetHistory(job.ID)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(history).To(HaveLen(3))
+			Expect(mockExec.GetExecutedCommands()).To(HaveLen(3))
+
+			updated, err := manager.Get(job.ID)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(updated.Metadata.LastRunStatus).To(Equal("failed"))
+			Expect(updated.Metadata.FailureCount).To(Equal(1))
+		})
+
+		It("should record an attempt once when the run is cancelled during the backoff", func() {
+			mockExec.SetDefaultResult(MockCommandResult{ExitCode: 1})
+
+			job, err := manager.Create(CreateCronRequest{
+				Name: "Cancelled", Schedule: "0 0 1 1 *", Command: "fail",
+				MaxRetries: 2, RetryBackoff: "1h",
+			})
+			Expect(err).ToNot(HaveOccurred())
+
+			done := make(chan struct{})
+			go func() {
+				defer close(done)
+				manager.executeJob(job.ID)
+			}()
+
+			Eventually(func() ([]CronExecutionResult, error) {
+				return manager.GetHistory(job.ID)
+			}).Should(HaveLen(1))
+
+			manager.mu.Lock()
+			for run := range manager.running[job.ID] {
+				run.cancel()
+			}
+			manager.mu.Unlock()
+			Eventually(done).Should(BeClosed())
+
+			history, err := manager.GetHistory(job.ID)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(history).To(HaveLen(1))
+			Expect(history[0].Attempt).To(Equal(1))
+
+			updated, err := manager.Get(job.ID)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(updated.Metadata.LastRunStatus).To(Equal("failed"))
+		})
+
+		It("should not retry when retries are disabled", func() {
+			mockExec.SetDefaultResult(MockCommandResult{ExitCode: 1})
+
+			job, err := manager.Create(CreateCronRequest{Name: "Once", Schedule: "0 0 1 1 *", Command: "fail"})
+			Expect(err).ToNot(HaveOccurred())
+
+			manager.executeJob(job.ID)
+
+			history, err := manager.GetHistory(job.ID)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(history).To(HaveLen(1))
+			Expect(history[0].Attempt).To(BeZero())
+		})
+	})
+})
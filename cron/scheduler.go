@@ -105,3 +105,48 @@ func FormatScheduleDescription(schedule string) string {
 	}
 	return schedule
 }
+
+// Schedule preview limits for PreviewSchedule
+const (
+	DefaultPreviewCount = 5
+	MaxPreviewCount     = 50
+)
+
+// PreviewSchedule validates a schedule and describes its upcoming run times.
+// Invalid schedules are reported in the response rather than as an error so
+// callers can show the problem while the user is still typing.
+func PreviewSchedule(schedule string, fromTime time.Time, count int) PreviewScheduleResponse {
+	return PreviewScheduleInTimezone(schedule, "", fromTime, count)
+}
+
+// PreviewScheduleInTimezone is PreviewSchedule with the schedule evaluated in
+// an IANA timezone; an empty timezone uses server local time
+func PreviewScheduleInTimezone(schedule, timezone string, fromTime time.Time, count int) PreviewScheduleResponse {
+	preview := PreviewScheduleResponse{
+		Schedule: schedule,
+		Timezone: timezone,
+		NextRuns: []int64{},
+	}
+
+	if err := ValidateSchedule(schedule); err != nil {
+		preview.Error = err.Error()
+		return preview
+	}
+	if err := ValidateTimezone(timezone); err != nil {
+		preview.Error = err.Error()
+		return preview
+	}
+
+	runTimes, err := CalculateNextRunTimes(scheduleSpec(schedule, timezone), fromTime, count)
+	if err != nil {
+		preview.Error = err.Error()
+		return preview
+	}
+
+	preview.Valid = true
+	preview.Description = FormatScheduleDescription(schedule)
+	for _, runTime := range runTimes {
+		preview.NextRuns = append(preview.NextRuns, runTime.Unix())
+	}
+	return preview
+}
@@ -464,4 +464,37 @@ var _ = Describe("CronScheduler", func() {
 			})
 		})
 	})
+
+	Describe("PreviewSchedule", func() {
+		baseTime := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+		It("should return the description and upcoming runs", func() {
+			preview := PreviewSchedule("0 * * * *", baseTime, 3)
+			Expect(preview.Valid).To(BeTrue())
+			Expect(preview.Description).To(Equal("Every hour"))
+			Expect(preview.NextRuns).To(Equal([]int64{
+				baseTime.Add(time.Hour).Unix(),
+				baseTime.Add(2 * time.Hour).Unix(),
+				baseTime.Add(3 * time.Hour).Unix(),
+			}))
+		})
+
+		It("should report invalid schedules without run times", func() {
+			preview := PreviewSchedule("not a schedule", baseTime, 3)
+			Expect(preview.Valid).To(BeFalse())
+			Expect(preview.Error).To(ContainSubstring("invalid cron expression"))
+			Expect(preview.NextRuns).To(BeEmpty())
+		})
+
+		It("should evaluate the schedule in the given timezone", func() {
+			preview := PreviewScheduleInTimezone("0 9 * * *", "Asia/Seoul", baseTime, 1)
+			Expect(preview.Valid).To(BeTrue())
+			// 09:00 KST is 00:00 UTC the next day
+			Expect(preview.NextRuns).To(Equal([]int64{time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC).Unix()}))
+
+			preview = PreviewScheduleInTimezone("0 9 * * *", "Mars/Olympus", baseTime, 1)
+			Expect(preview.Valid).To(BeFalse())
+			Expect(preview.Error).To(ContainSubstring("invalid timezone"))
+		})
+	})
 })
@@ -0,0 +1,143 @@
+package cron
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"maps"
+	"strings"
+	"sync"
+)
+
+// secretRefPrefix marks an environment variable value that names a secret in
+// the credential store, e.g. "secret://db-password"
+const secretRefPrefix = "secret://"
+
+// redactedSecret replaces secret values in recorded output
+const redactedSecret = "[REDACTED]"
+
+// SecretLookup returns the value of the secret stored under a credential name
+type SecretLookup func(name string) ([]byte, error)
+
+// SetSecretLookup configures how "secret://" environment variable values are
+// resolved. Without one, jobs that reference secrets fail to run.
+func (m *CronManager) SetSecretLookup(lookup SecretLookup) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.secretLookup = lookup
+}
+
+// secretRef returns the credential name a value references, if it does
+func secretRef(value string) (string, bool) {
+	return strings.CutPrefix(value, secretRefPrefix)
+}
+
+// ValidateSecretRefs checks that "secret://" values name a credential
+func ValidateSecretRefs(envVars map[string]string) error {
+	for name, value := range envVars {
+		if ref, ok := secretRef(value); ok && strings.TrimSpace(ref) == "" {
+			return fmt.Errorf("environment variable %s references a secret without a name", name)
+		}
+	}
+	return nil
+}
+
+// resolveSecretEnv returns job with its "secret://" environment variables
+// replaced by the secrets' values, and the values to redact from its output.
+// A job without references is returned as is.
+func resolveSecretEnv(job *CronJob, lookup SecretLookup) (*CronJob, []string, error) {
+	var env map[string]string
+	var secrets []string
+	for name, value := range job.EnvVars {
+		ref, ok := secretRef(value)
+		if !ok {
+			continue
+		}
+		if lookup == nil {
+			return nil, nil, fmt.Errorf("environment variable %s references secret %q, but the credential store is disabled", name, ref)
+		}
+		secret, err := lookup(ref)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to resolve secret %q for environment variable %s: %w", ref, name, err)
+		}
+		if env == nil {
+			env = maps.Clone(job.EnvVars)
+		}
+		env[name] = string(secret)
+		if len(secret) > 0 {
+			secrets = append(secrets, string(secret))
+		}
+	}
+	if env == nil {
+		return job, nil, nil
+	}
+
+	resolved := *job
+	resolved.EnvVars = env
+	return &resolved, secrets, nil
+}
+
+// redactSecrets replaces every secret in s
+func redactSecrets(s string, secrets []string) string {
+	for _, secret := range secrets {
+		s = strings.ReplaceAll(s, secret, redactedSecret)
+	}
+	return s
+}
+
+// redactResult removes secrets from everything a run records
+func redactResult(result *CronExecutionResult, secrets []string) {
+	result.Output = redactSecrets(result.Output, secrets)
+	result.Stdout = redactSecrets(result.Stdout, secrets)
+	result.Stderr = redactSecrets(result.Stderr, secrets)
+	result.Error = redactSecrets(result.Error, secrets)
+}
+
+// redactingWriter removes secrets from output on its way to live viewers and
+// log files. It holds back the end of what was written, which may be the
+// start of a secret split across writes, until more arrives or Flush.
+type redactingWriter struct {
+	mu      sync.Mutex
+	dst     io.Writer
+	secrets []string
+	keep    int // bytes held back: one less than the longest secret
+	pending []byte
+}
+
+func newRedactingWriter(dst io.Writer, secrets []string) *redactingWriter {
+	keep := 0
+	for _, secret := range secrets {
+		keep = max(keep, len(secret)-1)
+	}
+	return &redactingWriter{dst: dst, secrets: secrets, keep: keep}
+}
+
+func (w *redactingWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.pending = append(w.pending, p...)
+	for _, secret := range w.secrets {
+		w.pending = bytes.ReplaceAll(w.pending, []byte(secret), []byte(redactedSecret))
+	}
+	if len(w.pending) <= w.keep {
+		return len(p), nil
+	}
+	n := len(w.pending) - w.keep
+	_, err := w.dst.Write(w.pending[:n])
+	w.pending = append(w.pending[:0], w.pending[n:]...)
+	return len(p), err
+}
+
+// Flush writes what was held back, once the output is complete
+func (w *redactingWriter) Flush() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if len(w.pending) == 0 {
+		return nil
+	}
+	_, err := w.dst.Write(w.pending)
+	w.pending = w.pending[:0]
+	return err
+}
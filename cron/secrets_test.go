@@ -0,0 +1,114 @@
+package cron
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Secret environment variables", func() {
+	var (
+		tempDir string
+		manager *CronManager
+	)
+
+	BeforeEach(func() {
+		var err error
+		tempDir, err = os.MkdirTemp("", "cron-secrets-*")
+		Expect(err).ToNot(HaveOccurred())
+		manager, err = NewCronManager(filepath.Join(tempDir, "crons.json"), 100)
+		Expect(err).ToNot(HaveOccurred())
+
+		manager.SetSecretLookup(func(name string) ([]byte, error) {
+			if name == "api-token" {
+				return []byte("s3cr3t-value"), nil
+			}
+			return nil, errors.New("credential not found")
+		})
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(tempDir)
+	})
+
+	It("should resolve secrets at execution time and redact them from history", func() {
+		job, err := manager.Create(CreateCronRequest{
+			Name:     "Deploy",
+			Schedule: "0 0 1 1 *",
+			Command:  `echo "token=$API_TOKEN"; echo "$API_TOKEN" >&2; [ "$API_TOKEN" = s3cr3t-value ]`,
+			EnvVars:  map[string]string{"API_TOKEN": "secret://api-token"},
+		})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(job.EnvVars["API_TOKEN"]).To(Equal("secret://api-token"))
+
+		result, err := manager.RunNow(job.ID)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result.ExitCode).To(Equal(0))
+		Expect(result.Stdout).To(Equal("token=[REDACTED]\n"))
+		Expect(result.Stderr).To(Equal("[REDACTED]\n"))
+
+		history, err := manager.GetHistory(job.ID)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(history).To(HaveLen(1))
+		Expect(history[0].Output).ToNot(ContainSubstring("s3cr3t-value"))
+
+		stored, err := manager.Get(job.ID)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(stored.Metadata.LastRunOutput).ToNot(ContainSubstring("s3cr3t-value"))
+	})
+
+	It("should fail the run when a secret cannot be resolved", func() {
+		job, err := manager.Create(CreateCronRequest{
+			Name:     "Missing",
+			Schedule: "0 0 1 1 *",
+			Command:  "echo never",
+			EnvVars:  map[string]string{"API_TOKEN": "secret://gone"},
+		})
+		Expect(err).ToNot(HaveOccurred())
+
+		result, err := manager.RunNow(job.ID)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result.ExitCode).To(Equal(-1))
+		Expect(result.Error).To(ContainSubstring(`secret "gone"`))
+		Expect(result.Output).To(BeEmpty())
+	})
+
+	It("should not resolve references passed for a single run", func() {
+		job, err := manager.Create(CreateCronRequest{
+			Name:     "Hooked",
+			Schedule: "0 0 1 1 *",
+			Command:  `echo "ref=$REF"`,
+		})
+		Expect(err).ToNot(HaveOccurred())
+
+		result, err := manager.RunNowWithEnv(job.ID, map[string]string{"REF": "secret://api-token"})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result.Stdout).To(Equal("ref=secret://api-token\n"))
+	})
+
+	It("should reject references without a name", func() {
+		_, err := manager.Create(CreateCronRequest{
+			Name:     "Bad",
+			Schedule: "0 0 1 1 *",
+			Command:  "true",
+			EnvVars:  map[string]string{"API_TOKEN": "secret://"},
+		})
+		Expect(err).To(MatchError(ContainSubstring("without a name")))
+	})
+
+	It("should redact secrets split across writes", func() {
+		var out bytes.Buffer
+		w := newRedactingWriter(&out, []string{"hunter2"})
+		for _, chunk := range []string{"pass=hun", "ter", "2 ok; hunt", "er"} {
+			_, err := w.Write([]byte(chunk))
+			Expect(err).ToNot(HaveOccurred())
+		}
+		Expect(out.String()).ToNot(ContainSubstring("hunter2"))
+		Expect(w.Flush()).To(Succeed())
+		Expect(out.String()).To(Equal("pass=[REDACTED] ok; hunter"))
+	})
+})
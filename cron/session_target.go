@@ -0,0 +1,116 @@
+package cron
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"maps"
+	"time"
+
+	"github.com/iwanhae/terminal-hub/terminal"
+)
+
+// SessionLookup resolves a terminal session by ID
+type SessionLookup func(sessionID string) (terminal.Session, bool)
+
+// SetSessionLookup configures how jobs with a target_session_id find their session
+func (m *CronManager) SetSessionLookup(lookup SessionLookup) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.sessionLookup = lookup
+}
+
+// runJob executes a job headlessly, or types it into its target terminal
+// session. Headless runs get the job's environment, with secrets resolved,
+// overridden by env.
+func (m *CronManager) runJob(ctx context.Context, job *CronJob, env map[string]string, opts ExecuteOptions) (*CronExecutionResult, error) {
+	m.mu.RLock()
+	targetSessionID := job.TargetSessionID
+	lookup := m.sessionLookup
+	secretLookup := m.secretLookup
+	m.mu.RUnlock()
+
+	if targetSessionID != "" {
+		return executeInSession(job, targetSessionID, lookup, opts), nil
+	}
+
+	run, secrets, err := resolveSecretEnv(job, secretLookup)
+	if err != nil {
+		now := time.Now().Unix()
+		return &CronExecutionResult{
+			JobID:       job.ID,
+			ExecutionID: opts.ExecutionID,
+			StartedAt:   now,
+			FinishedAt:  now,
+			ExitCode:    -1,
+			Error:       err.Error(),
+		}, nil
+	}
+	if len(env) > 0 {
+		jobCopy := *run
+		jobCopy.EnvVars = maps.Clone(run.EnvVars)
+		if jobCopy.EnvVars == nil {
+			jobCopy.EnvVars = make(map[string]string, len(env))
+		}
+		maps.Copy(jobCopy.EnvVars, env)
+		run = &jobCopy
+	}
+	if len(secrets) == 0 {
+		return m.executor.ExecuteWithOptions(ctx, run, opts)
+	}
+
+	// Secret values never reach the history, live output or log files
+	var redacting *redactingWriter
+	if opts.Output != nil {
+		redacting = newRedactingWriter(opts.Output, secrets)
+		opts.Output = redacting
+	}
+	result, err := m.executor.ExecuteWithOptions(ctx, run, opts)
+	if redacting != nil {
+		if flushErr := redacting.Flush(); flushErr != nil {
+			log.Printf("[Cron] Failed to write live output for job %s: %v", job.ID, flushErr)
+		}
+	}
+	if result != nil {
+		redactResult(result, secrets)
+	}
+	return result, err
+}
+
+// executeInSession writes the job command into a live terminal session so attached
+// clients see it run. The command's exit code is not observable, so a run succeeds
+// once the command has been delivered to the session.
+func executeInSession(job *CronJob, sessionID string, lookup SessionLookup, opts ExecuteOptions) *CronExecutionResult {
+	startTime := time.Now()
+	result := &CronExecutionResult{
+		JobID:       job.ID,
+		ExecutionID: opts.ExecutionID,
+		StartedAt:   startTime.Unix(),
+	}
+
+	log.Printf("[Cron] Sending job %s (%s) to terminal session %s", job.ID, job.Name, sessionID)
+
+	var sess terminal.Session
+	ok := false
+	if lookup != nil {
+		sess, ok = lookup(sessionID)
+	}
+
+	if !ok {
+		result.ExitCode = -1
+		result.Error = fmt.Sprintf("target session %s not found", sessionID)
+	} else if _, err := sess.Write([]byte(job.Command + "\r")); err != nil {
+		result.ExitCode = -1
+		result.Error = fmt.Sprintf("Failed to write command to session %s: %v", sessionID, err)
+	} else {
+		result.Output = fmt.Sprintf("Command sent to terminal session %s\n", sessionID)
+		if opts.Output != nil {
+			if _, err := opts.Output.Write([]byte(result.Output)); err != nil {
+				log.Printf("[Cron] Failed to write live output for job %s: %v", job.ID, err)
+			}
+		}
+	}
+
+	result.FinishedAt = time.Now().Unix()
+	return result
+}
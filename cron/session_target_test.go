@@ -0,0 +1,115 @@
+package cron
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/iwanhae/terminal-hub/terminal"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// fakeSession records input written to it
+type fakeSession struct {
+	terminal.Session
+	mu    sync.Mutex
+	input []byte
+}
+
+func (s *fakeSession) Write(data []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.input = append(s.input, data...)
+	return len(data), nil
+}
+
+func (s *fakeSession) written() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return string(s.input)
+}
+
+var _ = Describe("Target session jobs", func() {
+	var (
+		tempDir  string
+		manager  *CronManager
+		mockExec *MockCommandExecutor
+		session  *fakeSession
+	)
+
+	BeforeEach(func() {
+		var err error
+		tempDir, err = os.MkdirTemp("", "cron-session-*")
+		Expect(err).ToNot(HaveOccurred())
+		manager, err = NewCronManager(filepath.Join(tempDir, "crons.json"), 100)
+		Expect(err).ToNot(HaveOccurred())
+
+		mockExec = NewMockCommandExecutor()
+		manager.executor = NewCronExecutorWithOptions(DefaultCronExecutorConfig(), WithMockExecutor(mockExec))
+
+		session = &fakeSession{}
+		manager.SetSessionLookup(func(sessionID string) (terminal.Session, bool) {
+			if sessionID == "live" {
+				return session, true
+			}
+			return nil, false
+		})
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(tempDir)
+	})
+
+	It("should type the command into the target session", func() {
+		job, err := manager.Create(CreateCronRequest{
+			Name:            "Interactive",
+			Schedule:        "0 0 1 1 *",
+			Command:         "apt update",
+			TargetSessionID: "live",
+		})
+		Expect(err).ToNot(HaveOccurred())
+
+		result, err := manager.RunNow(job.ID)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result.ExitCode).To(Equal(0))
+		Expect(result.Output).To(ContainSubstring("live"))
+		Expect(session.written()).To(Equal("apt update\r"))
+		Expect(mockExec.GetExecutedCommands()).To(BeEmpty())
+	})
+
+	It("should fail when the target session does not exist", func() {
+		job, err := manager.Create(CreateCronRequest{
+			Name:            "Missing",
+			Schedule:        "0 0 1 1 *",
+			Command:         "uptime",
+			TargetSessionID: "gone",
+		})
+		Expect(err).ToNot(HaveOccurred())
+
+		result, err := manager.RunNow(job.ID)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result.ExitCode).To(Equal(-1))
+		Expect(result.Error).To(ContainSubstring("not found"))
+	})
+
+	It("should run headlessly again once the target is cleared", func() {
+		job, err := manager.Create(CreateCronRequest{
+			Name:            "Toggle",
+			Schedule:        "0 0 1 1 *",
+			Command:         "echo hi",
+			TargetSessionID: "live",
+		})
+		Expect(err).ToNot(HaveOccurred())
+
+		empty := ""
+		job, err = manager.Update(job.ID, UpdateCronRequest{TargetSessionID: &empty})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(job.TargetSessionID).To(BeEmpty())
+
+		_, err = manager.RunNow(job.ID)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(mockExec.GetExecutedCommands()).To(Equal([]string{"echo hi"}))
+		Expect(session.written()).To(BeEmpty())
+	})
+})
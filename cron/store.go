@@ -0,0 +1,145 @@
+package cron
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Storage backends selectable via TERMINAL_HUB_CRON_STORE
+const (
+	StoreTypeJSON   = "json"
+	StoreTypeSQLite = "sqlite"
+)
+
+// Store persists cron jobs and execution history
+type Store interface {
+	// Load returns all persisted jobs and executions (oldest first)
+	Load() (CronData, error)
+	// Save replaces the persisted job definitions and scheduler state.
+	// data.Executions is the full in-memory history, for stores that cannot
+	// append it incrementally.
+	Save(data CronData) error
+	// AppendExecution persists one execution, keeping at most maxHistory entries
+	AppendExecution(exec CronExecutionResult, maxHistory int) error
+	// UpdateExecution rewrites a persisted execution, matched by its ID
+	UpdateExecution(exec CronExecutionResult) error
+	// Close releases any resources held by the store
+	Close() error
+	// String describes the store for logging
+	String() string
+}
+
+// jsonStore keeps jobs and executions together in one JSON file
+type jsonStore struct {
+	filePath string
+}
+
+// NewJSONStore creates a store backed by a single JSON file
+func NewJSONStore(filePath string) (Store, error) {
+	// Ensure directory exists
+	if err := os.MkdirAll(filepath.Dir(filePath), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create cron directory: %w", err)
+	}
+	return &jsonStore{filePath: filePath}, nil
+}
+
+// Load reads the cron data from the JSON file
+func (s *jsonStore) Load() (CronData, error) {
+	var cronData CronData
+
+	data, err := os.ReadFile(s.filePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			// File doesn't exist yet, that's OK
+			return cronData, nil
+		}
+		return cronData, err
+	}
+
+	if err := json.Unmarshal(data, &cronData); err != nil {
+		// Corrupt or partial JSON — start fresh
+		log.Printf("[Cron] Warning: corrupt data in %s, starting fresh: %v", s.filePath, err)
+		return CronData{}, nil
+	}
+
+	return cronData, nil
+}
+
+// Save writes jobs, state and the full execution history to the JSON file atomically
+func (s *jsonStore) Save(data CronData) error {
+	jsonData, err := json.MarshalIndent(data, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	// Atomic write: temp file + rename
+	tmpFile := s.filePath + ".tmp"
+	if err := os.WriteFile(tmpFile, jsonData, 0600); err != nil {
+		return fmt.Errorf("failed to write temp file: %w", err)
+	}
+
+	if err := os.Rename(tmpFile, s.filePath); err != nil {
+		return fmt.Errorf("failed to rename temp file: %w", err)
+	}
+
+	return nil
+}
+
+// AppendExecution is a no-op: executions are written with the next Save
+func (s *jsonStore) AppendExecution(exec CronExecutionResult, maxHistory int) error {
+	return nil
+}
+
+// UpdateExecution is a no-op: executions are written with the next Save
+func (s *jsonStore) UpdateExecution(exec CronExecutionResult) error {
+	return nil
+}
+
+// Close is a no-op for the JSON store
+func (s *jsonStore) Close() error {
+	return nil
+}
+
+func (s *jsonStore) String() string {
+	return s.filePath
+}
+
+// GetCronStoreTypeFromEnv returns the configured storage backend ("json" or "sqlite")
+func GetCronStoreTypeFromEnv() string {
+	storeType := strings.ToLower(strings.TrimSpace(os.Getenv("TERMINAL_HUB_CRON_STORE")))
+	if storeType == "" {
+		return StoreTypeJSON
+	}
+	return storeType
+}
+
+// GetCronDBPathFromEnv returns the SQLite database path from environment
+// variable or a crons.db file next to the JSON file
+func GetCronDBPathFromEnv(jsonPath string) string {
+	if path := os.Getenv("TERMINAL_HUB_CRON_DB"); path != "" {
+		return path
+	}
+	return strings.TrimSuffix(jsonPath, filepath.Ext(jsonPath)) + ".db"
+}
+
+// OpenStoreFromEnv opens the configured store. The SQLite store imports the
+// JSON file on first use; if SQLite cannot be opened the JSON store is used.
+func OpenStoreFromEnv(jsonPath string) (Store, error) {
+	switch storeType := GetCronStoreTypeFromEnv(); storeType {
+	case StoreTypeJSON:
+		return NewJSONStore(jsonPath)
+	case StoreTypeSQLite:
+		store, err := NewSQLiteStore(GetCronDBPathFromEnv(jsonPath), jsonPath)
+		if err != nil {
+			log.Printf("[Cron] Warning: failed to open SQLite store, falling back to JSON: %v", err)
+			return NewJSONStore(jsonPath)
+		}
+		return store, nil
+	default:
+		return nil, fmt.Errorf("unknown cron store %q: must be %q or %q", storeType, StoreTypeJSON, StoreTypeSQLite)
+	}
+}
@@ -0,0 +1,285 @@
+package cron
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	_ "modernc.org/sqlite" // registers the "sqlite" database/sql driver
+)
+
+// sqliteSchema creates the tables used by the SQLite store. Jobs and
+// executions are stored as JSON documents alongside the columns used for
+// ordering and lookups, so new job fields need no schema migration.
+const sqliteSchema = `
+CREATE TABLE IF NOT EXISTS jobs (
+	id   TEXT PRIMARY KEY,
+	data TEXT NOT NULL
+);
+CREATE TABLE IF NOT EXISTS executions (
+	seq          INTEGER PRIMARY KEY AUTOINCREMENT,
+	execution_id TEXT NOT NULL,
+	job_id       TEXT NOT NULL,
+	started_at   INTEGER NOT NULL,
+	exit_code    INTEGER NOT NULL,
+	data         TEXT NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_executions_job ON executions (job_id, seq);
+CREATE TABLE IF NOT EXISTS meta (
+	key   TEXT PRIMARY KEY,
+	value TEXT NOT NULL
+);
+`
+
+// Keys of the meta table
+const (
+	metaJSONMigrated = "json_migrated" // set once the JSON file has been imported
+	metaSuspended    = "suspended"     // "true" while scheduling is paused
+)
+
+// sqliteStore keeps jobs and executions in a SQLite database so each run
+// only appends a row instead of rewriting the whole history
+type sqliteStore struct {
+	db     *sql.DB
+	dbPath string
+}
+
+// NewSQLiteStore opens (or creates) the SQLite database at dbPath. On first
+// use, jobs and executions from the JSON file at jsonPath are imported; the
+// JSON file itself is left untouched.
+func NewSQLiteStore(dbPath, jsonPath string) (Store, error) {
+	if err := os.MkdirAll(filepath.Dir(dbPath), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create cron directory: %w", err)
+	}
+
+	db, err := sql.Open("sqlite", "file:"+dbPath+"?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)")
+	if err != nil {
+		return nil, fmt.Errorf("failed to open SQLite database: %w", err)
+	}
+	// SQLite allows a single writer; serialize access through one connection
+	db.SetMaxOpenConns(1)
+
+	if _, err := db.Exec(sqliteSchema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to initialize SQLite schema: %w", err)
+	}
+	if err := os.Chmod(dbPath, 0600); err != nil {
+		log.Printf("[Cron] Warning: failed to restrict permissions on %s: %v", dbPath, err)
+	}
+
+	store := &sqliteStore{db: db, dbPath: dbPath}
+	if err := store.migrateFromJSON(jsonPath); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return store, nil
+}
+
+// migrateFromJSON imports the JSON file once
+func (s *sqliteStore) migrateFromJSON(jsonPath string) error {
+	var migrated string
+	err := s.db.QueryRow(`SELECT value FROM meta WHERE key = ?`, metaJSONMigrated).Scan(&migrated)
+	if err == nil {
+		return nil
+	}
+	if err != sql.ErrNoRows {
+		return fmt.Errorf("failed to read migration state: %w", err)
+	}
+
+	data := CronData{}
+	if jsonPath != "" {
+		data, err = (&jsonStore{filePath: jsonPath}).Load()
+		if err != nil {
+			return fmt.Errorf("failed to read %s for migration: %w", jsonPath, err)
+		}
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := replaceJobsTx(tx, data.Jobs); err != nil {
+		return fmt.Errorf("failed to migrate jobs: %w", err)
+	}
+	if err := setMetaTx(tx, metaSuspended, strconv.FormatBool(data.Suspended)); err != nil {
+		return fmt.Errorf("failed to migrate state: %w", err)
+	}
+	for _, exec := range data.Executions {
+		if err := insertExecutionTx(tx, exec); err != nil {
+			return fmt.Errorf("failed to migrate executions: %w", err)
+		}
+	}
+	if err := setMetaTx(tx, metaJSONMigrated, jsonPath); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	if len(data.Jobs) > 0 || len(data.Executions) > 0 {
+		log.Printf("[Cron] Migrated %d jobs and %d executions from %s to %s",
+			len(data.Jobs), len(data.Executions), jsonPath, s.dbPath)
+	}
+	return nil
+}
+
+// Load reads all jobs and executions from the database
+func (s *sqliteStore) Load() (CronData, error) {
+	cronData := CronData{}
+
+	var suspended string
+	err := s.db.QueryRow(`SELECT value FROM meta WHERE key = ?`, metaSuspended).Scan(&suspended)
+	if err != nil && err != sql.ErrNoRows {
+		return cronData, err
+	}
+	cronData.Suspended = suspended == "true"
+
+	rows, err := s.db.Query(`SELECT data FROM jobs ORDER BY id`)
+	if err != nil {
+		return cronData, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var raw string
+		if err := rows.Scan(&raw); err != nil {
+			return cronData, err
+		}
+		var job CronJob
+		if err := json.Unmarshal([]byte(raw), &job); err != nil {
+			log.Printf("[Cron] Warning: skipping corrupt job row in %s: %v", s.dbPath, err)
+			continue
+		}
+		cronData.Jobs = append(cronData.Jobs, job)
+	}
+	if err := rows.Err(); err != nil {
+		return cronData, err
+	}
+
+	execRows, err := s.db.Query(`SELECT data FROM executions ORDER BY seq`)
+	if err != nil {
+		return cronData, err
+	}
+	defer execRows.Close()
+	for execRows.Next() {
+		var raw string
+		if err := execRows.Scan(&raw); err != nil {
+			return cronData, err
+		}
+		var exec CronExecutionResult
+		if err := json.Unmarshal([]byte(raw), &exec); err != nil {
+			log.Printf("[Cron] Warning: skipping corrupt execution row in %s: %v", s.dbPath, err)
+			continue
+		}
+		cronData.Executions = append(cronData.Executions, exec)
+	}
+
+	return cronData, execRows.Err()
+}
+
+// Save replaces the stored jobs and state in a single transaction.
+// Executions are appended individually, so data.Executions is ignored.
+func (s *sqliteStore) Save(data CronData) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := replaceJobsTx(tx, data.Jobs); err != nil {
+		return err
+	}
+	if err := setMetaTx(tx, metaSuspended, strconv.FormatBool(data.Suspended)); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// AppendExecution inserts an execution and trims history beyond maxHistory
+func (s *sqliteStore) AppendExecution(exec CronExecutionResult, maxHistory int) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := insertExecutionTx(tx, exec); err != nil {
+		return err
+	}
+	if maxHistory > 0 {
+		if _, err := tx.Exec(
+			`DELETE FROM executions WHERE seq <= (SELECT MAX(seq) FROM executions) - ?`,
+			maxHistory,
+		); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// UpdateExecution rewrites the data of a stored execution
+func (s *sqliteStore) UpdateExecution(exec CronExecutionResult) error {
+	data, err := json.Marshal(exec)
+	if err != nil {
+		return err
+	}
+	_, err = s.db.Exec(
+		`UPDATE executions SET exit_code = ?, data = ? WHERE execution_id = ?`,
+		exec.ExitCode, string(data), exec.ExecutionID,
+	)
+	return err
+}
+
+// Close closes the database
+func (s *sqliteStore) Close() error {
+	return s.db.Close()
+}
+
+func (s *sqliteStore) String() string {
+	return s.dbPath
+}
+
+// replaceJobsTx overwrites the jobs table with the given jobs
+func replaceJobsTx(tx *sql.Tx, jobs []CronJob) error {
+	if _, err := tx.Exec(`DELETE FROM jobs`); err != nil {
+		return err
+	}
+	for _, job := range jobs {
+		data, err := json.Marshal(job)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec(`INSERT INTO jobs (id, data) VALUES (?, ?)`, job.ID, string(data)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// setMetaTx stores a meta value
+func setMetaTx(tx *sql.Tx, key, value string) error {
+	_, err := tx.Exec(
+		`INSERT INTO meta (key, value) VALUES (?, ?) ON CONFLICT(key) DO UPDATE SET value = excluded.value`,
+		key, value,
+	)
+	return err
+}
+
+// insertExecutionTx appends one execution row
+func insertExecutionTx(tx *sql.Tx, exec CronExecutionResult) error {
+	data, err := json.Marshal(exec)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(
+		`INSERT INTO executions (execution_id, job_id, started_at, exit_code, data) VALUES (?, ?, ?, ?, ?)`,
+		exec.ExecutionID, exec.JobID, exec.StartedAt, exec.ExitCode, string(data),
+	)
+	return err
+}
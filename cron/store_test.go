@@ -0,0 +1,160 @@
+package cron
+
+import (
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Cron stores", func() {
+	var tempDir string
+
+	BeforeEach(func() {
+		var err error
+		tempDir, err = os.MkdirTemp("", "cron-store-*")
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(tempDir)
+	})
+
+	Describe("SQLite store", func() {
+		It("should persist jobs and executions across reopen", func() {
+			dbPath := filepath.Join(tempDir, "crons.db")
+			store, err := NewSQLiteStore(dbPath, "")
+			Expect(err).ToNot(HaveOccurred())
+
+			manager, err := NewCronManagerWithStore(store, 100)
+			Expect(err).ToNot(HaveOccurred())
+			manager.executor = NewCronExecutorWithOptions(DefaultCronExecutorConfig(), WithMockExecutor(NewMockCommandExecutor()))
+
+			job, err := manager.Create(CreateCronRequest{Name: "Persisted", Schedule: "0 0 1 1 *", Command: "true"})
+			Expect(err).ToNot(HaveOccurred())
+			result, err := manager.RunNow(job.ID)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(manager.Close()).To(Succeed())
+
+			store, err = NewSQLiteStore(dbPath, "")
+			Expect(err).ToNot(HaveOccurred())
+			reopened, err := NewCronManagerWithStore(store, 100)
+			Expect(err).ToNot(HaveOccurred())
+			defer reopened.Close()
+
+			loaded, err := reopened.Get(job.ID)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(loaded.Name).To(Equal("Persisted"))
+			Expect(loaded.Metadata.TotalRuns).To(Equal(1))
+
+			history, err := reopened.GetHistory(job.ID)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(history).To(HaveLen(1))
+			Expect(history[0].ExecutionID).To(Equal(result.ExecutionID))
+		})
+
+		It("should trim executions beyond the history size", func() {
+			store, err := NewSQLiteStore(filepath.Join(tempDir, "crons.db"), "")
+			Expect(err).ToNot(HaveOccurred())
+			defer store.Close()
+
+			for _, id := range []string{"e1", "e2", "e3", "e4"} {
+				Expect(store.AppendExecution(CronExecutionResult{JobID: "job", ExecutionID: id}, 2)).To(Succeed())
+			}
+
+			data, err := store.Load()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(data.Executions).To(HaveLen(2))
+			Expect(data.Executions[0].ExecutionID).To(Equal("e3"))
+			Expect(data.Executions[1].ExecutionID).To(Equal("e4"))
+		})
+
+		It("should update a stored execution", func() {
+			store, err := NewSQLiteStore(filepath.Join(tempDir, "crons.db"), "")
+			Expect(err).ToNot(HaveOccurred())
+			defer store.Close()
+
+			exec := CronExecutionResult{JobID: "job", ExecutionID: "e1", ExitCode: 1}
+			Expect(store.AppendExecution(exec, 10)).To(Succeed())
+			exec.Overlap = OverlapKilled
+			Expect(store.UpdateExecution(exec)).To(Succeed())
+
+			data, err := store.Load()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(data.Executions).To(HaveLen(1))
+			Expect(data.Executions[0].Overlap).To(Equal(OverlapKilled))
+		})
+
+		It("should import the JSON file once", func() {
+			jsonPath := filepath.Join(tempDir, "crons.json")
+			jsonStore, err := NewJSONStore(jsonPath)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(jsonStore.Save(CronData{
+				Jobs:       []CronJob{{ID: "legacy", Name: "Legacy", Schedule: "* * * * *", Command: "true"}},
+				Executions: []CronExecutionResult{{JobID: "legacy", ExecutionID: "exec_old"}},
+				Suspended:  true,
+			})).To(Succeed())
+
+			dbPath := filepath.Join(tempDir, "crons.db")
+			store, err := NewSQLiteStore(dbPath, jsonPath)
+			Expect(err).ToNot(HaveOccurred())
+
+			data, err := store.Load()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(data.Jobs).To(HaveLen(1))
+			Expect(data.Jobs[0].Name).To(Equal("Legacy"))
+			Expect(data.Executions).To(HaveLen(1))
+			Expect(data.Suspended).To(BeTrue())
+
+			// Deleting the job in SQLite must not resurrect it from JSON on reopen
+			Expect(store.Save(CronData{})).To(Succeed())
+			Expect(store.Close()).To(Succeed())
+
+			store, err = NewSQLiteStore(dbPath, jsonPath)
+			Expect(err).ToNot(HaveOccurred())
+			defer store.Close()
+			data, err = store.Load()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(data.Jobs).To(BeEmpty())
+
+			// The JSON file is kept as a fallback
+			Expect(jsonPath).To(BeAnExistingFile())
+		})
+	})
+
+	Describe("OpenStoreFromEnv", func() {
+		AfterEach(func() {
+			os.Unsetenv("TERMINAL_HUB_CRON_STORE")
+			os.Unsetenv("TERMINAL_HUB_CRON_DB")
+		})
+
+		It("should default to the JSON store", func() {
+			store, err := OpenStoreFromEnv(filepath.Join(tempDir, "crons.json"))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(store.String()).To(HaveSuffix("crons.json"))
+		})
+
+		It("should open SQLite next to the JSON file", func() {
+			os.Setenv("TERMINAL_HUB_CRON_STORE", "sqlite")
+			store, err := OpenStoreFromEnv(filepath.Join(tempDir, "crons.json"))
+			Expect(err).ToNot(HaveOccurred())
+			defer store.Close()
+			Expect(store.String()).To(Equal(filepath.Join(tempDir, "crons.db")))
+		})
+
+		It("should fall back to JSON when SQLite cannot be opened", func() {
+			os.Setenv("TERMINAL_HUB_CRON_STORE", "sqlite")
+			os.Setenv("TERMINAL_HUB_CRON_DB", tempDir) // a directory is not a database
+			store, err := OpenStoreFromEnv(filepath.Join(tempDir, "crons.json"))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(store.String()).To(HaveSuffix("crons.json"))
+		})
+
+		It("should reject unknown stores", func() {
+			os.Setenv("TERMINAL_HUB_CRON_STORE", "redis")
+			_, err := OpenStoreFromEnv(filepath.Join(tempDir, "crons.json"))
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
@@ -0,0 +1,71 @@
+package cron
+
+import (
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Maintenance mode", func() {
+	var (
+		tempDir  string
+		cronFile string
+		manager  *CronManager
+		mockExec *MockCommandExecutor
+	)
+
+	BeforeEach(func() {
+		var err error
+		tempDir, err = os.MkdirTemp("", "cron-suspend-*")
+		Expect(err).ToNot(HaveOccurred())
+		cronFile = filepath.Join(tempDir, "crons.json")
+		manager, err = NewCronManager(cronFile, 100)
+		Expect(err).ToNot(HaveOccurred())
+
+		mockExec = NewMockCommandExecutor()
+		manager.executor = NewCronExecutorWithOptions(DefaultCronExecutorConfig(), WithMockExecutor(mockExec))
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(tempDir)
+	})
+
+	It("should skip scheduled runs without touching Enabled", func() {
+		job, err := manager.Create(CreateCronRequest{Name: "Paused", Schedule: "0 0 1 1 *", Command: "echo", Enabled: true})
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(manager.Suspend()).To(Succeed())
+		Expect(manager.IsSuspended()).To(BeTrue())
+
+		manager.executeJob(job.ID)
+		Expect(mockExec.GetExecutedCommands()).To(BeEmpty())
+
+		loaded, err := manager.Get(job.ID)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(loaded.Enabled).To(BeTrue())
+
+		// Manual runs still work during maintenance
+		_, err = manager.RunNow(job.ID)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(mockExec.GetExecutedCommands()).To(HaveLen(1))
+
+		Expect(manager.Resume()).To(Succeed())
+		manager.executeJob(job.ID)
+		Expect(mockExec.GetExecutedCommands()).To(HaveLen(2))
+	})
+
+	It("should persist the suspended state", func() {
+		Expect(manager.Suspend()).To(Succeed())
+
+		reloaded, err := NewCronManager(cronFile, 100)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(reloaded.IsSuspended()).To(BeTrue())
+
+		Expect(reloaded.Resume()).To(Succeed())
+		reloaded, err = NewCronManager(cronFile, 100)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(reloaded.IsSuspended()).To(BeFalse())
+	})
+})
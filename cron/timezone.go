@@ -0,0 +1,85 @@
+package cron
+
+import (
+	"fmt"
+	"log"
+	"math/rand"
+	"time"
+)
+
+// MaxJitter bounds the random delay added before scheduled runs
+const MaxJitter = time.Hour
+
+// ValidateTimezone checks that timezone is empty (server local time) or an
+// IANA zone name such as "Europe/Berlin"
+func ValidateTimezone(timezone string) error {
+	if timezone == "" {
+		return nil
+	}
+	if _, err := time.LoadLocation(timezone); err != nil {
+		return fmt.Errorf("invalid timezone %q: %w", timezone, err)
+	}
+	return nil
+}
+
+// ValidateJitter checks a jitter duration such as "30s"
+func ValidateJitter(jitter string) error {
+	if jitter == "" {
+		return nil
+	}
+	parsed, err := time.ParseDuration(jitter)
+	if err != nil {
+		return fmt.Errorf("invalid jitter %q: %w", jitter, err)
+	}
+	if parsed < 0 || parsed > MaxJitter {
+		return fmt.Errorf("jitter must be between 0 and %s", MaxJitter)
+	}
+	return nil
+}
+
+// scheduleSpec returns the schedule evaluated in the given timezone, using
+// the CRON_TZ prefix understood by the cron parser
+func scheduleSpec(schedule, timezone string) string {
+	if schedule == "" || timezone == "" {
+		return schedule
+	}
+	return "CRON_TZ=" + timezone + " " + schedule
+}
+
+// jobJitter returns the maximum random delay before a job's scheduled runs
+func jobJitter(job *CronJob) time.Duration {
+	if job.Jitter == "" {
+		return 0
+	}
+	jitter, err := time.ParseDuration(job.Jitter)
+	if err != nil || jitter <= 0 {
+		return 0
+	}
+	return min(jitter, MaxJitter)
+}
+
+// runScheduled starts a scheduled run, first waiting a random delay of up to
+// the job's jitter so many hubs sharing a schedule do not fire at once
+func (m *CronManager) runScheduled(jobID string) {
+	m.mu.RLock()
+	var jitter time.Duration
+	if job, ok := m.jobs[jobID]; ok {
+		jitter = jobJitter(job)
+	}
+	m.mu.RUnlock()
+
+	if jitter <= 0 {
+		m.executeJob(jobID)
+		return
+	}
+
+	delay := time.Duration(rand.Int63n(int64(jitter) + 1))
+	log.Printf("[Cron] Delaying job %s by %s (jitter)", jobID, delay.Round(time.Millisecond))
+	time.AfterFunc(delay, func() {
+		if !m.IsStarted() {
+			log.Printf("[Cron] Scheduler stopped, skipping delayed job %s", jobID)
+			return
+		}
+		m.executeJob(jobID)
+	})
+}
@@ -0,0 +1,92 @@
+package cron
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Timezone and jitter", func() {
+	var (
+		tempDir  string
+		manager  *CronManager
+		mockExec *MockCommandExecutor
+	)
+
+	BeforeEach(func() {
+		var err error
+		tempDir, err = os.MkdirTemp("", "cron-timezone-*")
+		Expect(err).ToNot(HaveOccurred())
+		manager, err = NewCronManager(filepath.Join(tempDir, "crons.json"), 100)
+		Expect(err).ToNot(HaveOccurred())
+
+		mockExec = NewMockCommandExecutor()
+		manager.executor = NewCronExecutorWithOptions(DefaultCronExecutorConfig(), WithMockExecutor(mockExec))
+	})
+
+	AfterEach(func() {
+		manager.Stop()
+		os.RemoveAll(tempDir)
+	})
+
+	It("should validate timezone and jitter", func() {
+		Expect(ValidateTimezone("")).To(Succeed())
+		Expect(ValidateTimezone("America/New_York")).To(Succeed())
+		Expect(ValidateTimezone("Nowhere/Special")).ToNot(Succeed())
+
+		Expect(ValidateJitter("")).To(Succeed())
+		Expect(ValidateJitter("30s")).To(Succeed())
+		Expect(ValidateJitter("soon")).ToNot(Succeed())
+		Expect(ValidateJitter("-1s")).ToNot(Succeed())
+		Expect(ValidateJitter("2h")).ToNot(Succeed())
+
+		_, err := manager.Create(CreateCronRequest{Name: "Bad", Schedule: "0 9 * * *", Command: "echo", Timezone: "Nowhere/Special"})
+		Expect(err).To(MatchError(ContainSubstring("invalid timezone")))
+	})
+
+	It("should compute the next run in the job's timezone", func() {
+		job, err := manager.Create(CreateCronRequest{Name: "Seoul", Schedule: "0 9 * * *", Command: "echo", Timezone: "Asia/Seoul", Enabled: true})
+		Expect(err).ToNot(HaveOccurred())
+
+		seoul, err := time.LoadLocation("Asia/Seoul")
+		Expect(err).ToNot(HaveOccurred())
+		nextRun := time.Unix(job.Metadata.NextRunAt, 0).In(seoul)
+		Expect(nextRun.Hour()).To(Equal(9))
+		Expect(nextRun.Minute()).To(Equal(0))
+
+		utc := ""
+		updated, err := manager.Update(job.ID, UpdateCronRequest{Timezone: &utc})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(updated.Timezone).To(BeEmpty())
+	})
+
+	It("should delay scheduled runs by up to the jitter", func() {
+		job, err := manager.Create(CreateCronRequest{Name: "Jittered", Schedule: "0 0 1 1 *", Command: "echo", Jitter: "200ms", Enabled: true})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(manager.Start()).To(Succeed())
+
+		manager.runScheduled(job.ID)
+		Eventually(mockExec.GetExecutedCommands, "2s", "10ms").Should(HaveLen(1))
+	})
+
+	It("should carry the timezone through crontab import and export", func() {
+		reqs, err := ParseCrontab(strings.NewReader("CRON_TZ=Europe/Berlin\n0 9 * * * echo berlin\n"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(reqs).To(HaveLen(1))
+		Expect(reqs[0].Timezone).To(Equal("Europe/Berlin"))
+
+		exported := FormatCrontab([]CronJob{
+			{Name: "a", Schedule: "0 9 * * *", Command: "echo berlin", Timezone: "Europe/Berlin", Enabled: true},
+			{Name: "b", Schedule: "0 9 * * *", Command: "echo local", Enabled: true},
+		})
+		reqs, err = ParseCrontab(strings.NewReader(exported))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(reqs).To(HaveLen(2))
+		Expect(reqs[0].Timezone).To(Equal("Europe/Berlin"))
+		Expect(reqs[1].Timezone).To(BeEmpty())
+	})
+})
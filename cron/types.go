@@ -1,18 +1,43 @@
 package cron
 
-import "time"
+import (
+	"time"
+
+	"github.com/iwanhae/terminal-hub/terminal"
+)
 
 // CronJob configuration
 type CronJob struct {
-	ID               string            `json:"id"`
-	Name             string            `json:"name"`
-	Schedule         string            `json:"schedule"`        // cron expression: "* * * * *"
-	Command          string            `json:"command"`         // command to execute
-	Shell            string            `json:"shell,omitempty"` // optional: override default shell
-	WorkingDirectory string            `json:"working_directory,omitempty"`
-	EnvVars          map[string]string `json:"env_vars,omitempty"`
-	Enabled          bool              `json:"enabled"`
-	Metadata         CronMetadata      `json:"metadata"`
+	ID                string                   `json:"id"`
+	Name              string                   `json:"name"`
+	Schedule          string                   `json:"schedule"`        // cron expression: "* * * * *"
+	Command           string                   `json:"command"`         // command to execute
+	Shell             string                   `json:"shell,omitempty"` // optional: override default shell
+	WorkingDirectory  string                   `json:"working_directory,omitempty"`
+	EnvVars           map[string]string        `json:"env_vars,omitempty"`
+	Enabled           bool                     `json:"enabled"`
+	Notifications     *CronNotificationConfig  `json:"notifications,omitempty"`      // optional: failure/recovery alerts
+	MaxRetries        int                      `json:"max_retries,omitempty"`        // retries after a failed run (0 = none)
+	RetryBackoff      string                   `json:"retry_backoff,omitempty"`      // base delay, doubled per retry (e.g. "30s")
+	Timeout           string                   `json:"timeout,omitempty"`            // optional: overrides the executor timeout (e.g. "90s")
+	ConcurrencyPolicy string                   `json:"concurrency_policy,omitempty"` // optional: "allow" (default), "skip", "queue", "replace"
+	TargetSessionID   string                   `json:"target_session_id,omitempty"`  // optional: type the command into this terminal session instead
+	RunAfter          []string                 `json:"run_after,omitempty"`          // optional: run when these jobs succeed; schedule may then be empty
+	RunAt             int64                    `json:"run_at,omitempty"`             // optional: unix timestamp of a single run instead of a schedule
+	LogToFile         bool                     `json:"log_to_file,omitempty"`        // optional: append full output to a rotated per-job log file
+	Timezone          string                   `json:"timezone,omitempty"`           // optional: IANA zone the schedule is evaluated in (default: server local time)
+	Jitter            string                   `json:"jitter,omitempty"`             // optional: maximum random delay before scheduled runs (e.g. "30s")
+	CatchUp           bool                     `json:"catch_up,omitempty"`           // optional: run once at startup if a scheduled run was missed while the server was down
+	Limits            *terminal.ResourceLimits `json:"limits,omitempty"`             // optional: OS-level resource limits for the command
+	Metadata          CronMetadata             `json:"metadata"`
+	Chain             *CronChainStatus         `json:"chain,omitempty"` // computed in responses, not persisted
+}
+
+// CronNotificationConfig configures alerts sent when a job fails or recovers
+type CronNotificationConfig struct {
+	WebhookURL string   `json:"webhook_url,omitempty"` // receives a JSON POST of CronNotificationPayload
+	EmailTo    []string `json:"email_to,omitempty"`    // recipients, sent via the TERMINAL_HUB_SMTP_* settings
+	Channels   []string `json:"channels,omitempty"`    // names of channels in the notification channels file
 }
 
 // CronMetadata tracks job runtime information
@@ -21,45 +46,78 @@ type CronMetadata struct {
 	UpdatedAt      int64  `json:"updated_at"`
 	LastRunAt      int64  `json:"last_run_at"`     // unix timestamp, 0 if never run
 	NextRunAt      int64  `json:"next_run_at"`     // unix timestamp
-	LastRunStatus  string `json:"last_run_status"` // "success", "failed", "running", ""
+	LastRunStatus  string `json:"last_run_status"` // "success", "failed", "timeout", "running", ""
 	LastRunOutput  string `json:"last_run_output"` // truncated output (max 4KB)
 	LastRunError   string `json:"last_run_error"`  // error message if failed
 	TotalRuns      int    `json:"total_runs"`
 	FailureCount   int    `json:"failure_count"`
-	ExecutionCount int    `json:"execution_count"` // current run number
-	ConcurrentRuns int    `json:"concurrent_runs"` // current number of concurrent runs
+	ExecutionCount int    `json:"execution_count"`        // current run number
+	ConcurrentRuns int    `json:"concurrent_runs"`        // current number of concurrent runs
+	CompletedAt    int64  `json:"completed_at,omitempty"` // unix timestamp, set once a one-shot job has run
 }
 
 // Execution history (kept in memory, truncated per job)
 type CronExecutionResult struct {
 	JobID       string `json:"job_id"`
-	ExecutionID string `json:"execution_id"` // unique ID for this run
+	ExecutionID string `json:"execution_id"`       // unique ID for this run
+	Attempt     int    `json:"attempt,omitempty"`  // 1-based attempt number when retries are enabled
+	Overlap     string `json:"overlap,omitempty"`  // "skipped", "queued", "replaced" or "killed" when runs overlapped
+	CatchUp     bool   `json:"catch_up,omitempty"` // run at startup for a schedule missed while the server was down
 	StartedAt   int64  `json:"started_at"`
 	FinishedAt  int64  `json:"finished_at"`
 	ExitCode    int    `json:"exit_code"`
-	Output      string `json:"output"` // full command output
-	Error       string `json:"error"`  // error message if failed
+	Output      string `json:"output"`              // full command output
+	Stdout      string `json:"stdout,omitempty"`    // standard output alone; PTY runs merge the streams into Output
+	Stderr      string `json:"stderr,omitempty"`    // standard error alone
+	Error       string `json:"error"`               // error message if failed
+	TimedOut    bool   `json:"timed_out,omitempty"` // the run was stopped at its timeout
 }
 
 // Request/Response types
 type CreateCronRequest struct {
-	Name             string            `json:"name"`                        // Required
-	Schedule         string            `json:"schedule"`                    // Required: cron expression
-	Command          string            `json:"command"`                     // Required
-	Shell            string            `json:"shell,omitempty"`             // Optional
-	WorkingDirectory string            `json:"working_directory,omitempty"` // Optional
-	EnvVars          map[string]string `json:"env_vars,omitempty"`          // Optional
-	Enabled          bool              `json:"enabled"`                     // Default: true
+	Name              string                   `json:"name"`                         // Required
+	Schedule          string                   `json:"schedule"`                     // Required: cron expression
+	Command           string                   `json:"command"`                      // Required
+	Shell             string                   `json:"shell,omitempty"`              // Optional
+	WorkingDirectory  string                   `json:"working_directory,omitempty"`  // Optional
+	EnvVars           map[string]string        `json:"env_vars,omitempty"`           // Optional
+	Enabled           bool                     `json:"enabled"`                      // Default: true
+	Notifications     *CronNotificationConfig  `json:"notifications,omitempty"`      // Optional
+	MaxRetries        int                      `json:"max_retries,omitempty"`        // Optional
+	RetryBackoff      string                   `json:"retry_backoff,omitempty"`      // Optional: duration string
+	Timeout           string                   `json:"timeout,omitempty"`            // Optional: duration string
+	ConcurrencyPolicy string                   `json:"concurrency_policy,omitempty"` // Optional: "allow", "skip", "queue", "replace"
+	TargetSessionID   string                   `json:"target_session_id,omitempty"`  // Optional: terminal session to run in
+	RunAfter          []string                 `json:"run_after,omitempty"`          // Optional: upstream job IDs; on import also "#N" for the Nth job of the import
+	RunAt             int64                    `json:"run_at,omitempty"`             // Optional: unix timestamp to run once, instead of a schedule
+	LogToFile         bool                     `json:"log_to_file,omitempty"`        // Optional: keep full output in a per-job log file
+	Timezone          string                   `json:"timezone,omitempty"`           // Optional: IANA zone name, e.g. "America/New_York"
+	Jitter            string                   `json:"jitter,omitempty"`             // Optional: maximum random delay, e.g. "30s"
+	CatchUp           bool                     `json:"catch_up,omitempty"`           // Optional: run once at startup after missed runs
+	Limits            *terminal.ResourceLimits `json:"limits,omitempty"`             // Optional: OS-level resource limits
 }
 
 type UpdateCronRequest struct {
-	Name             *string           `json:"name,omitempty"` // Pointer to distinguish zero-value
-	Schedule         *string           `json:"schedule,omitempty"`
-	Command          *string           `json:"command,omitempty"`
-	Shell            *string           `json:"shell,omitempty"`
-	WorkingDirectory *string           `json:"working_directory,omitempty"`
-	EnvVars          map[string]string `json:"env_vars,omitempty"`
-	Enabled          *bool             `json:"enabled,omitempty"`
+	Name              *string                  `json:"name,omitempty"` // Pointer to distinguish zero-value
+	Schedule          *string                  `json:"schedule,omitempty"`
+	Command           *string                  `json:"command,omitempty"`
+	Shell             *string                  `json:"shell,omitempty"`
+	WorkingDirectory  *string                  `json:"working_directory,omitempty"`
+	EnvVars           map[string]string        `json:"env_vars,omitempty"`
+	Enabled           *bool                    `json:"enabled,omitempty"`
+	Notifications     *CronNotificationConfig  `json:"notifications,omitempty"`
+	MaxRetries        *int                     `json:"max_retries,omitempty"`
+	RetryBackoff      *string                  `json:"retry_backoff,omitempty"`
+	Timeout           *string                  `json:"timeout,omitempty"` // Empty string clears the override
+	ConcurrencyPolicy *string                  `json:"concurrency_policy,omitempty"`
+	TargetSessionID   *string                  `json:"target_session_id,omitempty"` // Empty string runs the job headlessly again
+	RunAfter          *[]string                `json:"run_after,omitempty"`         // Empty list removes all upstream jobs
+	RunAt             *int64                   `json:"run_at,omitempty"`            // 0 makes the job recurring again; a new time re-arms a completed job
+	LogToFile         *bool                    `json:"log_to_file,omitempty"`
+	Timezone          *string                  `json:"timezone,omitempty"` // Empty string uses server local time
+	Jitter            *string                  `json:"jitter,omitempty"`   // Empty string disables jitter
+	CatchUp           *bool                    `json:"catch_up,omitempty"`
+	Limits            *terminal.ResourceLimits `json:"limits,omitempty"` // An empty object removes all limits
 }
 
 type CreateCronResponse struct {
@@ -68,17 +126,50 @@ type CreateCronResponse struct {
 }
 
 type ListCronsResponse struct {
-	Jobs []CronJob `json:"jobs"`
+	Jobs      []CronJob `json:"jobs"`
+	Suspended bool      `json:"suspended"` // true while scheduling is paused
+}
+
+type SuspendResponse struct {
+	Suspended bool `json:"suspended"`
 }
 
 type GetHistoryResponse struct {
 	Executions []CronExecutionResult `json:"executions"`
 }
 
+// CronExport is the JSON import/export document
+type CronExport struct {
+	Jobs []CreateCronRequest `json:"jobs"`
+}
+
+// ImportCronsResponse lists the jobs created by an import
+type ImportCronsResponse struct {
+	Jobs []CronJob `json:"jobs"`
+}
+
+// HistoryPage is a page of execution history, newest first
+type HistoryPage struct {
+	Executions []CronExecutionResult `json:"executions"`
+	Total      int                   `json:"total"` // number of executions matching the filters
+	Limit      int                   `json:"limit"`
+	Offset     int                   `json:"offset"`
+}
+
+type PreviewScheduleResponse struct {
+	Schedule    string  `json:"schedule"`
+	Timezone    string  `json:"timezone,omitempty"`
+	Valid       bool    `json:"valid"`
+	Description string  `json:"description,omitempty"` // human-readable schedule
+	NextRuns    []int64 `json:"next_runs"`             // unix timestamps of upcoming runs
+	Error       string  `json:"error,omitempty"`       // parse error if the schedule is invalid
+}
+
 // CronData is the root structure stored in JSON file
 type CronData struct {
 	Jobs       []CronJob             `json:"jobs"`
-	Executions []CronExecutionResult `json:"executions"` // limited size, rotated
+	Executions []CronExecutionResult `json:"executions"`          // limited size, rotated
+	Suspended  bool                  `json:"suspended,omitempty"` // scheduling paused for maintenance
 }
 
 // CronExecutorConfig holds configuration for job execution
@@ -86,6 +177,8 @@ type CronExecutorConfig struct {
 	MaxOutputSize    int           // Max output size per run
 	ExecutionTimeout time.Duration // Max execution time
 	MaxConcurrent    int           // Max concurrent job runs
+	MaxJobTimeout    time.Duration // Upper bound for per-job timeout overrides
+	KillGracePeriod  time.Duration // Time between SIGTERM and SIGKILL for a stopped run
 }
 
 // DefaultCronExecutorConfig returns the default executor configuration
@@ -94,5 +187,7 @@ func DefaultCronExecutorConfig() CronExecutorConfig {
 		MaxOutputSize:    64 * 1024, // 64KB
 		ExecutionTimeout: 5 * time.Minute,
 		MaxConcurrent:    5,
+		MaxJobTimeout:    24 * time.Hour,
+		KillGracePeriod:  10 * time.Second,
 	}
 }
@@ -0,0 +1,174 @@
+// Package diskguard watches the free space of the filesystem holding the
+// hub's data directory, so that writes filling it — cron history, uploads and
+// session archives — are paused with a clear error instead of failing midway.
+package diskguard
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultMinFreeBytes is the free space below which writes are paused
+	DefaultMinFreeBytes uint64 = 512 << 20
+	// DefaultInterval is how often the free space is checked
+	DefaultInterval = 30 * time.Second
+)
+
+// ErrLowDiskSpace is wrapped by the errors of writes paused for lack of space
+var ErrLowDiskSpace = errors.New("low disk space")
+
+// Status is the result of the latest check
+type Status struct {
+	Path         string    `json:"path"`
+	FreeBytes    uint64    `json:"free_bytes"`     // available to unprivileged users
+	MinFreeBytes uint64    `json:"min_free_bytes"` // writes are paused below this
+	Low          bool      `json:"low"`
+	CheckedAt    time.Time `json:"checked_at"`
+}
+
+// Guard checks the free space under a directory periodically and reports
+// whether writes should be paused
+type Guard struct {
+	path    string
+	minFree uint64
+
+	mu       sync.RWMutex
+	status   Status
+	onChange func(Status) // called when the space becomes low or recovers
+}
+
+// New returns a guard pausing writes while less than minFree bytes are free
+// on the filesystem holding path
+func New(path string, minFree uint64) *Guard {
+	return &Guard{
+		path:    path,
+		minFree: minFree,
+		status:  Status{Path: path, MinFreeBytes: minFree},
+	}
+}
+
+// DefaultDataDir returns ~/.terminal-hub, where the hub keeps its data
+func DefaultDataDir() string {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return ".terminal-hub"
+	}
+	return filepath.Join(homeDir, ".terminal-hub")
+}
+
+// GetMinFreeBytesFromEnv returns TERMINAL_HUB_MIN_FREE_BYTES, or 512 MiB.
+// Zero turns the guard off.
+func GetMinFreeBytesFromEnv() uint64 {
+	if val := os.Getenv("TERMINAL_HUB_MIN_FREE_BYTES"); val != "" {
+		if n, err := strconv.ParseUint(val, 10, 64); err == nil {
+			return n
+		}
+	}
+	return DefaultMinFreeBytes
+}
+
+// SetChangeHandler registers a handler called whenever a check finds the
+// space has become low or has recovered
+func (g *Guard) SetChangeHandler(handler func(Status)) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.onChange = handler
+}
+
+// Run checks the free space right away and then every interval. It returns
+// right away when the guard is off or free space cannot be read on this
+// host.
+func (g *Guard) Run(interval time.Duration) {
+	if g.minFree == 0 || interval <= 0 || !supported {
+		return
+	}
+	_, _ = g.Check(time.Now())
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for now := range ticker.C {
+		_, _ = g.Check(now)
+	}
+}
+
+// Check reads the free space and makes it the latest status. A path that
+// does not exist yet is measured at its nearest existing parent.
+func (g *Guard) Check(now time.Time) (Status, error) {
+	free, err := freeBytes(existingParent(g.path))
+	if err != nil {
+		return g.Status(), err
+	}
+	status := Status{
+		Path:         g.path,
+		FreeBytes:    free,
+		MinFreeBytes: g.minFree,
+		Low:          free < g.minFree,
+		CheckedAt:    now,
+	}
+
+	g.mu.Lock()
+	changed := status.Low != g.status.Low
+	g.status = status
+	handler := g.onChange
+	g.mu.Unlock()
+
+	if changed && handler != nil {
+		handler(status)
+	}
+	return status, nil
+}
+
+// Status returns the latest status
+func (g *Guard) Status() Status {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return g.status
+}
+
+// Err returns an error wrapping ErrLowDiskSpace while the space is low, and
+// nil otherwise or for a nil guard
+func (g *Guard) Err() error {
+	if g == nil {
+		return nil
+	}
+	status := g.Status()
+	if !status.Low {
+		return nil
+	}
+	return fmt.Errorf("%w: %s free under %s, below the %s minimum",
+		ErrLowDiskSpace, FormatBytes(status.FreeBytes), status.Path, FormatBytes(status.MinFreeBytes))
+}
+
+// FormatBytes formats n with a binary unit, such as "1.5 GiB"
+func FormatBytes(n uint64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := uint64(unit), 0
+	for m := n / unit; m >= unit && exp < 5; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+// existingParent returns path or its nearest ancestor that exists
+func existingParent(path string) string {
+	for {
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+		parent := filepath.Dir(path)
+		if parent == path {
+			return path
+		}
+		path = parent
+	}
+}
@@ -0,0 +1,12 @@
+//go:build !unix
+
+package diskguard
+
+import "errors"
+
+const supported = false
+
+// freeBytes is only implemented for Unix hosts
+func freeBytes(path string) (uint64, error) {
+	return 0, errors.New("free disk space is only available on Unix hosts")
+}
@@ -0,0 +1,72 @@
+package diskguard
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestGuardCheck(t *testing.T) {
+	if !supported {
+		t.Skip("free disk space is not available on this platform")
+	}
+	// A data directory that does not exist yet is measured at its parent
+	dir := filepath.Join(t.TempDir(), "data", "nested")
+	g := New(dir, 1)
+	var changes []Status
+	g.SetChangeHandler(func(status Status) { changes = append(changes, status) })
+
+	now := time.Now()
+	status, err := g.Check(now)
+	if err != nil {
+		t.Fatalf("check failed: %v", err)
+	}
+	if status.Low || status.FreeBytes == 0 || status.Path != dir || !status.CheckedAt.Equal(now) {
+		t.Fatalf("unexpected status %+v", status)
+	}
+	if len(changes) != 0 || g.Err() != nil {
+		t.Fatalf("expected no change and no error while space is available, got %v, %v", changes, g.Err())
+	}
+
+	// No filesystem has this much free space
+	g.minFree = ^uint64(0)
+	if status, _ = g.Check(now); !status.Low {
+		t.Fatalf("expected low space, got %+v", status)
+	}
+	if err := g.Err(); !errors.Is(err, ErrLowDiskSpace) {
+		t.Fatalf("expected ErrLowDiskSpace, got %v", err)
+	}
+	_, _ = g.Check(now)
+	g.minFree = 1
+	_, _ = g.Check(now)
+	if len(changes) != 2 || !changes[0].Low || changes[1].Low {
+		t.Fatalf("expected one low and one recovered change, got %+v", changes)
+	}
+	if g.Err() != nil {
+		t.Fatalf("expected no error after recovering, got %v", g.Err())
+	}
+}
+
+func TestNilGuardErr(t *testing.T) {
+	var g *Guard
+	if err := g.Err(); err != nil {
+		t.Fatalf("expected a nil guard to allow writes, got %v", err)
+	}
+}
+
+func TestFormatBytes(t *testing.T) {
+	tests := map[uint64]string{
+		0:          "0 B",
+		1023:       "1023 B",
+		1536:       "1.5 KiB",
+		512 << 20:  "512.0 MiB",
+		3 << 30:    "3.0 GiB",
+		^uint64(0): "16.0 EiB",
+	}
+	for n, want := range tests {
+		if got := FormatBytes(n); got != want {
+			t.Errorf("FormatBytes(%d) = %q, want %q", n, got, want)
+		}
+	}
+}
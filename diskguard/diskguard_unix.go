@@ -0,0 +1,17 @@
+//go:build unix
+
+package diskguard
+
+import "golang.org/x/sys/unix"
+
+const supported = true
+
+// freeBytes returns the space unprivileged users can fill on the filesystem
+// holding path
+func freeBytes(path string) (uint64, error) {
+	var fs unix.Statfs_t
+	if err := unix.Statfs(path, &fs); err != nil {
+		return 0, err
+	}
+	return uint64(fs.Bavail) * uint64(fs.Bsize), nil
+}
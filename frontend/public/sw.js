@@ -3,11 +3,14 @@
 
 const CACHE_NAME = "terminal-hub-shell-v1";
 
+// Path the app is served under, "/" or a base path such as "/hub/"
+const BASE = new URL("./", self.location.href).pathname;
+
 const PRECACHE_URLS = [
-  "/",
-  "/index.html",
-  "/manifest.webmanifest",
-  "/terminal-hub-icon.svg",
+  BASE,
+  `${BASE}index.html`,
+  `${BASE}manifest.webmanifest`,
+  `${BASE}terminal-hub-icon.svg`,
 ];
 
 self.addEventListener("install", (event) => {
@@ -45,9 +48,10 @@ self.addEventListener("fetch", (event) => {
   const url = new URL(request.url);
   if (!isSameOrigin(url)) return;
 
-  // Never cache API or websocket endpoints.
-  if (url.pathname.startsWith("/api/")) return;
-  if (url.pathname.startsWith("/ws/")) return;
+  // Never cache API, websocket or event stream endpoints.
+  if (url.pathname.startsWith(`${BASE}api/`)) return;
+  if (url.pathname.startsWith(`${BASE}ws/`)) return;
+  if (url.pathname.startsWith(`${BASE}sse/`)) return;
 
   // SPA navigation: network-first, fallback to cached index.
   if (request.mode === "navigate") {
@@ -55,16 +59,16 @@ self.addEventListener("fetch", (event) => {
       fetch(request)
         .then((response) => {
           const copy = response.clone();
-          caches.open(CACHE_NAME).then((cache) => cache.put("/index.html", copy));
+          caches.open(CACHE_NAME).then((cache) => cache.put(`${BASE}index.html`, copy));
           return response;
         })
-        .catch(() => caches.match("/index.html")),
+        .catch(() => caches.match(`${BASE}index.html`)),
     );
     return;
   }
 
   // Static assets: cache-first.
-  if (url.pathname.startsWith("/assets/")) {
+  if (url.pathname.startsWith(`${BASE}assets/`)) {
     event.respondWith(
       caches.match(request).then((cached) =>
         cached ||
@@ -0,0 +1,51 @@
+// Writes brotli (.br) and gzip (.gz) copies of the built assets next to them,
+// so the server can send them precompressed to browsers that accept them.
+// Copies that would not be smaller are skipped.
+import { readdirSync, readFileSync, writeFileSync } from "node:fs";
+import { extname, join } from "node:path";
+import { fileURLToPath } from "node:url";
+import { brotliCompressSync, constants, gzipSync } from "node:zlib";
+
+const distDir = fileURLToPath(new URL("../dist", import.meta.url));
+const compressible = new Set([
+  ".css",
+  ".html",
+  ".js",
+  ".json",
+  ".mjs",
+  ".svg",
+  ".txt",
+  ".webmanifest",
+]);
+
+function* walk(dir) {
+  for (const entry of readdirSync(dir, { withFileTypes: true })) {
+    const path = join(dir, entry.name);
+    if (entry.isDirectory()) {
+      yield* walk(path);
+    } else if (compressible.has(extname(entry.name))) {
+      yield path;
+    }
+  }
+}
+
+let written = 0;
+for (const path of walk(distDir)) {
+  const data = readFileSync(path);
+  const variants = {
+    ".br": brotliCompressSync(data, {
+      params: {
+        [constants.BROTLI_PARAM_QUALITY]: constants.BROTLI_MAX_QUALITY,
+        [constants.BROTLI_PARAM_SIZE_HINT]: data.length,
+      },
+    }),
+    ".gz": gzipSync(data, { level: 9 }),
+  };
+  for (const [suffix, compressed] of Object.entries(variants)) {
+    if (compressed.length < data.length) {
+      writeFileSync(path + suffix, compressed);
+      written++;
+    }
+  }
+}
+console.log(`precompress: wrote ${written} compressed assets`);
@@ -11,8 +11,17 @@ import CronPage from "./features/crons/CronPage";
 import FilesPage from "./features/files/FilesPage";
 import LoginPage from "./features/auth/LoginPage";
 import { Toaster } from "react-hot-toast";
+import { BASE_PATH } from "./shared/http/basePath";
+import { DEFAULT_TITLE, useUIConfig } from "./shared/uiConfig";
+import { BrandingBanner } from "./components/ui/Branding";
 
 function App() {
+  const { title } = useUIConfig();
+
+  useEffect(() => {
+    document.title = title ?? DEFAULT_TITLE;
+  }, [title]);
+
   useEffect(() => {
     const handleKeyDown = (e: KeyboardEvent) => {
       if ((e.metaKey || e.ctrlKey) && e.key === "k") {
@@ -26,7 +35,7 @@ function App() {
   }, []);
 
   return (
-    <Router>
+    <Router basename={BASE_PATH || "/"}>
       <AuthProvider>
         <Routes>
           <Route path="/login" element={<LoginPage />} />
@@ -42,6 +51,7 @@ function App() {
                         onNavigate={() => {}}
                       />
                       <main className="flex-1 flex flex-col min-w-0 overflow-hidden relative">
+                        <BrandingBanner />
                         <Routes>
                           <Route path="/" element={<SessionGrid />} />
                           <Route
@@ -0,0 +1,41 @@
+import { DEFAULT_TITLE, useUIConfig } from "../../shared/uiConfig";
+
+type BrandNameProps = Readonly<{
+  logoClassName?: string;
+}>;
+
+// The hub's title, with the configured logo in front of it
+export function BrandName({ logoClassName = "h-5 w-5" }: BrandNameProps) {
+  const { title, logo_url: logoUrl } = useUIConfig();
+
+  return (
+    <span className="inline-flex items-center gap-2">
+      {logoUrl && (
+        <img
+          src={logoUrl}
+          alt=""
+          className={`${logoClassName} object-contain`}
+        />
+      )}
+      {title ?? DEFAULT_TITLE}
+    </span>
+  );
+}
+
+// The configured banner message, shown above the page
+export function BrandingBanner() {
+  const { banner } = useUIConfig();
+  if (!banner) {
+    return null;
+  }
+
+  return (
+    <div
+      role="status"
+      className="w-full px-4 py-2 text-center text-sm text-amber-100 bg-amber-900/60 border-b border-amber-700/60 whitespace-pre-line"
+      data-testid="ui-banner"
+    >
+      {banner}
+    </div>
+  );
+}
@@ -3,11 +3,20 @@ import { useLocation, useNavigate } from "react-router-dom";
 import type { ReactNode } from "react";
 import { AuthContext } from "./AuthContext";
 import { authApi } from "./api";
-import { SESSION_INVALID_EVENT } from "./sessionEvents";
+import type { PasswordPolicy } from "./api";
+import {
+  PASSWORD_CHANGE_REQUIRED_EVENT,
+  SESSION_INVALID_EVENT,
+} from "./sessionEvents";
 
 export function AuthProvider({ children }: { readonly children: ReactNode }) {
   const [isAuthenticated, setIsAuthenticated] = useState(false);
   const [username, setUsername] = useState<string | null>(null);
+  const [mustChangePassword, setMustChangePassword] = useState(false);
+  const [passwordPolicy, setPasswordPolicy] = useState<PasswordPolicy | null>(
+    null,
+  );
+  const [demo, setDemo] = useState(false);
   const [loading, setLoading] = useState(true);
   const navigate = useNavigate();
   const location = useLocation();
@@ -18,20 +27,24 @@ export function AuthProvider({ children }: { readonly children: ReactNode }) {
       const data = await authApi.status();
       setIsAuthenticated(data.authenticated);
       setUsername(data.username ?? null);
+      setMustChangePassword(data.must_change_password === true);
+      setPasswordPolicy(data.password_policy ?? null);
+      setDemo(data.demo === true);
       if (data.authenticated) {
         hasRedirectedForInvalidSessionRef.current = false;
       }
     } catch {
       setIsAuthenticated(false);
       setUsername(null);
+      setMustChangePassword(false);
     } finally {
       setLoading(false);
     }
   }, []);
 
   const login = useCallback(
-    async (usernameVal: string, passwordVal: string) => {
-      await authApi.login(usernameVal, passwordVal);
+    async (usernameVal: string, passwordVal: string, remember: boolean) => {
+      await authApi.login(usernameVal, passwordVal, remember);
 
       await checkAuth();
       // Navigate to home after successful login
@@ -45,6 +58,7 @@ export function AuthProvider({ children }: { readonly children: ReactNode }) {
     await authApi.logout();
     setIsAuthenticated(false);
     setUsername(null);
+    setMustChangePassword(false);
     hasRedirectedForInvalidSessionRef.current = false;
     // Navigate to login after logout
     // eslint-disable-next-line sonarjs/void-use
@@ -56,6 +70,24 @@ export function AuthProvider({ children }: { readonly children: ReactNode }) {
     void checkAuth();
   }, [checkAuth]);
 
+  useEffect(() => {
+    // A password that expired or was flagged while logged in
+    const handlePasswordChangeRequired = () => {
+      setMustChangePassword(true);
+    };
+
+    window.addEventListener(
+      PASSWORD_CHANGE_REQUIRED_EVENT,
+      handlePasswordChangeRequired,
+    );
+    return () => {
+      window.removeEventListener(
+        PASSWORD_CHANGE_REQUIRED_EVENT,
+        handlePasswordChangeRequired,
+      );
+    };
+  }, []);
+
   useEffect(() => {
     const handleSessionInvalid = () => {
       setIsAuthenticated(false);
@@ -87,7 +119,17 @@ export function AuthProvider({ children }: { readonly children: ReactNode }) {
 
   return (
     <AuthContext.Provider
-      value={{ isAuthenticated, username, loading, login, logout, checkAuth }}
+      value={{
+        isAuthenticated,
+        username,
+        mustChangePassword,
+        passwordPolicy,
+        demo,
+        loading,
+        login,
+        logout,
+        checkAuth,
+      }}
     >
       {children}
     </AuthContext.Provider>
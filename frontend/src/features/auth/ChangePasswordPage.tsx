@@ -0,0 +1,154 @@
+import { useState } from "react";
+import { useAuth } from "./useAuth";
+import { authApi } from "./api";
+import type { PasswordPolicy } from "./api";
+import { BrandName, BrandingBanner } from "../../components/ui/Branding";
+
+const inputClassName =
+  "w-full bg-zinc-950/70 border border-zinc-700/80 rounded px-3 py-2 text-zinc-200 focus:outline-none focus:border-emerald-400 focus:ring-2 focus:ring-emerald-500/40 transition-colors";
+
+// Describes what a new password must meet
+function policyHint(policy: PasswordPolicy | null): string | null {
+  if (!policy) {
+    return null;
+  }
+  let hint = `At least ${policy.min_length} characters`;
+  if (policy.min_classes > 1) {
+    hint += `, mixing ${policy.min_classes} of lowercase letters, uppercase letters, digits and symbols`;
+  }
+  return `${hint}.`;
+}
+
+// Shown instead of the app while the server requires a password change,
+// because the password expired, is weaker than the policy or was flagged by
+// an administrator
+export default function ChangePasswordPage() {
+  const { username, passwordPolicy, checkAuth, logout } = useAuth();
+  const [currentPassword, setCurrentPassword] = useState("");
+  const [newPassword, setNewPassword] = useState("");
+  const [confirmPassword, setConfirmPassword] = useState("");
+  const [error, setError] = useState("");
+  const [saving, setSaving] = useState(false);
+  const hint = policyHint(passwordPolicy);
+
+  const handleSubmit = async (e: React.FormEvent<HTMLFormElement>) => {
+    e.preventDefault();
+    setError("");
+    if (newPassword !== confirmPassword) {
+      setError("The new passwords do not match");
+      return;
+    }
+
+    setSaving(true);
+    try {
+      await authApi.changePassword(currentPassword, newPassword);
+      await checkAuth();
+    } catch (error_) {
+      setError(
+        error_ instanceof Error ? error_.message : "Failed to change password",
+      );
+    } finally {
+      setSaving(false);
+    }
+  };
+
+  return (
+    <>
+      <BrandingBanner />
+      <div className="min-h-screen flex items-center justify-center px-6 py-12">
+        <div className="w-full max-w-md bg-zinc-900/80 border border-zinc-800/80 rounded-2xl p-8 shadow-2xl backdrop-blur">
+          <div className="text-xs uppercase tracking-[0.3em] text-zinc-500">
+            <BrandName logoClassName="h-4 w-4" />
+          </div>
+          <h2 className="text-2xl font-semibold text-zinc-100 mt-2 mb-2">
+            Change your password
+          </h2>
+          <p className="text-sm text-zinc-400 mb-6">
+            {username ? `${username}, your` : "Your"} password has to be
+            changed before you continue.
+            {hint && ` ${hint}`}
+          </p>
+
+          {/* eslint-disable-next-line @typescript-eslint/no-misused-promises */}
+          <form onSubmit={handleSubmit} className="space-y-4">
+            {error && (
+              <div className="bg-red-900/30 border border-red-800 text-red-200 px-4 py-2 rounded-lg">
+                {error}
+              </div>
+            )}
+
+            <div>
+              <label
+                htmlFor="current-password"
+                className="block text-sm font-medium text-zinc-300 mb-1"
+              >
+                Current password
+              </label>
+              <input
+                id="current-password"
+                type="password"
+                autoComplete="current-password"
+                value={currentPassword}
+                onChange={(e) => setCurrentPassword(e.target.value)}
+                className={inputClassName}
+                required
+              />
+            </div>
+
+            <div>
+              <label
+                htmlFor="new-password"
+                className="block text-sm font-medium text-zinc-300 mb-1"
+              >
+                New password
+              </label>
+              <input
+                id="new-password"
+                type="password"
+                autoComplete="new-password"
+                minLength={passwordPolicy?.min_length}
+                value={newPassword}
+                onChange={(e) => setNewPassword(e.target.value)}
+                className={inputClassName}
+                required
+              />
+            </div>
+
+            <div>
+              <label
+                htmlFor="confirm-password"
+                className="block text-sm font-medium text-zinc-300 mb-1"
+              >
+                Confirm new password
+              </label>
+              <input
+                id="confirm-password"
+                type="password"
+                autoComplete="new-password"
+                value={confirmPassword}
+                onChange={(e) => setConfirmPassword(e.target.value)}
+                className={inputClassName}
+                required
+              />
+            </div>
+
+            <button
+              type="submit"
+              disabled={saving}
+              className="w-full bg-emerald-600 hover:bg-emerald-500 text-white font-medium py-2 px-4 rounded-lg shadow-lg shadow-emerald-900/20 disabled:opacity-50 disabled:cursor-not-allowed transition-colors"
+            >
+              {saving ? "Saving..." : "Change password"}
+            </button>
+            <button
+              type="button"
+              onClick={() => void logout()}
+              className="w-full text-sm text-zinc-400 hover:text-zinc-200 transition-colors"
+            >
+              Log out
+            </button>
+          </form>
+        </div>
+      </div>
+    </>
+  );
+}
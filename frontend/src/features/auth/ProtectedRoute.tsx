@@ -1,9 +1,10 @@
-import { Navigate, useLocation } from "react-router-dom";
+import { Link, Navigate, useLocation } from "react-router-dom";
 import { useAuth } from "./useAuth";
+import ChangePasswordPage from "./ChangePasswordPage";
 import type { ReactNode } from "react";
 
 export function ProtectedRoute({ children }: { readonly children: ReactNode }) {
-  const { isAuthenticated, loading } = useAuth();
+  const { isAuthenticated, mustChangePassword, demo, loading } = useAuth();
   const location = useLocation();
 
   if (loading) {
@@ -14,6 +15,22 @@ export function ProtectedRoute({ children }: { readonly children: ReactNode }) {
     );
   }
 
+  if (!isAuthenticated && demo) {
+    return (
+      <div className="flex flex-col h-screen">
+        <div className="shrink-0 px-4 py-1.5 text-center text-xs bg-amber-900/60 text-amber-100">
+          Read-only demo: you can watch the demo sessions but not type into
+          them.{" "}
+          <Link to="/login" className="underline hover:text-white">
+            Log in
+          </Link>{" "}
+          for full access.
+        </div>
+        <div className="flex-1 min-h-0">{children}</div>
+      </div>
+    );
+  }
+
   if (!isAuthenticated) {
     return (
       <Navigate
@@ -24,5 +41,9 @@ export function ProtectedRoute({ children }: { readonly children: ReactNode }) {
     );
   }
 
+  if (mustChangePassword) {
+    return <ChangePasswordPage />;
+  }
+
   return <>{children}</>;
 }
@@ -1,8 +1,17 @@
 import { apiFetch, throwApiError } from "../../shared/http/client";
 
+export interface PasswordPolicy {
+  min_length: number;
+  min_classes: number;
+}
+
 export interface AuthStatusResponse {
   authenticated: boolean;
   username: string;
+  must_change_password?: boolean;
+  password_policy?: PasswordPolicy;
+  // not logged in, but may watch the demo sessions read-only
+  demo?: boolean;
 }
 
 interface LoginErrorResponse {
@@ -20,13 +29,19 @@ export const authApi = {
     return response.json() as Promise<AuthStatusResponse>;
   },
 
-  async login(username: string, password: string): Promise<void> {
+  // remember keeps the login for the server's remember-me TTL; otherwise it
+  // ends when the browser closes
+  async login(
+    username: string,
+    password: string,
+    remember: boolean,
+  ): Promise<void> {
     const response = await apiFetch(
       "/auth/login",
       {
         method: "POST",
         headers: { "Content-Type": "application/json" },
-        body: JSON.stringify({ username, password }),
+        body: JSON.stringify({ username, password, remember }),
       },
       {
         skipAuthRedirect: true,
@@ -39,6 +54,23 @@ export const authApi = {
     }
   },
 
+  async changePassword(
+    currentPassword: string,
+    newPassword: string,
+  ): Promise<void> {
+    const response = await apiFetch("/auth/password", {
+      method: "POST",
+      headers: { "Content-Type": "application/json" },
+      body: JSON.stringify({
+        current_password: currentPassword,
+        new_password: newPassword,
+      }),
+    });
+    if (!response.ok) {
+      await throwApiError(response, "Failed to change password");
+    }
+  },
+
   async logout(): Promise<void> {
     await apiFetch(
       "/auth/logout",
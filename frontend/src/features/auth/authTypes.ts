@@ -1,8 +1,17 @@
+import type { PasswordPolicy } from "./api";
+
 export interface AuthContextType {
   isAuthenticated: boolean;
   username: string | null;
+  mustChangePassword: boolean;
+  passwordPolicy: PasswordPolicy | null;
+  demo: boolean;
   loading: boolean;
-  login: (username: string, password: string) => Promise<void>;
+  login: (
+    username: string,
+    password: string,
+    remember: boolean,
+  ) => Promise<void>;
   logout: () => Promise<void>;
   checkAuth: () => Promise<void>;
 }
@@ -15,3 +15,11 @@ export function dispatchSessionInvalidEvent(
     }),
   );
 }
+
+// Fired when the API refuses a request until the user changes their password
+export const PASSWORD_CHANGE_REQUIRED_EVENT =
+  "terminal-hub:password-change-required";
+
+export function dispatchPasswordChangeRequiredEvent(): void {
+  window.dispatchEvent(new CustomEvent(PASSWORD_CHANGE_REQUIRED_EVENT));
+}
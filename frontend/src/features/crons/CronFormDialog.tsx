@@ -306,6 +306,10 @@ export default function CronFormDialog({
               className="w-full bg-zinc-950/70 border border-zinc-700/80 focus:border-emerald-400 focus:ring-2 focus:ring-emerald-500/40 rounded-lg px-4 py-2.5 text-sm text-zinc-200 placeholder:text-zinc-600 focus:outline-none transition-colors font-mono resize-y"
               disabled={isSubmitting}
             />
+            <p className="text-xs text-zinc-500 mt-1">
+              Use <code className="font-mono">KEY=secret://name</code> to read
+              a secret from the credential store when the job runs.
+            </p>
             {parsedEnvVars.errors.length > 0 && (
               <p className="text-sm text-red-400 mt-1">
                 {parsedEnvVars.errors[0]}
@@ -337,6 +341,28 @@ export default function CronFormDialog({
             </label>
           </div>
 
+          <div>
+            <label
+              htmlFor="cron-catch-up"
+              className="flex items-center gap-3 cursor-pointer"
+            >
+              <input
+                id="cron-catch-up"
+                type="checkbox"
+                checked={values.catchUp}
+                onChange={(event) => {
+                  handleFieldChange("catchUp", event.target.checked);
+                }}
+                className="w-5 h-5 rounded border-zinc-600 bg-zinc-800 text-emerald-400 focus:ring-emerald-500 focus:ring-offset-0"
+                disabled={isSubmitting}
+              />
+              <span className="text-sm font-medium text-zinc-200">
+                Run once at startup if a run was missed while the server was
+                down
+              </span>
+            </label>
+          </div>
+
           <div className="flex gap-3 justify-end pt-4 border-t border-zinc-800">
             <button
               type="button"
@@ -11,10 +11,11 @@ import {
 } from "lucide-react";
 import { formatDistanceToNow } from "date-fns";
 import { useCrons } from "./useCrons";
-import type { CronExecutionResult, CronJob } from "./api";
+import { AD_HOC_JOB_ID, type CronExecutionResult, type CronJob } from "./api";
 
 interface CronHistoryDialogProps {
-  readonly job: CronJob;
+  // A job, or AD_HOC_JOB_ID for the history of one-off commands
+  readonly job: Pick<CronJob, "id" | "name">;
   readonly onClose: () => void;
 }
 
@@ -57,8 +58,18 @@ function truncateContent(content: string, maxLength = 280): string {
   return `${content.slice(0, Math.max(0, maxLength))}...`;
 }
 
+// Runs outside a PTY record stderr apart from stdout; show the two streams
+// separately when there was any stderr, otherwise the combined output
+function splitStreams(execution: CronExecutionResult): boolean {
+  return execution.stderr !== undefined && execution.stderr !== "";
+}
+
 function hasLongOutput(execution: CronExecutionResult): boolean {
-  return execution.output.length > 280 || execution.error.length > 280;
+  return (
+    execution.output.length > 280 ||
+    (execution.stderr?.length ?? 0) > 280 ||
+    execution.error.length > 280
+  );
 }
 
 export default function CronHistoryDialog({
@@ -215,7 +226,9 @@ export default function CronHistoryDialog({
                 No execution history
               </h3>
               <p className="text-zinc-400">
-                This cron job has not been executed yet.
+                {job.id === AD_HOC_JOB_ID
+                  ? "No one-off commands have been run yet."
+                  : "This cron job has not been executed yet."}
               </p>
             </div>
           )}
@@ -249,16 +262,28 @@ export default function CronHistoryDialog({
                                 execution.started_at,
                                 execution.finished_at,
                               )}
-                              {" • Exit code: "}
-                              <span
-                                className={
-                                  execution.exit_code === 0
-                                    ? "text-emerald-400"
-                                    : "text-red-400"
-                                }
-                              >
-                                {execution.exit_code}
-                              </span>
+                              {execution.timed_out === true ? (
+                                <>
+                                  {" • "}
+                                  <span className="text-red-400">
+                                    Timed out
+                                  </span>
+                                </>
+                              ) : (
+                                <>
+                                  {" • Exit code: "}
+                                  <span
+                                    className={
+                                      execution.exit_code === 0
+                                        ? "text-emerald-400"
+                                        : "text-red-400"
+                                    }
+                                  >
+                                    {execution.exit_code}
+                                  </span>
+                                </>
+                              )}
+                              {execution.catch_up === true && " • Catch-up run"}
                             </p>
                           </div>
                         </div>
@@ -266,14 +291,42 @@ export default function CronHistoryDialog({
 
                       {hasContent && (
                         <div className="mt-3 space-y-2">
-                          {execution.output !== "" && (
-                            <div className="bg-zinc-950/50 border border-zinc-800 rounded px-3 py-2">
-                              <pre className="text-xs text-zinc-300 font-mono whitespace-pre-wrap break-all">
-                                {isExpanded
-                                  ? execution.output
-                                  : truncateContent(execution.output)}
-                              </pre>
-                            </div>
+                          {splitStreams(execution) ? (
+                            <>
+                              {execution.stdout !== undefined &&
+                                execution.stdout !== "" && (
+                                  <div className="bg-zinc-950/50 border border-zinc-800 rounded px-3 py-2">
+                                    <p className="text-xs font-semibold uppercase tracking-wider text-zinc-500 mb-1">
+                                      stdout
+                                    </p>
+                                    <pre className="text-xs text-zinc-300 font-mono whitespace-pre-wrap break-all">
+                                      {isExpanded
+                                        ? execution.stdout
+                                        : truncateContent(execution.stdout)}
+                                    </pre>
+                                  </div>
+                                )}
+                              <div className="bg-zinc-950/50 border border-amber-900/60 rounded px-3 py-2">
+                                <p className="text-xs font-semibold uppercase tracking-wider text-amber-500 mb-1">
+                                  stderr
+                                </p>
+                                <pre className="text-xs text-amber-200 font-mono whitespace-pre-wrap break-all">
+                                  {isExpanded
+                                    ? execution.stderr
+                                    : truncateContent(execution.stderr ?? "")}
+                                </pre>
+                              </div>
+                            </>
+                          ) : (
+                            execution.output !== "" && (
+                              <div className="bg-zinc-950/50 border border-zinc-800 rounded px-3 py-2">
+                                <pre className="text-xs text-zinc-300 font-mono whitespace-pre-wrap break-all">
+                                  {isExpanded
+                                    ? execution.output
+                                    : truncateContent(execution.output)}
+                                </pre>
+                              </div>
+                            )
                           )}
                           {execution.error !== "" && (
                             <div className="bg-red-950/20 border border-red-900/60 rounded px-3 py-2">
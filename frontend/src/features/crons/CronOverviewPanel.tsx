@@ -0,0 +1,113 @@
+import { useEffect, useState } from "react";
+import { formatDistanceToNow } from "date-fns";
+import { cronsApi, type CronJob, type CronOverview } from "./api";
+
+interface CronOverviewPanelProps {
+  // The overview is fetched again whenever the job list changes
+  readonly crons: CronJob[];
+}
+
+function formatSuccessRate(overview: CronOverview): string {
+  if (overview.last_24h.total === 0) {
+    return "—";
+  }
+
+  return `${String(Math.round(overview.last_24h.success_rate * 100))}%`;
+}
+
+export default function CronOverviewPanel({ crons }: CronOverviewPanelProps) {
+  const [overview, setOverview] = useState<CronOverview | null>(null);
+
+  useEffect(() => {
+    let cancelled = false;
+
+    cronsApi
+      .getCronOverview()
+      .then((data) => {
+        if (!cancelled) {
+          setOverview(data);
+        }
+      })
+      .catch(() => {
+        // The job list reports sync issues; the overview just keeps its data
+      });
+
+    return () => {
+      cancelled = true;
+    };
+  }, [crons]);
+
+  if (overview === null) {
+    return null;
+  }
+
+  const stats = overview.last_24h;
+
+  return (
+    <div className="grid grid-cols-1 lg:grid-cols-3 gap-3">
+      <div className="rounded-xl border border-zinc-800/80 bg-zinc-900/70 px-4 py-3">
+        <p className="text-xs uppercase tracking-wide text-zinc-500">
+          Success Rate (24h)
+        </p>
+        <p className="text-2xl font-semibold text-zinc-100 mt-1">
+          {formatSuccessRate(overview)}
+        </p>
+        <p className="text-xs text-zinc-500 mt-1">
+          {stats.succeeded} of {stats.total} runs succeeded
+          {stats.timed_out > 0 && ` • ${String(stats.timed_out)} timed out`}
+          {stats.skipped > 0 && ` • ${String(stats.skipped)} skipped`}
+        </p>
+      </div>
+
+      <div className="rounded-xl border border-zinc-800/80 bg-zinc-900/70 px-4 py-3">
+        <p className="text-xs uppercase tracking-wide text-zinc-500">
+          Running
+        </p>
+        <p className="text-2xl font-semibold text-sky-300 mt-1">
+          {overview.running.length}
+        </p>
+        <ul className="mt-1 space-y-0.5">
+          {overview.running.slice(0, 3).map((execution) => (
+            <li
+              key={execution.execution_id}
+              className="text-xs text-zinc-400 truncate"
+            >
+              {execution.job_name === ""
+                ? execution.job_id
+                : execution.job_name}{" "}
+              • started{" "}
+              {formatDistanceToNow(new Date(execution.started_at * 1000), {
+                addSuffix: true,
+              })}
+            </li>
+          ))}
+        </ul>
+      </div>
+
+      <div className="rounded-xl border border-zinc-800/80 bg-zinc-900/70 px-4 py-3">
+        <p className="text-xs uppercase tracking-wide text-zinc-500">
+          Upcoming Runs{overview.suspended && " (paused)"}
+        </p>
+        {overview.upcoming.length === 0 ? (
+          <p className="text-sm text-zinc-500 mt-2">Nothing scheduled</p>
+        ) : (
+          <ul className="mt-2 space-y-0.5">
+            {overview.upcoming.map((run) => (
+              <li
+                key={`${run.job_id}-${String(run.run_at)}`}
+                className="flex items-center justify-between gap-3 text-xs"
+              >
+                <span className="text-zinc-300 truncate">{run.job_name}</span>
+                <span className="text-zinc-500 flex-shrink-0">
+                  {formatDistanceToNow(new Date(run.run_at * 1000), {
+                    addSuffix: true,
+                  })}
+                </span>
+              </li>
+            ))}
+          </ul>
+        )}
+      </div>
+    </div>
+  );
+}
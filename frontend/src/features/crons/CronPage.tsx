@@ -6,11 +6,14 @@ import {
   Plus,
   RefreshCw,
   Search,
+  SquareTerminal,
 } from "lucide-react";
-import type { CronJob } from "./api";
+import { AD_HOC_JOB_ID, type CronJob } from "./api";
 import { useCrons } from "./useCrons";
 import CronFormDialog from "./CronFormDialog";
 import CronHistoryDialog from "./CronHistoryDialog";
+import CronOverviewPanel from "./CronOverviewPanel";
+import RunCommandDialog from "./RunCommandDialog";
 import CronTable, {
   type CronSortDirection,
   type CronSortKey,
@@ -24,7 +27,9 @@ import {
 
 function isFailingJob(job: CronJob): boolean {
   return (
-    job.metadata.last_run_status === "failed" || job.metadata.failure_count > 0
+    job.metadata.last_run_status === "failed" ||
+    job.metadata.last_run_status === "timeout" ||
+    job.metadata.failure_count > 0
   );
 }
 
@@ -41,7 +46,10 @@ function getStatusRank(job: CronJob): number {
     return 4;
   }
 
-  if (job.metadata.last_run_status === "failed") {
+  if (
+    job.metadata.last_run_status === "failed" ||
+    job.metadata.last_run_status === "timeout"
+  ) {
     return 0;
   }
 
@@ -153,7 +161,11 @@ export default function CronPage() {
   const [sortDirection, setSortDirection] = useState<CronSortDirection>("asc");
   const [showCreateDialog, setShowCreateDialog] = useState(false);
   const [editingJob, setEditingJob] = useState<CronJob | null>(null);
-  const [historyJob, setHistoryJob] = useState<CronJob | null>(null);
+  const [historyJob, setHistoryJob] = useState<Pick<
+    CronJob,
+    "id" | "name"
+  > | null>(null);
+  const [showRunCommandDialog, setShowRunCommandDialog] = useState(false);
   const [refreshing, setRefreshing] = useState(false);
   const [pendingJobIds, setPendingJobIds] = useState<Set<string>>(new Set());
 
@@ -313,6 +325,24 @@ export default function CronPage() {
               )}
               Refresh
             </button>
+            <button
+              type="button"
+              className="inline-flex items-center gap-2 px-3 py-2 bg-zinc-800 hover:bg-zinc-700 border border-zinc-700/80 rounded-lg text-zinc-200 text-sm transition-colors"
+              onClick={() =>
+                setHistoryJob({ id: AD_HOC_JOB_ID, name: "One-off commands" })
+              }
+            >
+              <Clock3 className="w-4 h-4" />
+              One-off History
+            </button>
+            <button
+              type="button"
+              className="inline-flex items-center gap-2 px-3 py-2 bg-zinc-800 hover:bg-zinc-700 border border-zinc-700/80 rounded-lg text-zinc-200 text-sm transition-colors"
+              onClick={() => setShowRunCommandDialog(true)}
+            >
+              <SquareTerminal className="w-4 h-4" />
+              Run Command
+            </button>
             <button
               type="button"
               className="inline-flex items-center gap-2 px-3 py-2 bg-emerald-600 hover:bg-emerald-500 rounded-lg text-white text-sm font-medium transition-colors"
@@ -359,6 +389,8 @@ export default function CronPage() {
           </div>
         </div>
 
+        <CronOverviewPanel crons={crons} />
+
         <div className="rounded-xl border border-zinc-800/80 bg-zinc-900/60 p-3 flex flex-col md:flex-row md:items-center gap-3">
           <div className="relative flex-1">
             <Search className="absolute left-3 top-1/2 -translate-y-1/2 w-4 h-4 text-zinc-500" />
@@ -494,6 +526,10 @@ export default function CronPage() {
         />
       )}
 
+      {showRunCommandDialog && (
+        <RunCommandDialog onClose={() => setShowRunCommandDialog(false)} />
+      )}
+
       {historyJob !== null && (
         <CronHistoryDialog
           job={historyJob}
@@ -59,6 +59,10 @@ function getStatusLabel(job: CronJob): string {
     return "Failing";
   }
 
+  if (job.metadata.last_run_status === "timeout") {
+    return "Timed Out";
+  }
+
   if (job.metadata.last_run_status === "success") {
     return "Healthy";
   }
@@ -71,7 +75,7 @@ function statusClassName(label: string): string {
     return "bg-emerald-900/40 text-emerald-300 border border-emerald-800/50";
   }
 
-  if (label === "Failing") {
+  if (label === "Failing" || label === "Timed Out") {
     return "bg-red-900/30 text-red-300 border border-red-800/50";
   }
 
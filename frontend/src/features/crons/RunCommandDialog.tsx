@@ -0,0 +1,209 @@
+import { useEffect, useRef, useState } from "react";
+import {
+  CheckCircle2,
+  Loader2,
+  Play,
+  SquareTerminal,
+  X,
+  XCircle,
+} from "lucide-react";
+import { cronsApi, executionStreamUrl, type CronExecutionResult } from "./api";
+
+interface RunCommandDialogProps {
+  readonly onClose: () => void;
+}
+
+// Runs a one-off command in a short-lived PTY on the server and shows its
+// output as it arrives. Runs are kept in the one-off command history.
+export default function RunCommandDialog({ onClose }: RunCommandDialogProps) {
+  const [command, setCommand] = useState("");
+  const [workingDirectory, setWorkingDirectory] = useState("");
+  const [timeoutText, setTimeoutText] = useState("");
+  const [output, setOutput] = useState("");
+  const [result, setResult] = useState<CronExecutionResult | null>(null);
+  const [running, setRunning] = useState(false);
+  const [error, setError] = useState("");
+  const sourceRef = useRef<EventSource | null>(null);
+  const commandInputRef = useRef<HTMLInputElement>(null);
+
+  useEffect(() => {
+    commandInputRef.current?.focus();
+    return () => sourceRef.current?.close();
+  }, []);
+
+  useEffect(() => {
+    const handleEscape = (event: KeyboardEvent) => {
+      if (event.key === "Escape") {
+        onClose();
+      }
+    };
+
+    window.addEventListener("keydown", handleEscape);
+    return () => window.removeEventListener("keydown", handleEscape);
+  }, [onClose]);
+
+  const handleSubmit = async (event: React.FormEvent) => {
+    event.preventDefault();
+    if (command.trim() === "" || running) {
+      return;
+    }
+
+    sourceRef.current?.close();
+    setOutput("");
+    setResult(null);
+    setError("");
+    setRunning(true);
+
+    try {
+      const live = await cronsApi.runCommand({
+        command,
+        working_directory: workingDirectory.trim() || undefined,
+        timeout: timeoutText.trim() || undefined,
+      });
+
+      const source = new EventSource(
+        executionStreamUrl(live.job_id, live.execution_id),
+        { withCredentials: true },
+      );
+      sourceRef.current = source;
+      source.addEventListener("output", (message) => {
+        const chunk = JSON.parse(
+          (message as MessageEvent<string>).data,
+        ) as string;
+        setOutput((previous) => previous + chunk);
+      });
+      source.addEventListener("done", (message) => {
+        setResult(
+          JSON.parse(
+            (message as MessageEvent<string>).data,
+          ) as CronExecutionResult,
+        );
+        setRunning(false);
+        source.close();
+      });
+      source.addEventListener("error", () => {
+        // The server ends the stream after "done"; anything else is a failure
+        if (source.readyState !== EventSource.CLOSED) {
+          setError("Lost the output stream; the run is kept in the history.");
+        }
+        setRunning(false);
+        source.close();
+      });
+    } catch (error_) {
+      setError(
+        error_ instanceof Error ? error_.message : "Failed to run command",
+      );
+      setRunning(false);
+    }
+  };
+
+  return (
+    <div
+      className="fixed inset-0 bg-black/70 backdrop-blur-md flex items-center justify-center z-50 p-4"
+      onClick={onClose}
+      onKeyDown={(event) => {
+        if (event.key === "Escape") {
+          onClose();
+        }
+      }}
+      role="button"
+      tabIndex={0}
+    >
+      <div
+        className="bg-zinc-900/90 border border-zinc-800/80 rounded-2xl shadow-2xl w-full max-w-3xl max-h-[90vh] overflow-hidden flex flex-col"
+        role="presentation"
+        onClick={(event) => event.stopPropagation()}
+        onMouseDown={(event) => event.stopPropagation()}
+      >
+        <div className="flex items-center justify-between p-6 border-b border-zinc-800">
+          <div className="flex items-center gap-3">
+            <div className="p-2 bg-emerald-500/10 rounded-lg">
+              <SquareTerminal className="w-5 h-5 text-emerald-400" />
+            </div>
+            <div>
+              <h2 className="text-xl font-semibold text-zinc-100">
+                Run Command
+              </h2>
+              <p className="text-xs text-zinc-500 mt-0.5">
+                Runs once in a new terminal that closes when it finishes
+              </p>
+            </div>
+          </div>
+          <button
+            onClick={onClose}
+            className="p-1.5 text-zinc-400 hover:text-zinc-200 hover:bg-zinc-800 rounded-lg transition-colors"
+            type="button"
+          >
+            <X className="w-5 h-5" />
+          </button>
+        </div>
+
+        <form
+          onSubmit={(event) => {
+            void handleSubmit(event);
+          }}
+          className="p-6 space-y-4 border-b border-zinc-800"
+        >
+          <input
+            ref={commandInputRef}
+            type="text"
+            value={command}
+            onChange={(event) => setCommand(event.target.value)}
+            placeholder="e.g., df -h"
+            className="w-full bg-zinc-950/70 border border-zinc-700/80 focus:border-emerald-400 focus:ring-2 focus:ring-emerald-500/40 rounded-lg px-4 py-2.5 text-sm font-mono text-zinc-200 placeholder:text-zinc-600 focus:outline-none transition-colors"
+          />
+          <div className="flex flex-col md:flex-row gap-3">
+            <input
+              type="text"
+              value={workingDirectory}
+              onChange={(event) => setWorkingDirectory(event.target.value)}
+              placeholder="Working directory (optional)"
+              className="flex-1 bg-zinc-950/70 border border-zinc-700/80 focus:border-emerald-400 rounded-lg px-4 py-2 text-sm text-zinc-200 placeholder:text-zinc-600 focus:outline-none transition-colors"
+            />
+            <input
+              type="text"
+              value={timeoutText}
+              onChange={(event) => setTimeoutText(event.target.value)}
+              placeholder="Timeout, e.g. 90s (optional)"
+              className="md:w-56 bg-zinc-950/70 border border-zinc-700/80 focus:border-emerald-400 rounded-lg px-4 py-2 text-sm text-zinc-200 placeholder:text-zinc-600 focus:outline-none transition-colors"
+            />
+            <button
+              type="submit"
+              disabled={command.trim() === "" || running}
+              className="inline-flex items-center justify-center gap-2 px-4 py-2 bg-emerald-600 hover:bg-emerald-500 disabled:opacity-50 rounded-lg text-white text-sm font-medium transition-colors"
+            >
+              {running ? (
+                <Loader2 className="w-4 h-4 animate-spin" />
+              ) : (
+                <Play className="w-4 h-4" />
+              )}
+              Run
+            </button>
+          </div>
+          {error !== "" && <p className="text-sm text-red-400">{error}</p>}
+        </form>
+
+        <div className="flex-1 overflow-y-auto p-4 space-y-3">
+          {result !== null && (
+            <div className="flex items-center gap-2 text-sm">
+              {result.exit_code === 0 ? (
+                <CheckCircle2 className="w-4 h-4 text-emerald-400" />
+              ) : (
+                <XCircle className="w-4 h-4 text-red-400" />
+              )}
+              <span className="text-zinc-300">
+                Exit code {result.exit_code}
+                {result.error !== "" && ` — ${result.error}`}
+              </span>
+            </div>
+          )}
+          {(output !== "" || running) && (
+            <pre className="bg-zinc-950/50 border border-zinc-800 rounded px-3 py-2 text-xs text-zinc-300 font-mono whitespace-pre-wrap break-all min-h-24">
+              {output}
+            </pre>
+          )}
+        </div>
+      </div>
+    </div>
+  );
+}
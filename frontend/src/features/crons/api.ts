@@ -1,4 +1,8 @@
 import { apiFetch, throwApiError } from "../../shared/http/client";
+import { BASE_PATH } from "../../shared/http/basePath";
+
+// Job ID one-off commands are recorded under
+export const AD_HOC_JOB_ID = "adhoc";
 
 export interface CronMetadata {
   created_at: number;
@@ -23,6 +27,7 @@ export interface CronJob {
   working_directory?: string;
   env_vars?: Record<string, string>;
   enabled: boolean;
+  catch_up?: boolean;
   metadata: CronMetadata;
 }
 
@@ -34,6 +39,7 @@ export interface CreateCronRequest {
   working_directory?: string;
   env_vars?: Record<string, string>;
   enabled: boolean;
+  catch_up?: boolean;
 }
 
 export interface UpdateCronRequest {
@@ -44,6 +50,7 @@ export interface UpdateCronRequest {
   working_directory?: string;
   env_vars?: Record<string, string>;
   enabled?: boolean;
+  catch_up?: boolean;
 }
 
 export interface CronExecutionResult {
@@ -53,7 +60,60 @@ export interface CronExecutionResult {
   finished_at: number;
   exit_code: number;
   output: string;
+  stdout?: string;
+  stderr?: string;
   error: string;
+  timed_out?: boolean;
+  catch_up?: boolean;
+}
+
+export interface RunCommandRequest {
+  command: string;
+  working_directory?: string;
+  timeout?: string;
+}
+
+export interface LiveExecution {
+  job_id: string;
+  execution_id: string;
+  started_at: number;
+}
+
+export interface CronRunStats {
+  total: number;
+  succeeded: number;
+  failed: number;
+  timed_out: number;
+  skipped: number;
+  success_rate: number;
+}
+
+export interface CronRunningExecution {
+  job_id: string;
+  job_name: string;
+  execution_id: string;
+  started_at: number;
+}
+
+export interface CronUpcomingRun {
+  job_id: string;
+  job_name: string;
+  run_at: number;
+}
+
+export interface CronOverview {
+  jobs: { total: number; enabled: number; disabled: number };
+  last_24h: CronRunStats;
+  running: CronRunningExecution[];
+  upcoming: CronUpcomingRun[];
+  suspended: boolean;
+  generated_at: number;
+}
+
+// URL of the server-sent events carrying an execution's output ("output"
+// events) and, once it finished, its result ("done")
+export function executionStreamUrl(jobId: string, executionId: string): string {
+  return `${BASE_PATH}/api/crons/${jobId}/executions/${executionId}/stream`;
 }
 
 export const cronsApi = {
@@ -154,4 +214,26 @@ export const cronsApi = {
     };
     return data.executions;
   },
+
+  async getCronOverview(): Promise<CronOverview> {
+    const response = await apiFetch("/crons/overview");
+    if (!response.ok) {
+      await throwApiError(response, "Failed to get cron overview");
+    }
+    return response.json() as Promise<CronOverview>;
+  },
+
+  async runCommand(request: RunCommandRequest): Promise<LiveExecution> {
+    const response = await apiFetch("/exec", {
+      method: "POST",
+      headers: {
+        "Content-Type": "application/json",
+      },
+      body: JSON.stringify(request),
+    });
+    if (!response.ok) {
+      await throwApiError(response, "Failed to run command");
+    }
+    return response.json() as Promise<LiveExecution>;
+  },
 };
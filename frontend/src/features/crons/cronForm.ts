@@ -8,6 +8,7 @@ export interface CronFormValues {
   workingDirectory: string;
   envVarsText: string;
   enabled: boolean;
+  catchUp: boolean;
 }
 
 export interface CronScheduleValidationResult {
@@ -156,6 +157,7 @@ export function createCronFormValues(job?: CronJob): CronFormValues {
     workingDirectory: job?.working_directory ?? "",
     envVarsText: envVarsToText(job?.env_vars),
     enabled: job?.enabled ?? true,
+    catchUp: job?.catch_up ?? false,
   };
 }
 
@@ -170,7 +172,8 @@ export function areCronFormValuesEqual(
     left.shell === right.shell &&
     left.workingDirectory === right.workingDirectory &&
     left.envVarsText === right.envVarsText &&
-    left.enabled === right.enabled
+    left.enabled === right.enabled &&
+    left.catchUp === right.catchUp
   );
 }
 
@@ -190,6 +193,10 @@ export function formValuesToCreateRequest(
     enabled: values.enabled,
   };
 
+  if (values.catchUp) {
+    request.catch_up = true;
+  }
+
   const shell = optionalFieldValue(values.shell);
   if (shell !== undefined) {
     request.shell = shell;
@@ -242,6 +249,10 @@ export function formValuesToUpdateRequest(
     updates.enabled = values.enabled;
   }
 
+  if (values.catchUp !== (job.catch_up ?? false)) {
+    updates.catch_up = values.catchUp;
+  }
+
   const envVarsResult = parseEnvVarsText(values.envVarsText);
   if (envVarsResult.errors.length > 0) {
     throw new Error(envVarsResult.errors[0]);
@@ -10,20 +10,33 @@ import {
 } from "react";
 import {
   ArrowUp,
+  ClipboardPaste,
   Download,
   Eye,
   EyeOff,
   File,
   Folder,
+  HardDrive,
+  Link2,
   Loader2,
+  PackageOpen,
   RefreshCw,
+  SquareTerminal,
   Upload,
 } from "lucide-react";
+import { useNavigate, useSearchParams } from "react-router-dom";
 import toast from "react-hot-toast";
+import { websocketUrl } from "../../shared/http/basePath";
+import { sessionsApi } from "../sessions/api";
+import { useSessions } from "../sessions/useSessions";
 import {
   browseWorkspaceFiles,
+  createDownloadLink,
   downloadWorkspaceFile,
+  extractWorkspaceArchive,
   isUploadWorkspaceRequestError,
+  isWorkspaceArchive,
+  pasteWorkspaceText,
   uploadWorkspaceFile,
   type FilesWorkspaceEntry,
 } from "./api";
@@ -53,6 +66,19 @@ type PendingUploadTask = Readonly<{
   fileName: string;
 }>;
 
+function directorySizeLabel(
+  entry: FilesWorkspaceEntry,
+  showSizes: boolean,
+): string {
+  if (!entry.is_directory) {
+    return formatBytes(entry.size);
+  }
+  if (!showSizes) {
+    return "—";
+  }
+  return entry.size_pending === true ? "Calculating…" : formatBytes(entry.size);
+}
+
 function formatBytes(size: number): string {
   if (size < 1024) {
     return `${String(size)} B`;
@@ -118,6 +144,11 @@ function uploadProgressClass(status: UploadStatus): string {
 }
 
 export default function FilesPage() {
+  // Opened from a session, the page starts where its shell is
+  const [searchParams] = useSearchParams();
+  const sessionId = searchParams.get("session") ?? undefined;
+  const navigate = useNavigate();
+  const { createSession } = useSessions();
   const [rootPath, setRootPath] = useState("");
   const [currentPath, setCurrentPath] = useState("");
   const [parentPath, setParentPath] = useState("");
@@ -125,12 +156,21 @@ export default function FilesPage() {
   const [loading, setLoading] = useState(true);
   const [error, setError] = useState<string | null>(null);
   const [showHidden, setShowHidden] = useState(false);
+  const [showSizes, setShowSizes] = useState(false);
+  const [sizesPending, setSizesPending] = useState(false);
   const [isDragOver, setIsDragOver] = useState(false);
   const [downloadingPath, setDownloadingPath] = useState<string | null>(null);
+  const [extractingPath, setExtractingPath] = useState<string | null>(null);
+  const [pasteText, setPasteText] = useState("");
+  const [pasteFilename, setPasteFilename] = useState("");
+  const [pasting, setPasting] = useState(false);
   const [uploadItems, setUploadItems] = useState<UploadItem[]>([]);
   const [lastCompletedBatchId, setLastCompletedBatchId] = useState(0);
 
   const showHiddenRef = useRef(showHidden);
+  const showSizesRef = useRef(showSizes);
+  const currentPathRef = useRef(currentPath);
+  const eventsSocketRef = useRef<WebSocket | null>(null);
   const browseRequestIDRef = useRef(0);
   const fileInputRef = useRef<HTMLInputElement | null>(null);
   const uploadItemsRef = useRef<UploadItem[]>([]);
@@ -144,12 +184,20 @@ export default function FilesPage() {
     showHiddenRef.current = showHidden;
   }, [showHidden]);
 
+  useEffect(() => {
+    showSizesRef.current = showSizes;
+  }, [showSizes]);
+
   useEffect(() => {
     uploadItemsRef.current = uploadItems;
   }, [uploadItems]);
 
   const loadDirectory = useCallback(
-    async (path?: string, showHiddenOverride?: boolean) => {
+    async (
+      path?: string,
+      showHiddenOverride?: boolean,
+      startSessionId?: string,
+    ) => {
       const requestID = browseRequestIDRef.current + 1;
       browseRequestIDRef.current = requestID;
 
@@ -160,6 +208,8 @@ export default function FilesPage() {
         const response = await browseWorkspaceFiles(
           path,
           showHiddenOverride ?? showHiddenRef.current,
+          startSessionId,
+          showSizesRef.current,
         );
         if (requestID !== browseRequestIDRef.current) {
           return;
@@ -169,6 +219,7 @@ export default function FilesPage() {
         setCurrentPath(response.current);
         setParentPath(response.parent ?? "");
         setEntries(response.entries);
+        setSizesPending(response.sizes_pending === true);
       } catch (error_) {
         if (requestID !== browseRequestIDRef.current) {
           return;
@@ -186,8 +237,100 @@ export default function FilesPage() {
   );
 
   useEffect(() => {
-    void loadDirectory().catch(() => {});
-  }, [loadDirectory]);
+    void loadDirectory(undefined, undefined, sessionId).catch(() => {});
+  }, [loadDirectory, sessionId]);
+
+  // Lists path again without showing the loading state, unless another
+  // directory was opened meanwhile
+  const refreshEntries = useCallback(async (path: string) => {
+    const requestID = browseRequestIDRef.current;
+    const response = await browseWorkspaceFiles(
+      path,
+      showHiddenRef.current,
+      undefined,
+      showSizesRef.current,
+    );
+    if (requestID !== browseRequestIDRef.current) {
+      return;
+    }
+    setEntries(response.entries);
+    setSizesPending(response.sizes_pending === true);
+  }, []);
+
+  // Directory sizes are computed in the background; ask again until they
+  // are all ready
+  useEffect(() => {
+    if (!sizesPending || currentPath === "") {
+      return;
+    }
+    const timer = setTimeout(() => {
+      refreshEntries(currentPath).catch(() => {
+        setSizesPending(false);
+      });
+    }, 1000);
+    return () => clearTimeout(timer);
+  }, [sizesPending, currentPath, entries, refreshEntries]);
+
+  // The server reports changes to the directory shown on /ws/events, so the
+  // listing follows builds writing artifacts without reloading
+  useEffect(() => {
+    const socket = new WebSocket(websocketUrl("/ws/events"));
+    eventsSocketRef.current = socket;
+    socket.addEventListener("open", () => {
+      socket.send(
+        JSON.stringify({ type: "watch_files", path: currentPathRef.current }),
+      );
+    });
+    socket.addEventListener("message", (message) => {
+      const event = JSON.parse((message as MessageEvent<string>).data) as {
+        type: string;
+        path?: string;
+      };
+      if (
+        event.type === "files_changed" &&
+        event.path === currentPathRef.current
+      ) {
+        void refreshEntries(event.path).catch(() => {});
+      }
+    });
+    return () => {
+      eventsSocketRef.current = null;
+      socket.close();
+    };
+  }, [refreshEntries]);
+
+  useEffect(() => {
+    currentPathRef.current = currentPath;
+    const socket = eventsSocketRef.current;
+    if (socket?.readyState === WebSocket.OPEN) {
+      socket.send(JSON.stringify({ type: "watch_files", path: currentPath }));
+    }
+  }, [currentPath]);
+
+  // Opens a new session in the current directory
+  const openTerminalHere = () => {
+    const name = currentPath
+      .split("/")
+      .filter((part) => part !== "")
+      .at(-1);
+    createSession(name ?? "shell", currentPath)
+      .then((id) => {
+        void navigate(`/session/${id}`);
+      })
+      .catch(() => {});
+  };
+
+  // Moves the shell of the session the page was opened from here
+  const changeSessionDirectory = (targetSessionId: string) => {
+    sessionsApi
+      .changeDirectory(targetSessionId, currentPath)
+      .then(() => {
+        void navigate(`/session/${targetSessionId}`);
+      })
+      .catch((error_: unknown) => {
+        toast.error(getErrorMessage(error_, "Failed to change directory"));
+      });
+  };
 
   const refreshCurrentDirectory = useCallback(() => {
     const targetPath = currentPath.trim() === "" ? undefined : currentPath;
@@ -470,6 +613,82 @@ export default function FilesPage() {
       });
   };
 
+  // Copies a single-use download link, for fetching the entry on a device
+  // without a login
+  const handleCopyLink = (entry: FilesWorkspaceEntry) => {
+    createDownloadLink(entry.path)
+      .then(async (link) => {
+        const minutes = Math.round(
+          (link.expires_at * 1000 - Date.now()) / 60_000,
+        );
+        if (
+          navigator.clipboard == null ||
+          typeof navigator.clipboard.writeText !== "function"
+        ) {
+          toast.success(
+            `Download link (valid ${String(minutes)} min): ${link.url}`,
+            { duration: 15_000 },
+          );
+          return;
+        }
+        await navigator.clipboard.writeText(link.url);
+        toast.success(
+          `Link to ${entry.name} copied; it works once within ${String(minutes)} min`,
+        );
+      })
+      .catch((error_: Error) => {
+        toast.error(
+          getErrorMessage(error_, "Failed to create download link"),
+        );
+      });
+  };
+
+  // Saves the pasted text as a new file in the current directory
+  const handlePaste = () => {
+    setPasting(true);
+    pasteWorkspaceText(currentPath, pasteText, pasteFilename)
+      .then((result) => {
+        toast.success(`Saved ${result.filename}`);
+        setPasteText("");
+        void refreshEntries(currentPathRef.current).catch(() => {});
+      })
+      .catch((error_: Error) => {
+        toast.error(getErrorMessage(error_, "Failed to save pasted text"));
+      })
+      .finally(() => {
+        setPasting(false);
+      });
+  };
+
+  // Unpacks an archive next to it, such as a tarball just dropped in
+  const handleExtract = (entry: FilesWorkspaceEntry) => {
+    setExtractingPath(entry.path);
+    const toastId = toast.loading(`Extracting ${entry.name}...`);
+    extractWorkspaceArchive(entry.path, (progress) => {
+      toast.loading(
+        `Extracting ${entry.name}: ${String(progress.entries)} files, ${formatBytes(progress.bytes)}`,
+        { id: toastId },
+      );
+    })
+      .then((result) => {
+        const skipped =
+          result.skipped > 0 ? `, ${String(result.skipped)} skipped` : "";
+        toast.success(
+          `Extracted ${String(result.entries)} entries from ${entry.name}${skipped}`,
+          { id: toastId },
+        );
+        void refreshEntries(currentPathRef.current).catch(() => {});
+      })
+      .catch((error_: Error) => {
+        toast.error(getErrorMessage(error_, "Failed to extract archive"), {
+          id: toastId,
+        });
+      })
+      .finally(() => {
+        setExtractingPath(null);
+      });
+  };
+
   const pendingUploadCount = useMemo(
     () =>
       uploadItems.filter(
@@ -580,20 +799,51 @@ export default function FilesPage() {
                   </button>
                 </td>
                 <td className="px-4 py-2 text-xs text-zinc-400">
-                  {entry.is_directory ? "—" : formatBytes(entry.size)}
+                  {directorySizeLabel(entry, showSizes)}
                 </td>
                 <td className="px-4 py-2 text-xs text-zinc-400">
                   {formatDateTime(entry.modified_at)}
                 </td>
                 <td className="px-4 py-2 text-right">
-                  <button
-                    type="button"
-                    className="inline-flex items-center gap-1 rounded border border-zinc-700 bg-zinc-900 px-2 py-1 text-xs text-zinc-200 hover:bg-zinc-800 disabled:opacity-50"
-                    onClick={() => handleEntryClick(entry)}
-                    disabled={isDownloading}
-                  >
-                    {actionContent}
-                  </button>
+                  <div className="inline-flex items-center gap-2">
+                    {!entry.is_directory && isWorkspaceArchive(entry.name) && (
+                      <button
+                        type="button"
+                        className="inline-flex items-center gap-1 rounded border border-zinc-700 bg-zinc-900 px-2 py-1 text-xs text-zinc-200 hover:bg-zinc-800 disabled:opacity-50"
+                        onClick={() => handleExtract(entry)}
+                        disabled={extractingPath !== null}
+                        title="Extract into this directory"
+                      >
+                        {extractingPath === entry.path ? (
+                          <Loader2 className="h-3.5 w-3.5 animate-spin" />
+                        ) : (
+                          <PackageOpen className="h-3.5 w-3.5" />
+                        )}
+                        Extract
+                      </button>
+                    )}
+                    <button
+                      type="button"
+                      className="inline-flex items-center gap-1 rounded border border-zinc-700 bg-zinc-900 px-2 py-1 text-xs text-zinc-200 hover:bg-zinc-800"
+                      onClick={() => handleCopyLink(entry)}
+                      title={
+                        entry.is_directory
+                          ? "Copy a single-use link to a zip of this directory"
+                          : "Copy a single-use download link"
+                      }
+                    >
+                      <Link2 className="h-3.5 w-3.5" />
+                      Link
+                    </button>
+                    <button
+                      type="button"
+                      className="inline-flex items-center gap-1 rounded border border-zinc-700 bg-zinc-900 px-2 py-1 text-xs text-zinc-200 hover:bg-zinc-800 disabled:opacity-50"
+                      onClick={() => handleEntryClick(entry)}
+                      disabled={isDownloading}
+                    >
+                      {actionContent}
+                    </button>
+                  </div>
                 </td>
               </tr>
             );
@@ -664,6 +914,44 @@ export default function FilesPage() {
               )}
               {showHidden ? "Hide Hidden" : "Show Hidden"}
             </button>
+            <button
+              type="button"
+              className="inline-flex items-center gap-2 rounded-md border border-zinc-700 bg-zinc-900 px-3 py-2 text-sm text-zinc-200 hover:bg-zinc-800 disabled:opacity-50"
+              onClick={() => {
+                const nextValue = !showSizes;
+                setShowSizes(nextValue);
+                showSizesRef.current = nextValue;
+                const targetPath =
+                  currentPath.trim() === "" ? undefined : currentPath;
+                void loadDirectory(targetPath).catch(() => {});
+              }}
+              disabled={loading}
+              title="Add up the size of the files in each directory"
+            >
+              <HardDrive className="h-4 w-4" />
+              {showSizes ? "Hide Sizes" : "Show Sizes"}
+            </button>
+            {sessionId != null && (
+              <button
+                type="button"
+                className="inline-flex items-center gap-2 rounded-md border border-zinc-700 bg-zinc-900 px-3 py-2 text-sm text-zinc-200 hover:bg-zinc-800 disabled:opacity-50"
+                onClick={() => changeSessionDirectory(sessionId)}
+                disabled={loading || currentPath === ""}
+                title="Change the session's directory to this one"
+              >
+                <SquareTerminal className="h-4 w-4" />
+                cd in Session
+              </button>
+            )}
+            <button
+              type="button"
+              className="inline-flex items-center gap-2 rounded-md border border-zinc-700 bg-zinc-900 px-3 py-2 text-sm text-zinc-200 hover:bg-zinc-800 disabled:opacity-50"
+              onClick={openTerminalHere}
+              disabled={loading || currentPath === ""}
+            >
+              <SquareTerminal className="h-4 w-4" />
+              Open Terminal Here
+            </button>
           </div>
         </div>
 
@@ -772,6 +1060,41 @@ export default function FilesPage() {
               )}
             </div>
 
+            <div className="rounded-xl border border-zinc-800/80 bg-zinc-900/60 p-4">
+              <h2 className="text-sm font-semibold text-zinc-100">
+                Paste Text
+              </h2>
+              <textarea
+                className="mt-3 h-28 w-full resize-y rounded-md border border-zinc-700 bg-zinc-950/60 px-3 py-2 font-mono text-xs text-zinc-200 placeholder:text-zinc-600"
+                placeholder="Paste a snippet or config..."
+                value={pasteText}
+                onChange={(event) => setPasteText(event.target.value)}
+              />
+              <input
+                type="text"
+                className="mt-2 w-full rounded-md border border-zinc-700 bg-zinc-950/60 px-3 py-2 font-mono text-xs text-zinc-200 placeholder:text-zinc-600"
+                placeholder="paste-{date}-{time}.txt"
+                value={pasteFilename}
+                onChange={(event) => setPasteFilename(event.target.value)}
+                title="Filename; {date}, {time}, {unix} and {n} are filled in"
+              />
+              <button
+                type="button"
+                className="mt-2 inline-flex w-full items-center justify-center gap-2 rounded-md border border-zinc-700 bg-zinc-900 px-3 py-2 text-sm text-zinc-200 hover:bg-zinc-800 disabled:opacity-50"
+                onClick={handlePaste}
+                disabled={
+                  pasting || pasteText === "" || currentPath.trim() === ""
+                }
+              >
+                {pasting ? (
+                  <Loader2 className="h-4 w-4 animate-spin" />
+                ) : (
+                  <ClipboardPaste className="h-4 w-4" />
+                )}
+                Save as File
+              </button>
+            </div>
+
             <div className="rounded-xl border border-zinc-800/80 bg-zinc-900/60">
               <div className="flex items-center justify-between border-b border-zinc-800 px-4 py-3">
                 <h3 className="text-sm font-semibold text-zinc-100">
@@ -1,5 +1,6 @@
 import { dispatchSessionInvalidEvent } from "../auth/sessionEvents";
 import { apiFetch, throwApiError } from "../../shared/http/client";
+import { BASE_PATH } from "../../shared/http/basePath";
 
 const uploadPathHeader = "X-Terminal-Hub-Upload-Path";
 const uploadFilenameHeader = "X-Terminal-Hub-Upload-Filename";
@@ -9,7 +10,9 @@ export interface FilesWorkspaceEntry {
   name: string;
   path: string;
   is_directory: boolean;
+  // For directories, the size of their files when asked for with sizes
   size: number;
+  size_pending?: boolean;
   modified_at: string;
 }
 
@@ -18,6 +21,20 @@ export interface BrowseWorkspaceFilesResponse {
   current: string;
   parent?: string;
   entries: FilesWorkspaceEntry[];
+  sizes_pending?: boolean;
+}
+
+export interface ExtractWorkspaceArchiveResponse {
+  destination: string;
+  entries: number;
+  bytes: number;
+  skipped: number;
+}
+
+export interface DownloadLinkResponse {
+  url: string;
+  path: string;
+  expires_at: number;
 }
 
 export interface UploadWorkspaceFileOptions {
@@ -108,14 +125,25 @@ export function isUploadWorkspaceRequestError(
   );
 }
 
+// Without a path, lists the directory the shell of sessionId is in, or the
+// server's working directory. withSizes asks for directory sizes, which the
+// server computes in the background; ask again while sizes_pending is set.
 export async function browseWorkspaceFiles(
   path?: string,
   showHidden = false,
+  sessionId?: string,
+  withSizes = false,
 ): Promise<BrowseWorkspaceFilesResponse> {
   const query = new URLSearchParams();
   if (path != null && path.trim() !== "") {
     query.set("path", path.trim());
   }
+  if (sessionId != null && sessionId !== "") {
+    query.set("session", sessionId);
+  }
+  if (withSizes) {
+    query.set("withSizes", "true");
+  }
   if (showHidden) {
     query.set("showHidden", "true");
   }
@@ -130,6 +158,67 @@ export async function browseWorkspaceFiles(
   return response.json() as Promise<BrowseWorkspaceFilesResponse>;
 }
 
+const archiveNamePattern = /\.(zip|tar|tar\.gz|tgz)$/i;
+
+// Reports whether the server can extract the file called name
+export function isWorkspaceArchive(name: string): boolean {
+  return archiveNamePattern.test(name);
+}
+
+// Extracts the archive at path next to it on the server. Progress arrives
+// as server-sent events in the response, which onProgress is called with.
+export async function extractWorkspaceArchive(
+  path: string,
+  onProgress?: (progress: ExtractWorkspaceArchiveResponse) => void,
+): Promise<ExtractWorkspaceArchiveResponse> {
+  const response = await apiFetch("/files/extract", {
+    method: "POST",
+    headers: {
+      "Content-Type": "application/json",
+      Accept: "text/event-stream",
+    },
+    body: JSON.stringify({ path }),
+  });
+  if (!response.ok) {
+    await throwApiError(response, "Failed to extract archive");
+  }
+  if (response.body == null) {
+    throw new Error("Failed to extract archive: empty response");
+  }
+
+  const reader = response.body.pipeThrough(new TextDecoderStream()).getReader();
+  let buffered = "";
+  for (;;) {
+    const { done, value } = await reader.read();
+    if (done) {
+      break;
+    }
+    buffered += value;
+
+    let boundary = buffered.indexOf("\n\n");
+    while (boundary !== -1) {
+      const block = buffered.slice(0, boundary);
+      buffered = buffered.slice(boundary + 2);
+      boundary = buffered.indexOf("\n\n");
+
+      const event = /^event: (.*)$/m.exec(block)?.[1];
+      const data = /^data: (.*)$/m.exec(block)?.[1];
+      if (data == null) {
+        continue;
+      }
+      if (event === "progress") {
+        onProgress?.(JSON.parse(data) as ExtractWorkspaceArchiveResponse);
+      } else if (event === "done") {
+        return JSON.parse(data) as ExtractWorkspaceArchiveResponse;
+      } else if (event === "error") {
+        const { message } = JSON.parse(data) as { message: string };
+        throw new Error(`Failed to extract archive: ${message}`);
+      }
+    }
+  }
+  throw new Error("Failed to extract archive: the response ended early");
+}
+
 export function uploadWorkspaceFile(
   options: UploadWorkspaceFileOptions,
 ): Promise<UploadWorkspaceFileResponse> {
@@ -180,7 +269,7 @@ export function uploadWorkspaceFile(
       signal.addEventListener("abort", onAbort, { once: true });
     }
 
-    xhr.open("POST", "/api/upload");
+    xhr.open("POST", `${BASE_PATH}/api/upload`);
     xhr.withCredentials = true;
     xhr.responseType = "text";
     xhr.setRequestHeader(
@@ -235,6 +324,46 @@ export function uploadWorkspaceFile(
   });
 }
 
+// Writes text to a new file in directory. filename may use {date}, {time},
+// {unix} and {n}; the server picks "paste-{date}-{time}.txt" without one.
+export async function pasteWorkspaceText(
+  directory: string,
+  content: string,
+  filename?: string,
+): Promise<UploadWorkspaceFileResponse> {
+  const trimmedFilename = filename?.trim() ?? "";
+  const response = await apiFetch("/files/paste", {
+    method: "POST",
+    headers: { "Content-Type": "application/json" },
+    body: JSON.stringify({
+      directory,
+      content,
+      filename: trimmedFilename === "" ? undefined : trimmedFilename,
+    }),
+  });
+  if (!response.ok) {
+    await throwApiError(response, "Failed to save pasted text");
+  }
+  return response.json() as Promise<UploadWorkspaceFileResponse>;
+}
+
+// Creates a single-use link that downloads path without a login, for
+// pasting into another device
+export async function createDownloadLink(
+  path: string,
+  filename?: string,
+): Promise<DownloadLinkResponse> {
+  const response = await apiFetch("/download/link", {
+    method: "POST",
+    headers: { "Content-Type": "application/json" },
+    body: JSON.stringify({ path, filename }),
+  });
+  if (!response.ok) {
+    await throwApiError(response, "Failed to create download link");
+  }
+  return response.json() as Promise<DownloadLinkResponse>;
+}
+
 export async function downloadWorkspaceFile(
   path: string,
   filename?: string,
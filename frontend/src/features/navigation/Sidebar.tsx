@@ -6,6 +6,7 @@ import {
 } from "react-router-dom";
 import {
   Clock3,
+  Copy,
   FolderOpen,
   LayoutGrid,
   LogOut,
@@ -26,6 +27,8 @@ import MobileCommandBar from "../../components/ui/MobileCommandBar";
 import MobileCommandButton from "../../components/ui/MobileCommandButton";
 import MobileCommandSheet from "../../components/ui/MobileCommandSheet";
 import { MOBILE_COMMAND_OPEN_EVENT } from "../../shared/mobileCommandEvents";
+import { DEFAULT_TITLE, useUIConfig } from "../../shared/uiConfig";
+import { BrandName } from "../../components/ui/Branding";
 
 type SidebarProps = Readonly<{
   containerClassName?: string;
@@ -39,6 +42,7 @@ type SessionListItemProps = Readonly<{
   collapsed: boolean;
   onNavigate: (id: string) => void;
   onRename: (id: string) => void;
+  onDuplicate: (id: string) => void;
   onDelete: (id: string, name: string) => void;
   onCloseMenu?: () => void;
   actionMode?: "hover" | "always";
@@ -50,6 +54,7 @@ function SessionListItem({
   collapsed,
   onNavigate,
   onRename,
+  onDuplicate,
   onDelete,
   onCloseMenu,
   actionMode = "hover",
@@ -105,6 +110,17 @@ function SessionListItem({
           >
             <Pencil className="w-4 h-4" />
           </button>
+          <button
+            onClick={(event) => {
+              event.stopPropagation();
+              onDuplicate(session.id);
+              onCloseMenu?.();
+            }}
+            className="p-1 rounded hover:bg-zinc-700 text-zinc-500 hover:text-zinc-300 transition-all"
+            title="Duplicate session"
+          >
+            <Copy className="w-4 h-4" />
+          </button>
           <button
             onClick={(event) => {
               event.stopPropagation();
@@ -202,6 +218,7 @@ type MobileCommandMenuProps = Readonly<{
   onNavigateToCrons: () => void;
   onNavigateToFiles: () => void;
   onRename: (id: string) => void;
+  onDuplicate: (id: string) => void;
   onDelete: (id: string, name: string) => void;
   onCreateSession: () => void;
   onLogout: () => void;
@@ -221,6 +238,7 @@ function MobileCommandMenu({
   onNavigateToCrons,
   onNavigateToFiles,
   onRename,
+  onDuplicate,
   onDelete,
   onCreateSession,
   onLogout,
@@ -230,9 +248,14 @@ function MobileCommandMenu({
   const isFilesActive = currentPathname === "/files";
   const cronLabel =
     cronCount > 0 ? `Cron Jobs (${String(cronCount)})` : "Cron Jobs";
+  const { title } = useUIConfig();
 
   return (
-    <MobileCommandSheet open={open} onClose={onClose} title="Terminal Hub">
+    <MobileCommandSheet
+      open={open}
+      onClose={onClose}
+      title={title ?? DEFAULT_TITLE}
+    >
       <div className="space-y-3">
         <div className="space-y-2">
           <input
@@ -275,6 +298,7 @@ function MobileCommandMenu({
                   actionMode="always"
                   onNavigate={onNavigate}
                   onRename={onRename}
+                  onDuplicate={onDuplicate}
                   onDelete={onDelete}
                   onCloseMenu={onClose}
                 />
@@ -368,7 +392,7 @@ export default function Sidebar({
   onNavigate,
   testId,
 }: SidebarProps) {
-  const { sessions, deleteSession } = useSessions();
+  const { sessions, cloneSession, deleteSession } = useSessions();
   const { crons } = useCrons();
   const { logout } = useAuth();
   const navigate = useNavigate();
@@ -417,7 +441,22 @@ export default function Sidebar({
   };
 
   const handleNavigateToFiles = () => {
-    navigateWithHandler(navigate, "/files", onNavigate);
+    // From a session, browse the directory its shell is in
+    const path =
+      currentSessionId == null
+        ? "/files"
+        : `/files?session=${encodeURIComponent(currentSessionId)}`;
+    navigateWithHandler(navigate, path, onNavigate);
+  };
+
+  const handleDuplicateSession = (sessionId: string) => {
+    cloneSession(sessionId)
+      .then((cloneId) => {
+        handleNavigate(cloneId);
+      })
+      .catch((error: Error) => {
+        console.error(error);
+      });
   };
 
   const handleDeleteSession = (sessionId: string, sessionName: string) => {
@@ -482,7 +521,7 @@ export default function Sidebar({
               className="font-semibold text-zinc-100 tracking-tight hover:text-emerald-300 transition-colors"
               title="Go to Dashboard"
             >
-              Terminal Hub
+              <BrandName />
             </button>
           )}
           <button
@@ -548,6 +587,7 @@ export default function Sidebar({
                   collapsed={collapsed}
                   onNavigate={handleNavigate}
                   onRename={setRenameSessionId}
+                  onDuplicate={handleDuplicateSession}
                   onDelete={handleDeleteSession}
                 />
               ))}
@@ -614,6 +654,7 @@ export default function Sidebar({
         onNavigateToCrons={handleNavigateToCrons}
         onNavigateToFiles={handleNavigateToFiles}
         onRename={setRenameSessionId}
+        onDuplicate={handleDuplicateSession}
         onDelete={handleDeleteSession}
         onCreateSession={() => setShowCreateDialog(true)}
         onLogout={() => {
@@ -16,6 +16,7 @@ interface SessionContextType {
     envVars?: Record<string, string>,
     backend?: SessionBackend,
   ) => Promise<string>;
+  cloneSession: (sessionId: string) => Promise<string>;
   deleteSession: (sessionId: string) => Promise<void>;
   updateSessionName: (sessionId: string, newName: string) => Promise<void>;
 }
@@ -74,6 +75,22 @@ export function SessionProvider({
     }
   };
 
+  const cloneSession = async (sessionId: string): Promise<string> => {
+    try {
+      const response = await sessionsApi.cloneSession(sessionId);
+      toast.success(
+        `Session "${response.metadata.name}" created successfully`,
+      );
+      await refreshSessions();
+      return response.id;
+    } catch (error_) {
+      const message =
+        error_ instanceof Error ? error_.message : "Failed to clone session";
+      toast.error(message);
+      throw error_;
+    }
+  };
+
   const deleteSession = async (sessionId: string) => {
     try {
       await sessionsApi.deleteSession(sessionId);
@@ -115,6 +132,7 @@ export function SessionProvider({
         error,
         refreshSessions,
         createSession,
+        cloneSession,
         deleteSession,
         updateSessionName,
       }}
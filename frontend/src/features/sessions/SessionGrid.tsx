@@ -3,6 +3,8 @@ import { useNavigate } from "react-router-dom";
 import { useSessions } from "./useSessions";
 import type { SessionInfo } from "./api";
 import TerminalComponent from "../terminal/Terminal";
+import SessionThumbnail from "./SessionThumbnail";
+import { websocketUrl } from "../../shared/http/basePath";
 
 function useMediaQuery(query: string) {
   const [matches, setMatches] = useState(
@@ -151,10 +153,10 @@ export default function SessionGrid() {
             className={`flex flex-col gap-6 ${isDesktop ? "md:grid md:grid-cols-2 xl:grid-cols-2" : ""} min-h-[500px]`}
           >
             {sortedSessions.map((session, index) => {
-              const protocol =
-                window.location.protocol === "https:" ? "wss://" : "ws://";
-              const wsUrl = `${protocol}${window.location.host}/ws/${session.id}`;
-              const workingDirectory = session.metadata.working_directory;
+              const wsUrl = websocketUrl(`/ws/${session.id}`);
+              const workingDirectory =
+                session.metadata.current_directory ??
+                session.metadata.working_directory;
               const hasWorkingDirectory =
                 typeof workingDirectory === "string" &&
                 workingDirectory.trim() !== "";
@@ -235,14 +237,11 @@ export default function SessionGrid() {
                     </div>
                   )}
                   {!isDesktop && (
-                    <div className="flex-1 min-h-0 p-4 bg-zinc-950/80 flex items-center justify-center">
-                      <button
-                        onClick={() => handleNavigate(session.id)}
-                        className="text-zinc-400 hover:text-emerald-300 text-sm font-medium transition-colors"
-                        data-testid="session-card-open-mobile"
-                      >
-                        Open Session →
-                      </button>
+                    <div className="flex-1 min-h-0 bg-black">
+                      <SessionThumbnail
+                        sessionId={session.id}
+                        onOpen={() => handleNavigate(session.id)}
+                      />
                     </div>
                   )}
                   <div className="px-3 py-2 bg-zinc-950/60 border-t border-zinc-800/80 flex flex-wrap gap-2 text-xs text-zinc-400">
@@ -0,0 +1,54 @@
+import { useEffect, useState } from "react";
+import { sessionsApi } from "./api";
+
+// How often a thumbnail is re-rendered; unchanged screens cost a 304
+const THUMBNAIL_REFRESH_MS = 5000;
+
+interface SessionThumbnailProps {
+  sessionId: string;
+  onOpen: () => void;
+}
+
+// A live, read-only text preview of a session's screen, for places where
+// attaching a terminal per session would be too heavy
+export default function SessionThumbnail({
+  sessionId,
+  onOpen,
+}: SessionThumbnailProps) {
+  const [text, setText] = useState<string | null>(null);
+
+  useEffect(() => {
+    let cancelled = false;
+    const refresh = async () => {
+      try {
+        const snapshot = await sessionsApi.getSnapshot(sessionId);
+        if (!cancelled) {
+          setText(snapshot.lines.map((line) => line.text).join("\n"));
+        }
+      } catch {
+        // Keep the last preview; the session list reports sessions that end
+      }
+    };
+    void refresh();
+    const timer = window.setInterval(() => {
+      void refresh();
+    }, THUMBNAIL_REFRESH_MS);
+    return () => {
+      cancelled = true;
+      window.clearInterval(timer);
+    };
+  }, [sessionId]);
+
+  return (
+    <button
+      onClick={onOpen}
+      className="w-full h-full overflow-hidden text-left bg-black p-2"
+      data-testid="session-card-open-mobile"
+      aria-label="Open Session"
+    >
+      <pre className="text-[7px] leading-[9px] font-mono text-zinc-300 whitespace-pre">
+        {text ?? ""}
+      </pre>
+    </button>
+  );
+}
@@ -8,6 +8,7 @@ export interface SessionMetadata {
   last_activity_at: string;
   client_count: number;
   working_directory?: string;
+  current_directory?: string;
   command?: string;
   env_vars?: Record<string, string>;
   backend: SessionBackend;
@@ -37,6 +38,16 @@ export interface UpdateSessionRequest {
   name: string;
 }
 
+// The screen of a session rendered by the server from its history
+export interface ScreenSnapshot {
+  cols: number;
+  rows: number;
+  title?: string;
+  alt_screen: boolean;
+  cursor: { row: number; col: number; visible: boolean };
+  lines: { text: string }[];
+}
+
 export const sessionsApi = {
   async listSessions(): Promise<SessionInfo[]> {
     const response = await apiFetch("/sessions");
@@ -64,6 +75,50 @@ export const sessionsApi = {
     return response.json() as Promise<CreateSessionResponse>;
   },
 
+  // Starts a new session with the shell, working directory, environment
+  // variables and startup command of an existing one
+  async cloneSession(
+    sessionId: string,
+    name?: string,
+  ): Promise<CreateSessionResponse> {
+    const response = await apiFetch(`/sessions/${sessionId}/clone`, {
+      method: "POST",
+      headers: {
+        "Content-Type": "application/json",
+      },
+      body: JSON.stringify({ name }),
+    });
+
+    if (!response.ok) {
+      await throwApiError(response, "Failed to clone session");
+    }
+
+    return response.json() as Promise<CreateSessionResponse>;
+  },
+
+  // Types a cd to path at the session's prompt
+  async changeDirectory(sessionId: string, path: string): Promise<void> {
+    const response = await apiFetch(`/sessions/${sessionId}/cd`, {
+      method: "POST",
+      headers: {
+        "Content-Type": "application/json",
+      },
+      body: JSON.stringify({ path }),
+    });
+
+    if (!response.ok) {
+      await throwApiError(response, "Failed to change directory");
+    }
+  },
+
+  async getSnapshot(sessionId: string): Promise<ScreenSnapshot> {
+    const response = await apiFetch(`/sessions/${sessionId}/snapshot`);
+    if (!response.ok) {
+      await throwApiError(response, "Failed to render session");
+    }
+    return response.json() as Promise<ScreenSnapshot>;
+  },
+
   async deleteSession(sessionId: string): Promise<void> {
     const response = await apiFetch(`/sessions/${sessionId}`, {
       method: "DELETE",
@@ -18,6 +18,20 @@ import {
 } from "./mobileKeySequences";
 import { apiFetch } from "../../shared/http/client";
 import { dispatchSessionInvalidEvent } from "../auth/sessionEvents";
+import { useAuth } from "../auth/useAuth";
+import {
+  SSETerminalSocket,
+  prefersSSETransport,
+  rememberSSETransport,
+  sseUrlFromWebSocketUrl,
+  type TerminalSocket,
+} from "./sseSocket";
+import {
+  DEFAULT_TERMINAL_FONT_FAMILY,
+  DEFAULT_TERMINAL_FONT_SIZE,
+  terminalTheme,
+} from "./terminalThemes";
+import { loadUIConfig } from "../../shared/uiConfig";
 
 interface TerminalProps {
   wsUrl: string;
@@ -227,8 +241,9 @@ const TerminalComponent = forwardRef<TerminalHandle, TerminalProps>(
     const wrapperRef = useRef<HTMLDivElement>(null);
     const terminalRef = useRef<HTMLDivElement>(null);
     const terminalInstanceRef = useRef<Terminal | null>(null);
+    const { demo } = useAuth();
     const fitAddonRef = useRef<FitAddon | null>(null);
-    const wsRef = useRef<WebSocket | null>(null);
+    const wsRef = useRef<TerminalSocket | null>(null);
     const sendInputRef = useRef<(data: string) => void>(() => {});
     const pasteFromClipboardRef = useRef<() => Promise<void>>(async () => {});
     const getVisiblePlainTextSnapshotRef = useRef<() => string>(() => "");
@@ -268,6 +283,9 @@ const TerminalComponent = forwardRef<TerminalHandle, TerminalProps>(
     const errorCloseGraceTimeoutRef = useRef<number | null>(null);
     const lastHiddenAtRef = useRef<number | null>(null);
     const lastResumeReconnectAtRef = useRef<number>(0);
+    // Server-sent events replace WebSockets where proxies drop them
+    const useSSERef = useRef<boolean>(prefersSSETransport());
+    const failedWebSocketOpensRef = useRef<number>(0);
 
     // Constants
     const MAX_RECONNECT_ATTEMPTS = 10;
@@ -276,6 +294,7 @@ const TerminalComponent = forwardRef<TerminalHandle, TerminalProps>(
     const ERROR_CLOSE_GRACE_MS = 1500;
     const STALE_SOCKET_HIDDEN_MS = 30_000;
     const RESUME_RECONNECT_COOLDOWN_MS = 5000;
+    const WEBSOCKET_FAILURES_BEFORE_SSE = 2;
 
     const focus = useCallback(() => {
       terminalInstanceRef.current?.focus();
@@ -305,39 +324,14 @@ const TerminalComponent = forwardRef<TerminalHandle, TerminalProps>(
     useEffect(() => {
       if (!terminalRef.current) return;
 
-      // Theme configuration
-      const theme = {
-        background: "#000000",
-        foreground: "#ffffff",
-        cursor: "#ffffff",
-        selection: "rgba(255, 255, 255, 0.3)",
-        black: "#000000",
-        red: "#e06c75",
-        green: "#98c379",
-        yellow: "#d19a66",
-        blue: "#61afef",
-        magenta: "#c678dd",
-        cyan: "#56b6c2",
-        white: "#abb2bf",
-        brightBlack: "#5c6370",
-        brightRed: "#e06c75",
-        brightGreen: "#98c379",
-        brightYellow: "#d19a66",
-        brightBlue: "#61afef",
-        brightMagenta: "#c678dd",
-        brightCyan: "#56b6c2",
-        brightWhite: "#ffffff",
-      };
-
       // Initialize Terminal
       const terminal = new Terminal({
         cursorBlink: true,
         macOptionIsMeta: true,
         scrollback: 1000,
-        fontSize: 14,
-        fontFamily:
-          "JetBrains Mono, Menlo, Monaco, Consolas, 'Liberation Mono', 'Courier New', monospace",
-        theme: theme,
+        fontSize: DEFAULT_TERMINAL_FONT_SIZE,
+        fontFamily: DEFAULT_TERMINAL_FONT_FAMILY,
+        theme: terminalTheme(undefined),
         allowProposedApi: true,
       });
 
@@ -477,7 +471,7 @@ const TerminalComponent = forwardRef<TerminalHandle, TerminalProps>(
       }
 
       // Helper function to send resize events
-      const sendResize = (ws: WebSocket | null) => {
+      const sendResize = (ws: TerminalSocket | null) => {
         const dims = fitAddon.proposeDimensions();
         if (dims) {
           terminal.resize(dims.cols, dims.rows);
@@ -494,7 +488,7 @@ const TerminalComponent = forwardRef<TerminalHandle, TerminalProps>(
       };
 
       // Helper function to trigger TUI refresh by sending resize events
-      const triggerTUIRefresh = (ws: WebSocket) => {
+      const triggerTUIRefresh = (ws: TerminalSocket) => {
         const fitAddon = fitAddonRef.current;
         const terminal = terminalInstanceRef.current;
 
@@ -645,7 +639,7 @@ const TerminalComponent = forwardRef<TerminalHandle, TerminalProps>(
         }
       };
 
-      const closeSocketAfterErrorGrace = (socket: WebSocket) => {
+      const closeSocketAfterErrorGrace = (socket: TerminalSocket) => {
         if (isManuallyClosedRef.current || socket !== wsRef.current) {
           return;
         }
@@ -667,7 +661,7 @@ const TerminalComponent = forwardRef<TerminalHandle, TerminalProps>(
         }
       };
 
-      const scheduleErrorCloseFallback = (socket: WebSocket) => {
+      const scheduleErrorCloseFallback = (socket: TerminalSocket) => {
         clearErrorCloseGraceTimeout();
         errorCloseGraceTimeoutRef.current = window.setTimeout(() => {
           closeSocketAfterErrorGrace(socket);
@@ -690,7 +684,7 @@ const TerminalComponent = forwardRef<TerminalHandle, TerminalProps>(
         }
       };
 
-      const focusTerminalAfterReconnect = (socket: WebSocket) => {
+      const focusTerminalAfterReconnect = (socket: TerminalSocket) => {
         window.setTimeout(() => {
           if (isManuallyClosedRef.current || socket !== wsRef.current) {
             return;
@@ -713,9 +707,27 @@ const TerminalComponent = forwardRef<TerminalHandle, TerminalProps>(
         scheduleReconnect();
       };
 
+      // Structured messages arrive as text frames. Only the message of the
+      // day is shown, once per terminal rather than on every reconnect.
+      let motdShown = false;
+      const handleServerMessage = (data: string) => {
+        let message: { type?: string; data?: string };
+        try {
+          message = JSON.parse(data) as { type?: string; data?: string };
+        } catch {
+          return;
+        }
+        if (message.type !== "motd" || !message.data || motdShown) {
+          return;
+        }
+        motdShown = true;
+        const lines = message.data.trimEnd().split("\n").join("\r\n");
+        terminal.write(`\r\n\x1b[33m${lines}\x1b[0m\r\n`);
+      };
+
       const handleSocketClose = async (
         terminalInstance: Terminal,
-        closingWs: WebSocket,
+        closingWs: TerminalSocket,
         didOpen: boolean,
       ): Promise<void> => {
         // Don't reconnect if manually closed (e.g., component unmount)
@@ -784,6 +796,22 @@ const TerminalComponent = forwardRef<TerminalHandle, TerminalProps>(
         }, delay);
       };
 
+      // Switch to server-sent events once WebSockets fail to open in a row,
+      // and back if the event stream fails to open too
+      const noteFailedOpen = () => {
+        if (useSSERef.current) {
+          useSSERef.current = false;
+          rememberSSETransport(false);
+          failedWebSocketOpensRef.current = 0;
+          return;
+        }
+        failedWebSocketOpensRef.current++;
+        if (failedWebSocketOpensRef.current >= WEBSOCKET_FAILURES_BEFORE_SSE) {
+          console.info("WebSocket failed to open; falling back to SSE");
+          useSSERef.current = true;
+        }
+      };
+
       // Main WebSocket connection function
       const connectWebSocket = () => {
         const activeSocket = wsRef.current;
@@ -796,7 +824,9 @@ const TerminalComponent = forwardRef<TerminalHandle, TerminalProps>(
         }
 
         let didOpen = false;
-        const ws = new WebSocket(wsUrl);
+        const ws: TerminalSocket = useSSERef.current
+          ? new SSETerminalSocket(sseUrlFromWebSocketUrl(wsUrl))
+          : new WebSocket(wsUrl);
         ws.binaryType = "arraybuffer";
         wsRef.current = ws;
 
@@ -807,6 +837,11 @@ const TerminalComponent = forwardRef<TerminalHandle, TerminalProps>(
           }
 
           didOpen = true;
+          if (useSSERef.current) {
+            rememberSSETransport(true);
+          } else {
+            failedWebSocketOpensRef.current = 0;
+          }
           clearErrorCloseGraceTimeout();
           const wasReconnecting = isReconnectingRef.current;
           isReconnectingRef.current = false;
@@ -832,6 +867,11 @@ const TerminalComponent = forwardRef<TerminalHandle, TerminalProps>(
             return;
           }
 
+          if (typeof event.data === "string") {
+            handleServerMessage(event.data);
+            return;
+          }
+
           // Convert data to Uint8Array
           const dataToWrite = new Uint8Array(event.data);
 
@@ -841,6 +881,9 @@ const TerminalComponent = forwardRef<TerminalHandle, TerminalProps>(
 
         ws.onclose = () => {
           clearErrorCloseGraceTimeout();
+          if (!didOpen && ws === wsRef.current) {
+            noteFailedOpen();
+          }
           void handleSocketClose(terminal, ws, didOpen);
         };
 
@@ -1021,10 +1064,30 @@ const TerminalComponent = forwardRef<TerminalHandle, TerminalProps>(
         resizeObserver.observe(wrapperRef.current);
       }
 
+      // Apply the theme and font from the server's UI configuration
+      let disposed = false;
+      void loadUIConfig().then((config) => {
+        if (disposed) return;
+        terminal.options.theme = terminalTheme(config.terminal_theme);
+        if (config.font_family) {
+          terminal.options.fontFamily = config.font_family;
+        }
+        if (config.font_size) {
+          terminal.options.fontSize = config.font_size;
+        }
+        try {
+          fitAddon.fit();
+          sendResize(wsRef.current);
+        } catch (error) {
+          console.warn("Fit after applying the UI configuration failed", error);
+        }
+      });
+
       // Cleanup
       return () => {
         // Prevent reconnection attempts after unmount
         isManuallyClosedRef.current = true;
+        disposed = true;
 
         // Remove touch event listeners
         if (terminalDomNode != null) {
@@ -1064,6 +1127,13 @@ const TerminalComponent = forwardRef<TerminalHandle, TerminalProps>(
       };
     }, [wsUrl]);
 
+    // Demo visitors watch without typing; the server drops their input too
+    useEffect(() => {
+      if (terminalInstanceRef.current) {
+        terminalInstanceRef.current.options.disableStdin = demo;
+      }
+    }, [demo, wsUrl]);
+
     return (
       <div
         ref={wrapperRef}
@@ -9,6 +9,7 @@ import {
 } from "./mobileKeySequences";
 import CopyTextModal from "./CopyTextModal";
 import TerminalComponent, { type TerminalHandle } from "./Terminal";
+import { websocketUrl } from "../../shared/http/basePath";
 
 export default function TerminalPage() {
   const { sessionId } = useParams<{ sessionId: string }>();
@@ -35,8 +36,7 @@ export default function TerminalPage() {
   }, [navigate, trimmedSessionId]);
 
   // Determine WebSocket URL based on current protocol
-  const protocol = window.location.protocol === "https:" ? "wss://" : "ws://";
-  const wsUrl = `${protocol}${window.location.host}/ws/${trimmedSessionId}`;
+  const wsUrl = websocketUrl(`/ws/${trimmedSessionId}`);
 
   const focusTerminal = useCallback(() => {
     terminalRef.current?.focus();
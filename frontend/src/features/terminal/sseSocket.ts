@@ -0,0 +1,159 @@
+// The subset of WebSocket the terminal uses, so it can run over either
+// transport.
+export interface TerminalSocket {
+  readonly readyState: number;
+  binaryType: BinaryType;
+  send(data: string): void;
+  close(code?: number, reason?: string): void;
+  onopen: ((event: Event) => void) | null;
+  onmessage: ((event: MessageEvent) => void) | null;
+  onclose: ((event: CloseEvent) => void) | null;
+  onerror: ((event: Event) => void) | null;
+}
+
+// Transport to use first, remembered once the WebSocket fallback was needed.
+const TRANSPORT_STORAGE_KEY = "terminal-hub:terminal-transport";
+
+export function prefersSSETransport(): boolean {
+  try {
+    return sessionStorage.getItem(TRANSPORT_STORAGE_KEY) === "sse";
+  } catch {
+    return false;
+  }
+}
+
+export function rememberSSETransport(useSSE: boolean): void {
+  try {
+    if (useSSE) {
+      sessionStorage.setItem(TRANSPORT_STORAGE_KEY, "sse");
+    } else {
+      sessionStorage.removeItem(TRANSPORT_STORAGE_KEY);
+    }
+  } catch {
+    // Storage may be unavailable in private windows
+  }
+}
+
+// Turns ws(s)://host/base/ws/:id into http(s)://host/base/sse/:id
+export function sseUrlFromWebSocketUrl(wsUrl: string): string {
+  const url = new URL(wsUrl);
+  url.protocol = url.protocol === "wss:" ? "https:" : "http:";
+  url.pathname = url.pathname.replace(/\/ws\/([^/]+)\/?$/, "/sse/$1");
+  return url.toString();
+}
+
+function decodeBase64(data: string): ArrayBuffer {
+  const binary = atob(data);
+  const bytes = new Uint8Array(binary.length);
+  for (let i = 0; i < binary.length; i++) {
+    bytes[i] = binary.charCodeAt(i);
+  }
+  return bytes.buffer;
+}
+
+// SSETerminalSocket attaches to a session over server-sent events for
+// networks whose proxies drop WebSockets. Output arrives on the event stream
+// and input is posted, batched while a post is in flight to keep its order.
+export class SSETerminalSocket implements TerminalSocket {
+  readyState: number = WebSocket.CONNECTING;
+  binaryType: BinaryType = "arraybuffer";
+  onopen: ((event: Event) => void) | null = null;
+  onmessage: ((event: MessageEvent) => void) | null = null;
+  onclose: ((event: CloseEvent) => void) | null = null;
+  onerror: ((event: Event) => void) | null = null;
+
+  private readonly source: EventSource;
+  private readonly streamUrl: string;
+  private clientId: string | null = null;
+  private pending: unknown[] = [];
+  private posting = false;
+
+  constructor(url: string) {
+    this.streamUrl = url;
+    this.source = new EventSource(url, { withCredentials: true });
+
+    this.source.addEventListener("attached", (event) => {
+      const { client_id: clientId } = JSON.parse(
+        (event as MessageEvent<string>).data,
+      ) as { client_id: string };
+      this.clientId = clientId;
+      this.readyState = WebSocket.OPEN;
+      this.onopen?.(new Event("open"));
+    });
+    this.source.addEventListener("output", (event) => {
+      this.onmessage?.(
+        new MessageEvent("message", {
+          data: decodeBase64((event as MessageEvent<string>).data),
+        }),
+      );
+    });
+    this.source.addEventListener("message", (event) => {
+      // Structured messages, delivered as text like WebSocket text frames
+      this.onmessage?.(
+        new MessageEvent("message", {
+          data: (event as MessageEvent<string>).data,
+        }),
+      );
+    });
+    this.source.onerror = (event) => {
+      // EventSource would retry on its own; report a close instead so the
+      // terminal's reconnect logic stays in charge
+      this.onerror?.(event);
+      this.finish(1006, "event stream failed");
+    };
+  }
+
+  send(data: string): void {
+    if (this.readyState !== WebSocket.OPEN) {
+      return;
+    }
+    this.pending.push(JSON.parse(data));
+    void this.flush();
+  }
+
+  close(code = 1000, reason = ""): void {
+    this.finish(code, reason);
+  }
+
+  private async flush(): Promise<void> {
+    if (this.posting || this.pending.length === 0 || this.clientId == null) {
+      return;
+    }
+    const batch = this.pending;
+    this.pending = [];
+    this.posting = true;
+    try {
+      const url = new URL(this.streamUrl);
+      url.pathname = `${url.pathname.replace(/\/$/, "")}/input`;
+      url.searchParams.set("client", this.clientId);
+      const response = await fetch(url.toString(), {
+        method: "POST",
+        credentials: "same-origin",
+        headers: { "Content-Type": "application/json" },
+        body: JSON.stringify(batch),
+      });
+      if (!response.ok) {
+        this.finish(1011, `input rejected with ${response.status}`);
+        return;
+      }
+    } catch {
+      this.finish(1006, "input failed");
+      return;
+    } finally {
+      this.posting = false;
+    }
+    void this.flush();
+  }
+
+  private finish(code: number, reason: string): void {
+    if (this.readyState === WebSocket.CLOSED) {
+      return;
+    }
+    this.readyState = WebSocket.CLOSED;
+    this.source.close();
+    this.pending = [];
+    setTimeout(() => {
+      this.onclose?.(new CloseEvent("close", { code, reason }));
+    }, 0);
+  }
+}
@@ -0,0 +1,102 @@
+import type { ITheme } from "@xterm/xterm";
+
+export const DEFAULT_TERMINAL_FONT_FAMILY =
+  "JetBrains Mono, Menlo, Monaco, Consolas, 'Liberation Mono', 'Courier New', monospace";
+export const DEFAULT_TERMINAL_FONT_SIZE = 14;
+
+// Themes the server's UI configuration can pick by name. Keep the names in
+// sync with uiTerminalThemes in internal/server/ui_config.go.
+const TERMINAL_THEMES: Record<string, ITheme> = {
+  default: {
+    background: "#000000",
+    foreground: "#ffffff",
+    cursor: "#ffffff",
+    selectionBackground: "rgba(255, 255, 255, 0.3)",
+    black: "#000000",
+    red: "#e06c75",
+    green: "#98c379",
+    yellow: "#d19a66",
+    blue: "#61afef",
+    magenta: "#c678dd",
+    cyan: "#56b6c2",
+    white: "#abb2bf",
+    brightBlack: "#5c6370",
+    brightRed: "#e06c75",
+    brightGreen: "#98c379",
+    brightYellow: "#d19a66",
+    brightBlue: "#61afef",
+    brightMagenta: "#c678dd",
+    brightCyan: "#56b6c2",
+    brightWhite: "#ffffff",
+  },
+  light: {
+    background: "#fafafa",
+    foreground: "#383a42",
+    cursor: "#526fff",
+    selectionBackground: "rgba(56, 58, 66, 0.2)",
+    black: "#383a42",
+    red: "#e45649",
+    green: "#50a14f",
+    yellow: "#c18401",
+    blue: "#4078f2",
+    magenta: "#a626a4",
+    cyan: "#0184bc",
+    white: "#a0a1a7",
+    brightBlack: "#696c77",
+    brightRed: "#e45649",
+    brightGreen: "#50a14f",
+    brightYellow: "#c18401",
+    brightBlue: "#4078f2",
+    brightMagenta: "#a626a4",
+    brightCyan: "#0184bc",
+    brightWhite: "#fafafa",
+  },
+  "solarized-dark": {
+    background: "#002b36",
+    foreground: "#839496",
+    cursor: "#93a1a1",
+    selectionBackground: "rgba(147, 161, 161, 0.3)",
+    black: "#073642",
+    red: "#dc322f",
+    green: "#859900",
+    yellow: "#b58900",
+    blue: "#268bd2",
+    magenta: "#d33682",
+    cyan: "#2aa198",
+    white: "#eee8d5",
+    brightBlack: "#586e75",
+    brightRed: "#cb4b16",
+    brightGreen: "#586e75",
+    brightYellow: "#657b83",
+    brightBlue: "#839496",
+    brightMagenta: "#6c71c4",
+    brightCyan: "#93a1a1",
+    brightWhite: "#fdf6e3",
+  },
+  dracula: {
+    background: "#282a36",
+    foreground: "#f8f8f2",
+    cursor: "#f8f8f2",
+    selectionBackground: "rgba(68, 71, 90, 0.8)",
+    black: "#21222c",
+    red: "#ff5555",
+    green: "#50fa7b",
+    yellow: "#f1fa8c",
+    blue: "#bd93f9",
+    magenta: "#ff79c6",
+    cyan: "#8be9fd",
+    white: "#f8f8f2",
+    brightBlack: "#6272a4",
+    brightRed: "#ff6e6e",
+    brightGreen: "#69ff94",
+    brightYellow: "#ffffa5",
+    brightBlue: "#d6acff",
+    brightMagenta: "#ff92df",
+    brightCyan: "#a4ffff",
+    brightWhite: "#ffffff",
+  },
+};
+
+export function terminalTheme(name: string | undefined): ITheme {
+  return TERMINAL_THEMES[name ?? "default"] ?? TERMINAL_THEMES.default;
+}
@@ -1,6 +1,6 @@
 import { useCallback, useState, type ChangeEvent } from "react";
 import toast from "react-hot-toast";
-import { apiFetch } from "../../shared/http/client";
+import { apiFetch, readApiErrorMessage } from "../../shared/http/client";
 
 type UploadResponse = {
   size?: number;
@@ -103,7 +103,7 @@ async function runUploadRequest(
     }
 
     if (!response.ok) {
-      const errorText = await response.text();
+      const errorText = await readApiErrorMessage(response);
       return { type: "error", message: `Upload failed: ${errorText}` };
     }
 
@@ -235,7 +235,7 @@ export function useFileTransfer(
           method: "GET",
         });
         if (!response.ok) {
-          const errorText = await response.text();
+          const errorText = await readApiErrorMessage(response);
           const message = `Download failed: ${errorText}`;
           setTransferStatus(message);
           toast.error(message);
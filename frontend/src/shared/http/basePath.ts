@@ -0,0 +1,12 @@
+// Sub-path the server is mounted under, such as "/hub", or "" at the root.
+// The server announces it in index.html when TERMINAL_HUB_BASE_PATH is set.
+export const BASE_PATH = (
+  document.querySelector<HTMLMetaElement>(
+    'meta[name="terminal-hub-base-path"]',
+  )?.content ?? ""
+).replace(/\/+$/, "");
+
+export function websocketUrl(path: string): string {
+  const protocol = window.location.protocol === "https:" ? "wss://" : "ws://";
+  return `${protocol}${window.location.host}${BASE_PATH}${path}`;
+}
@@ -1,6 +1,10 @@
-import { dispatchSessionInvalidEvent } from "../../features/auth/sessionEvents";
+import {
+  dispatchPasswordChangeRequiredEvent,
+  dispatchSessionInvalidEvent,
+} from "../../features/auth/sessionEvents";
+import { BASE_PATH } from "./basePath";
 
-const API_BASE_URL = "/api";
+const API_BASE_URL = `${BASE_PATH}/api`;
 
 interface ApiFetchOptions {
   skipAuthRedirect?: boolean;
@@ -19,15 +23,48 @@ export async function apiFetch(
   if (response.status === 401 && options?.skipAuthRedirect !== true) {
     dispatchSessionInvalidEvent("http-401");
   }
+  if (response.status === 403) {
+    void notifyPasswordChangeRequired(response.clone());
+  }
 
   return response;
 }
 
+interface ApiErrorBody {
+  error?: { code?: string; message?: string };
+}
+
+// Tells the auth provider when a 403 means the password has to be changed
+async function notifyPasswordChangeRequired(response: Response) {
+  try {
+    const body = (await response.json()) as ApiErrorBody;
+    if (body.error?.code === "password_change_required") {
+      dispatchPasswordChangeRequiredEvent();
+    }
+  } catch {
+    // Not the JSON error envelope
+  }
+}
+
+// Reads the message of an error response: the JSON error envelope's message,
+// or the plain-text body of routes outside the API
+export async function readApiErrorMessage(response: Response): Promise<string> {
+  const body = await response.text();
+  try {
+    const parsed = JSON.parse(body) as ApiErrorBody;
+    if (parsed.error?.message) {
+      return parsed.error.message;
+    }
+  } catch {
+    // Not JSON
+  }
+  return body.trim() || response.statusText;
+}
+
 export async function throwApiError(
   response: Response,
   prefix: string,
 ): Promise<never> {
-  const body = await response.text();
-  const detail = body || response.statusText;
+  const detail = await readApiErrorMessage(response);
   throw new Error(`${prefix}: ${detail}`);
 }
@@ -0,0 +1,49 @@
+import { useEffect, useState } from "react";
+import { apiFetch } from "./http/client";
+
+// Branding set by the server in its UI configuration file. Missing fields
+// keep the built-in defaults.
+export interface UIConfig {
+  title?: string;
+  logo_url?: string;
+  terminal_theme?: string;
+  font_family?: string;
+  font_size?: number;
+  banner?: string;
+  motd?: string;
+}
+
+export const DEFAULT_TITLE = "Terminal Hub";
+
+let uiConfigPromise: Promise<UIConfig> | null = null;
+
+// Fetches the UI configuration once per page load. Failures fall back to the
+// defaults rather than blocking the app.
+export function loadUIConfig(): Promise<UIConfig> {
+  uiConfigPromise ??= apiFetch("/config/ui", undefined, {
+    skipAuthRedirect: true,
+  })
+    .then((response) =>
+      response.ok ? (response.json() as Promise<UIConfig>) : {},
+    )
+    .catch(() => ({}));
+  return uiConfigPromise;
+}
+
+export function useUIConfig(): UIConfig {
+  const [config, setConfig] = useState<UIConfig>({});
+
+  useEffect(() => {
+    let active = true;
+    void loadUIConfig().then((loaded) => {
+      if (active) {
+        setConfig(loaded);
+      }
+    });
+    return () => {
+      active = false;
+    };
+  }, []);
+
+  return config;
+}
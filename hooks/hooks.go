@@ -0,0 +1,263 @@
+// Package hooks defines inbound hooks: secret URLs that external services
+// such as CI pipelines call to run a pre-configured command, either as a run
+// of a cron job or typed into a terminal session. Values from the request
+// are passed to the command as environment variables through templates.
+package hooks
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// DefaultRateLimit is how many times per minute a hook may be triggered when
+// it does not set its own limit
+const DefaultRateLimit = 10
+
+// Hook actions
+const (
+	ActionCron    = "cron"    // run the cron job CronJobID once
+	ActionSession = "session" // type Command into the session Session
+)
+
+// Hook is a pre-configured command run when its URL is called
+type Hook struct {
+	ID          string            `json:"id"`
+	Name        string            `json:"name"`
+	Token       string            `json:"token,omitempty"`       // only returned when the hook is created or its token rotated
+	TokenHash   string            `json:"token_hash,omitempty"`  // SHA-256 of the token, never returned
+	CronJobID   string            `json:"cron_job_id,omitempty"` // run this cron job once, or
+	Session     string            `json:"session,omitempty"`     // type Command into this session, by name or ID
+	Command     string            `json:"command,omitempty"`     // the command typed into Session
+	Env         map[string]string `json:"env,omitempty"`         // variable name -> template such as "{{body.ref}}"
+	RateLimit   int               `json:"rate_limit,omitempty"`  // triggers per minute; 0 means DefaultRateLimit
+	Description string            `json:"description,omitempty"` // Optional
+	Disabled    bool              `json:"disabled,omitempty"`    // disabled hooks refuse to run
+	CreatedAt   int64             `json:"created_at"`            // unix timestamp
+}
+
+// envNamePattern matches portable environment variable names
+var envNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// placeholderPattern matches {{source}} and {{source.path}} placeholders
+var placeholderPattern = regexp.MustCompile(`\{\{\s*([a-z]+)(?:\.([^}\s]+))?\s*\}\}`)
+
+// Action returns ActionCron or ActionSession
+func (h Hook) Action() string {
+	if h.CronJobID != "" {
+		return ActionCron
+	}
+	return ActionSession
+}
+
+// Validate checks that a hook is complete and well-formed
+func (h Hook) Validate() error {
+	if strings.TrimSpace(h.Name) == "" {
+		return errors.New("name is required")
+	}
+	switch {
+	case h.CronJobID != "" && h.Session != "":
+		return errors.New("set either cron_job_id or session, not both")
+	case h.CronJobID != "" && h.Command != "":
+		return errors.New("command is only used with session; cron hooks run the job's command")
+	case h.CronJobID == "" && h.Session == "":
+		return errors.New("cron_job_id or session is required")
+	case h.Session != "" && strings.TrimSpace(h.Command) == "":
+		return errors.New("command is required for session hooks")
+	}
+	if h.RateLimit < 0 {
+		return errors.New("rate_limit must not be negative")
+	}
+	for name, tmpl := range h.Env {
+		if !envNamePattern.MatchString(name) {
+			return fmt.Errorf("invalid environment variable name %q", name)
+		}
+		if err := validateTemplate(tmpl); err != nil {
+			return fmt.Errorf("env %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// Limit returns the hook's triggers per minute
+func (h Hook) Limit() int {
+	if h.RateLimit > 0 {
+		return h.RateLimit
+	}
+	return DefaultRateLimit
+}
+
+// Redacted returns the hook without its token or token hash
+func (h Hook) Redacted() Hook {
+	h.Token = ""
+	h.TokenHash = ""
+	return h
+}
+
+// NewToken returns a random token and the hash to store for it
+func NewToken() (token, hash string, err error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", "", err
+	}
+	token = hex.EncodeToString(buf)
+	return token, HashToken(token), nil
+}
+
+// HashToken returns the stored form of a token
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// Request is what a template can read from a call of the hook
+type Request struct {
+	Body   []byte
+	Header http.Header
+	Query  url.Values
+}
+
+// validateTemplate checks that every placeholder names a known source:
+// {{body}}, {{body.path}}, {{header.Name}} or {{query.name}}
+func validateTemplate(tmpl string) error {
+	for _, match := range placeholderPattern.FindAllStringSubmatch(tmpl, -1) {
+		source, path := match[1], match[2]
+		switch {
+		case source == "body":
+		case (source == "header" || source == "query") && path != "":
+		default:
+			return fmt.Errorf("unknown placeholder %q: use {{body}}, {{body.path}}, {{header.Name}} or {{query.name}}", match[0])
+		}
+	}
+	return nil
+}
+
+// RenderEnv fills the hook's environment templates from req. Paths missing
+// from the request render as empty strings; body paths require a JSON body.
+func (h Hook) RenderEnv(req Request) (map[string]string, error) {
+	if len(h.Env) == 0 {
+		return nil, nil
+	}
+
+	var body any
+	bodyParsed := false
+	env := make(map[string]string, len(h.Env))
+	for name, tmpl := range h.Env {
+		var renderErr error
+		env[name] = placeholderPattern.ReplaceAllStringFunc(tmpl, func(placeholder string) string {
+			match := placeholderPattern.FindStringSubmatch(placeholder)
+			source, path := match[1], match[2]
+			switch source {
+			case "header":
+				return req.Header.Get(path)
+			case "query":
+				return req.Query.Get(path)
+			}
+			if path == "" {
+				return string(req.Body)
+			}
+			if !bodyParsed {
+				decoder := json.NewDecoder(bytes.NewReader(req.Body))
+				decoder.UseNumber()
+				if err := decoder.Decode(&body); err != nil {
+					renderErr = errors.New("the request body is not JSON")
+					return ""
+				}
+				bodyParsed = true
+			}
+			return formatValue(lookupPath(body, path))
+		})
+		if renderErr != nil {
+			return nil, renderErr
+		}
+	}
+	return env, nil
+}
+
+// lookupPath follows a dotted path of object keys and array indexes
+func lookupPath(value any, path string) any {
+	for _, key := range strings.Split(path, ".") {
+		switch v := value.(type) {
+		case map[string]any:
+			value = v[key]
+		case []any:
+			i, err := strconv.Atoi(key)
+			if err != nil || i < 0 || i >= len(v) {
+				return nil
+			}
+			value = v[i]
+		default:
+			return nil
+		}
+	}
+	return value
+}
+
+// formatValue renders a JSON value for an environment variable: strings and
+// numbers as they are, objects and arrays as compact JSON
+func formatValue(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	case bool:
+		return strconv.FormatBool(v)
+	default:
+		data, err := json.Marshal(v)
+		if err != nil {
+			return ""
+		}
+		return string(data)
+	}
+}
+
+// SessionInput returns the line typed into the session: the command, run in
+// a subshell that exports env when there is any. Control characters are
+// removed from the values, as the terminal would interpret them.
+func (h Hook) SessionInput(env map[string]string) string {
+	if len(env) == 0 {
+		return h.Command + "\r"
+	}
+
+	names := make([]string, 0, len(env))
+	for name := range env {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	var b strings.Builder
+	b.WriteString("(export")
+	for _, name := range names {
+		b.WriteString(" " + name + "=" + shellQuote(stripControl(env[name])))
+	}
+	b.WriteString("; " + h.Command + ")\r")
+	return b.String()
+}
+
+// shellQuote single-quotes s for a POSIX shell
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// stripControl replaces control characters with spaces
+func stripControl(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r < 0x20 || r == 0x7f || (r >= 0x80 && r < 0xa0) {
+			return ' '
+		}
+		return r
+	}, s)
+}
@@ -0,0 +1,120 @@
+package hooks
+
+import (
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"testing"
+)
+
+func TestHookValidate(t *testing.T) {
+	cases := []struct {
+		hook  Hook
+		valid bool
+	}{
+		{Hook{Name: "deploy", CronJobID: "job"}, true},
+		{Hook{Name: "deploy", Session: "build", Command: "make", Env: map[string]string{"REF": "{{body.ref}}", "EVENT": "{{header.X-GitHub-Event}}"}}, true},
+		{Hook{CronJobID: "job"}, false},
+		{Hook{Name: "deploy"}, false},
+		{Hook{Name: "deploy", CronJobID: "job", Session: "build"}, false},
+		{Hook{Name: "deploy", CronJobID: "job", Command: "make"}, false},
+		{Hook{Name: "deploy", Session: "build"}, false},
+		{Hook{Name: "deploy", CronJobID: "job", RateLimit: -1}, false},
+		{Hook{Name: "deploy", CronJobID: "job", Env: map[string]string{"1REF": "x"}}, false},
+		{Hook{Name: "deploy", CronJobID: "job", Env: map[string]string{"REF": "{{cookie.x}}"}}, false},
+		{Hook{Name: "deploy", CronJobID: "job", Env: map[string]string{"REF": "{{header}}"}}, false},
+	}
+	for _, c := range cases {
+		if err := c.hook.Validate(); (err == nil) != c.valid {
+			t.Errorf("Validate(%+v) = %v, want valid=%v", c.hook, err, c.valid)
+		}
+	}
+}
+
+func TestRenderEnv(t *testing.T) {
+	hook := Hook{Env: map[string]string{
+		"REF":     "{{body.ref}}",
+		"SHA":     "commit {{ body.commits.0.id }}",
+		"COUNT":   "{{body.count}}",
+		"FORCED":  "{{body.forced}}",
+		"PUSHER":  "{{body.pusher}}",
+		"MISSING": "[{{body.commits.5.id}}]",
+		"EVENT":   "{{header.X-GitHub-Event}}",
+		"BRANCH":  "{{query.branch}}",
+	}}
+	env, err := hook.RenderEnv(Request{
+		Body:   []byte(`{"ref":"refs/heads/main","commits":[{"id":"abc123"}],"count":12345678901234,"forced":false,"pusher":{"name":"ci"}}`),
+		Header: http.Header{"X-Github-Event": {"push"}},
+		Query:  url.Values{"branch": {"main"}},
+	})
+	if err != nil {
+		t.Fatalf("RenderEnv failed: %v", err)
+	}
+	want := map[string]string{
+		"REF":     "refs/heads/main",
+		"SHA":     "commit abc123",
+		"COUNT":   "12345678901234",
+		"FORCED":  "false",
+		"PUSHER":  `{"name":"ci"}`,
+		"MISSING": "[]",
+		"EVENT":   "push",
+		"BRANCH":  "main",
+	}
+	for name, value := range want {
+		if env[name] != value {
+			t.Errorf("%s = %q, want %q", name, env[name], value)
+		}
+	}
+
+	// The raw body is always available; its fields only for JSON
+	raw := Hook{Env: map[string]string{"BODY": "{{body}}"}}
+	if env, err := raw.RenderEnv(Request{Body: []byte("plain text")}); err != nil || env["BODY"] != "plain text" {
+		t.Errorf("raw body: got %v, %v", env, err)
+	}
+	if _, err := hook.RenderEnv(Request{Body: []byte("plain text")}); err == nil {
+		t.Error("expected body paths to require JSON")
+	}
+}
+
+func TestSessionInput(t *testing.T) {
+	hook := Hook{Command: "./deploy.sh"}
+	if got := hook.SessionInput(nil); got != "./deploy.sh\r" {
+		t.Errorf("without env: got %q", got)
+	}
+	got := hook.SessionInput(map[string]string{"REF": "it's\x03main", "A": "1"})
+	if want := `(export A='1' REF='it'\''s main'; ./deploy.sh)` + "\r"; got != want {
+		t.Errorf("with env: got %q, want %q", got, want)
+	}
+}
+
+func TestStoreFindsHooksByToken(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "hooks.json")
+	store, err := OpenStore(path)
+	if err != nil {
+		t.Fatalf("failed to open store: %v", err)
+	}
+	token, hash, err := NewToken()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Add(Hook{ID: "a", Name: "deploy", CronJobID: "job", TokenHash: hash}); err != nil {
+		t.Fatalf("add failed: %v", err)
+	}
+
+	reopened, err := OpenStore(path)
+	if err != nil {
+		t.Fatalf("failed to reopen store: %v", err)
+	}
+	if hook, err := reopened.FindByToken(token); err != nil || hook.ID != "a" {
+		t.Fatalf("expected to find the hook by its token, got %+v, %v", hook, err)
+	}
+	if _, err := reopened.FindByToken(hash); err != ErrHookNotFound {
+		t.Errorf("expected the hash not to work as a token, got %v", err)
+	}
+	if err := reopened.Delete("a"); err != nil {
+		t.Fatalf("delete failed: %v", err)
+	}
+	if _, err := reopened.FindByToken(token); err != ErrHookNotFound {
+		t.Errorf("expected ErrHookNotFound, got %v", err)
+	}
+}
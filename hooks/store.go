@@ -0,0 +1,148 @@
+package hooks
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// ErrHookNotFound is returned for unknown hook IDs and tokens
+var ErrHookNotFound = errors.New("hook not found")
+
+// Store persists inbound hooks in a JSON file
+type Store struct {
+	path  string
+	mu    sync.Mutex
+	hooks []Hook // in creation order
+}
+
+// OpenStore loads the store at path, creating it on first write
+func OpenStore(path string) (*Store, error) {
+	store := &Store{path: path}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return store, nil
+		}
+		return nil, fmt.Errorf("failed to read hooks: %w", err)
+	}
+	if err := json.Unmarshal(data, &store.hooks); err != nil {
+		return nil, fmt.Errorf("failed to parse hooks: %w", err)
+	}
+	return store, nil
+}
+
+// List returns all hooks in creation order
+func (s *Store) List() []Hook {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Hook{}, s.hooks...)
+}
+
+// Get returns a hook by ID
+func (s *Store) Get(id string) (Hook, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	i := s.indexLocked(id)
+	if i < 0 {
+		return Hook{}, ErrHookNotFound
+	}
+	return s.hooks[i], nil
+}
+
+// FindByToken returns the hook whose token is token
+func (s *Store) FindByToken(token string) (Hook, error) {
+	hash := HashToken(token)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, hook := range s.hooks {
+		if hook.TokenHash == hash {
+			return hook, nil
+		}
+	}
+	return Hook{}, ErrHookNotFound
+}
+
+// Add stores a hook, which must have a new ID
+func (s *Store) Add(hook Hook) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.indexLocked(hook.ID) >= 0 {
+		return fmt.Errorf("hook %q already exists", hook.ID)
+	}
+	s.hooks = append(s.hooks, hook)
+	return s.saveLocked()
+}
+
+// Update replaces a stored hook with the same ID
+func (s *Store) Update(hook Hook) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	i := s.indexLocked(hook.ID)
+	if i < 0 {
+		return ErrHookNotFound
+	}
+	previous := s.hooks[i]
+	s.hooks[i] = hook
+	if err := s.saveLocked(); err != nil {
+		s.hooks[i] = previous
+		return err
+	}
+	return nil
+}
+
+// Delete removes a hook
+func (s *Store) Delete(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	i := s.indexLocked(id)
+	if i < 0 {
+		return ErrHookNotFound
+	}
+	s.hooks = append(s.hooks[:i], s.hooks[i+1:]...)
+	return s.saveLocked()
+}
+
+// indexLocked returns the position of a hook, or -1. Must be called with
+// s.mu held.
+func (s *Store) indexLocked(id string) int {
+	for i, hook := range s.hooks {
+		if hook.ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// saveLocked writes the store atomically. Must be called with s.mu held.
+func (s *Store) saveLocked() error {
+	data, err := json.MarshalIndent(s.hooks, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0700); err != nil {
+		return fmt.Errorf("failed to create hooks directory: %w", err)
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("failed to write hooks: %w", err)
+	}
+	return os.Rename(tmp, s.path)
+}
+
+// GetStorePathFromEnv returns TERMINAL_HUB_HOOKS, defaulting to
+// ~/.terminal-hub/hooks.json
+func GetStorePathFromEnv() string {
+	if path := os.Getenv("TERMINAL_HUB_HOOKS"); path != "" {
+		return path
+	}
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "hooks.json"
+	}
+	return filepath.Join(homeDir, ".terminal-hub", "hooks.json")
+}
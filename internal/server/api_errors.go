@@ -0,0 +1,68 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// Error codes of API error responses. They are stable, so clients can match
+// on them instead of the message.
+const (
+	errCodeInvalidRequest     = "invalid_request"        // a parameter or field is missing or invalid
+	errCodeInvalidJSON        = "invalid_json"           // the body is not the expected JSON
+	errCodeMethodNotAllowed   = "method_not_allowed"     // the endpoint does not support the method
+	errCodeUnauthorized       = "unauthorized"           // no valid login
+	errCodeForbidden          = "forbidden"              // the caller may not do this
+	errCodeNotFound           = "not_found"              // no such resource
+	errCodeConflict           = "conflict"               // the resource's state does not allow this
+	errCodePayloadTooLarge    = "payload_too_large"      // the body or file exceeds a limit
+	errCodeUnsupportedMedia   = "unsupported_media_type" // the body is not of a type the endpoint takes
+	errCodeRateLimited        = "rate_limited"           // too many requests or failed logins
+	errCodeLimitReached       = "limit_reached"          // a session or client limit is reached
+	errCodeFeatureUnavailable = "feature_unavailable"    // the feature is disabled or failed to start
+	errCodeUpstreamFailed     = "upstream_failed"        // a session's port, tunnel target, ssh host or virus scanner could not be reached
+	errCodeLowDiskSpace       = "low_disk_space"         // writes are paused until space is freed
+	errCodeInternal           = "internal_error"         // the server failed; see its log
+
+	errCodePasswordChangeRequired = "password_change_required" // the user must change their password first, see GET /api/auth/status
+	errCodeDemoReadOnly           = "demo_read_only"           // demo visitors without a login may only watch demo sessions
+
+	errCodeSessionNotFound      = "session_not_found"
+	errCodeUnsupportedSession   = "unsupported_session" // the session's backend does not support this
+	errCodeTmuxSessionNotFound  = "tmux_session_not_found"
+	errCodeClientNotFound       = "client_not_found"
+	errCodeFileNotFound         = "file_not_found"
+	errCodeFileExists           = "file_exists"
+	errCodeDownloadLinkGone     = "download_link_gone" // the link expired or was already used
+	errCodeMalwareDetected      = "malware_detected"   // the virus scanner flagged an upload
+	errCodeCronJobNotFound      = "cron_job_not_found"
+	errCodeExecutionNotFound    = "execution_not_found"
+	errCodeTemplateNotFound     = "template_not_found"
+	errCodeWebhookNotFound      = "webhook_not_found"
+	errCodeHookNotFound         = "hook_not_found"
+	errCodeHookDisabled         = "hook_disabled"
+	errCodeSubscriptionNotFound = "subscription_not_found"
+	errCodeCredentialNotFound   = "credential_not_found"
+	errCodeWatchNotFound        = "watch_not_found"
+)
+
+// apiError describes why a request failed
+type apiError struct {
+	Code    string `json:"code"`    // one of the errCode constants
+	Message string `json:"message"` // for people; may change between versions
+}
+
+// errorResponse is the body of every API error
+type errorResponse struct {
+	Error apiError `json:"error"`
+}
+
+// writeError replies with status and a JSON error envelope
+func writeError(w http.ResponseWriter, status int, code, message string) {
+	h := w.Header()
+	h.Del("Content-Length")
+	h.Set("Content-Type", "application/json")
+	h.Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(errorResponse{Error: apiError{Code: code, Message: message}})
+}
@@ -0,0 +1,66 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/iwanhae/terminal-hub/terminal"
+)
+
+// decodeAPIError decodes the error envelope of a recorded response
+func decodeAPIError(t *testing.T, rec *httptest.ResponseRecorder) apiError {
+	t.Helper()
+	if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+		t.Fatalf("expected a JSON error, got Content-Type %q: %s", ct, rec.Body.String())
+	}
+	var resp errorResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode error: %v", err)
+	}
+	return resp.Error
+}
+
+func TestAPIErrorsUseTheEnvelope(t *testing.T) {
+	sessionManager = terminal.NewSessionManager()
+	t.Cleanup(func() { _ = sessionManager.CloseAll() })
+
+	cases := []struct {
+		name    string
+		handler http.HandlerFunc
+		req     *http.Request
+		status  int
+		code    string
+	}{
+		{"unknown session", handleSessionByID, httptest.NewRequest(http.MethodGet, "/api/sessions/missing", nil), http.StatusNotFound, errCodeSessionNotFound},
+		{"wrong method", handleSystemStats, httptest.NewRequest(http.MethodPost, "/api/system/stats", nil), http.StatusMethodNotAllowed, errCodeMethodNotAllowed},
+		{"malformed body", handleCreateSession, httptest.NewRequest(http.MethodPost, "/api/sessions", nil), http.StatusBadRequest, errCodeInvalidJSON},
+		{"relative upload path", handleFileUpload, newUploadRequest(t, "relative", "a.txt", false, nil), http.StatusBadRequest, errCodeInvalidRequest},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			tc.handler(rec, tc.req)
+			if rec.Code != tc.status {
+				t.Fatalf("expected status %d, got %d: %s", tc.status, rec.Code, rec.Body.String())
+			}
+			if apiErr := decodeAPIError(t, rec); apiErr.Code != tc.code || apiErr.Message == "" {
+				t.Errorf("expected code %q with a message, got %+v", tc.code, apiErr)
+			}
+		})
+	}
+}
+
+func TestWriteLimitError(t *testing.T) {
+	rec := httptest.NewRecorder()
+	writeLimitError(rec, http.StatusTooManyRequests, &terminal.LimitError{Resource: terminal.LimitResourceSessions, Limit: 3})
+
+	var resp limitErrorResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode error: %v", err)
+	}
+	if resp.Error.Code != errCodeLimitReached || resp.Error.Message == "" || resp.Error.Resource != "sessions" || resp.Error.Limit != 3 {
+		t.Errorf("unexpected limit error %+v", resp.Error)
+	}
+}
@@ -72,13 +72,13 @@ func TestHandleLoginBansIPOnTenthFailedAttempt(t *testing.T) {
 	ip := "198.51.100.10"
 
 	for i := 1; i <= 9; i++ {
-		rec := performLoginRequest(t, sm, banTracker, ip, "10.0.0.1:4000", "admin", "wrong")
+		rec := performLoginRequest(t, sm, banTracker, ip, "127.0.0.1:4000", "admin", "wrong")
 		if rec.Code != http.StatusUnauthorized {
 			t.Fatalf("attempt %d: expected status %d, got %d", i, http.StatusUnauthorized, rec.Code)
 		}
 	}
 
-	rec := performLoginRequest(t, sm, banTracker, ip, "10.0.0.1:4000", "admin", "wrong")
+	rec := performLoginRequest(t, sm, banTracker, ip, "127.0.0.1:4000", "admin", "wrong")
 	if rec.Code != http.StatusTooManyRequests {
 		t.Fatalf("attempt 10: expected status %d, got %d", http.StatusTooManyRequests, rec.Code)
 	}
@@ -88,7 +88,7 @@ func TestHandleLoginBansIPOnTenthFailedAttempt(t *testing.T) {
 		t.Fatalf("expected success=false on banned response")
 	}
 
-	bannedRec := performLoginRequest(t, sm, banTracker, ip, "10.0.0.1:4000", "admin", "secret")
+	bannedRec := performLoginRequest(t, sm, banTracker, ip, "127.0.0.1:4000", "admin", "secret")
 	if bannedRec.Code != http.StatusTooManyRequests {
 		t.Fatalf("expected banned IP to stay blocked with status %d, got %d",
 			http.StatusTooManyRequests, bannedRec.Code)
@@ -102,7 +102,7 @@ func TestHandleLoginBanExpiresAndAllowsSuccessfulLogin(t *testing.T) {
 	banTracker := newLoginFail2Ban(1, time.Hour)
 	ip := "198.51.100.20"
 
-	rec := performLoginRequest(t, sm, banTracker, ip, "10.0.0.1:4000", "admin", "wrong")
+	rec := performLoginRequest(t, sm, banTracker, ip, "127.0.0.1:4000", "admin", "wrong")
 	if rec.Code != http.StatusTooManyRequests {
 		t.Fatalf("expected status %d, got %d", http.StatusTooManyRequests, rec.Code)
 	}
@@ -111,7 +111,7 @@ func TestHandleLoginBanExpiresAndAllowsSuccessfulLogin(t *testing.T) {
 	banTracker.bannedUntil[ip] = time.Now().Add(-time.Second)
 	banTracker.mu.Unlock()
 
-	successRec := performLoginRequest(t, sm, banTracker, ip, "10.0.0.1:4000", "admin", "secret")
+	successRec := performLoginRequest(t, sm, banTracker, ip, "127.0.0.1:4000", "admin", "secret")
 	if successRec.Code != http.StatusOK {
 		t.Fatalf("expected status %d after ban expiry, got %d: %s",
 			http.StatusOK, successRec.Code, successRec.Body.String())
@@ -126,18 +126,18 @@ func TestHandleLoginSuccessResetsFailureCounter(t *testing.T) {
 	ip := "198.51.100.30"
 
 	for i := 1; i <= 2; i++ {
-		rec := performLoginRequest(t, sm, banTracker, ip, "10.0.0.1:4000", "admin", "wrong")
+		rec := performLoginRequest(t, sm, banTracker, ip, "127.0.0.1:4000", "admin", "wrong")
 		if rec.Code != http.StatusUnauthorized {
 			t.Fatalf("attempt %d: expected status %d, got %d", i, http.StatusUnauthorized, rec.Code)
 		}
 	}
 
-	successRec := performLoginRequest(t, sm, banTracker, ip, "10.0.0.1:4000", "admin", "secret")
+	successRec := performLoginRequest(t, sm, banTracker, ip, "127.0.0.1:4000", "admin", "secret")
 	if successRec.Code != http.StatusOK {
 		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, successRec.Code, successRec.Body.String())
 	}
 
-	postSuccessFailure := performLoginRequest(t, sm, banTracker, ip, "10.0.0.1:4000", "admin", "wrong")
+	postSuccessFailure := performLoginRequest(t, sm, banTracker, ip, "127.0.0.1:4000", "admin", "wrong")
 	if postSuccessFailure.Code != http.StatusUnauthorized {
 		t.Fatalf("expected reset failure count to return status %d, got %d",
 			http.StatusUnauthorized, postSuccessFailure.Code)
@@ -152,37 +152,51 @@ func TestHandleLoginBanIsScopedPerIP(t *testing.T) {
 	bannedIP := "198.51.100.40"
 	otherIP := "203.0.113.5"
 
-	first := performLoginRequest(t, sm, banTracker, bannedIP, "10.0.0.1:4000", "admin", "wrong")
+	first := performLoginRequest(t, sm, banTracker, bannedIP, "127.0.0.1:4000", "admin", "wrong")
 	if first.Code != http.StatusUnauthorized {
 		t.Fatalf("expected status %d, got %d", http.StatusUnauthorized, first.Code)
 	}
 
-	second := performLoginRequest(t, sm, banTracker, bannedIP, "10.0.0.1:4000", "admin", "wrong")
+	second := performLoginRequest(t, sm, banTracker, bannedIP, "127.0.0.1:4000", "admin", "wrong")
 	if second.Code != http.StatusTooManyRequests {
 		t.Fatalf("expected status %d, got %d", http.StatusTooManyRequests, second.Code)
 	}
 
-	other := performLoginRequest(t, sm, banTracker, otherIP, "10.0.0.2:5000", "admin", "secret")
+	other := performLoginRequest(t, sm, banTracker, otherIP, "127.0.0.2:5000", "admin", "secret")
 	if other.Code != http.StatusOK {
 		t.Fatalf("expected other IP to succeed with status %d, got %d: %s",
 			http.StatusOK, other.Code, other.Body.String())
 	}
 }
 
-func TestExtractClientIPUsesFirstValidForwardedAddress(t *testing.T) {
+func TestExtractClientIPUsesNearestUntrustedForwardedAddress(t *testing.T) {
 	t.Parallel()
 
 	req := httptest.NewRequest(http.MethodPost, "/api/auth/login", nil)
-	req.RemoteAddr = "10.0.0.9:8080"
-	req.Header.Set("X-Forwarded-For", "unknown, 198.51.100.50, 203.0.113.10")
+	req.RemoteAddr = "127.0.0.1:8080"
+	req.Header.Set("X-Forwarded-For", "198.51.100.50, 203.0.113.10, 127.0.0.3")
 
 	got := extractClientIP(req)
-	want := "198.51.100.50"
+	want := "203.0.113.10"
 	if got != want {
 		t.Fatalf("expected IP %q, got %q", want, got)
 	}
 }
 
+func TestExtractClientIPIgnoresForwardedForFromUntrustedPeer(t *testing.T) {
+	t.Parallel()
+
+	req := httptest.NewRequest(http.MethodPost, "/api/auth/login", nil)
+	req.RemoteAddr = "198.51.100.60:8080"
+	req.Header.Set("X-Forwarded-For", "203.0.113.10")
+
+	got := extractClientIP(req)
+	want := "198.51.100.60"
+	if got != want {
+		t.Fatalf("expected peer IP %q, got %q", want, got)
+	}
+}
+
 func TestExtractClientIPFallsBackToRemoteAddr(t *testing.T) {
 	t.Parallel()
 
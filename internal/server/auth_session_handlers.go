@@ -0,0 +1,148 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/iwanhae/terminal-hub/auth"
+)
+
+// listAuthSessionsResponse lists the current user's login sessions
+type listAuthSessionsResponse struct {
+	Sessions []auth.SessionInfo `json:"sessions"`
+}
+
+// currentAuthSession returns the login session making the request, writing
+// the error response if there is none
+func currentAuthSession(w http.ResponseWriter, r *http.Request, sm *auth.SessionManager) (*auth.Session, bool) {
+	if !sm.IsConfigured() {
+		writeError(w, http.StatusNotFound, errCodeFeatureUnavailable, "Authentication is not configured")
+		return nil, false
+	}
+	cookie, err := sessionCookieOf(r)
+	if err != nil {
+		writeError(w, http.StatusUnauthorized, errCodeUnauthorized, "Unauthorized")
+		return nil, false
+	}
+	session, valid := sm.ValidateSession(cookie.Value)
+	if !valid {
+		writeError(w, http.StatusUnauthorized, errCodeUnauthorized, "Unauthorized")
+		return nil, false
+	}
+	return session, true
+}
+
+// handleAuthSessions handles GET /api/auth/sessions, listing where the
+// current user is logged in
+func handleAuthSessions(w http.ResponseWriter, r *http.Request, sm *auth.SessionManager) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, errCodeMethodNotAllowed, "Method not allowed")
+		return
+	}
+	session, ok := currentAuthSession(w, r, sm)
+	if !ok {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	resp := listAuthSessionsResponse{Sessions: sm.ListSessions(session.Username, session.ID)}
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		log.Printf("Error encoding login sessions: %v", err)
+	}
+}
+
+// handleAuthSessionByID handles DELETE /api/auth/sessions/:id, logging the
+// current user out of that session
+func handleAuthSessionByID(w http.ResponseWriter, r *http.Request, sm *auth.SessionManager) {
+	if r.Method != http.MethodDelete {
+		writeError(w, http.StatusMethodNotAllowed, errCodeMethodNotAllowed, "Method not allowed")
+		return
+	}
+	publicID := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/api/auth/sessions/"), "/")
+	if publicID == "" {
+		writeError(w, http.StatusBadRequest, errCodeInvalidRequest, "Session ID is required")
+		return
+	}
+	session, ok := currentAuthSession(w, r, sm)
+	if !ok {
+		return
+	}
+
+	if !sm.RevokeSession(session.Username, publicID) {
+		writeError(w, http.StatusNotFound, errCodeSessionNotFound, "Session not found")
+		return
+	}
+	log.Printf("Login session %s of %s revoked", publicID, session.Username)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// maxPasswordLength bounds the passwords the password-change API hashes
+const maxPasswordLength = 1024
+
+// changePasswordRequest is the body of POST /api/auth/password
+type changePasswordRequest struct {
+	CurrentPassword string `json:"current_password"`
+	NewPassword     string `json:"new_password"`
+}
+
+// Validate checks that both passwords are given and the new one differs
+func (req changePasswordRequest) Validate() error {
+	if req.CurrentPassword == "" || req.NewPassword == "" {
+		return errors.New("current_password and new_password are required")
+	}
+	if len(req.NewPassword) > maxPasswordLength {
+		return fmt.Errorf("new_password must be at most %d bytes", maxPasswordLength)
+	}
+	if req.NewPassword == req.CurrentPassword {
+		return errors.New("new_password must differ from current_password")
+	}
+	return nil
+}
+
+// handleChangePassword handles POST /api/auth/password, changing the current
+// user's password in the password file and logging out their other sessions
+func handleChangePassword(w http.ResponseWriter, r *http.Request, sm *auth.SessionManager) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, errCodeMethodNotAllowed, "Method not allowed")
+		return
+	}
+	session, ok := currentAuthSession(w, r, sm)
+	if !ok {
+		return
+	}
+
+	var req changePasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, errCodeInvalidJSON, "Bad request")
+		return
+	}
+	if err := req.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, errCodeInvalidRequest, err.Error())
+		return
+	}
+
+	err := sm.ChangePassword(session.Username, req.CurrentPassword, req.NewPassword)
+	switch {
+	case errors.Is(err, auth.ErrPasswordChangeUnsupported):
+		writeError(w, http.StatusConflict, errCodeFeatureUnavailable, "Passwords set through TERMINAL_HUB_PASSWORD cannot be changed here")
+		return
+	case errors.Is(err, auth.ErrWrongPassword):
+		writeError(w, http.StatusForbidden, errCodeForbidden, "Current password is incorrect")
+		return
+	case errors.Is(err, auth.ErrWeakPassword):
+		writeError(w, http.StatusBadRequest, errCodeInvalidRequest, err.Error())
+		return
+	case err != nil:
+		log.Printf("Error changing the password of %s: %v", session.Username, err)
+		writeError(w, http.StatusInternalServerError, errCodeInternal, "Internal server error")
+		return
+	}
+
+	revoked := sm.RevokeOtherSessions(session.Username, session.ID)
+	log.Printf("Password of %s changed; %d other login sessions logged out", session.Username, revoked)
+	w.WriteHeader(http.StatusNoContent)
+}
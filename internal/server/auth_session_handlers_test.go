@@ -0,0 +1,261 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/iwanhae/terminal-hub/auth"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func authSessionRequest(method, path, token string) *http.Request {
+	req := httptest.NewRequest(method, path, nil)
+	req.AddCookie(&http.Cookie{Name: "session_token", Value: token})
+	return req
+}
+
+func TestAuthSessionsListAndRevoke(t *testing.T) {
+	sm := newTestAuthSessionManager()
+	laptop, err := sm.CreateClientSession("admin", auth.ClientInfo{UserAgent: "Firefox", IP: "203.0.113.1", DeviceID: "dev-1"}, auth.PersistDefault)
+	if err != nil {
+		t.Fatalf("failed to create session: %v", err)
+	}
+	phone, err := sm.CreateClientSession("admin", auth.ClientInfo{UserAgent: "Mobile Safari", IP: "198.51.100.2"}, auth.PersistDefault)
+	if err != nil {
+		t.Fatalf("failed to create session: %v", err)
+	}
+	if _, err := sm.CreateClientSession("guest", auth.ClientInfo{UserAgent: "curl"}, auth.PersistDefault); err != nil {
+		t.Fatalf("failed to create session: %v", err)
+	}
+
+	rec := httptest.NewRecorder()
+	handleAuthSessions(rec, authSessionRequest(http.MethodGet, "/api/auth/sessions", laptop.ID), sm)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("list: expected status 200, got %d", rec.Code)
+	}
+	var list listAuthSessionsResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &list); err != nil {
+		t.Fatalf("list: bad response %q: %v", rec.Body.String(), err)
+	}
+	if len(list.Sessions) != 2 {
+		t.Fatalf("expected the two sessions of admin, got %+v", list.Sessions)
+	}
+	byID := map[string]auth.SessionInfo{}
+	for _, info := range list.Sessions {
+		if info.ID == laptop.ID || info.ID == phone.ID {
+			t.Fatalf("session token leaked in %+v", info)
+		}
+		byID[info.ID] = info
+	}
+	if info := byID[laptop.PublicID]; !info.Current || info.UserAgent != "Firefox" || info.DeviceID != "dev-1" {
+		t.Errorf("unexpected laptop session %+v", info)
+	}
+	if info := byID[phone.PublicID]; info.Current || info.IP != "198.51.100.2" {
+		t.Errorf("unexpected phone session %+v", info)
+	}
+
+	rec = httptest.NewRecorder()
+	handleAuthSessionByID(rec, authSessionRequest(http.MethodDelete, "/api/auth/sessions/"+phone.PublicID, laptop.ID), sm)
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("revoke: expected status 204, got %d", rec.Code)
+	}
+	if _, valid := sm.ValidateSession(phone.ID); valid {
+		t.Error("expected the revoked session to be logged out")
+	}
+
+	rec = httptest.NewRecorder()
+	handleAuthSessionByID(rec, authSessionRequest(http.MethodDelete, "/api/auth/sessions/"+phone.PublicID, laptop.ID), sm)
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("revoke again: expected status 404, got %d", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	handleAuthSessions(rec, authSessionRequest(http.MethodGet, "/api/auth/sessions", "stolen"), sm)
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("invalid token: expected status 401, got %d", rec.Code)
+	}
+}
+
+func TestLoginRecordsSessionClient(t *testing.T) {
+	sm := newTestAuthSessionManager()
+	rec := performLoginRequest(t, sm, nil, "203.0.113.9", "127.0.0.1:4000", "admin", "secret")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("login: expected status 200, got %d", rec.Code)
+	}
+
+	sessions := sm.ListSessions("admin", "")
+	if len(sessions) != 1 || sessions[0].IP != "203.0.113.9" {
+		t.Fatalf("expected one session from 203.0.113.9, got %+v", sessions)
+	}
+}
+
+func changePasswordRequestFor(token, body string) *http.Request {
+	req := httptest.NewRequest(http.MethodPost, "/api/auth/password", bytes.NewReader([]byte(body)))
+	req.AddCookie(&http.Cookie{Name: "session_token", Value: token})
+	return req
+}
+
+func TestLoginRehashesBcryptPassword(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "credentials.json")
+	legacy, err := bcrypt.GenerateFromPassword([]byte("old-secret"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(`{"users": [{"username": "admin", "password_hash": "`+string(legacy)+`"}], "version": 2}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	users, err := auth.LoadUsers(path)
+	if err != nil {
+		t.Fatalf("failed to load users: %v", err)
+	}
+	sm := auth.NewSessionManagerFromUsers(users, time.Hour)
+	sm.SetPasswordFile(path)
+
+	if rec := performLoginRequest(t, sm, nil, "", "", "admin", "old-secret"); rec.Code != http.StatusOK {
+		t.Fatalf("expected the bcrypt password to log in, got %d", rec.Code)
+	}
+	data, _ := os.ReadFile(path)
+	if !strings.Contains(string(data), "$argon2id$v=19$") || strings.Contains(string(data), string(legacy)) {
+		t.Errorf("expected the password to be re-hashed with Argon2id, got %s", data)
+	}
+	if rec := performLoginRequest(t, sm, nil, "", "", "admin", "old-secret"); rec.Code != http.StatusOK {
+		t.Errorf("expected the re-hashed password to log in, got %d", rec.Code)
+	}
+}
+
+func TestChangePassword(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "credentials.json")
+	hash, err := auth.HashPassword("old-secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := auth.AddUser(path, "admin", hash, true); err != nil {
+		t.Fatal(err)
+	}
+	users, err := auth.LoadUsers(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sm := auth.NewSessionManagerFromUsers(users, time.Hour)
+	sm.SetPasswordFile(path)
+	current, _ := sm.CreateSession("admin")
+	other, _ := sm.CreateSession("admin")
+	if !sm.MustChangePassword("admin") {
+		t.Fatal("expected the flag in the password file to require a change")
+	}
+
+	tests := []struct {
+		body     string
+		wantCode int
+	}{
+		{`{"current_password": "wrong", "new_password": "new-secret"}`, http.StatusForbidden},
+		{`{"current_password": "old-secret"}`, http.StatusBadRequest},
+		{`{"current_password": "old-secret", "new_password": "old-secret"}`, http.StatusBadRequest},
+		{`{"current_password": "old-secret", "new_password": "short"}`, http.StatusBadRequest},
+		{`{"current_password": "old-secret", "new_password": "new-secret"}`, http.StatusNoContent},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		handleChangePassword(rec, changePasswordRequestFor(current.ID, tt.body), sm)
+		if rec.Code != tt.wantCode {
+			t.Errorf("%s: expected %d, got %d: %s", tt.body, tt.wantCode, rec.Code, rec.Body.String())
+		}
+	}
+
+	if sm.ValidateCredentials("admin", "old-secret") || !sm.ValidateCredentials("admin", "new-secret") {
+		t.Error("expected only the new password to work")
+	}
+	if sm.MustChangePassword("admin") {
+		t.Error("expected the change to clear the required change")
+	}
+	if _, ok := sm.ValidateSession(other.ID); ok {
+		t.Error("expected the other login session to be logged out")
+	}
+	if _, ok := sm.ValidateSession(current.ID); !ok {
+		t.Error("expected the session that changed the password to stay logged in")
+	}
+	saved, err := auth.ListUsers(path)
+	if err != nil || len(saved) != 1 || !auth.ValidatePassword("new-secret", saved[0].PasswordHash) || saved[0].MustChangePassword {
+		t.Errorf("expected the new password to be saved, got %+v, %v", saved, err)
+	}
+}
+
+func TestPasswordChangeRequired(t *testing.T) {
+	hash, err := auth.HashPassword("weak")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sm := auth.NewSessionManagerFromUsers([]auth.UserCredential{{Username: "admin", PasswordHash: hash}}, time.Hour)
+	sm.SetPasswordPolicy(auth.PasswordPolicy{MinLength: 12, MinClasses: 2})
+	if sm.MustChangePassword("admin") {
+		t.Fatal("expected no change to be required before logging in")
+	}
+	if !sm.ValidateCredentials("admin", "weak") || !sm.MustChangePassword("admin") {
+		t.Fatal("expected a password weaker than the policy to require a change once used")
+	}
+	session, _ := sm.CreateSession("admin")
+
+	rec := httptest.NewRecorder()
+	handleAuthStatus(rec, authSessionRequest(http.MethodGet, "/api/auth/status", session.ID), sm)
+	var status authStatusResponse
+	if err := json.NewDecoder(rec.Body).Decode(&status); err != nil {
+		t.Fatal(err)
+	}
+	if !status.MustChangePassword || status.PasswordPolicy == nil || status.PasswordPolicy.MinLength != 12 {
+		t.Errorf("expected the status to ask for a change and describe the policy, got %+v", status)
+	}
+
+	handler := sessionAuthMiddleware(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}, sm)
+	rec = httptest.NewRecorder()
+	handler(rec, authSessionRequest(http.MethodGet, "/api/sessions", session.ID))
+	if rec.Code != http.StatusForbidden || !strings.Contains(rec.Body.String(), errCodePasswordChangeRequired) {
+		t.Errorf("expected the API to be blocked, got %d: %s", rec.Code, rec.Body.String())
+	}
+	rec = httptest.NewRecorder()
+	handler(rec, authSessionRequest(http.MethodGet, "/api/auth/sessions", session.ID))
+	if rec.Code != http.StatusOK {
+		t.Errorf("expected the auth endpoints to stay open, got %d", rec.Code)
+	}
+}
+
+func TestPasswordMaxAge(t *testing.T) {
+	hash, err := auth.HashPassword("Old-password-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	changedAt := time.Now().Add(-48 * time.Hour).UTC().Format(time.RFC3339)
+	sm := auth.NewSessionManagerFromUsers([]auth.UserCredential{
+		{Username: "old", PasswordHash: hash, UpdatedAt: changedAt},
+		{Username: "unknown", PasswordHash: hash},
+	}, time.Hour)
+	sm.SetPasswordPolicy(auth.PasswordPolicy{MinLength: 8, MaxAge: 24 * time.Hour})
+	if !sm.MustChangePassword("old") {
+		t.Error("expected a password older than the maximum age to require a change")
+	}
+	if sm.MustChangePassword("unknown") {
+		t.Error("expected a password of unknown age not to expire")
+	}
+}
+
+func TestChangePasswordFromEnvironment(t *testing.T) {
+	sm := newTestAuthSessionManager()
+	session, _ := sm.CreateSession("admin")
+
+	rec := httptest.NewRecorder()
+	handleChangePassword(rec, changePasswordRequestFor(session.ID, `{"current_password": "secret", "new_password": "other"}`), sm)
+	if rec.Code != http.StatusConflict {
+		t.Errorf("expected 409 for environment credentials, got %d", rec.Code)
+	}
+	if !sm.ValidateCredentials("admin", "secret") {
+		t.Error("expected the password to be unchanged")
+	}
+}
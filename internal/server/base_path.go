@@ -0,0 +1,124 @@
+package server
+
+import (
+	"bytes"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// basePath is the sub-path terminal-hub is served under, such as "/hub", or
+// "" when it is served from the root
+var basePath string
+
+// basePathPattern matches a normalized base path: slash-separated segments of
+// URL-safe characters
+var basePathPattern = regexp.MustCompile(`^(/[A-Za-z0-9._~-]+)+$`)
+
+// rootURLPattern matches root-relative URLs in the SPA's HTML attributes and
+// web manifest, which must point below the base path
+var rootURLPattern = regexp.MustCompile(`((?:href|src)=|"(?:start_url|scope|src)":\s*)"/([^/])`)
+
+// rewrittenFiles are the embedded files whose root-relative URLs are prefixed
+// with the base path, by content type
+var rewrittenFiles = map[string]string{
+	"index.html":           "text/html; charset=utf-8",
+	"manifest.webmanifest": "application/manifest+json",
+}
+
+// getBasePathFromEnv returns TERMINAL_HUB_BASE_PATH normalized to a leading
+// slash and no trailing slash, or "" when unset or "/"
+func getBasePathFromEnv() (string, error) {
+	value := strings.TrimSpace(os.Getenv("TERMINAL_HUB_BASE_PATH"))
+	value = strings.TrimRight(value, "/")
+	if value == "" {
+		return "", nil
+	}
+	if !strings.HasPrefix(value, "/") {
+		value = "/" + value
+	}
+	if !basePathPattern.MatchString(value) {
+		return "", fmt.Errorf("TERMINAL_HUB_BASE_PATH %q must be a path such as /hub", value)
+	}
+	for _, segment := range strings.Split(value[1:], "/") {
+		if segment == "." || segment == ".." {
+			return "", fmt.Errorf("TERMINAL_HUB_BASE_PATH %q must not contain . or .. segments", value)
+		}
+	}
+	return value, nil
+}
+
+// cookiePath is the path cookies are scoped to
+func cookiePath() string {
+	return basePath + "/"
+}
+
+// withBasePath serves next under prefix, stripping it from request paths so
+// routes stay registered at the root. The bare prefix redirects to prefix/
+// and paths outside it are not found. An empty prefix serves next as is.
+func withBasePath(next http.Handler, prefix string) http.Handler {
+	if prefix == "" {
+		return next
+	}
+	stripped := http.StripPrefix(prefix, next)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == prefix {
+			target := prefix + "/"
+			if r.URL.RawQuery != "" {
+				target += "?" + r.URL.RawQuery
+			}
+			http.Redirect(w, r, target, http.StatusMovedPermanently)
+			return
+		}
+		if !strings.HasPrefix(r.URL.Path, prefix+"/") {
+			http.NotFound(w, r)
+			return
+		}
+		stripped.ServeHTTP(w, r)
+	})
+}
+
+// rewriteRootURLs prefixes the root-relative URLs in an SPA file with prefix
+func rewriteRootURLs(data []byte, prefix string) []byte {
+	return rootURLPattern.ReplaceAll(data, []byte(`${1}"`+prefix+`/${2}`))
+}
+
+// serveStatic serves an embedded frontend file. Under a base path, the SPA
+// entry point and web manifest are rewritten to load their assets below it,
+// and index.html tells the frontend the base path through a meta tag. Files
+// that are not rewritten are sent precompressed when the client accepts it.
+func serveStatic(w http.ResponseWriter, r *http.Request, fsys fs.FS, fileServer http.Handler) {
+	name := strings.TrimPrefix(r.URL.Path, "/")
+	if name == "" {
+		name = "index.html"
+	}
+	setStaticCacheHeaders(w, fsys, name)
+	contentType, rewrite := rewrittenFiles[name]
+	if basePath == "" || !rewrite {
+		// The file server redirects /index.html to /
+		if r.URL.Path != "/index.html" && servePrecompressed(w, r, fsys, name) {
+			return
+		}
+		fileServer.ServeHTTP(w, r)
+		return
+	}
+
+	data, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	data = rewriteRootURLs(data, basePath)
+	if name == "index.html" {
+		meta := `<meta name="terminal-hub-base-path" content="` + basePath + `" />`
+		data = bytes.Replace(data, []byte("</head>"), []byte(meta+"\n  </head>"), 1)
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("ETag", contentETag(data))
+	http.ServeContent(w, r, name, time.Time{}, bytes.NewReader(data))
+}
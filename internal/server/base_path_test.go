@@ -0,0 +1,129 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestGetBasePathFromEnv(t *testing.T) {
+	cases := map[string]string{
+		"":           "",
+		"/":          "",
+		"hub":        "/hub",
+		"/hub/":      "/hub",
+		"/tools/hub": "/tools/hub",
+	}
+	for value, want := range cases {
+		t.Setenv("TERMINAL_HUB_BASE_PATH", value)
+		got, err := getBasePathFromEnv()
+		if err != nil || got != want {
+			t.Errorf("base path %q: expected %q, got %q, %v", value, want, got, err)
+		}
+	}
+
+	for _, bad := range []string{"/hub/../etc", "/my hub", "/hub?x=1", "//hub"} {
+		t.Setenv("TERMINAL_HUB_BASE_PATH", bad)
+		if _, err := getBasePathFromEnv(); err == nil {
+			t.Errorf("expected base path %q to be rejected", bad)
+		}
+	}
+}
+
+func TestWithBasePath(t *testing.T) {
+	handler := withBasePath(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.URL.Path))
+	}), "/hub")
+
+	cases := []struct {
+		path       string
+		wantStatus int
+		wantBody   string
+	}{
+		{"/hub/api/sessions", http.StatusOK, "/api/sessions"},
+		{"/hub/", http.StatusOK, "/"},
+		{"/hub", http.StatusMovedPermanently, ""},
+		{"/api/sessions", http.StatusNotFound, ""},
+		{"/hubble/", http.StatusNotFound, ""},
+	}
+	for _, c := range cases {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, c.path, nil))
+		if rec.Code != c.wantStatus {
+			t.Errorf("%s: expected status %d, got %d", c.path, c.wantStatus, rec.Code)
+		}
+		if c.wantBody != "" && rec.Body.String() != c.wantBody {
+			t.Errorf("%s: expected path %q, got %q", c.path, c.wantBody, rec.Body.String())
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/hub?tab=crons", nil))
+	if location := rec.Header().Get("Location"); location != "/hub/?tab=crons" {
+		t.Errorf("expected redirect to /hub/?tab=crons, got %q", location)
+	}
+}
+
+func TestServeStaticRewritesURLsUnderBasePath(t *testing.T) {
+	basePath = "/hub"
+	t.Cleanup(func() { basePath = "" })
+
+	fsys := fstest.MapFS{
+		"index.html": {Data: []byte(`<html>
+  <head>
+    <link rel="manifest" href="/manifest.webmanifest" />
+    <script type="module" src="/assets/index.js"></script>
+    <link rel="preconnect" href="https://fonts.example" />
+  </head>
+</html>`)},
+		"manifest.webmanifest": {Data: []byte(`{"start_url": "/", "scope": "/", "icons": [{"src": "/icon.png"}]}`)},
+		"assets/index.js":      {Data: []byte(`fetch("/api/sessions")`)},
+	}
+	fileServer := http.FileServer(http.FS(fsys))
+
+	serve := func(path string) string {
+		rec := httptest.NewRecorder()
+		serveStatic(rec, httptest.NewRequest(http.MethodGet, path, nil), fsys, fileServer)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s: expected status 200, got %d", path, rec.Code)
+		}
+		return rec.Body.String()
+	}
+
+	index := serve("/")
+	for _, want := range []string{
+		`href="/hub/manifest.webmanifest"`,
+		`src="/hub/assets/index.js"`,
+		`href="https://fonts.example"`,
+		`<meta name="terminal-hub-base-path" content="/hub" />`,
+	} {
+		if !strings.Contains(index, want) {
+			t.Errorf("expected index.html to contain %s, got:\n%s", want, index)
+		}
+	}
+
+	manifest := serve("/manifest.webmanifest")
+	if manifest != `{"start_url": "/hub/", "scope": "/hub/", "icons": [{"src": "/hub/icon.png"}]}` {
+		t.Errorf("unexpected manifest: %s", manifest)
+	}
+
+	if script := serve("/assets/index.js"); script != `fetch("/api/sessions")` {
+		t.Errorf("expected other assets to be served as is, got %s", script)
+	}
+}
+
+func TestLoginCookieScopedToBasePath(t *testing.T) {
+	basePath = "/hub"
+	t.Cleanup(func() { basePath = "" })
+
+	rec := performLoginRequest(t, newTestAuthSessionManager(), nil, "", "127.0.0.1:4000", "admin", "secret")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("login: expected status 200, got %d", rec.Code)
+	}
+	cookies := rec.Result().Cookies()
+	if len(cookies) == 0 || cookies[len(cookies)-1].Path != "/hub/" {
+		t.Fatalf("expected the session cookie to be scoped to /hub/, got %+v", cookies)
+	}
+}
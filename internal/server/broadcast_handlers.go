@@ -0,0 +1,206 @@
+package server
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Broadcast input limits
+const (
+	maxBroadcastSessions       = 100
+	maxBroadcastInputBytes     = 64 * 1024
+	broadcastConfirmationTTL   = time.Minute
+	maxPendingBroadcastConfirm = 1000
+)
+
+// broadcastInputRequest is the body of POST /api/sessions/broadcast-input.
+// Without a confirm token the request is only validated and a token is
+// returned; repeating it with the token writes the input.
+type broadcastInputRequest struct {
+	SessionIDs   []string `json:"session_ids"`
+	Data         string   `json:"data"`
+	ConfirmToken string   `json:"confirm_token,omitempty"`
+}
+
+// broadcastConfirmResponse asks the client to confirm a broadcast
+type broadcastConfirmResponse struct {
+	ConfirmToken string   `json:"confirm_token"`
+	SessionIDs   []string `json:"session_ids"`
+	ExpiresAt    int64    `json:"expires_at"` // unix timestamp
+}
+
+// broadcastResult reports the outcome for one session
+type broadcastResult struct {
+	SessionID string `json:"session_id"`
+	OK        bool   `json:"ok"`
+	Error     string `json:"error,omitempty"`
+}
+
+// broadcastInputResponse reports the outcome of a confirmed broadcast
+type broadcastInputResponse struct {
+	Results []broadcastResult `json:"results"`
+}
+
+// broadcastConfirmations holds single-use tokens, each bound to the exact
+// sessions and input it was issued for
+type broadcastConfirmations struct {
+	mu     sync.Mutex
+	tokens map[string]broadcastConfirmation
+}
+
+type broadcastConfirmation struct {
+	digest    string
+	expiresAt time.Time
+}
+
+var pendingBroadcasts = &broadcastConfirmations{tokens: make(map[string]broadcastConfirmation)}
+
+// issue returns a new token for digest
+func (c *broadcastConfirmations) issue(digest string, now time.Time) (string, time.Time, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", time.Time{}, err
+	}
+	token := hex.EncodeToString(buf)
+	expiresAt := now.Add(broadcastConfirmationTTL)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for t, pending := range c.tokens {
+		if now.After(pending.expiresAt) {
+			delete(c.tokens, t)
+		}
+	}
+	if len(c.tokens) >= maxPendingBroadcastConfirm {
+		return "", time.Time{}, errors.New("too many pending broadcast confirmations")
+	}
+	c.tokens[token] = broadcastConfirmation{digest: digest, expiresAt: expiresAt}
+	return token, expiresAt, nil
+}
+
+// consume checks and invalidates a token
+func (c *broadcastConfirmations) consume(token, digest string, now time.Time) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	pending, ok := c.tokens[token]
+	if !ok {
+		return false
+	}
+	delete(c.tokens, token)
+	return pending.digest == digest && !now.After(pending.expiresAt)
+}
+
+// broadcastDigest identifies a set of sessions and input regardless of the
+// order the sessions were listed in
+func broadcastDigest(sessionIDs []string, data string) string {
+	sorted := append([]string(nil), sessionIDs...)
+	sort.Strings(sorted)
+	sum := sha256.Sum256([]byte(strings.Join(sorted, "\x00") + "\x00\x00" + data))
+	return hex.EncodeToString(sum[:])
+}
+
+// handleBroadcastInput handles POST /api/sessions/broadcast-input
+func handleBroadcastInput(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, errCodeMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	var req broadcastInputRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Printf("Error decoding request: %v", err)
+		writeError(w, http.StatusBadRequest, errCodeInvalidJSON, "Bad request")
+		return
+	}
+
+	if len(req.SessionIDs) == 0 {
+		writeError(w, http.StatusBadRequest, errCodeInvalidRequest, "session_ids is required")
+		return
+	}
+	if len(req.SessionIDs) > maxBroadcastSessions {
+		writeError(w, http.StatusBadRequest, errCodeInvalidRequest, fmt.Sprintf("At most %d sessions can be selected", maxBroadcastSessions))
+		return
+	}
+	if req.Data == "" {
+		writeError(w, http.StatusBadRequest, errCodeInvalidRequest, "data is required")
+		return
+	}
+	if len(req.Data) > maxBroadcastInputBytes {
+		writeError(w, http.StatusBadRequest, errCodeInvalidRequest, fmt.Sprintf("data must be at most %d bytes", maxBroadcastInputBytes))
+		return
+	}
+
+	seen := make(map[string]bool, len(req.SessionIDs))
+	var missing []string
+	for _, id := range req.SessionIDs {
+		if seen[id] {
+			writeError(w, http.StatusBadRequest, errCodeInvalidRequest, "Duplicate session ID "+id)
+			return
+		}
+		seen[id] = true
+		if _, ok := sessionManager.Get(id); !ok {
+			missing = append(missing, id)
+		}
+	}
+	if len(missing) > 0 {
+		writeError(w, http.StatusNotFound, errCodeSessionNotFound, "Sessions not found: "+strings.Join(missing, ", "))
+		return
+	}
+
+	now := time.Now()
+	digest := broadcastDigest(req.SessionIDs, req.Data)
+	if req.ConfirmToken == "" {
+		token, expiresAt, err := pendingBroadcasts.issue(digest, now)
+		if err != nil {
+			log.Printf("Error issuing broadcast confirmation: %v", err)
+			writeError(w, http.StatusServiceUnavailable, errCodeRateLimited, err.Error())
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusAccepted)
+		if err := json.NewEncoder(w).Encode(broadcastConfirmResponse{
+			ConfirmToken: token,
+			SessionIDs:   req.SessionIDs,
+			ExpiresAt:    expiresAt.Unix(),
+		}); err != nil {
+			log.Printf("Error encoding response: %v", err)
+		}
+		return
+	}
+	if !pendingBroadcasts.consume(req.ConfirmToken, digest, now) {
+		writeError(w, http.StatusConflict, errCodeConflict, "Invalid or expired confirm_token")
+		return
+	}
+
+	resp := broadcastInputResponse{Results: make([]broadcastResult, 0, len(req.SessionIDs))}
+	for _, id := range req.SessionIDs {
+		result := broadcastResult{SessionID: id}
+		// Sessions may have exited since the confirmation was issued
+		if sess, ok := sessionManager.Get(id); !ok {
+			result.Error = "session not found"
+		} else if _, err := sess.Write([]byte(req.Data)); err != nil {
+			result.Error = err.Error()
+		} else {
+			result.OK = true
+		}
+		resp.Results = append(resp.Results, result)
+	}
+	log.Printf("Broadcast %d bytes of input to %d sessions", len(req.Data), len(req.SessionIDs))
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		log.Printf("Error encoding response: %v", err)
+	}
+}
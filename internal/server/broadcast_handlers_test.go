@@ -0,0 +1,131 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"testing"
+	"time"
+
+	"github.com/creack/pty"
+	"github.com/iwanhae/terminal-hub/terminal"
+)
+
+// ptyPairService hands the session the master side of a real PTY so input
+// written to the session can be read back from the tty side
+type ptyPairService struct {
+	master *os.File
+}
+
+func (p *ptyPairService) Start(_ string) (*os.File, error) {
+	return p.master, nil
+}
+
+func (p *ptyPairService) StartWithConfig(_ string, _ string, _ map[string]string) (*os.File, *exec.Cmd, error) {
+	return p.master, nil, nil
+}
+
+func (p *ptyPairService) SetSize(_ *os.File, _ int, _ int) error {
+	return nil
+}
+
+func postBroadcast(t *testing.T, req broadcastInputRequest) *httptest.ResponseRecorder {
+	t.Helper()
+	body, err := json.Marshal(req)
+	if err != nil {
+		t.Fatalf("failed to encode request: %v", err)
+	}
+	rec := httptest.NewRecorder()
+	handleBroadcastInput(rec, httptest.NewRequest(http.MethodPost, "/api/sessions/broadcast-input", bytes.NewReader(body)))
+	return rec
+}
+
+func TestBroadcastInput(t *testing.T) {
+	sessionManager = terminal.NewSessionManager()
+	t.Cleanup(func() { _ = sessionManager.CloseAll() })
+
+	ttys := make(map[string]*os.File)
+	for _, id := range []string{"web-1", "web-2"} {
+		master, tty, err := pty.Open()
+		if err != nil {
+			t.Skipf("PTYs are not available: %v", err)
+		}
+		t.Cleanup(func() { _ = tty.Close() })
+		ttys[id] = tty
+		if _, err := sessionManager.CreateSession(terminal.SessionConfig{
+			ID:         id,
+			Backend:    terminal.SessionBackendPTY,
+			PTYService: &ptyPairService{master: master},
+		}); err != nil {
+			t.Fatalf("failed to create session: %v", err)
+		}
+	}
+
+	req := broadcastInputRequest{SessionIDs: []string{"web-1", "web-2"}, Data: "uptime\n"}
+	rec := postBroadcast(t, req)
+	if rec.Code != http.StatusAccepted {
+		t.Fatalf("expected status 202, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var confirm broadcastConfirmResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &confirm); err != nil || confirm.ConfirmToken == "" {
+		t.Fatalf("expected a confirm token, got %s (%v)", rec.Body.String(), err)
+	}
+
+	// The token is bound to the exact input
+	changed := req
+	changed.Data = "reboot\n"
+	changed.ConfirmToken = confirm.ConfirmToken
+	if rec := postBroadcast(t, changed); rec.Code != http.StatusConflict {
+		t.Fatalf("expected status 409 for changed input, got %d", rec.Code)
+	}
+
+	// A rejected token is spent, so ask for a new one
+	rec = postBroadcast(t, req)
+	if err := json.Unmarshal(rec.Body.Bytes(), &confirm); err != nil {
+		t.Fatalf("failed to decode confirmation: %v", err)
+	}
+	req.ConfirmToken = confirm.ConfirmToken
+	rec = postBroadcast(t, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var resp broadcastInputResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	for _, result := range resp.Results {
+		if !result.OK {
+			t.Errorf("broadcast to %s failed: %s", result.SessionID, result.Error)
+		}
+	}
+	for id, tty := range ttys {
+		_ = tty.SetReadDeadline(time.Now().Add(2 * time.Second))
+		buf := make([]byte, 64)
+		n, err := tty.Read(buf)
+		if err != nil || string(buf[:n]) != "uptime\n" {
+			t.Errorf("session %s received %q (%v)", id, buf[:n], err)
+		}
+	}
+
+	if rec := postBroadcast(t, req); rec.Code != http.StatusConflict {
+		t.Errorf("expected a reused token to be rejected, got %d", rec.Code)
+	}
+
+	tests := []struct {
+		req  broadcastInputRequest
+		want int
+	}{
+		{broadcastInputRequest{Data: "ls\n"}, http.StatusBadRequest},
+		{broadcastInputRequest{SessionIDs: []string{"web-1"}}, http.StatusBadRequest},
+		{broadcastInputRequest{SessionIDs: []string{"web-1", "web-1"}, Data: "ls\n"}, http.StatusBadRequest},
+		{broadcastInputRequest{SessionIDs: []string{"web-1", "missing"}, Data: "ls\n"}, http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		if rec := postBroadcast(t, tt.req); rec.Code != tt.want {
+			t.Errorf("%+v: expected status %d, got %d: %s", tt.req, tt.want, rec.Code, rec.Body.String())
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/iwanhae/terminal-hub/terminal"
+)
+
+// handleGetSession handles GET /api/sessions/:id, returning the session's
+// metadata and attached clients. Demo visitors get redacted metadata and no
+// clients, whose addresses and browsers belong to other users.
+func handleGetSession(w http.ResponseWriter, r *http.Request, sessionID string) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, errCodeMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	sess, ok := sessionManager.Get(sessionID)
+	if !ok {
+		writeError(w, http.StatusNotFound, errCodeSessionNotFound, "Session not found")
+		return
+	}
+
+	detail := terminal.SessionDetail{
+		ID:       sessionID,
+		Metadata: sess.GetMetadata(),
+		Clients:  []terminal.AttachedClient{},
+	}
+	if isDemoVisitor(r) {
+		detail.Metadata = redactDemoMetadata(detail.Metadata)
+	} else if clients, ok := sess.(terminal.ClientManager); ok {
+		detail.Clients = clients.AttachedClients()
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(detail); err != nil {
+		log.Printf("Error encoding session detail: %v", err)
+	}
+}
+
+// handleKickClient handles DELETE /api/sessions/:id/clients/:clientId,
+// disconnecting one client. The ID is the one shown in the session detail
+// and in presence messages.
+func handleKickClient(w http.ResponseWriter, r *http.Request, sessionID string, clientID string) {
+	if r.Method != http.MethodDelete {
+		writeError(w, http.StatusMethodNotAllowed, errCodeMethodNotAllowed, "Method not allowed")
+		return
+	}
+	if clientID == "" {
+		writeError(w, http.StatusBadRequest, errCodeInvalidRequest, "Client ID is required")
+		return
+	}
+
+	sess, ok := sessionManager.Get(sessionID)
+	if !ok {
+		writeError(w, http.StatusNotFound, errCodeSessionNotFound, "Session not found")
+		return
+	}
+	clients, ok := sess.(terminal.ClientManager)
+	if !ok {
+		writeError(w, http.StatusConflict, errCodeUnsupportedSession, "Session clients cannot be managed")
+		return
+	}
+
+	if err := clients.KickClient(clientID); err != nil {
+		if errors.Is(err, terminal.ErrUnknownClient) {
+			writeError(w, http.StatusNotFound, errCodeClientNotFound, "Client not found")
+			return
+		}
+		// The client is detached even if closing its connection failed
+		log.Printf("Error closing kicked client %s of session %s: %v", clientID, sessionID, err)
+	}
+	log.Printf("Session %s: kicked client %s", sessionID, clientID)
+
+	w.WriteHeader(http.StatusNoContent)
+}
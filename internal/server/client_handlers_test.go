@@ -0,0 +1,102 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/iwanhae/terminal-hub/terminal"
+)
+
+func TestSessionDetailListsAndKicksClients(t *testing.T) {
+	server, sessionID, _ := createWebSocketHeartbeatTestServer(t)
+
+	wsURL := "ws" + strings.TrimPrefix(server.URL, "http") + "/ws/" + sessionID + "?name=phone"
+	conn, _, err := websocket.DefaultDialer.Dial(wsURL, http.Header{"User-Agent": []string{"test-agent"}})
+	if err != nil {
+		t.Fatalf("failed to dial websocket: %v", err)
+	}
+	t.Cleanup(func() { _ = conn.Close() })
+	// The client is attached once it gets its first message
+	_ = conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	if _, _, err := conn.ReadMessage(); err != nil {
+		t.Fatalf("failed to read first message: %v", err)
+	}
+
+	getDetail := func() terminal.SessionDetail {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		handleSessionByID(rec, httptest.NewRequest(http.MethodGet, "/api/sessions/"+sessionID, nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got %d: %s", rec.Code, rec.Body.String())
+		}
+		var detail terminal.SessionDetail
+		if err := json.Unmarshal(rec.Body.Bytes(), &detail); err != nil {
+			t.Fatalf("failed to decode session detail: %v", err)
+		}
+		return detail
+	}
+
+	detail := getDetail()
+	if detail.ID != sessionID || len(detail.Clients) != 1 {
+		t.Fatalf("expected one client on %s, got %+v", sessionID, detail)
+	}
+	client := detail.Clients[0]
+	if client.Name != "phone" || client.UserAgent != "test-agent" || client.RemoteIP != "127.0.0.1" {
+		t.Fatalf("unexpected client: %+v", client)
+	}
+
+	// Demo visitors never see who else is attached, or where the session runs
+	demoReq := httptest.NewRequest(http.MethodGet, "/api/sessions/"+sessionID, nil)
+	demoReq = demoReq.WithContext(context.WithValue(demoReq.Context(), demoVisitorKey{}, true))
+	rec := httptest.NewRecorder()
+	handleSessionByID(rec, demoReq)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200 for a demo visitor, got %d: %s", rec.Code, rec.Body.String())
+	}
+	for _, field := range []string{"remote_ip", "user_agent", "working_directory", "current_directory", `"process"`} {
+		if strings.Contains(rec.Body.String(), field) {
+			t.Errorf("expected a demo visitor not to see %s, got %s", field, rec.Body.String())
+		}
+	}
+
+	rec = httptest.NewRecorder()
+	handleSessionByID(rec, httptest.NewRequest(http.MethodDelete, "/api/sessions/"+sessionID+"/clients/unknown", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("expected status 404 for unknown client, got %d", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	handleSessionByID(rec, httptest.NewRequest(http.MethodDelete, "/api/sessions/"+sessionID+"/clients/"+client.ID, nil))
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("expected status 204, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if clients := getDetail().Clients; len(clients) != 0 {
+		t.Fatalf("expected no clients after kick, got %+v", clients)
+	}
+
+	// The kicked connection is closed
+	_ = conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	for {
+		if _, _, err := conn.ReadMessage(); err != nil {
+			var netErr net.Error
+			if errors.As(err, &netErr) && netErr.Timeout() {
+				t.Fatal("expected kicked connection to be closed")
+			}
+			break
+		}
+	}
+
+	rec = httptest.NewRecorder()
+	handleSessionByID(rec, httptest.NewRequest(http.MethodGet, "/api/sessions/missing", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("expected status 404 for missing session, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,290 @@
+package server
+
+import (
+	"compress/gzip"
+	"io"
+	"io/fs"
+	"mime"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// compressMinSize is the smallest response body worth compressing
+const compressMinSize = 1024
+
+// uncompressedPrefixes are the paths whose responses are never compressed:
+// WebSocket upgrades, terminal streams, WebDAV and proxied session ports,
+// which carry their own encoding, and downloads, whose Content-Length and
+// byte offsets must match the file for interrupted downloads to resume
+var uncompressedPrefixes = []string{"/ws/", "/sse/", "/dav/", "/proxy/", "/api/download"}
+
+// precompressedEncodings are the encodings of the copies written next to the
+// embedded assets at build time, most preferred first
+var precompressedEncodings = []struct {
+	name   string
+	suffix string
+}{
+	{"br", ".br"},
+	{"gzip", ".gz"},
+}
+
+// gzipWriters are reused across responses, as each holds sizable buffers
+var gzipWriters = sync.Pool{
+	New: func() any {
+		w, _ := gzip.NewWriterLevel(io.Discard, gzip.DefaultCompression)
+		return w
+	},
+}
+
+// acceptsEncoding reports whether an Accept-Encoding header allows encoding,
+// by name or through "*", with a non-zero quality
+func acceptsEncoding(header, encoding string) bool {
+	accepted := false
+	for _, part := range strings.Split(header, ",") {
+		name, params, _ := strings.Cut(part, ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name != encoding && name != "*" {
+			continue
+		}
+		ok := true
+		for _, param := range strings.Split(params, ";") {
+			key, value, _ := strings.Cut(param, "=")
+			if strings.TrimSpace(key) != "q" {
+				continue
+			}
+			q, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+			ok = err == nil && q > 0
+		}
+		// An entry naming the encoding wins over "*"
+		if name == encoding {
+			return ok
+		}
+		accepted = ok
+	}
+	return accepted
+}
+
+// isCompressibleType reports whether a response of contentType shrinks when
+// compressed: text other than event streams, JSON, JavaScript and SVG
+func isCompressibleType(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	switch {
+	case mediaType == "text/event-stream":
+		return false
+	case strings.HasPrefix(mediaType, "text/"),
+		mediaType == "application/json",
+		strings.HasSuffix(mediaType, "+json"),
+		mediaType == "application/javascript",
+		mediaType == "application/xml",
+		mediaType == "image/svg+xml":
+		return true
+	}
+	return false
+}
+
+// servePrecompressed serves the brotli or gzip copy of an embedded asset
+// written at build time, if the client accepts it and the copy exists. It
+// reports whether it served the asset.
+func servePrecompressed(w http.ResponseWriter, r *http.Request, fsys fs.FS, name string) bool {
+	contentType, ok := rewrittenFiles[name]
+	if !ok {
+		contentType = mime.TypeByExtension(path.Ext(name))
+	}
+	if contentType == "" {
+		return false
+	}
+
+	acceptEncoding := r.Header.Get("Accept-Encoding")
+	for _, encoding := range precompressedEncodings {
+		if !acceptsEncoding(acceptEncoding, encoding.name) {
+			continue
+		}
+		file, err := fsys.Open(name + encoding.suffix)
+		if err != nil {
+			continue
+		}
+		defer file.Close()
+		content, ok := file.(io.ReadSeeker)
+		if !ok {
+			continue
+		}
+
+		h := w.Header()
+		h.Set("Content-Type", contentType)
+		h.Set("Content-Encoding", encoding.name)
+		h.Add("Vary", "Accept-Encoding")
+		if etag := h.Get("ETag"); etag != "" {
+			// Each encoding is a representation of its own
+			h.Set("ETag", strings.TrimSuffix(etag, `"`)+"-"+encoding.name+`"`)
+		}
+		http.ServeContent(w, r, name, time.Time{}, content)
+		return true
+	}
+	return false
+}
+
+// compressMiddleware gzips responses for clients that accept it, when they
+// are of a compressible type and at least compressMinSize bytes. Responses
+// that are already encoded, such as precompressed assets, pass through, as do
+// partial content and the paths in uncompressedPrefixes.
+func compressMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodHead || r.Header.Get("Range") != "" ||
+			r.Header.Get("Upgrade") != "" || !acceptsEncoding(r.Header.Get("Accept-Encoding"), "gzip") {
+			next.ServeHTTP(w, r)
+			return
+		}
+		for _, prefix := range uncompressedPrefixes {
+			if strings.HasPrefix(r.URL.Path, prefix) {
+				next.ServeHTTP(w, r)
+				return
+			}
+		}
+
+		gw := &gzipResponseWriter{ResponseWriter: w}
+		defer gw.finish()
+		next.ServeHTTP(gw, r)
+	})
+}
+
+// gzipResponseWriter compresses a response once it is known to be worth it.
+// Until then the start of the body is held back, as the decision needs the
+// headers and, without a Content-Length, compressMinSize bytes of the body.
+type gzipResponseWriter struct {
+	http.ResponseWriter
+	status  int
+	held    []byte
+	decided bool
+	gz      *gzip.Writer // nil when not compressing
+}
+
+// WriteHeader records the status; it is sent once compression is decided
+func (w *gzipResponseWriter) WriteHeader(status int) {
+	if w.status != 0 {
+		return
+	}
+	if status < http.StatusOK && status != 0 {
+		// Informational responses such as 103 Early Hints go out as is
+		w.ResponseWriter.WriteHeader(status)
+		return
+	}
+	w.status = status
+
+	h := w.Header()
+	if !w.compressible() {
+		w.decide(false)
+		return
+	}
+	h.Add("Vary", "Accept-Encoding")
+	if length, err := strconv.ParseInt(h.Get("Content-Length"), 10, 64); err == nil {
+		w.decide(length >= compressMinSize)
+	}
+}
+
+// compressible reports whether the status and headers allow compression
+func (w *gzipResponseWriter) compressible() bool {
+	switch w.status {
+	case http.StatusNoContent, http.StatusPartialContent, http.StatusNotModified:
+		return false
+	}
+	h := w.Header()
+	if h.Get("Content-Encoding") != "" {
+		return false
+	}
+	// An unset Content-Type is sniffed from the body when deciding
+	return h.Get("Content-Type") == "" || isCompressibleType(h.Get("Content-Type"))
+}
+
+// Write holds the body until compression is decided, then writes it through
+func (w *gzipResponseWriter) Write(p []byte) (int, error) {
+	if w.status == 0 {
+		w.WriteHeader(http.StatusOK)
+	}
+	if !w.decided {
+		w.held = append(w.held, p...)
+		if len(w.held) >= compressMinSize {
+			w.decide(true)
+		}
+		return len(p), nil
+	}
+	if w.gz != nil {
+		return w.gz.Write(p)
+	}
+	return w.ResponseWriter.Write(p)
+}
+
+// Flush decides on what was written so far and flushes it to the client
+func (w *gzipResponseWriter) Flush() {
+	if w.status == 0 {
+		w.WriteHeader(http.StatusOK)
+	}
+	if !w.decided {
+		w.decide(len(w.held) >= compressMinSize)
+	}
+	if w.gz != nil {
+		_ = w.gz.Flush()
+	}
+	http.NewResponseController(w.ResponseWriter).Flush()
+}
+
+// Unwrap returns the underlying writer for http.ResponseController
+func (w *gzipResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// decide sends the headers, compressed or not, and any held body
+func (w *gzipResponseWriter) decide(compress bool) {
+	w.decided = true
+	h := w.Header()
+	if compress && h.Get("Content-Type") == "" {
+		contentType := http.DetectContentType(w.held)
+		h.Set("Content-Type", contentType)
+		compress = isCompressibleType(contentType)
+	}
+	if compress {
+		h.Del("Content-Length")
+		h.Set("Content-Encoding", "gzip")
+		// The compressed body differs byte for byte, so a strong ETag
+		// would be wrong for it
+		if etag := h.Get("ETag"); strings.HasPrefix(etag, `"`) {
+			h.Set("ETag", "W/"+etag)
+		}
+		w.gz = gzipWriters.Get().(*gzip.Writer)
+		w.gz.Reset(w.ResponseWriter)
+	}
+	w.ResponseWriter.WriteHeader(w.status)
+
+	held := w.held
+	w.held = nil
+	if len(held) == 0 {
+		return
+	}
+	if w.gz != nil {
+		_, _ = w.gz.Write(held)
+	} else {
+		_, _ = w.ResponseWriter.Write(held)
+	}
+}
+
+// finish ends the response after the handler returned
+func (w *gzipResponseWriter) finish() {
+	if w.status == 0 {
+		return
+	}
+	if !w.decided {
+		w.decide(len(w.held) >= compressMinSize)
+	}
+	if w.gz != nil {
+		_ = w.gz.Close()
+		w.gz.Reset(io.Discard)
+		gzipWriters.Put(w.gz)
+		w.gz = nil
+	}
+}
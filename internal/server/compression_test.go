@@ -0,0 +1,149 @@
+package server
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestAcceptsEncoding(t *testing.T) {
+	tests := []struct {
+		header   string
+		encoding string
+		want     bool
+	}{
+		{"gzip, deflate, br", "gzip", true},
+		{"gzip, deflate, br", "br", true},
+		{"deflate", "gzip", false},
+		{"", "gzip", false},
+		{"gzip;q=0", "gzip", false},
+		{"GZIP;q=0.5", "gzip", true},
+		{"*", "br", true},
+		{"*, br;q=0", "br", false},
+		{"br;q=0, *", "br", false},
+	}
+	for _, tt := range tests {
+		if got := acceptsEncoding(tt.header, tt.encoding); got != tt.want {
+			t.Errorf("acceptsEncoding(%q, %q) = %v, want %v", tt.header, tt.encoding, got, tt.want)
+		}
+	}
+}
+
+func TestCompressMiddleware(t *testing.T) {
+	large := `{"data":"` + strings.Repeat("x", 4096) + `"}`
+	handler := compressMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/small":
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = io.WriteString(w, `{"ok":true}`)
+		case "/api/image":
+			w.Header().Set("Content-Type", "image/png")
+			_, _ = io.WriteString(w, large)
+		case "/api/stream":
+			w.Header().Set("Content-Type", "text/event-stream")
+			_, _ = io.WriteString(w, large)
+		case "/api/encoded":
+			w.Header().Set("Content-Type", "application/json")
+			w.Header().Set("Content-Encoding", "br")
+			_, _ = io.WriteString(w, large)
+		case "/api/empty":
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.Header().Set("Content-Type", "application/json")
+			w.Header().Set("ETag", `"abc"`)
+			_, _ = io.WriteString(w, large[:100])
+			_, _ = io.WriteString(w, large[100:])
+		}
+	}))
+	serve := func(path, acceptEncoding string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		if acceptEncoding != "" {
+			req.Header.Set("Accept-Encoding", acceptEncoding)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := serve("/api/large", "gzip, br")
+	if rec.Header().Get("Content-Encoding") != "gzip" {
+		t.Fatalf("expected a gzipped response, got Content-Encoding %q", rec.Header().Get("Content-Encoding"))
+	}
+	if rec.Header().Get("Vary") != "Accept-Encoding" {
+		t.Errorf("expected Vary: Accept-Encoding, got %q", rec.Header().Get("Vary"))
+	}
+	if rec.Header().Get("ETag") != `W/"abc"` {
+		t.Errorf("expected the ETag to turn weak, got %q", rec.Header().Get("ETag"))
+	}
+	gz, err := gzip.NewReader(rec.Body)
+	if err != nil {
+		t.Fatalf("failed to read gzip body: %v", err)
+	}
+	body, err := io.ReadAll(gz)
+	if err != nil || string(body) != large {
+		t.Errorf("expected the decompressed body to match, got %d bytes, err %v", len(body), err)
+	}
+
+	if rec := serve("/api/large", ""); rec.Header().Get("Content-Encoding") != "" || rec.Body.String() != large {
+		t.Errorf("expected an uncompressed body without Accept-Encoding, got %q", rec.Header().Get("Content-Encoding"))
+	}
+	for _, path := range []string{"/api/small", "/api/image", "/api/stream", "/api/encoded", "/api/empty", "/ws/abc", "/sse/abc"} {
+		rec := serve(path, "gzip")
+		if encoding := rec.Header().Get("Content-Encoding"); encoding == "gzip" {
+			t.Errorf("%s: expected no gzip, got Content-Encoding %q", path, encoding)
+		}
+	}
+}
+
+func TestServeStaticPrecompressed(t *testing.T) {
+	basePath = ""
+	fsys := fstest.MapFS{
+		"index.html":          {Data: []byte("<html></html>")},
+		"assets/app.js":       {Data: []byte("console.log('plain')")},
+		"assets/app.js.br":    {Data: []byte("brotli bytes")},
+		"assets/app.js.gz":    {Data: []byte("gzip bytes")},
+		"assets/style.css":    {Data: []byte("body{}")},
+		"assets/style.css.gz": {Data: []byte("gzip css")},
+	}
+	fileServer := http.FileServer(http.FS(fsys))
+	serve := func(path, acceptEncoding string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("Accept-Encoding", acceptEncoding)
+		rec := httptest.NewRecorder()
+		serveStatic(rec, req, fsys, fileServer)
+		return rec
+	}
+
+	tests := []struct {
+		path           string
+		acceptEncoding string
+		wantEncoding   string
+		wantBody       string
+	}{
+		{"/assets/app.js", "gzip, deflate, br", "br", "brotli bytes"},
+		{"/assets/app.js", "gzip", "gzip", "gzip bytes"},
+		{"/assets/app.js", "identity", "", "console.log('plain')"},
+		{"/assets/style.css", "br, gzip", "gzip", "gzip css"},
+		{"/", "br, gzip", "", "<html></html>"},
+	}
+	for _, tt := range tests {
+		rec := serve(tt.path, tt.acceptEncoding)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s: expected 200, got %d", tt.path, rec.Code)
+		}
+		if got := rec.Header().Get("Content-Encoding"); got != tt.wantEncoding {
+			t.Errorf("%s with %q: expected Content-Encoding %q, got %q", tt.path, tt.acceptEncoding, tt.wantEncoding, got)
+		}
+		if rec.Body.String() != tt.wantBody {
+			t.Errorf("%s with %q: expected body %q, got %q", tt.path, tt.acceptEncoding, tt.wantBody, rec.Body.String())
+		}
+		if tt.wantEncoding != "" && !strings.HasPrefix(rec.Header().Get("Content-Type"), "text/javascript") &&
+			!strings.HasPrefix(rec.Header().Get("Content-Type"), "text/css") {
+			t.Errorf("%s: expected the original file's Content-Type, got %q", tt.path, rec.Header().Get("Content-Type"))
+		}
+	}
+}
@@ -0,0 +1,160 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/iwanhae/terminal-hub/credstore"
+)
+
+// credentialStore holds SSH keys and secrets, nil when no master key is configured
+var credentialStore *credstore.Store
+
+// createCredentialRequest is the body of POST /api/credentials
+type createCredentialRequest struct {
+	Name        string `json:"name"`
+	Type        string `json:"type"`
+	Description string `json:"description,omitempty"`
+	Value       string `json:"value"`
+}
+
+// updateCredentialRequest is the body of PUT /api/credentials/:name
+type updateCredentialRequest struct {
+	Description *string `json:"description,omitempty"`
+	Value       *string `json:"value,omitempty"` // replaces the value when set
+}
+
+// listCredentialsResponse lists credentials without their values
+type listCredentialsResponse struct {
+	Credentials []credstore.Credential `json:"credentials"`
+}
+
+// handleCredentials handles GET (list) and POST (create) /api/credentials
+func handleCredentials(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		writeCredentialJSON(w, http.StatusOK, listCredentialsResponse{Credentials: credentialStore.List()})
+
+	case http.MethodPost:
+		var req createCredentialRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			log.Printf("Error decoding request: %v", err)
+			writeError(w, http.StatusBadRequest, errCodeInvalidJSON, "Bad request")
+			return
+		}
+
+		if err := validateCreateCredentialRequest(req); err != nil {
+			writeError(w, http.StatusBadRequest, errCodeInvalidRequest, err.Error())
+			return
+		}
+
+		cred, err := credentialStore.Create(req.Name, req.Type, req.Description, []byte(req.Value))
+		if err != nil {
+			log.Printf("Error creating credential: %v", err)
+			writeCredentialError(w, err)
+			return
+		}
+		log.Printf("Credential %q (%s) created", cred.Name, cred.Type)
+		writeCredentialJSON(w, http.StatusCreated, cred)
+
+	default:
+		writeError(w, http.StatusMethodNotAllowed, errCodeMethodNotAllowed, "Method not allowed")
+	}
+}
+
+// handleCredentialByName handles GET, PUT and DELETE /api/credentials/:name.
+// Values are write-only: responses never include them.
+func handleCredentialByName(w http.ResponseWriter, r *http.Request) {
+	name := strings.TrimPrefix(r.URL.Path, "/api/credentials/")
+	if name == "" || strings.Contains(name, "/") {
+		writeError(w, http.StatusBadRequest, errCodeInvalidRequest, "Credential name is required")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		cred, err := credentialStore.Get(name)
+		if err != nil {
+			writeCredentialError(w, err)
+			return
+		}
+		writeCredentialJSON(w, http.StatusOK, cred)
+
+	case http.MethodPut:
+		var req updateCredentialRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			log.Printf("Error decoding request: %v", err)
+			writeError(w, http.StatusBadRequest, errCodeInvalidJSON, "Bad request")
+			return
+		}
+		var value []byte
+		if req.Value != nil {
+			existing, err := credentialStore.Get(name)
+			if err != nil {
+				writeCredentialError(w, err)
+				return
+			}
+			value = []byte(*req.Value)
+			if err := credstore.ValidateValue(existing.Type, value); err != nil {
+				writeError(w, http.StatusBadRequest, errCodeInvalidRequest, err.Error())
+				return
+			}
+		}
+
+		cred, err := credentialStore.Update(name, req.Description, value)
+		if err != nil {
+			log.Printf("Error updating credential: %v", err)
+			writeCredentialError(w, err)
+			return
+		}
+		log.Printf("Credential %q updated", name)
+		writeCredentialJSON(w, http.StatusOK, cred)
+
+	case http.MethodDelete:
+		if err := credentialStore.Delete(name); err != nil {
+			log.Printf("Error deleting credential: %v", err)
+			writeCredentialError(w, err)
+			return
+		}
+		log.Printf("Credential %q deleted", name)
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		writeError(w, http.StatusMethodNotAllowed, errCodeMethodNotAllowed, "Method not allowed")
+	}
+}
+
+// validateCreateCredentialRequest checks a create request before it reaches the store
+func validateCreateCredentialRequest(req createCredentialRequest) error {
+	if err := credstore.ValidateName(req.Name); err != nil {
+		return err
+	}
+	if err := credstore.ValidateType(req.Type); err != nil {
+		return err
+	}
+	return credstore.ValidateValue(req.Type, []byte(req.Value))
+}
+
+// writeCredentialError maps credential store errors to status codes. Requests
+// are validated beforehand, so other errors are storage failures.
+func writeCredentialError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, credstore.ErrNotFound):
+		writeError(w, http.StatusNotFound, errCodeCredentialNotFound, err.Error())
+	case errors.Is(err, credstore.ErrExists):
+		writeError(w, http.StatusConflict, errCodeConflict, err.Error())
+	default:
+		writeError(w, http.StatusInternalServerError, errCodeInternal, "Failed to save credential")
+	}
+}
+
+func writeCredentialJSON(w http.ResponseWriter, statusCode int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		log.Printf("Error encoding credential response: %v", err)
+	}
+}
@@ -0,0 +1,97 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/iwanhae/terminal-hub/credstore"
+)
+
+func TestCredentialHandlersNeverReturnValues(t *testing.T) {
+	store, err := credstore.Open(filepath.Join(t.TempDir(), "secrets.json"), []byte("0123456789abcdef0123456789abcdef"))
+	if err != nil {
+		t.Fatalf("failed opening store: %v", err)
+	}
+	previous := credentialStore
+	credentialStore = store
+	t.Cleanup(func() { credentialStore = previous })
+
+	body := `{"name":"db-password","type":"secret","description":"database","value":"hunter2-top-secret"}`
+	rec := httptest.NewRecorder()
+	handleCredentials(rec, httptest.NewRequest(http.MethodPost, "/api/credentials", strings.NewReader(body)))
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusCreated, rec.Code, rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	handleCredentials(rec, httptest.NewRequest(http.MethodPost, "/api/credentials", strings.NewReader(body)))
+	if rec.Code != http.StatusConflict {
+		t.Fatalf("expected status %d for duplicate, got %d", http.StatusConflict, rec.Code)
+	}
+
+	for _, path := range []string{"/api/credentials", "/api/credentials/db-password"} {
+		rec = httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		if path == "/api/credentials" {
+			handleCredentials(rec, req)
+		} else {
+			handleCredentialByName(rec, req)
+		}
+		if rec.Code != http.StatusOK {
+			t.Fatalf("GET %s: expected status %d, got %d", path, http.StatusOK, rec.Code)
+		}
+		if strings.Contains(rec.Body.String(), "hunter2") {
+			t.Fatalf("GET %s leaked the credential value: %s", path, rec.Body.String())
+		}
+		if !strings.Contains(rec.Body.String(), `"db-password"`) {
+			t.Fatalf("GET %s: expected credential metadata, got %s", path, rec.Body.String())
+		}
+	}
+
+	rec = httptest.NewRecorder()
+	handleCredentialByName(rec, httptest.NewRequest(http.MethodPut, "/api/credentials/db-password", strings.NewReader(`{"value":"rotated"}`)))
+	if rec.Code != http.StatusOK || strings.Contains(rec.Body.String(), "rotated") {
+		t.Fatalf("expected update without value echo, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if value, err := store.Value("db-password", credstore.TypeSecret); err != nil || string(value) != "rotated" {
+		t.Fatalf("expected rotated value, got %q (%v)", value, err)
+	}
+
+	rec = httptest.NewRecorder()
+	handleCredentialByName(rec, httptest.NewRequest(http.MethodDelete, "/api/credentials/db-password", nil))
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("expected status %d, got %d", http.StatusNoContent, rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	handleCredentialByName(rec, httptest.NewRequest(http.MethodGet, "/api/credentials/db-password", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("expected status %d after delete, got %d", http.StatusNotFound, rec.Code)
+	}
+}
+
+func TestCredentialHandlersValidateRequests(t *testing.T) {
+	store, err := credstore.Open(filepath.Join(t.TempDir(), "secrets.json"), []byte("0123456789abcdef0123456789abcdef"))
+	if err != nil {
+		t.Fatalf("failed opening store: %v", err)
+	}
+	previous := credentialStore
+	credentialStore = store
+	t.Cleanup(func() { credentialStore = previous })
+
+	for _, body := range []string{
+		`{"name":"key","type":"ssh_key","value":"not a key"}`,
+		`{"name":"bad name","type":"secret","value":"x"}`,
+		`{"name":"token","type":"password","value":"x"}`,
+		`{"name":"token","type":"secret","value":""}`,
+	} {
+		rec := httptest.NewRecorder()
+		handleCredentials(rec, httptest.NewRequest(http.MethodPost, "/api/credentials", strings.NewReader(body)))
+		if rec.Code != http.StatusBadRequest {
+			t.Fatalf("expected status %d for %s, got %d", http.StatusBadRequest, body, rec.Code)
+		}
+	}
+}
@@ -2,8 +2,10 @@ package server
 
 import (
 	"encoding/json"
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"os"
 	"path/filepath"
 	"strings"
@@ -45,7 +47,15 @@ var _ = Describe("Cron HTTP Handlers", func() {
 		// Create test server with handlers
 		mux := http.NewServeMux()
 		mux.HandleFunc("/api/crons", handleCrons)
+		mux.HandleFunc("/api/crons/history", handleCronAllHistory)
+		mux.HandleFunc("/api/crons/overview", handleCronOverview)
+		mux.HandleFunc("/api/crons/preview", handleCronPreview)
+		mux.HandleFunc("/api/crons/export", handleCronExport)
+		mux.HandleFunc("/api/crons/pause-all", handleCronPauseAll)
+		mux.HandleFunc("/api/crons/resume-all", handleCronResumeAll)
+		mux.HandleFunc("/api/crons/import", handleCronImport)
 		mux.HandleFunc("/api/crons/", handleCronByID)
+		mux.HandleFunc("/api/exec", handleRunCommand)
 		testServer = httptest.NewServer(mux)
 	})
 
@@ -92,6 +102,31 @@ var _ = Describe("Cron HTTP Handlers", func() {
 			Expect(result.Jobs[0].Name).To(Equal("Handler Test"))
 		})
 
+		It("should answer an unchanged list with 304", func() {
+			resp, err := http.Get(testServer.URL + "/api/crons")
+			Expect(err).ToNot(HaveOccurred())
+			resp.Body.Close()
+			etag := resp.Header.Get("ETag")
+			Expect(etag).ToNot(BeEmpty())
+
+			req, _ := http.NewRequest(http.MethodGet, testServer.URL+"/api/crons", nil)
+			req.Header.Set("If-None-Match", etag)
+			resp, err = http.DefaultClient.Do(req)
+			Expect(err).ToNot(HaveOccurred())
+			resp.Body.Close()
+			Expect(resp.StatusCode).To(Equal(http.StatusNotModified))
+
+			_, err = cronManager.Create(cron.CreateCronRequest{
+				Name: "Changed", Schedule: "* * * * *", Command: "echo changed",
+			})
+			Expect(err).ToNot(HaveOccurred())
+			resp, err = http.DefaultClient.Do(req)
+			Expect(err).ToNot(HaveOccurred())
+			resp.Body.Close()
+			Expect(resp.StatusCode).To(Equal(http.StatusOK))
+			Expect(resp.Header.Get("ETag")).ToNot(Equal(etag))
+		})
+
 		It("should return multiple jobs", func() {
 			cronManager.Create(cron.CreateCronRequest{
 				Name: "Job 1", Schedule: "* * * * *", Command: "echo 1",
@@ -578,6 +613,64 @@ var _ = Describe("Cron HTTP Handlers", func() {
 			}
 		})
 
+		It("should page and filter history with query parameters", func() {
+			_, err := cronManager.RunNow(job.ID)
+			Expect(err).ToNot(HaveOccurred())
+
+			resp, err := http.Get(testServer.URL + "/api/crons/" + job.ID + "/history?limit=1&status=success")
+			Expect(err).ToNot(HaveOccurred())
+			defer resp.Body.Close()
+			Expect(resp.StatusCode).To(Equal(http.StatusOK))
+
+			var page cron.HistoryPage
+			Expect(json.NewDecoder(resp.Body).Decode(&page)).To(Succeed())
+			Expect(page.Total).To(BeNumerically(">=", 2))
+			Expect(page.Limit).To(Equal(1))
+			Expect(page.Executions).To(HaveLen(1))
+
+			resp, err = http.Get(testServer.URL + "/api/crons/" + job.ID + "/history?status=failed")
+			Expect(err).ToNot(HaveOccurred())
+			defer resp.Body.Close()
+			Expect(json.NewDecoder(resp.Body).Decode(&page)).To(Succeed())
+			Expect(page.Total).To(Equal(0))
+			Expect(page.Executions).To(BeEmpty())
+		})
+
+		It("should reject invalid query parameters", func() {
+			resp, err := http.Get(testServer.URL + "/api/crons/" + job.ID + "/history?limit=abc")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(resp.StatusCode).To(Equal(http.StatusBadRequest))
+
+			resp, err = http.Get(testServer.URL + "/api/crons/" + job.ID + "/history?status=unknown")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(resp.StatusCode).To(Equal(http.StatusBadRequest))
+		})
+
+		It("should return history of all jobs", func() {
+			other, err := cronManager.Create(cron.CreateCronRequest{
+				Name:     "Other History",
+				Schedule: "* * * * *",
+				Command:  "echo other",
+			})
+			Expect(err).ToNot(HaveOccurred())
+			_, err = cronManager.RunNow(other.ID)
+			Expect(err).ToNot(HaveOccurred())
+
+			resp, err := http.Get(testServer.URL + "/api/crons/history")
+			Expect(err).ToNot(HaveOccurred())
+			defer resp.Body.Close()
+			Expect(resp.StatusCode).To(Equal(http.StatusOK))
+
+			var page cron.HistoryPage
+			Expect(json.NewDecoder(resp.Body).Decode(&page)).To(Succeed())
+			Expect(page.Total).To(BeNumerically(">=", 2))
+			jobIDs := make([]string, 0, len(page.Executions))
+			for _, exec := range page.Executions {
+				jobIDs = append(jobIDs, exec.JobID)
+			}
+			Expect(jobIDs).To(ContainElements(job.ID, other.ID))
+		})
+
 		It("should return 404 for non-existent job", func() {
 			resp, err := http.Get(testServer.URL + "/api/crons/non-existent/history")
 			Expect(err).ToNot(HaveOccurred())
@@ -596,6 +689,323 @@ var _ = Describe("Cron HTTP Handlers", func() {
 		})
 	})
 
+	Describe("GET /api/crons/overview", func() {
+		It("should summarize jobs, recent runs and upcoming runs", func() {
+			job, err := cronManager.Create(cron.CreateCronRequest{
+				Name: "Every Minute", Schedule: "* * * * *", Command: "echo ok", Enabled: true,
+			})
+			Expect(err).ToNot(HaveOccurred())
+			_, err = cronManager.Create(cron.CreateCronRequest{
+				Name: "Off", Schedule: "0 0 * * *", Command: "echo off",
+			})
+			Expect(err).ToNot(HaveOccurred())
+			_, err = cronManager.RunNow(job.ID)
+			Expect(err).ToNot(HaveOccurred())
+
+			resp, err := http.Get(testServer.URL + "/api/crons/overview")
+			Expect(err).ToNot(HaveOccurred())
+			defer resp.Body.Close()
+			Expect(resp.StatusCode).To(Equal(http.StatusOK))
+
+			var overview cron.CronOverview
+			Expect(json.NewDecoder(resp.Body).Decode(&overview)).To(Succeed())
+			Expect(overview.Jobs).To(Equal(cron.CronJobCounts{Total: 2, Enabled: 1, Disabled: 1}))
+			Expect(overview.Last24h.Total).To(Equal(1))
+			Expect(overview.Last24h.SuccessRate).To(Equal(1.0))
+			Expect(overview.Running).To(BeEmpty())
+			Expect(overview.Upcoming).To(HaveLen(cron.OverviewUpcomingCount))
+			Expect(overview.Upcoming[0].JobName).To(Equal("Every Minute"))
+		})
+
+		It("should reject non-GET methods", func() {
+			resp, err := http.Post(testServer.URL+"/api/crons/overview", "application/json", nil)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(resp.StatusCode).To(Equal(http.StatusMethodNotAllowed))
+		})
+	})
+
+	Describe("POST /api/crons/pause-all and /api/crons/resume-all", func() {
+		listSuspended := func() bool {
+			resp, err := http.Get(testServer.URL + "/api/crons")
+			Expect(err).ToNot(HaveOccurred())
+			defer resp.Body.Close()
+			var result cron.ListCronsResponse
+			Expect(json.NewDecoder(resp.Body).Decode(&result)).To(Succeed())
+			return result.Suspended
+		}
+
+		It("should toggle maintenance mode", func() {
+			Expect(listSuspended()).To(BeFalse())
+
+			resp, err := http.Post(testServer.URL+"/api/crons/pause-all", "application/json", nil)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(resp.StatusCode).To(Equal(http.StatusOK))
+			Expect(listSuspended()).To(BeTrue())
+
+			resp, err = http.Post(testServer.URL+"/api/crons/resume-all", "application/json", nil)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(resp.StatusCode).To(Equal(http.StatusOK))
+			Expect(listSuspended()).To(BeFalse())
+		})
+
+		It("should reject non-POST methods", func() {
+			resp, err := http.Get(testServer.URL + "/api/crons/pause-all")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(resp.StatusCode).To(Equal(http.StatusMethodNotAllowed))
+		})
+	})
+
+	Describe("Import and export", func() {
+		It("should import a crontab and export it as JSON", func() {
+			resp, err := http.Post(
+				testServer.URL+"/api/crons/import",
+				"text/plain",
+				strings.NewReader("# name: Cleanup\n0 3 * * * rm -rf /tmp/cache\n@daily echo daily\n"),
+			)
+			Expect(err).ToNot(HaveOccurred())
+			defer resp.Body.Close()
+			Expect(resp.StatusCode).To(Equal(http.StatusCreated))
+
+			var imported cron.ImportCronsResponse
+			Expect(json.NewDecoder(resp.Body).Decode(&imported)).To(Succeed())
+			Expect(imported.Jobs).To(HaveLen(2))
+
+			resp, err = http.Get(testServer.URL + "/api/crons/export")
+			Expect(err).ToNot(HaveOccurred())
+			defer resp.Body.Close()
+			Expect(resp.StatusCode).To(Equal(http.StatusOK))
+
+			var export cron.CronExport
+			Expect(json.NewDecoder(resp.Body).Decode(&export)).To(Succeed())
+			Expect(export.Jobs).To(HaveLen(2))
+			Expect(export.Jobs[0].Name).To(Equal("Cleanup"))
+			Expect(export.Jobs[0].Schedule).To(Equal("0 3 * * *"))
+		})
+
+		It("should import JSON and export a crontab", func() {
+			body := `{"jobs":[{"name":"Report","schedule":"0 9 * * 1","command":"make report","enabled":true}]}`
+			resp, err := http.Post(testServer.URL+"/api/crons/import", "application/json", strings.NewReader(body))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(resp.StatusCode).To(Equal(http.StatusCreated))
+
+			resp, err = http.Get(testServer.URL + "/api/crons/export?format=crontab")
+			Expect(err).ToNot(HaveOccurred())
+			defer resp.Body.Close()
+			Expect(resp.Header.Get("Content-Type")).To(HavePrefix("text/plain"))
+
+			text, err := io.ReadAll(resp.Body)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(text)).To(ContainSubstring("# name: Report\n0 9 * * 1 make report\n"))
+		})
+
+		It("should reject invalid imports without creating jobs", func() {
+			resp, err := http.Post(
+				testServer.URL+"/api/crons/import",
+				"text/plain",
+				strings.NewReader("0 3 * * * echo ok\nbogus line\n"),
+			)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(resp.StatusCode).To(Equal(http.StatusBadRequest))
+			Expect(cronManager.GetJobCount()).To(Equal(0))
+		})
+
+		It("should reject unknown formats", func() {
+			resp, err := http.Get(testServer.URL + "/api/crons/export?format=yaml")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(resp.StatusCode).To(Equal(http.StatusBadRequest))
+		})
+	})
+
+	Describe("GET /api/crons/preview", func() {
+		It("should return upcoming runs for a valid schedule", func() {
+			resp, err := http.Get(testServer.URL + "/api/crons/preview?schedule=" + url.QueryEscape("*/5 * * * *") + "&count=3")
+			Expect(err).ToNot(HaveOccurred())
+			defer resp.Body.Close()
+			Expect(resp.StatusCode).To(Equal(http.StatusOK))
+
+			var result cron.PreviewScheduleResponse
+			Expect(json.NewDecoder(resp.Body).Decode(&result)).To(Succeed())
+			Expect(result.Valid).To(BeTrue())
+			Expect(result.Description).To(Equal("Every 5 minutes"))
+			Expect(result.NextRuns).To(HaveLen(3))
+		})
+
+		It("should report invalid schedules", func() {
+			resp, err := http.Get(testServer.URL + "/api/crons/preview?schedule=" + url.QueryEscape("61 * * * *"))
+			Expect(err).ToNot(HaveOccurred())
+			defer resp.Body.Close()
+			Expect(resp.StatusCode).To(Equal(http.StatusOK))
+
+			var result cron.PreviewScheduleResponse
+			Expect(json.NewDecoder(resp.Body).Decode(&result)).To(Succeed())
+			Expect(result.Valid).To(BeFalse())
+			Expect(result.Error).ToNot(BeEmpty())
+		})
+
+		It("should reject a missing schedule or bad count", func() {
+			resp, err := http.Get(testServer.URL + "/api/crons/preview")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(resp.StatusCode).To(Equal(http.StatusBadRequest))
+
+			resp, err = http.Get(testServer.URL + "/api/crons/preview?schedule=" + url.QueryEscape("* * * * *") + "&count=500")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(resp.StatusCode).To(Equal(http.StatusBadRequest))
+		})
+	})
+
+	Describe("GET /api/crons/:id/executions/:execId/stream", func() {
+		var job *cron.CronJob
+
+		BeforeEach(func() {
+			var err error
+			job, err = cronManager.Create(cron.CreateCronRequest{
+				Name:     "Stream Test",
+				Schedule: "0 0 1 1 *",
+				Command:  "echo first; sleep 0.5; echo second",
+			})
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("should stream output of a running execution", func() {
+			go cronManager.RunNow(job.ID)
+
+			var executionID string
+			Eventually(func() int {
+				resp, err := http.Get(testServer.URL + "/api/crons/" + job.ID + "/executions")
+				if err != nil {
+					return 0
+				}
+				defer resp.Body.Close()
+				var result struct {
+					Executions []cron.LiveExecution `json:"executions"`
+				}
+				json.NewDecoder(resp.Body).Decode(&result)
+				if len(result.Executions) > 0 {
+					executionID = result.Executions[0].ExecutionID
+				}
+				return len(result.Executions)
+			}, "2s", "10ms").Should(Equal(1))
+
+			resp, err := http.Get(testServer.URL + "/api/crons/" + job.ID + "/executions/" + executionID + "/stream")
+			Expect(err).ToNot(HaveOccurred())
+			defer resp.Body.Close()
+			Expect(resp.StatusCode).To(Equal(http.StatusOK))
+			Expect(resp.Header.Get("Content-Type")).To(Equal("text/event-stream"))
+
+			body, err := io.ReadAll(resp.Body)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(body)).To(ContainSubstring("event: output"))
+			Expect(string(body)).To(ContainSubstring("second"))
+			Expect(string(body)).To(ContainSubstring("event: done"))
+			Expect(string(body)).To(ContainSubstring(`"execution_id":"` + executionID + `"`))
+		})
+
+		It("should replay output of a finished execution", func() {
+			result, err := cronManager.RunNow(job.ID)
+			Expect(err).ToNot(HaveOccurred())
+
+			resp, err := http.Get(testServer.URL + "/api/crons/" + job.ID + "/executions/" + result.ExecutionID + "/stream")
+			Expect(err).ToNot(HaveOccurred())
+			defer resp.Body.Close()
+			Expect(resp.StatusCode).To(Equal(http.StatusOK))
+
+			body, err := io.ReadAll(resp.Body)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(body)).To(ContainSubstring(`data: "first\nsecond\n"`))
+			Expect(string(body)).To(ContainSubstring("event: done"))
+		})
+
+		It("should return 404 for unknown executions", func() {
+			resp, err := http.Get(testServer.URL + "/api/crons/" + job.ID + "/executions/exec_missing/stream")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(resp.StatusCode).To(Equal(http.StatusNotFound))
+		})
+	})
+
+	Describe("POST /api/exec", func() {
+		It("should run a one-off command, stream it and keep it in the history", func() {
+			resp, err := http.Post(testServer.URL+"/api/exec", "application/json", strings.NewReader(`{"command":"echo one-off","shell":"/bin/sh"}`))
+			Expect(err).ToNot(HaveOccurred())
+			defer resp.Body.Close()
+			Expect(resp.StatusCode).To(Equal(http.StatusAccepted))
+			var live cron.LiveExecution
+			Expect(json.NewDecoder(resp.Body).Decode(&live)).To(Succeed())
+			Expect(live.JobID).To(Equal(cron.AdHocJobID))
+
+			stream, err := http.Get(testServer.URL + "/api/crons/adhoc/executions/" + live.ExecutionID + "/stream")
+			Expect(err).ToNot(HaveOccurred())
+			defer stream.Body.Close()
+			body, err := io.ReadAll(stream.Body)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(body)).To(ContainSubstring("one-off"))
+			Expect(string(body)).To(ContainSubstring("event: done"))
+
+			history, err := http.Get(testServer.URL + "/api/crons/adhoc/history")
+			Expect(err).ToNot(HaveOccurred())
+			defer history.Body.Close()
+			var result cron.GetHistoryResponse
+			Expect(json.NewDecoder(history.Body).Decode(&result)).To(Succeed())
+			Expect(result.Executions).To(HaveLen(1))
+			Expect(result.Executions[0].ExecutionID).To(Equal(live.ExecutionID))
+		})
+
+		It("should reject a request without a command", func() {
+			resp, err := http.Post(testServer.URL+"/api/exec", "application/json", strings.NewReader(`{}`))
+			Expect(err).ToNot(HaveOccurred())
+			defer resp.Body.Close()
+			Expect(resp.StatusCode).To(Equal(http.StatusBadRequest))
+		})
+	})
+
+	Describe("GET /api/crons/:id/logs", func() {
+		It("should list and download log files", func() {
+			cronManager.SetJobLogs(cron.NewJobLogs(cron.JobLogConfig{Dir: filepath.Join(tempDir, "logs")}))
+			job, err := cronManager.Create(cron.CreateCronRequest{
+				Name:      "Log Test",
+				Schedule:  "0 0 1 1 *",
+				Command:   "echo logged",
+				LogToFile: true,
+			})
+			Expect(err).ToNot(HaveOccurred())
+			_, err = cronManager.RunNow(job.ID)
+			Expect(err).ToNot(HaveOccurred())
+
+			resp, err := http.Get(testServer.URL + "/api/crons/" + job.ID + "/logs")
+			Expect(err).ToNot(HaveOccurred())
+			defer resp.Body.Close()
+			Expect(resp.StatusCode).To(Equal(http.StatusOK))
+			var result struct {
+				Files []cron.JobLogFile `json:"files"`
+			}
+			Expect(json.NewDecoder(resp.Body).Decode(&result)).To(Succeed())
+			Expect(result.Files).To(HaveLen(1))
+			Expect(result.Files[0].Name).To(Equal(job.ID + ".log"))
+
+			resp, err = http.Get(testServer.URL + "/api/crons/" + job.ID + "/logs/" + job.ID + ".log")
+			Expect(err).ToNot(HaveOccurred())
+			defer resp.Body.Close()
+			Expect(resp.StatusCode).To(Equal(http.StatusOK))
+			Expect(resp.Header.Get("Content-Disposition")).To(ContainSubstring("attachment"))
+			body, err := io.ReadAll(resp.Body)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(body)).To(ContainSubstring("logged"))
+		})
+
+		It("should return 404 for unknown log files", func() {
+			cronManager.SetJobLogs(cron.NewJobLogs(cron.JobLogConfig{Dir: filepath.Join(tempDir, "logs")}))
+			job, err := cronManager.Create(cron.CreateCronRequest{Name: "Log Test", Schedule: "0 0 1 1 *", Command: "echo"})
+			Expect(err).ToNot(HaveOccurred())
+
+			resp, err := http.Get(testServer.URL + "/api/crons/" + job.ID + "/logs/crons.json")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(resp.StatusCode).To(Equal(http.StatusNotFound))
+
+			resp, err = http.Get(testServer.URL + "/api/crons/missing/logs")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(resp.StatusCode).To(Equal(http.StatusNotFound))
+		})
+	})
+
 	Describe("POST /api/crons/:id/enable", func() {
 		var job *cron.CronJob
 
@@ -0,0 +1,261 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/iwanhae/terminal-hub/auth"
+	"github.com/iwanhae/terminal-hub/terminal"
+	"github.com/iwanhae/terminal-hub/webhook"
+	"golang.org/x/net/webdav"
+)
+
+// davRealm names the hub in desktop clients' password prompts
+const davRealm = "terminal-hub"
+
+// davLocks keeps each session's WebDAV locks, which are scoped to paths
+// below the session root
+var davLocks = struct {
+	sync.Mutex
+	bySession map[string]webdav.LockSystem
+}{bySession: make(map[string]webdav.LockSystem)}
+
+// davLockSystem returns the lock system of a session, creating it on first use
+func davLockSystem(sessionID string) webdav.LockSystem {
+	davLocks.Lock()
+	defer davLocks.Unlock()
+	ls, ok := davLocks.bySession[sessionID]
+	if !ok {
+		ls = webdav.NewMemLS()
+		davLocks.bySession[sessionID] = ls
+	}
+	return ls
+}
+
+// sessionFileRoot returns the directory a session's files are served from:
+// its working directory, or the hub's when it has none. SSH sessions have
+// no local files.
+func sessionFileRoot(sess terminal.Session) (string, error) {
+	metadata := sess.GetMetadata()
+	if metadata.Backend == terminal.SessionBackendSSH {
+		return "", errors.New("SSH sessions have no local files")
+	}
+	if metadata.WorkingDirectory != "" {
+		return metadata.WorkingDirectory, nil
+	}
+	return os.Getwd()
+}
+
+// handleDAV serves /dav/:sessionId/ as a read/write WebDAV share of the
+// session's root, so it can be mounted as a network drive
+func handleDAV(w http.ResponseWriter, r *http.Request) {
+	rest := strings.TrimPrefix(r.URL.Path, "/dav/")
+	sessionID, _, hasSlash := strings.Cut(rest, "/")
+	if sessionID == "" {
+		http.Error(w, "Session ID required", http.StatusBadRequest)
+		return
+	}
+	// Mounting clients expect the share to be a directory
+	if !hasSlash {
+		http.Redirect(w, r, basePath+r.URL.Path+"/", http.StatusMovedPermanently)
+		return
+	}
+
+	sess, exists := sessionManager.Get(sessionID)
+	if !exists {
+		http.Error(w, "Session not found", http.StatusNotFound)
+		return
+	}
+	root, err := sessionFileRoot(sess)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if info, err := os.Stat(root); err != nil || !info.IsDir() {
+		http.Error(w, "Session directory not found", http.StatusNotFound)
+		return
+	}
+	switch r.Method {
+	case http.MethodPut, "MKCOL", "COPY", "MOVE":
+		if refuseLowDiskWrite(w) {
+			return
+		}
+	}
+
+	// Response hrefs and Destination headers carry the full path, base path
+	// included
+	prefix := basePath + "/dav/" + sessionID
+	full := new(http.Request)
+	*full = *r
+	fullURL := *r.URL
+	fullURL.Path = basePath + r.URL.Path
+	fullURL.RawPath = ""
+	full.URL = &fullURL
+
+	handler := &webdav.Handler{
+		Prefix:     prefix,
+		FileSystem: newRootedDAVFS(root),
+		LockSystem: davLockSystem(sessionID),
+		Logger: func(r *http.Request, err error) {
+			if err != nil && !errors.Is(err, os.ErrNotExist) {
+				log.Printf("WebDAV %s %s: %v", r.Method, r.URL.Path, err)
+			}
+		},
+	}
+	handler.ServeHTTP(w, full)
+}
+
+// davAuthMiddleware admits WebDAV requests with a login cookie, like the
+// rest of the hub, or with the hub credentials over HTTP Basic
+// authentication, which is what desktop clients mounting a drive send.
+// Failed passwords count towards the login ban.
+func davAuthMiddleware(next http.HandlerFunc, sm *auth.SessionManager, banTracker *loginFail2Ban) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !sm.IsConfigured() {
+			// Demo visitors get no files
+			if demoMode.Enabled {
+				writeError(w, http.StatusForbidden, errCodeDemoReadOnly, "Not available in the read-only demo")
+				return
+			}
+			next(w, r)
+			return
+		}
+		if cookie, err := sessionCookieOf(r); err == nil {
+			if session, valid := sm.ValidateSession(cookie.Value); valid {
+				sm.NoteClientIP(cookie.Value, extractClientIP(r))
+				if sm.MustChangePassword(session.Username) {
+					http.Error(w, "Password change required; log in to the web UI to change it", http.StatusForbidden)
+					return
+				}
+				next(w, r)
+				return
+			}
+		}
+
+		username, password, ok := r.BasicAuth()
+		if !ok {
+			w.Header().Set("WWW-Authenticate", fmt.Sprintf("Basic realm=%q", davRealm))
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		clientIP := extractClientIP(r)
+		if banTracker != nil {
+			if banned, remaining := banTracker.IsBanned(clientIP, time.Now()); banned {
+				logBannedLoginAttempt(clientIP, remaining)
+				http.Error(w, loginBanMessage(remaining), http.StatusTooManyRequests)
+				return
+			}
+		}
+		if !sm.ValidateCredentials(username, password) {
+			publishWebhookEvent(webhook.EventAuthLoginFailed, authWebhookEvent{Username: username, IP: clientIP, Via: "webdav", UserAgent: r.UserAgent()})
+			if banTracker != nil {
+				if banned, remaining := banTracker.RecordFailure(clientIP, time.Now()); banned {
+					logIPBanTriggered(clientIP, remaining)
+					alertLoginBan(clientIP, remaining)
+					http.Error(w, loginBanMessage(remaining), http.StatusTooManyRequests)
+					return
+				}
+			}
+			w.Header().Set("WWW-Authenticate", fmt.Sprintf("Basic realm=%q", davRealm))
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		if banTracker != nil {
+			banTracker.Reset(clientIP)
+		}
+		if sm.MustChangePassword(username) {
+			http.Error(w, "Password change required; log in to the web UI to change it", http.StatusForbidden)
+			return
+		}
+		next(w, r)
+	}
+}
+
+// rootedDAVFS is a webdav.Dir that also refuses paths whose symlinks lead
+// outside the root
+type rootedDAVFS struct {
+	webdav.Dir
+	root string // with symlinks resolved
+}
+
+func newRootedDAVFS(root string) rootedDAVFS {
+	resolved, err := filepath.EvalSymlinks(root)
+	if err != nil {
+		resolved = root
+	}
+	return rootedDAVFS{Dir: webdav.Dir(root), root: filepath.Clean(resolved)}
+}
+
+// check returns os.ErrPermission when name resolves outside the root. A
+// name that does not exist yet is checked through its nearest existing
+// parent.
+func (fs rootedDAVFS) check(name string) error {
+	target := filepath.Join(string(fs.Dir), filepath.FromSlash(path.Clean("/"+name)))
+	var missing []string
+	for {
+		resolved, err := filepath.EvalSymlinks(target)
+		if err == nil {
+			target = filepath.Join(append([]string{resolved}, missing...)...)
+			break
+		}
+		parent := filepath.Dir(target)
+		if parent == target {
+			return os.ErrPermission
+		}
+		missing = append([]string{filepath.Base(target)}, missing...)
+		target = parent
+	}
+	rel, err := filepath.Rel(fs.root, target)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return os.ErrPermission
+	}
+	return nil
+}
+
+func (fs rootedDAVFS) Mkdir(ctx context.Context, name string, perm os.FileMode) error {
+	if err := fs.check(name); err != nil {
+		return err
+	}
+	return fs.Dir.Mkdir(ctx, name, perm)
+}
+
+func (fs rootedDAVFS) OpenFile(ctx context.Context, name string, flag int, perm os.FileMode) (webdav.File, error) {
+	if err := fs.check(name); err != nil {
+		return nil, err
+	}
+	return fs.Dir.OpenFile(ctx, name, flag, perm)
+}
+
+func (fs rootedDAVFS) RemoveAll(ctx context.Context, name string) error {
+	if err := fs.check(name); err != nil {
+		return err
+	}
+	return fs.Dir.RemoveAll(ctx, name)
+}
+
+func (fs rootedDAVFS) Rename(ctx context.Context, oldName, newName string) error {
+	if err := fs.check(oldName); err != nil {
+		return err
+	}
+	if err := fs.check(newName); err != nil {
+		return err
+	}
+	return fs.Dir.Rename(ctx, oldName, newName)
+}
+
+func (fs rootedDAVFS) Stat(ctx context.Context, name string) (os.FileInfo, error) {
+	if err := fs.check(name); err != nil {
+		return nil, err
+	}
+	return fs.Dir.Stat(ctx, name)
+}
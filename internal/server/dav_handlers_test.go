@@ -0,0 +1,209 @@
+package server
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/iwanhae/terminal-hub/auth"
+	"github.com/iwanhae/terminal-hub/terminal"
+)
+
+// createDAVTestSession creates a session whose working directory is a new
+// temporary directory, returning the directory
+func createDAVTestSession(t *testing.T, id string) string {
+	t.Helper()
+	ptyReader, ptyWriter, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("failed to create PTY pipe: %v", err)
+	}
+	t.Cleanup(func() {
+		_ = ptyWriter.Close()
+		_ = ptyReader.Close()
+	})
+
+	root := t.TempDir()
+	sessionManager = terminal.NewSessionManager()
+	t.Cleanup(func() { _ = sessionManager.CloseAll() })
+	if _, err := sessionManager.CreateSession(terminal.SessionConfig{
+		ID:               id,
+		Backend:          terminal.SessionBackendPTY,
+		WorkingDirectory: root,
+		PTYService:       &pipePTYService{reader: ptyReader},
+	}); err != nil {
+		t.Fatalf("failed to create session: %v", err)
+	}
+	return root
+}
+
+func davRequest(t *testing.T, method, url, body string, header map[string]string) *http.Response {
+	t.Helper()
+	req, err := http.NewRequest(method, url, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, value := range header {
+		req.Header.Set(name, value)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("%s %s failed: %v", method, url, err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+	return resp
+}
+
+func TestHandleDAVServesSessionRoot(t *testing.T) {
+	root := createDAVTestSession(t, "dav-session")
+	previousBasePath := basePath
+	basePath = "/hub"
+	t.Cleanup(func() { basePath = previousBasePath })
+
+	server := httptest.NewServer(withBasePath(http.HandlerFunc(handleDAV), basePath))
+	defer server.Close()
+	share := server.URL + "/hub/dav/dav-session/"
+
+	resp := davRequest(t, http.MethodPut, share+"notes.txt", "hello dav", nil)
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("PUT status %d, want 201", resp.StatusCode)
+	}
+	if data, err := os.ReadFile(filepath.Join(root, "notes.txt")); err != nil || string(data) != "hello dav" {
+		t.Fatalf("unexpected file contents %q: %v", data, err)
+	}
+
+	resp = davRequest(t, "PROPFIND", share, "", map[string]string{"Depth": "1"})
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusMultiStatus {
+		t.Fatalf("PROPFIND status %d, want 207", resp.StatusCode)
+	}
+	// Hrefs include the base path so clients can follow them
+	if !strings.Contains(string(body), "<D:href>/hub/dav/dav-session/notes.txt</D:href>") {
+		t.Fatalf("expected notes.txt in listing, got %s", body)
+	}
+
+	resp = davRequest(t, "MOVE", share+"notes.txt", "", map[string]string{"Destination": share + "renamed.txt"})
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("MOVE status %d, want 201", resp.StatusCode)
+	}
+	resp = davRequest(t, http.MethodGet, share+"renamed.txt", "", nil)
+	if body, _ := io.ReadAll(resp.Body); resp.StatusCode != http.StatusOK || string(body) != "hello dav" {
+		t.Fatalf("GET status %d, body %q", resp.StatusCode, body)
+	}
+
+	resp = davRequest(t, http.MethodGet, server.URL+"/hub/dav/missing/", "", nil)
+	if resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("unknown session status %d, want 404", resp.StatusCode)
+	}
+}
+
+func TestHandleDAVRefusesSymlinksOutOfRoot(t *testing.T) {
+	root := createDAVTestSession(t, "dav-escape")
+	outside := t.TempDir()
+	if err := os.WriteFile(filepath.Join(outside, "secret.txt"), []byte("secret"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(outside, filepath.Join(root, "escape")); err != nil {
+		t.Skipf("symlinks are not available: %v", err)
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(handleDAV))
+	defer server.Close()
+	share := server.URL + "/dav/dav-escape/"
+
+	if resp := davRequest(t, http.MethodGet, share+"escape/secret.txt", "", nil); resp.StatusCode == http.StatusOK {
+		t.Fatalf("expected reading through the symlink to be refused")
+	}
+	if resp := davRequest(t, http.MethodPut, share+"escape/new.txt", "x", nil); resp.StatusCode < 400 {
+		t.Fatalf("expected writing through the symlink to be refused, got %d", resp.StatusCode)
+	}
+	if _, err := os.Stat(filepath.Join(outside, "new.txt")); !os.IsNotExist(err) {
+		t.Fatalf("expected no file outside the root, got %v", err)
+	}
+}
+
+func TestDAVAuthMiddleware(t *testing.T) {
+	sm := newTestAuthSessionManager()
+	bans := newLoginFail2Ban(2, time.Minute)
+	handler := davAuthMiddleware(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}, sm, bans)
+
+	serve := func(configure func(r *http.Request)) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("PROPFIND", "/dav/s/", nil)
+		req.RemoteAddr = "192.0.2.10:1234"
+		configure(req)
+		rec := httptest.NewRecorder()
+		handler(rec, req)
+		return rec
+	}
+
+	rec := serve(func(r *http.Request) {})
+	if rec.Code != http.StatusUnauthorized || !strings.HasPrefix(rec.Header().Get("WWW-Authenticate"), "Basic") {
+		t.Fatalf("expected a Basic challenge, got %d %q", rec.Code, rec.Header().Get("WWW-Authenticate"))
+	}
+	if rec := serve(func(r *http.Request) { r.SetBasicAuth("admin", "secret") }); rec.Code != http.StatusNoContent {
+		t.Fatalf("expected Basic credentials to be accepted, got %d", rec.Code)
+	}
+
+	session, err := sm.CreateSession("admin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rec := serve(func(r *http.Request) { r.AddCookie(&http.Cookie{Name: "session_token", Value: session.ID}) }); rec.Code != http.StatusNoContent {
+		t.Fatalf("expected a login cookie to be accepted, got %d", rec.Code)
+	}
+
+	// Wrong passwords count towards the login ban
+	if rec := serve(func(r *http.Request) { r.SetBasicAuth("admin", "wrong") }); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("expected a wrong password to be refused, got %d", rec.Code)
+	}
+	if rec := serve(func(r *http.Request) { r.SetBasicAuth("admin", "wrong") }); rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected the address to be banned, got %d", rec.Code)
+	}
+	if rec := serve(func(r *http.Request) { r.SetBasicAuth("admin", "secret") }); rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected a banned address to be refused, got %d", rec.Code)
+	}
+}
+
+func TestDAVAuthMiddlewareRequiresPasswordChange(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "credentials.json")
+	hash, err := auth.HashPassword("old-secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := auth.AddUser(path, "admin", hash, true); err != nil {
+		t.Fatal(err)
+	}
+	users, err := auth.LoadUsers(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sm := auth.NewSessionManagerFromUsers(users, time.Hour)
+	session, err := sm.CreateSession("admin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	handler := davAuthMiddleware(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}, sm, nil)
+
+	for name, configure := range map[string]func(r *http.Request){
+		"cookie": func(r *http.Request) { r.AddCookie(&http.Cookie{Name: "session_token", Value: session.ID}) },
+		"basic":  func(r *http.Request) { r.SetBasicAuth("admin", "old-secret") },
+	} {
+		req := httptest.NewRequest("PROPFIND", "/dav/s/", nil)
+		configure(req)
+		rec := httptest.NewRecorder()
+		handler(rec, req)
+		if rec.Code != http.StatusForbidden {
+			t.Errorf("%s: expected status 403 until the password is changed, got %d", name, rec.Code)
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/iwanhae/terminal-hub/terminal"
+)
+
+// defaultDemoTag tags the sessions demo visitors may watch unless
+// TERMINAL_HUB_DEMO_TAG names another
+const defaultDemoTag = "demo"
+
+// demoSettings configures the read-only demo started with -demo. Visitors
+// without a login may list and watch the sessions carrying Tag, without
+// typing into them; every other API stays locked. Logged-in users are not
+// affected.
+type demoSettings struct {
+	Enabled bool
+	Tag     string // TERMINAL_HUB_DEMO_TAG
+}
+
+// demoMode holds the demo settings
+var demoMode demoSettings
+
+// getDemoModeFromEnv returns the demo settings, enabled as given by -demo
+func getDemoModeFromEnv(enabled bool) (demoSettings, error) {
+	settings := demoSettings{Enabled: enabled, Tag: defaultDemoTag}
+	if tag := os.Getenv("TERMINAL_HUB_DEMO_TAG"); tag != "" {
+		normalized, err := terminal.NormalizeTags([]string{tag})
+		if err != nil {
+			return settings, fmt.Errorf("TERMINAL_HUB_DEMO_TAG: %w", err)
+		}
+		settings.Tag = normalized[0]
+	}
+	return settings, nil
+}
+
+// demoVisitorKey marks the context of requests made by demo visitors
+type demoVisitorKey struct{}
+
+// isDemoVisitor reports whether r was let through as a demo visitor's
+func isDemoVisitor(r *http.Request) bool {
+	visitor, _ := r.Context().Value(demoVisitorKey{}).(bool)
+	return visitor
+}
+
+// isDemoSession reports whether sessionID is a session demo visitors may watch
+func isDemoSession(sessionID string) bool {
+	sess, ok := sessionManager.Get(sessionID)
+	return ok && slices.Contains(sess.GetMetadata().Tags, demoMode.Tag)
+}
+
+// demoAllows reports whether a demo visitor may make r: load pages, list
+// sessions, and read, render or attach to demo sessions. Only GET and HEAD
+// are allowed.
+func demoAllows(r *http.Request) bool {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		return false
+	}
+	path := r.URL.Path
+	switch {
+	case path == "/api/sessions":
+		return true
+	case strings.HasPrefix(path, "/api/sessions/"):
+		sessionID := strings.TrimSuffix(strings.TrimPrefix(path, "/api/sessions/"), "/")
+		sessionID = strings.TrimSuffix(sessionID, "/snapshot")
+		return !strings.Contains(sessionID, "/") && isDemoSession(sessionID)
+	case strings.HasPrefix(path, "/ws/"):
+		sessionID := strings.TrimSuffix(strings.TrimPrefix(path, "/ws/"), "/")
+		return !strings.Contains(sessionID, "/") && isDemoSession(sessionID)
+	case strings.HasPrefix(path, "/sse/"):
+		sessionID := strings.TrimSuffix(strings.TrimPrefix(path, "/sse/"), "/")
+		return !strings.Contains(sessionID, "/") && isDemoSession(sessionID)
+	}
+	// Everything else but the frontend's pages is off limits
+	for _, prefix := range []string{"/api/", "/ws/", "/sse/", "/proxy/", "/dav/"} {
+		if strings.HasPrefix(path, prefix) {
+			return false
+		}
+	}
+	return true
+}
+
+// redactDemoMetadata strips what demo visitors must not see from a session's
+// metadata: its directories, SSH target, tmux name, processes, repository
+// and exit actions
+func redactDemoMetadata(meta terminal.SessionMetadata) terminal.SessionMetadata {
+	meta.WorkingDirectory = ""
+	meta.CurrentDirectory = ""
+	meta.SSH = nil
+	meta.TmuxSession = ""
+	meta.Process = nil
+	meta.Git = nil
+	meta.OnExit = nil
+	return meta
+}
+
+// serveDemoVisitor passes a request without a login on to next if demo
+// visitors may make it, listing only demo sessions
+func serveDemoVisitor(w http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	if !demoAllows(r) {
+		writeError(w, http.StatusForbidden, errCodeDemoReadOnly, "Not available in the read-only demo; log in for full access")
+		return
+	}
+	if r.URL.Path == "/api/sessions" {
+		query := r.URL.Query()
+		query["tag"] = []string{demoMode.Tag}
+		r.URL.RawQuery = query.Encode()
+	}
+	next(w, r.WithContext(context.WithValue(r.Context(), demoVisitorKey{}, true)))
+}
@@ -0,0 +1,164 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/iwanhae/terminal-hub/auth"
+	"github.com/iwanhae/terminal-hub/terminal"
+)
+
+func TestGetDemoModeFromEnv(t *testing.T) {
+	settings, err := getDemoModeFromEnv(true)
+	if err != nil || settings != (demoSettings{Enabled: true, Tag: defaultDemoTag}) {
+		t.Fatalf("expected the default demo tag, got %+v, %v", settings, err)
+	}
+
+	t.Setenv("TERMINAL_HUB_DEMO_TAG", " showcase ")
+	if settings, err := getDemoModeFromEnv(true); err != nil || settings.Tag != "showcase" {
+		t.Errorf("expected the trimmed tag, got %+v, %v", settings, err)
+	}
+	t.Setenv("TERMINAL_HUB_DEMO_TAG", "not a tag")
+	if _, err := getDemoModeFromEnv(true); err == nil {
+		t.Error("expected an invalid tag to be rejected")
+	}
+}
+
+func TestDemoModeMiddleware(t *testing.T) {
+	basePath = ""
+	savedDemo := demoMode
+	t.Cleanup(func() { demoMode = savedDemo })
+	demoMode = demoSettings{Enabled: true, Tag: "demo"}
+
+	sessionManager = terminal.NewSessionManager()
+	t.Cleanup(func() { _ = sessionManager.CloseAll() })
+	for id, tags := range map[string][]string{"showcase": {"demo"}, "private": nil} {
+		ptyReader, ptyWriter, err := os.Pipe()
+		if err != nil {
+			t.Fatalf("failed to create PTY pipe: %v", err)
+		}
+		t.Cleanup(func() {
+			_ = ptyWriter.Close()
+			_ = ptyReader.Close()
+		})
+		if _, err := sessionManager.CreateSession(terminal.SessionConfig{
+			ID:         id,
+			Name:       id,
+			Tags:       tags,
+			Backend:    terminal.SessionBackendPTY,
+			PTYService: &pipePTYService{reader: ptyReader},
+		}); err != nil {
+			t.Fatalf("failed to create session: %v", err)
+		}
+	}
+
+	sm := auth.NewSessionManager("admin", "secret", time.Hour)
+	var visitor bool
+	var query string
+	handler := sessionAuthMiddleware(func(w http.ResponseWriter, r *http.Request) {
+		visitor = isDemoVisitor(r)
+		query = r.URL.RawQuery
+		w.WriteHeader(http.StatusOK)
+	}, sm)
+
+	for _, tc := range []struct {
+		method, target string
+		allowed        bool
+	}{
+		{http.MethodGet, "/", true},
+		{http.MethodGet, "/sessions/showcase", true},
+		{http.MethodGet, "/api/sessions", true},
+		{http.MethodGet, "/api/sessions/showcase", true},
+		{http.MethodGet, "/api/sessions/showcase/snapshot", true},
+		{http.MethodGet, "/ws/showcase", true},
+		{http.MethodGet, "/sse/showcase", true},
+		{http.MethodGet, "/api/sessions/private", false},
+		{http.MethodGet, "/ws/private", false},
+		{http.MethodGet, "/api/sessions/showcase/history/search", false},
+		{http.MethodPost, "/sse/showcase/input", false},
+		{http.MethodPost, "/api/sessions", false},
+		{http.MethodDelete, "/api/sessions/showcase", false},
+		{http.MethodGet, "/api/files/browse", false},
+		{http.MethodGet, "/api/crons", false},
+		{http.MethodGet, "/ws/events", false},
+		{http.MethodGet, "/proxy/showcase/3000/", false},
+	} {
+		visitor = false
+		rec := httptest.NewRecorder()
+		handler(rec, httptest.NewRequest(tc.method, tc.target, nil))
+		if tc.allowed && (rec.Code != http.StatusOK || !visitor) {
+			t.Errorf("%s %s: expected a demo visitor to be let through, got %d", tc.method, tc.target, rec.Code)
+		}
+		if !tc.allowed && (rec.Code != http.StatusForbidden || !strings.Contains(rec.Body.String(), errCodeDemoReadOnly)) {
+			t.Errorf("%s %s: expected 403 %s, got %d: %s", tc.method, tc.target, errCodeDemoReadOnly, rec.Code, rec.Body.String())
+		}
+	}
+
+	// Visitors only list demo sessions, whatever they ask for
+	rec := httptest.NewRecorder()
+	handler(rec, httptest.NewRequest(http.MethodGet, "/api/sessions?tag=prod", nil))
+	if query != "tag=demo" {
+		t.Errorf("expected the list to be limited to the demo tag, got %q", query)
+	}
+
+	// A login still gets full access
+	session, err := sm.CreateSession("admin")
+	if err != nil {
+		t.Fatalf("failed to create login session: %v", err)
+	}
+	req := httptest.NewRequest(http.MethodPost, "/api/sessions", nil)
+	req.AddCookie(&http.Cookie{Name: sessionCookie.Name, Value: session.ID})
+	rec = httptest.NewRecorder()
+	handler(rec, req)
+	if rec.Code != http.StatusOK || visitor {
+		t.Errorf("expected a logged-in user to have full access, got %d (visitor %v)", rec.Code, visitor)
+	}
+}
+
+func TestRedactDemoMetadata(t *testing.T) {
+	t.Parallel()
+
+	meta := redactDemoMetadata(terminal.SessionMetadata{
+		Name:             "showcase",
+		WorkingDirectory: "/home/admin/project",
+		CurrentDirectory: "/home/admin/project/src",
+		SSH:              &terminal.SSHTarget{Host: "db.internal"},
+		TmuxSession:      "th-showcase",
+		Process:          &terminal.ProcessInfo{},
+		Git:              &terminal.GitInfo{},
+		Tags:             []string{"demo"},
+	})
+	if meta.WorkingDirectory != "" || meta.CurrentDirectory != "" || meta.SSH != nil ||
+		meta.TmuxSession != "" || meta.Process != nil || meta.Git != nil {
+		t.Errorf("expected locations, targets and processes to be redacted, got %+v", meta)
+	}
+	if meta.Name != "showcase" || len(meta.Tags) != 1 {
+		t.Errorf("expected the name and tags to be kept, got %+v", meta)
+	}
+}
+
+func TestAuthStatusReportsDemo(t *testing.T) {
+	savedDemo := demoMode
+	t.Cleanup(func() { demoMode = savedDemo })
+	demoMode = demoSettings{Enabled: true, Tag: "demo"}
+
+	for name, sm := range map[string]*auth.SessionManager{
+		"with credentials":    auth.NewSessionManager("admin", "secret", time.Hour),
+		"without credentials": auth.NewSessionManager("", "", time.Hour),
+	} {
+		rec := httptest.NewRecorder()
+		handleAuthStatus(rec, httptest.NewRequest(http.MethodGet, "/api/auth/status", nil), sm)
+		var status authStatusResponse
+		if err := json.Unmarshal(rec.Body.Bytes(), &status); err != nil {
+			t.Fatalf("%s: failed to decode status: %v", name, err)
+		}
+		if status.Authenticated || !status.Demo {
+			t.Errorf("%s: expected an unauthenticated demo visitor, got %+v", name, status)
+		}
+	}
+}
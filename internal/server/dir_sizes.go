@@ -0,0 +1,107 @@
+package server
+
+import (
+	"io/fs"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+const (
+	// dirSizeCacheTTL is how long a computed directory size is served
+	// before it is computed again
+	dirSizeCacheTTL = 5 * time.Minute
+	// maxDirSizeEntries bounds the cached sizes; expired ones are dropped
+	// first when it is reached
+	maxDirSizeEntries = 4096
+	// dirSizeWorkers bounds the directory walks running at once
+	dirSizeWorkers = 2
+)
+
+// dirSizeCache computes the total size of the files under directories in
+// the background, for GET /api/files/browse?withSizes=true, and keeps the
+// results for a while so listings polled until the sizes are ready stay
+// cheap
+type dirSizeCache struct {
+	mu      sync.Mutex
+	sizes   map[string]dirSize
+	pending map[string]bool
+	workers chan struct{}
+	ttl     time.Duration
+}
+
+type dirSize struct {
+	bytes      int64
+	computedAt time.Time
+}
+
+// browseDirSizes caches directory sizes for the file browser
+var browseDirSizes = newDirSizeCache(dirSizeCacheTTL)
+
+func newDirSizeCache(ttl time.Duration) *dirSizeCache {
+	return &dirSizeCache{
+		sizes:   make(map[string]dirSize),
+		pending: make(map[string]bool),
+		workers: make(chan struct{}, dirSizeWorkers),
+		ttl:     ttl,
+	}
+}
+
+// size returns the cached size of dir. When there is none, or it expired,
+// it starts computing the size and reports false.
+func (c *dirSizeCache) size(dir string) (int64, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	cached, ok := c.sizes[dir]
+	if ok && time.Since(cached.computedAt) < c.ttl {
+		return cached.bytes, true
+	}
+	if !c.pending[dir] {
+		c.pending[dir] = true
+		go c.compute(dir)
+	}
+	return 0, false
+}
+
+// compute walks dir and caches the sum of its files' sizes. Symbolic links
+// are not followed, and what cannot be read is left out.
+func (c *dirSizeCache) compute(dir string) {
+	c.workers <- struct{}{}
+	defer func() { <-c.workers }()
+
+	var total int64
+	_ = filepath.WalkDir(dir, func(_ string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return nil
+		}
+		if info, err := entry.Info(); err == nil && info.Mode().IsRegular() {
+			total += info.Size()
+		}
+		return nil
+	})
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.pending, dir)
+	if len(c.sizes) >= maxDirSizeEntries {
+		c.evictLocked()
+	}
+	c.sizes[dir] = dirSize{bytes: total, computedAt: time.Now()}
+}
+
+// evictLocked drops expired sizes, or some of the others when none expired.
+// Must be called with c.mu held.
+func (c *dirSizeCache) evictLocked() {
+	for dir, cached := range c.sizes {
+		if time.Since(cached.computedAt) >= c.ttl {
+			delete(c.sizes, dir)
+		}
+	}
+	for dir := range c.sizes {
+		if len(c.sizes) < maxDirSizeEntries {
+			break
+		}
+		delete(c.sizes, dir)
+	}
+}
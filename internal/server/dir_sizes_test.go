@@ -0,0 +1,103 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestDirSizeCache(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "a", "b"), 0o755); err != nil {
+		t.Fatalf("failed creating directories: %v", err)
+	}
+	for path, size := range map[string]int{"one.bin": 100, "a/two.bin": 20, "a/b/three.bin": 3} {
+		if err := os.WriteFile(filepath.Join(dir, path), make([]byte, size), 0o644); err != nil {
+			t.Fatalf("failed creating file: %v", err)
+		}
+	}
+	if err := os.Symlink(filepath.Join(dir, "one.bin"), filepath.Join(dir, "a", "link")); err != nil {
+		t.Fatalf("failed creating symlink: %v", err)
+	}
+
+	cache := newDirSizeCache(time.Minute)
+	if _, ready := cache.size(dir); ready {
+		t.Fatal("expected the first lookup to start computing")
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		size, ready := cache.size(dir)
+		if ready {
+			if size != 123 {
+				t.Fatalf("expected 123 bytes, got %d", size)
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("size was not computed")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// Expired sizes are computed again
+	cache.ttl = 0
+	if _, ready := cache.size(dir); ready {
+		t.Error("expected an expired size to be computed again")
+	}
+}
+
+func TestHandleFileBrowseWithSizes(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	if err := os.Mkdir(filepath.Join(dir, "logs"), 0o755); err != nil {
+		t.Fatalf("failed creating subdir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "logs", "app.log"), make([]byte, 2048), 0o644); err != nil {
+		t.Fatalf("failed creating file: %v", err)
+	}
+
+	browse := func(query url.Values) fileBrowseResponse {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		handleFileBrowse(rec, httptest.NewRequest(http.MethodGet, "/api/files/browse?"+query.Encode(), nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected status %d, got %d: %s", http.StatusOK, rec.Code, rec.Body.String())
+		}
+		var response fileBrowseResponse
+		if err := json.NewDecoder(rec.Body).Decode(&response); err != nil {
+			t.Fatalf("failed decoding response: %v", err)
+		}
+		return response
+	}
+
+	if response := browse(url.Values{"path": {dir}}); response.SizesPending || response.Entries[0].Size != 0 {
+		t.Fatalf("expected no sizes without withSizes, got %+v", response)
+	}
+
+	query := url.Values{"path": {dir}, "withSizes": {"true"}}
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		response := browse(query)
+		if !response.SizesPending {
+			if entry := response.Entries[0]; entry.Size != 2048 || entry.SizePending {
+				t.Fatalf("expected 2048 bytes, got %+v", entry)
+			}
+			break
+		}
+		if !response.Entries[0].SizePending {
+			t.Fatalf("expected the entry to be marked pending, got %+v", response.Entries[0])
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("sizes were not computed")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
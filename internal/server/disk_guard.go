@@ -0,0 +1,49 @@
+package server
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/iwanhae/terminal-hub/diskguard"
+	"github.com/iwanhae/terminal-hub/notify"
+	"github.com/iwanhae/terminal-hub/webhook"
+)
+
+// diskGuard pauses uploads, cron history and session archives while the
+// data directory is low on space, nil when the guard is off
+var diskGuard *diskguard.Guard
+
+// reportDiskSpace logs, publishes and notifies a change of the data
+// directory's free space
+func reportDiskSpace(status diskguard.Status) {
+	free, minFree := diskguard.FormatBytes(status.FreeBytes), diskguard.FormatBytes(status.MinFreeBytes)
+	if !status.Low {
+		log.Printf("Disk space recovered: %s free under %s; writes resumed", free, status.Path)
+		publishWebhookEvent(webhook.EventDiskRecovered, status)
+		return
+	}
+
+	log.Printf("Disk space low: %s free under %s, below the %s minimum; pausing uploads, cron history and session archives",
+		free, status.Path, minFree)
+	publishWebhookEvent(webhook.EventDiskLow, status)
+	publishNotification(notify.Notification{
+		Event: notify.EventDiskLow,
+		Title: "Low disk space",
+		Message: fmt.Sprintf("Only %s is free under %s (minimum %s). Uploads, cron history and session archives are paused until space is freed.",
+			free, status.Path, minFree),
+		Tags:     []string{"floppy_disk"},
+		Priority: notify.PriorityHigh,
+	})
+}
+
+// refuseLowDiskWrite replies 507 Insufficient Storage and returns true while
+// the data directory is low on space
+func refuseLowDiskWrite(w http.ResponseWriter) bool {
+	err := diskGuard.Err()
+	if err == nil {
+		return false
+	}
+	writeError(w, http.StatusInsufficientStorage, errCodeLowDiskSpace, err.Error())
+	return true
+}
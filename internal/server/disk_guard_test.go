@@ -0,0 +1,56 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/iwanhae/terminal-hub/diskguard"
+	"github.com/iwanhae/terminal-hub/notify"
+	"github.com/iwanhae/terminal-hub/terminal"
+)
+
+func TestLowDiskSpaceRefusesUploads(t *testing.T) {
+	sent := make(chan notify.Notification, 1)
+	adminEmail = recordingChannel{sent: sent}
+	// No filesystem has this much free space
+	diskGuard = diskguard.New(t.TempDir(), ^uint64(0))
+	diskGuard.SetChangeHandler(reportDiskSpace)
+	t.Cleanup(func() {
+		adminEmail = nil
+		diskGuard = nil
+	})
+	if _, err := diskGuard.Check(time.Now()); err != nil {
+		t.Skipf("free disk space is not available: %v", err)
+	}
+	alert := expectAlert(t, sent, notify.EventDiskLow)
+	if alert.Priority != notify.PriorityHigh || !strings.Contains(alert.Message, "Uploads") {
+		t.Errorf("unexpected alert %+v", alert)
+	}
+
+	dir := t.TempDir()
+	rec := httptest.NewRecorder()
+	handleFileUpload(rec, newUploadRequest(t, dir, "blocked.txt", false, []byte("data")))
+	if rec.Code != http.StatusInsufficientStorage || !strings.Contains(rec.Body.String(), "low disk space") {
+		t.Fatalf("expected status 507 with a clear error, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if _, err := os.Stat(filepath.Join(dir, "blocked.txt")); !os.IsNotExist(err) {
+		t.Fatalf("expected no file to be written, got %v", err)
+	}
+
+	sessionManager = terminal.NewSessionManager()
+	rec = httptest.NewRecorder()
+	handleSystemStats(rec, httptest.NewRequest(http.MethodGet, "/api/system/stats", nil))
+	var resp systemStatsResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode system stats: %v", err)
+	}
+	if resp.DiskGuard == nil || !resp.DiskGuard.Low {
+		t.Fatalf("expected the disk guard to report low space, got %+v", resp.DiskGuard)
+	}
+}
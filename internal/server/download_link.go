@@ -0,0 +1,312 @@
+package server
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Download link lifetimes
+const (
+	defaultDownloadLinkTTL = 10 * time.Minute
+	maxDownloadLinkTTL     = time.Hour
+)
+
+var (
+	errDownloadLinkInvalid = errors.New("invalid download link")
+	errDownloadLinkExpired = errors.New("download link expired")
+	errDownloadLinkUsed    = errors.New("download link already used")
+)
+
+// downloadLinkRequest is the body of POST /api/download/link
+type downloadLinkRequest struct {
+	Path      string `json:"path"`                 // the file or directory
+	Filename  string `json:"filename,omitempty"`   // the name to save as, like ?filename on /api/download
+	ExpiresIn int    `json:"expires_in,omitempty"` // seconds; defaults to 600, at most 3600
+}
+
+// Validate checks the path is absolute and the lifetime is within bounds
+func (req downloadLinkRequest) Validate() error {
+	if strings.TrimSpace(req.Path) == "" {
+		return errors.New("path is required")
+	}
+	if !filepath.IsAbs(req.Path) {
+		return errors.New("path must be absolute")
+	}
+	if req.ExpiresIn < 0 || time.Duration(req.ExpiresIn)*time.Second > maxDownloadLinkTTL {
+		return errors.New("expires_in must be between 1 and 3600 seconds")
+	}
+	return nil
+}
+
+// downloadLinkResponse is the response of POST /api/download/link
+type downloadLinkResponse struct {
+	URL       string `json:"url"`        // absolute, for pasting elsewhere
+	Path      string `json:"path"`       // the same link relative to the host
+	ExpiresAt int64  `json:"expires_at"` // unix timestamp
+}
+
+// downloadLinkClaims are what a download link grants, signed into its token
+type downloadLinkClaims struct {
+	Path      string `json:"p"`
+	Filename  string `json:"f,omitempty"`
+	ExpiresAt int64  `json:"e"` // unix timestamp
+	Nonce     string `json:"n"` // tells links apart, so each can be used once
+}
+
+// downloadLinkSigner issues and redeems download links. Tokens carry their
+// claims and an HMAC of them under a key made at startup, so links die with
+// the process; nonces of used links are kept until the links expire.
+type downloadLinkSigner struct {
+	key []byte
+
+	mu       sync.Mutex
+	redeemed map[string]time.Time // nonce → expiry
+}
+
+var downloadLinks = newDownloadLinkSigner()
+
+func newDownloadLinkSigner() *downloadLinkSigner {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		panic(err)
+	}
+	return &downloadLinkSigner{key: key, redeemed: make(map[string]time.Time)}
+}
+
+func (s *downloadLinkSigner) sign(payload string) string {
+	mac := hmac.New(sha256.New, s.key)
+	mac.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// issue returns a token for path, valid until expiresAt
+func (s *downloadLinkSigner) issue(path, filename string, expiresAt time.Time) (string, error) {
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	claims, err := json.Marshal(downloadLinkClaims{
+		Path:      path,
+		Filename:  filename,
+		ExpiresAt: expiresAt.Unix(),
+		Nonce:     hex.EncodeToString(nonce),
+	})
+	if err != nil {
+		return "", err
+	}
+	payload := base64.RawURLEncoding.EncodeToString(claims)
+	return payload + "." + s.sign(payload), nil
+}
+
+// redeem checks token and returns its claims. It fails with
+// errDownloadLinkUsed once use was called for the link.
+func (s *downloadLinkSigner) redeem(token string, now time.Time) (downloadLinkClaims, error) {
+	payload, signature, ok := strings.Cut(token, ".")
+	if !ok || !hmac.Equal([]byte(signature), []byte(s.sign(payload))) {
+		return downloadLinkClaims{}, errDownloadLinkInvalid
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		return downloadLinkClaims{}, errDownloadLinkInvalid
+	}
+	var claims downloadLinkClaims
+	if err := json.Unmarshal(raw, &claims); err != nil {
+		return downloadLinkClaims{}, errDownloadLinkInvalid
+	}
+	expiresAt := time.Unix(claims.ExpiresAt, 0)
+	if now.After(expiresAt) {
+		return downloadLinkClaims{}, errDownloadLinkExpired
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for nonce, expiry := range s.redeemed {
+		if now.After(expiry) {
+			delete(s.redeemed, nonce)
+		}
+	}
+	if _, used := s.redeemed[claims.Nonce]; used {
+		return downloadLinkClaims{}, errDownloadLinkUsed
+	}
+	return claims, nil
+}
+
+// use uses up the link of claims, so it can no longer be redeemed
+func (s *downloadLinkSigner) use(claims downloadLinkClaims) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.redeemed[claims.Nonce] = time.Unix(claims.ExpiresAt, 0)
+}
+
+// handleDownloadLink handles POST /api/download/link
+func handleDownloadLink(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, errCodeMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	var req downloadLinkRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, errCodeInvalidJSON, "Bad request")
+		return
+	}
+	if err := req.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, errCodeInvalidRequest, err.Error())
+		return
+	}
+
+	cleanPath := filepath.Clean(req.Path)
+	if _, err := os.Stat(cleanPath); os.IsNotExist(err) {
+		writeError(w, http.StatusNotFound, errCodeFileNotFound, "File not found")
+		return
+	} else if err != nil {
+		log.Printf("Error accessing file: %v", err)
+		writeError(w, http.StatusInternalServerError, errCodeInternal, "Failed to access file")
+		return
+	}
+
+	ttl := defaultDownloadLinkTTL
+	if req.ExpiresIn > 0 {
+		ttl = time.Duration(req.ExpiresIn) * time.Second
+	}
+	expiresAt := time.Now().Add(ttl)
+	token, err := downloadLinks.issue(cleanPath, req.Filename, expiresAt)
+	if err != nil {
+		log.Printf("Error issuing download link: %v", err)
+		writeError(w, http.StatusInternalServerError, errCodeInternal, "Failed to create link")
+		return
+	}
+
+	linkPath := basePath + "/api/download/link/" + token
+	scheme := "http"
+	if isSecure(r) {
+		scheme = "https"
+	}
+	log.Printf("Download link issued: path=%s, expires=%s, ip=%s",
+		cleanPath, expiresAt.Format(time.RFC3339), extractClientIP(r))
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(downloadLinkResponse{
+		URL:       scheme + "://" + r.Host + linkPath,
+		Path:      linkPath,
+		ExpiresAt: expiresAt.Unix(),
+	})
+}
+
+// handleDownloadLinkRedeem handles GET and HEAD /api/download/link/:token.
+// The token authorizes the download instead of a login. A GET that delivers
+// the end of the file uses the link up, whether as the whole file or as the
+// Range request resuming an interrupted download; HEAD, failed downloads and
+// ranges short of the end leave it valid until it expires.
+func handleDownloadLinkRedeem(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		writeError(w, http.StatusMethodNotAllowed, errCodeMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	token := strings.TrimPrefix(r.URL.Path, "/api/download/link/")
+	claims, err := downloadLinks.redeem(token, time.Now())
+	switch {
+	case errors.Is(err, errDownloadLinkExpired):
+		writeError(w, http.StatusGone, errCodeDownloadLinkGone, "Download link expired")
+		return
+	case errors.Is(err, errDownloadLinkUsed):
+		writeError(w, http.StatusGone, errCodeDownloadLinkGone, "Download link already used")
+		return
+	case err != nil:
+		writeError(w, http.StatusNotFound, errCodeNotFound, "Download link not found")
+		return
+	}
+
+	query := url.Values{"path": {claims.Path}}
+	if claims.Filename != "" {
+		query.Set("filename", claims.Filename)
+	}
+	download := r.Clone(r.Context())
+	download.URL.Path = "/api/download"
+	download.URL.RawQuery = query.Encode()
+	rw := &downloadLinkResponseWriter{ResponseWriter: w}
+	handleFileDownload(rw, download)
+
+	if r.Method == http.MethodGet && rw.reachedEnd() {
+		downloadLinks.use(claims)
+		log.Printf("Download link used: path=%s, ip=%s", claims.Path, extractClientIP(r))
+	}
+}
+
+// downloadLinkResponseWriter follows a download through a link, to tell
+// whether the end of the file reached the client
+type downloadLinkResponseWriter struct {
+	http.ResponseWriter
+	status  int
+	written int64
+	err     error
+}
+
+func (w *downloadLinkResponseWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *downloadLinkResponseWriter) Write(p []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	n, err := w.ResponseWriter.Write(p)
+	w.written += int64(n)
+	if err != nil && w.err == nil {
+		w.err = err
+	}
+	return n, err
+}
+
+func (w *downloadLinkResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// reachedEnd reports whether a successful response was written in full and
+// carried the last byte of the file: a 200, or a 206 whose range ends the
+// file. A multipart 206 counts whatever its ranges, as they may cover the
+// whole file too.
+func (w *downloadLinkResponseWriter) reachedEnd() bool {
+	if w.err != nil {
+		return false
+	}
+	length, err := strconv.ParseInt(w.Header().Get("Content-Length"), 10, 64)
+	if err != nil || w.written != length {
+		return false
+	}
+	switch w.status {
+	case http.StatusOK:
+		return true
+	case http.StatusPartialContent:
+		contentRange := w.Header().Get("Content-Range")
+		if contentRange == "" {
+			return true
+		}
+		var start, end, size int64
+		if _, err := fmt.Sscanf(contentRange, "bytes %d-%d/%d", &start, &end, &size); err != nil {
+			return true
+		}
+		return end == size-1
+	}
+	return false
+}
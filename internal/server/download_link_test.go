@@ -0,0 +1,164 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDownloadLinkSignerRedeem(t *testing.T) {
+	t.Parallel()
+
+	signer := newDownloadLinkSigner()
+	now := time.Now()
+	token, err := signer.issue("/srv/report.pdf", "", now.Add(time.Minute))
+	if err != nil {
+		t.Fatalf("issue failed: %v", err)
+	}
+
+	payload, signature, _ := strings.Cut(token, ".")
+	forged := payload[:len(payload)-2] + "xx." + signature
+	if _, err := signer.redeem(forged, now); !errors.Is(err, errDownloadLinkInvalid) {
+		t.Fatalf("expected a tampered token to be invalid, got %v", err)
+	}
+	if _, err := newDownloadLinkSigner().redeem(token, now); !errors.Is(err, errDownloadLinkInvalid) {
+		t.Fatalf("expected a token from another key to be invalid, got %v", err)
+	}
+	if _, err := signer.redeem(token, now.Add(2*time.Minute)); !errors.Is(err, errDownloadLinkExpired) {
+		t.Fatalf("expected errDownloadLinkExpired, got %v", err)
+	}
+
+	// Redeeming a link does not use it up; use does
+	claims, err := signer.redeem(token, now)
+	if err != nil || claims.Path != "/srv/report.pdf" {
+		t.Fatalf("unexpected claims %+v (%v)", claims, err)
+	}
+	if _, err := signer.redeem(token, now); err != nil {
+		t.Fatalf("redeem failed: %v", err)
+	}
+	signer.use(claims)
+	if _, err := signer.redeem(token, now); !errors.Is(err, errDownloadLinkUsed) {
+		t.Fatalf("expected errDownloadLinkUsed, got %v", err)
+	}
+}
+
+func TestHandleDownloadLink(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	filePath := filepath.Join(dir, "notes.txt")
+	if err := os.WriteFile(filePath, []byte("shared notes"), 0o644); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+
+	body := `{"path":` + jsonString(filePath) + `,"filename":"handout.txt","expires_in":60}`
+	req := httptest.NewRequest(http.MethodPost, "/api/download/link", strings.NewReader(body))
+	req.Host = "hub.example.com"
+	rec := httptest.NewRecorder()
+	handleDownloadLink(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var link downloadLinkResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &link); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if !strings.HasPrefix(link.URL, "http://hub.example.com/api/download/link/") ||
+		!strings.HasSuffix(link.URL, link.Path) || link.ExpiresAt-time.Now().Unix() > 60 {
+		t.Fatalf("unexpected link %+v", link)
+	}
+
+	rec = httptest.NewRecorder()
+	handleDownloadLinkRedeem(rec, httptest.NewRequest(http.MethodHead, link.Path, nil))
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Length") != "12" {
+		t.Fatalf("unexpected HEAD response %d %v", rec.Code, rec.Header())
+	}
+
+	// A Range request short of the end leaves the link for the rest
+	rangeReq := httptest.NewRequest(http.MethodGet, link.Path, nil)
+	rangeReq.Header.Set("Range", "bytes=0-6")
+	rec = httptest.NewRecorder()
+	handleDownloadLinkRedeem(rec, rangeReq)
+	if rec.Code != http.StatusPartialContent || rec.Body.String() != "shared " {
+		t.Fatalf("unexpected range response %d %q", rec.Code, rec.Body.String())
+	}
+
+	// A download that breaks off does not use the link up
+	handleDownloadLinkRedeem(&failingResponseWriter{ResponseRecorder: httptest.NewRecorder()},
+		httptest.NewRequest(http.MethodGet, link.Path, nil))
+
+	rec = httptest.NewRecorder()
+	handleDownloadLinkRedeem(rec, httptest.NewRequest(http.MethodGet, link.Path, nil))
+	if rec.Code != http.StatusOK || rec.Body.String() != "shared notes" ||
+		!strings.Contains(rec.Header().Get("Content-Disposition"), `filename="handout.txt"`) {
+		t.Fatalf("unexpected download %d %v %q", rec.Code, rec.Header(), rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	handleDownloadLinkRedeem(rec, httptest.NewRequest(http.MethodGet, link.Path, nil))
+	if rec.Code != http.StatusGone {
+		t.Fatalf("expected status 410 for a used link, got %d", rec.Code)
+	}
+	rec = httptest.NewRecorder()
+	handleDownloadLinkRedeem(rec, httptest.NewRequest(http.MethodGet, "/api/download/link/bogus", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("expected status 404 for an unknown link, got %d", rec.Code)
+	}
+
+	tests := []struct {
+		body string
+		want int
+	}{
+		{`{"path":` + jsonString(filepath.Join(dir, "missing.txt")) + `}`, http.StatusNotFound},
+		{`{"path":"notes.txt"}`, http.StatusBadRequest},
+		{`{"path":` + jsonString(filePath) + `,"expires_in":86400}`, http.StatusBadRequest},
+		{`not json`, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		handleDownloadLink(rec, httptest.NewRequest(http.MethodPost, "/api/download/link", strings.NewReader(tt.body)))
+		if rec.Code != tt.want {
+			t.Errorf("%s: expected status %d, got %d: %s", tt.body, tt.want, rec.Code, rec.Body.String())
+		}
+	}
+}
+
+func TestDownloadLinkRangeReachingTheEndUsesLinkUp(t *testing.T) {
+	t.Parallel()
+
+	filePath := filepath.Join(t.TempDir(), "notes.txt")
+	if err := os.WriteFile(filePath, []byte("shared notes"), 0o644); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+
+	for _, ranges := range []string{"bytes=0-", "bytes=7-", "bytes=0-3,4-"} {
+		token, err := downloadLinks.issue(filePath, "", time.Now().Add(time.Minute))
+		if err != nil {
+			t.Fatalf("issue failed: %v", err)
+		}
+		for i, want := range []int{http.StatusPartialContent, http.StatusGone} {
+			req := httptest.NewRequest(http.MethodGet, "/api/download/link/"+token, nil)
+			req.Header.Set("Range", ranges)
+			rec := httptest.NewRecorder()
+			handleDownloadLinkRedeem(rec, req)
+			if rec.Code != want {
+				t.Fatalf("%s: redemption %d: expected status %d, got %d", ranges, i+1, want, rec.Code)
+			}
+		}
+	}
+}
+
+// failingResponseWriter loses the connection as soon as the body is written
+type failingResponseWriter struct {
+	*httptest.ResponseRecorder
+}
+
+func (w *failingResponseWriter) Write([]byte) (int, error) {
+	return 0, errors.New("connection reset")
+}
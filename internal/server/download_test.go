@@ -0,0 +1,152 @@
+package server
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func downloadRequest(method, path string) *http.Request {
+	return httptest.NewRequest(method, "/api/download?path="+url.QueryEscape(path), nil)
+}
+
+func TestHandleFileDownloadResumes(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	filePath := filepath.Join(dir, "data.bin")
+	content := bytes.Repeat([]byte("0123456789"), 1000)
+	if err := os.WriteFile(filePath, content, 0o644); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+
+	rec := httptest.NewRecorder()
+	handleFileDownload(rec, downloadRequest(http.MethodHead, filePath))
+	if rec.Code != http.StatusOK || rec.Body.Len() != 0 ||
+		rec.Header().Get("Content-Length") != strconv.Itoa(len(content)) ||
+		rec.Header().Get("Accept-Ranges") != "bytes" {
+		t.Fatalf("unexpected HEAD response %d %v (%d bytes)", rec.Code, rec.Header(), rec.Body.Len())
+	}
+	etag := rec.Header().Get("ETag")
+	if etag == "" || rec.Header().Get("Last-Modified") == "" {
+		t.Fatalf("expected ETag and Last-Modified validators, got %v", rec.Header())
+	}
+
+	// Resuming with curl -C - sends an open-ended range
+	req := downloadRequest(http.MethodGet, filePath)
+	req.Header.Set("Range", "bytes=9000-")
+	req.Header.Set("If-Range", etag)
+	rec = httptest.NewRecorder()
+	handleFileDownload(rec, req)
+	if rec.Code != http.StatusPartialContent || !bytes.Equal(rec.Body.Bytes(), content[9000:]) ||
+		rec.Header().Get("Content-Range") != "bytes 9000-9999/10000" {
+		t.Fatalf("unexpected partial response %d %v", rec.Code, rec.Header())
+	}
+
+	// A stale validator restarts the download from the beginning
+	req = downloadRequest(http.MethodGet, filePath)
+	req.Header.Set("Range", "bytes=9000-")
+	req.Header.Set("If-Range", `"stale"`)
+	rec = httptest.NewRecorder()
+	handleFileDownload(rec, req)
+	if rec.Code != http.StatusOK || rec.Body.Len() != len(content) {
+		t.Fatalf("expected the whole file for a stale If-Range, got %d (%d bytes)", rec.Code, rec.Body.Len())
+	}
+
+	req = downloadRequest(http.MethodGet, filePath)
+	req.Header.Set("Range", "bytes=0-9,20-29")
+	rec = httptest.NewRecorder()
+	handleFileDownload(rec, req)
+	if rec.Code != http.StatusPartialContent ||
+		!strings.HasPrefix(rec.Header().Get("Content-Type"), "multipart/byteranges") {
+		t.Fatalf("unexpected multi-range response %d %v", rec.Code, rec.Header())
+	}
+
+	req = downloadRequest(http.MethodGet, filePath)
+	req.Header.Set("Range", "bytes=20000-")
+	rec = httptest.NewRecorder()
+	handleFileDownload(rec, req)
+	if rec.Code != http.StatusRequestedRangeNotSatisfiable {
+		t.Fatalf("expected status 416 past the end, got %d", rec.Code)
+	}
+}
+
+func TestHandleFileDownloadDirectoryZip(t *testing.T) {
+	t.Parallel()
+
+	dir := filepath.Join(t.TempDir(), "project")
+	for _, d := range []string{filepath.Join(dir, "src"), filepath.Join(dir, "empty")} {
+		if err := os.MkdirAll(d, 0o755); err != nil {
+			t.Fatalf("failed to create directory: %v", err)
+		}
+	}
+	files := map[string]string{
+		"project/README.md":   "# project",
+		"project/src/main.go": "package main",
+	}
+	for name, body := range files {
+		if err := os.WriteFile(filepath.Join(filepath.Dir(dir), filepath.FromSlash(name)), []byte(body), 0o644); err != nil {
+			t.Fatalf("failed to write file: %v", err)
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	handleFileDownload(rec, downloadRequest(http.MethodHead, dir))
+	headLength := rec.Header().Get("Content-Length")
+	if rec.Code != http.StatusOK || rec.Body.Len() != 0 || headLength == "" ||
+		rec.Header().Get("Accept-Ranges") != "none" {
+		t.Fatalf("unexpected HEAD response %d %v", rec.Code, rec.Header())
+	}
+
+	rec = httptest.NewRecorder()
+	handleFileDownload(rec, downloadRequest(http.MethodGet, dir))
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != "application/zip" ||
+		!strings.Contains(rec.Header().Get("Content-Disposition"), `filename="project.zip"`) {
+		t.Fatalf("unexpected response %d %v", rec.Code, rec.Header())
+	}
+	if rec.Header().Get("Content-Length") != headLength || strconv.Itoa(rec.Body.Len()) != headLength {
+		t.Fatalf("expected Content-Length %s to match the body, got %d bytes", headLength, rec.Body.Len())
+	}
+
+	archive, err := zip.NewReader(bytes.NewReader(rec.Body.Bytes()), int64(rec.Body.Len()))
+	if err != nil {
+		t.Fatalf("failed to read zip: %v", err)
+	}
+	found := map[string]bool{}
+	for _, file := range archive.File {
+		found[file.Name] = true
+		want, ok := files[file.Name]
+		if !ok {
+			continue
+		}
+		reader, err := file.Open()
+		if err != nil {
+			t.Fatalf("failed to open %s: %v", file.Name, err)
+		}
+		got, _ := io.ReadAll(reader)
+		_ = reader.Close()
+		if string(got) != want {
+			t.Errorf("%s: expected %q, got %q", file.Name, want, got)
+		}
+	}
+	for _, name := range []string{"project/README.md", "project/src/main.go", "project/empty/"} {
+		if !found[name] {
+			t.Errorf("expected %s in the archive, got %v", name, found)
+		}
+	}
+
+	// Directories holding more than the download limit are refused
+	entries, err := collectDownloadZipEntries(dir, 5)
+	if !errors.Is(err, errDownloadZipTooLarge) {
+		t.Fatalf("expected errDownloadZipTooLarge, got %d entries (%v)", len(entries), err)
+	}
+}
@@ -0,0 +1,171 @@
+package server
+
+import (
+	"archive/zip"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+)
+
+// maxDownloadZipEntries bounds the files and directories in a directory
+// download
+const maxDownloadZipEntries = 10000
+
+var errDownloadZipTooLarge = errors.New("directory too large")
+
+// downloadZipEntry is a file or directory in a directory download
+type downloadZipEntry struct {
+	path   string // on disk
+	header zip.FileHeader
+}
+
+// collectDownloadZipEntries lists dir for a directory download, naming the
+// entries below dir's own name. Links and special files are left out, and
+// the listing fails with errDownloadZipTooLarge when it has more than
+// maxDownloadZipEntries entries or its files hold more than maxSize bytes.
+func collectDownloadZipEntries(dir string, maxSize int64) ([]downloadZipEntry, error) {
+	var entries []downloadZipEntry
+	var total int64
+	root := filepath.Base(dir)
+	err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() && !d.Type().IsRegular() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+
+		header, err := zip.FileInfoHeader(info)
+		if err != nil {
+			return err
+		}
+		header.Name = path.Join(root, filepath.ToSlash(rel))
+		if d.IsDir() {
+			header.Name += "/"
+		} else {
+			// Stored, not deflated, so the archive's length is known up front
+			header.Method = zip.Store
+			total += info.Size()
+		}
+		entries = append(entries, downloadZipEntry{path: p, header: *header})
+		if len(entries) > maxDownloadZipEntries || total > maxSize {
+			return errDownloadZipTooLarge
+		}
+		return nil
+	})
+	return entries, err
+}
+
+// zeroReader reads endless zeros
+type zeroReader struct{}
+
+func (zeroReader) Read(p []byte) (int, error) {
+	clear(p)
+	return len(p), nil
+}
+
+// countingWriter counts the bytes written to it
+type countingWriter struct {
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	c.n += int64(len(p))
+	return len(p), nil
+}
+
+// writeDownloadZip writes entries to w as a zip archive. Each file
+// contributes exactly the size it had when it was listed, so the archive's
+// length does not depend on its content: with dryRun set, zeros are written
+// in place of the files to measure it.
+func writeDownloadZip(w io.Writer, entries []downloadZipEntry, dryRun bool) error {
+	zw := zip.NewWriter(w)
+	for i := range entries {
+		entry := &entries[i]
+		header := entry.header
+		fw, err := zw.CreateHeader(&header)
+		if err != nil {
+			return err
+		}
+		if entry.header.Mode().IsDir() {
+			continue
+		}
+
+		if dryRun {
+			if _, err := io.CopyN(fw, zeroReader{}, int64(entry.header.UncompressedSize64)); err != nil {
+				return err
+			}
+			continue
+		}
+		file, err := os.Open(entry.path)
+		if err != nil {
+			return err
+		}
+		// A file that shrank since it was listed fails the download rather
+		// than breaking the announced Content-Length
+		_, err = io.CopyN(fw, file, int64(entry.header.UncompressedSize64))
+		_ = file.Close()
+		if err != nil {
+			return fmt.Errorf("%s: %w", entry.path, err)
+		}
+	}
+	return zw.Close()
+}
+
+// serveDirectoryZip answers GET and HEAD /api/download for a directory with
+// a zip archive of it. The archive is built on the fly, so it has no ranges
+// to resume from, but its Content-Length is measured beforehand so clients
+// can tell a complete download from a truncated one.
+func serveDirectoryZip(w http.ResponseWriter, r *http.Request, dir, filename string, maxSize int64) {
+	entries, err := collectDownloadZipEntries(dir, maxSize)
+	if errors.Is(err, errDownloadZipTooLarge) {
+		writeError(w, http.StatusRequestEntityTooLarge, errCodePayloadTooLarge,
+			fmt.Sprintf("Directory too large (max %d MB in %d entries)", maxSize/(1024*1024), maxDownloadZipEntries))
+		return
+	}
+	if err != nil {
+		log.Printf("Error listing directory for download: %v", err)
+		writeError(w, http.StatusInternalServerError, errCodeInternal, "Failed to read directory")
+		return
+	}
+
+	var size countingWriter
+	if err := writeDownloadZip(&size, entries, true); err != nil {
+		log.Printf("Error measuring directory download: %v", err)
+		writeError(w, http.StatusInternalServerError, errCodeInternal, "Failed to prepare archive")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition",
+		fmt.Sprintf("attachment; filename=\"%s\"", sanitizeFilename(filename)))
+	w.Header().Set("Content-Length", strconv.FormatInt(size.n, 10))
+	w.Header().Set("Accept-Ranges", "none")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	if r.Method == http.MethodHead {
+		return
+	}
+
+	if err := writeDownloadZip(w, entries, false); err != nil {
+		log.Printf("Error streaming directory download %s: %v", dir, err)
+		return
+	}
+	log.Printf("Directory downloaded: path=%s, entries=%d, size=%d, filename=%s",
+		dir, len(entries), size.n, filename)
+}
@@ -0,0 +1,55 @@
+package server
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+)
+
+// writeJSONWithETag writes v as JSON with an ETag of its content, or a bare
+// 304 when the request's If-None-Match already names it, so polling clients
+// only download listings that changed
+func writeJSONWithETag(w http.ResponseWriter, r *http.Request, v any) {
+	body, err := json.Marshal(v)
+	if err != nil {
+		log.Printf("Error encoding response: %v", err)
+		writeError(w, http.StatusInternalServerError, errCodeInternal, "Internal server error")
+		return
+	}
+	body = append(body, '\n')
+	etag := contentETag(body)
+
+	h := w.Header()
+	h.Set("ETag", etag)
+	// Revalidate every time rather than reuse a cached copy
+	h.Set("Cache-Control", "no-cache")
+	if etagMatches(r.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	h.Set("Content-Type", "application/json")
+	if _, err := w.Write(body); err != nil {
+		log.Printf("Error writing response: %v", err)
+	}
+}
+
+// contentETag returns a strong ETag derived from a response body
+func contentETag(body []byte) string {
+	sum := sha256.Sum256(body)
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// etagMatches reports whether an If-None-Match header names etag. Weak
+// validators match their strong counterpart, as RFC 9110 asks for GET.
+func etagMatches(ifNoneMatch, etag string) bool {
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,43 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/iwanhae/terminal-hub/terminal"
+)
+
+func TestListSessionsETag(t *testing.T) {
+	sessionManager = terminal.NewSessionManager()
+	t.Cleanup(func() { _ = sessionManager.CloseAll() })
+
+	list := func(ifNoneMatch string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/sessions", nil)
+		if ifNoneMatch != "" {
+			req.Header.Set("If-None-Match", ifNoneMatch)
+		}
+		rec := httptest.NewRecorder()
+		handleListSessions(rec, req)
+		return rec
+	}
+
+	first := list("")
+	etag := first.Header().Get("ETag")
+	if first.Code != http.StatusOK || etag == "" {
+		t.Fatalf("expected 200 with an ETag, got %d %q", first.Code, etag)
+	}
+	if second := list(""); second.Header().Get("ETag") != etag {
+		t.Errorf("expected the same ETag for the same listing, got %q and %q", etag, second.Header().Get("ETag"))
+	}
+
+	for _, header := range []string{etag, "W/" + etag, `"other", ` + etag, "*"} {
+		rec := list(header)
+		if rec.Code != http.StatusNotModified || rec.Body.Len() != 0 {
+			t.Errorf("If-None-Match %s: expected an empty 304, got %d: %s", header, rec.Code, rec.Body.String())
+		}
+	}
+	if rec := list(`"stale"`); rec.Code != http.StatusOK || rec.Body.Len() == 0 {
+		t.Errorf("expected 200 for a stale ETag, got %d", rec.Code)
+	}
+}
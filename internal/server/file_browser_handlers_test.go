@@ -8,6 +8,8 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+
+	"github.com/iwanhae/terminal-hub/terminal"
 )
 
 type fileBrowseTestResponse struct {
@@ -154,6 +156,50 @@ func TestHandleFileBrowseDefaultsToServerWorkingDirectory(t *testing.T) {
 	}
 }
 
+func TestHandleFileBrowseStartsInSessionDirectory(t *testing.T) {
+	sessionManager = terminal.NewSessionManager()
+	t.Cleanup(func() { _ = sessionManager.CloseAll() })
+
+	dir := t.TempDir()
+	if _, err := sessionManager.CreateSession(terminal.SessionConfig{
+		ID:               "browse",
+		Shell:            "/bin/sh",
+		WorkingDirectory: dir,
+		Backend:          terminal.SessionBackendPTY,
+	}); err != nil {
+		t.Fatalf("failed to create session: %v", err)
+	}
+
+	browse := func(query string) string {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		handleFileBrowse(rec, httptest.NewRequest(http.MethodGet, "/api/files/browse"+query, nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s: expected status %d, got %d: %s", query, http.StatusOK, rec.Code, rec.Body.String())
+		}
+		var response fileBrowseTestResponse
+		if err := json.NewDecoder(rec.Body).Decode(&response); err != nil {
+			t.Fatalf("failed decoding response: %v", err)
+		}
+		return response.Current
+	}
+
+	if current := browse("?session=browse"); current != dir {
+		t.Errorf("expected the session's directory %q, got %q", dir, current)
+	}
+	workingDirectory, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("failed reading cwd: %v", err)
+	}
+	if current := browse("?session=missing"); current != filepath.Clean(workingDirectory) {
+		t.Errorf("unknown session: expected %q, got %q", workingDirectory, current)
+	}
+	other := t.TempDir()
+	if current := browse("?session=browse&path=" + url.QueryEscape(other)); current != other {
+		t.Errorf("path: expected %q, got %q", other, current)
+	}
+}
+
 func TestHandleFileBrowseRejectsFilePath(t *testing.T) {
 	t.Parallel()
 
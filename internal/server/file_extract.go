@@ -0,0 +1,442 @@
+package server
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	// maxExtractEntries bounds the entries of an archive that is extracted
+	maxExtractEntries = 10000
+	// maxExtractBytes bounds the bytes an extraction writes, whatever the
+	// archive's headers claim
+	maxExtractBytes = 4 << 30
+	// extractProgressInterval is how often progress events are sent
+	extractProgressInterval = 250 * time.Millisecond
+)
+
+var (
+	errExtractTooManyEntries = fmt.Errorf("archive has more than %d entries", maxExtractEntries)
+	errExtractTooLarge       = fmt.Errorf("archive expands to more than %d bytes", int64(maxExtractBytes))
+	errUnsafeArchiveEntry    = errors.New("archive entry escapes the destination")
+	errUnsupportedArchive    = errors.New("path must end in .zip, .tar, .tar.gz or .tgz")
+)
+
+// fileExtractRequest is the body of POST /api/files/extract
+type fileExtractRequest struct {
+	Path        string `json:"path"`                  // the archive
+	Destination string `json:"destination,omitempty"` // defaults to the archive's directory
+	Overwrite   bool   `json:"overwrite,omitempty"`   // replace existing files instead of keeping them
+}
+
+// Validate checks that the paths are absolute and the archive is of a
+// supported format
+func (req fileExtractRequest) Validate() error {
+	if strings.TrimSpace(req.Path) == "" {
+		return errors.New("path is required")
+	}
+	if !filepath.IsAbs(req.Path) {
+		return errors.New("path must be absolute")
+	}
+	if req.Destination != "" && !filepath.IsAbs(req.Destination) {
+		return errors.New("destination must be absolute")
+	}
+	if archiveFormat(req.Path) == "" {
+		return errUnsupportedArchive
+	}
+	return nil
+}
+
+// fileExtractResult is the body of POST /api/files/extract, and of its
+// progress events while it runs
+type fileExtractResult struct {
+	Destination string `json:"destination"`
+	Entries     int    `json:"entries"` // files and directories written
+	Bytes       int64  `json:"bytes"`   // bytes written
+	Skipped     int    `json:"skipped"` // existing files kept, links and special files
+}
+
+// archiveFormat returns "zip", "tar" or "tar.gz" after the extension of
+// name, or "" for other files
+func archiveFormat(name string) string {
+	name = strings.ToLower(name)
+	switch {
+	case strings.HasSuffix(name, ".zip"):
+		return "zip"
+	case strings.HasSuffix(name, ".tar.gz"), strings.HasSuffix(name, ".tgz"):
+		return "tar.gz"
+	case strings.HasSuffix(name, ".tar"):
+		return "tar"
+	}
+	return ""
+}
+
+// archiveExtractor writes the entries of an archive under dest
+type archiveExtractor struct {
+	ctx          context.Context
+	dest         string // with symbolic links resolved
+	overwrite    bool
+	seen         int
+	result       fileExtractResult
+	progress     func(fileExtractResult)
+	lastProgress time.Time
+}
+
+// extractArchive extracts the archive at archivePath into dest, which must
+// exist. Entries must stay under dest: names with .. or absolute paths, and
+// paths leading through symbolic links out of dest, fail the extraction.
+// Links and special files in the archive are skipped, as are existing files
+// unless overwrite is set. progress, if set, is called every
+// extractProgressInterval while files are written.
+func extractArchive(ctx context.Context, archivePath, dest string, overwrite bool, progress func(fileExtractResult)) (fileExtractResult, error) {
+	resolved, err := filepath.EvalSymlinks(dest)
+	if err != nil {
+		return fileExtractResult{Destination: dest}, err
+	}
+	x := &archiveExtractor{
+		ctx:          ctx,
+		dest:         resolved,
+		overwrite:    overwrite,
+		result:       fileExtractResult{Destination: dest},
+		progress:     progress,
+		lastProgress: time.Now(),
+	}
+
+	switch archiveFormat(archivePath) {
+	case "zip":
+		err = x.extractZip(archivePath)
+	case "tar", "tar.gz":
+		err = x.extractTar(archivePath)
+	default:
+		err = errUnsupportedArchive
+	}
+	return x.result, err
+}
+
+func (x *archiveExtractor) extractZip(archivePath string) error {
+	reader, err := zip.OpenReader(archivePath)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+	if len(reader.File) > maxExtractEntries {
+		return errExtractTooManyEntries
+	}
+
+	for _, file := range reader.File {
+		mode := file.Mode()
+		switch {
+		case mode.IsDir():
+			err = x.mkdir(file.Name)
+		case mode.IsRegular():
+			err = x.extractZipFile(file)
+		default:
+			err = x.skip(file.Name)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (x *archiveExtractor) extractZipFile(file *zip.File) error {
+	content, err := file.Open()
+	if err != nil {
+		return err
+	}
+	defer content.Close()
+	return x.writeFile(file.Name, file.Mode(), content)
+}
+
+func (x *archiveExtractor) extractTar(archivePath string) error {
+	archive, err := os.Open(archivePath)
+	if err != nil {
+		return err
+	}
+	defer archive.Close()
+
+	var stream io.Reader = archive
+	if archiveFormat(archivePath) == "tar.gz" {
+		gz, err := gzip.NewReader(archive)
+		if err != nil {
+			return err
+		}
+		defer gz.Close()
+		stream = gz
+	}
+
+	reader := tar.NewReader(stream)
+	for {
+		header, err := reader.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		switch header.Typeflag {
+		case tar.TypeXGlobalHeader:
+			continue
+		case tar.TypeDir:
+			err = x.mkdir(header.Name)
+		case tar.TypeReg:
+			err = x.writeFile(header.Name, header.FileInfo().Mode(), reader)
+		default:
+			err = x.skip(header.Name)
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// target returns where the entry called name goes, or "" for the archive's
+// root directory
+func (x *archiveExtractor) target(name string) (string, error) {
+	if err := x.ctx.Err(); err != nil {
+		return "", err
+	}
+	x.seen++
+	if x.seen > maxExtractEntries {
+		return "", errExtractTooManyEntries
+	}
+
+	// Archives made on Windows may separate with backslashes
+	name = strings.ReplaceAll(name, `\`, "/")
+	if path.IsAbs(name) || filepath.VolumeName(name) != "" {
+		return "", fmt.Errorf("%w: %s", errUnsafeArchiveEntry, name)
+	}
+	clean := path.Clean(name)
+	if clean == ".." || strings.HasPrefix(clean, "../") {
+		return "", fmt.Errorf("%w: %s", errUnsafeArchiveEntry, name)
+	}
+	if clean == "." {
+		return "", nil
+	}
+	return filepath.Join(x.dest, filepath.FromSlash(clean)), nil
+}
+
+// mkdirInside creates dir and checks that it did not end up outside the
+// destination through a symbolic link
+func (x *archiveExtractor) mkdirInside(dir string) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	resolved, err := filepath.EvalSymlinks(dir)
+	if err != nil {
+		return err
+	}
+	rel, err := filepath.Rel(x.dest, resolved)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return fmt.Errorf("%w: %s", errUnsafeArchiveEntry, dir)
+	}
+	return nil
+}
+
+func (x *archiveExtractor) mkdir(name string) error {
+	target, err := x.target(name)
+	if err != nil || target == "" {
+		return err
+	}
+	if err := x.mkdirInside(target); err != nil {
+		return err
+	}
+	x.result.Entries++
+	return nil
+}
+
+func (x *archiveExtractor) skip(name string) error {
+	if _, err := x.target(name); err != nil {
+		return err
+	}
+	x.result.Skipped++
+	return nil
+}
+
+func (x *archiveExtractor) writeFile(name string, mode fs.FileMode, content io.Reader) error {
+	target, err := x.target(name)
+	if err != nil {
+		return err
+	}
+	if target == "" {
+		x.result.Skipped++
+		return nil
+	}
+	if err := x.mkdirInside(filepath.Dir(target)); err != nil {
+		return err
+	}
+
+	if info, err := os.Lstat(target); err == nil {
+		if !x.overwrite || info.IsDir() {
+			x.result.Skipped++
+			return nil
+		}
+		// Replace files and links rather than writing through a link
+		if err := os.Remove(target); err != nil {
+			return err
+		}
+	}
+
+	perm := fs.FileMode(0o644)
+	if mode&0o111 != 0 {
+		perm = 0o755
+	}
+	file, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_EXCL, perm)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(extractWriter{file: file, x: x}, content)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		_ = os.Remove(target)
+		return err
+	}
+	x.result.Entries++
+	return nil
+}
+
+// extractWriter writes an extracted file, counting the bytes against
+// maxExtractBytes and reporting progress
+type extractWriter struct {
+	file *os.File
+	x    *archiveExtractor
+}
+
+func (w extractWriter) Write(p []byte) (int, error) {
+	if w.x.result.Bytes+int64(len(p)) > maxExtractBytes {
+		return 0, errExtractTooLarge
+	}
+	n, err := w.file.Write(p)
+	w.x.result.Bytes += int64(n)
+	if w.x.progress != nil && time.Since(w.x.lastProgress) >= extractProgressInterval {
+		w.x.lastProgress = time.Now()
+		w.x.progress(w.x.result)
+	}
+	return n, err
+}
+
+// extractErrorStatus maps an extraction error to a status and error code
+func extractErrorStatus(err error) (int, string) {
+	switch {
+	case errors.Is(err, errExtractTooManyEntries), errors.Is(err, errExtractTooLarge):
+		return http.StatusRequestEntityTooLarge, errCodePayloadTooLarge
+	case errors.Is(err, errUnsafeArchiveEntry), errors.Is(err, zip.ErrFormat),
+		errors.Is(err, gzip.ErrHeader), errors.Is(err, tar.ErrHeader),
+		errors.Is(err, io.ErrUnexpectedEOF):
+		return http.StatusBadRequest, errCodeInvalidRequest
+	}
+	return http.StatusInternalServerError, errCodeInternal
+}
+
+// handleFileExtract handles POST /api/files/extract, unpacking a zip or tar
+// archive on the server. With Accept: text/event-stream the response is a
+// stream of "progress" events, ending with a "done" event carrying the
+// result or an "error" event.
+func handleFileExtract(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, errCodeMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	if refuseLowDiskWrite(w) {
+		return
+	}
+
+	var req fileExtractRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, errCodeInvalidJSON, "Bad request")
+		return
+	}
+	if err := req.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, errCodeInvalidRequest, err.Error())
+		return
+	}
+
+	archivePath := filepath.Clean(req.Path)
+	info, err := os.Stat(archivePath)
+	if os.IsNotExist(err) {
+		writeError(w, http.StatusNotFound, errCodeFileNotFound, "Archive not found")
+		return
+	}
+	if err != nil {
+		log.Printf("Error accessing archive: %v", err)
+		writeError(w, http.StatusInternalServerError, errCodeInternal, "Failed to access archive")
+		return
+	}
+	if info.IsDir() {
+		writeError(w, http.StatusBadRequest, errCodeInvalidRequest, "Path must be a file")
+		return
+	}
+
+	dest := filepath.Dir(archivePath)
+	if req.Destination != "" {
+		dest = filepath.Clean(req.Destination)
+	}
+	if err := os.MkdirAll(dest, 0o755); err != nil {
+		log.Printf("Error creating extract destination: %v", err)
+		writeError(w, http.StatusInternalServerError, errCodeInternal, "Failed to create destination")
+		return
+	}
+
+	if !strings.Contains(r.Header.Get("Accept"), "text/event-stream") {
+		result, err := extractArchive(r.Context(), archivePath, dest, req.Overwrite, nil)
+		if err != nil {
+			log.Printf("Error extracting %s: %v", archivePath, err)
+			status, code := extractErrorStatus(err)
+			writeError(w, status, code, err.Error())
+			return
+		}
+		log.Printf("Archive extracted: path=%s, destination=%s, entries=%d", archivePath, dest, result.Entries)
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(result); err != nil {
+			log.Printf("Error encoding extract response: %v", err)
+		}
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, http.StatusInternalServerError, errCodeInternal, "Streaming not supported")
+		return
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	result, err := extractArchive(r.Context(), archivePath, dest, req.Overwrite, func(progress fileExtractResult) {
+		if writeSSEEvent(w, "progress", progress) == nil {
+			flusher.Flush()
+		}
+	})
+	if err != nil {
+		log.Printf("Error extracting %s: %v", archivePath, err)
+		_, code := extractErrorStatus(err)
+		if writeSSEEvent(w, "error", apiError{Code: code, Message: err.Error()}) == nil {
+			flusher.Flush()
+		}
+		return
+	}
+	log.Printf("Archive extracted: path=%s, destination=%s, entries=%d", archivePath, dest, result.Entries)
+	if writeSSEEvent(w, "done", result) == nil {
+		flusher.Flush()
+	}
+}
@@ -0,0 +1,230 @@
+package server
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// writeTestTarGz writes a .tar.gz archive of headers, with body as the
+// content of each regular file
+func writeTestTarGz(t *testing.T, archivePath string, headers []tar.Header, body string) {
+	t.Helper()
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for _, header := range headers {
+		if header.Typeflag == tar.TypeReg {
+			header.Size = int64(len(body))
+		}
+		if err := tw.WriteHeader(&header); err != nil {
+			t.Fatalf("failed to write tar header: %v", err)
+		}
+		if header.Typeflag == tar.TypeReg {
+			if _, err := tw.Write([]byte(body)); err != nil {
+				t.Fatalf("failed to write tar entry: %v", err)
+			}
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatalf("failed to close tar: %v", err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatalf("failed to close gzip: %v", err)
+	}
+	if err := os.WriteFile(archivePath, buf.Bytes(), 0o644); err != nil {
+		t.Fatalf("failed to write archive: %v", err)
+	}
+}
+
+func TestExtractArchiveTarGz(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	archivePath := filepath.Join(dir, "build.tar.gz")
+	writeTestTarGz(t, archivePath, []tar.Header{
+		{Name: "dist/", Typeflag: tar.TypeDir, Mode: 0o755},
+		{Name: "dist/app.js", Typeflag: tar.TypeReg, Mode: 0o644},
+		{Name: "dist/bin/run", Typeflag: tar.TypeReg, Mode: 0o755},
+		{Name: "dist/latest", Typeflag: tar.TypeSymlink, Linkname: "/etc/passwd"},
+	}, "console.log(1)")
+
+	dest := filepath.Join(dir, "out")
+	if err := os.Mkdir(dest, 0o755); err != nil {
+		t.Fatalf("failed to create destination: %v", err)
+	}
+	result, err := extractArchive(context.Background(), archivePath, dest, false, nil)
+	if err != nil {
+		t.Fatalf("extractArchive failed: %v", err)
+	}
+	if result.Entries != 3 || result.Skipped != 1 || result.Bytes != int64(2*len("console.log(1)")) {
+		t.Fatalf("unexpected result %+v", result)
+	}
+
+	content, err := os.ReadFile(filepath.Join(dest, "dist", "app.js"))
+	if err != nil || string(content) != "console.log(1)" {
+		t.Fatalf("unexpected extracted file %q (%v)", content, err)
+	}
+	if _, err := os.Lstat(filepath.Join(dest, "dist", "latest")); !os.IsNotExist(err) {
+		t.Fatalf("expected the symlink to be skipped, got %v", err)
+	}
+	if runtime.GOOS != "windows" {
+		info, err := os.Stat(filepath.Join(dest, "dist", "bin", "run"))
+		if err != nil || info.Mode().Perm()&0o111 == 0 {
+			t.Fatalf("expected an executable file, got %v (%v)", info, err)
+		}
+	}
+
+	// Existing files are kept unless overwrite is set
+	if err := os.WriteFile(filepath.Join(dest, "dist", "app.js"), []byte("local"), 0o644); err != nil {
+		t.Fatalf("failed to modify file: %v", err)
+	}
+	result, err = extractArchive(context.Background(), archivePath, dest, false, nil)
+	if err != nil || result.Skipped != 3 {
+		t.Fatalf("unexpected result %+v (%v)", result, err)
+	}
+	if content, _ := os.ReadFile(filepath.Join(dest, "dist", "app.js")); string(content) != "local" {
+		t.Fatalf("expected the existing file to be kept, got %q", content)
+	}
+	if _, err := extractArchive(context.Background(), archivePath, dest, true, nil); err != nil {
+		t.Fatalf("extractArchive failed: %v", err)
+	}
+	if content, _ := os.ReadFile(filepath.Join(dest, "dist", "app.js")); string(content) != "console.log(1)" {
+		t.Fatalf("expected the existing file to be replaced, got %q", content)
+	}
+}
+
+func TestExtractArchiveRefusesEscapes(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	dest := filepath.Join(dir, "out")
+	outside := filepath.Join(dir, "outside")
+	for _, d := range []string{dest, outside} {
+		if err := os.Mkdir(d, 0o755); err != nil {
+			t.Fatalf("failed to create directory: %v", err)
+		}
+	}
+
+	slip := filepath.Join(dir, "slip.tar.gz")
+	writeTestTarGz(t, slip, []tar.Header{
+		{Name: "../outside/evil", Typeflag: tar.TypeReg, Mode: 0o644},
+	}, "pwned")
+	if _, err := extractArchive(context.Background(), slip, dest, false, nil); !errors.Is(err, errUnsafeArchiveEntry) {
+		t.Fatalf("expected errUnsafeArchiveEntry, got %v", err)
+	}
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	if _, err := zw.Create(`..\outside\evil`); err != nil {
+		t.Fatalf("failed to add zip entry: %v", err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("failed to close zip: %v", err)
+	}
+	zipSlip := filepath.Join(dir, "slip.zip")
+	if err := os.WriteFile(zipSlip, buf.Bytes(), 0o644); err != nil {
+		t.Fatalf("failed to write zip: %v", err)
+	}
+	if _, err := extractArchive(context.Background(), zipSlip, dest, false, nil); !errors.Is(err, errUnsafeArchiveEntry) {
+		t.Fatalf("expected errUnsafeArchiveEntry for zip, got %v", err)
+	}
+
+	// A link already in the destination does not lead entries out of it
+	if err := os.Symlink(outside, filepath.Join(dest, "link")); err == nil {
+		linked := filepath.Join(dir, "linked.tar.gz")
+		writeTestTarGz(t, linked, []tar.Header{
+			{Name: "link/evil", Typeflag: tar.TypeReg, Mode: 0o644},
+		}, "pwned")
+		if _, err := extractArchive(context.Background(), linked, dest, false, nil); !errors.Is(err, errUnsafeArchiveEntry) {
+			t.Fatalf("expected errUnsafeArchiveEntry through a link, got %v", err)
+		}
+	}
+
+	if _, err := os.Stat(filepath.Join(outside, "evil")); !os.IsNotExist(err) {
+		t.Fatalf("expected nothing written outside the destination, got %v", err)
+	}
+}
+
+func TestHandleFileExtract(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	entry, err := zw.Create("docs/readme.txt")
+	if err != nil {
+		t.Fatalf("failed to add zip entry: %v", err)
+	}
+	_, _ = entry.Write([]byte("hello"))
+	if err := zw.Close(); err != nil {
+		t.Fatalf("failed to close zip: %v", err)
+	}
+	archivePath := filepath.Join(dir, "docs.zip")
+	if err := os.WriteFile(archivePath, buf.Bytes(), 0o644); err != nil {
+		t.Fatalf("failed to write zip: %v", err)
+	}
+
+	body := `{"path":` + jsonString(archivePath) + `}`
+	rec := httptest.NewRecorder()
+	handleFileExtract(rec, httptest.NewRequest(http.MethodPost, "/api/files/extract", strings.NewReader(body)))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var result fileExtractResult
+	if err := json.Unmarshal(rec.Body.Bytes(), &result); err != nil || result.Entries != 1 || result.Destination != dir {
+		t.Fatalf("unexpected result %s (%v)", rec.Body.String(), err)
+	}
+	if content, _ := os.ReadFile(filepath.Join(dir, "docs", "readme.txt")); string(content) != "hello" {
+		t.Fatalf("unexpected extracted content %q", content)
+	}
+
+	// Progress is streamed as server-sent events
+	dest := filepath.Join(dir, "streamed")
+	req := httptest.NewRequest(http.MethodPost, "/api/files/extract", strings.NewReader(
+		`{"path":`+jsonString(archivePath)+`,"destination":`+jsonString(dest)+`}`))
+	req.Header.Set("Accept", "text/event-stream")
+	rec = httptest.NewRecorder()
+	handleFileExtract(rec, req)
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != "text/event-stream" ||
+		!strings.Contains(rec.Body.String(), "event: done\n") {
+		t.Fatalf("unexpected stream %d %q", rec.Code, rec.Body.String())
+	}
+	if _, err := os.Stat(filepath.Join(dest, "docs", "readme.txt")); err != nil {
+		t.Fatalf("expected the archive to be extracted into the destination: %v", err)
+	}
+
+	tests := []struct {
+		body string
+		want int
+	}{
+		{`{"path":` + jsonString(filepath.Join(dir, "missing.zip")) + `}`, http.StatusNotFound},
+		{`{"path":` + jsonString(filepath.Join(dir, "docs", "readme.txt")) + `}`, http.StatusBadRequest},
+		{`{"path":"docs.zip"}`, http.StatusBadRequest},
+		{`not json`, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		handleFileExtract(rec, httptest.NewRequest(http.MethodPost, "/api/files/extract", strings.NewReader(tt.body)))
+		if rec.Code != tt.want {
+			t.Errorf("%s: expected status %d, got %d: %s", tt.body, tt.want, rec.Code, rec.Body.String())
+		}
+	}
+}
+
+func jsonString(s string) string {
+	encoded, _ := json.Marshal(s)
+	return string(encoded)
+}
@@ -0,0 +1,205 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// maxPasteBytes bounds the text of a paste
+	maxPasteBytes = 1 << 20
+	// maxPasteRequestBytes bounds the body of a paste, which JSON escaping
+	// makes larger than its text
+	maxPasteRequestBytes = 8 * maxPasteBytes
+	// maxPasteNumber bounds the numbers tried for {n} in a paste's filename
+	maxPasteNumber = 1000
+	// defaultPasteFilename is the filename template of pastes that set none
+	defaultPasteFilename = "paste-{date}-{time}.txt"
+)
+
+// filePasteRequest is the body of POST /api/files/paste
+type filePasteRequest struct {
+	Content   string `json:"content"`
+	Filename  string `json:"filename,omitempty"`  // template; defaults to "paste-{date}-{time}.txt"
+	Directory string `json:"directory,omitempty"` // defaults to the session's working directory
+	Session   string `json:"session,omitempty"`
+	Overwrite bool   `json:"overwrite,omitempty"` // replace an existing file instead of failing
+}
+
+// Validate checks there is text to write and the directory is absolute
+func (req filePasteRequest) Validate() error {
+	if req.Content == "" {
+		return errors.New("content is required")
+	}
+	if req.Directory != "" && !filepath.IsAbs(req.Directory) {
+		return errors.New("directory must be absolute")
+	}
+	if req.Filename != "" && strings.ContainsAny(req.Filename, `/\`) {
+		return errors.New("filename must not contain path separators")
+	}
+	return nil
+}
+
+// expandPasteFilename fills in the placeholders of a paste's filename
+// template: {date} and {time} as 2006-01-02 and 150405 in local time, {unix}
+// as seconds since the epoch and {n} as n
+func expandPasteFilename(template string, now time.Time, n int) string {
+	return strings.NewReplacer(
+		"{date}", now.Format("2006-01-02"),
+		"{time}", now.Format("150405"),
+		"{unix}", strconv.FormatInt(now.Unix(), 10),
+		"{n}", strconv.Itoa(n),
+	).Replace(template)
+}
+
+// pasteTarget picks the file a paste is written to in dir. A template with
+// {n} takes the lowest number from 1 that names no existing file; otherwise
+// the one name is returned, with whether a file has it.
+func pasteTarget(dir, template string, now time.Time) (string, bool, error) {
+	if !strings.Contains(template, "{n}") {
+		filename := sanitizeFilename(expandPasteFilename(template, now, 0))
+		if filename == "" || filename == "." {
+			return "", false, errors.New("filename is empty")
+		}
+		_, err := os.Lstat(filepath.Join(dir, filename))
+		return filename, err == nil, nil
+	}
+
+	for n := 1; n <= maxPasteNumber; n++ {
+		filename := sanitizeFilename(expandPasteFilename(template, now, n))
+		if filename == "" || filename == "." {
+			return "", false, errors.New("filename is empty")
+		}
+		if _, err := os.Lstat(filepath.Join(dir, filename)); os.IsNotExist(err) {
+			return filename, false, nil
+		}
+	}
+	return "", false, fmt.Errorf("no free filename with {n} up to %d", maxPasteNumber)
+}
+
+// handleFilePaste handles POST /api/files/paste, writing text to a new file,
+// named from a template, in a directory or the session's working directory
+func handleFilePaste(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, errCodeMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	if refuseLowDiskWrite(w) {
+		return
+	}
+
+	var req filePasteRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxPasteRequestBytes)).Decode(&req); err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			writeError(w, http.StatusRequestEntityTooLarge, errCodePayloadTooLarge, "Paste too large")
+			return
+		}
+		writeError(w, http.StatusBadRequest, errCodeInvalidJSON, "Bad request")
+		return
+	}
+	if len(req.Content) > maxPasteBytes {
+		writeError(w, http.StatusRequestEntityTooLarge, errCodePayloadTooLarge,
+			fmt.Sprintf("Paste too large (max %d KB)", maxPasteBytes/1024))
+		return
+	}
+	if err := req.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, errCodeInvalidRequest, err.Error())
+		return
+	}
+
+	var dir string
+	switch {
+	case req.Directory != "":
+		dir = filepath.Clean(req.Directory)
+	case req.Session != "":
+		sess, ok := sessionManager.Get(req.Session)
+		if !ok {
+			writeError(w, http.StatusNotFound, errCodeSessionNotFound, "Session not found")
+			return
+		}
+		metadata := sess.GetMetadata()
+		if dir = localSessionDirectory(metadata, metadata.WorkingDirectory); dir == "" {
+			writeError(w, http.StatusBadRequest, errCodeInvalidRequest, "Session has no local working directory")
+			return
+		}
+	default:
+		writeError(w, http.StatusBadRequest, errCodeInvalidRequest, "directory or session is required")
+		return
+	}
+	if info, err := os.Stat(dir); os.IsNotExist(err) {
+		writeError(w, http.StatusNotFound, errCodeFileNotFound, "Directory not found")
+		return
+	} else if err != nil || !info.IsDir() {
+		writeError(w, http.StatusBadRequest, errCodeInvalidRequest, "Directory must be a directory")
+		return
+	}
+
+	template := req.Filename
+	if template == "" {
+		template = defaultPasteFilename
+	}
+	filename, exists, err := pasteTarget(dir, template, time.Now())
+	if err != nil {
+		writeError(w, http.StatusBadRequest, errCodeInvalidRequest, err.Error())
+		return
+	}
+	if err := uploadPolicy.checkFilename(filename); err != nil {
+		writeError(w, http.StatusUnsupportedMediaType, errCodeUnsupportedMedia, err.Error())
+		return
+	}
+	targetPath := filepath.Join(dir, filename)
+	if info, err := os.Stat(targetPath); err == nil && info.IsDir() {
+		writeError(w, http.StatusBadRequest, errCodeInvalidRequest, "Paste target cannot be a directory")
+		return
+	}
+	if exists && !req.Overwrite {
+		writeError(w, http.StatusConflict, errCodeFileExists, "File already exists")
+		return
+	}
+
+	if uploadPolicy.scanner != nil {
+		if written, ok := receiveScannedUpload(w, strings.NewReader(req.Content), dir, targetPath, req.Overwrite); ok {
+			writeUploadResponse(w, targetPath, filename, written, exists)
+		}
+		return
+	}
+
+	flags := os.O_CREATE | os.O_WRONLY
+	if req.Overwrite {
+		flags |= os.O_TRUNC
+	} else {
+		flags |= os.O_EXCL
+	}
+	file, err := os.OpenFile(targetPath, flags, 0o644)
+	if os.IsExist(err) {
+		writeError(w, http.StatusConflict, errCodeFileExists, "File already exists")
+		return
+	}
+	if err != nil {
+		log.Printf("Error opening paste target file: %v", err)
+		writeError(w, http.StatusInternalServerError, errCodeInternal, "Failed to open paste target")
+		return
+	}
+	written, err := file.WriteString(req.Content)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		_ = os.Remove(targetPath)
+		log.Printf("Error writing paste: %v", err)
+		writeError(w, http.StatusInternalServerError, errCodeInternal, "Failed to write paste")
+		return
+	}
+
+	writeUploadResponse(w, targetPath, filename, int64(written), exists)
+}
@@ -0,0 +1,89 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestExpandPasteFilename(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2026, 3, 14, 15, 9, 26, 0, time.Local)
+	got := expandPasteFilename("snippet-{date}-{time}-{n}.txt", now, 3)
+	if got != "snippet-2026-03-14-150926-3.txt" {
+		t.Fatalf("unexpected filename %q", got)
+	}
+	if got := expandPasteFilename("{unix}.log", now, 0); got != strconv.FormatInt(now.Unix(), 10)+".log" {
+		t.Fatalf("unexpected filename %q", got)
+	}
+}
+
+func TestHandleFilePaste(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	paste := func(body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		handleFilePaste(rec, httptest.NewRequest(http.MethodPost, "/api/files/paste", strings.NewReader(body)))
+		return rec
+	}
+
+	rec := paste(`{"directory":` + jsonString(dir) + `,"filename":"nginx.conf","content":"server {}\n"}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var result fileUploadResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &result); err != nil || result.Filename != "nginx.conf" || result.Size != 10 {
+		t.Fatalf("unexpected response %s (%v)", rec.Body.String(), err)
+	}
+	if content, _ := os.ReadFile(filepath.Join(dir, "nginx.conf")); string(content) != "server {}\n" {
+		t.Fatalf("unexpected content %q", content)
+	}
+
+	// Existing files are kept unless overwrite is set
+	if rec := paste(`{"directory":` + jsonString(dir) + `,"filename":"nginx.conf","content":"x"}`); rec.Code != http.StatusConflict {
+		t.Fatalf("expected status 409, got %d: %s", rec.Code, rec.Body.String())
+	}
+	rec = paste(`{"directory":` + jsonString(dir) + `,"filename":"nginx.conf","content":"x","overwrite":true}`)
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"overwritten":true`) {
+		t.Fatalf("unexpected overwrite %d: %s", rec.Code, rec.Body.String())
+	}
+
+	// {n} counts up to the first free name
+	for _, want := range []string{"note-1.md", "note-2.md"} {
+		rec := paste(`{"directory":` + jsonString(dir) + `,"filename":"note-{n}.md","content":"hi"}`)
+		if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"filename":"`+want+`"`) {
+			t.Fatalf("expected %s, got %d: %s", want, rec.Code, rec.Body.String())
+		}
+	}
+
+	rec = paste(`{"directory":` + jsonString(dir) + `,"content":"from my phone"}`)
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"filename":"paste-`) {
+		t.Fatalf("expected the default filename, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	tests := []struct {
+		body string
+		want int
+	}{
+		{`{"directory":` + jsonString(dir) + `,"content":""}`, http.StatusBadRequest},
+		{`{"directory":` + jsonString(dir) + `,"filename":"../escape","content":"x"}`, http.StatusBadRequest},
+		{`{"directory":"relative","content":"x"}`, http.StatusBadRequest},
+		{`{"content":"x"}`, http.StatusBadRequest},
+		{`{"directory":` + jsonString(filepath.Join(dir, "missing")) + `,"content":"x"}`, http.StatusNotFound},
+		{`{"directory":` + jsonString(dir) + `,"content":"` + strings.Repeat("a", maxPasteBytes+1) + `"}`, http.StatusRequestEntityTooLarge},
+		{`not json`, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		if rec := paste(tt.body); rec.Code != tt.want {
+			t.Errorf("%.80s: expected status %d, got %d: %s", tt.body, tt.want, rec.Code, rec.Body.String())
+		}
+	}
+}
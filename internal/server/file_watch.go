@@ -0,0 +1,108 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// fileWatchInterval is how often a watched directory is listed for changes
+const fileWatchInterval = time.Second
+
+// Messages about watched directories on /ws/events
+const (
+	fileWatchMessage       = "watch_files"        // client: watch a directory, or stop with an empty path
+	fileEventChanged       = "files_changed"      // server: the watched directory's entries changed
+	fileEventWatchRejected = "files_watch_failed" // server: the directory cannot be watched
+)
+
+// fileWatchRequest asks /ws/events to report changes to the entries of a
+// directory, replacing the directory watched before
+type fileWatchRequest struct {
+	Type string `json:"type"`
+	Path string `json:"path"`
+}
+
+// fileWatchEvent reports a change to, or a failure to watch, the directory
+// a /ws/events client watches
+type fileWatchEvent struct {
+	Type      string `json:"type"`
+	Path      string `json:"path"`
+	Error     string `json:"error,omitempty"`
+	Timestamp int64  `json:"timestamp"` // unix timestamp
+}
+
+// fileStamp is what a directory listing shows of an entry
+type fileStamp struct {
+	size    int64
+	modTime time.Time
+	mode    os.FileMode
+}
+
+// validateWatchDirectory checks that dir is an absolute path of a directory
+// and returns it cleaned
+func validateWatchDirectory(dir string) (string, error) {
+	dir = filepath.Clean(dir)
+	if !filepath.IsAbs(dir) {
+		return "", errors.New("path must be absolute")
+	}
+	info, err := os.Stat(dir)
+	if err != nil {
+		return "", errors.New("path not found")
+	}
+	if !info.IsDir() {
+		return "", errors.New("path must be a directory")
+	}
+	return dir, nil
+}
+
+// snapshotDirectory lists the entries of dir with what the file browser
+// shows of them
+func snapshotDirectory(dir string) (map[string]fileStamp, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	snapshot := make(map[string]fileStamp, len(entries))
+	for _, entry := range entries {
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		snapshot[entry.Name()] = fileStamp{size: info.Size(), modTime: info.ModTime(), mode: info.Mode()}
+	}
+	return snapshot, nil
+}
+
+// watchDirectory lists dir every interval until ctx is done, sending dir on
+// changed whenever its entries were added, removed or modified since the
+// last listing. Changes during one interval are reported once, and a change
+// is dropped while the previous one is still unread, so a build writing many
+// files does not flood the client.
+func watchDirectory(ctx context.Context, dir string, interval time.Duration, changed chan<- string) {
+	// Listing errors give no entries, so a removed directory is reported once
+	previous, _ := snapshotDirectory(dir)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		current, _ := snapshotDirectory(dir)
+		if maps.Equal(previous, current) {
+			continue
+		}
+		previous = current
+		select {
+		case changed <- dir:
+		default:
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package server
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/iwanhae/terminal-hub/terminal"
+)
+
+// handleSessionHistorySearch handles GET /api/sessions/:id/history/search?q=...
+// Optional parameters: regex=true, case_sensitive=true and limit.
+func handleSessionHistorySearch(w http.ResponseWriter, r *http.Request, sessionID string) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, errCodeMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	query := r.URL.Query()
+	opts := terminal.HistorySearchOptions{
+		Query:         query.Get("q"),
+		Regex:         query.Get("regex") == "true",
+		CaseSensitive: query.Get("case_sensitive") == "true",
+	}
+	if opts.Query == "" {
+		writeError(w, http.StatusBadRequest, errCodeInvalidRequest, "Query parameter q is required")
+		return
+	}
+	if limitStr := query.Get("limit"); limitStr != "" {
+		limit, err := strconv.Atoi(limitStr)
+		if err != nil || limit < 1 || limit > terminal.MaxHistorySearchLimit {
+			writeError(w, http.StatusBadRequest, errCodeInvalidRequest, "limit must be between 1 and "+strconv.Itoa(terminal.MaxHistorySearchLimit))
+			return
+		}
+		opts.Limit = limit
+	}
+
+	sess, ok := sessionManager.Get(sessionID)
+	if !ok {
+		writeError(w, http.StatusNotFound, errCodeSessionNotFound, "Session not found")
+		return
+	}
+	searcher, ok := sess.(terminal.HistorySearcher)
+	if !ok {
+		writeError(w, http.StatusConflict, errCodeUnsupportedSession, "Session history cannot be searched")
+		return
+	}
+
+	result, err := searcher.SearchHistory(opts)
+	if err != nil {
+		// Invalid regular expressions are the only expected failure
+		writeError(w, http.StatusBadRequest, errCodeInvalidRequest, err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(result); err != nil {
+		log.Printf("Error encoding history search result: %v", err)
+	}
+}
+
+// handleSessionSnapshot handles GET /api/sessions/:id/snapshot, the
+// session's current screen rendered from its history. ?format=text returns
+// the screen's lines as plain text instead of JSON with attributes.
+func handleSessionSnapshot(w http.ResponseWriter, r *http.Request, sessionID string) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, errCodeMethodNotAllowed, "Method not allowed")
+		return
+	}
+	format := r.URL.Query().Get("format")
+	if format != "" && format != "json" && format != "text" {
+		writeError(w, http.StatusBadRequest, errCodeInvalidRequest, `format must be "json" or "text"`)
+		return
+	}
+
+	sess, ok := sessionManager.Get(sessionID)
+	if !ok {
+		writeError(w, http.StatusNotFound, errCodeSessionNotFound, "Session not found")
+		return
+	}
+	snapshotter, ok := sess.(terminal.Snapshotter)
+	if !ok {
+		writeError(w, http.StatusConflict, errCodeUnsupportedSession, "Session screen cannot be rendered")
+		return
+	}
+
+	snapshot := snapshotter.Snapshot()
+	if format == "text" {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		if _, err := w.Write([]byte(snapshot.Text())); err != nil {
+			log.Printf("Error writing snapshot: %v", err)
+		}
+		return
+	}
+	writeJSONWithETag(w, r, snapshot)
+}
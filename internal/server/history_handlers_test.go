@@ -0,0 +1,145 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/iwanhae/terminal-hub/terminal"
+)
+
+func TestSessionHistorySearch(t *testing.T) {
+	ptyReader, ptyWriter, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("failed to create PTY pipe: %v", err)
+	}
+	sessionManager = terminal.NewSessionManager()
+	_, err = sessionManager.CreateSession(terminal.SessionConfig{
+		ID:         "history-session",
+		Backend:    terminal.SessionBackendPTY,
+		PTYService: &pipePTYService{reader: ptyReader},
+	})
+	if err != nil {
+		t.Fatalf("failed to create test session: %v", err)
+	}
+	t.Cleanup(func() {
+		_ = sessionManager.CloseAll()
+		_ = ptyWriter.Close()
+		_ = ptyReader.Close()
+	})
+
+	if _, err := ptyWriter.Write([]byte("build ok\r\ntest FAILED\r\n")); err != nil {
+		t.Fatalf("failed to write PTY output: %v", err)
+	}
+
+	// Output reaches the history asynchronously
+	var result terminal.HistorySearchResult
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		rec := httptest.NewRecorder()
+		handleSessionByID(rec, httptest.NewRequest(http.MethodGet, "/api/sessions/history-session/history/search?q=fail", nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got %d: %s", rec.Code, rec.Body.String())
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &result); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		if len(result.Matches) > 0 || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if len(result.Matches) != 1 || result.Matches[0].Offset != 15 || result.Matches[0].Text != "FAIL" {
+		t.Fatalf("unexpected matches: %+v", result.Matches)
+	}
+
+	tests := []struct {
+		path string
+		want int
+	}{
+		{"/api/sessions/history-session/history/search", http.StatusBadRequest},
+		{"/api/sessions/history-session/history/search?q=(&regex=true", http.StatusBadRequest},
+		{"/api/sessions/history-session/history/search?q=a&limit=0", http.StatusBadRequest},
+		{"/api/sessions/missing/history/search?q=a", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		handleSessionByID(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+		if rec.Code != tt.want {
+			t.Errorf("%s: expected status %d, got %d: %s", tt.path, tt.want, rec.Code, rec.Body.String())
+		}
+	}
+}
+
+func TestSessionSnapshot(t *testing.T) {
+	ptyReader, ptyWriter, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("failed to create PTY pipe: %v", err)
+	}
+	sessionManager = terminal.NewSessionManager()
+	_, err = sessionManager.CreateSession(terminal.SessionConfig{
+		ID:         "snapshot-session",
+		Backend:    terminal.SessionBackendPTY,
+		PTYService: &pipePTYService{reader: ptyReader},
+	})
+	if err != nil {
+		t.Fatalf("failed to create test session: %v", err)
+	}
+	t.Cleanup(func() {
+		_ = sessionManager.CloseAll()
+		_ = ptyWriter.Close()
+		_ = ptyReader.Close()
+	})
+
+	if _, err := ptyWriter.Write([]byte("old line\x1b[2J\x1b[H$ make\r\n\x1b[31mFAIL\x1b[0m\r\n$ ")); err != nil {
+		t.Fatalf("failed to write PTY output: %v", err)
+	}
+
+	// Output reaches the history asynchronously
+	var snapshot terminal.ScreenSnapshot
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		rec := httptest.NewRecorder()
+		handleSessionByID(rec, httptest.NewRequest(http.MethodGet, "/api/sessions/snapshot-session/snapshot", nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got %d: %s", rec.Code, rec.Body.String())
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &snapshot); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		if snapshot.Lines[2].Text != "" || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if snapshot.Cols != 80 || snapshot.Rows != 24 || snapshot.Lines[0].Text != "$ make" || snapshot.Lines[1].Text != "FAIL" {
+		t.Fatalf("unexpected snapshot: %+v", snapshot)
+	}
+	if spans := snapshot.Lines[1].Spans; len(spans) != 1 || spans[0].Fg != "1" || spans[0].Length != 4 {
+		t.Errorf("expected FAIL in red, got %+v", spans)
+	}
+	if snapshot.Cursor.Row != 2 || snapshot.Cursor.Col != 2 {
+		t.Errorf("expected the cursor after the prompt, got %+v", snapshot.Cursor)
+	}
+
+	rec := httptest.NewRecorder()
+	handleSessionByID(rec, httptest.NewRequest(http.MethodGet, "/api/sessions/snapshot-session/snapshot?format=text", nil))
+	if rec.Code != http.StatusOK || !strings.HasPrefix(rec.Body.String(), "$ make\nFAIL\n$\n\n") {
+		t.Errorf("expected the screen as text, got %d: %q", rec.Code, rec.Body.String())
+	}
+
+	for path, want := range map[string]int{
+		"/api/sessions/snapshot-session/snapshot?format=html": http.StatusBadRequest,
+		"/api/sessions/missing/snapshot":                      http.StatusNotFound,
+	} {
+		rec := httptest.NewRecorder()
+		handleSessionByID(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		if rec.Code != want {
+			t.Errorf("%s: expected status %d, got %d: %s", path, want, rec.Code, rec.Body.String())
+		}
+	}
+}
@@ -0,0 +1,338 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/iwanhae/terminal-hub/hooks"
+)
+
+// maxHookPayloadSize bounds the request body of a hook call
+const maxHookPayloadSize = 1 << 20
+
+// inboundHooks holds the hooks external services call, nil when the store
+// could not be opened
+var inboundHooks *hooks.Store
+
+// hookLimiters rate-limits each hook, keyed by hook ID. A limiter is
+// replaced when its hook's limit changes.
+var hookLimiters = struct {
+	sync.Mutex
+	byHook map[string]*hookLimiter
+}{byHook: make(map[string]*hookLimiter)}
+
+// hookLimiter is the token bucket of one hook, with the limit it was made for
+type hookLimiter struct {
+	perMinute int
+	limiter   *rateLimiter
+}
+
+// allowHookTrigger takes a token from the hook's bucket, whose burst is its
+// per-minute limit
+func allowHookTrigger(hook hooks.Hook, now time.Time) (bool, time.Duration) {
+	hookLimiters.Lock()
+	entry, ok := hookLimiters.byHook[hook.ID]
+	if !ok || entry.perMinute != hook.Limit() {
+		entry = &hookLimiter{perMinute: hook.Limit(), limiter: newRateLimiter(hook.Limit(), hook.Limit())}
+		hookLimiters.byHook[hook.ID] = entry
+	}
+	hookLimiters.Unlock()
+	return entry.limiter.Allow(hook.ID, now)
+}
+
+// forgetHookLimiter drops the bucket of a deleted hook
+func forgetHookLimiter(id string) {
+	hookLimiters.Lock()
+	defer hookLimiters.Unlock()
+	delete(hookLimiters.byHook, id)
+}
+
+// updateInboundHookRequest is the body of PUT /api/inbound-hooks/:id; omitted
+// fields are left unchanged
+type updateInboundHookRequest struct {
+	Name        *string            `json:"name,omitempty"`
+	CronJobID   *string            `json:"cron_job_id,omitempty"`
+	Session     *string            `json:"session,omitempty"`
+	Command     *string            `json:"command,omitempty"`
+	Env         *map[string]string `json:"env,omitempty"`
+	RateLimit   *int               `json:"rate_limit,omitempty"`
+	Description *string            `json:"description,omitempty"`
+	Disabled    *bool              `json:"disabled,omitempty"`
+	RotateToken bool               `json:"rotate_token,omitempty"` // issue a new token, returned in the response
+}
+
+// listInboundHooksResponse lists the hooks without their tokens
+type listInboundHooksResponse struct {
+	Hooks []hooks.Hook `json:"hooks"`
+}
+
+// triggerHookResponse is returned once a hook's command has been started
+type triggerHookResponse struct {
+	HookID string `json:"hook_id"`
+	Action string `json:"action"` // "cron" or "session"
+}
+
+// validateInboundHook checks a hook, including that its cron job exists
+func validateInboundHook(hook hooks.Hook) error {
+	if err := hook.Validate(); err != nil {
+		return err
+	}
+	if hook.CronJobID == "" {
+		return nil
+	}
+	if cronManager == nil {
+		return errors.New("cron jobs are disabled")
+	}
+	if _, err := cronManager.Get(hook.CronJobID); err != nil {
+		return errors.New("cron job not found")
+	}
+	return nil
+}
+
+// handleInboundHooks handles GET (list) and POST (create) /api/inbound-hooks.
+// The hook's token is only returned by POST.
+func handleInboundHooks(w http.ResponseWriter, r *http.Request) {
+	if inboundHooks == nil {
+		writeError(w, http.StatusServiceUnavailable, errCodeFeatureUnavailable, "Inbound hooks are unavailable")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		resp := listInboundHooksResponse{Hooks: []hooks.Hook{}}
+		for _, hook := range inboundHooks.List() {
+			resp.Hooks = append(resp.Hooks, hook.Redacted())
+		}
+		writeHookJSON(w, http.StatusOK, resp)
+
+	case http.MethodPost:
+		var hook hooks.Hook
+		if err := json.NewDecoder(r.Body).Decode(&hook); err != nil {
+			log.Printf("Error decoding request: %v", err)
+			writeError(w, http.StatusBadRequest, errCodeInvalidJSON, "Bad request")
+			return
+		}
+		if err := validateInboundHook(hook); err != nil {
+			writeError(w, http.StatusBadRequest, errCodeInvalidRequest, err.Error())
+			return
+		}
+		token, hash, err := hooks.NewToken()
+		if err != nil {
+			log.Printf("Error generating hook token: %v", err)
+			writeError(w, http.StatusInternalServerError, errCodeInternal, "Failed to save hook")
+			return
+		}
+		hook.ID = uuid.New().String()
+		hook.TokenHash = hash
+		hook.CreatedAt = time.Now().Unix()
+		hook.Token = ""
+		if err := inboundHooks.Add(hook); err != nil {
+			log.Printf("Error saving hook: %v", err)
+			writeError(w, http.StatusInternalServerError, errCodeInternal, "Failed to save hook")
+			return
+		}
+		log.Printf("Inbound hook %q created (%s)", hook.Name, hook.Action())
+		created := hook.Redacted()
+		created.Token = token
+		writeHookJSON(w, http.StatusCreated, created)
+
+	default:
+		writeError(w, http.StatusMethodNotAllowed, errCodeMethodNotAllowed, "Method not allowed")
+	}
+}
+
+// handleInboundHookByID handles GET, PUT and DELETE /api/inbound-hooks/:id.
+// Tokens are only returned when PUT rotates them.
+func handleInboundHookByID(w http.ResponseWriter, r *http.Request) {
+	if inboundHooks == nil {
+		writeError(w, http.StatusServiceUnavailable, errCodeFeatureUnavailable, "Inbound hooks are unavailable")
+		return
+	}
+	id := strings.TrimPrefix(r.URL.Path, "/api/inbound-hooks/")
+	if id == "" || strings.Contains(id, "/") {
+		writeError(w, http.StatusBadRequest, errCodeInvalidRequest, "Hook ID is required")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		hook, err := inboundHooks.Get(id)
+		if err != nil {
+			writeHookError(w, err)
+			return
+		}
+		writeHookJSON(w, http.StatusOK, hook.Redacted())
+
+	case http.MethodPut:
+		var req updateInboundHookRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			log.Printf("Error decoding request: %v", err)
+			writeError(w, http.StatusBadRequest, errCodeInvalidJSON, "Bad request")
+			return
+		}
+		hook, err := inboundHooks.Get(id)
+		if err != nil {
+			writeHookError(w, err)
+			return
+		}
+		if req.Name != nil {
+			hook.Name = *req.Name
+		}
+		if req.CronJobID != nil {
+			hook.CronJobID = *req.CronJobID
+		}
+		if req.Session != nil {
+			hook.Session = *req.Session
+		}
+		if req.Command != nil {
+			hook.Command = *req.Command
+		}
+		if req.Env != nil {
+			hook.Env = *req.Env
+		}
+		if req.RateLimit != nil {
+			hook.RateLimit = *req.RateLimit
+		}
+		if req.Description != nil {
+			hook.Description = *req.Description
+		}
+		if req.Disabled != nil {
+			hook.Disabled = *req.Disabled
+		}
+		if err := validateInboundHook(hook); err != nil {
+			writeError(w, http.StatusBadRequest, errCodeInvalidRequest, err.Error())
+			return
+		}
+		token := ""
+		if req.RotateToken {
+			token, hook.TokenHash, err = hooks.NewToken()
+			if err != nil {
+				log.Printf("Error generating hook token: %v", err)
+				writeError(w, http.StatusInternalServerError, errCodeInternal, "Failed to save hook")
+				return
+			}
+		}
+		if err := inboundHooks.Update(hook); err != nil {
+			log.Printf("Error updating hook: %v", err)
+			writeHookError(w, err)
+			return
+		}
+		log.Printf("Inbound hook %q updated", hook.Name)
+		updated := hook.Redacted()
+		updated.Token = token
+		writeHookJSON(w, http.StatusOK, updated)
+
+	case http.MethodDelete:
+		if err := inboundHooks.Delete(id); err != nil {
+			log.Printf("Error deleting hook: %v", err)
+			writeHookError(w, err)
+			return
+		}
+		forgetHookLimiter(id)
+		log.Printf("Inbound hook %s deleted", id)
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		writeError(w, http.StatusMethodNotAllowed, errCodeMethodNotAllowed, "Method not allowed")
+	}
+}
+
+// handleHookTrigger handles POST /api/hooks/:token, which needs no login: the
+// token authorizes the call. It runs the hook's cron job, or types its
+// command into its session, with the environment rendered from the request,
+// and returns without waiting for the command to finish.
+func handleHookTrigger(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, errCodeMethodNotAllowed, "Method not allowed")
+		return
+	}
+	if inboundHooks == nil {
+		writeError(w, http.StatusServiceUnavailable, errCodeFeatureUnavailable, "Inbound hooks are unavailable")
+		return
+	}
+	token := strings.TrimPrefix(r.URL.Path, "/api/hooks/")
+	hook, err := inboundHooks.FindByToken(token)
+	if token == "" || err != nil {
+		writeError(w, http.StatusNotFound, errCodeHookNotFound, "Hook not found")
+		return
+	}
+	if hook.Disabled {
+		writeError(w, http.StatusForbidden, errCodeHookDisabled, "Hook is disabled")
+		return
+	}
+	if allowed, wait := allowHookTrigger(hook, time.Now()); !allowed {
+		log.Printf("Inbound hook %q rate limited: ip=%s", hook.Name, extractClientIP(r))
+		w.Header().Set("Retry-After", strconv.Itoa(max(1, int(math.Ceil(wait.Seconds())))))
+		writeError(w, http.StatusTooManyRequests, errCodeRateLimited, "Too many requests")
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxHookPayloadSize))
+	if err != nil {
+		writeError(w, http.StatusRequestEntityTooLarge, errCodePayloadTooLarge, "Payload too large")
+		return
+	}
+	env, err := hook.RenderEnv(hooks.Request{Body: body, Header: r.Header, Query: r.URL.Query()})
+	if err != nil {
+		writeError(w, http.StatusBadRequest, errCodeInvalidRequest, err.Error())
+		return
+	}
+
+	switch hook.Action() {
+	case hooks.ActionCron:
+		if cronManager == nil {
+			writeError(w, http.StatusServiceUnavailable, errCodeFeatureUnavailable, "Cron jobs are disabled")
+			return
+		}
+		if _, err := cronManager.Get(hook.CronJobID); err != nil {
+			writeError(w, http.StatusConflict, errCodeConflict, "The hook's cron job no longer exists")
+			return
+		}
+		go func() {
+			if _, err := cronManager.RunNowWithEnv(hook.CronJobID, env); err != nil {
+				log.Printf("Inbound hook %q failed to run cron job %s: %v", hook.Name, hook.CronJobID, err)
+			}
+		}()
+	case hooks.ActionSession:
+		sess, err := resolveSessionRef(hook.Session)
+		if err != nil {
+			writeError(w, http.StatusConflict, errCodeConflict, err.Error())
+			return
+		}
+		if _, err := sess.Write([]byte(hook.SessionInput(env))); err != nil {
+			log.Printf("Inbound hook %q failed to write to session %s: %v", hook.Name, sess.ID(), err)
+			writeError(w, http.StatusInternalServerError, errCodeInternal, "Failed to write to the session")
+			return
+		}
+	}
+
+	log.Printf("Inbound hook %q triggered (%s): ip=%s", hook.Name, hook.Action(), extractClientIP(r))
+	writeHookJSON(w, http.StatusAccepted, triggerHookResponse{HookID: hook.ID, Action: hook.Action()})
+}
+
+// writeHookError maps hook store errors to status codes. Requests are
+// validated beforehand, so other errors are storage failures.
+func writeHookError(w http.ResponseWriter, err error) {
+	if errors.Is(err, hooks.ErrHookNotFound) {
+		writeError(w, http.StatusNotFound, errCodeHookNotFound, "Hook not found")
+		return
+	}
+	writeError(w, http.StatusInternalServerError, errCodeInternal, "Failed to save hook")
+}
+
+func writeHookJSON(w http.ResponseWriter, statusCode int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		log.Printf("Error encoding hook response: %v", err)
+	}
+}
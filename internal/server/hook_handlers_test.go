@@ -0,0 +1,154 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/iwanhae/terminal-hub/cron"
+	"github.com/iwanhae/terminal-hub/hooks"
+	"github.com/iwanhae/terminal-hub/terminal"
+)
+
+func openTestInboundHooks(t *testing.T) {
+	t.Helper()
+	store, err := hooks.OpenStore(filepath.Join(t.TempDir(), "hooks.json"))
+	if err != nil {
+		t.Fatalf("failed to open store: %v", err)
+	}
+	inboundHooks = store
+	t.Cleanup(func() { inboundHooks = nil })
+}
+
+// createTestInboundHook creates a hook through the API and returns it with
+// its token
+func createTestInboundHook(t *testing.T, body string) hooks.Hook {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	handleInboundHooks(rec, httptest.NewRequest(http.MethodPost, "/api/inbound-hooks", strings.NewReader(body)))
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("create: expected status 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var hook hooks.Hook
+	if err := json.Unmarshal(rec.Body.Bytes(), &hook); err != nil || hook.Token == "" || hook.TokenHash != "" {
+		t.Fatalf("create: expected the token without its hash, got %q: %v", rec.Body.String(), err)
+	}
+	return hook
+}
+
+func triggerTestHook(token, body string) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/api/hooks/"+token+"?env=prod", strings.NewReader(body))
+	req.Header.Set("X-GitHub-Event", "push")
+	handleHookTrigger(rec, req)
+	return rec
+}
+
+func TestInboundHookTypesIntoSession(t *testing.T) {
+	openTestInboundHooks(t)
+	sessionManager = terminal.NewSessionManager()
+	t.Cleanup(func() { _ = sessionManager.CloseAll() })
+	tty := createTestSSHSession(t, "hook-session-id", "deploys")
+
+	hook := createTestInboundHook(t, `{"name":"deploy","session":"deploys","command":"./deploy.sh","rate_limit":2,
+		"env":{"REF":"{{body.ref}}","EVENT":"{{header.X-GitHub-Event}}","TARGET":"{{query.env}}"}}`)
+
+	rec := triggerTestHook(hook.Token, `{"ref":"refs/heads/main"}`)
+	if rec.Code != http.StatusAccepted {
+		t.Fatalf("trigger: expected status 202, got %d: %s", rec.Code, rec.Body.String())
+	}
+	readUntil(t, tty, `(export EVENT='push' REF='refs/heads/main' TARGET='prod'; ./deploy.sh)`)
+
+	// Body paths need a JSON body
+	if rec := triggerTestHook(hook.Token, `not json`); rec.Code != http.StatusBadRequest {
+		t.Errorf("non-JSON body: expected status 400, got %d", rec.Code)
+	}
+	// The limit of 2 per minute is used up
+	if rec := triggerTestHook(hook.Token, `{}`); rec.Code != http.StatusTooManyRequests || rec.Header().Get("Retry-After") == "" {
+		t.Errorf("rate limit: expected status 429 with Retry-After, got %d", rec.Code)
+	}
+
+	if rec := triggerTestHook("wrong-token", `{}`); rec.Code != http.StatusNotFound {
+		t.Errorf("unknown token: expected status 404, got %d", rec.Code)
+	}
+	rec = httptest.NewRecorder()
+	handleInboundHookByID(rec, httptest.NewRequest(http.MethodPut, "/api/inbound-hooks/"+hook.ID, strings.NewReader(`{"disabled":true}`)))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("disable: expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if rec := triggerTestHook(hook.Token, `{}`); rec.Code != http.StatusForbidden {
+		t.Errorf("disabled hook: expected status 403, got %d", rec.Code)
+	}
+}
+
+func TestInboundHookRunsCronJob(t *testing.T) {
+	openTestInboundHooks(t)
+	originalCron := cronManager
+	t.Cleanup(func() { cronManager = originalCron })
+	var err error
+	cronManager, err = cron.NewCronManager(filepath.Join(t.TempDir(), "crons.json"), 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	marker := filepath.Join(t.TempDir(), "ref")
+	job, err := cronManager.Create(cron.CreateCronRequest{
+		Name:     "deploy",
+		Schedule: "0 0 1 1 *",
+		Command:  `printf %s "$REF" > ` + marker,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rec := httptest.NewRecorder()
+	handleInboundHooks(rec, httptest.NewRequest(http.MethodPost, "/api/inbound-hooks", strings.NewReader(`{"name":"deploy","cron_job_id":"missing"}`)))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("unknown job: expected status 400, got %d", rec.Code)
+	}
+	hook := createTestInboundHook(t, `{"name":"deploy","cron_job_id":"`+job.ID+`","env":{"REF":"{{body.ref}}"}}`)
+
+	if rec := triggerTestHook(hook.Token, `{"ref":"v1.2.3"}`); rec.Code != http.StatusAccepted {
+		t.Fatalf("trigger: expected status 202, got %d: %s", rec.Code, rec.Body.String())
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if data, err := os.ReadFile(marker); err == nil && string(data) == "v1.2.3" {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for the cron job to run")
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+
+	// Rotating the token retires the old one
+	rec = httptest.NewRecorder()
+	handleInboundHookByID(rec, httptest.NewRequest(http.MethodPut, "/api/inbound-hooks/"+hook.ID, strings.NewReader(`{"rotate_token":true}`)))
+	var rotated hooks.Hook
+	if err := json.Unmarshal(rec.Body.Bytes(), &rotated); err != nil || rotated.Token == "" || rotated.Token == hook.Token {
+		t.Fatalf("rotate: expected a new token, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if rec := triggerTestHook(hook.Token, `{}`); rec.Code != http.StatusNotFound {
+		t.Errorf("old token: expected status 404, got %d", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	handleInboundHooks(rec, httptest.NewRequest(http.MethodGet, "/api/inbound-hooks", nil))
+	if rec.Code != http.StatusOK || strings.Contains(rec.Body.String(), rotated.Token) || strings.Contains(rec.Body.String(), "token_hash") {
+		t.Fatalf("list: expected hooks without tokens, got %d: %s", rec.Code, rec.Body.String())
+	}
+	rec = httptest.NewRecorder()
+	handleInboundHookByID(rec, httptest.NewRequest(http.MethodDelete, "/api/inbound-hooks/"+hook.ID, nil))
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("delete: expected status 204, got %d", rec.Code)
+	}
+	if rec := triggerTestHook(rotated.Token, `{}`); rec.Code != http.StatusNotFound {
+		t.Errorf("deleted hook: expected status 404, got %d", rec.Code)
+	}
+}
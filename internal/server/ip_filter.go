@@ -0,0 +1,162 @@
+package server
+
+import (
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// ipRanges is a list of address ranges
+type ipRanges []*net.IPNet
+
+// parseIPRanges parses a comma-separated list of CIDRs. A bare address is a
+// range of one.
+func parseIPRanges(value string) (ipRanges, error) {
+	var ranges ipRanges
+	for _, part := range strings.Split(value, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		if !strings.Contains(part, "/") {
+			ip := net.ParseIP(part)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid address %q", part)
+			}
+			bits := 128
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 32
+			}
+			ranges = append(ranges, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, ipNet, err := net.ParseCIDR(part)
+		if err != nil {
+			return nil, fmt.Errorf("invalid CIDR %q", part)
+		}
+		ranges = append(ranges, ipNet)
+	}
+	return ranges, nil
+}
+
+// Contains reports whether ip is in any of the ranges
+func (r ipRanges) Contains(ip net.IP) bool {
+	for _, ipNet := range r {
+		if ipNet.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// defaultTrustedProxies are trusted when TERMINAL_HUB_TRUSTED_PROXIES is
+// unset: loopback only, so peers on the LAN cannot spoof X-Forwarded-For.
+// Proxies on other hosts must be listed explicitly.
+const defaultTrustedProxies = "127.0.0.0/8,::1"
+
+// trustedProxies are the peers whose X-Forwarded-For and X-Forwarded-Proto
+// headers are honored
+var trustedProxies, _ = parseIPRanges(defaultTrustedProxies)
+
+// trustedProxiesFromEnv returns the ranges in TERMINAL_HUB_TRUSTED_PROXIES,
+// the defaults when it is unset, or none when it is set but empty
+func trustedProxiesFromEnv() (ipRanges, error) {
+	value, ok := os.LookupEnv("TERMINAL_HUB_TRUSTED_PROXIES")
+	if !ok {
+		value = defaultTrustedProxies
+	}
+	ranges, err := parseIPRanges(value)
+	if err != nil {
+		return nil, fmt.Errorf("TERMINAL_HUB_TRUSTED_PROXIES: %w", err)
+	}
+	return ranges, nil
+}
+
+// trustsPeer reports whether the request came directly from one of the
+// ranges
+func (r ipRanges) trustsPeer(req *http.Request) bool {
+	peer := net.ParseIP(parseIPCandidate(req.RemoteAddr))
+	return peer != nil && r.Contains(peer)
+}
+
+// clientIP resolves the address of the client behind any trusted proxies:
+// X-Forwarded-For is only honored when the peer is a trusted proxy, and is
+// read from the right, skipping further trusted proxies. It returns nil when
+// no address can be parsed.
+func (r ipRanges) clientIP(req *http.Request) net.IP {
+	peer := net.ParseIP(parseIPCandidate(req.RemoteAddr))
+	if peer == nil || !r.Contains(peer) {
+		return peer
+	}
+
+	hops := strings.Split(strings.Join(req.Header.Values("X-Forwarded-For"), ","), ",")
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop := net.ParseIP(parseIPCandidate(hops[i]))
+		if hop == nil {
+			break
+		}
+		peer = hop
+		if !r.Contains(hop) {
+			break
+		}
+	}
+	return peer
+}
+
+// ipFilter admits requests by client address, resolved through the trusted
+// proxies. Denied ranges take precedence; when allowed ranges are set, only
+// addresses in them are admitted.
+type ipFilter struct {
+	allowed ipRanges
+	denied  ipRanges
+}
+
+// newIPFilterFromEnv configures the filter from TERMINAL_HUB_ALLOWED_CIDRS
+// and TERMINAL_HUB_DENIED_CIDRS. It returns nil when neither is set.
+func newIPFilterFromEnv() (*ipFilter, error) {
+	allowed, err := parseIPRanges(os.Getenv("TERMINAL_HUB_ALLOWED_CIDRS"))
+	if err != nil {
+		return nil, fmt.Errorf("TERMINAL_HUB_ALLOWED_CIDRS: %w", err)
+	}
+	denied, err := parseIPRanges(os.Getenv("TERMINAL_HUB_DENIED_CIDRS"))
+	if err != nil {
+		return nil, fmt.Errorf("TERMINAL_HUB_DENIED_CIDRS: %w", err)
+	}
+	if len(allowed) == 0 && len(denied) == 0 {
+		return nil, nil
+	}
+	return &ipFilter{allowed: allowed, denied: denied}, nil
+}
+
+// Allow reports whether a request's client may connect
+func (f *ipFilter) Allow(r *http.Request) (bool, net.IP) {
+	ip := trustedProxies.clientIP(r)
+	return f.AllowIP(ip), ip
+}
+
+// AllowIP reports whether a client address may connect
+func (f *ipFilter) AllowIP(ip net.IP) bool {
+	if ip == nil || f.denied.Contains(ip) {
+		return false
+	}
+	return len(f.allowed) == 0 || f.allowed.Contains(ip)
+}
+
+// ipFilterMiddleware rejects requests from addresses the filter does not
+// admit with 403, before authentication. A nil filter admits everyone.
+func ipFilterMiddleware(next http.Handler, filter *ipFilter) http.Handler {
+	if filter == nil {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if allowed, ip := filter.Allow(r); !allowed {
+			log.Printf("Connection refused by IP filter: ip=%s, path=%s", ip, r.URL.Path)
+			writeError(w, http.StatusForbidden, errCodeForbidden, "Forbidden")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,141 @@
+package server
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func mustParseIPRanges(t *testing.T, value string) ipRanges {
+	t.Helper()
+	ranges, err := parseIPRanges(value)
+	if err != nil {
+		t.Fatalf("parseIPRanges(%q): %v", value, err)
+	}
+	return ranges
+}
+
+func TestParseIPRanges(t *testing.T) {
+	ranges := mustParseIPRanges(t, "10.8.0.0/24, 192.0.2.7 ,fd00::/8,")
+	if len(ranges) != 3 {
+		t.Fatalf("expected 3 ranges, got %v", ranges)
+	}
+	for _, bad := range []string{"10.8.0.0/33", "vpn", "192.0.2.300"} {
+		if _, err := parseIPRanges(bad); err == nil {
+			t.Errorf("expected %q to be rejected", bad)
+		}
+	}
+}
+
+func TestIPFilterMiddleware(t *testing.T) {
+	filter := &ipFilter{
+		allowed: mustParseIPRanges(t, "10.8.0.0/24,fd00::/8"),
+		denied:  mustParseIPRanges(t, "10.8.0.66"),
+	}
+	handler := ipFilterMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}), filter)
+
+	cases := []struct {
+		name         string
+		remoteAddr   string
+		forwardedFor string
+		want         int
+	}{
+		{"allowed range", "10.8.0.5:5000", "", http.StatusNoContent},
+		{"allowed IPv6", "[fd00::1]:5000", "", http.StatusNoContent},
+		{"outside allowed ranges", "203.0.113.9:5000", "", http.StatusForbidden},
+		{"denied wins over allowed", "10.8.0.66:5000", "", http.StatusForbidden},
+		{"forwarded by trusted proxy", "127.0.0.1:5000", "10.8.0.5", http.StatusNoContent},
+		{"forwarded outsider by trusted proxy", "127.0.0.1:5000", "203.0.113.9", http.StatusForbidden},
+		{"spoofed hop before the proxy", "127.0.0.1:5000", "10.8.0.5, 203.0.113.9", http.StatusForbidden},
+		{"forwarded header from untrusted peer", "203.0.113.9:5000", "10.8.0.5", http.StatusForbidden},
+	}
+	for _, c := range cases {
+		req := httptest.NewRequest(http.MethodGet, "/api/sessions", nil)
+		req.RemoteAddr = c.remoteAddr
+		if c.forwardedFor != "" {
+			req.Header.Set("X-Forwarded-For", c.forwardedFor)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != c.want {
+			t.Errorf("%s: expected status %d, got %d", c.name, c.want, rec.Code)
+		}
+	}
+}
+
+func TestNewIPFilterFromEnv(t *testing.T) {
+	t.Setenv("TERMINAL_HUB_ALLOWED_CIDRS", "")
+	t.Setenv("TERMINAL_HUB_DENIED_CIDRS", "")
+	if filter, err := newIPFilterFromEnv(); filter != nil || err != nil {
+		t.Errorf("expected no filter without ranges, got %v, %v", filter, err)
+	}
+
+	t.Setenv("TERMINAL_HUB_DENIED_CIDRS", "not-a-cidr")
+	if _, err := newIPFilterFromEnv(); err == nil {
+		t.Error("expected invalid ranges to be rejected")
+	}
+}
+
+func TestTrustedProxiesFromEnv(t *testing.T) {
+	t.Setenv("TERMINAL_HUB_TRUSTED_PROXIES", "")
+	if ranges, err := trustedProxiesFromEnv(); err != nil || len(ranges) != 0 {
+		t.Errorf("expected an empty setting to trust no proxies, got %v, %v", ranges, err)
+	}
+
+	t.Setenv("TERMINAL_HUB_TRUSTED_PROXIES", "192.0.2.1, 10.0.0.0/8")
+	if ranges, err := trustedProxiesFromEnv(); err != nil || len(ranges) != 2 {
+		t.Errorf("expected 2 trusted ranges, got %v, %v", ranges, err)
+	}
+
+	t.Setenv("TERMINAL_HUB_TRUSTED_PROXIES", "proxy")
+	if _, err := trustedProxiesFromEnv(); err == nil {
+		t.Error("expected invalid ranges to be rejected")
+	}
+}
+
+func TestDefaultTrustedProxiesAreLoopbackOnly(t *testing.T) {
+	t.Parallel()
+
+	ranges := mustParseIPRanges(t, defaultTrustedProxies)
+	for _, peer := range []string{"127.0.0.1", "::1"} {
+		if !ranges.Contains(net.ParseIP(peer)) {
+			t.Errorf("expected loopback peer %s to be trusted by default", peer)
+		}
+	}
+	for _, peer := range []string{"10.0.0.2", "172.16.0.2", "192.168.1.2", "fd00::2"} {
+		if ranges.Contains(net.ParseIP(peer)) {
+			t.Errorf("expected LAN peer %s not to be trusted by default", peer)
+		}
+	}
+}
+
+func TestIsSecureHonorsForwardedProtoFromTrustedProxiesOnly(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		remoteAddr string
+		want       bool
+	}{
+		{"127.0.0.1:5000", true},
+		{"[::1]:5000", true},
+		{"10.0.0.9:5000", false},
+		{"203.0.113.9:5000", false},
+	}
+	for _, c := range cases {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.RemoteAddr = c.remoteAddr
+		req.Header.Set("X-Forwarded-Proto", "https")
+		if got := isSecure(req); got != c.want {
+			t.Errorf("isSecure from %s: expected %v, got %v", c.remoteAddr, c.want, got)
+		}
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "https://terminal.example/", nil)
+	req.RemoteAddr = "203.0.113.9:5000"
+	if !isSecure(req) {
+		t.Error("expected a TLS request to be secure")
+	}
+}
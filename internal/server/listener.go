@@ -0,0 +1,104 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// unixSocketPrefix marks a -listen address as a unix domain socket path
+const unixSocketPrefix = "unix:"
+
+// unixSocketMode lets the owner and group, such as a reverse proxy's group,
+// connect to the socket
+const unixSocketMode = 0660
+
+// systemdListenFDsStart is the first file descriptor systemd passes
+const systemdListenFDsStart = 3
+
+// openListener returns the socket handed over by an upgrade or passed by
+// systemd when socket-activated, otherwise listens on address: host:port or
+// unix:/path/to.sock
+func openListener(address string) (net.Listener, error) {
+	listener, err := upgradeListener()
+	if err != nil || listener != nil {
+		return listener, err
+	}
+	listener, err = systemdListener()
+	if err != nil || listener != nil {
+		return listener, err
+	}
+	if path, ok := strings.CutPrefix(address, unixSocketPrefix); ok {
+		return listenUnix(path)
+	}
+	return net.Listen("tcp", address)
+}
+
+// systemdListener returns the first socket passed through LISTEN_FDS, or nil
+// when the process was not socket-activated. The variables are unset so the
+// shells started by the hub do not inherit them.
+func systemdListener() (net.Listener, error) {
+	pid, fds := os.Getenv("LISTEN_PID"), os.Getenv("LISTEN_FDS")
+	if pid == "" || fds == "" || pid != strconv.Itoa(os.Getpid()) {
+		return nil, nil
+	}
+	os.Unsetenv("LISTEN_PID")
+	os.Unsetenv("LISTEN_FDS")
+	os.Unsetenv("LISTEN_FDNAMES")
+
+	count, err := strconv.Atoi(fds)
+	if err != nil || count < 1 {
+		return nil, fmt.Errorf("invalid LISTEN_FDS %q", fds)
+	}
+
+	// net.FileListener duplicates the descriptor, so the original is closed
+	file := os.NewFile(systemdListenFDsStart, "systemd-socket")
+	defer file.Close()
+	listener, err := net.FileListener(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to use socket from systemd: %w", err)
+	}
+	return listener, nil
+}
+
+// listenUnix listens on a unix domain socket at path, replacing a stale
+// socket left by a previous run
+func listenUnix(path string) (net.Listener, error) {
+	if path == "" {
+		return nil, errors.New("unix socket path is required")
+	}
+	if info, err := os.Lstat(path); err == nil {
+		if info.Mode()&os.ModeSocket == 0 {
+			return nil, fmt.Errorf("%s exists and is not a socket", path)
+		}
+		if err := os.Remove(path); err != nil {
+			return nil, fmt.Errorf("failed to remove stale socket: %w", err)
+		}
+	}
+
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Chmod(path, unixSocketMode); err != nil {
+		listener.Close()
+		return nil, fmt.Errorf("failed to set socket permissions: %w", err)
+	}
+	return listener, nil
+}
+
+// localPeerMiddleware marks requests over a unix socket as coming from the
+// loopback address. They can only come from this host, typically a reverse
+// proxy, so the client IP is resolved from its forwarded headers.
+func localPeerMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		local := new(http.Request)
+		*local = *r
+		local.RemoteAddr = "127.0.0.1:0"
+		next.ServeHTTP(w, local)
+	})
+}
@@ -0,0 +1,84 @@
+package server
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestOpenListenerServesOverUnixSocket(t *testing.T) {
+	t.Setenv("LISTEN_PID", "")
+	socketPath := filepath.Join(t.TempDir(), "hub.sock")
+
+	// A socket left behind by a previous run is replaced
+	stale, err := net.Listen("unix", socketPath)
+	if err != nil {
+		t.Fatalf("failed to create stale socket: %v", err)
+	}
+	stale.(*net.UnixListener).SetUnlinkOnClose(false)
+	stale.Close()
+
+	listener, err := openListener("unix:" + socketPath)
+	if err != nil {
+		t.Fatalf("openListener: %v", err)
+	}
+	defer listener.Close()
+
+	info, err := os.Stat(socketPath)
+	if err != nil {
+		t.Fatalf("stat socket: %v", err)
+	}
+	if mode := info.Mode().Perm(); mode != unixSocketMode {
+		t.Errorf("expected socket mode %o, got %o", unixSocketMode, mode)
+	}
+
+	server := &http.Server{Handler: localPeerMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, extractClientIP(r))
+	}))}
+	go server.Serve(listener)
+	defer server.Close()
+
+	client := &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, "unix", socketPath)
+		},
+	}}
+	req, _ := http.NewRequest(http.MethodGet, "http://terminal-hub/", nil)
+	req.Header.Set("X-Forwarded-For", "203.0.113.7")
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("request over unix socket: %v", err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	if string(body) != "203.0.113.7" {
+		t.Errorf("expected the proxy's forwarded client IP, got %q", body)
+	}
+}
+
+func TestOpenListenerRefusesToReplaceRegularFile(t *testing.T) {
+	t.Setenv("LISTEN_PID", "")
+	path := filepath.Join(t.TempDir(), "hub.sock")
+	if err := os.WriteFile(path, []byte("data"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := openListener("unix:" + path); err == nil {
+		t.Fatal("expected a regular file at the socket path to be refused")
+	}
+}
+
+func TestSystemdListenerIgnoresOtherProcesses(t *testing.T) {
+	t.Setenv("LISTEN_PID", "1")
+	t.Setenv("LISTEN_FDS", "1")
+	listener, err := systemdListener()
+	if listener != nil || err != nil {
+		t.Fatalf("expected no listener for another process's sockets, got %v, %v", listener, err)
+	}
+	if os.Getenv("LISTEN_FDS") != "1" {
+		t.Error("expected LISTEN_FDS to be left alone")
+	}
+}
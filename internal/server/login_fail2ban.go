@@ -113,19 +113,9 @@ func (b *loginFail2Ban) StartCleanupLoop(interval time.Duration) {
 }
 
 func extractClientIP(r *http.Request) string {
-	if forwardedFor := r.Header.Get("X-Forwarded-For"); forwardedFor != "" {
-		parts := strings.Split(forwardedFor, ",")
-		for _, part := range parts {
-			if ip := parseIPCandidate(part); ip != "" {
-				return ip
-			}
-		}
-	}
-
-	if ip := parseIPCandidate(r.RemoteAddr); ip != "" {
-		return ip
+	if ip := trustedProxies.clientIP(r); ip != nil {
+		return ip.String()
 	}
-
 	return strings.TrimSpace(r.RemoteAddr)
 }
 
@@ -0,0 +1,116 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/iwanhae/terminal-hub/notify"
+	"github.com/iwanhae/terminal-hub/terminal"
+)
+
+// notifier delivers push notifications, nil when the subscription store
+// could not be opened
+var notifier *notify.Dispatcher
+
+// notifyChannels are the named Slack, Discord, Telegram and ntfy channels
+// from the notification channels file that cron jobs and watch rules can
+// notify
+var notifyChannels *notify.Channels
+
+// listSubscriptionsResponse lists the registered notification endpoints
+type listSubscriptionsResponse struct {
+	Subscriptions []notify.Subscription `json:"subscriptions"`
+}
+
+// handleNotificationSubscribe handles POST /api/notifications/subscribe,
+// registering an endpoint that receives push notifications
+func handleNotificationSubscribe(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, errCodeMethodNotAllowed, "Method not allowed")
+		return
+	}
+	if notifier == nil {
+		writeError(w, http.StatusServiceUnavailable, errCodeFeatureUnavailable, "Notifications are unavailable")
+		return
+	}
+
+	var sub notify.Subscription
+	if err := json.NewDecoder(r.Body).Decode(&sub); err != nil {
+		log.Printf("Error decoding request: %v", err)
+		writeError(w, http.StatusBadRequest, errCodeInvalidJSON, "Bad request")
+		return
+	}
+	if sub.Type == "" {
+		sub.Type = notify.TypeNtfy
+	}
+	if err := sub.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, errCodeInvalidRequest, err.Error())
+		return
+	}
+	sub.ID = uuid.New().String()
+	sub.CreatedAt = time.Now().Unix()
+	if err := notifier.Store().Add(sub); err != nil {
+		log.Printf("Error saving subscription: %v", err)
+		writeError(w, http.StatusInternalServerError, errCodeInternal, "Failed to save subscription")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(sub.Redacted()); err != nil {
+		log.Printf("Error encoding subscription: %v", err)
+	}
+}
+
+// handleNotificationSubscriptions handles GET /api/notifications/subscriptions
+// (list) and DELETE /api/notifications/subscriptions/:id
+func handleNotificationSubscriptions(w http.ResponseWriter, r *http.Request) {
+	if notifier == nil {
+		writeError(w, http.StatusServiceUnavailable, errCodeFeatureUnavailable, "Notifications are unavailable")
+		return
+	}
+	subID := strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/notifications/subscriptions"), "/")
+
+	switch {
+	case r.Method == http.MethodGet && subID == "":
+		resp := listSubscriptionsResponse{Subscriptions: []notify.Subscription{}}
+		for _, sub := range notifier.Store().List() {
+			resp.Subscriptions = append(resp.Subscriptions, sub.Redacted())
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(resp); err != nil {
+			log.Printf("Error encoding subscriptions: %v", err)
+		}
+	case r.Method == http.MethodDelete && subID != "":
+		if err := notifier.Store().Delete(subID); err != nil {
+			if errors.Is(err, notify.ErrSubscriptionNotFound) {
+				writeError(w, http.StatusNotFound, errCodeSubscriptionNotFound, "Subscription not found")
+				return
+			}
+			log.Printf("Error deleting subscription: %v", err)
+			writeError(w, http.StatusInternalServerError, errCodeInternal, "Failed to delete subscription")
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		writeError(w, http.StatusMethodNotAllowed, errCodeMethodNotAllowed, "Method not allowed")
+	}
+}
+
+// forwardSessionNotifications turns session events into push notifications
+// until the bus subscription is closed
+func forwardSessionNotifications(bus *terminal.EventBus, dispatcher *notify.Dispatcher) {
+	events, unsubscribe := bus.Subscribe()
+	defer unsubscribe()
+
+	for event := range events {
+		if event.Notifies() {
+			dispatcher.Notify(event.Notification())
+		}
+	}
+}
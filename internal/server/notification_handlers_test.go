@@ -0,0 +1,62 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/iwanhae/terminal-hub/notify"
+)
+
+func TestNotificationSubscriptions(t *testing.T) {
+	store, err := notify.OpenStore(filepath.Join(t.TempDir(), "notifications.json"))
+	if err != nil {
+		t.Fatalf("failed to open store: %v", err)
+	}
+	notifier = notify.NewDispatcher(store)
+	t.Cleanup(func() { notifier = nil })
+
+	rec := httptest.NewRecorder()
+	body := `{"url":"https://ntfy.sh/my-alerts","token":"tk_secret","events":["cron_failed"]}`
+	handleNotificationSubscribe(rec, httptest.NewRequest(http.MethodPost, "/api/notifications/subscribe", strings.NewReader(body)))
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("subscribe: expected status 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var created notify.Subscription
+	if err := json.Unmarshal(rec.Body.Bytes(), &created); err != nil || created.ID == "" {
+		t.Fatalf("subscribe: bad response %q: %v", rec.Body.String(), err)
+	}
+	if created.Type != notify.TypeNtfy || created.Token != "" {
+		t.Errorf("expected an ntfy subscription without its token, got %+v", created)
+	}
+
+	rec = httptest.NewRecorder()
+	handleNotificationSubscribe(rec, httptest.NewRequest(http.MethodPost, "/api/notifications/subscribe", strings.NewReader(`{"url":"https://ntfy.sh/x","events":["reboot"]}`)))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("unknown event: expected status 400, got %d", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	handleNotificationSubscriptions(rec, httptest.NewRequest(http.MethodGet, "/api/notifications/subscriptions", nil))
+	if rec.Code != http.StatusOK || strings.Contains(rec.Body.String(), "tk_secret") {
+		t.Fatalf("list: expected redacted subscriptions, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var list listSubscriptionsResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &list); err != nil || len(list.Subscriptions) != 1 {
+		t.Fatalf("list: bad response %q: %v", rec.Body.String(), err)
+	}
+
+	rec = httptest.NewRecorder()
+	handleNotificationSubscriptions(rec, httptest.NewRequest(http.MethodDelete, "/api/notifications/subscriptions/"+created.ID, nil))
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("delete: expected status 204, got %d", rec.Code)
+	}
+	rec = httptest.NewRecorder()
+	handleNotificationSubscriptions(rec, httptest.NewRequest(http.MethodDelete, "/api/notifications/subscriptions/"+created.ID, nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("delete again: expected status 404, got %d", rec.Code)
+	}
+}
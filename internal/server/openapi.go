@@ -0,0 +1,426 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"path"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/iwanhae/terminal-hub/auth"
+	"github.com/iwanhae/terminal-hub/credstore"
+	"github.com/iwanhae/terminal-hub/cron"
+	"github.com/iwanhae/terminal-hub/hooks"
+	"github.com/iwanhae/terminal-hub/notify"
+	"github.com/iwanhae/terminal-hub/terminal"
+	"github.com/iwanhae/terminal-hub/webhook"
+)
+
+// apiOperation describes one endpoint in the OpenAPI specification.
+// TestOpenAPISpecCoversDocumentedEndpoints checks that every endpoint named
+// in a handler's doc comment is listed in apiOperations.
+type apiOperation struct {
+	Method   string
+	Path     string // with {name} placeholders for path parameters
+	Tag      string
+	Summary  string
+	Query    []string // optional query parameters
+	Request  any      // type of the JSON request body, nil for none
+	Response any      // type of the JSON response body, nil for none
+	Status   int      // success status, 200 when zero
+	Consumes string   // media type of a non-JSON request body
+	Produces string   // media type of a non-JSON response body
+	Public   bool     // callable without logging in
+	ETag     bool     // answers If-None-Match with 304 Not Modified, see writeJSONWithETag
+
+	// Checked by validateRequestMiddleware before the handler runs
+	MaxBody  int64           // largest request body in bytes, maxJSONBodySize when zero
+	Validate func(any) error // checks the decoded Request, given as a pointer to its type
+}
+
+// apiOperations lists the REST API. The WebSocket endpoints under /ws are
+// not described, as OpenAPI has no way to.
+var apiOperations = []apiOperation{
+	{Method: "POST", Path: "/api/auth/login", Tag: "auth", Summary: "Log in", Request: auth.LoginRequest{}, Response: auth.LoginResponse{}, Public: true},
+	{Method: "POST", Path: "/api/auth/logout", Tag: "auth", Summary: "Log out", Response: logoutResponse{}, Public: true},
+	{Method: "GET", Path: "/api/auth/status", Tag: "auth", Summary: "Report whether the caller is logged in", Response: authStatusResponse{}, Public: true},
+	{Method: "GET", Path: "/api/auth/sessions", Tag: "auth", Summary: "List where the current user is logged in", Response: listAuthSessionsResponse{}},
+	{Method: "DELETE", Path: "/api/auth/sessions/{id}", Tag: "auth", Summary: "Log out another login session", Status: http.StatusNoContent},
+	{Method: "POST", Path: "/api/auth/password", Tag: "auth", Summary: "Change the current user's password and log out their other sessions", Request: changePasswordRequest{}, Status: http.StatusNoContent, Validate: validateAs(changePasswordRequest.Validate)},
+
+	{Method: "GET", Path: "/api/sessions", Tag: "sessions", Summary: "List sessions; with limit or offset, a page of them with the total", Query: []string{"tag", "name", "state", "sort", "order", "limit", "offset", "fields"}, Response: []terminal.SessionInfo{}, ETag: true},
+	{Method: "POST", Path: "/api/sessions", Tag: "sessions", Summary: "Create a session", Request: terminal.CreateSessionRequest{}, Response: terminal.CreateSessionResponse{}, Status: http.StatusCreated, Validate: validateAs(terminal.CreateSessionRequest.Validate)},
+	{Method: "GET", Path: "/api/sessions/{id}", Tag: "sessions", Summary: "Get a session and its attached clients", Response: terminal.SessionDetail{}},
+	{Method: "PUT", Path: "/api/sessions/{id}", Tag: "sessions", Summary: "Update a session", Request: terminal.UpdateSessionRequest{}, Status: http.StatusNoContent, Validate: validateAs(terminal.UpdateSessionRequest.Validate)},
+	{Method: "DELETE", Path: "/api/sessions/{id}", Tag: "sessions", Summary: "Close a session", Status: http.StatusNoContent},
+	{Method: "POST", Path: "/api/sessions/reorder", Tag: "sessions", Summary: "Set the custom order of sessions", Request: terminal.ReorderSessionsRequest{}, Response: []terminal.SessionInfo{}},
+	{Method: "POST", Path: "/api/sessions/broadcast-input", Tag: "sessions", Summary: "Send input to several sessions", Request: broadcastInputRequest{}, Response: broadcastInputResponse{}},
+	{Method: "POST", Path: "/api/sessions/adopt", Tag: "sessions", Summary: "Attach to an existing tmux session", Request: terminal.AdoptSessionRequest{}, Response: terminal.CreateSessionResponse{}, Status: http.StatusCreated, Validate: validateAs(terminal.AdoptSessionRequest.Validate)},
+	{Method: "POST", Path: "/api/sessions/{id}/signal", Tag: "sessions", Summary: "Signal the session's foreground program", Request: terminal.SignalSessionRequest{}, Status: http.StatusNoContent},
+	{Method: "POST", Path: "/api/sessions/{id}/exec", Tag: "sessions", Summary: "Run a command at the session's prompt and return its output", Request: terminal.ExecSessionRequest{}, Response: terminal.ExecResult{}, Validate: validateAs(terminal.ExecSessionRequest.Validate)},
+	{Method: "POST", Path: "/api/sessions/{id}/cd", Tag: "sessions", Summary: "Type a cd to a directory at the session's prompt", Request: terminal.ChangeDirectoryRequest{}, Status: http.StatusNoContent, Validate: validateAs(terminal.ChangeDirectoryRequest.Validate)},
+	{Method: "POST", Path: "/api/sessions/{id}/clone", Tag: "sessions", Summary: "Start a new session with the shell, working directory, environment variables and startup command of this one", Request: terminal.CloneSessionRequest{}, Response: terminal.CreateSessionResponse{}, Status: http.StatusCreated, Validate: validateAs(terminal.CloneSessionRequest.Validate)},
+	{Method: "GET", Path: "/api/sessions/{id}/history/search", Tag: "sessions", Summary: "Search the session's scrollback", Query: []string{"q", "regex", "case_sensitive", "limit"}, Response: terminal.HistorySearchResult{}},
+	{Method: "GET", Path: "/api/sessions/{id}/snapshot", Tag: "sessions", Summary: "Render the session's current screen; format=text for plain text", Query: []string{"format"}, Response: terminal.ScreenSnapshot{}, ETag: true},
+	{Method: "GET", Path: "/api/sessions/{id}/windows", Tag: "sessions", Summary: "List the session's tmux windows", Response: listWindowsResponse{}},
+	{Method: "POST", Path: "/api/sessions/{id}/windows", Tag: "sessions", Summary: "Create a tmux window", Request: createWindowRequest{}, Response: terminal.TmuxWindow{}, Status: http.StatusCreated},
+	{Method: "DELETE", Path: "/api/sessions/{id}/windows/{index}", Tag: "sessions", Summary: "Kill a tmux window", Status: http.StatusNoContent},
+	{Method: "POST", Path: "/api/sessions/{id}/windows/{index}/select", Tag: "sessions", Summary: "Select a tmux window", Status: http.StatusNoContent},
+	{Method: "POST", Path: "/api/sessions/{id}/panes/select", Tag: "sessions", Summary: "Select a tmux pane", Request: selectPaneRequest{}, Status: http.StatusNoContent},
+	{Method: "GET", Path: "/api/sessions/{id}/watches", Tag: "sessions", Summary: "List output watch rules", Response: listWatchRulesResponse{}},
+	{Method: "POST", Path: "/api/sessions/{id}/watches", Tag: "sessions", Summary: "Add an output watch rule", Request: terminal.WatchRule{}, Response: terminal.WatchRule{}, Status: http.StatusCreated, Validate: validateAs(terminal.WatchRule.Validate)},
+	{Method: "DELETE", Path: "/api/sessions/{id}/watches/{ruleID}", Tag: "sessions", Summary: "Remove an output watch rule", Status: http.StatusNoContent},
+	{Method: "DELETE", Path: "/api/sessions/{id}/clients/{clientId}", Tag: "sessions", Summary: "Disconnect a client", Status: http.StatusNoContent},
+	{Method: "GET", Path: "/api/tmux/sessions", Tag: "sessions", Summary: "List the host's tmux sessions", Response: listHostTmuxSessionsResponse{}},
+
+	{Method: "GET", Path: "/api/templates", Tag: "templates", Summary: "List session templates", Response: []terminal.SessionTemplate{}},
+	{Method: "POST", Path: "/api/templates", Tag: "templates", Summary: "Create a session template", Request: terminal.SessionTemplate{}, Response: terminal.SessionTemplate{}, Status: http.StatusCreated},
+	{Method: "PUT", Path: "/api/templates/{id}", Tag: "templates", Summary: "Replace a session template", Request: terminal.SessionTemplate{}, Status: http.StatusNoContent},
+	{Method: "DELETE", Path: "/api/templates/{id}", Tag: "templates", Summary: "Delete a session template", Status: http.StatusNoContent},
+
+	{Method: "POST", Path: "/api/notifications/subscribe", Tag: "notifications", Summary: "Subscribe to push notifications", Request: notify.Subscription{}, Response: notify.Subscription{}, Status: http.StatusCreated},
+	{Method: "GET", Path: "/api/notifications/subscriptions", Tag: "notifications", Summary: "List push subscriptions", Response: listSubscriptionsResponse{}},
+	{Method: "DELETE", Path: "/api/notifications/subscriptions/{id}", Tag: "notifications", Summary: "Remove a push subscription", Status: http.StatusNoContent},
+
+	{Method: "GET", Path: "/api/webhooks", Tag: "webhooks", Summary: "List webhooks, without their secrets", Response: listWebhooksResponse{}},
+	{Method: "POST", Path: "/api/webhooks", Tag: "webhooks", Summary: "Register a webhook, returning its signing secret", Request: webhook.Webhook{}, Response: webhook.Webhook{}, Status: http.StatusCreated},
+	{Method: "GET", Path: "/api/webhooks/{id}", Tag: "webhooks", Summary: "Get a webhook, without its secret", Response: webhook.Webhook{}},
+	{Method: "PUT", Path: "/api/webhooks/{id}", Tag: "webhooks", Summary: "Update a webhook", Request: updateWebhookRequest{}, Response: webhook.Webhook{}},
+	{Method: "DELETE", Path: "/api/webhooks/{id}", Tag: "webhooks", Summary: "Delete a webhook", Status: http.StatusNoContent},
+
+	{Method: "GET", Path: "/api/inbound-hooks", Tag: "hooks", Summary: "List inbound hooks, without their tokens", Response: listInboundHooksResponse{}},
+	{Method: "POST", Path: "/api/inbound-hooks", Tag: "hooks", Summary: "Create an inbound hook, returning its token", Request: hooks.Hook{}, Response: hooks.Hook{}, Status: http.StatusCreated, Validate: validateAs(hooks.Hook.Validate)},
+	{Method: "GET", Path: "/api/inbound-hooks/{id}", Tag: "hooks", Summary: "Get an inbound hook, without its token", Response: hooks.Hook{}},
+	{Method: "PUT", Path: "/api/inbound-hooks/{id}", Tag: "hooks", Summary: "Update an inbound hook or rotate its token", Request: updateInboundHookRequest{}, Response: hooks.Hook{}},
+	{Method: "DELETE", Path: "/api/inbound-hooks/{id}", Tag: "hooks", Summary: "Delete an inbound hook", Status: http.StatusNoContent},
+	{Method: "POST", Path: "/api/hooks/{token}", Tag: "hooks", Summary: "Trigger an inbound hook; the token authorizes the call", Consumes: "*/*", Response: triggerHookResponse{}, Status: http.StatusAccepted, Public: true},
+
+	{Method: "GET", Path: "/api/files/browse", Tag: "files", Summary: "List a directory", Query: []string{"path", "session", "showHidden", "withSizes"}, Response: fileBrowseResponse{}},
+	{Method: "GET", Path: "/api/download", Tag: "files", Summary: "Download a file, resumable with Range and If-Range, or a directory as a zip archive", Query: []string{"path", "filename"}, Produces: "application/octet-stream"},
+	{Method: "HEAD", Path: "/api/download", Tag: "files", Summary: "Get a download's Content-Length, ETag and Last-Modified without its body", Query: []string{"path", "filename"}},
+	{Method: "POST", Path: "/api/download/link", Tag: "files", Summary: "Create a short-lived, single-use download link that works without a login", Request: downloadLinkRequest{}, Response: downloadLinkResponse{}, Validate: validateAs(downloadLinkRequest.Validate)},
+	{Method: "GET", Path: "/api/download/link/{token}", Tag: "files", Summary: "Download through a link; the token authorizes the call and is used up by a complete download", Produces: "application/octet-stream", Public: true},
+	{Method: "HEAD", Path: "/api/download/link/{token}", Tag: "files", Summary: "Check a download link without using it up", Public: true},
+	{Method: "POST", Path: "/api/upload", Tag: "files", Summary: "Upload a file, named by the X-Terminal-Hub-Upload-Path, -Filename and -Overwrite headers", Consumes: "application/octet-stream", Response: fileUploadResponse{}},
+	{Method: "POST", Path: "/api/files/extract", Tag: "files", Summary: "Extract a zip or tar archive on the server; with Accept: text/event-stream, streams progress events", Request: fileExtractRequest{}, Response: fileExtractResult{}, Validate: validateAs(fileExtractRequest.Validate)},
+	{Method: "POST", Path: "/api/files/paste", Tag: "files", Summary: "Write text to a new file, named from a template with {date}, {time}, {unix} and {n}, in a directory or a session's working directory", Request: filePasteRequest{}, Response: fileUploadResponse{}, Validate: validateAs(filePasteRequest.Validate)},
+
+	{Method: "GET", Path: "/api/ssh/keys", Tag: "ssh", Summary: "List the hub's SSH public keys", Response: sshKeysResponse{}},
+	{Method: "GET", Path: "/api/ssh/known-hosts", Tag: "ssh", Summary: "List known SSH hosts", Response: sshKnownHostsResponse{}},
+	{Method: "DELETE", Path: "/api/ssh/known-hosts", Tag: "ssh", Summary: "Forget a known SSH host", Query: []string{"host"}, Status: http.StatusNoContent},
+
+	{Method: "GET", Path: "/api/credentials", Tag: "credentials", Summary: "List credentials, without their values", Response: listCredentialsResponse{}},
+	{Method: "POST", Path: "/api/credentials", Tag: "credentials", Summary: "Store a credential", Request: createCredentialRequest{}, Response: credstore.Credential{}, Status: http.StatusCreated},
+	{Method: "GET", Path: "/api/credentials/{name}", Tag: "credentials", Summary: "Get a credential, without its value", Response: credstore.Credential{}},
+	{Method: "PUT", Path: "/api/credentials/{name}", Tag: "credentials", Summary: "Update a credential", Request: updateCredentialRequest{}, Response: credstore.Credential{}},
+	{Method: "DELETE", Path: "/api/credentials/{name}", Tag: "credentials", Summary: "Delete a credential", Status: http.StatusNoContent},
+
+	{Method: "GET", Path: "/api/crons", Tag: "crons", Summary: "List cron jobs", Response: cron.ListCronsResponse{}, ETag: true},
+	{Method: "POST", Path: "/api/crons", Tag: "crons", Summary: "Create a cron job", Request: cron.CreateCronRequest{}, Response: cron.CreateCronResponse{}, Status: http.StatusCreated},
+	{Method: "GET", Path: "/api/crons/{id}", Tag: "crons", Summary: "Get a cron job", Response: cron.CronJob{}},
+	{Method: "PUT", Path: "/api/crons/{id}", Tag: "crons", Summary: "Update a cron job", Request: cron.UpdateCronRequest{}, Response: cron.CronJob{}},
+	{Method: "DELETE", Path: "/api/crons/{id}", Tag: "crons", Summary: "Delete a cron job", Status: http.StatusNoContent},
+	{Method: "POST", Path: "/api/crons/{id}/run", Tag: "crons", Summary: "Run a cron job now", Response: cron.CronExecutionResult{}},
+	{Method: "POST", Path: "/api/crons/{id}/enable", Tag: "crons", Summary: "Enable a cron job", Status: http.StatusNoContent},
+	{Method: "POST", Path: "/api/crons/{id}/disable", Tag: "crons", Summary: "Disable a cron job", Status: http.StatusNoContent},
+	{Method: "GET", Path: "/api/crons/{id}/history", Tag: "crons", Summary: "Get a cron job's execution history", Query: []string{"status", "limit", "offset", "since", "until"}, Response: cron.GetHistoryResponse{}},
+	{Method: "GET", Path: "/api/crons/{id}/executions", Tag: "crons", Summary: "List a cron job's in-flight executions", Response: liveExecutionsResponse{}},
+	{Method: "GET", Path: "/api/crons/{id}/executions/{execId}/stream", Tag: "crons", Summary: "Stream an execution's output as server-sent events", Produces: "text/event-stream"},
+	{Method: "GET", Path: "/api/crons/{id}/logs", Tag: "crons", Summary: "List a cron job's log files", Response: jobLogsResponse{}},
+	{Method: "GET", Path: "/api/crons/{id}/logs/{name}", Tag: "crons", Summary: "Download a cron job's log file", Produces: "text/plain"},
+	{Method: "POST", Path: "/api/exec", Tag: "crons", Summary: "Run a one-off command in a short-lived PTY; its output streams and its result is kept under the adhoc job", Request: cron.RunCommandRequest{}, Response: cron.LiveExecution{}, Status: http.StatusAccepted},
+	{Method: "GET", Path: "/api/crons/history", Tag: "crons", Summary: "Get the execution history of all cron jobs", Query: []string{"status", "limit", "offset", "since", "until"}, Response: cron.HistoryPage{}},
+	{Method: "GET", Path: "/api/crons/overview", Tag: "crons", Summary: "Summarize all cron jobs: job counts, success rate in the last 24 hours, running executions and upcoming runs", Response: cron.CronOverview{}},
+	{Method: "GET", Path: "/api/crons/preview", Tag: "crons", Summary: "Preview a schedule's next runs", Query: []string{"schedule", "count", "timezone"}, Response: cron.PreviewScheduleResponse{}},
+	{Method: "POST", Path: "/api/crons/pause-all", Tag: "crons", Summary: "Pause all scheduled runs", Response: cron.SuspendResponse{}},
+	{Method: "POST", Path: "/api/crons/resume-all", Tag: "crons", Summary: "Resume scheduled runs", Response: cron.SuspendResponse{}},
+	{Method: "GET", Path: "/api/crons/export", Tag: "crons", Summary: "Export cron jobs as JSON or a crontab", Query: []string{"format"}, Response: cron.CronExport{}},
+	{Method: "POST", Path: "/api/crons/import", Tag: "crons", Summary: "Import cron jobs from JSON or a crontab", Query: []string{"format"}, Request: cron.CronExport{}, Consumes: "text/plain", Response: cron.ImportCronsResponse{}, Status: http.StatusCreated, MaxBody: maxCronImportSize},
+
+	{Method: "GET", Path: "/api/config/ui", Tag: "system", Summary: "Get the title, logo, terminal theme and banner the hub is branded with", Response: uiConfig{}, ETag: true, Public: true},
+	{Method: "GET", Path: "/api/system/stats", Tag: "system", Summary: "Get host CPU, memory, disk and load, the data directory's free space and each session's usage", Response: systemStatsResponse{}},
+
+	{Method: "POST", Path: "/api/admin/upgrade", Tag: "admin", Summary: "Restart on the current binary, keeping sessions", Response: upgradeResponse{}},
+	{Method: "GET", Path: "/api/openapi.json", Tag: "admin", Summary: "This specification"},
+	{Method: "GET", Path: "/api/docs", Tag: "admin", Summary: "Browse this specification with Swagger UI", Produces: "text/html"},
+}
+
+// openAPIPathParam matches a {name} path parameter
+var openAPIPathParam = regexp.MustCompile(`\{([^}]+)\}`)
+
+// schemaRegistry collects the named schemas referenced by operations
+type schemaRegistry struct {
+	schemas map[string]any
+	names   map[reflect.Type]string
+}
+
+var (
+	timeType       = reflect.TypeOf(time.Time{})
+	rawMessageType = reflect.TypeOf(json.RawMessage{})
+)
+
+// schemaFor returns the JSON schema of values of type t, as encoding/json
+// marshals them. Structs are registered as components and referenced.
+func (s *schemaRegistry) schemaFor(t reflect.Type) map[string]any {
+	switch t {
+	case timeType:
+		return map[string]any{"type": "string", "format": "date-time"}
+	case rawMessageType:
+		return map[string]any{}
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		return s.schemaFor(t.Elem())
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return map[string]any{"type": "string", "format": "byte"}
+		}
+		return map[string]any{"type": "array", "items": s.schemaFor(t.Elem())}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": s.schemaFor(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return s.structSchema(t)
+		}
+		return map[string]any{"$ref": "#/components/schemas/" + s.register(t)}
+	}
+	return map[string]any{}
+}
+
+// register adds a named struct type to the components, qualifying its name
+// with its package when another package has a type of the same name
+func (s *schemaRegistry) register(t reflect.Type) string {
+	if name, ok := s.names[t]; ok {
+		return name
+	}
+	name := t.Name()
+	if _, taken := s.schemas[name]; taken {
+		name = path.Base(t.PkgPath()) + "." + name
+	}
+	// Registered before its fields so recursive types terminate
+	s.names[t] = name
+	s.schemas[name] = nil
+	s.schemas[name] = s.structSchema(t)
+	return name
+}
+
+// structSchema describes a struct's JSON object. Fields without omitempty
+// are always present, so they are listed as required.
+func (s *schemaRegistry) structSchema(t reflect.Type) map[string]any {
+	properties := map[string]any{}
+	var required []string
+	s.addFields(t, properties, &required)
+	schema := map[string]any{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
+
+func (s *schemaRegistry) addFields(t reflect.Type, properties map[string]any, required *[]string) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, options, _ := strings.Cut(tag, ",")
+		fieldType := field.Type
+		if fieldType.Kind() == reflect.Pointer {
+			fieldType = fieldType.Elem()
+		}
+		// Untagged embedded structs have their fields promoted
+		if field.Anonymous && name == "" && fieldType.Kind() == reflect.Struct {
+			s.addFields(fieldType, properties, required)
+			continue
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		properties[name] = s.schemaFor(field.Type)
+		if !strings.Contains(options, "omitempty") {
+			*required = append(*required, name)
+		}
+	}
+}
+
+// buildOpenAPISpec describes apiOperations as an OpenAPI 3 document
+func buildOpenAPISpec() map[string]any {
+	registry := &schemaRegistry{schemas: map[string]any{}, names: map[reflect.Type]string{}}
+	paths := map[string]any{}
+
+	for _, op := range apiOperations {
+		operation := map[string]any{
+			"tags":        []string{op.Tag},
+			"summary":     op.Summary,
+			"operationId": operationID(op),
+		}
+
+		var parameters []any
+		for _, match := range openAPIPathParam.FindAllStringSubmatch(op.Path, -1) {
+			parameters = append(parameters, map[string]any{
+				"name": match[1], "in": "path", "required": true,
+				"schema": map[string]any{"type": "string"},
+			})
+		}
+		for _, name := range op.Query {
+			parameters = append(parameters, map[string]any{
+				"name": name, "in": "query",
+				"schema": map[string]any{"type": "string"},
+			})
+		}
+		if op.ETag {
+			parameters = append(parameters, map[string]any{
+				"name": "If-None-Match", "in": "header",
+				"schema": map[string]any{"type": "string"},
+			})
+		}
+		if len(parameters) > 0 {
+			operation["parameters"] = parameters
+		}
+
+		if op.Request != nil || op.Consumes != "" {
+			content := map[string]any{}
+			if op.Request != nil {
+				content["application/json"] = map[string]any{"schema": registry.schemaFor(reflect.TypeOf(op.Request))}
+			}
+			if op.Consumes != "" {
+				content[op.Consumes] = map[string]any{"schema": map[string]any{"type": "string", "format": "binary"}}
+			}
+			operation["requestBody"] = map[string]any{"required": true, "content": content}
+		}
+
+		status := op.Status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		success := map[string]any{"description": http.StatusText(status)}
+		switch {
+		case op.Response != nil:
+			success["content"] = map[string]any{
+				"application/json": map[string]any{"schema": registry.schemaFor(reflect.TypeOf(op.Response))},
+			}
+		case op.Produces != "":
+			success["content"] = map[string]any{
+				op.Produces: map[string]any{"schema": map[string]any{"type": "string"}},
+			}
+		}
+		responses := map[string]any{
+			strconv.Itoa(status): success,
+			"default": map[string]any{
+				"description": "Error, with a machine-readable code",
+				"content": map[string]any{
+					"application/json": map[string]any{"schema": registry.schemaFor(reflect.TypeOf(errorResponse{}))},
+				},
+			},
+		}
+		if op.ETag {
+			success["headers"] = map[string]any{"ETag": map[string]any{"schema": map[string]any{"type": "string"}}}
+			responses[strconv.Itoa(http.StatusNotModified)] = map[string]any{"description": "Unchanged since the ETag in If-None-Match"}
+		}
+		operation["responses"] = responses
+		if op.Public {
+			operation["security"] = []any{}
+		}
+
+		item, _ := paths[op.Path].(map[string]any)
+		if item == nil {
+			item = map[string]any{}
+			paths[op.Path] = item
+		}
+		item[strings.ToLower(op.Method)] = operation
+	}
+
+	server := basePath
+	if server == "" {
+		server = "/"
+	}
+	return map[string]any{
+		"openapi": "3.0.3",
+		"info": map[string]any{
+			"title":   "terminal-hub",
+			"version": "1.0",
+		},
+		"servers":  []any{map[string]any{"url": server}},
+		"paths":    paths,
+		"security": []any{map[string]any{"cookieAuth": []string{}}},
+		"components": map[string]any{
+			"schemas": registry.schemas,
+			"securitySchemes": map[string]any{
+				"cookieAuth": map[string]any{"type": "apiKey", "in": "cookie", "name": sessionCookie.Name},
+			},
+		},
+	}
+}
+
+// operationID derives a unique operation name such as "getApiSessionsId"
+func operationID(op apiOperation) string {
+	var b strings.Builder
+	b.WriteString(strings.ToLower(op.Method))
+	for _, word := range strings.FieldsFunc(op.Path, func(r rune) bool {
+		return r == '/' || r == '{' || r == '}' || r == '-' || r == '.'
+	}) {
+		b.WriteString(strings.ToUpper(word[:1]) + word[1:])
+	}
+	return b.String()
+}
+
+// handleOpenAPISpec handles GET /api/openapi.json
+func handleOpenAPISpec(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, errCodeMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(buildOpenAPISpec()); err != nil {
+		log.Printf("Error encoding OpenAPI spec: %v", err)
+	}
+}
+
+// apiDocsPage renders the spec with Swagger UI, loaded from a CDN
+const apiDocsPage = `<!DOCTYPE html>
+<html>
+  <head>
+    <meta charset="utf-8" />
+    <title>terminal-hub API</title>
+    <link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@5/swagger-ui.css" />
+  </head>
+  <body>
+    <div id="swagger-ui"></div>
+    <script src="https://unpkg.com/swagger-ui-dist@5/swagger-ui-bundle.js"></script>
+    <script>
+      SwaggerUIBundle({ url: %q, dom_id: "#swagger-ui" });
+    </script>
+  </body>
+</html>
+`
+
+// handleAPIDocs handles GET /api/docs
+func handleAPIDocs(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, errCodeMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	fmt.Fprintf(w, apiDocsPage, basePath+"/api/openapi.json")
+}
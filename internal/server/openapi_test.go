@@ -0,0 +1,160 @@
+package server
+
+import (
+	"encoding/json"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// documentedEndpointToken matches the methods and paths in a handler's doc
+// comment, such as "GET, PUT and DELETE /api/credentials/:name"
+var documentedEndpointToken = regexp.MustCompile(`\b(GET|HEAD|POST|PUT|DELETE)\b|/api/[A-Za-z0-9_/:.{}-]*[A-Za-z0-9_}]`)
+
+// documentedEndpoints collects "METHOD /api/path" from the doc comments of
+// the package's handle* functions, with :param written as {param}
+func documentedEndpoints(t *testing.T) map[string]bool {
+	t.Helper()
+	files, err := filepath.Glob("*.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	endpoints := map[string]bool{}
+	fset := token.NewFileSet()
+	for _, name := range files {
+		if strings.HasSuffix(name, "_test.go") {
+			continue
+		}
+		file, err := parser.ParseFile(fset, name, nil, parser.ParseComments)
+		if err != nil {
+			t.Fatalf("parse %s: %v", name, err)
+		}
+		for _, decl := range file.Decls {
+			fn, ok := decl.(*ast.FuncDecl)
+			if !ok || fn.Doc == nil || !strings.HasPrefix(fn.Name.Name, "handle") {
+				continue
+			}
+			var methods []string
+			for _, tok := range documentedEndpointToken.FindAllString(fn.Doc.Text(), -1) {
+				if !strings.HasPrefix(tok, "/") {
+					methods = append(methods, tok)
+					continue
+				}
+				apiPath := regexp.MustCompile(`:([A-Za-z]+)`).ReplaceAllString(tok, "{$1}")
+				for _, method := range methods {
+					endpoints[method+" "+apiPath] = true
+				}
+				methods = nil
+			}
+		}
+	}
+	return endpoints
+}
+
+func TestOpenAPISpecCoversDocumentedEndpoints(t *testing.T) {
+	documented := documentedEndpoints(t)
+	if !documented["DELETE /api/credentials/{name}"] || !documented["POST /api/crons/import"] {
+		t.Fatalf("expected handler doc comments to be parsed, got %v", documented)
+	}
+
+	listed := map[string]bool{}
+	for _, op := range apiOperations {
+		key := op.Method + " " + op.Path
+		if listed[key] {
+			t.Errorf("%s is listed twice", key)
+		}
+		listed[key] = true
+	}
+	for endpoint := range documented {
+		if !listed[endpoint] {
+			t.Errorf("%s is documented on its handler but missing from apiOperations", endpoint)
+		}
+	}
+}
+
+func TestHandleOpenAPISpec(t *testing.T) {
+	rec := httptest.NewRecorder()
+	handleOpenAPISpec(rec, httptest.NewRequest(http.MethodGet, "/api/openapi.json", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", rec.Code)
+	}
+
+	var spec struct {
+		OpenAPI string `json:"openapi"`
+		Paths   map[string]map[string]struct {
+			OperationID string           `json:"operationId"`
+			Security    *[]any           `json:"security"`
+			Responses   map[string]any   `json:"responses"`
+			Parameters  []map[string]any `json:"parameters"`
+		} `json:"paths"`
+		Components struct {
+			Schemas map[string]struct {
+				Properties map[string]map[string]any `json:"properties"`
+				Required   []string                  `json:"required"`
+			} `json:"schemas"`
+		} `json:"components"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&spec); err != nil {
+		t.Fatalf("decode spec: %v", err)
+	}
+	if !strings.HasPrefix(spec.OpenAPI, "3.") {
+		t.Errorf("expected an OpenAPI 3 document, got %q", spec.OpenAPI)
+	}
+
+	create, ok := spec.Paths["/api/sessions"]["post"]
+	if !ok {
+		t.Fatal("expected POST /api/sessions to be described")
+	}
+	if _, ok := create.Responses["201"]; !ok {
+		t.Errorf("expected POST /api/sessions to respond 201, got %v", create.Responses)
+	}
+	if create.Security != nil {
+		t.Error("expected POST /api/sessions to use the default cookie security")
+	}
+	if login := spec.Paths["/api/auth/login"]["post"]; login.Security == nil || len(*login.Security) != 0 {
+		t.Error("expected POST /api/auth/login to need no login")
+	}
+	if params := spec.Paths["/api/sessions/{id}/windows/{index}"]["delete"].Parameters; len(params) != 2 {
+		t.Errorf("expected two path parameters, got %v", params)
+	}
+
+	request, ok := spec.Components.Schemas["CreateSessionRequest"]
+	if !ok {
+		t.Fatal("expected a CreateSessionRequest schema")
+	}
+	if request.Properties["env_vars"]["type"] != "object" || request.Properties["tags"]["type"] != "array" {
+		t.Errorf("unexpected CreateSessionRequest properties: %v", request.Properties)
+	}
+	if len(request.Required) != 1 || request.Required[0] != "name" {
+		t.Errorf("expected only name to be required, got %v", request.Required)
+	}
+
+	// auth.SessionInfo is listed first, so terminal.SessionInfo is qualified
+	if _, ok := spec.Components.Schemas["SessionInfo"].Properties["user_agent"]; !ok {
+		t.Errorf("expected SessionInfo to describe auth sessions, got %v", spec.Components.Schemas["SessionInfo"])
+	}
+	if _, ok := spec.Components.Schemas["terminal.SessionInfo"].Properties["metadata"]; !ok {
+		t.Errorf("expected a terminal.SessionInfo schema, got %v", spec.Components.Schemas["terminal.SessionInfo"])
+	}
+}
+
+func TestHandleAPIDocsPointsAtSpecUnderBasePath(t *testing.T) {
+	basePath = "/hub"
+	t.Cleanup(func() { basePath = "" })
+
+	rec := httptest.NewRecorder()
+	handleAPIDocs(rec, httptest.NewRequest(http.MethodGet, "/api/docs", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", rec.Code)
+	}
+	if !strings.Contains(rec.Body.String(), `"/hub/api/openapi.json"`) {
+		t.Errorf("expected the docs to load the spec under the base path, got:\n%s", rec.Body.String())
+	}
+}
@@ -0,0 +1,153 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/iwanhae/terminal-hub/terminal"
+)
+
+// sessionPortLister is implemented by sessions whose processes' ports can be
+// looked up
+type sessionPortLister interface {
+	ListeningPorts() ([]terminal.ListeningPort, error)
+}
+
+// proxyTarget returns the address to reach port on: the address a process in
+// the session listens on, loopback for wildcard addresses. Where ports cannot
+// be inspected the port is assumed to be on loopback.
+func proxyTarget(sess terminal.Session, port int) (string, error) {
+	if sess.GetMetadata().Backend == terminal.SessionBackendSSH {
+		return "", errors.New("SSH sessions have no local ports")
+	}
+	lister, ok := sess.(sessionPortLister)
+	if !ok {
+		return net.JoinHostPort("127.0.0.1", strconv.Itoa(port)), nil
+	}
+	ports, err := lister.ListeningPorts()
+	if errors.Is(err, terminal.ErrListeningPortsUnsupported) {
+		return net.JoinHostPort("127.0.0.1", strconv.Itoa(port)), nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	for _, listening := range ports {
+		if listening.Port != port {
+			continue
+		}
+		host := listening.Address
+		if ip := net.ParseIP(host); ip != nil && ip.IsUnspecified() {
+			host = "127.0.0.1"
+			if ip.To4() == nil {
+				host = "::1"
+			}
+		}
+		return net.JoinHostPort(host, strconv.Itoa(port)), nil
+	}
+	return "", fmt.Errorf("no process in this session listens on port %d", port)
+}
+
+// handleProxy handles /proxy/:sessionId/:port/*, reverse-proxying HTTP and
+// WebSocket requests to a port a process in the session listens on, so a dev
+// server started in a session can be previewed through the hub's origin and
+// login
+func handleProxy(w http.ResponseWriter, r *http.Request) {
+	rest := strings.TrimPrefix(r.URL.Path, "/proxy/")
+	sessionID, rest, _ := strings.Cut(rest, "/")
+	portText, upstreamPath, hasSlash := strings.Cut(rest, "/")
+	if sessionID == "" || portText == "" {
+		http.Error(w, "Session ID and port required", http.StatusBadRequest)
+		return
+	}
+	port, err := strconv.Atoi(portText)
+	if err != nil || port < 1 || port > 65535 {
+		http.Error(w, "Invalid port", http.StatusBadRequest)
+		return
+	}
+	prefix := basePath + "/proxy/" + sessionID + "/" + portText
+	// Relative URLs in the previewed page resolve against a directory
+	if !hasSlash {
+		target := prefix + "/"
+		if r.URL.RawQuery != "" {
+			target += "?" + r.URL.RawQuery
+		}
+		http.Redirect(w, r, target, http.StatusMovedPermanently)
+		return
+	}
+
+	sess, exists := sessionManager.Get(sessionID)
+	if !exists {
+		http.Error(w, "Session not found", http.StatusNotFound)
+		return
+	}
+	target, err := proxyTarget(sess, port)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+
+	proxy := &httputil.ReverseProxy{
+		Rewrite: func(pr *httputil.ProxyRequest) {
+			pr.SetURL(&url.URL{Scheme: "http", Host: target})
+			pr.Out.URL.Path = "/" + upstreamPath
+			pr.Out.URL.RawPath = ""
+			pr.SetXForwarded()
+			pr.Out.Header.Set("X-Forwarded-Prefix", prefix)
+			stripHubCookie(pr.Out)
+		},
+		ModifyResponse: func(resp *http.Response) error {
+			rewriteProxyResponse(resp, prefix)
+			return nil
+		},
+		ErrorHandler: func(w http.ResponseWriter, r *http.Request, err error) {
+			log.Printf("Proxy to session %s port %d failed: %v", sessionID, port, err)
+			http.Error(w, "Failed to reach the session's port", http.StatusBadGateway)
+		},
+	}
+	proxy.ServeHTTP(w, r)
+}
+
+// stripHubCookie keeps the hub's login cookie from the proxied server
+func stripHubCookie(r *http.Request) {
+	cookies := r.Cookies()
+	r.Header.Del("Cookie")
+	for _, cookie := range cookies {
+		if cookie.Name != sessionCookie.Name {
+			r.AddCookie(cookie)
+		}
+	}
+}
+
+// rewriteProxyResponse maps root-relative redirects and cookie paths of the
+// proxied server under prefix, and drops cookies that would replace the
+// hub's login cookie
+func rewriteProxyResponse(resp *http.Response, prefix string) {
+	if location := resp.Header.Get("Location"); strings.HasPrefix(location, "/") && !strings.HasPrefix(location, "//") {
+		resp.Header.Set("Location", prefix+location)
+	}
+
+	cookies := resp.Cookies()
+	if len(cookies) == 0 {
+		return
+	}
+	resp.Header.Del("Set-Cookie")
+	for _, cookie := range cookies {
+		if cookie.Name == sessionCookie.Name {
+			continue
+		}
+		if cookie.Path == "" || strings.HasPrefix(cookie.Path, "/") {
+			cookie.Path = prefix + cookie.Path
+		}
+		if value := cookie.String(); value != "" {
+			resp.Header.Add("Set-Cookie", value)
+		}
+	}
+}
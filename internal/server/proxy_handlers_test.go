@@ -0,0 +1,116 @@
+package server
+
+import (
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/iwanhae/terminal-hub/terminal"
+)
+
+func serveProxy(method, target string) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	handleProxy(rec, httptest.NewRequest(method, target, nil))
+	return rec
+}
+
+func TestProxyToSessionPort(t *testing.T) {
+	if _, err := exec.LookPath("python3"); err != nil {
+		t.Skip("python3 is not installed")
+	}
+	sessionManager = terminal.NewSessionManager()
+	t.Cleanup(func() { _ = sessionManager.CloseAll() })
+
+	root := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(root, "docs"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "docs", "index.html"), []byte("<h1>preview</h1>"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	port := strconv.Itoa(listener.Addr().(*net.TCPAddr).Port)
+	_ = listener.Close()
+
+	sess, err := sessionManager.CreateSession(terminal.SessionConfig{
+		ID:               "preview",
+		Name:             "dev",
+		Shell:            "/bin/sh",
+		WorkingDirectory: root,
+		Backend:          terminal.SessionBackendPTY,
+		PTYService:       &terminal.DefaultPTYService{},
+	})
+	if err != nil {
+		t.Fatalf("failed to create session: %v", err)
+	}
+
+	// Nothing listens on the port yet
+	if rec := serveProxy(http.MethodGet, "/proxy/preview/"+port+"/"); rec.Code != http.StatusBadGateway {
+		t.Errorf("closed port: expected status 502, got %d", rec.Code)
+	}
+
+	if _, err := sess.Write([]byte("python3 -m http.server --bind 127.0.0.1 " + port + "\n")); err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(10 * time.Second)
+	var rec *httptest.ResponseRecorder
+	for {
+		rec = serveProxy(http.MethodGet, "/proxy/preview/"+port+"/docs/index.html")
+		if rec.Code == http.StatusOK || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "preview") {
+		t.Fatalf("expected the file through the proxy, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	// The server's redirect from /docs to /docs/ stays under the proxy
+	rec = serveProxy(http.MethodGet, "/proxy/preview/"+port+"/docs")
+	if rec.Code != http.StatusMovedPermanently || rec.Header().Get("Location") != "/proxy/preview/"+port+"/docs/" {
+		t.Errorf("expected the redirect under the proxy prefix, got %d %q", rec.Code, rec.Header().Get("Location"))
+	}
+	rec = serveProxy(http.MethodGet, "/proxy/preview/"+port)
+	if rec.Code != http.StatusMovedPermanently || rec.Header().Get("Location") != "/proxy/preview/"+port+"/" {
+		t.Errorf("expected a redirect to the proxy root, got %d %q", rec.Code, rec.Header().Get("Location"))
+	}
+
+	if rec := serveProxy(http.MethodGet, "/proxy/preview/70000/"); rec.Code != http.StatusBadRequest {
+		t.Errorf("invalid port: expected status 400, got %d", rec.Code)
+	}
+	if rec := serveProxy(http.MethodGet, "/proxy/missing/"+port+"/"); rec.Code != http.StatusNotFound {
+		t.Errorf("unknown session: expected status 404, got %d", rec.Code)
+	}
+}
+
+func TestProxyKeepsHubCookies(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Cookie", "session_token=secret; theme=dark")
+	stripHubCookie(req)
+	if got := req.Header.Get("Cookie"); got != "theme=dark" {
+		t.Errorf("expected only the app's cookies upstream, got %q", got)
+	}
+
+	resp := &http.Response{Header: http.Header{}, Body: io.NopCloser(strings.NewReader(""))}
+	resp.Header.Add("Set-Cookie", "session_token=evil; Path=/")
+	resp.Header.Add("Set-Cookie", "sid=1; Path=/app")
+	resp.Header.Set("Location", "//example.com/")
+	rewriteProxyResponse(resp, "/proxy/s/3000")
+	if got := resp.Header.Values("Set-Cookie"); len(got) != 1 || got[0] != "sid=1; Path=/proxy/s/3000/app" {
+		t.Errorf("expected the app's cookie scoped to the proxy, got %q", got)
+	}
+	if got := resp.Header.Get("Location"); got != "//example.com/" {
+		t.Errorf("expected protocol-relative redirects unchanged, got %q", got)
+	}
+}
@@ -0,0 +1,192 @@
+package server
+
+import (
+	"log"
+	"math"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultAPIRateLimit       = 600 // requests per minute for /api/ routes
+	defaultAPIRateBurst       = 100
+	defaultSensitiveRateLimit = 30 // requests per minute for login, upload and session creation
+	defaultSensitiveRateBurst = 10
+	rateLimitIdleTimeout      = 10 * time.Minute
+)
+
+// tokenBucket holds the tokens left for one client
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// rateLimiter is a token-bucket limiter keyed by client
+type rateLimiter struct {
+	mu      sync.Mutex
+	buckets map[string]*tokenBucket
+	rate    float64 // tokens added per second
+	burst   float64
+}
+
+func newRateLimiter(perMinute, burst int) *rateLimiter {
+	if burst <= 0 {
+		burst = 1
+	}
+	return &rateLimiter{
+		buckets: make(map[string]*tokenBucket),
+		rate:    float64(perMinute) / 60,
+		burst:   float64(burst),
+	}
+}
+
+// Allow takes a token for key. When none is left it returns false and how
+// long until the next token is available.
+func (l *rateLimiter) Allow(key string, now time.Time) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	bucket, ok := l.buckets[key]
+	if !ok {
+		bucket = &tokenBucket{tokens: l.burst, last: now}
+		l.buckets[key] = bucket
+	}
+
+	if elapsed := now.Sub(bucket.last).Seconds(); elapsed > 0 {
+		bucket.tokens = math.Min(l.burst, bucket.tokens+elapsed*l.rate)
+		bucket.last = now
+	}
+
+	if bucket.tokens >= 1 {
+		bucket.tokens--
+		return true, 0
+	}
+
+	wait := time.Duration((1 - bucket.tokens) / l.rate * float64(time.Second))
+	return false, wait
+}
+
+// CleanupIdle drops buckets that have not been used for idleTimeout; they
+// would have refilled completely anyway
+func (l *rateLimiter) CleanupIdle(now time.Time, idleTimeout time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for key, bucket := range l.buckets {
+		if now.Sub(bucket.last) >= idleTimeout {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+// apiRateLimiter limits /api/ requests per client IP and per session cookie,
+// with a stricter budget for login, upload and session creation
+type apiRateLimiter struct {
+	general   *rateLimiter
+	sensitive *rateLimiter
+}
+
+// newAPIRateLimiterFromEnv configures the limiter from TERMINAL_HUB_RATE_LIMIT*
+// variables. It returns nil when TERMINAL_HUB_RATE_LIMIT is 0.
+func newAPIRateLimiterFromEnv() *apiRateLimiter {
+	limit := getEnvIntDefault("TERMINAL_HUB_RATE_LIMIT", defaultAPIRateLimit)
+	if limit <= 0 {
+		return nil
+	}
+	sensitiveLimit := getEnvIntDefault("TERMINAL_HUB_RATE_LIMIT_SENSITIVE", defaultSensitiveRateLimit)
+	if sensitiveLimit <= 0 {
+		sensitiveLimit = limit
+	}
+
+	return &apiRateLimiter{
+		general:   newRateLimiter(limit, getEnvIntDefault("TERMINAL_HUB_RATE_LIMIT_BURST", defaultAPIRateBurst)),
+		sensitive: newRateLimiter(sensitiveLimit, getEnvIntDefault("TERMINAL_HUB_RATE_LIMIT_SENSITIVE_BURST", defaultSensitiveRateBurst)),
+	}
+}
+
+// Allow checks every key of a request against the matching limiters
+func (l *apiRateLimiter) Allow(r *http.Request, now time.Time) (bool, time.Duration) {
+	keys := []string{"ip:" + extractClientIP(r)}
+	if cookie, err := sessionCookieOf(r); err == nil && cookie.Value != "" {
+		keys = append(keys, "session:"+cookie.Value)
+	}
+
+	limiters := []*rateLimiter{l.general}
+	if isSensitiveAPIRequest(r) {
+		limiters = append(limiters, l.sensitive)
+	}
+
+	for _, limiter := range limiters {
+		for _, key := range keys {
+			if allowed, wait := limiter.Allow(key, now); !allowed {
+				return false, wait
+			}
+		}
+	}
+	return true, 0
+}
+
+// StartCleanupLoop periodically forgets idle clients
+func (l *apiRateLimiter) StartCleanupLoop(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	for range ticker.C {
+		now := time.Now()
+		l.general.CleanupIdle(now, rateLimitIdleTimeout)
+		l.sensitive.CleanupIdle(now, rateLimitIdleTimeout)
+	}
+}
+
+// isSensitiveAPIRequest reports whether a request is a login, upload or
+// session creation, which get the stricter limit
+func isSensitiveAPIRequest(r *http.Request) bool {
+	if r.Method != http.MethodPost {
+		return false
+	}
+	switch strings.TrimSuffix(r.URL.Path, "/") {
+	case "/api/auth/login", "/api/upload", "/api/sessions":
+		return true
+	}
+	return false
+}
+
+// rateLimitMiddleware rejects /api/ requests over the limit with 429 and a
+// Retry-After header. A nil limiter disables rate limiting.
+func rateLimitMiddleware(next http.Handler, limiter *apiRateLimiter) http.Handler {
+	if limiter == nil {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.URL.Path, "/api/") {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if allowed, wait := limiter.Allow(r, time.Now()); !allowed {
+			retryAfter := int(math.Ceil(wait.Seconds()))
+			if retryAfter < 1 {
+				retryAfter = 1
+			}
+			log.Printf("Rate limit exceeded: ip=%s, path=%s", extractClientIP(r), r.URL.Path)
+			w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+			writeError(w, http.StatusTooManyRequests, errCodeRateLimited, "Too many requests")
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// getEnvIntDefault reads an integer environment variable, returning def when
+// it is unset or invalid
+func getEnvIntDefault(key string, def int) int {
+	if val := os.Getenv(key); val != "" {
+		if intVal, err := strconv.Atoi(val); err == nil {
+			return intVal
+		}
+	}
+	return def
+}
@@ -0,0 +1,131 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func newTestAPIRateLimiter(perMinute, burst, sensitivePerMinute, sensitiveBurst int) *apiRateLimiter {
+	return &apiRateLimiter{
+		general:   newRateLimiter(perMinute, burst),
+		sensitive: newRateLimiter(sensitivePerMinute, sensitiveBurst),
+	}
+}
+
+func performRateLimitedRequest(handler http.Handler, method, path, remoteAddr, sessionToken string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, nil)
+	req.RemoteAddr = remoteAddr
+	if sessionToken != "" {
+		req.AddCookie(&http.Cookie{Name: "session_token", Value: sessionToken})
+	}
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestRateLimiterRefillsOverTime(t *testing.T) {
+	limiter := newRateLimiter(60, 2)
+	now := time.Now()
+
+	for i := 0; i < 2; i++ {
+		if allowed, _ := limiter.Allow("client", now); !allowed {
+			t.Fatalf("request %d should be allowed within burst", i+1)
+		}
+	}
+
+	allowed, wait := limiter.Allow("client", now)
+	if allowed {
+		t.Fatalf("request beyond burst should be rejected")
+	}
+	if wait <= 0 || wait > time.Second {
+		t.Fatalf("expected wait of at most 1s, got %s", wait)
+	}
+
+	if allowed, _ := limiter.Allow("client", now.Add(time.Second)); !allowed {
+		t.Fatalf("request should be allowed after a token refilled")
+	}
+}
+
+func TestRateLimitMiddlewareReturns429WithRetryAfter(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	handler := rateLimitMiddleware(next, newTestAPIRateLimiter(60, 1, 60, 1))
+
+	first := performRateLimitedRequest(handler, http.MethodGet, "/api/sessions", "198.51.100.10:1234", "")
+	if first.Code != http.StatusOK {
+		t.Fatalf("expected first request to pass, got %d", first.Code)
+	}
+
+	second := performRateLimitedRequest(handler, http.MethodGet, "/api/sessions", "198.51.100.10:1234", "")
+	if second.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected status %d, got %d", http.StatusTooManyRequests, second.Code)
+	}
+	if got := second.Header().Get("Retry-After"); got != "1" {
+		t.Fatalf("expected Retry-After 1, got %q", got)
+	}
+
+	// Other clients and non-API paths are unaffected
+	if rec := performRateLimitedRequest(handler, http.MethodGet, "/api/sessions", "198.51.100.11:1234", ""); rec.Code != http.StatusOK {
+		t.Fatalf("expected other IP to pass, got %d", rec.Code)
+	}
+	if rec := performRateLimitedRequest(handler, http.MethodGet, "/assets/app.js", "198.51.100.10:1234", ""); rec.Code != http.StatusOK {
+		t.Fatalf("expected non-API path to pass, got %d", rec.Code)
+	}
+}
+
+func TestRateLimitMiddlewareLimitsPerSessionCookie(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	handler := rateLimitMiddleware(next, newTestAPIRateLimiter(60, 1, 60, 1))
+
+	if rec := performRateLimitedRequest(handler, http.MethodGet, "/api/crons", "198.51.100.20:1234", "token-a"); rec.Code != http.StatusOK {
+		t.Fatalf("expected first request to pass, got %d", rec.Code)
+	}
+	// Same session from a different IP shares the session budget
+	if rec := performRateLimitedRequest(handler, http.MethodGet, "/api/crons", "198.51.100.21:1234", "token-a"); rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected session to be limited, got %d", rec.Code)
+	}
+}
+
+func TestRateLimitMiddlewareAppliesStricterLimitToSensitiveEndpoints(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	handler := rateLimitMiddleware(next, newTestAPIRateLimiter(600, 100, 1, 2))
+
+	for i := 0; i < 2; i++ {
+		if rec := performRateLimitedRequest(handler, http.MethodPost, "/api/auth/login", "198.51.100.30:1234", ""); rec.Code != http.StatusOK {
+			t.Fatalf("expected login %d to pass, got %d", i+1, rec.Code)
+		}
+	}
+
+	rec := performRateLimitedRequest(handler, http.MethodPost, "/api/auth/login", "198.51.100.30:1234", "")
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected login to be limited, got %d", rec.Code)
+	}
+	if got := rec.Header().Get("Retry-After"); got != "60" {
+		t.Fatalf("expected Retry-After 60, got %q", got)
+	}
+
+	// Regular API requests still use the general budget
+	if rec := performRateLimitedRequest(handler, http.MethodGet, "/api/sessions", "198.51.100.30:1234", ""); rec.Code != http.StatusOK {
+		t.Fatalf("expected regular request to pass, got %d", rec.Code)
+	}
+}
+
+func TestRateLimitMiddlewareDisabledWithNilLimiter(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	handler := rateLimitMiddleware(next, nil)
+
+	for i := 0; i < 5; i++ {
+		if rec := performRateLimitedRequest(handler, http.MethodPost, "/api/auth/login", "198.51.100.40:1234", ""); rec.Code != http.StatusOK {
+			t.Fatalf("expected request %d to pass, got %d", i+1, rec.Code)
+		}
+	}
+}
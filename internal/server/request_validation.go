@@ -0,0 +1,149 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"reflect"
+	"strings"
+)
+
+// maxJSONBodySize is the largest JSON request body by default
+const maxJSONBodySize = 1 << 20
+
+// errBodyTooLarge is returned when a request body exceeds its operation's
+// limit
+var errBodyTooLarge = errors.New("request body is too large")
+
+// validateAs adapts a Validate method to apiOperation.Validate
+func validateAs[T any](validate func(T) error) func(any) error {
+	return func(body any) error {
+		return validate(*body.(*T))
+	}
+}
+
+// findOperation returns the operation serving method and path. A {name}
+// placeholder matches one path segment; literal segments win over
+// placeholders, so /api/sessions/adopt is not taken for /api/sessions/{id}.
+func findOperation(method, path string) *apiOperation {
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	var best *apiOperation
+	bestLiterals := -1
+	for i := range apiOperations {
+		op := &apiOperations[i]
+		if op.Method != method {
+			continue
+		}
+		opSegments := strings.Split(strings.Trim(op.Path, "/"), "/")
+		if len(opSegments) != len(segments) {
+			continue
+		}
+		literals := 0
+		matched := true
+		for j, segment := range opSegments {
+			if strings.HasPrefix(segment, "{") {
+				continue
+			}
+			if segment != segments[j] {
+				matched = false
+				break
+			}
+			literals++
+		}
+		if matched && literals > bestLiterals {
+			best, bestLiterals = op, literals
+		}
+	}
+	return best
+}
+
+// isJSONMediaType reports whether contentType is application/json or a
+// +json type such as application/merge-patch+json
+func isJSONMediaType(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	return err == nil && (mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"))
+}
+
+// readLimitedBody reads at most limit bytes of the request body
+func readLimitedBody(r *http.Request, limit int64) ([]byte, error) {
+	if r.ContentLength > limit {
+		return nil, errBodyTooLarge
+	}
+	body, err := io.ReadAll(io.LimitReader(r.Body, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(body)) > limit {
+		return nil, errBodyTooLarge
+	}
+	return body, nil
+}
+
+// validateRequestMiddleware checks the bodies of API requests against their
+// operation in apiOperations before the handler runs: the size, that JSON
+// is sent as JSON, that it decodes into the operation's request type and,
+// for operations with a Validate function, its fields. Failures are answered
+// with the error envelope. Empty bodies are left to the handler, as some
+// requests take optional bodies.
+func validateRequestMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.URL.Path, "/api/") ||
+			(r.Method != http.MethodPost && r.Method != http.MethodPut && r.Method != http.MethodPatch) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		op := findOperation(r.Method, r.URL.Path)
+		if op == nil || op.Request == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		limit := op.MaxBody
+		if limit == 0 {
+			limit = maxJSONBodySize
+		}
+		body, err := readLimitedBody(r, limit)
+		if errors.Is(err, errBodyTooLarge) {
+			writeError(w, http.StatusRequestEntityTooLarge, errCodePayloadTooLarge,
+				fmt.Sprintf("Request body must be at most %d bytes", limit))
+			return
+		}
+		if err != nil {
+			writeError(w, http.StatusBadRequest, errCodeInvalidRequest, "Failed to read request body")
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		r.ContentLength = int64(len(body))
+		if len(body) == 0 {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if !isJSONMediaType(r.Header.Get("Content-Type")) {
+			// Operations that also take another body type check it themselves
+			if op.Consumes != "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+			writeError(w, http.StatusUnsupportedMediaType, errCodeUnsupportedMedia, "Content-Type must be application/json")
+			return
+		}
+
+		decoded := reflect.New(reflect.TypeOf(op.Request)).Interface()
+		if err := json.Unmarshal(body, decoded); err != nil {
+			writeError(w, http.StatusBadRequest, errCodeInvalidJSON, "Invalid JSON: "+err.Error())
+			return
+		}
+		if op.Validate != nil {
+			if err := op.Validate(decoded); err != nil {
+				writeError(w, http.StatusBadRequest, errCodeInvalidRequest, err.Error())
+				return
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,87 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestFindOperation(t *testing.T) {
+	cases := []struct {
+		method, path, want string
+	}{
+		{http.MethodPost, "/api/sessions/adopt", "/api/sessions/adopt"},
+		{http.MethodPut, "/api/sessions/abc/", "/api/sessions/{id}"},
+		{http.MethodPost, "/api/sessions/abc/watches", "/api/sessions/{id}/watches"},
+		{http.MethodPost, "/api/unknown", ""},
+	}
+	for _, tc := range cases {
+		op := findOperation(tc.method, tc.path)
+		got := ""
+		if op != nil {
+			got = op.Path
+		}
+		if got != tc.want {
+			t.Errorf("%s %s: expected %q, got %q", tc.method, tc.path, tc.want, got)
+		}
+	}
+}
+
+func TestValidateRequestMiddleware(t *testing.T) {
+	reached := false
+	handler := validateRequestMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		reached = true
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	cases := []struct {
+		name        string
+		method      string
+		path        string
+		contentType string
+		body        string
+		status      int
+		code        string
+	}{
+		{"valid session", http.MethodPost, "/api/sessions", "application/json", `{"name":"dev"}`, http.StatusNoContent, ""},
+		{"charset parameter", http.MethodPost, "/api/sessions", "application/json; charset=utf-8", `{"name":"dev"}`, http.StatusNoContent, ""},
+		{"empty body", http.MethodPost, "/api/sessions/abc/windows", "", "", http.StatusNoContent, ""},
+		{"too large", http.MethodPost, "/api/sessions", "application/json", `{"name":"` + strings.Repeat("a", maxJSONBodySize) + `"}`, http.StatusRequestEntityTooLarge, errCodePayloadTooLarge},
+		{"form body", http.MethodPost, "/api/sessions", "application/x-www-form-urlencoded", `{"name":"dev"}`, http.StatusUnsupportedMediaType, errCodeUnsupportedMedia},
+		{"malformed json", http.MethodPost, "/api/sessions", "application/json", `{"name":`, http.StatusBadRequest, errCodeInvalidJSON},
+		{"wrong field type", http.MethodPost, "/api/sessions", "application/json", `{"name":1}`, http.StatusBadRequest, errCodeInvalidJSON},
+		{"long name", http.MethodPost, "/api/sessions", "application/json", `{"name":"` + strings.Repeat("a", 200) + `"}`, http.StatusBadRequest, errCodeInvalidRequest},
+		{"bad env var", http.MethodPost, "/api/sessions", "application/json", `{"name":"dev","env_vars":{"1A":"x"}}`, http.StatusBadRequest, errCodeInvalidRequest},
+		{"empty update", http.MethodPut, "/api/sessions/abc", "application/json", `{}`, http.StatusBadRequest, errCodeInvalidRequest},
+		{"crontab import", http.MethodPost, "/api/crons/import", "text/plain", "* * * * * true\n", http.StatusNoContent, ""},
+		{"raw upload", http.MethodPost, "/api/upload", "application/octet-stream", "\x00\x01", http.StatusNoContent, ""},
+		{"no body operation", http.MethodPost, "/api/crons/abc/run", "text/plain", "ignored", http.StatusNoContent, ""},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			reached = false
+			req := httptest.NewRequest(tc.method, tc.path, strings.NewReader(tc.body))
+			if tc.contentType != "" {
+				req.Header.Set("Content-Type", tc.contentType)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			if rec.Code != tc.status {
+				t.Fatalf("expected status %d, got %d: %s", tc.status, rec.Code, rec.Body.String())
+			}
+			if tc.code == "" {
+				if !reached {
+					t.Error("expected the request to reach the handler")
+				}
+				return
+			}
+			if reached {
+				t.Error("expected the request to be refused before the handler")
+			}
+			if apiErr := decodeAPIError(t, rec); apiErr.Code != tc.code {
+				t.Errorf("expected code %q, got %+v", tc.code, apiErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,101 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/iwanhae/terminal-hub/auth"
+	"github.com/iwanhae/terminal-hub/notify"
+	"github.com/iwanhae/terminal-hub/webhook"
+)
+
+// deviceCookieMaxAge is how long a browser keeps its device cookie
+const deviceCookieMaxAge = 365 * 24 * time.Hour
+
+// adminEmail emails security alerts and cron failures to the addresses in
+// TERMINAL_HUB_ADMIN_EMAIL, nil when that or SMTP is not configured
+var adminEmail notify.Channel
+
+// adminEmailEvents are the events emailed to the admin
+var adminEmailEvents = []string{notify.EventCronFailed, notify.EventLoginBanned, notify.EventNewDevice, notify.EventDiskLow}
+
+// knownDevices remembers the browsers users logged in from, nil when the
+// store could not be opened
+var knownDevices *auth.DeviceStore
+
+// publishNotification sends n to the push subscriptions and, for security
+// alerts and cron failures, emails it to the admin
+func publishNotification(n notify.Notification) {
+	if notifier != nil {
+		notifier.Notify(n)
+	}
+	if adminEmail != nil && slices.Contains(adminEmailEvents, n.Event) {
+		go func() {
+			ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+			defer cancel()
+			if err := adminEmail.Send(ctx, n); err != nil {
+				log.Printf("Error emailing %s alert to the admin: %v", n.Event, err)
+			}
+		}()
+	}
+}
+
+// alertLoginBan reports an IP banned after repeated failed logins
+func alertLoginBan(clientIP string, banDuration time.Duration) {
+	publishWebhookEvent(webhook.EventAuthBanned, authWebhookEvent{IP: clientIP, BanSeconds: int64(banDuration.Seconds())})
+	publishNotification(notify.Notification{
+		Event:    notify.EventLoginBanned,
+		Title:    "Repeated failed logins",
+		Message:  fmt.Sprintf("%s was banned for %s after repeated failed login attempts.", clientIP, banDuration.Round(time.Second)),
+		Tags:     []string{"rotating_light"},
+		Priority: notify.PriorityHigh,
+	})
+}
+
+// recordLoginDevice remembers the browser of a successful login, setting its
+// device cookie, and reports logins from browsers not seen before. It returns
+// the device ID, or "" when devices are not tracked.
+func recordLoginDevice(w http.ResponseWriter, r *http.Request, username, clientIP string) string {
+	if knownDevices == nil {
+		return ""
+	}
+
+	deviceID := ""
+	if cookie, err := r.Cookie(auth.DeviceCookieName); err == nil {
+		deviceID = cookie.Value
+	}
+	device, isNew, err := knownDevices.RecordLogin(deviceID, username, r.UserAgent(), clientIP, time.Now())
+	if err != nil {
+		log.Printf("Error recording login device: %v", err)
+	}
+	if device.ID == "" {
+		return ""
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     auth.DeviceCookieName,
+		Value:    device.ID,
+		MaxAge:   int(deviceCookieMaxAge.Seconds()),
+		HttpOnly: true,
+		Secure:   isSecure(r),
+		SameSite: http.SameSiteLaxMode,
+		Path:     cookiePath(),
+	})
+
+	if isNew {
+		log.Printf("New device login for %s from %s (%s)", username, clientIP, device.UserAgent)
+		publishWebhookEvent(webhook.EventAuthNewDevice, authWebhookEvent{Username: username, IP: clientIP, Via: "web", UserAgent: device.UserAgent})
+		publishNotification(notify.Notification{
+			Event:    notify.EventNewDevice,
+			Title:    "Login from a new device",
+			Message:  fmt.Sprintf("%s logged in from a new device.\nIP: %s\nBrowser: %s", username, clientIP, device.UserAgent),
+			Tags:     []string{"key"},
+			Priority: notify.PriorityHigh,
+		})
+	}
+	return device.ID
+}
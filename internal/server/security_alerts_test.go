@@ -0,0 +1,97 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/iwanhae/terminal-hub/auth"
+	"github.com/iwanhae/terminal-hub/notify"
+)
+
+// recordingChannel captures notifications sent to it
+type recordingChannel struct {
+	sent chan notify.Notification
+}
+
+func (c recordingChannel) Send(ctx context.Context, n notify.Notification) error {
+	c.sent <- n
+	return nil
+}
+
+func expectAlert(t *testing.T, sent chan notify.Notification, event string) notify.Notification {
+	t.Helper()
+	select {
+	case n := <-sent:
+		if n.Event != event {
+			t.Fatalf("expected a %s alert, got %+v", event, n)
+		}
+		return n
+	case <-time.After(2 * time.Second):
+		t.Fatalf("timed out waiting for a %s alert", event)
+	}
+	return notify.Notification{}
+}
+
+func TestLoginSecurityAlerts(t *testing.T) {
+	sent := make(chan notify.Notification, 8)
+	adminEmail = recordingChannel{sent: sent}
+	devices, err := auth.OpenDeviceStore(filepath.Join(t.TempDir(), "devices.json"))
+	if err != nil {
+		t.Fatalf("failed to open device store: %v", err)
+	}
+	knownDevices = devices
+	t.Cleanup(func() {
+		adminEmail = nil
+		knownDevices = nil
+	})
+
+	sm := newTestAuthSessionManager()
+	rec := performLoginRequest(t, sm, nil, "203.0.113.5", "", "admin", "secret")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("login: expected status 200, got %d", rec.Code)
+	}
+	alert := expectAlert(t, sent, notify.EventNewDevice)
+	if alert.Priority != notify.PriorityHigh {
+		t.Errorf("expected a high priority alert, got %+v", alert)
+	}
+
+	var deviceCookie *http.Cookie
+	for _, cookie := range rec.Result().Cookies() {
+		if cookie.Name == auth.DeviceCookieName {
+			deviceCookie = cookie
+		}
+	}
+	if deviceCookie == nil || deviceCookie.Value == "" || !deviceCookie.HttpOnly {
+		t.Fatalf("expected an HttpOnly device cookie, got %+v", rec.Result().Cookies())
+	}
+
+	// The same browser logging in again is not reported
+	req := httptest.NewRequest(http.MethodPost, "/api/auth/login", strings.NewReader(`{"username":"admin","password":"secret"}`))
+	req.AddCookie(deviceCookie)
+	rec = httptest.NewRecorder()
+	handleLogin(rec, req, sm, nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("second login: expected status 200, got %d", rec.Code)
+	}
+	select {
+	case n := <-sent:
+		t.Fatalf("unexpected alert %+v", n)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	// Another user on the same browser counts as a new device
+	if _, isNew, _ := knownDevices.RecordLogin(deviceCookie.Value, "guest", "", "203.0.113.5", time.Now()); !isNew {
+		t.Error("expected a device of another user to be new")
+	}
+
+	banTracker := newLoginFail2Ban(2, time.Hour)
+	for i := 0; i < 2; i++ {
+		performLoginRequest(t, sm, banTracker, "198.51.100.7", "", "admin", "wrong")
+	}
+	expectAlert(t, sent, notify.EventLoginBanned)
+}
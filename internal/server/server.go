@@ -18,25 +18,61 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/gorilla/websocket"
 	"github.com/iwanhae/terminal-hub/auth"
+	"github.com/iwanhae/terminal-hub/credstore"
 	"github.com/iwanhae/terminal-hub/cron"
+	"github.com/iwanhae/terminal-hub/diskguard"
 	"github.com/iwanhae/terminal-hub/frontend/dist"
+	"github.com/iwanhae/terminal-hub/hooks"
+	"github.com/iwanhae/terminal-hub/notify"
+	"github.com/iwanhae/terminal-hub/sysstats"
 	"github.com/iwanhae/terminal-hub/terminal"
+	"github.com/iwanhae/terminal-hub/webhook"
 )
 
 // WebSocketClientImpl implements terminal.WebSocketClient for gorilla/websocket
 type WebSocketClientImpl struct {
 	conn *websocket.Conn
-	send chan []byte
+	send chan outgoingMessage
 	mu   sync.Mutex
+	name string // display name shown to other clients of the session
+
+	since    uint64 // output sequence number to resume from
+	resuming bool
+
+	remoteIP  string
+	userAgent string
+	bytesSent atomic.Uint64 // bytes written to the connection, including messages
+}
+
+// outgoingMessage is a frame queued for the write pump. Terminal output is
+// sent as binary frames and structured messages as JSON text frames.
+type outgoingMessage struct {
+	messageType int
+	data        []byte
 }
 
-// Send sends data to the WebSocket client
+// Send sends terminal output to the WebSocket client
 func (c *WebSocketClientImpl) Send(data []byte) error {
+	return c.queue(outgoingMessage{messageType: websocket.BinaryMessage, data: data})
+}
+
+// SendMessage sends a structured message to the WebSocket client
+func (c *WebSocketClientImpl) SendMessage(msg terminal.ServerMessage) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	return c.queue(outgoingMessage{messageType: websocket.TextMessage, data: data})
+}
+
+// queue hands a frame to the write pump
+func (c *WebSocketClientImpl) queue(message outgoingMessage) error {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
@@ -45,13 +81,33 @@ func (c *WebSocketClientImpl) Send(data []byte) error {
 	}
 
 	select {
-	case c.send <- data:
+	case c.send <- message:
 		return nil
 	case <-time.After(2 * time.Second):
 		return os.ErrDeadlineExceeded
 	}
 }
 
+// ClientName returns the display name the client connected with
+func (c *WebSocketClientImpl) ClientName() string {
+	return c.name
+}
+
+// ConnectionInfo reports where the client connected from and how much it
+// was sent
+func (c *WebSocketClientImpl) ConnectionInfo() terminal.ClientConnection {
+	return terminal.ClientConnection{
+		RemoteIP:  c.remoteIP,
+		UserAgent: c.userAgent,
+		BytesSent: c.bytesSent.Load(),
+	}
+}
+
+// ResumeFrom returns the output sequence number given with ?since=
+func (c *WebSocketClientImpl) ResumeFrom() (uint64, bool) {
+	return c.since, c.resuming
+}
+
 // Close closes the WebSocket connection
 func (c *WebSocketClientImpl) Close() error {
 	c.mu.Lock()
@@ -67,6 +123,14 @@ func (c *WebSocketClientImpl) Close() error {
 var sessionManager *terminal.SessionManager
 var cronManager *cron.CronManager
 
+// executionAllowlist restricts the shells and working directories that new
+// sessions and cron jobs may request
+var executionAllowlist terminal.ExecutionAllowlist
+
+// sessionTemplates holds saved session configurations, nil when the store
+// could not be opened
+var sessionTemplates *terminal.SessionTemplateStore
+
 const (
 	uploadPathHeader      = "X-Terminal-Hub-Upload-Path"
 	uploadFilenameHeader  = "X-Terminal-Hub-Upload-Filename"
@@ -81,6 +145,37 @@ var (
 	websocketReadLimit  int64 = 64 * 1024
 )
 
+// configureWebSocketKeepaliveFromEnv applies TERMINAL_HUB_WS_PING_INTERVAL
+// and TERMINAL_HUB_WS_PONG_TIMEOUT. Connections that send nothing, not even a
+// pong, for the pong timeout are closed. Lower values clean up clients that
+// dropped off the network sooner at the cost of more ping traffic.
+func configureWebSocketKeepaliveFromEnv() error {
+	pingPeriod, pongWait := websocketPingPeriod, websocketPongWait
+	for _, setting := range []struct {
+		key   string
+		value *time.Duration
+	}{
+		{"TERMINAL_HUB_WS_PING_INTERVAL", &pingPeriod},
+		{"TERMINAL_HUB_WS_PONG_TIMEOUT", &pongWait},
+	} {
+		raw := os.Getenv(setting.key)
+		if raw == "" {
+			continue
+		}
+		d, err := time.ParseDuration(raw)
+		if err != nil || d <= 0 {
+			return fmt.Errorf("%s must be a positive duration, got %q", setting.key, raw)
+		}
+		*setting.value = d
+	}
+	if pingPeriod >= pongWait {
+		return fmt.Errorf("ping interval %s must be shorter than pong timeout %s", pingPeriod, pongWait)
+	}
+
+	websocketPingPeriod, websocketPongWait = pingPeriod, pongWait
+	return nil
+}
+
 var uploadCopyBufferPool = sync.Pool{
 	New: func() interface{} {
 		return make([]byte, uploadCopyBufferSize)
@@ -93,49 +188,64 @@ var upgrader = websocket.Upgrader{
 	CheckOrigin: func(r *http.Request) bool {
 		return true // Allow all for demo
 	},
+	// Negotiate permessage-deflate with clients that offer it; terminal
+	// output compresses well, which matters on slow mobile links
+	EnableCompression: true,
 }
 
 // sessionAuthMiddleware validates session cookies
 func sessionAuthMiddleware(next http.HandlerFunc, sm *auth.SessionManager) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		// Skip auth if not configured
+		// Skip auth if not configured, unless every visitor is a demo visitor
 		if !sm.IsConfigured() {
+			if demoMode.Enabled {
+				serveDemoVisitor(w, r, next)
+				return
+			}
 			next(w, r)
 			return
 		}
 
 		// Extract session cookie
-		cookie, err := r.Cookie("session_token")
+		cookie, err := sessionCookieOf(r)
 		if err != nil {
-			if isAPIRequest(r) {
-				http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			if demoMode.Enabled {
+				serveDemoVisitor(w, r, next)
+			} else if isAPIRequest(r) {
+				writeError(w, http.StatusUnauthorized, errCodeUnauthorized, "Unauthorized")
 			} else {
-				http.Redirect(w, r, "/login", http.StatusSeeOther)
+				http.Redirect(w, r, basePath+"/login", http.StatusSeeOther)
 			}
 			return
 		}
 
 		// Validate session
-		_, valid := sm.ValidateSession(cookie.Value)
+		session, valid := sm.ValidateSession(cookie.Value)
 		if !valid {
 			// Clear invalid cookie
-			http.SetCookie(w, &http.Cookie{
-				Name:     "session_token",
-				Value:    "",
-				MaxAge:   -1,
-				HttpOnly: true,
-				Secure:   isSecure(r),
-				SameSite: http.SameSiteLaxMode,
-				Path:     "/",
-			})
+			clearSessionCookie(w, r)
 
-			if isAPIRequest(r) {
-				http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			if demoMode.Enabled {
+				serveDemoVisitor(w, r, next)
+			} else if isAPIRequest(r) {
+				writeError(w, http.StatusUnauthorized, errCodeUnauthorized, "Unauthorized")
 			} else {
-				http.Redirect(w, r, "/login", http.StatusSeeOther)
+				http.Redirect(w, r, basePath+"/login", http.StatusSeeOther)
 			}
 			return
 		}
+		sm.NoteClientIP(cookie.Value, extractClientIP(r))
+		if sessionCookie.Rolling {
+			// Push the cookie's expiry out with the session's
+			setSessionCookie(w, r, sm, session)
+		}
+
+		// Until a required password change is done, only the auth endpoints
+		// answer; pages still load, so the frontend can ask for the change
+		if isAPIRequest(r) && !strings.HasPrefix(r.URL.Path, "/api/auth/") && sm.MustChangePassword(session.Username) {
+			writeError(w, http.StatusForbidden, errCodePasswordChangeRequired, "Password change required")
+			return
+		}
 
 		next(w, r)
 	}
@@ -174,16 +284,18 @@ func isPublicPath(path string) bool {
 	return false
 }
 
-// isAPIRequest checks if request is for API/WebSocket
+// isAPIRequest checks if request is for API/WebSocket/SSE
 func isAPIRequest(r *http.Request) bool {
 	return strings.HasPrefix(r.URL.Path, "/api/") ||
-		strings.HasPrefix(r.URL.Path, "/ws/")
+		strings.HasPrefix(r.URL.Path, "/ws/") ||
+		strings.HasPrefix(r.URL.Path, "/sse/")
 }
 
-// isSecure checks if using HTTPS
+// isSecure reports whether the client connected over HTTPS. X-Forwarded-Proto
+// is only honored from trusted proxies.
 func isSecure(r *http.Request) bool {
-	return r.URL.Scheme == "https" ||
-		r.Header.Get("X-Forwarded-Proto") == "https"
+	return r.TLS != nil || r.URL.Scheme == "https" ||
+		(r.Header.Get("X-Forwarded-Proto") == "https" && trustedProxies.trustsPeer(r))
 }
 
 func writeLoginResponse(w http.ResponseWriter, statusCode int, success bool, message string) {
@@ -198,7 +310,7 @@ func writeLoginResponse(w http.ResponseWriter, statusCode int, success bool, mes
 // handleLogin handles POST /api/auth/login
 func handleLogin(w http.ResponseWriter, r *http.Request, sm *auth.SessionManager, banTracker *loginFail2Ban) {
 	if r.Method != http.MethodPost {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		writeError(w, http.StatusMethodNotAllowed, errCodeMethodNotAllowed, "Method not allowed")
 		return
 	}
 
@@ -213,12 +325,13 @@ func handleLogin(w http.ResponseWriter, r *http.Request, sm *auth.SessionManager
 
 	var req auth.LoginRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Bad request", http.StatusBadRequest)
+		writeError(w, http.StatusBadRequest, errCodeInvalidJSON, "Bad request")
 		return
 	}
 
 	// Validate credentials
 	if !sm.ValidateCredentials(req.Username, req.Password) {
+		publishWebhookEvent(webhook.EventAuthLoginFailed, authWebhookEvent{Username: req.Username, IP: clientIP, Via: "web", UserAgent: r.UserAgent()})
 		if banTracker == nil {
 			writeLoginResponse(w, http.StatusUnauthorized, false, "Invalid username or password")
 			return
@@ -227,6 +340,7 @@ func handleLogin(w http.ResponseWriter, r *http.Request, sm *auth.SessionManager
 		banned, remaining := banTracker.RecordFailure(clientIP, time.Now())
 		if banned {
 			logIPBanTriggered(clientIP, remaining)
+			alertLoginBan(clientIP, remaining)
 			writeLoginResponse(w, http.StatusTooManyRequests, false, loginBanMessage(remaining))
 			return
 		}
@@ -240,94 +354,171 @@ func handleLogin(w http.ResponseWriter, r *http.Request, sm *auth.SessionManager
 	}
 
 	// Create session
-	session, err := sm.CreateSession(req.Username)
+	deviceID := recordLoginDevice(w, r, req.Username, clientIP)
+	session, err := sm.CreateClientSession(req.Username, auth.ClientInfo{
+		UserAgent: r.UserAgent(),
+		IP:        clientIP,
+		DeviceID:  deviceID,
+	}, req.Persistence())
 	if err != nil {
 		log.Printf("Error creating session: %v", err)
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		writeError(w, http.StatusInternalServerError, errCodeInternal, "Internal server error")
 		return
 	}
 
 	// Set secure cookie
-	http.SetCookie(w, &http.Cookie{
-		Name:     "session_token",
-		Value:    session.ID,
-		Expires:  time.Now().Add(24 * time.Hour),
-		HttpOnly: true,
-		Secure:   isSecure(r),
-		SameSite: http.SameSiteLaxMode,
-		Path:     "/",
-	})
+	setSessionCookie(w, r, sm, session)
 
+	publishWebhookEvent(webhook.EventAuthLogin, authWebhookEvent{Username: req.Username, IP: clientIP, Via: "web", UserAgent: r.UserAgent()})
 	writeLoginResponse(w, http.StatusOK, true, "Login successful")
 }
 
 // handleLogout handles POST /api/auth/logout
 func handleLogout(w http.ResponseWriter, r *http.Request, sm *auth.SessionManager) {
 	if r.Method != http.MethodPost {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		writeError(w, http.StatusMethodNotAllowed, errCodeMethodNotAllowed, "Method not allowed")
 		return
 	}
 
 	// Delete session
-	if cookie, err := r.Cookie("session_token"); err == nil {
+	if cookie, err := sessionCookieOf(r); err == nil {
+		if session, valid := sm.ValidateSession(cookie.Value); valid {
+			publishWebhookEvent(webhook.EventAuthLogout, authWebhookEvent{Username: session.Username, IP: extractClientIP(r), Via: "web", UserAgent: r.UserAgent()})
+		}
 		sm.DeleteSession(cookie.Value)
 	}
 
 	// Clear cookie
-	http.SetCookie(w, &http.Cookie{
-		Name:     "session_token",
-		Value:    "",
-		MaxAge:   -1,
-		HttpOnly: true,
-		Secure:   isSecure(r),
-		SameSite: http.SameSiteLaxMode,
-		Path:     "/",
-	})
+	clearSessionCookie(w, r)
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]bool{"success": true})
+	json.NewEncoder(w).Encode(logoutResponse{Success: true})
+}
+
+// logoutResponse is the body of POST /api/auth/logout
+type logoutResponse struct {
+	Success bool `json:"success"`
+}
+
+// authStatusResponse is the body of GET /api/auth/status
+type authStatusResponse struct {
+	Authenticated      bool                 `json:"authenticated"`
+	Username           string               `json:"username"`
+	MustChangePassword bool                 `json:"must_change_password"`      // the user must change their password before using the API
+	PasswordPolicy     *auth.PasswordPolicy `json:"password_policy,omitempty"` // what a new password must meet, for logged-in users
+	Demo               bool                 `json:"demo,omitempty"`            // not logged in, but may watch the demo sessions read-only
 }
 
 // handleAuthStatus handles GET /api/auth/status
 func handleAuthStatus(w http.ResponseWriter, r *http.Request, sm *auth.SessionManager) {
 	if r.Method != http.MethodGet {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		writeError(w, http.StatusMethodNotAllowed, errCodeMethodNotAllowed, "Method not allowed")
 		return
 	}
 
 	// If authentication is not configured, allow access without a session
 	if !sm.IsConfigured() {
 		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(map[string]interface{}{
-			"authenticated": true,
-			"username":      "",
-		})
+		json.NewEncoder(w).Encode(authStatusResponse{Authenticated: !demoMode.Enabled, Demo: demoMode.Enabled})
 		return
 	}
 
-	cookie, err := r.Cookie("session_token")
-	authenticated := false
-	username := ""
+	cookie, err := sessionCookieOf(r)
+	status := authStatusResponse{Demo: demoMode.Enabled}
 
 	if err == nil {
 		if session, valid := sm.ValidateSession(cookie.Value); valid {
-			authenticated = true
-			username = session.Username
+			if sessionCookie.Rolling {
+				setSessionCookie(w, r, sm, session)
+			}
+			policy := sm.PasswordPolicy()
+			status = authStatusResponse{
+				Authenticated:      true,
+				Username:           session.Username,
+				MustChangePassword: sm.MustChangePassword(session.Username),
+				PasswordPolicy:     &policy,
+			}
 		}
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]interface{}{
-		"authenticated": authenticated,
-		"username":      username,
-	})
+	json.NewEncoder(w).Encode(status)
 }
 
 // InitSessionManager initializes the global session manager
 func InitSessionManager() error {
-	sessionManager = terminal.NewSessionManager()
+	sessionManager = terminal.NewSessionManagerWithLimits(
+		terminal.GetMaxSessionsFromEnv(),
+		terminal.GetMaxClientsPerSessionFromEnv(),
+	)
+
+	runAs, err := terminal.GetRunAsFromEnv()
+	if err != nil {
+		return fmt.Errorf("invalid run-as configuration: %w", err)
+	}
+	if runAs != nil {
+		log.Printf("Running sessions and cron jobs as uid=%d gid=%d (home: %s)", runAs.UID, runAs.GID, runAs.Home)
+		sessionManager.SetRunAs(runAs)
+	}
+
+	sessionManager.SetSSHGateway(terminal.NewSSHGateway(terminal.GetSSHConfigFromEnv()))
+
+	prefs, err := terminal.OpenSessionPrefsStore(terminal.GetSessionPrefsPathFromEnv())
+	if err != nil {
+		log.Printf("Warning: session pins and order will not persist: %v", err)
+	} else {
+		sessionManager.SetPrefsStore(prefs)
+	}
+
+	channels, err := notify.LoadChannels(notify.GetChannelsPathFromEnv())
+	if err != nil {
+		log.Printf("Warning: notification channels are unavailable: %v", err)
+	} else {
+		notifyChannels = channels
+		sessionManager.SetNotificationChannels(channels)
+		if names := channels.Names(); len(names) > 0 {
+			log.Printf("Notification channels: %s", strings.Join(names, ", "))
+		}
+	}
+
+	templates, err := terminal.OpenSessionTemplateStore(terminal.GetSessionTemplatesPathFromEnv())
+	if err != nil {
+		log.Printf("Warning: session templates are unavailable: %v", err)
+	} else {
+		sessionTemplates = templates
+	}
+
+	if upgradeHandoff != nil {
+		restored := sessionManager.RestoreSessions(upgradeHandoff.Sessions)
+		log.Printf("Reattached %d of %d sessions handed over by the previous process", restored, len(upgradeHandoff.Sessions))
+		return nil
+	}
+	return startAutostartSessions()
+}
+
+// startAutostartSessions creates a session from every autostart template, or
+// a "default" session when there are none
+func startAutostartSessions() error {
+	var autostart []terminal.SessionTemplate
+	if sessionTemplates != nil {
+		autostart = sessionTemplates.Autostart()
+	}
+	if len(autostart) == 0 {
+		return createInitialSession("default")
+	}
 
-	return createInitialSession("default")
+	for _, template := range autostart {
+		if err := executionAllowlist.Check(template.ShellPath, template.WorkingDirectory); err != nil {
+			log.Printf("Error starting session from template %q: %v", template.Name, err)
+			continue
+		}
+		if _, err := sessionManager.CreateSession(template.SessionConfig(uuid.New().String())); err != nil {
+			log.Printf("Error starting session from template %q: %v", template.Name, err)
+			continue
+		}
+		log.Printf("Started session %q from template", template.Name)
+	}
+	return nil
 }
 
 func createInitialSession(name string) error {
@@ -348,52 +539,145 @@ func createInitialSession(name string) error {
 
 // -- REST API Handlers --
 
+// selectedSessionPage is a terminal.SessionPage whose sessions may be
+// reduced to the requested fields
+type selectedSessionPage struct {
+	Sessions any `json:"sessions"`
+	Total    int `json:"total"`
+	Limit    int `json:"limit"`
+	Offset   int `json:"offset"`
+}
+
 // handleListSessions handles GET /api/sessions
 func handleListSessions(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		writeError(w, http.StatusMethodNotAllowed, errCodeMethodNotAllowed, "Method not allowed")
 		return
 	}
 
-	sessions := sessionManager.ListSessionsInfo()
+	// Optional filters: ?tag=a&tag=b&name=...&state=attached|detached
+	// and ?sort=custom|created_at|last_activity_at|name&order=asc|desc. The
+	// default custom order lists pinned sessions first, then the order set via
+	// /api/sessions/reorder. Times sort newest first unless an order is given.
+	// With ?limit=&offset= a page with the total is returned instead of the
+	// list, and ?fields=name,tags keeps only those metadata fields.
+	query := r.URL.Query()
+	filter := terminal.SessionFilter{
+		Tags:  query["tag"],
+		Name:  query.Get("name"),
+		State: query.Get("state"),
+		Sort:  query.Get("sort"),
+	}
+	switch query.Get("order") {
+	case "":
+		filter.Descending = filter.Sort == terminal.SessionSortCreatedAt || filter.Sort == terminal.SessionSortLastActivity
+	case "asc":
+	case "desc":
+		filter.Descending = true
+	default:
+		writeError(w, http.StatusBadRequest, errCodeInvalidRequest, `order must be "asc" or "desc"`)
+		return
+	}
+	if err := filter.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, errCodeInvalidRequest, err.Error())
+		return
+	}
 
-	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(sessions); err != nil {
-		log.Printf("Error encoding sessions: %v", err)
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	paged := query.Has("limit") || query.Has("offset")
+	var limit, offset int
+	for name, target := range map[string]*int{"limit": &limit, "offset": &offset} {
+		if raw := query.Get(name); raw != "" {
+			value, err := strconv.Atoi(raw)
+			if err != nil {
+				writeError(w, http.StatusBadRequest, errCodeInvalidRequest, fmt.Sprintf("invalid %s: %q", name, raw))
+				return
+			}
+			*target = value
+		}
+	}
+	var fields []string
+	for _, value := range query["fields"] {
+		for _, field := range strings.Split(value, ",") {
+			if field = strings.TrimSpace(field); field != "" {
+				fields = append(fields, field)
+			}
+		}
+	}
+	if err := terminal.ValidateSessionFields(fields); err != nil {
+		writeError(w, http.StatusBadRequest, errCodeInvalidRequest, err.Error())
+		return
 	}
+
+	sessions := filter.Apply(sessionManager.ListSessionsInfo())
+	if isDemoVisitor(r) {
+		for i := range sessions {
+			sessions[i].Metadata = redactDemoMetadata(sessions[i].Metadata)
+		}
+	}
+	page := terminal.SessionPage{Sessions: sessions, Total: len(sessions)}
+	if paged {
+		var err error
+		if page, err = terminal.PageSessions(sessions, limit, offset); err != nil {
+			writeError(w, http.StatusBadRequest, errCodeInvalidRequest, err.Error())
+			return
+		}
+	}
+	var response any = page.Sessions
+	if fields != nil {
+		selected, err := terminal.SelectSessionFields(page.Sessions, fields)
+		if err != nil {
+			log.Printf("Error selecting session fields: %v", err)
+			writeError(w, http.StatusInternalServerError, errCodeInternal, "Internal server error")
+			return
+		}
+		response = selected
+	}
+	if paged {
+		response = selectedSessionPage{Sessions: response, Total: page.Total, Limit: page.Limit, Offset: page.Offset}
+	}
+
+	writeJSONWithETag(w, r, response)
 }
 
 // handleCreateSession handles POST /api/sessions
 func handleCreateSession(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		writeError(w, http.StatusMethodNotAllowed, errCodeMethodNotAllowed, "Method not allowed")
 		return
 	}
 
 	var req terminal.CreateSessionRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		log.Printf("Error decoding request: %v", err)
-		http.Error(w, "Bad request", http.StatusBadRequest)
+		writeError(w, http.StatusBadRequest, errCodeInvalidJSON, "Bad request")
 		return
 	}
 
-	// Validate request
-	if req.Name == "" {
-		http.Error(w, "Name is required", http.StatusBadRequest)
+	if err := req.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, errCodeInvalidRequest, err.Error())
 		return
 	}
-
-	requestedBackend := terminal.SessionBackend(
-		strings.ToLower(strings.TrimSpace(string(req.Backend))),
-	)
-	if requestedBackend == "" {
-		requestedBackend = terminal.SessionBackendTmux
+	requestedBackend, _ := terminal.NormalizeBackend(req.Backend)
+	tags, _ := terminal.NormalizeTags(req.Tags)
+	var resizePolicy terminal.ResizePolicy
+	if req.ResizePolicy != nil {
+		resizePolicy = *req.ResizePolicy
 	}
-	if requestedBackend != terminal.SessionBackendTmux &&
-		requestedBackend != terminal.SessionBackendPTY {
-		http.Error(w, `Backend must be either "tmux" or "pty"`, http.StatusBadRequest)
-		return
+	var exitActions terminal.ExitActions
+	if req.OnExit != nil {
+		exitActions = *req.OnExit
+	}
+	var limits terminal.ResourceLimits
+	if req.Limits != nil {
+		limits = *req.Limits
+	}
+
+	// The allowlist guards local shells; ssh sessions run on the remote host
+	if requestedBackend != terminal.SessionBackendSSH {
+		if err := executionAllowlist.Check(req.ShellPath, req.WorkingDirectory); err != nil {
+			writeError(w, http.StatusBadRequest, errCodeInvalidRequest, err.Error())
+			return
+		}
 	}
 
 	// Generate a unique session ID
@@ -409,13 +693,29 @@ func handleCreateSession(w http.ResponseWriter, r *http.Request) {
 		Shell:            req.ShellPath,
 		Backend:          requestedBackend,
 		HistorySize:      4096,
+		Limits:           limits,
+		SSH:              req.SSH,
+		Tags:             tags,
+		InputMode:        req.InputMode,
+		ResizePolicy:     resizePolicy,
+		ExitActions:      exitActions,
 	}
 
 	// Create the session
 	sess, err := sessionManager.CreateSession(config)
 	if err != nil {
 		log.Printf("Error creating session: %v", err)
-		http.Error(w, "Failed to create session", http.StatusInternalServerError)
+		var limitErr *terminal.LimitError
+		if errors.As(err, &limitErr) {
+			writeLimitError(w, http.StatusTooManyRequests, limitErr)
+			return
+		}
+		if requestedBackend == terminal.SessionBackendSSH {
+			// Connection and host key errors are actionable for the user
+			writeError(w, http.StatusBadGateway, errCodeUpstreamFailed, err.Error())
+			return
+		}
+		writeError(w, http.StatusInternalServerError, errCodeInternal, "Failed to create session")
 		return
 	}
 
@@ -432,10 +732,176 @@ func handleCreateSession(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// handleSessionClone handles POST /api/sessions/:id/clone, starting a new
+// session with the shell, working directory, environment variables and
+// startup command of an existing one. The body may set the new name.
+func handleSessionClone(w http.ResponseWriter, r *http.Request, sessionID string) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, errCodeMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	var req terminal.CloneSessionRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			log.Printf("Error decoding request: %v", err)
+			writeError(w, http.StatusBadRequest, errCodeInvalidJSON, "Bad request")
+			return
+		}
+	}
+	if err := req.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, errCodeInvalidRequest, err.Error())
+		return
+	}
+
+	source, ok := sessionManager.Get(sessionID)
+	if !ok {
+		writeError(w, http.StatusNotFound, errCodeSessionNotFound, "Session not found")
+		return
+	}
+	cloner, ok := source.(terminal.Cloner)
+	if !ok {
+		writeError(w, http.StatusConflict, errCodeUnsupportedSession, "Session cannot be cloned")
+		return
+	}
+	name := req.Name
+	if name == "" {
+		name = cloneSessionName(source.GetMetadata().Name)
+	}
+	config := cloner.CloneConfig(uuid.New().String(), name)
+
+	// The allowlist may have changed since the session was created
+	if config.Backend != terminal.SessionBackendSSH {
+		if err := executionAllowlist.Check(config.Shell, config.WorkingDirectory); err != nil {
+			writeError(w, http.StatusBadRequest, errCodeInvalidRequest, err.Error())
+			return
+		}
+	}
+
+	sess, err := sessionManager.CreateSession(config)
+	if err != nil {
+		log.Printf("Error cloning session %s: %v", sessionID, err)
+		var limitErr *terminal.LimitError
+		if errors.As(err, &limitErr) {
+			writeLimitError(w, http.StatusTooManyRequests, limitErr)
+			return
+		}
+		if config.Backend == terminal.SessionBackendSSH {
+			writeError(w, http.StatusBadGateway, errCodeUpstreamFailed, err.Error())
+			return
+		}
+		writeError(w, http.StatusInternalServerError, errCodeInternal, "Failed to clone session")
+		return
+	}
+	log.Printf("Session %s: cloned as %s", sessionID, sess.ID())
+
+	resp := terminal.CreateSessionResponse{
+		ID:       sess.ID(),
+		Metadata: sess.GetMetadata(),
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		log.Printf("Error encoding response: %v", err)
+	}
+}
+
+// cloneSessionName names a clone of the session called name, keeping within
+// the length allowed for names
+func cloneSessionName(name string) string {
+	const suffix = " (copy)"
+	runes := []rune(name)
+	if maxRunes := terminal.MaxSessionNameLength - len(suffix); len(runes) > maxRunes {
+		runes = runes[:maxRunes]
+	}
+	return string(runes) + suffix
+}
+
+// limitErrorResponse is the JSON body returned when a session or client limit is reached
+type limitErrorResponse struct {
+	Error limitError `json:"error"`
+}
+
+// limitError is an apiError naming the limit that was reached
+type limitError struct {
+	apiError
+	Resource string `json:"resource"` // "sessions" or "clients"
+	Limit    int    `json:"limit"`
+}
+
+// writeLimitError reports a reached session or client limit as JSON
+func writeLimitError(w http.ResponseWriter, statusCode int, err *terminal.LimitError) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	_ = json.NewEncoder(w).Encode(limitErrorResponse{Error: limitError{
+		apiError: apiError{Code: errCodeLimitReached, Message: err.Error()},
+		Resource: err.Resource,
+		Limit:    err.Limit,
+	}})
+}
+
+// handleSessionByID routes /api/sessions/:id and its sub-resources
+func handleSessionByID(w http.ResponseWriter, r *http.Request) {
+	// URL format: /api/sessions/:id or /api/sessions/:id/action
+	path := strings.TrimPrefix(r.URL.Path, "/api/sessions/")
+	parts := strings.SplitN(path, "/", 2)
+	sessionID := parts[0]
+
+	if len(parts) > 1 && parts[1] != "" {
+		if sessionID == "" {
+			writeError(w, http.StatusBadRequest, errCodeInvalidRequest, "Session ID is required")
+			return
+		}
+		action := parts[1]
+		switch {
+		case action == "windows":
+			handleSessionWindows(w, r, sessionID)
+		case action == "panes/select":
+			handleSessionSelectPane(w, r, sessionID)
+		case action == "history/search":
+			handleSessionHistorySearch(w, r, sessionID)
+		case action == "snapshot":
+			handleSessionSnapshot(w, r, sessionID)
+		case action == "signal":
+			handleSessionSignal(w, r, sessionID)
+		case action == "exec":
+			handleSessionExec(w, r, sessionID)
+		case action == "clone":
+			handleSessionClone(w, r, sessionID)
+		case action == "cd":
+			handleSessionChangeDirectory(w, r, sessionID)
+		case action == "watches":
+			handleSessionWatches(w, r, sessionID)
+		case strings.HasPrefix(action, "watches/"):
+			handleSessionWatch(w, r, sessionID, strings.TrimPrefix(action, "watches/"))
+		case strings.HasPrefix(action, "clients/"):
+			handleKickClient(w, r, sessionID, strings.TrimSuffix(strings.TrimPrefix(action, "clients/"), "/"))
+		case strings.HasPrefix(action, "windows/"):
+			// URL format: /api/sessions/:id/windows/:index[/select]
+			handleSessionWindow(w, r, sessionID, strings.TrimPrefix(action, "windows/"))
+		default:
+			http.NotFound(w, r)
+		}
+		return
+	}
+
+	// Handle operations on specific sessions
+	switch r.Method {
+	case http.MethodGet:
+		handleGetSession(w, r, sessionID)
+	case http.MethodDelete:
+		handleDeleteSession(w, r)
+	case http.MethodPut:
+		handleUpdateSession(w, r)
+	default:
+		writeError(w, http.StatusMethodNotAllowed, errCodeMethodNotAllowed, "Method not allowed")
+	}
+}
+
 // handleDeleteSession handles DELETE /api/sessions/:id
 func handleDeleteSession(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodDelete {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		writeError(w, http.StatusMethodNotAllowed, errCodeMethodNotAllowed, "Method not allowed")
 		return
 	}
 
@@ -445,14 +911,14 @@ func handleDeleteSession(w http.ResponseWriter, r *http.Request) {
 	sessionID := strings.TrimSuffix(path, "/")
 
 	if sessionID == "" {
-		http.Error(w, "Session ID is required", http.StatusBadRequest)
+		writeError(w, http.StatusBadRequest, errCodeInvalidRequest, "Session ID is required")
 		return
 	}
 
 	// Remove the session
 	if err := sessionManager.Remove(sessionID); err != nil {
 		log.Printf("Error removing session: %v", err)
-		http.Error(w, "Session not found", http.StatusNotFound)
+		writeError(w, http.StatusNotFound, errCodeSessionNotFound, "Session not found")
 		return
 	}
 
@@ -462,7 +928,7 @@ func handleDeleteSession(w http.ResponseWriter, r *http.Request) {
 // handleUpdateSession handles PUT /api/sessions/:id
 func handleUpdateSession(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPut {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		writeError(w, http.StatusMethodNotAllowed, errCodeMethodNotAllowed, "Method not allowed")
 		return
 	}
 
@@ -472,94 +938,201 @@ func handleUpdateSession(w http.ResponseWriter, r *http.Request) {
 	sessionID := strings.TrimSuffix(path, "/")
 
 	if sessionID == "" {
-		http.Error(w, "Session ID is required", http.StatusBadRequest)
+		writeError(w, http.StatusBadRequest, errCodeInvalidRequest, "Session ID is required")
 		return
 	}
 
 	var req terminal.UpdateSessionRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		log.Printf("Error decoding request: %v", err)
-		http.Error(w, "Bad request", http.StatusBadRequest)
+		writeError(w, http.StatusBadRequest, errCodeInvalidJSON, "Bad request")
 		return
 	}
 
-	// Validate request
-	if req.Name == "" {
-		http.Error(w, "Name is required", http.StatusBadRequest)
+	if err := req.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, errCodeInvalidRequest, err.Error())
 		return
 	}
 
 	// Update the session
-	if err := sessionManager.UpdateSessionName(sessionID, req.Name); err != nil {
-		log.Printf("Error updating session: %v", err)
-		http.Error(w, "Session not found", http.StatusNotFound)
-		return
+	if req.Name != "" {
+		if err := sessionManager.UpdateSessionName(sessionID, req.Name); err != nil {
+			log.Printf("Error updating session: %v", err)
+			writeError(w, http.StatusNotFound, errCodeSessionNotFound, "Session not found")
+			return
+		}
+	}
+	if req.Tags != nil {
+		if err := sessionManager.UpdateSessionTags(sessionID, *req.Tags); err != nil {
+			log.Printf("Error updating session tags: %v", err)
+			writeError(w, http.StatusNotFound, errCodeSessionNotFound, "Session not found")
+			return
+		}
+	}
+	if req.Pinned != nil {
+		if err := sessionManager.SetSessionPinned(sessionID, *req.Pinned); err != nil {
+			log.Printf("Error updating session pinned state: %v", err)
+			writeError(w, http.StatusNotFound, errCodeSessionNotFound, "Session not found")
+			return
+		}
+	}
+	if req.InputMode != "" {
+		if err := sessionManager.SetSessionInputMode(sessionID, req.InputMode); err != nil {
+			log.Printf("Error updating session input mode: %v", err)
+			writeError(w, http.StatusNotFound, errCodeSessionNotFound, "Session not found")
+			return
+		}
+	}
+	if req.ResizePolicy != nil {
+		if err := sessionManager.SetSessionResizePolicy(sessionID, *req.ResizePolicy); err != nil {
+			log.Printf("Error updating session resize policy: %v", err)
+			writeError(w, http.StatusNotFound, errCodeSessionNotFound, "Session not found")
+			return
+		}
 	}
 
 	w.WriteHeader(http.StatusNoContent)
 }
 
+// handleReorderSessions handles POST /api/sessions/reorder. The listed
+// sessions come first in the given order; unlisted sessions follow.
+func handleReorderSessions(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, errCodeMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	var req terminal.ReorderSessionsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Printf("Error decoding request: %v", err)
+		writeError(w, http.StatusBadRequest, errCodeInvalidJSON, "Bad request")
+		return
+	}
+	if len(req.SessionIDs) == 0 {
+		writeError(w, http.StatusBadRequest, errCodeInvalidRequest, "session_ids is required")
+		return
+	}
+
+	if err := sessionManager.ReorderSessions(req.SessionIDs); err != nil {
+		log.Printf("Error reordering sessions: %v", err)
+		status, code := http.StatusBadRequest, errCodeInvalidRequest
+		if errors.Is(err, terminal.ErrSessionNotFound) {
+			status, code = http.StatusNotFound, errCodeSessionNotFound
+		}
+		writeError(w, status, code, err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode((terminal.SessionFilter{}).Apply(sessionManager.ListSessionsInfo())); err != nil {
+		log.Printf("Error encoding response: %v", err)
+	}
+}
+
 type fileBrowseEntry struct {
 	Name        string    `json:"name"`
 	Path        string    `json:"path"`
 	IsDirectory bool      `json:"is_directory"`
-	Size        int64     `json:"size"`
+	Size        int64     `json:"size"`                   // of a directory, its files' with ?withSizes=true
+	SizePending bool      `json:"size_pending,omitempty"` // the directory's size is still being computed
 	ModifiedAt  time.Time `json:"modified_at"`
 }
 
 type fileBrowseResponse struct {
-	Root    string            `json:"root"`
-	Current string            `json:"current"`
-	Parent  string            `json:"parent,omitempty"`
-	Entries []fileBrowseEntry `json:"entries"`
+	Root         string            `json:"root"`
+	Current      string            `json:"current"`
+	Parent       string            `json:"parent,omitempty"`
+	Entries      []fileBrowseEntry `json:"entries"`
+	SizesPending bool              `json:"sizes_pending,omitempty"` // ask again for the sizes still missing
+}
+
+// sessionBrowseDirectory returns the local directory the shell of the
+// session is in, for browsing files there, or "" if there is none
+func sessionBrowseDirectory(sessionID string) string {
+	if sessionID == "" {
+		return ""
+	}
+	sess, ok := sessionManager.Get(sessionID)
+	if !ok {
+		return ""
+	}
+	metadata := sess.GetMetadata()
+	dir := metadata.CurrentDirectory
+	if dir == "" {
+		dir = metadata.WorkingDirectory
+	}
+	return localSessionDirectory(metadata, dir)
+}
+
+// localSessionDirectory returns dir, a directory of the session described by
+// metadata, cleaned, or "" if it is not a local directory
+func localSessionDirectory(metadata terminal.SessionMetadata, dir string) string {
+	if metadata.Backend == terminal.SessionBackendSSH {
+		return ""
+	}
+	if !filepath.IsAbs(dir) {
+		return ""
+	}
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		return ""
+	}
+	return filepath.Clean(dir)
 }
 
-// handleFileBrowse handles GET /api/files/browse
+// handleFileBrowse handles GET /api/files/browse. Without a path it lists
+// the directory the shell of ?session= is in, or the hub's. With
+// ?withSizes=true directories report the total size of their files, computed
+// in the background and cached; those not ready yet are marked pending.
 func handleFileBrowse(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		writeError(w, http.StatusMethodNotAllowed, errCodeMethodNotAllowed, "Method not allowed")
 		return
 	}
 
 	browseRoot, err := os.Getwd()
 	if err != nil {
 		log.Printf("Error resolving browse root: %v", err)
-		http.Error(w, "Failed to resolve browse root", http.StatusInternalServerError)
+		writeError(w, http.StatusInternalServerError, errCodeInternal, "Failed to resolve browse root")
 		return
 	}
 	browseRoot = filepath.Clean(browseRoot)
 
 	requestedPath := strings.TrimSpace(r.URL.Query().Get("path"))
 	targetPath := browseRoot
-	if requestedPath != "" {
+	if requestedPath == "" {
+		if dir := sessionBrowseDirectory(r.URL.Query().Get("session")); dir != "" {
+			targetPath = dir
+		}
+	} else {
 		targetPath = filepath.Clean(requestedPath)
 		if !filepath.IsAbs(targetPath) {
-			http.Error(w, "Path must be absolute", http.StatusBadRequest)
+			writeError(w, http.StatusBadRequest, errCodeInvalidRequest, "Path must be absolute")
 			return
 		}
 	}
 
 	targetInfo, err := os.Stat(targetPath)
 	if os.IsNotExist(err) {
-		http.Error(w, "Path not found", http.StatusNotFound)
+		writeError(w, http.StatusNotFound, errCodeFileNotFound, "Path not found")
 		return
 	}
 	if err != nil {
 		log.Printf("Error accessing browse path: %v", err)
-		http.Error(w, "Failed to access path", http.StatusInternalServerError)
+		writeError(w, http.StatusInternalServerError, errCodeInternal, "Failed to access path")
 		return
 	}
 	if !targetInfo.IsDir() {
-		http.Error(w, "Path must be a directory", http.StatusBadRequest)
+		writeError(w, http.StatusBadRequest, errCodeInvalidRequest, "Path must be a directory")
 		return
 	}
 
 	showHidden := strings.EqualFold(r.URL.Query().Get("showHidden"), "true")
+	withSizes := strings.EqualFold(r.URL.Query().Get("withSizes"), "true")
 
 	dirEntries, err := os.ReadDir(targetPath)
 	if err != nil {
 		log.Printf("Error reading directory: %v", err)
-		http.Error(w, "Failed to read directory", http.StatusInternalServerError)
+		writeError(w, http.StatusInternalServerError, errCodeInternal, "Failed to read directory")
 		return
 	}
 
@@ -576,16 +1149,24 @@ func handleFileBrowse(w http.ResponseWriter, r *http.Request) {
 			continue
 		}
 
+		entryPath := filepath.Join(targetPath, name)
 		size := info.Size()
+		sizePending := false
 		if info.IsDir() {
 			size = 0
+			if withSizes {
+				var ready bool
+				size, ready = browseDirSizes.size(entryPath)
+				sizePending = !ready
+			}
 		}
 
 		entries = append(entries, fileBrowseEntry{
 			Name:        name,
-			Path:        filepath.Join(targetPath, name),
+			Path:        entryPath,
 			IsDirectory: info.IsDir(),
 			Size:        size,
+			SizePending: sizePending,
 			ModifiedAt:  info.ModTime(),
 		})
 	}
@@ -611,61 +1192,83 @@ func handleFileBrowse(w http.ResponseWriter, r *http.Request) {
 		Parent:  parentPath,
 		Entries: entries,
 	}
+	for _, entry := range entries {
+		if entry.SizePending {
+			response.SizesPending = true
+			break
+		}
+	}
 
 	w.Header().Set("Content-Type", "application/json")
 	if err := json.NewEncoder(w).Encode(response); err != nil {
 		log.Printf("Error encoding browse response: %v", err)
-		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		writeError(w, http.StatusInternalServerError, errCodeInternal, "Failed to encode response")
 		return
 	}
 }
 
+// fileUploadResponse is the body of POST /api/upload
+type fileUploadResponse struct {
+	Path        string `json:"path"`
+	Filename    string `json:"filename"`
+	Size        int64  `json:"size"`
+	Overwritten bool   `json:"overwritten"`
+}
+
 // handleFileUpload handles POST /api/upload
 func handleFileUpload(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		writeError(w, http.StatusMethodNotAllowed, errCodeMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	if refuseLowDiskWrite(w) {
 		return
 	}
 
 	uploadPath := strings.TrimSpace(r.Header.Get(uploadPathHeader))
 	if uploadPath == "" {
-		http.Error(w, "Upload path is required", http.StatusBadRequest)
+		writeError(w, http.StatusBadRequest, errCodeInvalidRequest, "Upload path is required")
 		return
 	}
 
 	rawFilename := strings.TrimSpace(r.Header.Get(uploadFilenameHeader))
 	if rawFilename == "" {
-		http.Error(w, "Filename is required", http.StatusBadRequest)
+		writeError(w, http.StatusBadRequest, errCodeInvalidRequest, "Filename is required")
 		return
 	}
 
 	filename := sanitizeFilename(rawFilename)
 	if filename == "" || filename == "." {
-		http.Error(w, "Filename is required", http.StatusBadRequest)
+		writeError(w, http.StatusBadRequest, errCodeInvalidRequest, "Filename is required")
+		return
+	}
+	if err := uploadPolicy.checkFilename(filename); err != nil {
+		writeError(w, http.StatusUnsupportedMediaType, errCodeUnsupportedMedia, err.Error())
 		return
 	}
 
 	cleanPath := filepath.Clean(uploadPath)
 	if !filepath.IsAbs(cleanPath) {
-		http.Error(w, "Upload path must be absolute", http.StatusBadRequest)
+		writeError(w, http.StatusBadRequest, errCodeInvalidRequest, "Upload path must be absolute")
 		return
 	}
 
 	// Ensure destination directory exists and is a directory.
 	if fileInfo, err := os.Stat(cleanPath); err == nil {
 		if !fileInfo.IsDir() {
-			http.Error(w, "Upload path must be a directory", http.StatusBadRequest)
+			writeError(w, http.StatusBadRequest, errCodeInvalidRequest, "Upload path must be a directory")
 			return
 		}
 	} else if os.IsNotExist(err) {
 		if err := os.MkdirAll(cleanPath, 0o755); err != nil {
 			log.Printf("Error creating upload path: %v", err)
-			http.Error(w, "Failed to create upload path", http.StatusInternalServerError)
+			writeError(w, http.StatusInternalServerError, errCodeInternal, "Failed to create upload path")
 			return
 		}
 	} else {
 		log.Printf("Error checking upload path: %v", err)
-		http.Error(w, "Failed to access upload path", http.StatusInternalServerError)
+		writeError(w, http.StatusInternalServerError, errCodeInternal, "Failed to access upload path")
 		return
 	}
 
@@ -679,18 +1282,25 @@ func handleFileUpload(w http.ResponseWriter, r *http.Request) {
 	targetInfo, targetErr := os.Stat(targetPath)
 	if targetErr == nil {
 		if targetInfo.IsDir() {
-			http.Error(w, "Upload target cannot be a directory", http.StatusBadRequest)
+			writeError(w, http.StatusBadRequest, errCodeInvalidRequest, "Upload target cannot be a directory")
 			return
 		}
 		overwritten = true
 	} else if !os.IsNotExist(targetErr) {
 		log.Printf("Error checking upload target file: %v", targetErr)
-		http.Error(w, "Failed to access upload target", http.StatusInternalServerError)
+		writeError(w, http.StatusInternalServerError, errCodeInternal, "Failed to access upload target")
 		return
 	}
 
 	if overwritten && !overwrite {
-		http.Error(w, "File already exists", http.StatusConflict)
+		writeError(w, http.StatusConflict, errCodeFileExists, "File already exists")
+		return
+	}
+
+	if uploadPolicy.scanner != nil {
+		if written, ok := receiveScannedUpload(w, r.Body, cleanPath, targetPath, overwrite); ok {
+			writeUploadResponse(w, targetPath, filename, written, overwritten)
+		}
 		return
 	}
 
@@ -704,11 +1314,11 @@ func handleFileUpload(w http.ResponseWriter, r *http.Request) {
 	targetFile, err := os.OpenFile(targetPath, flags, 0o644)
 	if err != nil {
 		if os.IsExist(err) {
-			http.Error(w, "File already exists", http.StatusConflict)
+			writeError(w, http.StatusConflict, errCodeFileExists, "File already exists")
 			return
 		}
 		log.Printf("Error opening upload target file: %v", err)
-		http.Error(w, "Failed to open upload target", http.StatusInternalServerError)
+		writeError(w, http.StatusInternalServerError, errCodeInternal, "Failed to open upload target")
 		return
 	}
 
@@ -720,22 +1330,27 @@ func handleFileUpload(w http.ResponseWriter, r *http.Request) {
 	if err != nil {
 		_ = os.Remove(targetPath)
 		log.Printf("Error streaming upload to file: %v", err)
-		http.Error(w, "Failed to write upload", http.StatusInternalServerError)
+		writeError(w, http.StatusInternalServerError, errCodeInternal, "Failed to write upload")
 		return
 	}
 	if closeErr != nil {
 		_ = os.Remove(targetPath)
 		log.Printf("Error closing uploaded file: %v", closeErr)
-		http.Error(w, "Failed to finalize upload", http.StatusInternalServerError)
+		writeError(w, http.StatusInternalServerError, errCodeInternal, "Failed to finalize upload")
 		return
 	}
 
+	writeUploadResponse(w, targetPath, filename, written, overwritten)
+}
+
+// writeUploadResponse reports a finished upload
+func writeUploadResponse(w http.ResponseWriter, targetPath, filename string, written int64, overwritten bool) {
 	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(map[string]interface{}{
-		"path":        targetPath,
-		"filename":    filename,
-		"size":        written,
-		"overwritten": overwritten,
+	if err := json.NewEncoder(w).Encode(fileUploadResponse{
+		Path:        targetPath,
+		Filename:    filename,
+		Size:        written,
+		Overwritten: overwritten,
 	}); err != nil {
 		log.Printf("Error encoding upload response: %v", err)
 	}
@@ -744,17 +1359,20 @@ func handleFileUpload(w http.ResponseWriter, r *http.Request) {
 		targetPath, written, filename)
 }
 
-// handleFileDownload handles GET /api/download
+// handleFileDownload handles GET and HEAD /api/download. Files are served
+// with http.ServeContent, so single and multiple byte ranges and If-Range,
+// against the ETag or Last-Modified, let interrupted downloads resume.
+// Directories are streamed as a zip archive.
 func handleFileDownload(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodGet {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		writeError(w, http.StatusMethodNotAllowed, errCodeMethodNotAllowed, "Method not allowed")
 		return
 	}
 
 	// Get file path from query parameter
 	filePath := r.URL.Query().Get("path")
 	if filePath == "" {
-		http.Error(w, "File path is required", http.StatusBadRequest)
+		writeError(w, http.StatusBadRequest, errCodeInvalidRequest, "File path is required")
 		return
 	}
 
@@ -769,25 +1387,19 @@ func handleFileDownload(w http.ResponseWriter, r *http.Request) {
 
 	// Additional security: Ensure path is absolute
 	if !filepath.IsAbs(cleanPath) {
-		http.Error(w, "File path must be absolute", http.StatusBadRequest)
+		writeError(w, http.StatusBadRequest, errCodeInvalidRequest, "File path must be absolute")
 		return
 	}
 
 	// Get file info
 	fileInfo, err := os.Stat(cleanPath)
 	if os.IsNotExist(err) {
-		http.Error(w, "File not found", http.StatusNotFound)
+		writeError(w, http.StatusNotFound, errCodeFileNotFound, "File not found")
 		return
 	}
 	if err != nil {
 		log.Printf("Error accessing file: %v", err)
-		http.Error(w, "Failed to access file", http.StatusInternalServerError)
-		return
-	}
-
-	// Security: Don't allow downloading directories
-	if fileInfo.IsDir() {
-		http.Error(w, "Cannot download directory", http.StatusBadRequest)
+		writeError(w, http.StatusInternalServerError, errCodeInternal, "Failed to access file")
 		return
 	}
 
@@ -798,9 +1410,18 @@ func handleFileDownload(w http.ResponseWriter, r *http.Request) {
 			maxFileSize = maxSize
 		}
 	}
+
+	if fileInfo.IsDir() {
+		if r.URL.Query().Get("filename") == "" {
+			filename = filepath.Base(cleanPath) + ".zip"
+		}
+		serveDirectoryZip(w, r, cleanPath, filename, maxFileSize)
+		return
+	}
+
 	if fileInfo.Size() > maxFileSize {
-		http.Error(w, fmt.Sprintf("File too large (max %d MB)", maxFileSize/(1024*1024)),
-			http.StatusRequestEntityTooLarge)
+		writeError(w, http.StatusRequestEntityTooLarge, errCodePayloadTooLarge,
+			fmt.Sprintf("File too large (max %d MB)", maxFileSize/(1024*1024)))
 		return
 	}
 
@@ -808,7 +1429,7 @@ func handleFileDownload(w http.ResponseWriter, r *http.Request) {
 	file, err := os.Open(cleanPath)
 	if err != nil {
 		log.Printf("Error opening file: %v", err)
-		http.Error(w, "Failed to open file", http.StatusInternalServerError)
+		writeError(w, http.StatusInternalServerError, errCodeInternal, "Failed to open file")
 		return
 	}
 	defer file.Close()
@@ -823,14 +1444,18 @@ func handleFileDownload(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", contentType)
 	w.Header().Set("Content-Disposition",
 		fmt.Sprintf("attachment; filename=\"%s\"", sanitizeFilename(filename)))
-	w.Header().Set("Content-Length", strconv.FormatInt(fileInfo.Size(), 10))
 	w.Header().Set("Cache-Control", "no-cache")
+	// A strong validator, so If-Range resumes only the same version
+	w.Header().Set("ETag", fmt.Sprintf(`"%x-%x"`, fileInfo.ModTime().UnixNano(), fileInfo.Size()))
 
-	// Stream file to client
+	// Stream file to client; ServeContent sets Content-Length and answers
+	// Range, If-Range and HEAD requests
 	http.ServeContent(w, r, filename, fileInfo.ModTime(), file)
 
-	log.Printf("File downloaded: path=%s, size=%d, filename=%s",
-		cleanPath, fileInfo.Size(), filename)
+	if r.Method == http.MethodGet {
+		log.Printf("File downloaded: path=%s, size=%d, filename=%s",
+			cleanPath, fileInfo.Size(), filename)
+	}
 }
 
 // sanitizeFilename removes dangerous characters from filename
@@ -850,6 +1475,18 @@ func isNotFoundError(err error) bool {
 	return err != nil && strings.Contains(err.Error(), "not found")
 }
 
+// cronNotFoundCode returns the error code of a cron manager's not found error
+func cronNotFoundCode(err error) string {
+	switch message := err.Error(); {
+	case strings.Contains(message, "execution not found"):
+		return errCodeExecutionNotFound
+	case strings.Contains(message, "log file not found"):
+		return errCodeFileNotFound
+	default:
+		return errCodeCronJobNotFound
+	}
+}
+
 // handleCrons handles GET /api/crons (list) and POST /api/crons (create)
 func handleCrons(w http.ResponseWriter, r *http.Request) {
 	switch r.Method {
@@ -857,41 +1494,38 @@ func handleCrons(w http.ResponseWriter, r *http.Request) {
 		jobs, err := cronManager.List()
 		if err != nil {
 			log.Printf("Error listing cron jobs: %v", err)
-			http.Error(w, "Failed to list jobs", http.StatusInternalServerError)
+			writeError(w, http.StatusInternalServerError, errCodeInternal, "Failed to list jobs")
 			return
 		}
 
-		w.Header().Set("Content-Type", "application/json")
-		if err := json.NewEncoder(w).Encode(cron.ListCronsResponse{Jobs: jobs}); err != nil {
-			log.Printf("Error encoding cron jobs: %v", err)
-		}
+		writeJSONWithETag(w, r, cron.ListCronsResponse{Jobs: jobs, Suspended: cronManager.IsSuspended()})
 
 	case http.MethodPost:
 		var req cron.CreateCronRequest
 		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 			log.Printf("Error decoding request: %v", err)
-			http.Error(w, "Bad request", http.StatusBadRequest)
+			writeError(w, http.StatusBadRequest, errCodeInvalidJSON, "Bad request")
 			return
 		}
 
 		// Validate request
 		if req.Name == "" {
-			http.Error(w, "Name is required", http.StatusBadRequest)
+			writeError(w, http.StatusBadRequest, errCodeInvalidRequest, "Name is required")
 			return
 		}
-		if req.Schedule == "" {
-			http.Error(w, "Schedule is required", http.StatusBadRequest)
+		if req.Schedule == "" && len(req.RunAfter) == 0 && req.RunAt == 0 {
+			writeError(w, http.StatusBadRequest, errCodeInvalidRequest, "Schedule is required")
 			return
 		}
 		if req.Command == "" {
-			http.Error(w, "Command is required", http.StatusBadRequest)
+			writeError(w, http.StatusBadRequest, errCodeInvalidRequest, "Command is required")
 			return
 		}
 
 		job, err := cronManager.Create(req)
 		if err != nil {
 			log.Printf("Error creating cron job: %v", err)
-			http.Error(w, err.Error(), http.StatusBadRequest)
+			writeError(w, http.StatusBadRequest, errCodeInvalidRequest, err.Error())
 			return
 		}
 
@@ -902,7 +1536,167 @@ func handleCrons(w http.ResponseWriter, r *http.Request) {
 		}
 
 	default:
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		writeError(w, http.StatusMethodNotAllowed, errCodeMethodNotAllowed, "Method not allowed")
+	}
+}
+
+// handleCronPreview handles GET /api/crons/preview?schedule=...&count=N&timezone=...
+func handleCronPreview(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, errCodeMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	schedule := strings.TrimSpace(r.URL.Query().Get("schedule"))
+	if schedule == "" {
+		writeError(w, http.StatusBadRequest, errCodeInvalidRequest, "Schedule is required")
+		return
+	}
+
+	count := cron.DefaultPreviewCount
+	if raw := r.URL.Query().Get("count"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed <= 0 || parsed > cron.MaxPreviewCount {
+			writeError(w, http.StatusBadRequest, errCodeInvalidRequest, fmt.Sprintf("count must be between 1 and %d", cron.MaxPreviewCount))
+			return
+		}
+		count = parsed
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	timezone := strings.TrimSpace(r.URL.Query().Get("timezone"))
+	if err := json.NewEncoder(w).Encode(cron.PreviewScheduleInTimezone(schedule, timezone, time.Now(), count)); err != nil {
+		log.Printf("Error encoding response: %v", err)
+	}
+}
+
+// handleCronPauseAll handles POST /api/crons/pause-all
+func handleCronPauseAll(w http.ResponseWriter, r *http.Request) {
+	handleCronSuspend(w, r, true)
+}
+
+// handleCronResumeAll handles POST /api/crons/resume-all
+func handleCronResumeAll(w http.ResponseWriter, r *http.Request) {
+	handleCronSuspend(w, r, false)
+}
+
+// handleCronSuspend switches scheduling maintenance mode on or off
+func handleCronSuspend(w http.ResponseWriter, r *http.Request, suspend bool) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, errCodeMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	var err error
+	if suspend {
+		err = cronManager.Suspend()
+	} else {
+		err = cronManager.Resume()
+	}
+	if err != nil {
+		log.Printf("Error changing cron suspension: %v", err)
+		writeError(w, http.StatusInternalServerError, errCodeInternal, err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(cron.SuspendResponse{Suspended: cronManager.IsSuspended()}); err != nil {
+		log.Printf("Error encoding response: %v", err)
+	}
+}
+
+// maxCronImportSize limits the size of an import request body
+const maxCronImportSize = 1 << 20
+
+// handleCronExport handles GET /api/crons/export?format=json|crontab
+func handleCronExport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, errCodeMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	switch format := r.URL.Query().Get("format"); format {
+	case "", cron.ExportFormatJSON:
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Content-Disposition", `attachment; filename="crons.json"`)
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(cronManager.Export()); err != nil {
+			log.Printf("Error encoding cron export: %v", err)
+		}
+	case cron.ExportFormatCrontab:
+		jobs, err := cronManager.List()
+		if err != nil {
+			log.Printf("Error listing cron jobs: %v", err)
+			writeError(w, http.StatusInternalServerError, errCodeInternal, "Failed to list jobs")
+			return
+		}
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.Header().Set("Content-Disposition", `attachment; filename="crontab"`)
+		if _, err := io.WriteString(w, cron.FormatCrontab(jobs)); err != nil {
+			log.Printf("Error writing cron export: %v", err)
+		}
+	default:
+		writeError(w, http.StatusBadRequest, errCodeInvalidRequest, fmt.Sprintf("Unknown format %q", format))
+	}
+}
+
+// handleCronImport handles POST /api/crons/import. The format is taken from
+// ?format=json|crontab, or from the Content-Type (JSON or plain-text crontab).
+func handleCronImport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, errCodeMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = cron.ExportFormatCrontab
+		if mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type")); err == nil && mediaType == "application/json" {
+			format = cron.ExportFormatJSON
+		}
+	}
+
+	body := http.MaxBytesReader(w, r.Body, maxCronImportSize)
+
+	var reqs []cron.CreateCronRequest
+	switch format {
+	case cron.ExportFormatJSON:
+		var export cron.CronExport
+		if err := json.NewDecoder(body).Decode(&export); err != nil {
+			log.Printf("Error decoding cron import: %v", err)
+			writeError(w, http.StatusBadRequest, errCodeInvalidJSON, "Bad request")
+			return
+		}
+		reqs = export.Jobs
+	case cron.ExportFormatCrontab:
+		var err error
+		reqs, err = cron.ParseCrontab(body)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, errCodeInvalidRequest, err.Error())
+			return
+		}
+	default:
+		writeError(w, http.StatusBadRequest, errCodeInvalidRequest, fmt.Sprintf("Unknown format %q", format))
+		return
+	}
+
+	if len(reqs) == 0 {
+		writeError(w, http.StatusBadRequest, errCodeInvalidRequest, "No jobs to import")
+		return
+	}
+
+	jobs, err := cronManager.Import(reqs)
+	if err != nil {
+		log.Printf("Error importing cron jobs: %v", err)
+		writeError(w, http.StatusBadRequest, errCodeInvalidRequest, err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(cron.ImportCronsResponse{Jobs: jobs}); err != nil {
+		log.Printf("Error encoding response: %v", err)
 	}
 }
 
@@ -915,7 +1709,7 @@ func handleCronByID(w http.ResponseWriter, r *http.Request) {
 	jobID := parts[0]
 
 	if jobID == "" {
-		http.Error(w, "Job ID is required", http.StatusBadRequest)
+		writeError(w, http.StatusBadRequest, errCodeInvalidRequest, "Job ID is required")
 		return
 	}
 
@@ -935,6 +1729,26 @@ func handleCronByID(w http.ResponseWriter, r *http.Request) {
 		case "disable":
 			handleCronDisable(w, r, jobID)
 			return
+		case "executions":
+			handleCronExecutions(w, r, jobID)
+			return
+		case "logs":
+			handleCronLogs(w, r, jobID)
+			return
+		}
+
+		// URL format: /api/crons/:id/logs/:name
+		if name, ok := strings.CutPrefix(action, "logs/"); ok && name != "" && !strings.Contains(name, "/") {
+			handleCronLogDownload(w, r, jobID, name)
+			return
+		}
+
+		// URL format: /api/crons/:id/executions/:execId/stream
+		if rest, ok := strings.CutPrefix(action, "executions/"); ok {
+			if execID, ok := strings.CutSuffix(rest, "/stream"); ok && execID != "" && !strings.Contains(execID, "/") {
+				handleCronExecutionStream(w, r, jobID, execID)
+				return
+			}
 		}
 	}
 
@@ -944,7 +1758,7 @@ func handleCronByID(w http.ResponseWriter, r *http.Request) {
 		job, err := cronManager.Get(jobID)
 		if err != nil {
 			log.Printf("Error getting cron job: %v", err)
-			http.Error(w, "Job not found", http.StatusNotFound)
+			writeError(w, http.StatusNotFound, errCodeCronJobNotFound, "Job not found")
 			return
 		}
 
@@ -957,7 +1771,7 @@ func handleCronByID(w http.ResponseWriter, r *http.Request) {
 		var req cron.UpdateCronRequest
 		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 			log.Printf("Error decoding request: %v", err)
-			http.Error(w, "Bad request", http.StatusBadRequest)
+			writeError(w, http.StatusBadRequest, errCodeInvalidJSON, "Bad request")
 			return
 		}
 
@@ -965,9 +1779,9 @@ func handleCronByID(w http.ResponseWriter, r *http.Request) {
 		if err != nil {
 			log.Printf("Error updating cron job: %v", err)
 			if isNotFoundError(err) {
-				http.Error(w, err.Error(), http.StatusNotFound)
+				writeError(w, http.StatusNotFound, cronNotFoundCode(err), err.Error())
 			} else {
-				http.Error(w, err.Error(), http.StatusBadRequest)
+				writeError(w, http.StatusBadRequest, errCodeInvalidRequest, err.Error())
 			}
 			return
 		}
@@ -980,20 +1794,20 @@ func handleCronByID(w http.ResponseWriter, r *http.Request) {
 	case http.MethodDelete:
 		if err := cronManager.Delete(jobID); err != nil {
 			log.Printf("Error deleting cron job: %v", err)
-			http.Error(w, err.Error(), http.StatusNotFound)
+			writeError(w, http.StatusNotFound, cronNotFoundCode(err), err.Error())
 			return
 		}
 		w.WriteHeader(http.StatusNoContent)
 
 	default:
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		writeError(w, http.StatusMethodNotAllowed, errCodeMethodNotAllowed, "Method not allowed")
 	}
 }
 
 // handleCronRunNow handles POST /api/crons/:id/run
 func handleCronRunNow(w http.ResponseWriter, r *http.Request, jobID string) {
 	if r.Method != http.MethodPost {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		writeError(w, http.StatusMethodNotAllowed, errCodeMethodNotAllowed, "Method not allowed")
 		return
 	}
 
@@ -1001,9 +1815,9 @@ func handleCronRunNow(w http.ResponseWriter, r *http.Request, jobID string) {
 	if err != nil {
 		log.Printf("Error running cron job: %v", err)
 		if isNotFoundError(err) {
-			http.Error(w, err.Error(), http.StatusNotFound)
+			writeError(w, http.StatusNotFound, cronNotFoundCode(err), err.Error())
 		} else {
-			http.Error(w, err.Error(), http.StatusBadRequest)
+			writeError(w, http.StatusBadRequest, errCodeInvalidRequest, err.Error())
 		}
 		return
 	}
@@ -1014,17 +1828,54 @@ func handleCronRunNow(w http.ResponseWriter, r *http.Request, jobID string) {
 	}
 }
 
-// handleCronHistory handles GET /api/crons/:id/history
+// handleRunCommand handles POST /api/exec, starting a one-off command in a
+// short-lived PTY. It answers at once with the execution, whose output streams
+// from /api/crons/adhoc/executions/:execId/stream.
+func handleRunCommand(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, errCodeMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	var req cron.RunCommandRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Printf("Error decoding request: %v", err)
+		writeError(w, http.StatusBadRequest, errCodeInvalidJSON, "Bad request")
+		return
+	}
+
+	live, err := cronManager.RunCommand(req)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, errCodeInvalidRequest, err.Error())
+		return
+	}
+	log.Printf("Started one-off command %s", live.ExecutionID)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	if err := json.NewEncoder(w).Encode(live); err != nil {
+		log.Printf("Error encoding response: %v", err)
+	}
+}
+
+// handleCronHistory handles GET /api/crons/:id/history.
+// Without query parameters the job's full history is returned oldest first;
+// with limit/offset/status/since/until a page is returned newest first.
 func handleCronHistory(w http.ResponseWriter, r *http.Request, jobID string) {
 	if r.Method != http.MethodGet {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		writeError(w, http.StatusMethodNotAllowed, errCodeMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	if len(r.URL.Query()) > 0 {
+		handleCronHistoryQuery(w, r, jobID)
 		return
 	}
 
 	history, err := cronManager.GetHistory(jobID)
 	if err != nil {
 		log.Printf("Error getting cron history: %v", err)
-		http.Error(w, err.Error(), http.StatusNotFound)
+		writeError(w, http.StatusNotFound, cronNotFoundCode(err), err.Error())
 		return
 	}
 
@@ -1034,19 +1885,275 @@ func handleCronHistory(w http.ResponseWriter, r *http.Request, jobID string) {
 	}
 }
 
+// handleCronAllHistory handles GET /api/crons/history (history of all jobs)
+func handleCronAllHistory(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, errCodeMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	handleCronHistoryQuery(w, r, "")
+}
+
+// handleCronOverview handles GET /api/crons/overview (dashboard statistics)
+func handleCronOverview(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, errCodeMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(cronManager.Overview(time.Now())); err != nil {
+		log.Printf("Error encoding response: %v", err)
+	}
+}
+
+// handleCronHistoryQuery writes a page of execution history selected by the
+// limit, offset, status, since and until query parameters
+func handleCronHistoryQuery(w http.ResponseWriter, r *http.Request, jobID string) {
+	query, err := parseHistoryQuery(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, errCodeInvalidRequest, err.Error())
+		return
+	}
+	query.JobID = jobID
+
+	page, err := cronManager.QueryHistory(query)
+	if err != nil {
+		log.Printf("Error querying cron history: %v", err)
+		if isNotFoundError(err) {
+			writeError(w, http.StatusNotFound, cronNotFoundCode(err), err.Error())
+		} else {
+			writeError(w, http.StatusBadRequest, errCodeInvalidRequest, err.Error())
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(page); err != nil {
+		log.Printf("Error encoding response: %v", err)
+	}
+}
+
+// parseHistoryQuery reads history filters from the request query string
+func parseHistoryQuery(r *http.Request) (cron.HistoryQuery, error) {
+	params := r.URL.Query()
+	query := cron.HistoryQuery{Status: params.Get("status")}
+
+	intParams := map[string]*int{"limit": &query.Limit, "offset": &query.Offset}
+	for name, target := range intParams {
+		if raw := params.Get(name); raw != "" {
+			value, err := strconv.Atoi(raw)
+			if err != nil {
+				return query, fmt.Errorf("invalid %s: %q", name, raw)
+			}
+			*target = value
+		}
+	}
+
+	timeParams := map[string]*int64{"since": &query.Since, "until": &query.Until}
+	for name, target := range timeParams {
+		if raw := params.Get(name); raw != "" {
+			value, err := strconv.ParseInt(raw, 10, 64)
+			if err != nil {
+				return query, fmt.Errorf("invalid %s: %q (expected unix timestamp)", name, raw)
+			}
+			*target = value
+		}
+	}
+
+	return query, nil
+}
+
+// liveExecutionsResponse lists a job's in-flight executions
+type liveExecutionsResponse struct {
+	Executions []*cron.LiveExecution `json:"executions"`
+}
+
+// jobLogsResponse lists a job's log files
+type jobLogsResponse struct {
+	Files []cron.JobLogFile `json:"files"`
+}
+
+// handleCronExecutions handles GET /api/crons/:id/executions (in-flight executions)
+func handleCronExecutions(w http.ResponseWriter, r *http.Request, jobID string) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, errCodeMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	executions, err := cronManager.ListLiveExecutions(jobID)
+	if err != nil {
+		log.Printf("Error listing cron executions: %v", err)
+		writeError(w, http.StatusNotFound, cronNotFoundCode(err), err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(liveExecutionsResponse{Executions: executions}); err != nil {
+		log.Printf("Error encoding response: %v", err)
+	}
+}
+
+// handleCronLogs handles GET /api/crons/:id/logs (log files of log_to_file jobs)
+func handleCronLogs(w http.ResponseWriter, r *http.Request, jobID string) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, errCodeMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	files, err := cronManager.ListJobLogs(jobID)
+	if err != nil {
+		log.Printf("Error listing cron logs: %v", err)
+		if isNotFoundError(err) {
+			writeError(w, http.StatusNotFound, cronNotFoundCode(err), err.Error())
+		} else {
+			writeError(w, http.StatusInternalServerError, errCodeInternal, err.Error())
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(jobLogsResponse{Files: files}); err != nil {
+		log.Printf("Error encoding response: %v", err)
+	}
+}
+
+// handleCronLogDownload handles GET /api/crons/:id/logs/:name
+func handleCronLogDownload(w http.ResponseWriter, r *http.Request, jobID, name string) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		writeError(w, http.StatusMethodNotAllowed, errCodeMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	file, err := cronManager.OpenJobLog(jobID, name)
+	if err != nil {
+		log.Printf("Error opening cron log: %v", err)
+		if isNotFoundError(err) {
+			writeError(w, http.StatusNotFound, cronNotFoundCode(err), err.Error())
+		} else {
+			writeError(w, http.StatusInternalServerError, errCodeInternal, err.Error())
+		}
+		return
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, errCodeInternal, err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", name))
+	http.ServeContent(w, r, name, info.ModTime(), file)
+}
+
+// handleCronExecutionStream handles GET /api/crons/:id/executions/:execId/stream.
+// Output is sent as Server-Sent Events: "output" events carry JSON-encoded text
+// chunks, and a final "done" event carries the execution result.
+func handleCronExecutionStream(w http.ResponseWriter, r *http.Request, jobID, execID string) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, errCodeMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	live, result, err := cronManager.GetExecution(jobID, execID)
+	if err != nil {
+		log.Printf("Error getting cron execution: %v", err)
+		writeError(w, http.StatusNotFound, cronNotFoundCode(err), err.Error())
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, http.StatusInternalServerError, errCodeInternal, "Streaming not supported")
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	if live != nil {
+		backlog, output, unsubscribe := live.Subscribe()
+		defer unsubscribe()
+
+		if len(backlog) > 0 {
+			if err := writeSSEEvent(w, "output", string(backlog)); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+
+	streamLoop:
+		for {
+			select {
+			case chunk, ok := <-output:
+				if !ok {
+					break streamLoop
+				}
+				if err := writeSSEEvent(w, "output", string(chunk)); err != nil {
+					return
+				}
+				flusher.Flush()
+			case <-r.Context().Done():
+				return
+			}
+		}
+
+		select {
+		case <-live.Done():
+		default:
+			// The stream fell behind the command output and was disconnected
+			if err := writeSSEEvent(w, "error", "stream fell behind the execution output"); err == nil {
+				flusher.Flush()
+			}
+			return
+		}
+
+		// The result is recorded in history before the live execution finishes
+		_, result, err = cronManager.GetExecution(jobID, execID)
+		if err != nil {
+			log.Printf("Error getting finished cron execution: %v", err)
+			return
+		}
+	} else if result.Output != "" {
+		if err := writeSSEEvent(w, "output", result.Output); err != nil {
+			return
+		}
+	}
+
+	if err := writeSSEEvent(w, "done", result); err == nil {
+		flusher.Flush()
+	}
+}
+
+// writeSSEEvent writes a single Server-Sent Event with a JSON-encoded payload
+func writeSSEEvent(w io.Writer, event string, data interface{}) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, payload)
+	return err
+}
+
 // handleCronEnable handles POST /api/crons/:id/enable
 func handleCronEnable(w http.ResponseWriter, r *http.Request, jobID string) {
 	if r.Method != http.MethodPost {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		writeError(w, http.StatusMethodNotAllowed, errCodeMethodNotAllowed, "Method not allowed")
 		return
 	}
 
 	if err := cronManager.Enable(jobID); err != nil {
 		log.Printf("Error enabling cron job: %v", err)
 		if isNotFoundError(err) {
-			http.Error(w, err.Error(), http.StatusNotFound)
+			writeError(w, http.StatusNotFound, cronNotFoundCode(err), err.Error())
 		} else {
-			http.Error(w, err.Error(), http.StatusBadRequest)
+			writeError(w, http.StatusBadRequest, errCodeInvalidRequest, err.Error())
 		}
 		return
 	}
@@ -1057,16 +2164,16 @@ func handleCronEnable(w http.ResponseWriter, r *http.Request, jobID string) {
 // handleCronDisable handles POST /api/crons/:id/disable
 func handleCronDisable(w http.ResponseWriter, r *http.Request, jobID string) {
 	if r.Method != http.MethodPost {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		writeError(w, http.StatusMethodNotAllowed, errCodeMethodNotAllowed, "Method not allowed")
 		return
 	}
 
 	if err := cronManager.Disable(jobID); err != nil {
 		log.Printf("Error disabling cron job: %v", err)
 		if isNotFoundError(err) {
-			http.Error(w, err.Error(), http.StatusNotFound)
+			writeError(w, http.StatusNotFound, cronNotFoundCode(err), err.Error())
 		} else {
-			http.Error(w, err.Error(), http.StatusBadRequest)
+			writeError(w, http.StatusBadRequest, errCodeInvalidRequest, err.Error())
 		}
 		return
 	}
@@ -1082,7 +2189,7 @@ func handleWebSocket(w http.ResponseWriter, r *http.Request) {
 
 	if sessionID == "" {
 		log.Println("Session ID is required")
-		http.Error(w, "Session ID is required", http.StatusBadRequest)
+		writeError(w, http.StatusBadRequest, errCodeInvalidRequest, "Session ID is required")
 		return
 	}
 
@@ -1090,9 +2197,34 @@ func handleWebSocket(w http.ResponseWriter, r *http.Request) {
 	sess, ok := sessionManager.Get(sessionID)
 	if !ok {
 		log.Printf("Session not found: %s", sessionID)
-		http.Error(w, "Session not found", http.StatusNotFound)
+		writeError(w, http.StatusNotFound, errCodeSessionNotFound, "Session not found")
 		return
 	}
+	readOnly := isDemoVisitor(r)
+
+	// A reconnecting client sends the sequence number of the first output
+	// byte it has not seen to receive only what it missed
+	var since uint64
+	sinceParam := r.URL.Query().Get("since")
+	if sinceParam != "" {
+		var err error
+		if since, err = strconv.ParseUint(sinceParam, 10, 64); err != nil {
+			writeError(w, http.StatusBadRequest, errCodeInvalidRequest, "since must be a non-negative integer")
+			return
+		}
+	}
+
+	// Refuse before upgrading so the client gets a proper HTTP status
+	if limited, ok := sess.(interface{ CanAddClient() error }); ok {
+		if err := limited.CanAddClient(); err != nil {
+			var limitErr *terminal.LimitError
+			if errors.As(err, &limitErr) {
+				log.Printf("Rejecting client for session %s: %v", sessionID, err)
+				writeLimitError(w, http.StatusConflict, limitErr)
+				return
+			}
+		}
+	}
 
 	conn, err := upgrader.Upgrade(w, r, nil)
 	if err != nil {
@@ -1107,20 +2239,35 @@ func handleWebSocket(w http.ResponseWriter, r *http.Request) {
 		return conn.SetReadDeadline(time.Now().Add(websocketPongWait))
 	})
 
-	// Create WebSocket client wrapper
+	// Create WebSocket client wrapper. ?name= sets the display name other
+	// clients see in presence messages.
 	wsClient := &WebSocketClientImpl{
-		conn: conn,
-		send: make(chan []byte, 256),
+		conn:     conn,
+		send:     make(chan outgoingMessage, 256),
+		name:     terminal.SanitizeClientName(r.URL.Query().Get("name")),
+		since:    since,
+		resuming: sinceParam != "",
+
+		remoteIP:  extractClientIP(r),
+		userAgent: r.UserAgent(),
 	}
 
 	// Register client with session
 	if err := sess.AddClient(wsClient); err != nil {
 		log.Printf("Error adding client: %v", err)
+		var limitErr *terminal.LimitError
+		if errors.As(err, &limitErr) {
+			// Lost a race for the last slot after upgrading
+			_ = conn.WriteControl(websocket.CloseMessage,
+				websocket.FormatCloseMessage(websocket.ClosePolicyViolation, limitErr.Error()),
+				time.Now().Add(websocketWriteWait))
+		}
 		if closeErr := conn.Close(); closeErr != nil {
 			log.Printf("Error closing connection: %v", closeErr)
 		}
 		return
 	}
+	sendMOTD(wsClient)
 
 	// Handle cleanup on close
 	defer func() {
@@ -1146,12 +2293,12 @@ func handleWebSocket(w http.ResponseWriter, r *http.Request) {
 				// Reset write deadline before each message
 				_ = conn.SetWriteDeadline(time.Now().Add(websocketWriteWait))
 
-				w, err := conn.NextWriter(websocket.BinaryMessage)
+				w, err := conn.NextWriter(message.messageType)
 				if err != nil {
 					log.Printf("Error getting writer: %v", err)
 					return
 				}
-				if _, err := w.Write(message); err != nil {
+				if _, err := w.Write(message.data); err != nil {
 					log.Printf("Error writing to WebSocket: %v", err)
 					return
 				}
@@ -1159,6 +2306,7 @@ func handleWebSocket(w http.ResponseWriter, r *http.Request) {
 					log.Printf("Error closing writer: %v", err)
 					return
 				}
+				wsClient.bytesSent.Add(uint64(len(message.data)))
 			case <-pingTicker.C:
 				_ = conn.SetWriteDeadline(time.Now().Add(websocketWriteWait))
 				if pingErr := conn.WriteMessage(websocket.PingMessage, nil); pingErr != nil {
@@ -1182,6 +2330,9 @@ func handleWebSocket(w http.ResponseWriter, r *http.Request) {
 			}
 			break
 		}
+		// Any message shows the client is still there, even if a pong is
+		// stuck behind a burst of input
+		_ = conn.SetReadDeadline(time.Now().Add(websocketPongWait))
 
 		var msg terminal.ClientMessage
 		if err := json.Unmarshal(message, &msg); err != nil {
@@ -1189,26 +2340,96 @@ func handleWebSocket(w http.ResponseWriter, r *http.Request) {
 			continue
 		}
 
+		// Demo visitors only watch: no input, resizes or control requests
+		if readOnly {
+			continue
+		}
+		handleClientMessage(sess, sessionID, wsClient, msg)
+	}
+}
+
+// handleClientMessage applies a message from a client attached over
+// WebSocket or server-sent events to its session
+func handleClientMessage(sess terminal.Session, sessionID string, client terminal.WebSocketClient, msg terminal.ClientMessage) {
+	controller, _ := sess.(terminal.InputController)
+	if msg.Type == "input" || msg.Type == "paste" || msg.Type == "signal" {
+		// In single-writer mode input from clients without control is dropped
+		if controller != nil && !controller.AllowInput(client) {
+			return
+		}
+		if tracker, ok := sess.(terminal.InputTracker); ok {
+			tracker.NoteInput(client)
+		}
+	}
+
+	switch msg.Type {
+	case "input":
+		if _, err := sess.Write([]byte(msg.Data)); err != nil {
+			log.Printf("Error writing to session: %v", err)
+		}
+	case "resize":
+		if err := sess.Resize(client, msg.Cols, msg.Rows); err != nil {
+			log.Printf("Error resizing session: %v", err)
+		}
+	case "paste":
+		paster, ok := sess.(terminal.Paster)
+		if !ok {
+			log.Printf("Session %s does not accept pastes", sessionID)
+			return
+		}
+		if err := paster.Paste(msg.Data); err != nil {
+			log.Printf("Error pasting to session: %v", err)
+		}
+	case "signal":
+		signaler, ok := sess.(terminal.Signaler)
+		if !ok {
+			log.Printf("Session %s does not accept signals", sessionID)
+			return
+		}
+		if err := signaler.Signal(msg.Signal); err != nil {
+			log.Printf("Error sending %s to session %s: %v", msg.Signal, sessionID, err)
+		}
+	case "request_control", "grant_control", "release_control":
+		if controller == nil {
+			log.Printf("Session %s does not support input control", sessionID)
+			return
+		}
+		var err error
 		switch msg.Type {
-		case "input":
-			if _, err := sess.Write([]byte(msg.Data)); err != nil {
-				log.Printf("Error writing to session: %v", err)
-			}
-		case "resize":
-			if err := sess.Resize(wsClient, msg.Cols, msg.Rows); err != nil {
-				log.Printf("Error resizing session: %v", err)
-			}
+		case "request_control":
+			err = controller.RequestControl(client)
+		case "grant_control":
+			err = controller.GrantControl(client, msg.ClientID)
 		default:
-			log.Printf("Unknown message type: %s", msg.Type)
+			err = controller.ReleaseControl(client)
+		}
+		if err != nil {
+			log.Printf("Error handling %s for session %s: %v", msg.Type, sessionID, err)
 		}
+	default:
+		log.Printf("Unknown message type: %s", msg.Type)
 	}
 }
 
 func Run() {
 	var addr = flag.String("addr", ":8081", "http service address")
+	var listenAddr = flag.String("listen", "", "listen address, host:port or unix:/path/to.sock (overrides -addr)")
 	var passwordFile = flag.String("password-file", "", "path to password file (default: ~/.terminal-hub/credentials.json)")
+	var sshAddr = flag.String("ssh-addr", "", "SSH service address for attaching native terminals, such as :2222 (disabled when empty)")
+	var demo = flag.Bool("demo", false, "read-only demo: visitors without a login may watch sessions tagged TERMINAL_HUB_DEMO_TAG (default: demo)")
 	flag.Parse()
 
+	handoff, err := readUpgradeState()
+	if err != nil {
+		log.Fatal("Failed to take over from the previous process: ", err)
+	}
+	upgradeHandoff = handoff
+
+	if err := configureWebSocketKeepaliveFromEnv(); err != nil {
+		log.Printf("Warning: invalid WebSocket keepalive settings, using defaults: %v", err)
+	}
+	log.Printf("WebSocket keepalive: ping every %s, close after %s without a reply", websocketPingPeriod, websocketPongWait)
+
 	// Session TTL (default 24h)
 	sessionTTL := 24 * time.Hour
 	if ttlStr := os.Getenv("TERMINAL_HUB_SESSION_TTL"); ttlStr != "" {
@@ -1216,6 +2437,13 @@ func Run() {
 			sessionTTL = ttl
 		}
 	}
+	// Remember-me logins (default 30 days)
+	rememberTTL := 30 * 24 * time.Hour
+	if ttlStr := os.Getenv("TERMINAL_HUB_SESSION_REMEMBER_TTL"); ttlStr != "" {
+		if ttl, err := time.ParseDuration(ttlStr); err == nil {
+			rememberTTL = ttl
+		}
+	}
 
 	// Initialize session manager with authentication
 	// Priority: environment variables > password file
@@ -1241,20 +2469,22 @@ func Run() {
 			filePath = os.Getenv("TERMINAL_HUB_PASSWORD_FILE")
 		}
 
-		usernameHash, passwordHash, err := auth.LoadCredentials(filePath)
+		users, err := auth.LoadUsers(filePath)
 		if err != nil {
 			log.Fatalf("Failed to load password file: %v", err)
 		}
 
-		sessionAuthManager = auth.NewSessionManagerFromHash(usernameHash, passwordHash, sessionTTL)
-		log.Printf("Cookie-based authentication enabled (source: password file: %s)", filePath)
+		sessionAuthManager = auth.NewSessionManagerFromUsers(users, sessionTTL)
+		sessionAuthManager.SetPasswordFile(filePath)
+		log.Printf("Cookie-based authentication enabled (source: password file: %s, %d users)", filePath, len(users))
 	} else {
 		// Try default password file location
 		defaultPath, err := auth.DefaultPasswordFilePath()
 		if err == nil {
-			if usernameHash, passwordHash, err := auth.LoadCredentials(defaultPath); err == nil {
-				sessionAuthManager = auth.NewSessionManagerFromHash(usernameHash, passwordHash, sessionTTL)
-				log.Printf("Cookie-based authentication enabled (source: password file: %s)", defaultPath)
+			if users, err := auth.LoadUsers(defaultPath); err == nil {
+				sessionAuthManager = auth.NewSessionManagerFromUsers(users, sessionTTL)
+				sessionAuthManager.SetPasswordFile(defaultPath)
+				log.Printf("Cookie-based authentication enabled (source: password file: %s, %d users)", defaultPath, len(users))
 			} else {
 				// No default password file, run without auth
 				sessionAuthManager = auth.NewSessionManager("", "", sessionTTL)
@@ -1266,31 +2496,220 @@ func Run() {
 		}
 	}
 
+	passwordPolicy, err := auth.GetPasswordPolicyFromEnv()
+	if err != nil {
+		log.Fatalf("Invalid password policy: %v", err)
+	}
+	sessionAuthManager.SetPasswordPolicy(passwordPolicy)
+	sessionAuthManager.SetRememberTTL(rememberTTL)
+
+	if upgradeHandoff != nil {
+		sessionAuthManager.RestoreSessions(upgradeHandoff.LoginSessions)
+	}
+
+	executionAllowlist = terminal.GetExecutionAllowlistFromEnv()
+	if len(executionAllowlist.Shells) > 0 || len(executionAllowlist.Directories) > 0 {
+		log.Printf("Restricting shells to %v and working directories to %v", executionAllowlist.Shells, executionAllowlist.Directories)
+	}
+
 	if err := InitSessionManager(); err != nil {
 		log.Fatal("Failed to initialize session manager:", err)
 	}
 
+	masterKey, err := credstore.GetMasterKeyFromEnv()
+	if err != nil {
+		log.Fatal("Failed to read credential store key:", err)
+	}
+	if masterKey != nil {
+		storePath := credstore.GetStorePathFromEnv()
+		credentialStore, err = credstore.Open(storePath, masterKey)
+		if err != nil {
+			log.Fatal("Failed to open credential store:", err)
+		}
+		sessionManager.SSHGateway().SetKeyLookup(func(name string) ([]byte, error) {
+			return credentialStore.Value(name, credstore.TypeSSHKey)
+		})
+		log.Printf("Credential store enabled (%s)", storePath)
+	} else {
+		log.Printf("Credential store disabled: set TERMINAL_HUB_CREDENTIALS_KEY or TERMINAL_HUB_CREDENTIALS_KEY_FILE")
+	}
+
 	loginBanTracker := newLoginFail2Ban(defaultMaxLoginFailures, defaultLoginBanDuration)
 	go loginBanTracker.StartCleanupLoop(5 * time.Minute)
 
+	// Process info (PID, CPU, memory, foreground command) in session metadata
+	go sessionManager.StartProcessSampler(terminal.GetProcessSampleIntervalFromEnv())
+	// Branch, dirty state and ahead/behind counts of sessions in git repos
+	go sessionManager.StartGitSampler(terminal.GetGitSampleIntervalFromEnv())
+	// Host CPU, memory, disk and load for GET /api/system/stats
+	if interval := sysstats.GetIntervalFromEnv(); interval > 0 {
+		systemStats = sysstats.NewCollector(sysstats.DefaultDisks())
+		go systemStats.Run(interval)
+	}
+
+	// Push notifications (ntfy) for session events and cron failures
+	notifyStore, err := notify.OpenStore(notify.GetStorePathFromEnv())
+	if err != nil {
+		log.Printf("Warning: push notifications are unavailable: %v", err)
+	} else {
+		notifier = notify.NewDispatcher(notifyStore)
+		go forwardSessionNotifications(sessionManager.Events(), notifier)
+	}
+
+	// Signed webhooks for session, cron and auth events
+	webhookStore, err := webhook.OpenStore(webhook.GetStorePathFromEnv())
+	if err != nil {
+		log.Printf("Warning: webhooks are unavailable: %v", err)
+	} else {
+		webhooks = webhook.NewDispatcher(webhookStore)
+		go forwardSessionWebhooks(sessionManager.Events(), webhooks)
+	}
+
+	// Inbound hooks that run cron jobs or session commands when called
+	if store, err := hooks.OpenStore(hooks.GetStorePathFromEnv()); err != nil {
+		log.Printf("Warning: inbound hooks are unavailable: %v", err)
+	} else {
+		inboundHooks = store
+	}
+
+	// Security alerts and cron failures emailed to the admin
+	if adminAddresses, err := notify.GetAdminEmailFromEnv(); err != nil {
+		log.Printf("Warning: admin email alerts are disabled: %v", err)
+	} else if len(adminAddresses) > 0 {
+		if channel, err := notify.NewEmailChannel(notify.GetSMTPConfigFromEnv(), adminAddresses); err != nil {
+			log.Printf("Warning: admin email alerts are disabled: %v", err)
+		} else {
+			adminEmail = channel
+			log.Printf("Admin email alerts enabled (%s)", strings.Join(adminAddresses, ", "))
+		}
+	}
+	if devices, err := auth.OpenDeviceStore(auth.GetDeviceStorePathFromEnv()); err != nil {
+		log.Printf("Warning: new device logins will not be reported: %v", err)
+	} else {
+		knownDevices = devices
+	}
+
+	// Uploads, cron history and session archives pause while the data
+	// directory is low on space
+	if minFree := diskguard.GetMinFreeBytesFromEnv(); minFree > 0 {
+		diskGuard = diskguard.New(diskguard.DefaultDataDir(), minFree)
+		diskGuard.SetChangeHandler(reportDiskSpace)
+		sessionManager.SetWriteGuard(diskGuard.Err)
+		go diskGuard.Run(diskguard.DefaultInterval)
+	}
+
+	apiLimiter := newAPIRateLimiterFromEnv()
+	if apiLimiter != nil {
+		go apiLimiter.StartCleanupLoop(5 * time.Minute)
+	} else {
+		log.Printf("API rate limiting disabled via TERMINAL_HUB_RATE_LIMIT")
+	}
+
+	basePath, err = getBasePathFromEnv()
+	if err != nil {
+		log.Fatal("Invalid base path: ", err)
+	}
+	sessionCookie, err = getSessionCookieFromEnv()
+	if err != nil {
+		log.Fatal("Invalid session cookie settings: ", err)
+	}
+	demoMode, err = getDemoModeFromEnv(*demo)
+	if err != nil {
+		log.Fatal("Invalid demo settings: ", err)
+	}
+	if demoMode.Enabled {
+		log.Printf("Demo mode: visitors without a login may watch sessions tagged %q read-only", demoMode.Tag)
+	}
+
+	// Branding served to the frontend by GET /api/config/ui
+	currentUIConfig, err = loadUIConfig(getUIConfigPathFromEnv())
+	if err != nil {
+		log.Fatal("Invalid UI configuration: ", err)
+	}
+
+	proxies, err := trustedProxiesFromEnv()
+	if err != nil {
+		log.Fatal("Invalid trusted proxy configuration: ", err)
+	}
+	trustedProxies = proxies
+
+	ipAccess, err := newIPFilterFromEnv()
+	if err != nil {
+		log.Fatal("Invalid IP filter configuration: ", err)
+	}
+	if ipAccess != nil {
+		log.Printf("IP filter enabled (allowed: %d ranges, denied: %d ranges)", len(ipAccess.allowed), len(ipAccess.denied))
+	}
+
+	uploadPolicy, err = getUploadRulesFromEnv()
+	if err != nil {
+		log.Fatal("Invalid upload configuration: ", err)
+	}
+	if uploadPolicy.scanner != nil {
+		log.Printf("Uploads are scanned with clamd at %s", uploadPolicy.scanner.address)
+	}
+
+	tunnelAllow, err = getTunnelAllowlistFromEnv()
+	if err != nil {
+		log.Fatal("Invalid tunnel configuration: ", err)
+	}
+	if len(tunnelAllow) > 0 {
+		log.Printf("TCP tunnels enabled (%d allowed targets)", len(tunnelAllow))
+	}
+
 	// Initialize CronManager if enabled
 	if cron.IsCronEnabledFromEnv() {
 		cronFile := cron.GetCronFilePathFromEnv()
 		maxHistory := cron.GetHistorySizeFromEnv()
 
-		var err error
-		cronManager, err = cron.NewCronManager(cronFile, maxHistory)
+		store, err := cron.OpenStoreFromEnv(cronFile)
+		if err != nil {
+			log.Fatal("Failed to open cron store:", err)
+		}
+		cronManager, err = cron.NewCronManagerWithStore(store, maxHistory)
 		if err != nil {
 			log.Fatal("Failed to initialize cron manager:", err)
 		}
 
+		// Jobs with a target_session_id are typed into live terminal sessions
+		cronManager.SetSessionLookup(sessionManager.Get)
+		// "secret://name" env var values come from the credential store
+		if credentialStore != nil {
+			cronManager.SetSecretLookup(func(name string) ([]byte, error) {
+				return credentialStore.Value(name, credstore.TypeSecret)
+			})
+		}
+		cronManager.SetExecutionAllowlist(executionAllowlist)
+		cronManager.SetRunAs(sessionManager.RunAs())
+		cronManager.SetNotificationChannels(notifyChannels)
+		if diskGuard != nil {
+			cronManager.SetWriteGuard(diskGuard.Err)
+		}
+		cronManager.SetNotificationHandler(func(job cron.CronJob, event string, result cron.CronExecutionResult) {
+			publishNotification(cron.PushNotification(job, event, result))
+			if event == cron.NotificationEventRecovered {
+				publishCronWebhookEvent(job, event, result)
+			}
+		})
+		cronManager.SetExecutionHandler(func(job cron.CronJob, result cron.CronExecutionResult) {
+			event := "succeeded"
+			if result.ExitCode != 0 {
+				event = cron.NotificationEventFailed
+			}
+			publishCronWebhookEvent(job, event, result)
+		})
+
+		// Jobs with log_to_file keep their full output in rotated files
+		jobLogConfig := cron.GetJobLogConfigFromEnv(cronFile)
+		cronManager.SetJobLogs(cron.NewJobLogs(jobLogConfig))
+
 		// Start the scheduler
 		if err := cronManager.Start(); err != nil {
 			log.Fatal("Failed to start cron scheduler:", err)
 		}
 
-		log.Printf("Cron feature enabled (file: %s, max history: %d)", cronFile, maxHistory)
-		defer cronManager.Stop()
+		log.Printf("Cron feature enabled (store: %s, max history: %d, logs: %s)", store, maxHistory, jobLogConfig.Dir)
+		defer cronManager.Close()
 	} else {
 		log.Printf("Cron feature disabled via TERMINAL_HUB_CRON_ENABLED")
 	}
@@ -1314,6 +2733,18 @@ func Run() {
 	http.HandleFunc("/api/auth/status", func(w http.ResponseWriter, r *http.Request) {
 		handleAuthStatus(w, r, sessionAuthManager)
 	})
+	http.HandleFunc("/api/config/ui", handleUIConfig)
+
+	// Login sessions of the current user
+	http.HandleFunc("/api/auth/sessions", sessionAuthMiddleware(func(w http.ResponseWriter, r *http.Request) {
+		handleAuthSessions(w, r, sessionAuthManager)
+	}, sessionAuthManager))
+	http.HandleFunc("/api/auth/sessions/", sessionAuthMiddleware(func(w http.ResponseWriter, r *http.Request) {
+		handleAuthSessionByID(w, r, sessionAuthManager)
+	}, sessionAuthManager))
+	http.HandleFunc("/api/auth/password", sessionAuthMiddleware(func(w http.ResponseWriter, r *http.Request) {
+		handleChangePassword(w, r, sessionAuthManager)
+	}, sessionAuthManager))
 
 	// Serve the embedded React frontend with SPA fallback
 	http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
@@ -1328,7 +2759,7 @@ func Run() {
 			if trimmedPath == "/login" {
 				r.URL.Path = "/"
 			}
-			fileServer.ServeHTTP(w, r)
+			serveStatic(w, r, embeddedFS, fileServer)
 			return
 		}
 
@@ -1339,13 +2770,13 @@ func Run() {
 
 			// Check if the file exists in the embedded filesystem
 			if _, err := embeddedFS.Open(strings.TrimPrefix(path, "/")); err == nil {
-				fileServer.ServeHTTP(w, r)
+				serveStatic(w, r, embeddedFS, fileServer)
 				return
 			}
 
 			// If not found, serve index.html for SPA routing
 			r.URL.Path = "/"
-			fileServer.ServeHTTP(w, r)
+			serveStatic(w, r, embeddedFS, fileServer)
 		}, sessionAuthManager)(w, r)
 	})
 
@@ -1358,40 +2789,153 @@ func Run() {
 		case http.MethodPost:
 			handleCreateSession(w, r)
 		default:
-			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			writeError(w, http.StatusMethodNotAllowed, errCodeMethodNotAllowed, "Method not allowed")
 		}
 	}, sessionAuthManager))
 
-	// Handle /api/sessions/:id (DELETE, PUT)
-	http.HandleFunc("/api/sessions/", sessionAuthMiddleware(func(w http.ResponseWriter, r *http.Request) {
-		// Handle operations on specific sessions
-		switch r.Method {
-		case http.MethodDelete:
-			handleDeleteSession(w, r)
-		case http.MethodPut:
-			handleUpdateSession(w, r)
-		default:
-			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-		}
-	}, sessionAuthManager))
+	// Write the same input to several sessions
+	http.HandleFunc("/api/sessions/broadcast-input", sessionAuthMiddleware(handleBroadcastInput, sessionAuthManager))
+
+	// Persist a custom session order
+	http.HandleFunc("/api/sessions/reorder", sessionAuthMiddleware(handleReorderSessions, sessionAuthManager))
+
+	// Attach to tmux sessions started outside terminal-hub
+	http.HandleFunc("/api/sessions/adopt", sessionAuthMiddleware(handleAdoptSession, sessionAuthManager))
+	http.HandleFunc("/api/tmux/sessions", sessionAuthMiddleware(handleHostTmuxSessions, sessionAuthManager))
+
+	// Handle /api/sessions/:id (DELETE, PUT) and /api/sessions/:id/* (tmux windows and panes, history search, screen snapshots, watch rules)
+	http.HandleFunc("/api/sessions/", sessionAuthMiddleware(handleSessionByID, sessionAuthManager))
+
+	// Saved session configurations, some started at boot
+	http.HandleFunc("/api/templates", sessionAuthMiddleware(handleTemplates, sessionAuthManager))
+	http.HandleFunc("/api/templates/", sessionAuthMiddleware(handleTemplateByID, sessionAuthManager))
+	http.HandleFunc("/api/notifications/subscribe", sessionAuthMiddleware(handleNotificationSubscribe, sessionAuthManager))
+	http.HandleFunc("/api/notifications/subscriptions", sessionAuthMiddleware(handleNotificationSubscriptions, sessionAuthManager))
+	http.HandleFunc("/api/notifications/subscriptions/", sessionAuthMiddleware(handleNotificationSubscriptions, sessionAuthManager))
+	http.HandleFunc("/api/webhooks", sessionAuthMiddleware(handleWebhooks, sessionAuthManager))
+	http.HandleFunc("/api/webhooks/", sessionAuthMiddleware(handleWebhookByID, sessionAuthManager))
+	http.HandleFunc("/api/inbound-hooks", sessionAuthMiddleware(handleInboundHooks, sessionAuthManager))
+	http.HandleFunc("/api/inbound-hooks/", sessionAuthMiddleware(handleInboundHookByID, sessionAuthManager))
+	// Hook calls are authorized by their token, not a login
+	http.HandleFunc("/api/hooks/", handleHookTrigger)
 
 	// File download endpoint (session-independent)
 	http.HandleFunc("/api/files/browse", sessionAuthMiddleware(handleFileBrowse, sessionAuthManager))
 	http.HandleFunc("/api/download", sessionAuthMiddleware(handleFileDownload, sessionAuthManager))
+	http.HandleFunc("/api/download/link", sessionAuthMiddleware(handleDownloadLink, sessionAuthManager))
+	// Download links are authorized by their token, not a login
+	http.HandleFunc("/api/download/link/", handleDownloadLinkRedeem)
 	http.HandleFunc("/api/upload", sessionAuthMiddleware(handleFileUpload, sessionAuthManager))
+	http.HandleFunc("/api/files/extract", sessionAuthMiddleware(handleFileExtract, sessionAuthManager))
+	http.HandleFunc("/api/files/paste", sessionAuthMiddleware(handleFilePaste, sessionAuthManager))
+
+	// SSH gateway keys and known hosts
+	http.HandleFunc("/api/ssh/keys", sessionAuthMiddleware(handleSSHKeys, sessionAuthManager))
+	http.HandleFunc("/api/ssh/known-hosts", sessionAuthMiddleware(handleSSHKnownHosts, sessionAuthManager))
+
+	// Credential store routes (only if a master key is configured)
+	if credentialStore != nil {
+		http.HandleFunc("/api/credentials", sessionAuthMiddleware(handleCredentials, sessionAuthManager))
+		http.HandleFunc("/api/credentials/", sessionAuthMiddleware(handleCredentialByName, sessionAuthManager))
+	}
 
 	// Cron API routes (only if cron is enabled)
 	if cronManager != nil {
 		// Handle /api/crons (GET list, POST create)
 		http.HandleFunc("/api/crons", sessionAuthMiddleware(handleCrons, sessionAuthManager))
 
+		// Handle /api/crons/history (GET history of all jobs)
+		http.HandleFunc("/api/crons/history", sessionAuthMiddleware(handleCronAllHistory, sessionAuthManager))
+
+		// Handle /api/crons/overview (GET dashboard statistics)
+		http.HandleFunc("/api/crons/overview", sessionAuthMiddleware(handleCronOverview, sessionAuthManager))
+
+		// Handle /api/crons/export (GET) and /api/crons/import (POST)
+		http.HandleFunc("/api/crons/export", sessionAuthMiddleware(handleCronExport, sessionAuthManager))
+		http.HandleFunc("/api/crons/import", sessionAuthMiddleware(handleCronImport, sessionAuthManager))
+
+		// Handle /api/crons/pause-all and /api/crons/resume-all (POST maintenance mode)
+		http.HandleFunc("/api/crons/pause-all", sessionAuthMiddleware(handleCronPauseAll, sessionAuthManager))
+		http.HandleFunc("/api/crons/resume-all", sessionAuthMiddleware(handleCronResumeAll, sessionAuthManager))
+
+		// Handle /api/crons/preview (GET schedule preview)
+		http.HandleFunc("/api/crons/preview", sessionAuthMiddleware(handleCronPreview, sessionAuthManager))
+
 		// Handle /api/crons/:id (GET, PUT, DELETE) and /api/crons/:id/* (actions)
 		http.HandleFunc("/api/crons/", sessionAuthMiddleware(handleCronByID, sessionAuthManager))
+
+		// Handle /api/exec (POST one-off command, recorded under /api/crons/adhoc)
+		http.HandleFunc("/api/exec", sessionAuthMiddleware(handleRunCommand, sessionAuthManager))
 	}
 
-	// WebSocket route - handle /ws/:sessionId
+	// Restart on a new binary without ending tmux-backed sessions
+	http.HandleFunc("/api/admin/upgrade", sessionAuthMiddleware(handleAdminUpgrade, sessionAuthManager))
+
+	// Host and per-session resource usage
+	http.HandleFunc("/api/system/stats", sessionAuthMiddleware(handleSystemStats, sessionAuthManager))
+
+	// OpenAPI description of the REST API, and Swagger UI to browse it
+	http.HandleFunc("/api/openapi.json", sessionAuthMiddleware(handleOpenAPISpec, sessionAuthManager))
+	http.HandleFunc("/api/docs", sessionAuthMiddleware(handleAPIDocs, sessionAuthManager))
+
+	// WebDAV share of each session's root, for mounting as a network drive
+	http.HandleFunc("/dav/", davAuthMiddleware(handleDAV, sessionAuthManager, loginBanTracker))
+
+	// Preview of ports opened by processes in a session, such as dev servers
+	http.HandleFunc("/proxy/", sessionAuthMiddleware(handleProxy, sessionAuthManager))
+
+	// WebSocket routes - /ws/events streams session events, /ws/tunnel bridges TCP, /ws/:sessionId attaches to a session
+	http.HandleFunc("/ws/events", sessionAuthMiddleware(handleEventsWebSocket, sessionAuthManager))
+	http.HandleFunc("/ws/tunnel", sessionAuthMiddleware(handleTunnelWebSocket, sessionAuthManager))
 	http.HandleFunc("/ws/", sessionAuthMiddleware(handleWebSocket, sessionAuthManager))
+	// /sse/:sessionId is the fallback for networks that drop WebSockets
+	http.HandleFunc("/sse/", sessionAuthMiddleware(handleSSE, sessionAuthManager))
 
-	log.Printf("Server starting on %s", *addr)
-	log.Fatal(http.ListenAndServe(*addr, nil))
+	address := *addr
+	if *listenAddr != "" {
+		address = *listenAddr
+	}
+	listener, err := openListener(address)
+	if err != nil {
+		log.Fatal("Failed to listen: ", err)
+	}
+
+	handler := ipFilterMiddleware(withBasePath(compressMiddleware(rateLimitMiddleware(validateRequestMiddleware(http.DefaultServeMux), apiLimiter)), basePath), ipAccess)
+	if listener.Addr().Network() == "unix" {
+		handler = localPeerMiddleware(handler)
+	}
+	httpServer := &http.Server{Handler: handler}
+	hubUpgrader = newUpgrader(listener, httpServer, sessionAuthManager)
+	go handleUpgradeSignals(hubUpgrader)
+
+	// SSH access to sessions, for native terminal emulators
+	if *sshAddr != "" {
+		sshListener, err := upgradeSSHListener()
+		if err == nil && sshListener == nil {
+			sshListener, err = net.Listen("tcp", *sshAddr)
+		}
+		if err != nil {
+			log.Fatal("Failed to listen for SSH: ", err)
+		}
+		hostKeyFile, authorizedKeysFile := sshServerFiles()
+		sshSrv, err := newSSHServer(sessionAuthManager, loginBanTracker, ipAccess, hostKeyFile, authorizedKeysFile)
+		if err != nil {
+			log.Fatal("Failed to start SSH server: ", err)
+		}
+		hubUpgrader.SetSSHServer(sshSrv, sshListener)
+		go func() {
+			if err := sshSrv.Serve(sshListener); err != nil {
+				log.Printf("SSH server stopped: %v", err)
+			}
+		}()
+		log.Printf("SSH server starting on %s", sshListener.Addr())
+	}
+
+	log.Printf("Server starting on %s:%s%s", listener.Addr().Network(), listener.Addr(), basePath)
+	signalUpgradeReady()
+	if err := httpServer.Serve(listener); !errors.Is(err, http.ErrServerClosed) {
+		log.Fatal(err)
+	}
+	hubUpgrader.Wait()
+	log.Printf("Handed over to the new process, exiting")
 }
@@ -0,0 +1,116 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/iwanhae/terminal-hub/auth"
+)
+
+// defaultSessionCookieName is the login cookie's name unless
+// TERMINAL_HUB_COOKIE_NAME sets another
+const defaultSessionCookieName = "session_token"
+
+// sessionCookieSettings configures the cookie logins are kept in
+type sessionCookieSettings struct {
+	Name     string        // TERMINAL_HUB_COOKIE_NAME
+	Domain   string        // TERMINAL_HUB_COOKIE_DOMAIN; host-only when empty
+	SameSite http.SameSite // TERMINAL_HUB_COOKIE_SAMESITE: lax, strict or none
+	Rolling  bool          // TERMINAL_HUB_COOKIE_ROLLING: re-issue the cookie on every authenticated request
+}
+
+// sessionCookie holds the settings of the login cookie
+var sessionCookie = sessionCookieSettings{
+	Name:     defaultSessionCookieName,
+	SameSite: http.SameSiteLaxMode,
+}
+
+// getSessionCookieFromEnv reads the login cookie's settings
+func getSessionCookieFromEnv() (sessionCookieSettings, error) {
+	settings := sessionCookieSettings{
+		Name:     defaultSessionCookieName,
+		Domain:   strings.TrimSpace(os.Getenv("TERMINAL_HUB_COOKIE_DOMAIN")),
+		SameSite: http.SameSiteLaxMode,
+	}
+	if name := strings.TrimSpace(os.Getenv("TERMINAL_HUB_COOKIE_NAME")); name != "" {
+		settings.Name = name
+	}
+
+	switch value := strings.ToLower(strings.TrimSpace(os.Getenv("TERMINAL_HUB_COOKIE_SAMESITE"))); value {
+	case "", "lax":
+	case "strict":
+		settings.SameSite = http.SameSiteStrictMode
+	case "none":
+		settings.SameSite = http.SameSiteNoneMode
+	default:
+		return settings, fmt.Errorf("TERMINAL_HUB_COOKIE_SAMESITE must be lax, strict or none, got %q", value)
+	}
+
+	if value := strings.TrimSpace(os.Getenv("TERMINAL_HUB_COOKIE_ROLLING")); value != "" {
+		rolling, err := strconv.ParseBool(value)
+		if err != nil {
+			return settings, fmt.Errorf("TERMINAL_HUB_COOKIE_ROLLING must be true or false, got %q", value)
+		}
+		settings.Rolling = rolling
+	}
+
+	if err := (&http.Cookie{Name: settings.Name, Value: "x", Domain: settings.Domain}).Valid(); err != nil {
+		return settings, fmt.Errorf("invalid session cookie: %w", err)
+	}
+	// Browsers drop __Host- cookies that name a domain or a path other than /
+	if strings.HasPrefix(settings.Name, "__Host-") && (settings.Domain != "" || cookiePath() != "/") {
+		return settings, fmt.Errorf("cookie name %q needs no TERMINAL_HUB_COOKIE_DOMAIN and no base path", settings.Name)
+	}
+	return settings, nil
+}
+
+// secure reports whether the cookie must be marked Secure for r: over HTTPS,
+// and always when browsers only accept it so
+func (s sessionCookieSettings) secure(r *http.Request) bool {
+	return isSecure(r) || s.SameSite == http.SameSiteNoneMode ||
+		strings.HasPrefix(s.Name, "__Secure-") || strings.HasPrefix(s.Name, "__Host-")
+}
+
+// setSessionCookie sends the login cookie of session. It expires with the
+// session's TTL from now, or when the browser closes for
+// PersistBrowserSession logins.
+func setSessionCookie(w http.ResponseWriter, r *http.Request, sm *auth.SessionManager, session *auth.Session) {
+	cookie := &http.Cookie{
+		Name:     sessionCookie.Name,
+		Value:    session.ID,
+		HttpOnly: true,
+		Secure:   sessionCookie.secure(r),
+		SameSite: sessionCookie.SameSite,
+		Domain:   sessionCookie.Domain,
+		Path:     cookiePath(),
+	}
+	if session.Persistence != auth.PersistBrowserSession {
+		ttl := sm.SessionTTL(session)
+		cookie.Expires = time.Now().Add(ttl)
+		cookie.MaxAge = int(ttl / time.Second)
+	}
+	http.SetCookie(w, cookie)
+}
+
+// clearSessionCookie tells the browser to drop the login cookie
+func clearSessionCookie(w http.ResponseWriter, r *http.Request) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     sessionCookie.Name,
+		Value:    "",
+		MaxAge:   -1,
+		HttpOnly: true,
+		Secure:   sessionCookie.secure(r),
+		SameSite: sessionCookie.SameSite,
+		Domain:   sessionCookie.Domain,
+		Path:     cookiePath(),
+	})
+}
+
+// sessionCookieOf returns the login cookie of r
+func sessionCookieOf(r *http.Request) (*http.Cookie, error) {
+	return r.Cookie(sessionCookie.Name)
+}
@@ -0,0 +1,151 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/iwanhae/terminal-hub/auth"
+)
+
+func TestGetSessionCookieFromEnv(t *testing.T) {
+	basePath = ""
+	t.Setenv("TERMINAL_HUB_COOKIE_NAME", "__Secure-hub")
+	t.Setenv("TERMINAL_HUB_COOKIE_DOMAIN", "example.com")
+	t.Setenv("TERMINAL_HUB_COOKIE_SAMESITE", "Strict")
+	t.Setenv("TERMINAL_HUB_COOKIE_ROLLING", "true")
+
+	settings, err := getSessionCookieFromEnv()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := sessionCookieSettings{Name: "__Secure-hub", Domain: "example.com", SameSite: http.SameSiteStrictMode, Rolling: true}
+	if settings != want {
+		t.Errorf("expected %+v, got %+v", want, settings)
+	}
+
+	for name, env := range map[string][2]string{
+		"unknown SameSite":    {"TERMINAL_HUB_COOKIE_SAMESITE", "sometimes"},
+		"invalid rolling":     {"TERMINAL_HUB_COOKIE_ROLLING", "often"},
+		"invalid name":        {"TERMINAL_HUB_COOKIE_NAME", "bad name"},
+		"invalid domain":      {"TERMINAL_HUB_COOKIE_DOMAIN", "exa mple.com"},
+		"__Host- with domain": {"TERMINAL_HUB_COOKIE_NAME", "__Host-hub"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Setenv(env[0], env[1])
+			if _, err := getSessionCookieFromEnv(); err == nil {
+				t.Errorf("expected %s=%q to be rejected", env[0], env[1])
+			}
+		})
+	}
+}
+
+func TestSessionCookieSettingsApply(t *testing.T) {
+	basePath = ""
+	saved := sessionCookie
+	t.Cleanup(func() { sessionCookie = saved })
+	sessionCookie = sessionCookieSettings{Name: "hub_login", Domain: "example.com", SameSite: http.SameSiteStrictMode}
+
+	sm := auth.NewSessionManager("admin", "secret", 2*time.Hour)
+	rec := performLoginRequest(t, sm, nil, "", "", "admin", "secret")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected login to succeed, got %d", rec.Code)
+	}
+	var login *http.Cookie
+	for _, cookie := range rec.Result().Cookies() {
+		if cookie.Name == "hub_login" {
+			login = cookie
+		}
+	}
+	if login == nil {
+		t.Fatalf("expected the renamed login cookie, got %v", rec.Result().Cookies())
+	}
+	if login.SameSite != http.SameSiteStrictMode || login.Domain != "example.com" || !login.HttpOnly || login.MaxAge != 7200 {
+		t.Errorf("expected a strict, domain-scoped, HttpOnly cookie for the TTL, got %+v", login)
+	}
+	if remaining := time.Until(login.Expires); remaining < time.Hour || remaining > 2*time.Hour {
+		t.Errorf("expected the cookie to expire with the TTL, got %v", login.Expires)
+	}
+
+	handler := sessionAuthMiddleware(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}, sm)
+	request := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/sessions", nil)
+		req.AddCookie(&http.Cookie{Name: "hub_login", Value: login.Value})
+		rec := httptest.NewRecorder()
+		handler(rec, req)
+		return rec
+	}
+	if rec := request(); rec.Code != http.StatusOK || len(rec.Result().Cookies()) != 0 {
+		t.Errorf("expected the renamed cookie to authenticate without renewal, got %d, %v", rec.Code, rec.Result().Cookies())
+	}
+
+	sessionCookie.Rolling = true
+	rec = request()
+	if cookies := rec.Result().Cookies(); len(cookies) != 1 || cookies[0].Name != "hub_login" || cookies[0].Value != login.Value || cookies[0].MaxAge != 7200 {
+		t.Errorf("expected a rolling cookie to be re-issued, got %v", cookies)
+	}
+}
+
+func TestSessionCookieSameSiteNoneIsSecure(t *testing.T) {
+	settings := sessionCookieSettings{Name: defaultSessionCookieName, SameSite: http.SameSiteNoneMode}
+	if !settings.secure(httptest.NewRequest(http.MethodGet, "http://hub/", nil)) {
+		t.Error("expected SameSite=None cookies to be Secure, as browsers require")
+	}
+}
+
+func TestLoginPersistence(t *testing.T) {
+	saved := sessionCookie
+	t.Cleanup(func() { sessionCookie = saved })
+	sessionCookie = sessionCookieSettings{Name: defaultSessionCookieName, SameSite: http.SameSiteLaxMode, Rolling: true}
+	sm := auth.NewSessionManager("admin", "secret", time.Hour)
+	sm.SetRememberTTL(72 * time.Hour)
+
+	login := func(body string) *http.Cookie {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodPost, "/api/auth/login", strings.NewReader(body))
+		rec := httptest.NewRecorder()
+		handleLogin(rec, req, sm, nil)
+		for _, cookie := range rec.Result().Cookies() {
+			if cookie.Name == defaultSessionCookieName {
+				return cookie
+			}
+		}
+		t.Fatalf("%s: expected a session cookie, got %d", body, rec.Code)
+		return nil
+	}
+
+	tests := []struct {
+		body       string
+		wantMaxAge int
+	}{
+		{`{"username": "admin", "password": "secret"}`, 3600},
+		{`{"username": "admin", "password": "secret", "remember": true}`, 72 * 3600},
+		{`{"username": "admin", "password": "secret", "remember": false}`, 0},
+	}
+	for _, tt := range tests {
+		cookie := login(tt.body)
+		if cookie.MaxAge != tt.wantMaxAge || (tt.wantMaxAge == 0) != cookie.Expires.IsZero() {
+			t.Errorf("%s: expected Max-Age %d, got %d and Expires %v", tt.body, tt.wantMaxAge, cookie.MaxAge, cookie.Expires)
+		}
+
+		// Renewal keeps the mode chosen at login
+		handler := sessionAuthMiddleware(func(w http.ResponseWriter, r *http.Request) {}, sm)
+		req := httptest.NewRequest(http.MethodGet, "/api/sessions", nil)
+		req.AddCookie(&http.Cookie{Name: defaultSessionCookieName, Value: cookie.Value})
+		rec := httptest.NewRecorder()
+		handler(rec, req)
+		if renewed := rec.Result().Cookies(); len(renewed) != 1 || renewed[0].MaxAge != tt.wantMaxAge {
+			t.Errorf("%s: expected the renewed cookie to keep Max-Age %d, got %v", tt.body, tt.wantMaxAge, renewed)
+		}
+	}
+
+	remembered, _ := sm.CreateClientSession("admin", auth.ClientInfo{}, auth.PersistRemember)
+	remembered.LastActivity = time.Now().Add(-2 * time.Hour)
+	if _, ok := sm.ValidateSession(remembered.ID); !ok {
+		t.Error("expected a remembered session to outlive the default TTL")
+	}
+}
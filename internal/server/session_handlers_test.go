@@ -0,0 +1,205 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/iwanhae/terminal-hub/terminal"
+)
+
+func TestSessionTagsAndListFilters(t *testing.T) {
+	sessionManager = terminal.NewSessionManager()
+	t.Cleanup(func() { _ = sessionManager.CloseAll() })
+
+	for _, id := range []string{"alpha", "beta"} {
+		ptyReader, ptyWriter, err := os.Pipe()
+		if err != nil {
+			t.Fatalf("failed to create PTY pipe: %v", err)
+		}
+		t.Cleanup(func() {
+			_ = ptyWriter.Close()
+			_ = ptyReader.Close()
+		})
+		if _, err := sessionManager.CreateSession(terminal.SessionConfig{
+			ID:         id,
+			Name:       id,
+			Backend:    terminal.SessionBackendPTY,
+			PTYService: &pipePTYService{reader: ptyReader},
+		}); err != nil {
+			t.Fatalf("failed to create session: %v", err)
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	handleSessionByID(rec, httptest.NewRequest(http.MethodPut, "/api/sessions/beta", strings.NewReader(`{"tags":["web","prod"]}`)))
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("expected status 204, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	list := func(query string) []string {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		handleListSessions(rec, httptest.NewRequest(http.MethodGet, "/api/sessions"+query, nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s: expected status 200, got %d: %s", query, rec.Code, rec.Body.String())
+		}
+		var infos []terminal.SessionInfo
+		if err := json.Unmarshal(rec.Body.Bytes(), &infos); err != nil {
+			t.Fatalf("failed to decode sessions: %v", err)
+		}
+		var ids []string
+		for _, info := range infos {
+			ids = append(ids, info.ID)
+		}
+		return ids
+	}
+
+	if got := strings.Join(list("?tag=web"), ","); got != "beta" {
+		t.Errorf("tag filter: got %q", got)
+	}
+	if got := strings.Join(list("?sort=name"), ","); got != "alpha,beta" {
+		t.Errorf("name sort: got %q", got)
+	}
+	if got := strings.Join(list("?sort=name&order=desc&state=detached"), ","); got != "beta,alpha" {
+		t.Errorf("descending name sort: got %q", got)
+	}
+
+	rec = httptest.NewRecorder()
+	handleReorderSessions(rec, httptest.NewRequest(http.MethodPost, "/api/sessions/reorder", strings.NewReader(`{"session_ids":["beta"]}`)))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("reorder: expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if got := strings.Join(list(""), ","); got != "beta,alpha" {
+		t.Errorf("custom order: got %q", got)
+	}
+	rec = httptest.NewRecorder()
+	handleSessionByID(rec, httptest.NewRequest(http.MethodPut, "/api/sessions/alpha", strings.NewReader(`{"pinned":true}`)))
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("pin: expected status 204, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if got := strings.Join(list(""), ","); got != "alpha,beta" {
+		t.Errorf("pinned order: got %q", got)
+	}
+
+	rec = httptest.NewRecorder()
+	handleListSessions(rec, httptest.NewRequest(http.MethodGet, "/api/sessions?limit=1&offset=1&fields=name,tags", nil))
+	var page struct {
+		Sessions []struct {
+			ID       string                     `json:"id"`
+			Metadata map[string]json.RawMessage `json:"metadata"`
+		} `json:"sessions"`
+		Total int `json:"total"`
+		Limit int `json:"limit"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &page); err != nil {
+		t.Fatalf("failed to decode page: %v", err)
+	}
+	if page.Total != 2 || page.Limit != 1 || len(page.Sessions) != 1 || page.Sessions[0].ID != "beta" {
+		t.Fatalf("unexpected page: %s", rec.Body.String())
+	}
+	if metadata := page.Sessions[0].Metadata; len(metadata) != 2 || metadata["name"] == nil || metadata["tags"] == nil {
+		t.Errorf("expected only name and tags, got %s", rec.Body.String())
+	}
+
+	tests := []struct {
+		method string
+		path   string
+		body   string
+		want   int
+	}{
+		{http.MethodPut, "/api/sessions/beta", `{}`, http.StatusBadRequest},
+		{http.MethodPut, "/api/sessions/beta", `{"tags":["no spaces"]}`, http.StatusBadRequest},
+		{http.MethodPut, "/api/sessions/missing", `{"tags":["web"]}`, http.StatusNotFound},
+		{http.MethodPut, "/api/sessions/beta", `{"input_mode":"exclusive"}`, http.StatusBadRequest},
+		{http.MethodPut, "/api/sessions/beta", `{"input_mode":"single_writer"}`, http.StatusNoContent},
+		{http.MethodGet, "/api/sessions?state=sleeping", "", http.StatusBadRequest},
+		{http.MethodGet, "/api/sessions?order=up", "", http.StatusBadRequest},
+		{http.MethodGet, "/api/sessions?limit=-1", "", http.StatusBadRequest},
+		{http.MethodGet, "/api/sessions?offset=x", "", http.StatusBadRequest},
+		{http.MethodGet, "/api/sessions?fields=password", "", http.StatusBadRequest},
+		{http.MethodPost, "/api/sessions/reorder", `{"session_ids":[]}`, http.StatusBadRequest},
+		{http.MethodPost, "/api/sessions/reorder", `{"session_ids":["beta","beta"]}`, http.StatusBadRequest},
+		{http.MethodPost, "/api/sessions/reorder", `{"session_ids":["missing"]}`, http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+		switch {
+		case tt.method == http.MethodGet:
+			handleListSessions(rec, req)
+		case tt.path == "/api/sessions/reorder":
+			handleReorderSessions(rec, req)
+		default:
+			handleSessionByID(rec, req)
+		}
+		if rec.Code != tt.want {
+			t.Errorf("%s %s: expected status %d, got %d: %s", tt.method, tt.path, tt.want, rec.Code, rec.Body.String())
+		}
+	}
+}
+
+func TestSessionCloneEndpoint(t *testing.T) {
+	sessionManager = terminal.NewSessionManager()
+	t.Cleanup(func() { _ = sessionManager.CloseAll() })
+
+	dir := t.TempDir()
+	if _, err := sessionManager.CreateSession(terminal.SessionConfig{
+		ID:               "source",
+		Name:             "worker",
+		Shell:            "/bin/sh",
+		WorkingDirectory: dir,
+		EnvVars:          map[string]string{"QUEUE": "jobs"},
+		Backend:          terminal.SessionBackendPTY,
+		Tags:             []string{"ops"},
+	}); err != nil {
+		t.Fatalf("failed to create session: %v", err)
+	}
+
+	clone := func(id, body string) *httptest.ResponseRecorder {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		handleSessionByID(rec, httptest.NewRequest(http.MethodPost, "/api/sessions/"+id+"/clone", strings.NewReader(body)))
+		return rec
+	}
+
+	for body, name := range map[string]string{"": "worker (copy)", `{"name":"worker 2"}`: "worker 2"} {
+		rec := clone("source", body)
+		if rec.Code != http.StatusCreated {
+			t.Fatalf("%q: expected status 201, got %d: %s", body, rec.Code, rec.Body.String())
+		}
+		var resp terminal.CreateSessionResponse
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		if resp.ID == "source" || resp.Metadata.Name != name {
+			t.Errorf("%q: got session %s named %q", body, resp.ID, resp.Metadata.Name)
+		}
+		if resp.Metadata.WorkingDirectory != dir || strings.Join(resp.Metadata.Tags, ",") != "ops" {
+			t.Errorf("%q: clone metadata differs: %+v", body, resp.Metadata)
+		}
+	}
+	if count := sessionManager.SessionCount(); count != 3 {
+		t.Errorf("expected 3 sessions, got %d", count)
+	}
+
+	if rec := clone("source", `{"name":" "}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("blank name: expected status 400, got %d", rec.Code)
+	}
+	if rec := clone("missing", ""); rec.Code != http.StatusNotFound {
+		t.Errorf("missing session: expected status 404, got %d", rec.Code)
+	}
+}
+
+func TestCloneSessionName(t *testing.T) {
+	if got := cloneSessionName("api"); got != "api (copy)" {
+		t.Errorf("got %q", got)
+	}
+	long := strings.Repeat("é", terminal.MaxSessionNameLength)
+	if err := terminal.ValidateSessionName(cloneSessionName(long)); err != nil {
+		t.Errorf("name of a clone of a long name: %v", err)
+	}
+}
@@ -0,0 +1,728 @@
+package server
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+// SFTP version 3 (draft-ietf-secsh-filexfer-02), served on the SSH server's
+// "sftp" subsystem so FileZilla, sftp and scp can transfer files in bulk
+
+// Packet types
+const (
+	sftpPacketInit     = 1
+	sftpPacketVersion  = 2
+	sftpPacketOpen     = 3
+	sftpPacketClose    = 4
+	sftpPacketRead     = 5
+	sftpPacketWrite    = 6
+	sftpPacketLstat    = 7
+	sftpPacketFstat    = 8
+	sftpPacketSetstat  = 9
+	sftpPacketFsetstat = 10
+	sftpPacketOpendir  = 11
+	sftpPacketReaddir  = 12
+	sftpPacketRemove   = 13
+	sftpPacketMkdir    = 14
+	sftpPacketRmdir    = 15
+	sftpPacketRealpath = 16
+	sftpPacketStat     = 17
+	sftpPacketRename   = 18
+	sftpPacketReadlink = 19
+	sftpPacketSymlink  = 20
+	sftpPacketStatus   = 101
+	sftpPacketHandle   = 102
+	sftpPacketData     = 103
+	sftpPacketName     = 104
+	sftpPacketAttrs    = 105
+	sftpPacketExtended = 200
+)
+
+// Status codes
+const (
+	sftpStatusOK               = 0
+	sftpStatusEOF              = 1
+	sftpStatusNoSuchFile       = 2
+	sftpStatusPermissionDenied = 3
+	sftpStatusFailure          = 4
+	sftpStatusBadMessage       = 5
+	sftpStatusOpUnsupported    = 8
+)
+
+// Open flags
+const (
+	sftpOpenRead   = 0x01
+	sftpOpenWrite  = 0x02
+	sftpOpenAppend = 0x04
+	sftpOpenCreate = 0x08
+	sftpOpenTrunc  = 0x10
+	sftpOpenExcl   = 0x20
+)
+
+// Attribute flags
+const (
+	sftpAttrSize        = 0x01
+	sftpAttrUIDGID      = 0x02
+	sftpAttrPermissions = 0x04
+	sftpAttrACModTime   = 0x08
+	sftpAttrExtended    = 0x80000000
+)
+
+const (
+	sftpMaxPacket    = 256*1024 + 1024 // the largest read or write clients send, plus headers
+	sftpMaxRead      = 256 * 1024
+	sftpReaddirBatch = 100
+
+	// sftpPosixRename renames over an existing file, which plain RENAME
+	// refuses; the OpenSSH client uses it when the server advertises it
+	sftpPosixRename = "posix-rename@openssh.com"
+)
+
+var errSFTPBadMessage = errors.New("malformed packet")
+
+// sftpAttrs are a file's attributes; flags says which are set
+type sftpAttrs struct {
+	flags       uint32
+	size        uint64
+	uid, gid    uint32
+	permissions uint32
+	atime       uint32
+	mtime       uint32
+}
+
+// sftpHandle is an open file or directory
+type sftpHandle struct {
+	file    *os.File
+	append  bool          // writes go to the end, ignoring offsets
+	dir     string        // set for directories
+	entries []os.DirEntry // directory entries not yet returned, nil before the first READDIR
+	listed  bool
+}
+
+// sftpSession serves one client's requests against the host's filesystem.
+// Relative paths are relative to root, the file browser's directory.
+type sftpSession struct {
+	root       string
+	out        io.Writer
+	handles    map[string]*sftpHandle
+	nextHandle uint64
+}
+
+// serveSFTP answers SFTP requests from rw until the client disconnects
+func serveSFTP(rw io.ReadWriter, root string) error {
+	s := &sftpSession{root: root, out: rw, handles: make(map[string]*sftpHandle)}
+	defer s.closeAll()
+
+	header := make([]byte, 4)
+	for {
+		if _, err := io.ReadFull(rw, header); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+		length := binary.BigEndian.Uint32(header)
+		if length == 0 || length > sftpMaxPacket {
+			return fmt.Errorf("sftp packet of %d bytes is too large", length)
+		}
+		packet := make([]byte, length)
+		if _, err := io.ReadFull(rw, packet); err != nil {
+			return err
+		}
+		if err := s.handle(packet[0], &sftpReader{buf: packet[1:]}); err != nil {
+			return err
+		}
+	}
+}
+
+// handle answers a request, returning an error only when the reply cannot
+// be sent
+func (s *sftpSession) handle(packetType byte, r *sftpReader) error {
+	if packetType == sftpPacketInit {
+		reply := &sftpWriter{}
+		reply.byte(sftpPacketVersion)
+		reply.uint32(3)
+		reply.string(sftpPosixRename)
+		reply.string("1")
+		return s.send(reply)
+	}
+
+	id := r.uint32()
+	if r.err != nil {
+		return s.sendStatus(id, errSFTPBadMessage)
+	}
+	switch packetType {
+	case sftpPacketOpen:
+		return s.open(id, r)
+	case sftpPacketClose:
+		handle := r.string()
+		if r.err != nil {
+			return s.sendStatus(id, r.err)
+		}
+		return s.sendStatus(id, s.closeHandle(handle))
+	case sftpPacketRead:
+		return s.read(id, r)
+	case sftpPacketWrite:
+		return s.write(id, r)
+	case sftpPacketLstat, sftpPacketStat:
+		path := s.resolve(r.string())
+		if r.err != nil {
+			return s.sendStatus(id, r.err)
+		}
+		stat := os.Stat
+		if packetType == sftpPacketLstat {
+			stat = os.Lstat
+		}
+		info, err := stat(path)
+		if err != nil {
+			return s.sendStatus(id, err)
+		}
+		return s.sendAttrs(id, info)
+	case sftpPacketFstat:
+		h, err := s.fileHandle(r)
+		if err != nil {
+			return s.sendStatus(id, err)
+		}
+		info, err := h.file.Stat()
+		if err != nil {
+			return s.sendStatus(id, err)
+		}
+		return s.sendAttrs(id, info)
+	case sftpPacketSetstat:
+		path := s.resolve(r.string())
+		attrs := r.attrs()
+		if r.err != nil {
+			return s.sendStatus(id, r.err)
+		}
+		return s.sendStatus(id, setSFTPAttrs(path, nil, attrs))
+	case sftpPacketFsetstat:
+		h, err := s.fileHandle(r)
+		attrs := r.attrs()
+		if err == nil {
+			err = r.err
+		}
+		if err != nil {
+			return s.sendStatus(id, err)
+		}
+		return s.sendStatus(id, setSFTPAttrs(h.file.Name(), h.file, attrs))
+	case sftpPacketOpendir:
+		return s.opendir(id, r)
+	case sftpPacketReaddir:
+		return s.readdir(id, r)
+	case sftpPacketRemove:
+		path := s.resolve(r.string())
+		if r.err != nil {
+			return s.sendStatus(id, r.err)
+		}
+		if info, err := os.Lstat(path); err != nil {
+			return s.sendStatus(id, err)
+		} else if info.IsDir() {
+			return s.sendStatus(id, fmt.Errorf("%s is a directory", path))
+		}
+		return s.sendStatus(id, os.Remove(path))
+	case sftpPacketMkdir:
+		path := s.resolve(r.string())
+		attrs := r.attrs()
+		if r.err != nil {
+			return s.sendStatus(id, r.err)
+		}
+		perm := fs.FileMode(0o755)
+		if attrs.flags&sftpAttrPermissions != 0 {
+			perm = fs.FileMode(attrs.permissions) & fs.ModePerm
+		}
+		return s.sendStatus(id, os.Mkdir(path, perm))
+	case sftpPacketRmdir:
+		path := s.resolve(r.string())
+		if r.err != nil {
+			return s.sendStatus(id, r.err)
+		}
+		if info, err := os.Lstat(path); err != nil {
+			return s.sendStatus(id, err)
+		} else if !info.IsDir() {
+			return s.sendStatus(id, fmt.Errorf("%s is not a directory", path))
+		}
+		return s.sendStatus(id, os.Remove(path))
+	case sftpPacketRealpath:
+		path := s.resolve(r.string())
+		if r.err != nil {
+			return s.sendStatus(id, r.err)
+		}
+		return s.sendName(id, filepath.ToSlash(path))
+	case sftpPacketRename:
+		from, to := s.resolve(r.string()), s.resolve(r.string())
+		if r.err != nil {
+			return s.sendStatus(id, r.err)
+		}
+		// Version 3 renames fail when the target exists
+		if _, err := os.Lstat(to); err == nil {
+			return s.sendStatus(id, fmt.Errorf("%s already exists", to))
+		}
+		return s.sendStatus(id, os.Rename(from, to))
+	case sftpPacketReadlink:
+		path := s.resolve(r.string())
+		if r.err != nil {
+			return s.sendStatus(id, r.err)
+		}
+		target, err := os.Readlink(path)
+		if err != nil {
+			return s.sendStatus(id, err)
+		}
+		return s.sendName(id, filepath.ToSlash(target))
+	case sftpPacketSymlink:
+		// OpenSSH sends the target first, the reverse of the draft, and
+		// clients follow it
+		target, link := r.string(), s.resolve(r.string())
+		if r.err != nil {
+			return s.sendStatus(id, r.err)
+		}
+		return s.sendStatus(id, os.Symlink(filepath.FromSlash(target), link))
+	case sftpPacketExtended:
+		name := r.string()
+		if name != sftpPosixRename {
+			return s.sendStatusCode(id, sftpStatusOpUnsupported, "unsupported extension "+name)
+		}
+		from, to := s.resolve(r.string()), s.resolve(r.string())
+		if r.err != nil {
+			return s.sendStatus(id, r.err)
+		}
+		return s.sendStatus(id, os.Rename(from, to))
+	default:
+		return s.sendStatusCode(id, sftpStatusOpUnsupported, fmt.Sprintf("unsupported request %d", packetType))
+	}
+}
+
+// resolve returns the host path of a client path
+func (s *sftpSession) resolve(path string) string {
+	path = filepath.FromSlash(path)
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(s.root, path)
+	}
+	return filepath.Clean(path)
+}
+
+func (s *sftpSession) open(id uint32, r *sftpReader) error {
+	path := s.resolve(r.string())
+	pflags := r.uint32()
+	attrs := r.attrs()
+	if r.err != nil {
+		return s.sendStatus(id, r.err)
+	}
+
+	flags := os.O_RDONLY
+	switch {
+	case pflags&sftpOpenRead != 0 && pflags&sftpOpenWrite != 0:
+		flags = os.O_RDWR
+	case pflags&sftpOpenWrite != 0:
+		flags = os.O_WRONLY
+	}
+	if pflags&sftpOpenAppend != 0 {
+		flags |= os.O_APPEND
+	}
+	if pflags&sftpOpenCreate != 0 {
+		flags |= os.O_CREATE
+	}
+	if pflags&sftpOpenTrunc != 0 {
+		flags |= os.O_TRUNC
+	}
+	if pflags&sftpOpenExcl != 0 {
+		flags |= os.O_EXCL
+	}
+	perm := fs.FileMode(0o644)
+	if attrs.flags&sftpAttrPermissions != 0 {
+		perm = fs.FileMode(attrs.permissions) & fs.ModePerm
+	}
+
+	file, err := os.OpenFile(path, flags, perm)
+	if err != nil {
+		return s.sendStatus(id, err)
+	}
+	return s.sendHandle(id, &sftpHandle{file: file, append: flags&os.O_APPEND != 0})
+}
+
+func (s *sftpSession) opendir(id uint32, r *sftpReader) error {
+	path := s.resolve(r.string())
+	if r.err != nil {
+		return s.sendStatus(id, r.err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return s.sendStatus(id, err)
+	}
+	if !info.IsDir() {
+		return s.sendStatus(id, fmt.Errorf("%s is not a directory", path))
+	}
+	return s.sendHandle(id, &sftpHandle{dir: path})
+}
+
+func (s *sftpSession) read(id uint32, r *sftpReader) error {
+	h, err := s.fileHandle(r)
+	offset, length := r.uint64(), r.uint32()
+	if err == nil {
+		err = r.err
+	}
+	if err != nil {
+		return s.sendStatus(id, err)
+	}
+
+	data := make([]byte, min(length, sftpMaxRead))
+	n, err := h.file.ReadAt(data, int64(offset))
+	if n == 0 && err != nil {
+		return s.sendStatus(id, err)
+	}
+	reply := &sftpWriter{}
+	reply.byte(sftpPacketData)
+	reply.uint32(id)
+	reply.bytes(data[:n])
+	return s.send(reply)
+}
+
+func (s *sftpSession) write(id uint32, r *sftpReader) error {
+	h, err := s.fileHandle(r)
+	offset, data := r.uint64(), r.bytes()
+	if err == nil {
+		err = r.err
+	}
+	if err == nil {
+		err = diskGuard.Err()
+	}
+	if err != nil {
+		return s.sendStatus(id, err)
+	}
+	if h.append {
+		_, err = h.file.Write(data)
+	} else {
+		_, err = h.file.WriteAt(data, int64(offset))
+	}
+	return s.sendStatus(id, err)
+}
+
+func (s *sftpSession) readdir(id uint32, r *sftpReader) error {
+	handle := r.string()
+	h, ok := s.handles[handle]
+	if r.err != nil || !ok || h.dir == "" {
+		return s.sendStatusCode(id, sftpStatusFailure, "invalid handle")
+	}
+	if !h.listed {
+		entries, err := os.ReadDir(h.dir)
+		if err != nil {
+			return s.sendStatus(id, err)
+		}
+		h.entries, h.listed = entries, true
+	}
+	if len(h.entries) == 0 {
+		return s.sendStatus(id, io.EOF)
+	}
+
+	batch := h.entries[:min(len(h.entries), sftpReaddirBatch)]
+	h.entries = h.entries[len(batch):]
+	infos := make([]fs.FileInfo, 0, len(batch))
+	for _, entry := range batch {
+		// Entries removed since the listing are skipped
+		if info, err := entry.Info(); err == nil {
+			infos = append(infos, info)
+		}
+	}
+
+	reply := &sftpWriter{}
+	reply.byte(sftpPacketName)
+	reply.uint32(id)
+	reply.uint32(uint32(len(infos)))
+	for _, info := range infos {
+		reply.string(info.Name())
+		reply.string(sftpLongName(info))
+		reply.attrs(info)
+	}
+	return s.send(reply)
+}
+
+// fileHandle reads a handle and returns the open file it names
+func (s *sftpSession) fileHandle(r *sftpReader) (*sftpHandle, error) {
+	h, ok := s.handles[r.string()]
+	if r.err != nil || !ok || h.file == nil {
+		return nil, errors.New("invalid handle")
+	}
+	return h, nil
+}
+
+func (s *sftpSession) closeHandle(handle string) error {
+	h, ok := s.handles[handle]
+	if !ok {
+		return errors.New("invalid handle")
+	}
+	delete(s.handles, handle)
+	if h.file != nil {
+		return h.file.Close()
+	}
+	return nil
+}
+
+// closeAll closes the files a disconnected client left open
+func (s *sftpSession) closeAll() {
+	for handle, h := range s.handles {
+		if h.file != nil {
+			h.file.Close()
+		}
+		delete(s.handles, handle)
+	}
+}
+
+// setSFTPAttrs applies SETSTAT or FSETSTAT attributes to path, or to file
+// when it is open
+func setSFTPAttrs(path string, file *os.File, attrs sftpAttrs) error {
+	if attrs.flags&sftpAttrSize != 0 {
+		var err error
+		if file != nil {
+			err = file.Truncate(int64(attrs.size))
+		} else {
+			err = os.Truncate(path, int64(attrs.size))
+		}
+		if err != nil {
+			return err
+		}
+	}
+	if attrs.flags&sftpAttrPermissions != 0 {
+		if err := os.Chmod(path, fs.FileMode(attrs.permissions)&fs.ModePerm); err != nil {
+			return err
+		}
+	}
+	if attrs.flags&sftpAttrACModTime != 0 {
+		atime, mtime := time.Unix(int64(attrs.atime), 0), time.Unix(int64(attrs.mtime), 0)
+		if err := os.Chtimes(path, atime, mtime); err != nil {
+			return err
+		}
+	}
+	if attrs.flags&sftpAttrUIDGID != 0 {
+		if err := os.Chown(path, int(attrs.uid), int(attrs.gid)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *sftpSession) sendHandle(id uint32, h *sftpHandle) error {
+	s.nextHandle++
+	handle := strconv.FormatUint(s.nextHandle, 10)
+	s.handles[handle] = h
+
+	reply := &sftpWriter{}
+	reply.byte(sftpPacketHandle)
+	reply.uint32(id)
+	reply.string(handle)
+	return s.send(reply)
+}
+
+func (s *sftpSession) sendAttrs(id uint32, info fs.FileInfo) error {
+	reply := &sftpWriter{}
+	reply.byte(sftpPacketAttrs)
+	reply.uint32(id)
+	reply.attrs(info)
+	return s.send(reply)
+}
+
+// sendName replies with a single path and no attributes, for REALPATH and
+// READLINK
+func (s *sftpSession) sendName(id uint32, name string) error {
+	reply := &sftpWriter{}
+	reply.byte(sftpPacketName)
+	reply.uint32(id)
+	reply.uint32(1)
+	reply.string(name)
+	reply.string(name)
+	reply.uint32(0)
+	return s.send(reply)
+}
+
+// sendStatus replies with the status code matching err, OK when it is nil
+func (s *sftpSession) sendStatus(id uint32, err error) error {
+	switch {
+	case err == nil:
+		return s.sendStatusCode(id, sftpStatusOK, "")
+	case errors.Is(err, io.EOF):
+		return s.sendStatusCode(id, sftpStatusEOF, "end of file")
+	case errors.Is(err, errSFTPBadMessage):
+		return s.sendStatusCode(id, sftpStatusBadMessage, err.Error())
+	case errors.Is(err, fs.ErrNotExist):
+		return s.sendStatusCode(id, sftpStatusNoSuchFile, err.Error())
+	case errors.Is(err, fs.ErrPermission):
+		return s.sendStatusCode(id, sftpStatusPermissionDenied, err.Error())
+	default:
+		return s.sendStatusCode(id, sftpStatusFailure, err.Error())
+	}
+}
+
+func (s *sftpSession) sendStatusCode(id, code uint32, message string) error {
+	reply := &sftpWriter{}
+	reply.byte(sftpPacketStatus)
+	reply.uint32(id)
+	reply.uint32(code)
+	reply.string(message)
+	reply.string("en")
+	return s.send(reply)
+}
+
+func (s *sftpSession) send(w *sftpWriter) error {
+	packet := binary.BigEndian.AppendUint32(nil, uint32(len(w.buf)))
+	_, err := s.out.Write(append(packet, w.buf...))
+	return err
+}
+
+// sftpLongName formats a directory entry like `ls -l`, which clients show
+// as is
+func sftpLongName(info fs.FileInfo) string {
+	mode := info.Mode()
+	kind := "-"
+	switch {
+	case mode.IsDir():
+		kind = "d"
+	case mode&fs.ModeSymlink != 0:
+		kind = "l"
+	case mode&fs.ModeNamedPipe != 0:
+		kind = "p"
+	case mode&fs.ModeSocket != 0:
+		kind = "s"
+	case mode&fs.ModeCharDevice != 0:
+		kind = "c"
+	case mode&fs.ModeDevice != 0:
+		kind = "b"
+	}
+	modTime := info.ModTime()
+	stamp := modTime.Format("Jan _2 15:04")
+	if time.Since(modTime) > 180*24*time.Hour || modTime.After(time.Now()) {
+		stamp = modTime.Format("Jan _2  2006")
+	}
+	return fmt.Sprintf("%s%s 1 - - %8d %s %s", kind, (mode & fs.ModePerm).String()[1:], info.Size(), stamp, info.Name())
+}
+
+// sftpPermissions returns the POSIX mode bits, file type included, of a
+// Go file mode
+func sftpPermissions(mode fs.FileMode) uint32 {
+	bits := uint32(mode & fs.ModePerm)
+	if mode&fs.ModeSetuid != 0 {
+		bits |= 0o4000
+	}
+	if mode&fs.ModeSetgid != 0 {
+		bits |= 0o2000
+	}
+	if mode&fs.ModeSticky != 0 {
+		bits |= 0o1000
+	}
+	switch {
+	case mode.IsDir():
+		bits |= 0o040000
+	case mode&fs.ModeSymlink != 0:
+		bits |= 0o120000
+	case mode&fs.ModeNamedPipe != 0:
+		bits |= 0o010000
+	case mode&fs.ModeSocket != 0:
+		bits |= 0o140000
+	case mode&fs.ModeCharDevice != 0:
+		bits |= 0o020000
+	case mode&fs.ModeDevice != 0:
+		bits |= 0o060000
+	default:
+		bits |= 0o100000
+	}
+	return bits
+}
+
+// sftpReader decodes a packet, recording the first error so a request's
+// fields can be read before checking
+type sftpReader struct {
+	buf []byte
+	err error
+}
+
+func (r *sftpReader) uint32() uint32 {
+	if len(r.buf) < 4 {
+		r.err = errSFTPBadMessage
+		return 0
+	}
+	v := binary.BigEndian.Uint32(r.buf)
+	r.buf = r.buf[4:]
+	return v
+}
+
+func (r *sftpReader) uint64() uint64 {
+	if len(r.buf) < 8 {
+		r.err = errSFTPBadMessage
+		return 0
+	}
+	v := binary.BigEndian.Uint64(r.buf)
+	r.buf = r.buf[8:]
+	return v
+}
+
+func (r *sftpReader) bytes() []byte {
+	n := r.uint32()
+	if r.err != nil || uint32(len(r.buf)) < n {
+		r.err = errSFTPBadMessage
+		return nil
+	}
+	v := r.buf[:n]
+	r.buf = r.buf[n:]
+	return v
+}
+
+func (r *sftpReader) string() string {
+	return string(r.bytes())
+}
+
+func (r *sftpReader) attrs() sftpAttrs {
+	var a sftpAttrs
+	a.flags = r.uint32()
+	if a.flags&sftpAttrSize != 0 {
+		a.size = r.uint64()
+	}
+	if a.flags&sftpAttrUIDGID != 0 {
+		a.uid, a.gid = r.uint32(), r.uint32()
+	}
+	if a.flags&sftpAttrPermissions != 0 {
+		a.permissions = r.uint32()
+	}
+	if a.flags&sftpAttrACModTime != 0 {
+		a.atime, a.mtime = r.uint32(), r.uint32()
+	}
+	if a.flags&sftpAttrExtended != 0 {
+		for count := r.uint32(); count > 0 && r.err == nil; count-- {
+			r.string()
+			r.string()
+		}
+	}
+	return a
+}
+
+// sftpWriter encodes a packet body
+type sftpWriter struct {
+	buf []byte
+}
+
+func (w *sftpWriter) byte(v byte) { w.buf = append(w.buf, v) }
+
+func (w *sftpWriter) uint32(v uint32) { w.buf = binary.BigEndian.AppendUint32(w.buf, v) }
+
+func (w *sftpWriter) uint64(v uint64) { w.buf = binary.BigEndian.AppendUint64(w.buf, v) }
+
+func (w *sftpWriter) bytes(v []byte) {
+	w.uint32(uint32(len(v)))
+	w.buf = append(w.buf, v...)
+}
+
+func (w *sftpWriter) string(v string) { w.bytes([]byte(v)) }
+
+// attrs encodes a file's size, permissions and modification time, which is
+// also sent as its access time
+func (w *sftpWriter) attrs(info fs.FileInfo) {
+	w.uint32(sftpAttrSize | sftpAttrPermissions | sftpAttrACModTime)
+	w.uint64(uint64(info.Size()))
+	w.uint32(sftpPermissions(info.Mode()))
+	mtime := uint32(info.ModTime().Unix())
+	w.uint32(mtime)
+	w.uint32(mtime)
+}
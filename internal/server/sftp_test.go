@@ -0,0 +1,176 @@
+package server
+
+import (
+	"encoding/binary"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// sftpTestClient speaks just enough SFTP to exercise serveSFTP
+type sftpTestClient struct {
+	t      *testing.T
+	conn   net.Conn
+	nextID uint32
+}
+
+func newSFTPTestClient(t *testing.T, root string) *sftpTestClient {
+	t.Helper()
+	client, server := net.Pipe()
+	go func() { _ = serveSFTP(server, root) }()
+	t.Cleanup(func() { client.Close() })
+
+	c := &sftpTestClient{t: t, conn: client}
+	init := &sftpWriter{}
+	init.byte(sftpPacketInit)
+	init.uint32(3)
+	if packetType, r := c.roundTrip(init); packetType != sftpPacketVersion || r.uint32() != 3 {
+		t.Fatalf("unexpected version reply %d", packetType)
+	}
+	return c
+}
+
+// roundTrip sends a packet and returns the reply's type and body
+func (c *sftpTestClient) roundTrip(w *sftpWriter) (byte, *sftpReader) {
+	c.t.Helper()
+	packet := binary.BigEndian.AppendUint32(nil, uint32(len(w.buf)))
+	if _, err := c.conn.Write(append(packet, w.buf...)); err != nil {
+		c.t.Fatalf("failed to send: %v", err)
+	}
+	header := make([]byte, 4)
+	if _, err := io.ReadFull(c.conn, header); err != nil {
+		c.t.Fatalf("failed to read reply: %v", err)
+	}
+	reply := make([]byte, binary.BigEndian.Uint32(header))
+	if _, err := io.ReadFull(c.conn, reply); err != nil {
+		c.t.Fatalf("failed to read reply: %v", err)
+	}
+	return reply[0], &sftpReader{buf: reply[1:]}
+}
+
+// request sends a request of the given type, whose fields are written by
+// fields, and returns the reply with its ID checked
+func (c *sftpTestClient) request(packetType byte, fields func(w *sftpWriter)) (byte, *sftpReader) {
+	c.t.Helper()
+	c.nextID++
+	w := &sftpWriter{}
+	w.byte(packetType)
+	w.uint32(c.nextID)
+	fields(w)
+	replyType, r := c.roundTrip(w)
+	if id := r.uint32(); id != c.nextID {
+		c.t.Fatalf("reply ID %d, want %d", id, c.nextID)
+	}
+	return replyType, r
+}
+
+// expectStatus sends a request and checks the reply is a status with the
+// given code
+func (c *sftpTestClient) expectStatus(code uint32, packetType byte, fields func(w *sftpWriter)) {
+	c.t.Helper()
+	replyType, r := c.request(packetType, fields)
+	if replyType != sftpPacketStatus {
+		c.t.Fatalf("expected a status, got packet %d", replyType)
+	}
+	if got := r.uint32(); got != code {
+		c.t.Fatalf("status %d (%s), want %d", got, r.string(), code)
+	}
+}
+
+func (c *sftpTestClient) handle(replyType byte, r *sftpReader) string {
+	c.t.Helper()
+	if replyType != sftpPacketHandle {
+		c.t.Fatalf("expected a handle, got packet %d", replyType)
+	}
+	return r.string()
+}
+
+func TestSFTPTransfersFilesRelativeToRoot(t *testing.T) {
+	root := t.TempDir()
+	c := newSFTPTestClient(t, root)
+
+	// REALPATH resolves "." to the root
+	replyType, r := c.request(sftpPacketRealpath, func(w *sftpWriter) { w.string(".") })
+	if replyType != sftpPacketName || r.uint32() != 1 || r.string() != filepath.ToSlash(root) {
+		t.Fatalf("unexpected realpath reply")
+	}
+
+	// Upload a file in two writes
+	handle := c.handle(c.request(sftpPacketOpen, func(w *sftpWriter) {
+		w.string("notes.txt")
+		w.uint32(sftpOpenWrite | sftpOpenCreate | sftpOpenTrunc)
+		w.uint32(0)
+	}))
+	for i, chunk := range []string{"hello ", "sftp"} {
+		c.expectStatus(sftpStatusOK, sftpPacketWrite, func(w *sftpWriter) {
+			w.string(handle)
+			w.uint64(uint64(i * len("hello ")))
+			w.string(chunk)
+		})
+	}
+	c.expectStatus(sftpStatusOK, sftpPacketClose, func(w *sftpWriter) { w.string(handle) })
+	if data, err := os.ReadFile(filepath.Join(root, "notes.txt")); err != nil || string(data) != "hello sftp" {
+		t.Fatalf("unexpected file contents %q: %v", data, err)
+	}
+
+	// Download it back, then hit the end of the file
+	handle = c.handle(c.request(sftpPacketOpen, func(w *sftpWriter) {
+		w.string(filepath.ToSlash(filepath.Join(root, "notes.txt")))
+		w.uint32(sftpOpenRead)
+		w.uint32(0)
+	}))
+	replyType, r = c.request(sftpPacketRead, func(w *sftpWriter) { w.string(handle); w.uint64(0); w.uint32(1024) })
+	if replyType != sftpPacketData || r.string() != "hello sftp" {
+		t.Fatalf("unexpected read reply %d", replyType)
+	}
+	c.expectStatus(sftpStatusEOF, sftpPacketRead, func(w *sftpWriter) { w.string(handle); w.uint64(10); w.uint32(1024) })
+	c.expectStatus(sftpStatusOK, sftpPacketClose, func(w *sftpWriter) { w.string(handle) })
+
+	// Directories list their entries, then report the end
+	c.expectStatus(sftpStatusOK, sftpPacketMkdir, func(w *sftpWriter) { w.string("docs"); w.uint32(0) })
+	handle = c.handle(c.request(sftpPacketOpendir, func(w *sftpWriter) { w.string(".") }))
+	replyType, r = c.request(sftpPacketReaddir, func(w *sftpWriter) { w.string(handle) })
+	if replyType != sftpPacketName {
+		t.Fatalf("expected names, got packet %d", replyType)
+	}
+	names := map[string]string{}
+	for count := r.uint32(); count > 0; count-- {
+		name, longName := r.string(), r.string()
+		r.attrs()
+		names[name] = longName
+	}
+	if !strings.HasPrefix(names["docs"], "drwx") || !strings.HasPrefix(names["notes.txt"], "-rw") || r.err != nil {
+		t.Fatalf("unexpected listing %v", names)
+	}
+	c.expectStatus(sftpStatusEOF, sftpPacketReaddir, func(w *sftpWriter) { w.string(handle) })
+
+	// Renames refuse to replace files, unless posix-rename is used
+	if err := os.WriteFile(filepath.Join(root, "docs", "old.txt"), []byte("old"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	c.expectStatus(sftpStatusFailure, sftpPacketRename, func(w *sftpWriter) { w.string("notes.txt"); w.string("docs/old.txt") })
+	c.expectStatus(sftpStatusOK, sftpPacketExtended, func(w *sftpWriter) {
+		w.string(sftpPosixRename)
+		w.string("notes.txt")
+		w.string("docs/old.txt")
+	})
+
+	replyType, r = c.request(sftpPacketStat, func(w *sftpWriter) { w.string("docs/old.txt") })
+	if attrs := r.attrs(); replyType != sftpPacketAttrs || attrs.size != uint64(len("hello sftp")) || attrs.permissions&0o100000 == 0 {
+		t.Fatalf("unexpected stat reply %d: %+v", replyType, attrs)
+	}
+	c.expectStatus(sftpStatusFailure, sftpPacketRmdir, func(w *sftpWriter) { w.string("docs") })
+	c.expectStatus(sftpStatusOK, sftpPacketRemove, func(w *sftpWriter) { w.string("docs/old.txt") })
+	c.expectStatus(sftpStatusNoSuchFile, sftpPacketRemove, func(w *sftpWriter) { w.string("docs/old.txt") })
+	c.expectStatus(sftpStatusOK, sftpPacketRmdir, func(w *sftpWriter) { w.string("docs") })
+}
+
+func TestSFTPRejectsUnknownRequests(t *testing.T) {
+	c := newSFTPTestClient(t, t.TempDir())
+	c.expectStatus(sftpStatusOpUnsupported, sftpPacketExtended, func(w *sftpWriter) { w.string("statvfs@openssh.com") })
+	c.expectStatus(sftpStatusFailure, sftpPacketRead, func(w *sftpWriter) { w.string("missing") })
+	c.expectStatus(sftpStatusBadMessage, sftpPacketOpen, func(w *sftpWriter) { w.string("truncated") })
+}
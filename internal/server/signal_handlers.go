@@ -0,0 +1,179 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/iwanhae/terminal-hub/terminal"
+)
+
+// handleSessionSignal handles POST /api/sessions/:id/signal with a body like
+// {"signal":"SIGINT"}, signalling the program in the foreground of the
+// session's terminal
+func handleSessionSignal(w http.ResponseWriter, r *http.Request, sessionID string) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, errCodeMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	var req terminal.SignalSessionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Printf("Error decoding request: %v", err)
+		writeError(w, http.StatusBadRequest, errCodeInvalidJSON, "Bad request")
+		return
+	}
+	if err := terminal.ValidateSignal(req.Signal); err != nil {
+		writeError(w, http.StatusBadRequest, errCodeInvalidRequest, err.Error())
+		return
+	}
+
+	sess, ok := sessionManager.Get(sessionID)
+	if !ok {
+		writeError(w, http.StatusNotFound, errCodeSessionNotFound, "Session not found")
+		return
+	}
+	signaler, ok := sess.(terminal.Signaler)
+	if !ok {
+		writeError(w, http.StatusConflict, errCodeUnsupportedSession, terminal.ErrSignalUnsupported.Error())
+		return
+	}
+
+	if err := signaler.Signal(req.Signal); err != nil {
+		log.Printf("Error sending %s to session %s: %v", req.Signal, sessionID, err)
+		if errors.Is(err, terminal.ErrSignalUnsupported) {
+			writeError(w, http.StatusConflict, errCodeUnsupportedSession, err.Error())
+			return
+		}
+		writeError(w, http.StatusInternalServerError, errCodeInternal, "Failed to send signal: "+err.Error())
+		return
+	}
+	log.Printf("Session %s: sent %s", sessionID, req.Signal)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleSessionExec handles POST /api/sessions/:id/exec with a body like
+// {"command":"make test","timeout_seconds":60}. It types the command at the
+// session's prompt and returns what it printed once the shell reports it
+// finished, with OSC 133 shell integration, or the timeout passes.
+func handleSessionExec(w http.ResponseWriter, r *http.Request, sessionID string) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, errCodeMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	var req terminal.ExecSessionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Printf("Error decoding request: %v", err)
+		writeError(w, http.StatusBadRequest, errCodeInvalidJSON, "Bad request")
+		return
+	}
+	if err := req.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, errCodeInvalidRequest, err.Error())
+		return
+	}
+
+	sess, ok := sessionManager.Get(sessionID)
+	if !ok {
+		writeError(w, http.StatusNotFound, errCodeSessionNotFound, "Session not found")
+		return
+	}
+	runner, ok := sess.(terminal.CommandRunner)
+	if !ok {
+		writeError(w, http.StatusConflict, errCodeUnsupportedSession, terminal.ErrExecUnsupported.Error())
+		return
+	}
+
+	result, err := runner.Exec(r.Context(), req.Command, time.Duration(req.TimeoutSeconds)*time.Second)
+	if err != nil {
+		if errors.Is(err, terminal.ErrExecBusy) {
+			writeError(w, http.StatusConflict, errCodeConflict, err.Error())
+			return
+		}
+		if errors.Is(err, r.Context().Err()) {
+			return
+		}
+		log.Printf("Error running a command in session %s: %v", sessionID, err)
+		writeError(w, http.StatusInternalServerError, errCodeInternal, "Failed to run command: "+err.Error())
+		return
+	}
+	log.Printf("Session %s: ran a command (finished %v, timed out %v)", sessionID, result.Finished, result.TimedOut)
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(result); err != nil {
+		log.Printf("Error encoding exec result: %v", err)
+	}
+}
+
+// handleSessionChangeDirectory handles POST /api/sessions/:id/cd with a body
+// like {"path":"/srv/app"}, typing a quoted cd to the directory at the
+// session's prompt. The directory must exist and be allowed by the
+// execution allowlist.
+func handleSessionChangeDirectory(w http.ResponseWriter, r *http.Request, sessionID string) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, errCodeMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	var req terminal.ChangeDirectoryRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Printf("Error decoding request: %v", err)
+		writeError(w, http.StatusBadRequest, errCodeInvalidJSON, "Bad request")
+		return
+	}
+	if err := req.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, errCodeInvalidRequest, err.Error())
+		return
+	}
+	dir := filepath.Clean(req.Path)
+	info, err := os.Stat(dir)
+	if os.IsNotExist(err) {
+		writeError(w, http.StatusNotFound, errCodeFileNotFound, "Path not found")
+		return
+	}
+	if err != nil {
+		log.Printf("Error accessing directory: %v", err)
+		writeError(w, http.StatusInternalServerError, errCodeInternal, "Failed to access path")
+		return
+	}
+	if !info.IsDir() {
+		writeError(w, http.StatusBadRequest, errCodeInvalidRequest, "Path must be a directory")
+		return
+	}
+	if err := executionAllowlist.CheckWorkingDirectory(dir); err != nil {
+		writeError(w, http.StatusBadRequest, errCodeInvalidRequest, err.Error())
+		return
+	}
+
+	sess, ok := sessionManager.Get(sessionID)
+	if !ok {
+		writeError(w, http.StatusNotFound, errCodeSessionNotFound, "Session not found")
+		return
+	}
+	changer, ok := sess.(terminal.DirectoryChanger)
+	if !ok {
+		writeError(w, http.StatusConflict, errCodeUnsupportedSession, terminal.ErrChangeDirectoryUnsupported.Error())
+		return
+	}
+
+	if err := changer.ChangeDirectory(dir); err != nil {
+		switch {
+		case errors.Is(err, terminal.ErrChangeDirectoryUnsupported):
+			writeError(w, http.StatusConflict, errCodeUnsupportedSession, err.Error())
+		case errors.Is(err, terminal.ErrShellBusy):
+			writeError(w, http.StatusConflict, errCodeConflict, err.Error())
+		default:
+			log.Printf("Error changing the directory of session %s: %v", sessionID, err)
+			writeError(w, http.StatusInternalServerError, errCodeInternal, "Failed to change directory: "+err.Error())
+		}
+		return
+	}
+	log.Printf("Session %s: changed directory to %s", sessionID, dir)
+
+	w.WriteHeader(http.StatusNoContent)
+}
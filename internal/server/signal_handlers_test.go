@@ -0,0 +1,107 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/iwanhae/terminal-hub/terminal"
+)
+
+func TestSessionSignalEndpoint(t *testing.T) {
+	_, sessionID, _ := createWebSocketHeartbeatTestServer(t)
+
+	post := func(id, body string) *httptest.ResponseRecorder {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		handleSessionByID(rec, httptest.NewRequest(http.MethodPost, "/api/sessions/"+id+"/signal", strings.NewReader(body)))
+		return rec
+	}
+
+	if rec := post(sessionID, `{"signal":"SIGHUP"}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("unknown signal: expected status 400, got %d", rec.Code)
+	}
+	if rec := post("missing", `{"signal":"SIGINT"}`); rec.Code != http.StatusNotFound {
+		t.Errorf("missing session: expected status 404, got %d", rec.Code)
+	}
+	// The test session has no local process to signal
+	if rec := post(sessionID, `{"signal":"SIGINT"}`); rec.Code != http.StatusConflict {
+		t.Errorf("session without process: expected status 409, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	rec := httptest.NewRecorder()
+	handleSessionByID(rec, httptest.NewRequest(http.MethodGet, "/api/sessions/"+sessionID+"/signal", nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("GET: expected status 405, got %d", rec.Code)
+	}
+}
+
+func TestSessionExecEndpoint(t *testing.T) {
+	_, sessionID, _ := createWebSocketHeartbeatTestServer(t)
+
+	post := func(id, body string) *httptest.ResponseRecorder {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		handleSessionByID(rec, httptest.NewRequest(http.MethodPost, "/api/sessions/"+id+"/exec", strings.NewReader(body)))
+		return rec
+	}
+
+	for _, body := range []string{`{}`, `{"command":"ls\nrm"}`, `{"command":"ls","timeout_seconds":-1}`} {
+		if rec := post(sessionID, body); rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected status 400, got %d", body, rec.Code)
+		}
+	}
+	if rec := post("missing", `{"command":"ls"}`); rec.Code != http.StatusNotFound {
+		t.Errorf("missing session: expected status 404, got %d", rec.Code)
+	}
+
+	rec := httptest.NewRecorder()
+	handleSessionByID(rec, httptest.NewRequest(http.MethodGet, "/api/sessions/"+sessionID+"/exec", nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("GET: expected status 405, got %d", rec.Code)
+	}
+}
+
+func TestSessionChangeDirectoryEndpoint(t *testing.T) {
+	sessionManager = terminal.NewSessionManager()
+	t.Cleanup(func() { _ = sessionManager.CloseAll() })
+	if _, err := sessionManager.CreateSession(terminal.SessionConfig{
+		ID:      "cd",
+		Shell:   "/bin/sh",
+		Backend: terminal.SessionBackendPTY,
+	}); err != nil {
+		t.Fatalf("failed to create session: %v", err)
+	}
+
+	dir := t.TempDir()
+	file := filepath.Join(dir, "notes.txt")
+	if err := os.WriteFile(file, []byte("x"), 0o644); err != nil {
+		t.Fatalf("failed creating file: %v", err)
+	}
+	post := func(id, path string) int {
+		t.Helper()
+		body, _ := json.Marshal(terminal.ChangeDirectoryRequest{Path: path})
+		rec := httptest.NewRecorder()
+		handleSessionByID(rec, httptest.NewRequest(http.MethodPost, "/api/sessions/"+id+"/cd", bytes.NewReader(body)))
+		return rec.Code
+	}
+
+	for path, want := range map[string]int{
+		dir:                          http.StatusNoContent,
+		"relative":                   http.StatusBadRequest,
+		file:                         http.StatusBadRequest,
+		filepath.Join(dir, "absent"): http.StatusNotFound,
+	} {
+		if code := post("cd", path); code != want {
+			t.Errorf("%s: expected status %d, got %d", path, want, code)
+		}
+	}
+	if code := post("missing", dir); code != http.StatusNotFound {
+		t.Errorf("missing session: expected status 404, got %d", code)
+	}
+}
@@ -0,0 +1,285 @@
+package server
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/iwanhae/terminal-hub/terminal"
+)
+
+// errSSEClientClosed is returned when sending to a detached SSE client
+var errSSEClientClosed = errors.New("sse client is closed")
+
+// sseClients are the clients attached over /sse by client ID, so that input
+// posted for a client reaches its session
+var sseClients = struct {
+	sync.Mutex
+	byID map[string]*sseClient
+}{byID: map[string]*sseClient{}}
+
+// sseEvent is an event queued for an SSE stream
+type sseEvent struct {
+	name string
+	data []byte // one line: base64 output or a JSON message
+}
+
+// sseClient implements terminal.WebSocketClient for clients attached over
+// server-sent events, for networks whose proxies drop WebSockets. Output and
+// messages stream over GET /sse/:sessionId; input arrives by POST.
+type sseClient struct {
+	id        string
+	sessionID string
+	send      chan sseEvent
+	mu        sync.Mutex
+	name      string // display name shown to other clients of the session
+
+	since    uint64 // output sequence number to resume from
+	resuming bool
+
+	remoteIP  string
+	userAgent string
+	bytesSent atomic.Uint64 // bytes written to the stream, including messages
+}
+
+// Send queues terminal output, base64-encoded as SSE is text only
+func (c *sseClient) Send(data []byte) error {
+	return c.queue(sseEvent{name: "output", data: []byte(base64.StdEncoding.EncodeToString(data))})
+}
+
+// SendMessage queues a structured message
+func (c *sseClient) SendMessage(msg terminal.ServerMessage) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	return c.queue(sseEvent{name: "message", data: data})
+}
+
+// queue hands an event to the stream
+func (c *sseClient) queue(event sseEvent) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.send == nil {
+		return errSSEClientClosed
+	}
+
+	select {
+	case c.send <- event:
+		return nil
+	case <-time.After(2 * time.Second):
+		return os.ErrDeadlineExceeded
+	}
+}
+
+// ClientName returns the display name the client connected with
+func (c *sseClient) ClientName() string {
+	return c.name
+}
+
+// ConnectionInfo reports where the client connected from and how much it
+// was sent
+func (c *sseClient) ConnectionInfo() terminal.ClientConnection {
+	return terminal.ClientConnection{
+		RemoteIP:  c.remoteIP,
+		UserAgent: c.userAgent,
+		BytesSent: c.bytesSent.Load(),
+	}
+}
+
+// ResumeFrom returns the output sequence number given with ?since=
+func (c *sseClient) ResumeFrom() (uint64, bool) {
+	return c.since, c.resuming
+}
+
+// Close ends the client's stream
+func (c *sseClient) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.send != nil {
+		close(c.send)
+		c.send = nil
+	}
+	return nil
+}
+
+// handleSSE handles GET /sse/:sessionId, attaching to a session over
+// server-sent events, and POST /sse/:sessionId/input?client=, which takes
+// the client's input
+func handleSSE(w http.ResponseWriter, r *http.Request) {
+	// URL format: /sse/:sessionId[/input]
+	path := strings.Trim(strings.TrimPrefix(r.URL.Path, "/sse/"), "/")
+	sessionID, action, _ := strings.Cut(path, "/")
+	if sessionID == "" {
+		writeError(w, http.StatusBadRequest, errCodeInvalidRequest, "Session ID is required")
+		return
+	}
+
+	switch {
+	case action == "" && r.Method == http.MethodGet:
+		handleSSEStream(w, r, sessionID)
+	case action == "input" && r.Method == http.MethodPost:
+		handleSSEInput(w, r, sessionID)
+	case action == "" || action == "input":
+		writeError(w, http.StatusMethodNotAllowed, errCodeMethodNotAllowed, "Method not allowed")
+	default:
+		writeError(w, http.StatusNotFound, errCodeNotFound, "Not found")
+	}
+}
+
+// handleSSEStream streams a session to a new SSE client. The first event,
+// "attached", carries the client ID to post input with; "output" events carry
+// base64 terminal output and "message" events the JSON messages WebSocket
+// clients get as text frames.
+func handleSSEStream(w http.ResponseWriter, r *http.Request, sessionID string) {
+	sess, ok := sessionManager.Get(sessionID)
+	if !ok {
+		writeError(w, http.StatusNotFound, errCodeSessionNotFound, "Session not found")
+		return
+	}
+
+	var since uint64
+	sinceParam := r.URL.Query().Get("since")
+	if sinceParam != "" {
+		var err error
+		if since, err = strconv.ParseUint(sinceParam, 10, 64); err != nil {
+			writeError(w, http.StatusBadRequest, errCodeInvalidRequest, "since must be a non-negative integer")
+			return
+		}
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, http.StatusInternalServerError, errCodeInternal, "Streaming not supported")
+		return
+	}
+
+	client := &sseClient{
+		id:        uuid.New().String(),
+		sessionID: sessionID,
+		send:      make(chan sseEvent, 256),
+		name:      terminal.SanitizeClientName(r.URL.Query().Get("name")),
+		since:     since,
+		resuming:  sinceParam != "",
+		remoteIP:  extractClientIP(r),
+		userAgent: r.UserAgent(),
+	}
+	if err := sess.AddClient(client); err != nil {
+		var limitErr *terminal.LimitError
+		if errors.As(err, &limitErr) {
+			writeLimitError(w, http.StatusConflict, limitErr)
+			return
+		}
+		log.Printf("Error adding SSE client: %v", err)
+		writeError(w, http.StatusInternalServerError, errCodeInternal, "Failed to attach to the session")
+		return
+	}
+	sseClients.Lock()
+	sseClients.byID[client.id] = client
+	sseClients.Unlock()
+	defer func() {
+		sseClients.Lock()
+		delete(sseClients.byID, client.id)
+		sseClients.Unlock()
+		sess.RemoveClient(client)
+		_ = client.Close()
+		log.Printf("SSE client disconnected from session %s", sessionID)
+	}()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	if err := writeSSEEvent(w, "attached", map[string]string{"client_id": client.id}); err != nil {
+		return
+	}
+	flusher.Flush()
+	sendMOTD(client)
+
+	// Comments keep proxies from closing a quiet stream
+	keepalive := time.NewTicker(websocketPingPeriod)
+	defer keepalive.Stop()
+	for {
+		select {
+		case event, ok := <-client.send:
+			if !ok {
+				return
+			}
+			n, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.name, event.data)
+			if err != nil {
+				return
+			}
+			client.bytesSent.Add(uint64(n))
+			flusher.Flush()
+		case <-keepalive.C:
+			if _, err := io.WriteString(w, ": keepalive\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+// handleSSEInput applies input posted for an SSE client: one client message
+// as sent over WebSocket, or an array of them in order
+func handleSSEInput(w http.ResponseWriter, r *http.Request, sessionID string) {
+	// A JSON body needs a CORS preflight, so other sites cannot post input
+	if mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type")); err != nil || mediaType != "application/json" {
+		writeError(w, http.StatusUnsupportedMediaType, errCodeUnsupportedMedia, "Content-Type must be application/json")
+		return
+	}
+
+	sseClients.Lock()
+	client := sseClients.byID[r.URL.Query().Get("client")]
+	sseClients.Unlock()
+	if client == nil || client.sessionID != sessionID {
+		writeError(w, http.StatusNotFound, errCodeClientNotFound, "Client not found")
+		return
+	}
+	sess, ok := sessionManager.Get(sessionID)
+	if !ok {
+		writeError(w, http.StatusNotFound, errCodeSessionNotFound, "Session not found")
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, websocketReadLimit))
+	if err != nil {
+		writeError(w, http.StatusRequestEntityTooLarge, errCodePayloadTooLarge, "Input is too large")
+		return
+	}
+	var messages []terminal.ClientMessage
+	if trimmed := bytes.TrimSpace(body); len(trimmed) > 0 && trimmed[0] == '[' {
+		err = json.Unmarshal(trimmed, &messages)
+	} else {
+		var msg terminal.ClientMessage
+		err = json.Unmarshal(body, &msg)
+		messages = []terminal.ClientMessage{msg}
+	}
+	if err != nil {
+		writeError(w, http.StatusBadRequest, errCodeInvalidJSON, "Invalid JSON: "+err.Error())
+		return
+	}
+
+	for _, msg := range messages {
+		handleClientMessage(sess, sessionID, client, msg)
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,123 @@
+package server
+
+import (
+	"bufio"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/iwanhae/terminal-hub/terminal"
+)
+
+// readSSEEvent reads the next event, skipping comments
+func readSSEEvent(t *testing.T, reader *bufio.Reader) (name, data string) {
+	t.Helper()
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			t.Fatalf("failed to read event: %v", err)
+		}
+		line = strings.TrimRight(line, "\n")
+		switch {
+		case strings.HasPrefix(line, "event: "):
+			name = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			data = strings.TrimPrefix(line, "data: ")
+		case line == "" && name != "":
+			return name, data
+		}
+	}
+}
+
+func TestSSETerminalTransport(t *testing.T) {
+	ptyReader, ptyWriter, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("failed to create PTY pipe: %v", err)
+	}
+	t.Cleanup(func() {
+		_ = ptyWriter.Close()
+		_ = ptyReader.Close()
+	})
+	sessionManager = terminal.NewSessionManager()
+	t.Cleanup(func() { _ = sessionManager.CloseAll() })
+	if _, err := sessionManager.CreateSession(terminal.SessionConfig{
+		ID:         "sse",
+		Name:       "sse",
+		Backend:    terminal.SessionBackendPTY,
+		PTYService: &pipePTYService{reader: ptyReader},
+	}); err != nil {
+		t.Fatalf("failed to create session: %v", err)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/sse/", handleSSE)
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	resp, err := http.Get(server.URL + "/sse/sse")
+	if err != nil {
+		t.Fatalf("failed to open stream: %v", err)
+	}
+	t.Cleanup(func() { _ = resp.Body.Close() })
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("expected an event stream, got %d %q", resp.StatusCode, ct)
+	}
+	reader := bufio.NewReader(resp.Body)
+
+	name, data := readSSEEvent(t, reader)
+	var attached struct {
+		ClientID string `json:"client_id"`
+	}
+	if err := json.Unmarshal([]byte(data), &attached); name != "attached" || err != nil || attached.ClientID == "" {
+		t.Fatalf("expected an attached event with a client ID, got %s %s", name, data)
+	}
+
+	if _, err := ptyWriter.Write([]byte("hello\x1b[0m")); err != nil {
+		t.Fatalf("failed to write output: %v", err)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	var output string
+	for !strings.Contains(output, "hello\x1b[0m") && time.Now().Before(deadline) {
+		if name, data := readSSEEvent(t, reader); name == "output" {
+			decoded, err := base64.StdEncoding.DecodeString(data)
+			if err != nil {
+				t.Fatalf("output is not base64: %q", data)
+			}
+			output += string(decoded)
+		}
+	}
+	if !strings.Contains(output, "hello\x1b[0m") {
+		t.Fatalf("expected the output on the stream, got %q", output)
+	}
+
+	post := func(client, contentType, body string) int {
+		req, _ := http.NewRequest(http.MethodPost, server.URL+"/sse/sse/input?client="+client, strings.NewReader(body))
+		req.Header.Set("Content-Type", contentType)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("failed to post input: %v", err)
+		}
+		_ = resp.Body.Close()
+		return resp.StatusCode
+	}
+	if status := post(attached.ClientID, "application/json", `[{"type":"resize","cols":100,"rows":30}]`); status != http.StatusNoContent {
+		t.Errorf("expected 204 for a batch, got %d", status)
+	}
+	if status := post(attached.ClientID, "application/json", `{"type":"resize","cols":90,"rows":30}`); status != http.StatusNoContent {
+		t.Errorf("expected 204 for a message, got %d", status)
+	}
+	if status := post("unknown", "application/json", `{}`); status != http.StatusNotFound {
+		t.Errorf("expected 404 for an unknown client, got %d", status)
+	}
+	if status := post(attached.ClientID, "text/plain", `{}`); status != http.StatusUnsupportedMediaType {
+		t.Errorf("expected 415 for a text body, got %d", status)
+	}
+	if status := post(attached.ClientID, "application/json", `{`); status != http.StatusBadRequest {
+		t.Errorf("expected 400 for bad JSON, got %d", status)
+	}
+}
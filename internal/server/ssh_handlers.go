@@ -0,0 +1,88 @@
+package server
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"github.com/iwanhae/terminal-hub/terminal"
+)
+
+// sshKeysResponse lists the private keys usable as key_ref
+type sshKeysResponse struct {
+	Keys []string `json:"keys"`
+}
+
+// sshKnownHostsResponse lists the entries of the managed known_hosts store
+type sshKnownHostsResponse struct {
+	Hosts []terminal.KnownHost `json:"hosts"`
+}
+
+// handleSSHKeys handles GET /api/ssh/keys
+func handleSSHKeys(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, errCodeMethodNotAllowed, "Method not allowed")
+		return
+	}
+	gateway := sessionManager.SSHGateway()
+	if gateway == nil {
+		writeError(w, http.StatusNotFound, errCodeFeatureUnavailable, "SSH backend is not configured")
+		return
+	}
+
+	keys, err := gateway.ListKeys()
+	if err != nil {
+		log.Printf("Error listing ssh keys: %v", err)
+		writeError(w, http.StatusInternalServerError, errCodeInternal, "Failed to list ssh keys")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(sshKeysResponse{Keys: keys}); err != nil {
+		log.Printf("Error encoding ssh keys: %v", err)
+	}
+}
+
+// handleSSHKnownHosts handles GET /api/ssh/known-hosts and
+// DELETE /api/ssh/known-hosts?host=...
+func handleSSHKnownHosts(w http.ResponseWriter, r *http.Request) {
+	gateway := sessionManager.SSHGateway()
+	if gateway == nil {
+		writeError(w, http.StatusNotFound, errCodeFeatureUnavailable, "SSH backend is not configured")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		hosts, err := gateway.KnownHosts().List()
+		if err != nil {
+			log.Printf("Error listing known hosts: %v", err)
+			writeError(w, http.StatusInternalServerError, errCodeInternal, "Failed to list known hosts")
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(sshKnownHostsResponse{Hosts: hosts}); err != nil {
+			log.Printf("Error encoding known hosts: %v", err)
+		}
+
+	case http.MethodDelete:
+		host := r.URL.Query().Get("host")
+		if host == "" {
+			writeError(w, http.StatusBadRequest, errCodeInvalidRequest, "Host is required")
+			return
+		}
+		if err := gateway.KnownHosts().Remove(host); err != nil {
+			log.Printf("Error removing known host: %v", err)
+			if isNotFoundError(err) {
+				writeError(w, http.StatusNotFound, errCodeNotFound, err.Error())
+			} else {
+				writeError(w, http.StatusInternalServerError, errCodeInternal, "Failed to remove known host")
+			}
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		writeError(w, http.StatusMethodNotAllowed, errCodeMethodNotAllowed, "Method not allowed")
+	}
+}
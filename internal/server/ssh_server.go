@@ -0,0 +1,586 @@
+package server
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/iwanhae/terminal-hub/auth"
+	"github.com/iwanhae/terminal-hub/terminal"
+	"github.com/iwanhae/terminal-hub/webhook"
+	"golang.org/x/crypto/ssh"
+)
+
+// sshHandshakeTimeout bounds how long a connection may take to authenticate
+const sshHandshakeTimeout = 30 * time.Second
+
+// sshServer lets native terminals attach to hub sessions over SSH:
+// `ssh -t -p 2222 hub session-name` attaches to the session like a browser
+// tab. Logins use the hub's username and password, or keys listed in an
+// authorized_keys file.
+type sshServer struct {
+	config *ssh.ServerConfig
+	filter *ipFilter
+
+	mu       sync.Mutex
+	listener net.Listener
+	closed   bool
+}
+
+// sshServerFiles returns the host key and authorized_keys paths:
+// TERMINAL_HUB_SSH_SERVER_HOST_KEY and TERMINAL_HUB_SSH_AUTHORIZED_KEYS,
+// defaulting to files next to the SSH gateway's known_hosts
+func sshServerFiles() (hostKeyFile, authorizedKeysFile string) {
+	dir := filepath.Dir(terminal.GetSSHConfigFromEnv().KnownHostsFile)
+	hostKeyFile = os.Getenv("TERMINAL_HUB_SSH_SERVER_HOST_KEY")
+	if hostKeyFile == "" {
+		hostKeyFile = filepath.Join(dir, "server_host_ed25519_key")
+	}
+	authorizedKeysFile = os.Getenv("TERMINAL_HUB_SSH_AUTHORIZED_KEYS")
+	if authorizedKeysFile == "" {
+		authorizedKeysFile = filepath.Join(dir, "authorized_keys")
+	}
+	return hostKeyFile, authorizedKeysFile
+}
+
+// newSSHServer configures an SSH server authenticating against the hub's
+// credentials and the keys in authorizedKeysFile, which is read on every
+// login so edits apply without a restart. It fails when neither is
+// configured: unlike the web UI, SSH has no read-only mode to fall back on.
+func newSSHServer(authManager *auth.SessionManager, bans *loginFail2Ban, filter *ipFilter, hostKeyFile, authorizedKeysFile string) (*sshServer, error) {
+	passwords := authManager != nil && authManager.IsConfigured()
+	if _, err := os.Stat(authorizedKeysFile); err != nil && !passwords {
+		return nil, fmt.Errorf("no authentication configured: set hub credentials or create %s", authorizedKeysFile)
+	}
+
+	hostKey, err := loadOrCreateSSHHostKey(hostKeyFile)
+	if err != nil {
+		return nil, err
+	}
+
+	config := &ssh.ServerConfig{ServerVersion: "SSH-2.0-terminal-hub"}
+	config.AddHostKey(hostKey)
+
+	if passwords {
+		config.PasswordCallback = func(conn ssh.ConnMetadata, password []byte) (*ssh.Permissions, error) {
+			return checkSSHPassword(authManager, bans, conn, string(password))
+		}
+	}
+	config.PublicKeyCallback = func(conn ssh.ConnMetadata, key ssh.PublicKey) (*ssh.Permissions, error) {
+		if !isAuthorizedSSHKey(authorizedKeysFile, key) {
+			return nil, errors.New("unknown public key")
+		}
+		return nil, nil
+	}
+	return &sshServer{config: config, filter: filter}, nil
+}
+
+// checkSSHPassword validates a password login, banning addresses after
+// repeated failures as the web login does
+func checkSSHPassword(authManager *auth.SessionManager, bans *loginFail2Ban, conn ssh.ConnMetadata, password string) (*ssh.Permissions, error) {
+	clientIP := sshClientIP(conn.RemoteAddr())
+	if bans != nil {
+		if banned, remaining := bans.IsBanned(clientIP, time.Now()); banned {
+			logBannedLoginAttempt(clientIP, remaining)
+			return nil, errors.New(loginBanMessage(remaining))
+		}
+	}
+
+	if !authManager.ValidateCredentials(conn.User(), password) {
+		publishWebhookEvent(webhook.EventAuthLoginFailed, authWebhookEvent{Username: conn.User(), IP: clientIP, Via: "ssh", UserAgent: string(conn.ClientVersion())})
+		if bans != nil {
+			if banned, remaining := bans.RecordFailure(clientIP, time.Now()); banned {
+				logIPBanTriggered(clientIP, remaining)
+				alertLoginBan(clientIP, remaining)
+			}
+		}
+		return nil, errors.New("invalid username or password")
+	}
+
+	if bans != nil {
+		bans.Reset(clientIP)
+	}
+	if authManager.MustChangePassword(conn.User()) {
+		return nil, errors.New("password change required; log in to the web UI to change it")
+	}
+	return nil, nil
+}
+
+// isAuthorizedSSHKey reports whether key is listed in the authorized_keys file
+func isAuthorizedSSHKey(path string, key ssh.PublicKey) bool {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return false
+	}
+	wanted := key.Marshal()
+	for len(data) > 0 {
+		authorized, _, _, rest, err := ssh.ParseAuthorizedKey(data)
+		if err != nil {
+			return false
+		}
+		if bytes.Equal(authorized.Marshal(), wanted) {
+			return true
+		}
+		data = rest
+	}
+	return false
+}
+
+// loadOrCreateSSHHostKey reads the server's host key, generating an ed25519
+// key on first use so clients see the same host key across restarts
+func loadOrCreateSSHHostKey(path string) (ssh.Signer, error) {
+	data, err := os.ReadFile(path)
+	if err == nil {
+		signer, err := ssh.ParsePrivateKey(data)
+		if err != nil {
+			return nil, fmt.Errorf("invalid SSH host key %s: %w", path, err)
+		}
+		return signer, nil
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("failed to read SSH host key: %w", err)
+	}
+
+	_, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	block, err := ssh.MarshalPrivateKey(privateKey, "terminal-hub")
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, fmt.Errorf("failed to create SSH host key directory: %w", err)
+	}
+	if err := os.WriteFile(path, pem.EncodeToMemory(block), 0600); err != nil {
+		return nil, fmt.Errorf("failed to write SSH host key: %w", err)
+	}
+	log.Printf("Generated SSH host key %s", path)
+	return ssh.NewSignerFromKey(privateKey)
+}
+
+// Serve accepts connections until the listener is closed
+func (s *sshServer) Serve(listener net.Listener) error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return net.ErrClosed
+	}
+	s.listener = listener
+	s.mu.Unlock()
+
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			s.mu.Lock()
+			closed := s.closed
+			s.mu.Unlock()
+			if closed {
+				return nil
+			}
+			return err
+		}
+		go s.handleConn(conn)
+	}
+}
+
+// Close stops accepting connections. Attached clients are detached with
+// their sessions, as browsers are.
+func (s *sshServer) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	if s.listener == nil {
+		return nil
+	}
+	return s.listener.Close()
+}
+
+// handleConn authenticates a connection and serves its channels
+func (s *sshServer) handleConn(conn net.Conn) {
+	clientIP := sshClientIP(conn.RemoteAddr())
+	if s.filter != nil && !s.filter.AllowIP(net.ParseIP(clientIP)) {
+		log.Printf("SSH connection refused by IP filter: ip=%s", clientIP)
+		conn.Close()
+		return
+	}
+
+	_ = conn.SetDeadline(time.Now().Add(sshHandshakeTimeout))
+	serverConn, channels, requests, err := ssh.NewServerConn(conn, s.config)
+	if err != nil {
+		conn.Close()
+		return
+	}
+	_ = conn.SetDeadline(time.Time{})
+	defer serverConn.Close()
+	log.Printf("SSH login: user=%s, ip=%s", serverConn.User(), clientIP)
+	publishWebhookEvent(webhook.EventAuthLogin, authWebhookEvent{Username: serverConn.User(), IP: clientIP, Via: "ssh", UserAgent: string(serverConn.ClientVersion())})
+
+	go ssh.DiscardRequests(requests)
+	for newChannel := range channels {
+		if newChannel.ChannelType() != "session" {
+			_ = newChannel.Reject(ssh.UnknownChannelType, "only session channels are supported")
+			continue
+		}
+		channel, channelRequests, err := newChannel.Accept()
+		if err != nil {
+			log.Printf("Error accepting SSH channel: %v", err)
+			continue
+		}
+		client := &sshClient{
+			channel:   channel,
+			send:      make(chan []byte, 256),
+			name:      terminal.SanitizeClientName("ssh:" + serverConn.User()),
+			remoteIP:  clientIP,
+			userAgent: string(serverConn.ClientVersion()),
+		}
+		go client.serve(channelRequests)
+	}
+}
+
+// sshClientIP returns the IP of a connection's remote address
+func sshClientIP(addr net.Addr) string {
+	if tcpAddr, ok := addr.(*net.TCPAddr); ok {
+		return tcpAddr.IP.String()
+	}
+	host, _, err := net.SplitHostPort(addr.String())
+	if err != nil {
+		return addr.String()
+	}
+	return host
+}
+
+// SSH request payloads, see RFC 4254 section 6
+type (
+	sshPTYRequest struct {
+		Term          string
+		Cols, Rows    uint32
+		Width, Height uint32
+		Modes         string
+	}
+	sshWindowChange struct {
+		Cols, Rows    uint32
+		Width, Height uint32
+	}
+	sshExecRequest struct {
+		Command string
+	}
+	sshSubsystemRequest struct {
+		Name string
+	}
+	sshExitStatus struct {
+		Status uint32
+	}
+)
+
+// sshClient is a session channel attached to a hub session. It receives the
+// session's output like a WebSocket client and forwards the channel's input
+// to the session.
+type sshClient struct {
+	channel   ssh.Channel
+	name      string
+	remoteIP  string
+	userAgent string
+	bytesSent atomic.Uint64
+
+	mu         sync.Mutex
+	send       chan []byte   // nil once closed
+	flushed    chan struct{} // closed when the write pump has drained send
+	sess       terminal.Session
+	cols, rows int
+	closeOnce  sync.Once
+}
+
+// Send queues output for the channel
+func (c *sshClient) Send(data []byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.send == nil {
+		return io.ErrClosedPipe
+	}
+	select {
+	case c.send <- data:
+		return nil
+	case <-time.After(2 * time.Second):
+		return os.ErrDeadlineExceeded
+	}
+}
+
+// ClientName returns "ssh:" followed by the login name
+func (c *sshClient) ClientName() string {
+	return c.name
+}
+
+// ConnectionInfo describes the SSH connection, with the client's version
+// string as its user agent
+func (c *sshClient) ConnectionInfo() terminal.ClientConnection {
+	return terminal.ClientConnection{
+		RemoteIP:  c.remoteIP,
+		UserAgent: c.userAgent,
+		BytesSent: c.bytesSent.Load(),
+	}
+}
+
+// Close ends the channel with exit status 0, as when the session's shell
+// exits
+func (c *sshClient) Close() error {
+	return c.exit(0)
+}
+
+// exit reports status to the client and closes the channel
+func (c *sshClient) exit(status uint32) error {
+	c.mu.Lock()
+	send, flushed := c.send, c.flushed
+	c.send = nil
+	c.mu.Unlock()
+
+	var err error
+	c.closeOnce.Do(func() {
+		if send != nil {
+			close(send)
+		}
+		// Deliver the session's last output, such as a shell's goodbye
+		if flushed != nil {
+			select {
+			case <-flushed:
+			case <-time.After(2 * time.Second):
+			}
+		}
+		_, _ = c.channel.SendRequest("exit-status", false, ssh.Marshal(sshExitStatus{Status: status}))
+		err = c.channel.Close()
+	})
+	return err
+}
+
+// serve handles the channel's requests: pty-req and window-change size the
+// terminal, shell or exec names the session to attach to, and the sftp
+// subsystem transfers files instead
+func (c *sshClient) serve(requests <-chan *ssh.Request) {
+	started := false
+	for req := range requests {
+		ok := true
+		switch req.Type {
+		case "pty-req":
+			var pty sshPTYRequest
+			if ok = ssh.Unmarshal(req.Payload, &pty) == nil; ok {
+				c.resize(int(pty.Cols), int(pty.Rows))
+			}
+		case "window-change":
+			var size sshWindowChange
+			if ssh.Unmarshal(req.Payload, &size) == nil {
+				c.resize(int(size.Cols), int(size.Rows))
+			}
+		case "shell", "exec":
+			var command sshExecRequest
+			if req.Type == "exec" {
+				ok = ssh.Unmarshal(req.Payload, &command) == nil
+			}
+			if ok = ok && !started; ok {
+				started = true
+				go c.attach(strings.TrimSpace(command.Command))
+			}
+		case "subsystem":
+			var subsystem sshSubsystemRequest
+			ok = ssh.Unmarshal(req.Payload, &subsystem) == nil && subsystem.Name == "sftp"
+			if ok = ok && !started; ok {
+				started = true
+				go c.serveSFTP()
+			}
+		default:
+			// env and agent forwarding have no meaning for a hub session
+			ok = false
+		}
+		if req.WantReply {
+			_ = req.Reply(ok, nil)
+		}
+	}
+	// The connection is gone
+	c.detach()
+}
+
+// serveSFTP serves the sftp subsystem, with paths relative to the file
+// browser's directory
+func (c *sshClient) serveSFTP() {
+	root, err := os.Getwd()
+	if err != nil {
+		c.fail(fmt.Sprintf("failed to resolve the browse root: %v\r\n", err))
+		return
+	}
+	log.Printf("SFTP session started for %s from %s", c.name, c.remoteIP)
+	if err := serveSFTP(c.channel, root); err != nil {
+		log.Printf("SFTP session for %s ended: %v", c.name, err)
+	}
+	_ = c.Close()
+}
+
+// resize applies a terminal size, or keeps it until the client attaches
+func (c *sshClient) resize(cols, rows int) {
+	c.mu.Lock()
+	c.cols, c.rows = cols, rows
+	sess := c.sess
+	c.mu.Unlock()
+
+	if sess != nil && cols > 0 && rows > 0 {
+		if err := sess.Resize(c, cols, rows); err != nil {
+			log.Printf("Error resizing session: %v", err)
+		}
+	}
+}
+
+// attach connects the channel to the session named by ref, an ID or name.
+// Without one, it lists the sessions.
+func (c *sshClient) attach(ref string) {
+	if ref == "" {
+		c.fail(sessionListing())
+		return
+	}
+	sess, err := resolveSessionRef(ref)
+	if err != nil {
+		c.fail(err.Error() + "\r\n")
+		return
+	}
+
+	c.mu.Lock()
+	c.flushed = make(chan struct{})
+	c.mu.Unlock()
+	go c.writePump()
+	if err := sess.AddClient(c); err != nil {
+		log.Printf("Error adding SSH client: %v", err)
+		c.fail(err.Error() + "\r\n")
+		return
+	}
+	log.Printf("SSH client %s attached to session %s", c.name, sess.ID())
+
+	c.mu.Lock()
+	c.sess = sess
+	cols, rows := c.cols, c.rows
+	c.mu.Unlock()
+	c.resize(cols, rows)
+
+	c.readPump(sess)
+	c.detach()
+}
+
+// readPump forwards the channel's input to the session until the client
+// disconnects or closes its input
+func (c *sshClient) readPump(sess terminal.Session) {
+	controller, _ := sess.(terminal.InputController)
+	tracker, _ := sess.(terminal.InputTracker)
+	buf := make([]byte, 32*1024)
+	for {
+		n, err := c.channel.Read(buf)
+		if n > 0 {
+			// In single-writer mode input from clients without control is dropped
+			if controller == nil || controller.AllowInput(c) {
+				if tracker != nil {
+					tracker.NoteInput(c)
+				}
+				if _, err := sess.Write(buf[:n]); err != nil {
+					log.Printf("Error writing to session: %v", err)
+				}
+			}
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// writePump copies queued output to the channel
+func (c *sshClient) writePump() {
+	c.mu.Lock()
+	send, flushed := c.send, c.flushed
+	c.mu.Unlock()
+	defer close(flushed)
+	if send == nil {
+		return
+	}
+	for data := range send {
+		n, err := c.channel.Write(data)
+		c.bytesSent.Add(uint64(n))
+		if err != nil {
+			return
+		}
+	}
+}
+
+// detach removes the client from its session and closes the channel
+func (c *sshClient) detach() {
+	c.mu.Lock()
+	sess := c.sess
+	c.sess = nil
+	c.mu.Unlock()
+
+	if sess != nil {
+		sess.RemoveClient(c)
+		log.Printf("SSH client %s disconnected from session %s", c.name, sess.ID())
+	}
+	_ = c.Close()
+}
+
+// fail writes message to the client's stderr and exits with status 1
+func (c *sshClient) fail(message string) {
+	_, _ = io.WriteString(c.channel.Stderr(), message)
+	_ = c.exit(1)
+}
+
+// resolveSessionRef returns the session with the given ID or, failing that,
+// the only session with the given name
+func resolveSessionRef(ref string) (terminal.Session, error) {
+	if sessionManager == nil {
+		return nil, errors.New("sessions are unavailable")
+	}
+	if sess, ok := sessionManager.Get(ref); ok {
+		return sess, nil
+	}
+	var matches []string
+	for _, info := range sessionManager.ListSessionsInfo() {
+		if info.Metadata.Name == ref {
+			matches = append(matches, info.ID)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return nil, fmt.Errorf("no session %q", ref)
+	case 1:
+		if sess, ok := sessionManager.Get(matches[0]); ok {
+			return sess, nil
+		}
+		return nil, fmt.Errorf("no session %q", ref)
+	default:
+		return nil, fmt.Errorf("%d sessions are named %q, use an ID: %s", len(matches), ref, strings.Join(matches, ", "))
+	}
+}
+
+// sessionListing describes the sessions and how to attach to one, for
+// logins without a session name
+func sessionListing() string {
+	var b strings.Builder
+	b.WriteString("Usage: ssh -t <hub> <session name or ID>\r\n")
+	if sessionManager == nil {
+		return b.String()
+	}
+	sessions := sessionManager.ListSessionsInfo()
+	if len(sessions) == 0 {
+		b.WriteString("\r\nNo sessions.\r\n")
+		return b.String()
+	}
+	b.WriteString("\r\nSessions:\r\n")
+	for _, s := range sessions {
+		fmt.Fprintf(&b, "  %-24s %s\r\n", s.Metadata.Name, s.ID)
+	}
+	return b.String()
+}
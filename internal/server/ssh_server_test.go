@@ -0,0 +1,257 @@
+package server
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"errors"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/creack/pty"
+	"github.com/iwanhae/terminal-hub/terminal"
+	"golang.org/x/crypto/ssh"
+)
+
+// startTestSSHServer serves SSH on a loopback port for the hub credentials
+// admin/secret and the keys in the returned authorized_keys path
+func startTestSSHServer(t *testing.T, bans *loginFail2Ban) (string, string) {
+	t.Helper()
+	dir := t.TempDir()
+	authorizedKeys := filepath.Join(dir, "authorized_keys")
+	server, err := newSSHServer(newTestAuthSessionManager(), bans, nil, filepath.Join(dir, "host_key"), authorizedKeys)
+	if err != nil {
+		t.Fatalf("failed to create SSH server: %v", err)
+	}
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	go func() { _ = server.Serve(listener) }()
+	t.Cleanup(func() { _ = server.Close() })
+	return listener.Addr().String(), authorizedKeys
+}
+
+// createTestSSHSession creates a session named name backed by a PTY pair and
+// returns the tty side, which sees the session's input and writes its output
+func createTestSSHSession(t *testing.T, id, name string) *os.File {
+	t.Helper()
+	master, tty, err := pty.Open()
+	if err != nil {
+		t.Skipf("PTYs are not available: %v", err)
+	}
+	t.Cleanup(func() { _ = tty.Close() })
+	if _, err := sessionManager.CreateSession(terminal.SessionConfig{
+		ID:         id,
+		Name:       name,
+		Backend:    terminal.SessionBackendPTY,
+		PTYService: &ptyPairService{master: master},
+	}); err != nil {
+		t.Fatalf("failed to create session: %v", err)
+	}
+	return tty
+}
+
+func dialTestSSH(addr, user string, methods ...ssh.AuthMethod) (*ssh.Client, error) {
+	return ssh.Dial("tcp", addr, &ssh.ClientConfig{
+		User:            user,
+		Auth:            methods,
+		HostKeyCallback: ssh.InsecureIgnoreHostKey(),
+		Timeout:         5 * time.Second,
+	})
+}
+
+// readUntil reads from r until the output contains want
+func readUntil(t *testing.T, r io.Reader, want string) {
+	t.Helper()
+	found := make(chan string, 1)
+	go func() {
+		var seen bytes.Buffer
+		buf := make([]byte, 1024)
+		for {
+			n, err := r.Read(buf)
+			seen.Write(buf[:n])
+			if strings.Contains(seen.String(), want) || err != nil {
+				found <- seen.String()
+				return
+			}
+		}
+	}()
+	select {
+	case seen := <-found:
+		if !strings.Contains(seen, want) {
+			t.Fatalf("expected %q, got %q", want, seen)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("timed out waiting for %q", want)
+	}
+}
+
+func TestSSHServerAttachesToSessionByName(t *testing.T) {
+	sessionManager = terminal.NewSessionManager()
+	t.Cleanup(func() { _ = sessionManager.CloseAll() })
+	tty := createTestSSHSession(t, "ssh-session-id", "build")
+	addr, _ := startTestSSHServer(t, nil)
+
+	client, err := dialTestSSH(addr, "admin", ssh.Password("secret"))
+	if err != nil {
+		t.Fatalf("failed to log in: %v", err)
+	}
+	defer client.Close()
+	session, err := client.NewSession()
+	if err != nil {
+		t.Fatalf("failed to open session: %v", err)
+	}
+	defer session.Close()
+	stdin, _ := session.StdinPipe()
+	stdout, _ := session.StdoutPipe()
+	if err := session.RequestPty("xterm-256color", 40, 120, ssh.TerminalModes{}); err != nil {
+		t.Fatalf("pty request failed: %v", err)
+	}
+	if err := session.Start("build"); err != nil {
+		t.Fatalf("exec failed: %v", err)
+	}
+
+	// Wait until attached, then check output and input flow both ways
+	deadline := time.Now().Add(5 * time.Second)
+	sess, _ := sessionManager.Get("ssh-session-id")
+	for sess.ClientCount() == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if sess.ClientCount() != 1 {
+		t.Fatalf("expected the SSH client to attach, got %d clients", sess.ClientCount())
+	}
+	if _, err := tty.WriteString("from-session\n"); err != nil {
+		t.Fatalf("failed to write output: %v", err)
+	}
+	readUntil(t, stdout, "from-session")
+	if _, err := stdin.Write([]byte("from-ssh\n")); err != nil {
+		t.Fatalf("failed to write input: %v", err)
+	}
+	readUntil(t, tty, "from-ssh")
+
+	clients := sess.(terminal.ClientManager).AttachedClients()
+	if len(clients) != 1 || clients[0].Name != "ssh:admin" || !strings.HasPrefix(clients[0].UserAgent, "SSH-2.0-") {
+		t.Fatalf("unexpected client list: %+v", clients)
+	}
+
+	// Disconnecting detaches without closing the session
+	session.Close()
+	client.Close()
+	deadline = time.Now().Add(5 * time.Second)
+	for sess.ClientCount() != 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if sess.ClientCount() != 0 {
+		t.Fatalf("expected the SSH client to detach")
+	}
+	if _, ok := sessionManager.Get("ssh-session-id"); !ok {
+		t.Fatalf("expected the session to outlive the SSH client")
+	}
+}
+
+func TestSSHServerWithoutSessionListsSessions(t *testing.T) {
+	sessionManager = terminal.NewSessionManager()
+	t.Cleanup(func() { _ = sessionManager.CloseAll() })
+	createTestSSHSession(t, "ssh-list-id", "editor")
+	addr, _ := startTestSSHServer(t, nil)
+
+	client, err := dialTestSSH(addr, "admin", ssh.Password("secret"))
+	if err != nil {
+		t.Fatalf("failed to log in: %v", err)
+	}
+	defer client.Close()
+
+	for _, command := range []string{"", "missing"} {
+		session, err := client.NewSession()
+		if err != nil {
+			t.Fatalf("failed to open session: %v", err)
+		}
+		var stderr bytes.Buffer
+		session.Stderr = &stderr
+		if command == "" {
+			err = session.Shell()
+			if err == nil {
+				err = session.Wait()
+			}
+		} else {
+			err = session.Run(command)
+		}
+		var exitErr *ssh.ExitError
+		if !errors.As(err, &exitErr) || exitErr.ExitStatus() != 1 {
+			t.Fatalf("%q: expected exit status 1, got %v", command, err)
+		}
+		want := "editor"
+		if command != "" {
+			want = `no session "missing"`
+		}
+		if !strings.Contains(stderr.String(), want) {
+			t.Fatalf("%q: expected %q in %q", command, want, stderr.String())
+		}
+	}
+}
+
+func TestNewSSHServerRequiresAuthentication(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	authorizedKeys := filepath.Join(dir, "authorized_keys")
+	if _, err := newSSHServer(nil, nil, nil, filepath.Join(dir, "host_key"), authorizedKeys); err == nil {
+		t.Fatal("expected an SSH server without credentials or authorized keys to be refused")
+	}
+
+	if err := os.WriteFile(authorizedKeys, nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	server, err := newSSHServer(nil, nil, nil, filepath.Join(dir, "host_key"), authorizedKeys)
+	if err != nil {
+		t.Fatalf("expected authorized keys to be enough, got %v", err)
+	}
+	if server.config.NoClientAuth {
+		t.Error("expected clients to authenticate")
+	}
+}
+
+func TestSSHServerAuthentication(t *testing.T) {
+	sessionManager = terminal.NewSessionManager()
+	bans := newLoginFail2Ban(2, time.Minute)
+	addr, authorizedKeys := startTestSSHServer(t, bans)
+
+	_, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer, err := ssh.NewSignerFromKey(privateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := dialTestSSH(addr, "anyone", ssh.PublicKeys(signer)); err == nil {
+		t.Fatalf("expected an unlisted key to be refused")
+	}
+	if err := os.WriteFile(authorizedKeys, ssh.MarshalAuthorizedKey(signer.PublicKey()), 0600); err != nil {
+		t.Fatal(err)
+	}
+	client, err := dialTestSSH(addr, "anyone", ssh.PublicKeys(signer))
+	if err != nil {
+		t.Fatalf("expected an authorized key to log in: %v", err)
+	}
+	client.Close()
+
+	// Repeated wrong passwords ban the address, even for the right password
+	for range 2 {
+		if _, err := dialTestSSH(addr, "admin", ssh.Password("wrong")); err == nil {
+			t.Fatalf("expected a wrong password to be refused")
+		}
+	}
+	if _, err := dialTestSSH(addr, "admin", ssh.Password("secret")); err == nil {
+		t.Fatalf("expected a banned address to be refused")
+	}
+	if banned, _ := bans.IsBanned("127.0.0.1", time.Now()); !banned {
+		t.Fatalf("expected 127.0.0.1 to be banned")
+	}
+}
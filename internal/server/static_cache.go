@@ -0,0 +1,35 @@
+package server
+
+import (
+	"io/fs"
+	"net/http"
+	"strings"
+)
+
+// immutableAssetsDir holds the frontend build's content-hashed files, whose
+// names change whenever their content does
+const immutableAssetsDir = "assets/"
+
+// immutableCacheControl lets browsers and proxies keep a file for a year
+// without revalidating it
+const immutableCacheControl = "public, max-age=31536000, immutable"
+
+// setStaticCacheHeaders sets how long an embedded frontend file may be
+// cached. Hashed assets are immutable; every other file, index.html above
+// all, is revalidated on each use against an ETag of its content, so a deploy
+// is picked up on the next load instead of whenever a heuristic cache expires.
+func setStaticCacheHeaders(w http.ResponseWriter, fsys fs.FS, name string) {
+	h := w.Header()
+	if strings.HasPrefix(name, immutableAssetsDir) {
+		// A missing asset is answered with 404, which must not be cached
+		if info, err := fs.Stat(fsys, name); err == nil && !info.IsDir() {
+			h.Set("Cache-Control", immutableCacheControl)
+		}
+		return
+	}
+
+	h.Set("Cache-Control", "no-cache")
+	if data, err := fs.ReadFile(fsys, name); err == nil {
+		h.Set("ETag", contentETag(data))
+	}
+}
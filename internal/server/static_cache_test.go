@@ -0,0 +1,46 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+)
+
+func TestServeStaticCacheHeaders(t *testing.T) {
+	basePath = ""
+	fsys := fstest.MapFS{
+		"index.html":           {Data: []byte("<html></html>")},
+		"sw.js":                {Data: []byte("self.addEventListener('fetch', () => {})")},
+		"assets/index-abc1.js": {Data: []byte("console.log('app')")},
+	}
+	fileServer := http.FileServer(http.FS(fsys))
+	serve := func(path, ifNoneMatch string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		if ifNoneMatch != "" {
+			req.Header.Set("If-None-Match", ifNoneMatch)
+		}
+		rec := httptest.NewRecorder()
+		serveStatic(rec, req, fsys, fileServer)
+		return rec
+	}
+
+	if rec := serve("/assets/index-abc1.js", ""); rec.Header().Get("Cache-Control") != immutableCacheControl {
+		t.Errorf("expected hashed assets to be immutable, got Cache-Control %q", rec.Header().Get("Cache-Control"))
+	}
+	rec := serve("/assets/missing.js", "")
+	if rec.Code != http.StatusNotFound || rec.Header().Get("Cache-Control") != "" {
+		t.Errorf("expected an uncached 404 for a missing asset, got %d with Cache-Control %q", rec.Code, rec.Header().Get("Cache-Control"))
+	}
+
+	for _, path := range []string{"/", "/sw.js"} {
+		rec := serve(path, "")
+		etag := rec.Header().Get("ETag")
+		if rec.Header().Get("Cache-Control") != "no-cache" || etag == "" {
+			t.Fatalf("%s: expected no-cache with an ETag, got Cache-Control %q and ETag %q", path, rec.Header().Get("Cache-Control"), etag)
+		}
+		if rec := serve(path, etag); rec.Code != http.StatusNotModified {
+			t.Errorf("%s: expected 304 for a matching If-None-Match, got %d", path, rec.Code)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package server
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/iwanhae/terminal-hub/diskguard"
+	"github.com/iwanhae/terminal-hub/sysstats"
+	"github.com/iwanhae/terminal-hub/terminal"
+)
+
+// systemStats samples the host for GET /api/system/stats, nil when sampling
+// is off
+var systemStats *sysstats.Collector
+
+// systemStatsResponse is the body of GET /api/system/stats
+type systemStatsResponse struct {
+	Host      *sysstats.HostStats `json:"host"`                 // null until the first sample, or where the host cannot be sampled
+	DiskGuard *diskguard.Status   `json:"disk_guard,omitempty"` // free space under the data directory, omitted when the guard is off
+	Sessions  []sessionUsage      `json:"sessions"`
+}
+
+// sessionUsage is the resource usage of one session's processes, from the
+// process sampler
+type sessionUsage struct {
+	ID                string    `json:"id"`
+	Name              string    `json:"name"`
+	Sampled           bool      `json:"sampled"` // false until its processes have been sampled, and for ssh sessions
+	CPUPercent        float64   `json:"cpu_percent"`
+	RSSBytes          uint64    `json:"rss_bytes"`
+	ProcessCount      int       `json:"process_count"` // the shell and its descendants
+	ForegroundCommand string    `json:"foreground_command,omitempty"`
+	SampledAt         time.Time `json:"sampled_at"`
+}
+
+// countProcesses counts the processes in a tree of nodes
+func countProcesses(nodes []terminal.ProcessNode) int {
+	count := len(nodes)
+	for _, node := range nodes {
+		count += countProcesses(node.Children)
+	}
+	return count
+}
+
+// handleSystemStats handles GET /api/system/stats: the latest host sample
+// and the usage of each session
+func handleSystemStats(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, errCodeMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	resp := systemStatsResponse{Sessions: []sessionUsage{}}
+	if systemStats != nil {
+		resp.Host = systemStats.Latest()
+	}
+	if diskGuard != nil {
+		status := diskGuard.Status()
+		resp.DiskGuard = &status
+	}
+	for _, info := range sessionManager.ListSessionsInfo() {
+		usage := sessionUsage{ID: info.ID, Name: info.Metadata.Name}
+		if process := info.Metadata.Process; process != nil {
+			usage.Sampled = true
+			usage.CPUPercent = process.CPUPercent
+			usage.RSSBytes = process.RSSBytes
+			usage.ProcessCount = 1 + countProcesses(process.Children)
+			usage.ForegroundCommand = process.ForegroundCommand
+			usage.SampledAt = process.SampledAt
+		}
+		resp.Sessions = append(resp.Sessions, usage)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		log.Printf("Error encoding system stats: %v", err)
+	}
+}
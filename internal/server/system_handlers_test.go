@@ -0,0 +1,70 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/iwanhae/terminal-hub/sysstats"
+	"github.com/iwanhae/terminal-hub/terminal"
+)
+
+func TestSystemStats(t *testing.T) {
+	sessionManager = terminal.NewSessionManager()
+	t.Cleanup(func() { _ = sessionManager.CloseAll() })
+	ptyReader, ptyWriter, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = ptyWriter.Close()
+		_ = ptyReader.Close()
+	})
+	if _, err := sessionManager.CreateSession(terminal.SessionConfig{
+		ID:         "stats",
+		Name:       "build",
+		Backend:    terminal.SessionBackendPTY,
+		PTYService: &pipePTYService{reader: ptyReader},
+	}); err != nil {
+		t.Fatalf("failed to create session: %v", err)
+	}
+
+	systemStats = sysstats.NewCollector([]string{"/"})
+	t.Cleanup(func() { systemStats = nil })
+	_ = systemStats.Sample(time.Now())
+
+	rec := httptest.NewRecorder()
+	handleSystemStats(rec, httptest.NewRequest(http.MethodGet, "/api/system/stats", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var resp systemStatsResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("invalid response %s: %v", rec.Body.String(), err)
+	}
+	if len(resp.Sessions) != 1 || resp.Sessions[0].ID != "stats" || resp.Sessions[0].Name != "build" || resp.Sessions[0].Sampled {
+		t.Errorf("expected the unsampled session, got %+v", resp.Sessions)
+	}
+	if latest := systemStats.Latest(); (latest == nil) != (resp.Host == nil) {
+		t.Errorf("expected the latest host sample, got %+v", resp.Host)
+	}
+
+	rec = httptest.NewRecorder()
+	handleSystemStats(rec, httptest.NewRequest(http.MethodPost, "/api/system/stats", nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("POST: expected status 405, got %d", rec.Code)
+	}
+}
+
+func TestCountProcesses(t *testing.T) {
+	tree := []terminal.ProcessNode{
+		{PID: 2, Children: []terminal.ProcessNode{{PID: 3}, {PID: 4, Children: []terminal.ProcessNode{{PID: 5}}}}},
+		{PID: 6},
+	}
+	if got := countProcesses(tree); got != 5 {
+		t.Errorf("expected 5 processes, got %d", got)
+	}
+}
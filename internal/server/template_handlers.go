@@ -0,0 +1,109 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/iwanhae/terminal-hub/terminal"
+)
+
+// handleTemplates handles GET /api/templates (list) and POST /api/templates
+// (create)
+func handleTemplates(w http.ResponseWriter, r *http.Request) {
+	if sessionTemplates == nil {
+		writeError(w, http.StatusServiceUnavailable, errCodeFeatureUnavailable, "Session templates are unavailable")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(sessionTemplates.List()); err != nil {
+			log.Printf("Error encoding templates: %v", err)
+		}
+	case http.MethodPost:
+		template, ok := decodeTemplate(w, r)
+		if !ok {
+			return
+		}
+		template.ID = uuid.New().String()
+		if err := sessionTemplates.Create(template); err != nil {
+			log.Printf("Error creating template: %v", err)
+			writeError(w, http.StatusInternalServerError, errCodeInternal, "Failed to save template")
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		if err := json.NewEncoder(w).Encode(template); err != nil {
+			log.Printf("Error encoding template: %v", err)
+		}
+	default:
+		writeError(w, http.StatusMethodNotAllowed, errCodeMethodNotAllowed, "Method not allowed")
+	}
+}
+
+// handleTemplateByID handles PUT /api/templates/:id (replace) and
+// DELETE /api/templates/:id
+func handleTemplateByID(w http.ResponseWriter, r *http.Request) {
+	if sessionTemplates == nil {
+		writeError(w, http.StatusServiceUnavailable, errCodeFeatureUnavailable, "Session templates are unavailable")
+		return
+	}
+	templateID := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/api/templates/"), "/")
+	if templateID == "" {
+		writeError(w, http.StatusBadRequest, errCodeInvalidRequest, "Template ID is required")
+		return
+	}
+
+	var err error
+	switch r.Method {
+	case http.MethodPut:
+		template, ok := decodeTemplate(w, r)
+		if !ok {
+			return
+		}
+		template.ID = templateID
+		err = sessionTemplates.Update(template)
+	case http.MethodDelete:
+		err = sessionTemplates.Delete(templateID)
+	default:
+		writeError(w, http.StatusMethodNotAllowed, errCodeMethodNotAllowed, "Method not allowed")
+		return
+	}
+	if err != nil {
+		if errors.Is(err, terminal.ErrTemplateNotFound) {
+			writeError(w, http.StatusNotFound, errCodeTemplateNotFound, "Template not found")
+			return
+		}
+		log.Printf("Error saving template: %v", err)
+		writeError(w, http.StatusInternalServerError, errCodeInternal, "Failed to save template")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// decodeTemplate reads and validates a template from the request body,
+// writing an error response when it is invalid
+func decodeTemplate(w http.ResponseWriter, r *http.Request) (terminal.SessionTemplate, bool) {
+	var template terminal.SessionTemplate
+	if err := json.NewDecoder(r.Body).Decode(&template); err != nil {
+		log.Printf("Error decoding request: %v", err)
+		writeError(w, http.StatusBadRequest, errCodeInvalidJSON, "Bad request")
+		return template, false
+	}
+	if err := template.Normalize(); err != nil {
+		writeError(w, http.StatusBadRequest, errCodeInvalidRequest, err.Error())
+		return template, false
+	}
+	if err := executionAllowlist.Check(template.ShellPath, template.WorkingDirectory); err != nil {
+		writeError(w, http.StatusBadRequest, errCodeInvalidRequest, err.Error())
+		return template, false
+	}
+	return template, true
+}
@@ -0,0 +1,70 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/iwanhae/terminal-hub/terminal"
+)
+
+func TestSessionTemplatesAndAutostart(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "templates.json")
+	store, err := terminal.OpenSessionTemplateStore(path)
+	if err != nil {
+		t.Fatalf("failed to open template store: %v", err)
+	}
+	sessionTemplates = store
+	t.Cleanup(func() { sessionTemplates = nil })
+
+	rec := httptest.NewRecorder()
+	handleTemplates(rec, httptest.NewRequest(http.MethodPost, "/api/templates", strings.NewReader(`{"name":"logs","backend":"pty","shell_path":"/bin/sh"}`)))
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("create: expected status 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var created terminal.SessionTemplate
+	if err := json.Unmarshal(rec.Body.Bytes(), &created); err != nil || created.ID == "" {
+		t.Fatalf("create: bad response %q: %v", rec.Body.String(), err)
+	}
+
+	rec = httptest.NewRecorder()
+	handleTemplates(rec, httptest.NewRequest(http.MethodPost, "/api/templates", strings.NewReader(`{"name":"remote","backend":"ssh"}`)))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("ssh backend: expected status 400, got %d", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	body := `{"name":"logs","backend":"pty","shell_path":"/bin/sh","autostart":true}`
+	handleTemplateByID(rec, httptest.NewRequest(http.MethodPut, "/api/templates/"+created.ID, strings.NewReader(body)))
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("update: expected status 204, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	// Templates survive a restart and autostart ones become sessions
+	if sessionTemplates, err = terminal.OpenSessionTemplateStore(path); err != nil {
+		t.Fatalf("failed to reopen template store: %v", err)
+	}
+	sessionManager = terminal.NewSessionManager()
+	t.Cleanup(func() { _ = sessionManager.CloseAll() })
+	if err := startAutostartSessions(); err != nil {
+		t.Fatalf("autostart failed: %v", err)
+	}
+	infos := sessionManager.ListSessionsInfo()
+	if len(infos) != 1 || infos[0].Metadata.Name != "logs" || infos[0].Metadata.Backend != terminal.SessionBackendPTY {
+		t.Fatalf("expected one pty session named logs, got %+v", infos)
+	}
+
+	rec = httptest.NewRecorder()
+	handleTemplateByID(rec, httptest.NewRequest(http.MethodDelete, "/api/templates/"+created.ID, nil))
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("delete: expected status 204, got %d", rec.Code)
+	}
+	rec = httptest.NewRecorder()
+	handleTemplateByID(rec, httptest.NewRequest(http.MethodDelete, "/api/templates/"+created.ID, nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("second delete: expected status 404, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,287 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/iwanhae/terminal-hub/terminal"
+)
+
+// createWindowRequest is the body of POST /api/sessions/:id/windows
+type createWindowRequest struct {
+	Name string `json:"name,omitempty"`
+}
+
+// selectPaneRequest is the body of POST /api/sessions/:id/panes/select
+type selectPaneRequest struct {
+	Pane string `json:"pane"` // left, right, up, down, next, previous or a pane ID such as "%3"
+}
+
+// listWindowsResponse lists a tmux session's windows
+type listWindowsResponse struct {
+	Windows []terminal.TmuxWindow `json:"windows"`
+}
+
+// tmuxSession looks up a session and checks that it supports windows,
+// writing the error response if not
+func tmuxSession(w http.ResponseWriter, sessionID string) (terminal.TmuxWindowManager, bool) {
+	sess, ok := sessionManager.Get(sessionID)
+	if !ok {
+		writeError(w, http.StatusNotFound, errCodeSessionNotFound, "Session not found")
+		return nil, false
+	}
+	manager, ok := sess.(terminal.TmuxWindowManager)
+	if !ok {
+		writeError(w, http.StatusConflict, errCodeUnsupportedSession, terminal.ErrNotTmuxSession.Error())
+		return nil, false
+	}
+	return manager, true
+}
+
+// writeTmuxError maps window and pane errors to status codes
+func writeTmuxError(w http.ResponseWriter, err error) {
+	log.Printf("Error managing tmux windows: %v", err)
+	switch {
+	case errors.Is(err, terminal.ErrNotTmuxSession):
+		writeError(w, http.StatusConflict, errCodeUnsupportedSession, err.Error())
+	case isNotFoundError(err):
+		writeError(w, http.StatusNotFound, errCodeNotFound, err.Error())
+	case strings.HasPrefix(err.Error(), "tmux "):
+		writeError(w, http.StatusInternalServerError, errCodeInternal, err.Error())
+	default:
+		writeError(w, http.StatusBadRequest, errCodeInvalidRequest, err.Error())
+	}
+}
+
+// handleSessionWindows handles GET (list) and POST (create) /api/sessions/:id/windows
+func handleSessionWindows(w http.ResponseWriter, r *http.Request, sessionID string) {
+	if r.Method != http.MethodGet && r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, errCodeMethodNotAllowed, "Method not allowed")
+		return
+	}
+	manager, ok := tmuxSession(w, sessionID)
+	if !ok {
+		return
+	}
+
+	if r.Method == http.MethodGet {
+		windows, err := manager.ListWindows()
+		if err != nil {
+			writeTmuxError(w, err)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(listWindowsResponse{Windows: windows}); err != nil {
+			log.Printf("Error encoding windows: %v", err)
+		}
+		return
+	}
+
+	var req createWindowRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			log.Printf("Error decoding request: %v", err)
+			writeError(w, http.StatusBadRequest, errCodeInvalidJSON, "Bad request")
+			return
+		}
+	}
+	window, err := manager.CreateWindow(req.Name)
+	if err != nil {
+		writeTmuxError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(window); err != nil {
+		log.Printf("Error encoding window: %v", err)
+	}
+}
+
+// handleSessionWindow handles DELETE /api/sessions/:id/windows/:index (kill)
+// and POST /api/sessions/:id/windows/:index/select
+func handleSessionWindow(w http.ResponseWriter, r *http.Request, sessionID, rest string) {
+	indexPart, action, _ := strings.Cut(rest, "/")
+	index, err := strconv.Atoi(indexPart)
+	if err != nil || index < 0 {
+		writeError(w, http.StatusBadRequest, errCodeInvalidRequest, "Invalid window index")
+		return
+	}
+
+	switch {
+	case action == "" && r.Method == http.MethodDelete:
+		manager, ok := tmuxSession(w, sessionID)
+		if !ok {
+			return
+		}
+		if err := manager.KillWindow(index); err != nil {
+			writeTmuxError(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+
+	case action == "select" && r.Method == http.MethodPost:
+		manager, ok := tmuxSession(w, sessionID)
+		if !ok {
+			return
+		}
+		if err := manager.SelectWindow(index); err != nil {
+			writeTmuxError(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+
+	case action == "" || action == "select":
+		writeError(w, http.StatusMethodNotAllowed, errCodeMethodNotAllowed, "Method not allowed")
+
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// handleSessionSelectPane handles POST /api/sessions/:id/panes/select
+func handleSessionSelectPane(w http.ResponseWriter, r *http.Request, sessionID string) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, errCodeMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	var req selectPaneRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Printf("Error decoding request: %v", err)
+		writeError(w, http.StatusBadRequest, errCodeInvalidJSON, "Bad request")
+		return
+	}
+	if req.Pane == "" {
+		writeError(w, http.StatusBadRequest, errCodeInvalidRequest, "Pane is required")
+		return
+	}
+
+	manager, ok := tmuxSession(w, sessionID)
+	if !ok {
+		return
+	}
+	if err := manager.SelectPane(req.Pane); err != nil {
+		writeTmuxError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// listHostTmuxSessionsResponse lists the tmux sessions running on the host
+type listHostTmuxSessionsResponse struct {
+	Sessions []terminal.HostTmuxSession `json:"sessions"`
+}
+
+// managedTmuxSessions returns the tmux session names already attached to
+// terminal-hub sessions
+func managedTmuxSessions() map[string]bool {
+	managed := make(map[string]bool)
+	for _, info := range sessionManager.ListSessionsInfo() {
+		if info.Metadata.TmuxSession != "" {
+			managed[info.Metadata.TmuxSession] = true
+		}
+	}
+	return managed
+}
+
+// handleHostTmuxSessions handles GET /api/tmux/sessions
+func handleHostTmuxSessions(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, errCodeMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	sessions, err := terminal.ListHostTmuxSessions(sessionManager.RunAs())
+	if err != nil {
+		log.Printf("Error listing tmux sessions: %v", err)
+		writeError(w, http.StatusInternalServerError, errCodeInternal, "Failed to list tmux sessions")
+		return
+	}
+	managed := managedTmuxSessions()
+	for i := range sessions {
+		sessions[i].Managed = managed[sessions[i].Name]
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(listHostTmuxSessionsResponse{Sessions: sessions}); err != nil {
+		log.Printf("Error encoding tmux sessions: %v", err)
+	}
+}
+
+// handleAdoptSession handles POST /api/sessions/adopt
+func handleAdoptSession(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, errCodeMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	var req terminal.AdoptSessionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Printf("Error decoding request: %v", err)
+		writeError(w, http.StatusBadRequest, errCodeInvalidJSON, "Bad request")
+		return
+	}
+	if err := req.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, errCodeInvalidRequest, err.Error())
+		return
+	}
+
+	sessions, err := terminal.ListHostTmuxSessions(sessionManager.RunAs())
+	if err != nil {
+		log.Printf("Error listing tmux sessions: %v", err)
+		writeError(w, http.StatusInternalServerError, errCodeInternal, "Failed to list tmux sessions")
+		return
+	}
+	found := false
+	for _, hostSession := range sessions {
+		if hostSession.Name == req.TmuxSession {
+			found = true
+			break
+		}
+	}
+	if !found {
+		writeError(w, http.StatusNotFound, errCodeTmuxSessionNotFound, "tmux session not found")
+		return
+	}
+	if managedTmuxSessions()[req.TmuxSession] {
+		writeError(w, http.StatusConflict, errCodeConflict, "tmux session is already attached to a session")
+		return
+	}
+
+	name := req.Name
+	if name == "" {
+		name = req.TmuxSession
+	}
+	sess, err := sessionManager.CreateSession(terminal.SessionConfig{
+		ID:               uuid.New().String(),
+		Name:             name,
+		Backend:          terminal.SessionBackendTmux,
+		HistorySize:      4096,
+		AdoptTmuxSession: req.TmuxSession,
+	})
+	if err != nil {
+		log.Printf("Error adopting tmux session: %v", err)
+		var limitErr *terminal.LimitError
+		if errors.As(err, &limitErr) {
+			writeLimitError(w, http.StatusTooManyRequests, limitErr)
+			return
+		}
+		writeError(w, http.StatusInternalServerError, errCodeInternal, "Failed to adopt tmux session")
+		return
+	}
+
+	resp := terminal.CreateSessionResponse{
+		ID:       sess.ID(),
+		Metadata: sess.GetMetadata(),
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		log.Printf("Error encoding response: %v", err)
+	}
+}
@@ -0,0 +1,89 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/iwanhae/terminal-hub/terminal"
+)
+
+func TestSessionWindowRoutesRequireTmuxSession(t *testing.T) {
+	ptyReader, ptyWriter, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("failed to create PTY pipe: %v", err)
+	}
+	sessionManager = terminal.NewSessionManager()
+	_, err = sessionManager.CreateSession(terminal.SessionConfig{
+		ID:         "pty-session",
+		Backend:    terminal.SessionBackendPTY,
+		PTYService: &pipePTYService{reader: ptyReader},
+	})
+	if err != nil {
+		t.Fatalf("failed to create test session: %v", err)
+	}
+	t.Cleanup(func() {
+		_ = sessionManager.CloseAll()
+		_ = ptyWriter.Close()
+		_ = ptyReader.Close()
+	})
+
+	tests := []struct {
+		method string
+		path   string
+		body   string
+		want   int
+	}{
+		{http.MethodGet, "/api/sessions/pty-session/windows", "", http.StatusConflict},
+		{http.MethodPost, "/api/sessions/pty-session/windows", `{"name":"logs"}`, http.StatusConflict},
+		{http.MethodPost, "/api/sessions/pty-session/panes/select", `{"pane":"left"}`, http.StatusConflict},
+		{http.MethodPost, "/api/sessions/pty-session/panes/select", `{}`, http.StatusBadRequest},
+		{http.MethodGet, "/api/sessions/missing/windows", "", http.StatusNotFound},
+		{http.MethodDelete, "/api/sessions/pty-session/windows/abc", "", http.StatusBadRequest},
+		{http.MethodGet, "/api/sessions/pty-session/windows/1", "", http.StatusMethodNotAllowed},
+		{http.MethodGet, "/api/sessions/pty-session/unknown", "", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		handleSessionByID(rec, httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body)))
+		if rec.Code != tt.want {
+			t.Errorf("%s %s: expected status %d, got %d: %s", tt.method, tt.path, tt.want, rec.Code, rec.Body.String())
+		}
+	}
+}
+
+func TestAdoptSessionValidation(t *testing.T) {
+	// Use a private tmux server so the test never sees the user's sessions
+	t.Setenv("TMUX_TMPDIR", t.TempDir())
+	t.Setenv("TMUX", "")
+	sessionManager = terminal.NewSessionManager()
+
+	tests := []struct {
+		method string
+		body   string
+		want   int
+	}{
+		{http.MethodGet, "", http.StatusMethodNotAllowed},
+		{http.MethodPost, `not json`, http.StatusBadRequest},
+		{http.MethodPost, `{}`, http.StatusBadRequest},
+		{http.MethodPost, `{"tmux_session":"missing"}`, http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		handleAdoptSession(rec, httptest.NewRequest(tt.method, "/api/sessions/adopt", strings.NewReader(tt.body)))
+		if rec.Code != tt.want {
+			t.Errorf("%s %q: expected status %d, got %d: %s", tt.method, tt.body, tt.want, rec.Code, rec.Body.String())
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	handleHostTmuxSessions(rec, httptest.NewRequest(http.MethodGet, "/api/tmux/sessions", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if got := strings.TrimSpace(rec.Body.String()); got != `{"sessions":[]}` {
+		t.Errorf("expected no tmux sessions, got %s", got)
+	}
+}
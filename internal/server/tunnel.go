@@ -0,0 +1,267 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+const (
+	// tunnelDialTimeout bounds connecting to a tunnel's target
+	tunnelDialTimeout = 10 * time.Second
+	// tunnelBufferSize is the most target data sent in one message
+	tunnelBufferSize = 32 * 1024
+	// tunnelReadLimit bounds one message from the client
+	tunnelReadLimit int64 = 1 << 20
+)
+
+// tunnelAllow lists the targets TCP tunnels may reach. Tunnels are disabled
+// while it is empty.
+var tunnelAllow tunnelAllowlist
+
+// errTunnelNotAllowed is returned for targets no rule allows
+var errTunnelNotAllowed = errors.New("tunnel target is not allowed")
+
+// tunnelRule is one HOST:PORT entry of TERMINAL_HUB_TUNNEL_ALLOW
+type tunnelRule struct {
+	host             string   // lower-case host name, "" for address rules
+	addresses        ipRanges // nil for host name rules
+	anyHost          bool     // "*"
+	minPort, maxPort int
+}
+
+// tunnelAllowlist is the parsed TERMINAL_HUB_TUNNEL_ALLOW
+type tunnelAllowlist []tunnelRule
+
+// getTunnelAllowlistFromEnv parses TERMINAL_HUB_TUNNEL_ALLOW
+func getTunnelAllowlistFromEnv() (tunnelAllowlist, error) {
+	allow, err := parseTunnelAllowlist(os.Getenv("TERMINAL_HUB_TUNNEL_ALLOW"))
+	if err != nil {
+		return nil, fmt.Errorf("TERMINAL_HUB_TUNNEL_ALLOW: %w", err)
+	}
+	return allow, nil
+}
+
+// parseTunnelAllowlist parses a comma-separated list of HOST:PORT entries.
+// HOST is a host name, an address or CIDR (IPv6 in brackets) or "*"; PORT is
+// a port, a range such as "8000-8999" or "*".
+func parseTunnelAllowlist(value string) (tunnelAllowlist, error) {
+	var allow tunnelAllowlist
+	for _, part := range strings.Split(value, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		host, ports, err := net.SplitHostPort(part)
+		if err != nil || host == "" {
+			return nil, fmt.Errorf("invalid entry %q: use HOST:PORT", part)
+		}
+
+		rule := tunnelRule{minPort: 1, maxPort: 65535}
+		if ports != "*" {
+			low, high, isRange := strings.Cut(ports, "-")
+			if !isRange {
+				high = low
+			}
+			rule.minPort, err = strconv.Atoi(low)
+			if err == nil {
+				rule.maxPort, err = strconv.Atoi(high)
+			}
+			if err != nil || rule.minPort < 1 || rule.maxPort > 65535 || rule.minPort > rule.maxPort {
+				return nil, fmt.Errorf("invalid port %q in %q", ports, part)
+			}
+		}
+
+		switch {
+		case host == "*":
+			rule.anyHost = true
+		case strings.Contains(host, "/") || net.ParseIP(host) != nil:
+			if rule.addresses, err = parseIPRanges(host); err != nil {
+				return nil, err
+			}
+		default:
+			rule.host = strings.ToLower(host)
+		}
+		allow = append(allow, rule)
+	}
+	return allow, nil
+}
+
+// allows reports whether a rule admits port on ip, which host resolved to
+func (a tunnelAllowlist) allows(host string, ip net.IP, port int) bool {
+	for _, rule := range a {
+		if port < rule.minPort || port > rule.maxPort {
+			continue
+		}
+		if rule.anyHost || (rule.host != "" && strings.EqualFold(rule.host, host)) || rule.addresses.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// resolve returns the addresses to dial for host and port: those of host a
+// rule allows. Addresses are checked rather than the name alone, so a name
+// cannot be rebound to reach an address the allowlist excludes.
+func (a tunnelAllowlist) resolve(ctx context.Context, host string, port int) ([]string, error) {
+	var ips []net.IP
+	if ip := net.ParseIP(host); ip != nil {
+		ips = []net.IP{ip}
+	} else {
+		addrs, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+		if err != nil {
+			return nil, err
+		}
+		for _, addr := range addrs {
+			ips = append(ips, addr.IP)
+		}
+	}
+	var addresses []string
+	for _, ip := range ips {
+		if a.allows(host, ip, port) {
+			addresses = append(addresses, net.JoinHostPort(ip.String(), strconv.Itoa(port)))
+		}
+	}
+	if len(addresses) == 0 {
+		return nil, errTunnelNotAllowed
+	}
+	return addresses, nil
+}
+
+// dialTunnelTarget connects to the first of addresses that accepts
+func dialTunnelTarget(addresses []string) (net.Conn, error) {
+	var err error
+	for _, address := range addresses {
+		var target net.Conn
+		if target, err = net.DialTimeout("tcp", address, tunnelDialTimeout); err == nil {
+			return target, nil
+		}
+	}
+	return nil, err
+}
+
+// handleTunnelWebSocket handles WS /ws/tunnel?host=&port=, bridging a TCP
+// connection to host and port over the WebSocket: binary messages from the
+// client are written to the target and the target's data comes back as
+// binary messages. Only targets in TERMINAL_HUB_TUNNEL_ALLOW are reachable.
+func handleTunnelWebSocket(w http.ResponseWriter, r *http.Request) {
+	if len(tunnelAllow) == 0 {
+		http.Error(w, "Tunnels are disabled", http.StatusServiceUnavailable)
+		return
+	}
+	host := r.URL.Query().Get("host")
+	port, err := strconv.Atoi(r.URL.Query().Get("port"))
+	if host == "" || err != nil || port < 1 || port > 65535 {
+		http.Error(w, "host and port are required", http.StatusBadRequest)
+		return
+	}
+
+	addresses, err := tunnelAllow.resolve(r.Context(), host, port)
+	if errors.Is(err, errTunnelNotAllowed) {
+		log.Printf("Tunnel to %s:%d refused: ip=%s", host, port, extractClientIP(r))
+		http.Error(w, "Tunnel target is not allowed", http.StatusForbidden)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Failed to resolve "+host, http.StatusBadGateway)
+		return
+	}
+	target, err := dialTunnelTarget(addresses)
+	if err != nil {
+		log.Printf("Tunnel to %s:%d failed: %v", host, port, err)
+		http.Error(w, "Failed to connect to the tunnel target", http.StatusBadGateway)
+		return
+	}
+	defer target.Close()
+
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		log.Println("Upgrade error:", err)
+		return
+	}
+	defer conn.Close()
+
+	address := target.RemoteAddr().String()
+	log.Printf("Tunnel to %s:%d (%s) opened: ip=%s", host, port, address, extractClientIP(r))
+	sent, received := bridgeTunnel(conn, target)
+	log.Printf("Tunnel to %s closed: %d bytes sent, %d received", address, sent, received)
+}
+
+// bridgeTunnel copies between the WebSocket and the target until either
+// side closes, and returns the bytes written to and read from the target
+func bridgeTunnel(conn *websocket.Conn, target net.Conn) (sent, received int64) {
+	conn.SetReadLimit(tunnelReadLimit)
+	_ = conn.SetReadDeadline(time.Now().Add(websocketPongWait))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(websocketPongWait))
+	})
+
+	// Target to WebSocket, with pings to keep idle tunnels open
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		buf := make([]byte, tunnelBufferSize)
+		for {
+			n, err := target.Read(buf)
+			if n > 0 {
+				_ = conn.SetWriteDeadline(time.Now().Add(websocketWriteWait))
+				if err := conn.WriteMessage(websocket.BinaryMessage, buf[:n]); err != nil {
+					return
+				}
+				received += int64(n)
+			}
+			if err != nil {
+				// Give the client a moment to answer the close
+				_ = conn.WriteControl(websocket.CloseMessage,
+					websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""),
+					time.Now().Add(websocketWriteWait))
+				_ = conn.SetReadDeadline(time.Now().Add(websocketWriteWait))
+				return
+			}
+		}
+	}()
+	go func() {
+		ticker := time.NewTicker(websocketPingPeriod)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(websocketWriteWait)); err != nil {
+					return
+				}
+			}
+		}
+	}()
+
+	// WebSocket to target
+	for {
+		messageType, reader, err := conn.NextReader()
+		if err != nil {
+			break
+		}
+		if messageType != websocket.BinaryMessage {
+			continue
+		}
+		n, err := io.Copy(target, reader)
+		sent += n
+		if err != nil {
+			break
+		}
+	}
+	_ = target.Close()
+	_ = conn.Close()
+	<-done
+	return sent, received
+}
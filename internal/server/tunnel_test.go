@@ -0,0 +1,105 @@
+package server
+
+import (
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+func TestTunnelAllowlist(t *testing.T) {
+	allow, err := parseTunnelAllowlist("localhost:5432, 10.0.0.0/8:8000-8999, [::1]:*, *:443")
+	if err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+	cases := []struct {
+		host    string
+		ip      string
+		port    int
+		allowed bool
+	}{
+		{"LocalHost", "127.0.0.1", 5432, true},
+		{"localhost", "127.0.0.1", 5433, false},
+		{"db.internal", "10.1.2.3", 8080, true},
+		{"db.internal", "10.1.2.3", 9000, false},
+		{"::1", "::1", 22, true},
+		{"example.com", "93.184.216.34", 443, true},
+		{"example.com", "93.184.216.34", 80, false},
+	}
+	for _, c := range cases {
+		if got := allow.allows(c.host, net.ParseIP(c.ip), c.port); got != c.allowed {
+			t.Errorf("allows(%s, %s, %d) = %v, want %v", c.host, c.ip, c.port, got, c.allowed)
+		}
+	}
+
+	for _, value := range []string{"localhost", "localhost:0", "localhost:90-80", "10.0.0.0/33:22", ":22"} {
+		if _, err := parseTunnelAllowlist(value); err == nil {
+			t.Errorf("%q: expected an error", value)
+		}
+	}
+}
+
+func TestTunnelWebSocket(t *testing.T) {
+	// An echo server as the tunnel target
+	target, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer target.Close()
+	go func() {
+		for {
+			conn, err := target.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				_, _ = io.Copy(conn, conn)
+			}()
+		}
+	}()
+	port := strconv.Itoa(target.Addr().(*net.TCPAddr).Port)
+
+	server := httptest.NewServer(http.HandlerFunc(handleTunnelWebSocket))
+	defer server.Close()
+	wsURL := "ws" + strings.TrimPrefix(server.URL, "http") + "/ws/tunnel"
+
+	tunnelAllow = nil
+	if _, resp, err := websocket.DefaultDialer.Dial(wsURL+"?host=127.0.0.1&port="+port, nil); err == nil || resp.StatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("disabled: expected status 503, got %v", err)
+	}
+
+	tunnelAllow, err = parseTunnelAllowlist("localhost:" + port)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { tunnelAllow = nil })
+	if _, resp, err := websocket.DefaultDialer.Dial(wsURL+"?host=localhost&port=1", nil); err == nil || resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("other port: expected status 403, got %v", err)
+	}
+
+	conn, _, err := websocket.DefaultDialer.Dial(wsURL+"?host=localhost&port="+port, nil)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer conn.Close()
+	if err := conn.WriteMessage(websocket.BinaryMessage, []byte("hello")); err != nil {
+		t.Fatal(err)
+	}
+	_ = conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	messageType, data, err := conn.ReadMessage()
+	if err != nil || messageType != websocket.BinaryMessage || string(data) != "hello" {
+		t.Fatalf("expected the echo as a binary message, got %d %q: %v", messageType, data, err)
+	}
+
+	target.Close()
+	if _, resp, err := websocket.DefaultDialer.Dial(wsURL+"?host=localhost&port="+port, nil); err == nil || resp.StatusCode != http.StatusBadGateway {
+		t.Errorf("closed target: expected status 502, got %v", err)
+	}
+}
@@ -0,0 +1,133 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/iwanhae/terminal-hub/terminal"
+)
+
+// Limits of the UI configuration's fields
+const (
+	maxUITitleLength  = 64
+	maxUIBannerLength = 1024
+	maxUIMOTDLength   = 4096
+	minUIFontSize     = 8
+	maxUIFontSize     = 32
+)
+
+// uiTerminalThemes are the terminal themes the frontend ships
+var uiTerminalThemes = []string{"default", "light", "solarized-dark", "dracula"}
+
+// uiConfig holds the server-controlled UI settings for GET /api/config/ui,
+// read at startup from TERMINAL_HUB_UI_CONFIG, so a hub can be branded
+// without rebuilding the frontend. Empty fields keep the frontend's defaults.
+type uiConfig struct {
+	Title         string `json:"title,omitempty"`          // replaces "Terminal Hub" in the page title, sidebar and login page
+	LogoURL       string `json:"logo_url,omitempty"`       // http(s) or root-relative URL of an image shown next to the title
+	TerminalTheme string `json:"terminal_theme,omitempty"` // default, light, solarized-dark or dracula
+	FontFamily    string `json:"font_family,omitempty"`    // CSS font-family of terminals
+	FontSize      int    `json:"font_size,omitempty"`      // terminal font size in pixels, 8-32
+	Banner        string `json:"banner,omitempty"`         // plain-text message shown above every page, including the login page
+	MOTD          string `json:"motd,omitempty"`           // plain-text message of the day, shown on the login page and sent to every client attaching to a terminal
+}
+
+// currentUIConfig is served by GET /api/config/ui
+var currentUIConfig uiConfig
+
+// getUIConfigPathFromEnv returns TERMINAL_HUB_UI_CONFIG, or ui.json in the
+// data directory
+func getUIConfigPathFromEnv() string {
+	if path := os.Getenv("TERMINAL_HUB_UI_CONFIG"); path != "" {
+		return path
+	}
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "ui.json"
+	}
+	return filepath.Join(homeDir, ".terminal-hub", "ui.json")
+}
+
+// loadUIConfig reads and validates the UI configuration at path. A missing
+// file is an empty configuration.
+func loadUIConfig(path string) (uiConfig, error) {
+	var config uiConfig
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return config, nil
+	}
+	if err != nil {
+		return config, err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&config); err != nil {
+		return config, fmt.Errorf("%s: %w", path, err)
+	}
+	if err := config.Validate(); err != nil {
+		return config, fmt.Errorf("%s: %w", path, err)
+	}
+	return config, nil
+}
+
+// Validate checks the lengths of the texts, that the logo cannot run script
+// and that the theme and font size are ones the frontend supports
+func (c uiConfig) Validate() error {
+	if utf8.RuneCountInString(c.Title) > maxUITitleLength {
+		return fmt.Errorf("title must be at most %d characters", maxUITitleLength)
+	}
+	if utf8.RuneCountInString(c.Banner) > maxUIBannerLength {
+		return fmt.Errorf("banner must be at most %d characters", maxUIBannerLength)
+	}
+	if utf8.RuneCountInString(c.MOTD) > maxUIMOTDLength {
+		return fmt.Errorf("motd must be at most %d characters", maxUIMOTDLength)
+	}
+	if c.LogoURL != "" {
+		logo, err := url.Parse(c.LogoURL)
+		rootRelative := err == nil && logo.Scheme == "" && logo.Host == "" && strings.HasPrefix(logo.Path, "/")
+		if err != nil || !(rootRelative || ((logo.Scheme == "http" || logo.Scheme == "https") && logo.Host != "")) {
+			return errors.New("logo_url must be an http(s) URL or a path starting with /")
+		}
+	}
+	if c.TerminalTheme != "" && !slices.Contains(uiTerminalThemes, c.TerminalTheme) {
+		return fmt.Errorf("terminal_theme must be one of %s", strings.Join(uiTerminalThemes, ", "))
+	}
+	if strings.ContainsAny(c.FontFamily, ";{}") {
+		return errors.New("font_family must be a CSS font-family list")
+	}
+	if c.FontSize != 0 && (c.FontSize < minUIFontSize || c.FontSize > maxUIFontSize) {
+		return fmt.Errorf("font_size must be between %d and %d", minUIFontSize, maxUIFontSize)
+	}
+	return nil
+}
+
+// sendMOTD sends the message of the day, if one is set, to a client that
+// just attached to a session, as a "motd" message
+func sendMOTD(client terminal.MessageSender) {
+	if currentUIConfig.MOTD == "" {
+		return
+	}
+	if err := client.SendMessage(terminal.ServerMessage{Type: "motd", Data: currentUIConfig.MOTD}); err != nil {
+		log.Printf("Error sending the message of the day: %v", err)
+	}
+}
+
+// handleUIConfig handles GET /api/config/ui. It is public, as the login page
+// is branded too.
+func handleUIConfig(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, errCodeMethodNotAllowed, "Method not allowed")
+		return
+	}
+	writeJSONWithETag(w, r, currentUIConfig)
+}
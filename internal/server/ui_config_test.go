@@ -0,0 +1,104 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/iwanhae/terminal-hub/terminal"
+)
+
+func TestLoadUIConfig(t *testing.T) {
+	dir := t.TempDir()
+	write := func(content string) string {
+		path := filepath.Join(dir, "ui.json")
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	config, err := loadUIConfig(filepath.Join(dir, "missing.json"))
+	if err != nil || config != (uiConfig{}) {
+		t.Fatalf("expected an empty configuration for a missing file, got %+v, %v", config, err)
+	}
+
+	config, err = loadUIConfig(write(`{"title": "Ops Hub", "logo_url": "/branding/logo.svg", "terminal_theme": "dracula", "font_size": 16, "banner": "Maintenance tonight"}`))
+	if err != nil {
+		t.Fatalf("failed to load a valid configuration: %v", err)
+	}
+	if config.Title != "Ops Hub" || config.TerminalTheme != "dracula" || config.FontSize != 16 || config.Banner != "Maintenance tonight" {
+		t.Errorf("unexpected configuration: %+v", config)
+	}
+
+	for _, content := range []string{
+		`{"title": "` + strings.Repeat("x", maxUITitleLength+1) + `"}`,
+		`{"logo_url": "javascript:alert(1)"}`,
+		`{"logo_url": "//evil.example/logo.png"}`,
+		`{"terminal_theme": "neon"}`,
+		`{"font_size": 80}`,
+		`{"motd": "` + strings.Repeat("x", maxUIMOTDLength+1) + `"}`,
+		`{"font_family": "x; } body { display: none"}`,
+		`{"titel": "typo"}`,
+		`not json`,
+	} {
+		if _, err := loadUIConfig(write(content)); err == nil {
+			t.Errorf("expected %s to be rejected", content)
+		}
+	}
+}
+
+func TestHandleUIConfig(t *testing.T) {
+	currentUIConfig = uiConfig{Title: "Ops Hub", LogoURL: "https://example.com/logo.png"}
+	t.Cleanup(func() { currentUIConfig = uiConfig{} })
+
+	rec := httptest.NewRecorder()
+	handleUIConfig(rec, httptest.NewRequest(http.MethodGet, "/api/config/ui", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var got uiConfig
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if got != currentUIConfig {
+		t.Errorf("expected %+v, got %+v", currentUIConfig, got)
+	}
+
+	rec = httptest.NewRecorder()
+	handleUIConfig(rec, httptest.NewRequest(http.MethodPost, "/api/config/ui", nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("expected 405 for POST, got %d", rec.Code)
+	}
+}
+
+// recordingSender records the messages sent to a client
+type recordingSender struct {
+	messages []terminal.ServerMessage
+}
+
+func (s *recordingSender) SendMessage(msg terminal.ServerMessage) error {
+	s.messages = append(s.messages, msg)
+	return nil
+}
+
+func TestSendMOTD(t *testing.T) {
+	t.Cleanup(func() { currentUIConfig = uiConfig{} })
+
+	currentUIConfig = uiConfig{}
+	client := &recordingSender{}
+	sendMOTD(client)
+	if len(client.messages) != 0 {
+		t.Fatalf("expected no message without a MOTD, got %+v", client.messages)
+	}
+
+	currentUIConfig = uiConfig{MOTD: "Authorized use only"}
+	sendMOTD(client)
+	if len(client.messages) != 1 || client.messages[0].Type != "motd" || client.messages[0].Data != "Authorized use only" {
+		t.Errorf("expected one motd message, got %+v", client.messages)
+	}
+}
@@ -0,0 +1,354 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/iwanhae/terminal-hub/auth"
+	"github.com/iwanhae/terminal-hub/terminal"
+)
+
+// Environment variables telling a new process started by an upgrade which
+// inherited descriptors hold the listening sockets, the handed-over state and
+// the pipe to report readiness on
+const (
+	upgradeListenerFDEnv    = "TERMINAL_HUB_UPGRADE_LISTENER_FD"
+	upgradeStateFDEnv       = "TERMINAL_HUB_UPGRADE_STATE_FD"
+	upgradeReadyFDEnv       = "TERMINAL_HUB_UPGRADE_READY_FD"
+	upgradeSSHListenerFDEnv = "TERMINAL_HUB_UPGRADE_SSH_LISTENER_FD"
+)
+
+const (
+	upgradeReadyTimeout    = 30 * time.Second // for the new process to start serving
+	upgradeShutdownTimeout = 10 * time.Second // for in-flight requests of the old process
+)
+
+var errUpgradeInProgress = errors.New("an upgrade is already in progress")
+
+// upgradeState is handed from the old process to the new one
+type upgradeState struct {
+	Sessions      []terminal.HandoffSession `json:"sessions"`
+	LoginSessions []auth.Session            `json:"login_sessions"`
+}
+
+// upgradeHandoff is the state handed over by the previous process when this
+// one was started by an upgrade, nil otherwise
+var upgradeHandoff *upgradeState
+
+// hubUpgrader restarts the hub on a new binary, nil until the server listens
+var hubUpgrader *binaryUpgrader
+
+// binaryUpgrader replaces the running process with a new one started from the
+// executable on disk, handing over the listening sockets, the tmux-backed
+// sessions and the login sessions
+type binaryUpgrader struct {
+	listener net.Listener
+	server   *http.Server
+	auth     *auth.SessionManager
+
+	sshListener net.Listener // nil when the SSH server is disabled
+	sshServer   *sshServer
+
+	mu        sync.Mutex
+	upgrading bool
+	done      chan struct{} // closed once the old process has handed over
+}
+
+func newUpgrader(listener net.Listener, server *http.Server, authManager *auth.SessionManager) *binaryUpgrader {
+	return &binaryUpgrader{listener: listener, server: server, auth: authManager, done: make(chan struct{})}
+}
+
+// SetSSHServer hands the SSH server's socket over with the HTTP one
+func (u *binaryUpgrader) SetSSHServer(server *sshServer, listener net.Listener) {
+	u.sshServer, u.sshListener = server, listener
+}
+
+// Upgrade stops the cron scheduler, starts the new process and, once it
+// serves requests, shuts this one down in the background. It returns the new
+// process's PID.
+func (u *binaryUpgrader) Upgrade() (int, error) {
+	u.mu.Lock()
+	if u.upgrading {
+		u.mu.Unlock()
+		return 0, errUpgradeInProgress
+	}
+	u.upgrading = true
+	u.mu.Unlock()
+
+	// The new process starts its own scheduler from the saved jobs, so this
+	// one stops first to never run a job twice
+	if cronManager != nil {
+		cronManager.Stop()
+	}
+	pid, err := u.startSuccessor()
+	if err != nil {
+		if cronManager != nil {
+			if err := cronManager.Start(); err != nil {
+				log.Printf("Upgrade: failed to restart the cron scheduler: %v", err)
+			}
+		}
+		u.mu.Lock()
+		u.upgrading = false
+		u.mu.Unlock()
+		return 0, err
+	}
+	log.Printf("Upgrade: new process %d is serving, handing over", pid)
+	go u.handOver()
+	return pid, nil
+}
+
+// Wait blocks until the old process has handed over
+func (u *binaryUpgrader) Wait() {
+	<-u.done
+}
+
+// startSuccessor starts the executable with the same arguments and waits for
+// it to report that it is serving
+func (u *binaryUpgrader) startSuccessor() (int, error) {
+	fileListener, ok := u.listener.(interface{ File() (*os.File, error) })
+	if !ok {
+		return 0, fmt.Errorf("cannot hand over a %s listener", u.listener.Addr().Network())
+	}
+	listenerFile, err := fileListener.File()
+	if err != nil {
+		return 0, fmt.Errorf("failed to duplicate the listening socket: %w", err)
+	}
+	defer listenerFile.Close()
+
+	executable, err := os.Executable()
+	if err != nil {
+		return 0, fmt.Errorf("failed to locate the executable: %w", err)
+	}
+	stateRead, stateWrite, err := os.Pipe()
+	if err != nil {
+		return 0, err
+	}
+	readyRead, readyWrite, err := os.Pipe()
+	if err != nil {
+		stateRead.Close()
+		stateWrite.Close()
+		return 0, err
+	}
+	defer readyRead.Close()
+
+	// ExtraFiles become descriptors 3, 4, 5 and, with SSH, 6 of the new process
+	cmd := exec.Command(executable, os.Args[1:]...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	cmd.Env = append(os.Environ(),
+		upgradeListenerFDEnv+"=3",
+		upgradeStateFDEnv+"=4",
+		upgradeReadyFDEnv+"=5",
+	)
+	cmd.ExtraFiles = []*os.File{listenerFile, stateRead, readyWrite}
+	if tcpListener, ok := u.sshListener.(*net.TCPListener); ok {
+		sshFile, err := tcpListener.File()
+		if err != nil {
+			stateRead.Close()
+			stateWrite.Close()
+			readyWrite.Close()
+			return 0, fmt.Errorf("failed to duplicate the SSH socket: %w", err)
+		}
+		defer sshFile.Close()
+		cmd.Env = append(cmd.Env, upgradeSSHListenerFDEnv+"=6")
+		cmd.ExtraFiles = append(cmd.ExtraFiles, sshFile)
+	}
+	err = cmd.Start()
+	stateRead.Close()
+	readyWrite.Close()
+	if err != nil {
+		stateWrite.Close()
+		return 0, fmt.Errorf("failed to start %s: %w", executable, err)
+	}
+
+	state := upgradeState{Sessions: sessionManager.HandoffSessions()}
+	if u.auth != nil {
+		state.LoginSessions = u.auth.Sessions()
+	}
+	go func() {
+		defer stateWrite.Close()
+		if err := json.NewEncoder(stateWrite).Encode(state); err != nil {
+			log.Printf("Error handing over state to the new process: %v", err)
+		}
+	}()
+
+	ready := make(chan error, 1)
+	go func() {
+		_, err := readyRead.Read(make([]byte, 1))
+		ready <- err
+	}()
+	select {
+	case err = <-ready:
+	case <-time.After(upgradeReadyTimeout):
+		err = errors.New("timed out")
+	}
+	if err != nil {
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+		return 0, fmt.Errorf("new process did not start serving: %w", err)
+	}
+	// The new process outlives this one
+	pid := cmd.Process.Pid
+	_ = cmd.Process.Release()
+	return pid, nil
+}
+
+// handOver stops serving and detaches from the sessions, which the new
+// process has reattached to. Browsers reconnect to the new process; SSH
+// clients are disconnected and reconnect by hand.
+func (u *binaryUpgrader) handOver() {
+	defer close(u.done)
+
+	// The socket file now belongs to the new process
+	if unixListener, ok := u.listener.(*net.UnixListener); ok {
+		unixListener.SetUnlinkOnClose(false)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), upgradeShutdownTimeout)
+	defer cancel()
+	if err := u.server.Shutdown(ctx); err != nil {
+		log.Printf("Error shutting down for upgrade: %v", err)
+	}
+	if u.sshServer != nil {
+		if err := u.sshServer.Close(); err != nil {
+			log.Printf("Error closing SSH server for upgrade: %v", err)
+		}
+	}
+	if err := sessionManager.DetachAll(); err != nil {
+		log.Printf("Error detaching sessions for upgrade: %v", err)
+	}
+}
+
+// inheritedFile returns the descriptor named by an upgrade environment
+// variable, or nil when the variable is unset. The variable is unset so the
+// shells started by the hub do not inherit it.
+func inheritedFile(env, name string) (*os.File, error) {
+	value := os.Getenv(env)
+	if value == "" {
+		return nil, nil
+	}
+	os.Unsetenv(env)
+	fd, err := strconv.Atoi(value)
+	if err != nil || fd < 3 {
+		return nil, fmt.Errorf("invalid %s %q", env, value)
+	}
+	return os.NewFile(uintptr(fd), name), nil
+}
+
+// upgradeListener returns the listening socket handed over by the previous
+// process, or nil when this process was not started by an upgrade
+func upgradeListener() (net.Listener, error) {
+	file, err := inheritedFile(upgradeListenerFDEnv, "upgrade-listener")
+	if err != nil || file == nil {
+		return nil, err
+	}
+	defer file.Close()
+	listener, err := net.FileListener(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to use the inherited socket: %w", err)
+	}
+	return listener, nil
+}
+
+// upgradeSSHListener returns the SSH server's socket handed over by the
+// previous process, or nil when there is none
+func upgradeSSHListener() (net.Listener, error) {
+	file, err := inheritedFile(upgradeSSHListenerFDEnv, "upgrade-ssh-listener")
+	if err != nil || file == nil {
+		return nil, err
+	}
+	defer file.Close()
+	listener, err := net.FileListener(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to use the inherited SSH socket: %w", err)
+	}
+	return listener, nil
+}
+
+// readUpgradeState reads the state handed over by the previous process, or
+// returns nil when this process was not started by an upgrade
+func readUpgradeState() (*upgradeState, error) {
+	file, err := inheritedFile(upgradeStateFDEnv, "upgrade-state")
+	if err != nil || file == nil {
+		return nil, err
+	}
+	defer file.Close()
+	var state upgradeState
+	if err := json.NewDecoder(file).Decode(&state); err != nil {
+		return nil, fmt.Errorf("failed to read the handed-over state: %w", err)
+	}
+	return &state, nil
+}
+
+// signalUpgradeReady tells the previous process that this one is serving, so
+// it can shut down. It does nothing when this process was not started by an
+// upgrade.
+func signalUpgradeReady() {
+	file, err := inheritedFile(upgradeReadyFDEnv, "upgrade-ready")
+	if err != nil {
+		log.Printf("Error reporting upgrade readiness: %v", err)
+		return
+	}
+	if file == nil {
+		return
+	}
+	defer file.Close()
+	if _, err := file.Write([]byte{1}); err != nil {
+		log.Printf("Error reporting upgrade readiness: %v", err)
+	}
+}
+
+// handleUpgradeSignals upgrades on SIGUSR2
+func handleUpgradeSignals(u *binaryUpgrader) {
+	signals := make(chan os.Signal, 1)
+	notifyUpgradeSignal(signals)
+	for range signals {
+		log.Printf("Upgrade requested by signal")
+		if _, err := u.Upgrade(); err != nil {
+			log.Printf("Error upgrading: %v", err)
+		}
+	}
+}
+
+// upgradeResponse is the body of POST /api/admin/upgrade
+type upgradeResponse struct {
+	Status string `json:"status"`
+	PID    int    `json:"pid"` // of the new process
+}
+
+// handleAdminUpgrade handles POST /api/admin/upgrade, restarting the hub on
+// the executable on disk without ending tmux-backed sessions
+func handleAdminUpgrade(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, errCodeMethodNotAllowed, "Method not allowed")
+		return
+	}
+	if hubUpgrader == nil {
+		writeError(w, http.StatusServiceUnavailable, errCodeFeatureUnavailable, "Upgrade is not available")
+		return
+	}
+
+	pid, err := hubUpgrader.Upgrade()
+	if errors.Is(err, errUpgradeInProgress) {
+		writeError(w, http.StatusConflict, errCodeConflict, err.Error())
+		return
+	}
+	if err != nil {
+		log.Printf("Error upgrading: %v", err)
+		writeError(w, http.StatusInternalServerError, errCodeInternal, "Upgrade failed: "+err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(upgradeResponse{Status: "upgraded", PID: pid}); err != nil {
+		log.Printf("Error encoding upgrade response: %v", err)
+	}
+}
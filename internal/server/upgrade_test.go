@@ -0,0 +1,91 @@
+//go:build !windows
+
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/iwanhae/terminal-hub/auth"
+	"github.com/iwanhae/terminal-hub/terminal"
+)
+
+// inheritPipeEnd sets env to a duplicate of f's descriptor, as if it had
+// been inherited from the previous process
+func inheritPipeEnd(t *testing.T, env string, f *os.File) {
+	t.Helper()
+	fd, err := syscall.Dup(int(f.Fd()))
+	if err != nil {
+		t.Fatalf("dup: %v", err)
+	}
+	t.Setenv(env, strconv.Itoa(fd))
+}
+
+func TestReadUpgradeState(t *testing.T) {
+	t.Setenv(upgradeStateFDEnv, "")
+	if state, err := readUpgradeState(); state != nil || err != nil {
+		t.Fatalf("expected no state outside an upgrade, got %v, %v", state, err)
+	}
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	sent := upgradeState{
+		Sessions:      []terminal.HandoffSession{{ID: "s1", Name: "build", TmuxSession: "s1"}},
+		LoginSessions: []auth.Session{{ID: "token", Username: "admin", LastActivity: time.Now()}},
+	}
+	if err := json.NewEncoder(w).Encode(sent); err != nil {
+		t.Fatal(err)
+	}
+	w.Close()
+
+	inheritPipeEnd(t, upgradeStateFDEnv, r)
+	state, err := readUpgradeState()
+	if err != nil {
+		t.Fatalf("readUpgradeState: %v", err)
+	}
+	if len(state.Sessions) != 1 || state.Sessions[0].Name != "build" || len(state.LoginSessions) != 1 {
+		t.Errorf("unexpected state: %+v", state)
+	}
+	if value, ok := os.LookupEnv(upgradeStateFDEnv); ok {
+		t.Errorf("expected %s to be unset, got %q", upgradeStateFDEnv, value)
+	}
+}
+
+func TestSignalUpgradeReady(t *testing.T) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	inheritPipeEnd(t, upgradeReadyFDEnv, w)
+	w.Close()
+
+	signalUpgradeReady()
+	buf := make([]byte, 1)
+	if n, err := r.Read(buf); n != 1 || err != nil {
+		t.Fatalf("expected a readiness byte, got %d, %v", n, err)
+	}
+}
+
+func TestHandleAdminUpgradeUnavailable(t *testing.T) {
+	rec := httptest.NewRecorder()
+	handleAdminUpgrade(rec, httptest.NewRequest(http.MethodGet, "/api/admin/upgrade", nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("GET: expected status 405, got %d", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	handleAdminUpgrade(rec, httptest.NewRequest(http.MethodPost, "/api/admin/upgrade", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("POST without a listener: expected status 503, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,14 @@
+//go:build !windows
+
+package server
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// notifyUpgradeSignal relays SIGUSR2, which requests an upgrade
+func notifyUpgradeSignal(signals chan<- os.Signal) {
+	signal.Notify(signals, syscall.SIGUSR2)
+}
@@ -0,0 +1,8 @@
+//go:build windows
+
+package server
+
+import "os"
+
+// notifyUpgradeSignal does nothing: Windows has no SIGUSR2
+func notifyUpgradeSignal(signals chan<- os.Signal) {}
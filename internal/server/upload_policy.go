@@ -0,0 +1,256 @@
+package server
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	// clamdTimeout bounds connecting to clamd and each exchange with it
+	clamdTimeout = 2 * time.Minute
+	// clamdChunkSize is the most upload data sent to clamd in one chunk
+	clamdChunkSize = 64 * 1024
+	// clamdMaxReply bounds clamd's reply to a scan
+	clamdMaxReply = 4096
+)
+
+// uploadPolicy restricts the files POST /api/upload accepts
+var uploadPolicy uploadRules
+
+// uploadRules are the extension lists and virus scanner applied to uploads
+type uploadRules struct {
+	allowed []string      // lower-case extensions such as ".tar.gz"; empty allows all
+	blocked []string      // lower-case extensions refused even when allowed
+	scanner *clamdScanner // nil when uploads are not scanned
+}
+
+// getUploadRulesFromEnv parses TERMINAL_HUB_UPLOAD_ALLOWED_EXTENSIONS,
+// TERMINAL_HUB_UPLOAD_BLOCKED_EXTENSIONS and TERMINAL_HUB_CLAMD
+func getUploadRulesFromEnv() (uploadRules, error) {
+	var rules uploadRules
+	var err error
+	if rules.allowed, err = parseExtensionList(os.Getenv("TERMINAL_HUB_UPLOAD_ALLOWED_EXTENSIONS")); err != nil {
+		return uploadRules{}, fmt.Errorf("TERMINAL_HUB_UPLOAD_ALLOWED_EXTENSIONS: %w", err)
+	}
+	if rules.blocked, err = parseExtensionList(os.Getenv("TERMINAL_HUB_UPLOAD_BLOCKED_EXTENSIONS")); err != nil {
+		return uploadRules{}, fmt.Errorf("TERMINAL_HUB_UPLOAD_BLOCKED_EXTENSIONS: %w", err)
+	}
+	if address := strings.TrimSpace(os.Getenv("TERMINAL_HUB_CLAMD")); address != "" {
+		if rules.scanner, err = parseClamdAddress(address); err != nil {
+			return uploadRules{}, fmt.Errorf("TERMINAL_HUB_CLAMD: %w", err)
+		}
+	}
+	return rules, nil
+}
+
+// parseExtensionList parses a comma-separated list of extensions such as
+// "exe, .bat,tar.gz"; the leading dot is optional
+func parseExtensionList(value string) ([]string, error) {
+	var extensions []string
+	for _, part := range strings.Split(value, ",") {
+		ext := strings.ToLower(strings.TrimSpace(part))
+		if ext == "" {
+			continue
+		}
+		ext = "." + strings.TrimPrefix(ext, ".")
+		if ext == "." || strings.ContainsAny(ext, `/\`) {
+			return nil, fmt.Errorf("invalid extension %q", part)
+		}
+		extensions = append(extensions, ext)
+	}
+	return extensions, nil
+}
+
+// checkFilename returns an error when files called name may not be uploaded
+func (u uploadRules) checkFilename(name string) error {
+	lower := strings.ToLower(name)
+	for _, ext := range u.blocked {
+		if strings.HasSuffix(lower, ext) {
+			return fmt.Errorf("files ending in %s may not be uploaded", ext)
+		}
+	}
+	if len(u.allowed) == 0 {
+		return nil
+	}
+	for _, ext := range u.allowed {
+		if strings.HasSuffix(lower, ext) {
+			return nil
+		}
+	}
+	return fmt.Errorf("only files ending in %s may be uploaded", strings.Join(u.allowed, ", "))
+}
+
+// clamdScanner scans uploads with a ClamAV daemon
+type clamdScanner struct {
+	network string // "unix" or "tcp"
+	address string
+}
+
+// parseClamdAddress parses "unix:/run/clamav/clamd.ctl", "tcp:host:3310", a
+// socket path or HOST:PORT
+func parseClamdAddress(value string) (*clamdScanner, error) {
+	switch {
+	case strings.HasPrefix(value, "unix:"):
+		return &clamdScanner{network: "unix", address: strings.TrimPrefix(value, "unix:")}, nil
+	case strings.HasPrefix(value, "tcp:"):
+		value = strings.TrimPrefix(value, "tcp:")
+	case filepath.IsAbs(value):
+		return &clamdScanner{network: "unix", address: value}, nil
+	}
+	if _, _, err := net.SplitHostPort(value); err != nil {
+		return nil, fmt.Errorf("invalid address %q: use unix:PATH or tcp:HOST:PORT", value)
+	}
+	return &clamdScanner{network: "tcp", address: value}, nil
+}
+
+// clamdScan is a scan in progress: data written to it is streamed to clamd
+// with the INSTREAM command
+type clamdScan struct {
+	conn net.Conn
+}
+
+// start connects to clamd and begins a scan
+func (s *clamdScanner) start() (*clamdScan, error) {
+	conn, err := net.DialTimeout(s.network, s.address, clamdTimeout)
+	if err != nil {
+		return nil, err
+	}
+	_ = conn.SetDeadline(time.Now().Add(clamdTimeout))
+	if _, err := conn.Write([]byte("zINSTREAM\x00")); err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+	return &clamdScan{conn: conn}, nil
+}
+
+// Write sends p to clamd in length-prefixed chunks
+func (c *clamdScan) Write(p []byte) (int, error) {
+	written := 0
+	for len(p) > 0 {
+		chunk := p[:min(len(p), clamdChunkSize)]
+		_ = c.conn.SetDeadline(time.Now().Add(clamdTimeout))
+		var size [4]byte
+		binary.BigEndian.PutUint32(size[:], uint32(len(chunk)))
+		if _, err := c.conn.Write(size[:]); err != nil {
+			return written, err
+		}
+		if _, err := c.conn.Write(chunk); err != nil {
+			return written, err
+		}
+		written += len(chunk)
+		p = p[len(chunk):]
+	}
+	return written, nil
+}
+
+// finish ends the stream and returns the name of the signature clamd found,
+// or "" when the data is clean
+func (c *clamdScan) finish() (string, error) {
+	defer c.conn.Close()
+	_ = c.conn.SetDeadline(time.Now().Add(clamdTimeout))
+	if _, err := c.conn.Write([]byte{0, 0, 0, 0}); err != nil {
+		return "", err
+	}
+	reply, err := io.ReadAll(io.LimitReader(c.conn, clamdMaxReply))
+	if err != nil {
+		return "", err
+	}
+
+	// Replies are "stream: OK", "stream: <signature> FOUND" or "... ERROR"
+	result := strings.TrimSpace(string(bytes.TrimRight(reply, "\x00")))
+	result = strings.TrimPrefix(result, "stream: ")
+	switch {
+	case result == "OK":
+		return "", nil
+	case strings.HasSuffix(result, " FOUND"):
+		return strings.TrimSuffix(result, " FOUND"), nil
+	}
+	return "", fmt.Errorf("clamd: %s", result)
+}
+
+// close abandons the scan
+func (c *clamdScan) close() {
+	_ = c.conn.Close()
+}
+
+// receiveScannedUpload streams body to a hidden file in dir while clamd
+// scans it, and moves the file to targetPath once clamd finds it clean, so
+// flagged files never appear under their name. It writes the error response
+// and returns false when the upload is refused or fails.
+func receiveScannedUpload(w http.ResponseWriter, body io.Reader, dir, targetPath string, overwrite bool) (int64, bool) {
+	scan, err := uploadPolicy.scanner.start()
+	if err != nil {
+		log.Printf("Error connecting to clamd: %v", err)
+		writeError(w, http.StatusBadGateway, errCodeUpstreamFailed, "Virus scanner unavailable")
+		return 0, false
+	}
+	defer scan.close()
+
+	tempFile, err := os.CreateTemp(dir, "."+filepath.Base(targetPath)+".upload-*")
+	if err != nil {
+		log.Printf("Error creating upload file: %v", err)
+		writeError(w, http.StatusInternalServerError, errCodeInternal, "Failed to open upload target")
+		return 0, false
+	}
+	tempPath := tempFile.Name()
+	committed := false
+	defer func() {
+		if !committed {
+			_ = os.Remove(tempPath)
+		}
+	}()
+
+	copyBuffer := uploadCopyBufferPool.Get().([]byte)
+	defer uploadCopyBufferPool.Put(copyBuffer)
+
+	written, err := io.CopyBuffer(io.MultiWriter(tempFile, scan), body, copyBuffer)
+	closeErr := tempFile.Close()
+	if err != nil {
+		log.Printf("Error streaming upload to file: %v", err)
+		writeError(w, http.StatusInternalServerError, errCodeInternal, "Failed to write upload")
+		return 0, false
+	}
+	if closeErr != nil {
+		log.Printf("Error closing uploaded file: %v", closeErr)
+		writeError(w, http.StatusInternalServerError, errCodeInternal, "Failed to finalize upload")
+		return 0, false
+	}
+
+	signature, err := scan.finish()
+	if err != nil {
+		log.Printf("Error scanning upload %s: %v", targetPath, err)
+		writeError(w, http.StatusBadGateway, errCodeUpstreamFailed, "Virus scan failed")
+		return 0, false
+	}
+	if signature != "" {
+		log.Printf("Upload %s rejected: clamd found %s", targetPath, signature)
+		writeError(w, http.StatusUnprocessableEntity, errCodeMalwareDetected, "Upload rejected: "+signature+" found")
+		return 0, false
+	}
+
+	if !overwrite {
+		if _, err := os.Lstat(targetPath); err == nil {
+			writeError(w, http.StatusConflict, errCodeFileExists, "File already exists")
+			return 0, false
+		}
+	}
+	if err := os.Chmod(tempPath, 0o644); err != nil {
+		log.Printf("Error setting upload permissions: %v", err)
+	}
+	if err := os.Rename(tempPath, targetPath); err != nil {
+		log.Printf("Error moving upload into place: %v", err)
+		writeError(w, http.StatusInternalServerError, errCodeInternal, "Failed to finalize upload")
+		return 0, false
+	}
+	committed = true
+	return written, true
+}
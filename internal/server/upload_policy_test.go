@@ -0,0 +1,177 @@
+package server
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestUploadRulesCheckFilename(t *testing.T) {
+	t.Parallel()
+
+	allowed, err := parseExtensionList("tar.gz, .TXT ,png")
+	if err != nil {
+		t.Fatalf("parseExtensionList failed: %v", err)
+	}
+	blocked, err := parseExtensionList(".exe,bat")
+	if err != nil {
+		t.Fatalf("parseExtensionList failed: %v", err)
+	}
+	rules := uploadRules{allowed: allowed, blocked: blocked}
+
+	tests := []struct {
+		name string
+		ok   bool
+	}{
+		{"notes.txt", true},
+		{"build.TAR.GZ", true},
+		{"setup.exe", false},
+		{"script.sh", false},
+		{"run.bat", false},
+	}
+	for _, tt := range tests {
+		if err := rules.checkFilename(tt.name); (err == nil) != tt.ok {
+			t.Errorf("checkFilename(%q) = %v, want ok=%v", tt.name, err, tt.ok)
+		}
+	}
+	if err := (uploadRules{}).checkFilename("anything.exe"); err != nil {
+		t.Fatalf("expected uploads to be unrestricted without lists, got %v", err)
+	}
+	if _, err := parseExtensionList("exe,a/b"); err == nil {
+		t.Fatalf("expected extensions with slashes to be rejected")
+	}
+}
+
+func TestParseClamdAddress(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		value, network, address string
+	}{
+		{"unix:/run/clamav/clamd.ctl", "unix", "/run/clamav/clamd.ctl"},
+		{"/run/clamav/clamd.ctl", "unix", "/run/clamav/clamd.ctl"},
+		{"tcp:127.0.0.1:3310", "tcp", "127.0.0.1:3310"},
+		{"clamav:3310", "tcp", "clamav:3310"},
+	}
+	for _, tt := range tests {
+		if runtime.GOOS == "windows" && tt.value == tt.address && tt.network == "unix" {
+			// Socket paths are not absolute paths there
+			continue
+		}
+		scanner, err := parseClamdAddress(tt.value)
+		if err != nil || scanner.network != tt.network || scanner.address != tt.address {
+			t.Errorf("parseClamdAddress(%q) = %+v, %v", tt.value, scanner, err)
+		}
+	}
+	if _, err := parseClamdAddress("clamav"); err == nil {
+		t.Fatalf("expected an address without a port to be rejected")
+	}
+}
+
+// startFakeClamd serves INSTREAM scans, flagging streams that contain
+// "EICAR"
+func startFakeClamd(t *testing.T) string {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	t.Cleanup(func() { _ = listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				command := make([]byte, len("zINSTREAM\x00"))
+				if _, err := io.ReadFull(conn, command); err != nil || string(command) != "zINSTREAM\x00" {
+					return
+				}
+				var data []byte
+				for {
+					var size [4]byte
+					if _, err := io.ReadFull(conn, size[:]); err != nil {
+						return
+					}
+					n := binary.BigEndian.Uint32(size[:])
+					if n == 0 {
+						break
+					}
+					chunk := make([]byte, n)
+					if _, err := io.ReadFull(conn, chunk); err != nil {
+						return
+					}
+					data = append(data, chunk...)
+				}
+				if bytes.Contains(data, []byte("EICAR")) {
+					_, _ = conn.Write([]byte("stream: Eicar-Test-Signature FOUND\x00"))
+					return
+				}
+				_, _ = conn.Write([]byte("stream: OK\x00"))
+			}()
+		}
+	}()
+	return listener.Addr().String()
+}
+
+// Not parallel: it sets uploadPolicy, which parallel upload tests read
+func TestHandleFileUploadAppliesUploadPolicy(t *testing.T) {
+	scanner, err := parseClamdAddress("tcp:" + startFakeClamd(t))
+	if err != nil {
+		t.Fatalf("parseClamdAddress failed: %v", err)
+	}
+	uploadPolicy = uploadRules{blocked: []string{".exe"}, scanner: scanner}
+	t.Cleanup(func() { uploadPolicy = uploadRules{} })
+
+	dir := t.TempDir()
+	rec := httptest.NewRecorder()
+	handleFileUpload(rec, newUploadRequest(t, dir, "setup.exe", false, []byte("MZ")))
+	if rec.Code != http.StatusUnsupportedMediaType {
+		t.Fatalf("expected status 415 for a blocked extension, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	handleFileUpload(rec, newUploadRequest(t, dir, "clean.txt", false, bytes.Repeat([]byte("clean "), 50_000)))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200 for a clean file, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if info, err := os.Stat(filepath.Join(dir, "clean.txt")); err != nil || info.Size() != 300_000 {
+		t.Fatalf("expected the clean file to be written, got %v (%v)", info, err)
+	}
+
+	rec = httptest.NewRecorder()
+	handleFileUpload(rec, newUploadRequest(t, dir, "clean.txt", false, []byte("again")))
+	if rec.Code != http.StatusConflict {
+		t.Fatalf("expected status 409 for an existing file, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	handleFileUpload(rec, newUploadRequest(t, dir, "eicar.txt", false, []byte("X5O!P%@AP EICAR test")))
+	if rec.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("expected status 422 for a flagged file, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	// Flagged uploads leave nothing behind, not even the temporary file
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("failed to read directory: %v", err)
+	}
+	if len(entries) != 1 || entries[0].Name() != "clean.txt" {
+		names := make([]string, 0, len(entries))
+		for _, entry := range entries {
+			names = append(names, entry.Name())
+		}
+		t.Fatalf("expected only clean.txt in the directory, got %v", names)
+	}
+}
@@ -0,0 +1,203 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/iwanhae/terminal-hub/terminal"
+)
+
+// listWatchRulesResponse lists a session's watch rules
+type listWatchRulesResponse struct {
+	Rules []terminal.WatchRule `json:"rules"`
+}
+
+// watchRuleSession looks up a session and checks that it supports watch
+// rules, writing the error response if not
+func watchRuleSession(w http.ResponseWriter, sessionID string) (terminal.WatchRuleManager, bool) {
+	sess, ok := sessionManager.Get(sessionID)
+	if !ok {
+		writeError(w, http.StatusNotFound, errCodeSessionNotFound, "Session not found")
+		return nil, false
+	}
+	manager, ok := sess.(terminal.WatchRuleManager)
+	if !ok {
+		writeError(w, http.StatusConflict, errCodeUnsupportedSession, "Session does not support watch rules")
+		return nil, false
+	}
+	return manager, true
+}
+
+// handleSessionWatches handles GET (list) and POST (create) /api/sessions/:id/watches
+func handleSessionWatches(w http.ResponseWriter, r *http.Request, sessionID string) {
+	switch r.Method {
+	case http.MethodGet:
+		manager, ok := watchRuleSession(w, sessionID)
+		if !ok {
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(listWatchRulesResponse{Rules: manager.ListWatchRules()}); err != nil {
+			log.Printf("Error encoding watch rules: %v", err)
+		}
+
+	case http.MethodPost:
+		var rule terminal.WatchRule
+		if err := json.NewDecoder(r.Body).Decode(&rule); err != nil {
+			log.Printf("Error decoding request: %v", err)
+			writeError(w, http.StatusBadRequest, errCodeInvalidJSON, "Bad request")
+			return
+		}
+		manager, ok := watchRuleSession(w, sessionID)
+		if !ok {
+			return
+		}
+		created, err := manager.AddWatchRule(rule)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, errCodeInvalidRequest, err.Error())
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		if err := json.NewEncoder(w).Encode(created); err != nil {
+			log.Printf("Error encoding watch rule: %v", err)
+		}
+
+	default:
+		writeError(w, http.StatusMethodNotAllowed, errCodeMethodNotAllowed, "Method not allowed")
+	}
+}
+
+// handleSessionWatch handles DELETE /api/sessions/:id/watches/:ruleID
+func handleSessionWatch(w http.ResponseWriter, r *http.Request, sessionID, ruleID string) {
+	if r.Method != http.MethodDelete {
+		writeError(w, http.StatusMethodNotAllowed, errCodeMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	manager, ok := watchRuleSession(w, sessionID)
+	if !ok {
+		return
+	}
+	if err := manager.RemoveWatchRule(ruleID); err != nil {
+		writeError(w, http.StatusNotFound, errCodeWatchNotFound, err.Error())
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleEventsWebSocket streams session events such as fired watch rules as
+// JSON text messages on /ws/events. A client may also send a
+// fileWatchRequest to be told when the entries of a directory change, which
+// the file browser uses to refresh its listing.
+func handleEventsWebSocket(w http.ResponseWriter, r *http.Request) {
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		log.Println("Upgrade error:", err)
+		return
+	}
+	defer conn.Close()
+
+	events, unsubscribe := sessionManager.Events().Subscribe()
+	defer unsubscribe()
+
+	conn.SetReadLimit(websocketReadLimit)
+	_ = conn.SetReadDeadline(time.Now().Add(websocketPongWait))
+	conn.SetPongHandler(func(appData string) error {
+		return conn.SetReadDeadline(time.Now().Add(websocketPongWait))
+	})
+
+	// Read pump: besides file watch requests, clients only send control
+	// frames, reading detects disconnects. Demo visitors cannot browse files,
+	// so their watch requests are ignored.
+	done := make(chan struct{})
+	watchRequests := make(chan string, 1)
+	go func() {
+		defer close(done)
+		for {
+			messageType, data, err := conn.ReadMessage()
+			if err != nil {
+				var netErr net.Error
+				if errors.As(err, &netErr) && netErr.Timeout() {
+					log.Printf("Events WebSocket read timeout; closing stale connection")
+				}
+				return
+			}
+			var req fileWatchRequest
+			if messageType != websocket.TextMessage || isDemoVisitor(r) ||
+				json.Unmarshal(data, &req) != nil || req.Type != fileWatchMessage {
+				continue
+			}
+			// Only the latest request matters
+			select {
+			case <-watchRequests:
+			default:
+			}
+			watchRequests <- req.Path
+		}
+	}()
+
+	pingTicker := time.NewTicker(websocketPingPeriod)
+	defer pingTicker.Stop()
+
+	var watchedDir string
+	stopWatch := func() {}
+	defer func() { stopWatch() }()
+	fileChanges := make(chan string, 1)
+
+	for {
+		select {
+		case path := <-watchRequests:
+			stopWatch()
+			stopWatch, watchedDir = func() {}, ""
+			if path == "" {
+				continue
+			}
+			dir, err := validateWatchDirectory(path)
+			if err != nil {
+				_ = conn.SetWriteDeadline(time.Now().Add(websocketWriteWait))
+				if err := conn.WriteJSON(fileWatchEvent{Type: fileEventWatchRejected, Path: path, Error: err.Error(), Timestamp: time.Now().Unix()}); err != nil {
+					log.Printf("Error writing event: %v", err)
+					return
+				}
+				continue
+			}
+			ctx, cancel := context.WithCancel(r.Context())
+			stopWatch, watchedDir = cancel, dir
+			go watchDirectory(ctx, dir, fileWatchInterval, fileChanges)
+		case dir := <-fileChanges:
+			// Changes of a directory no longer watched are stale
+			if dir != watchedDir {
+				continue
+			}
+			_ = conn.SetWriteDeadline(time.Now().Add(websocketWriteWait))
+			if err := conn.WriteJSON(fileWatchEvent{Type: fileEventChanged, Path: dir, Timestamp: time.Now().Unix()}); err != nil {
+				log.Printf("Error writing event: %v", err)
+				return
+			}
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+			_ = conn.SetWriteDeadline(time.Now().Add(websocketWriteWait))
+			if err := conn.WriteJSON(event); err != nil {
+				log.Printf("Error writing event: %v", err)
+				return
+			}
+		case <-pingTicker.C:
+			_ = conn.SetWriteDeadline(time.Now().Add(websocketWriteWait))
+			if err := conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				log.Printf("Error sending ping frame: %v", err)
+				return
+			}
+		case <-done:
+			return
+		}
+	}
+}
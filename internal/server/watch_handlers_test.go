@@ -0,0 +1,128 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/iwanhae/terminal-hub/terminal"
+)
+
+func TestSessionWatchRulesAndEvents(t *testing.T) {
+	_, sessionID, ptyWriter := createWebSocketHeartbeatTestServer(t)
+	basePath := "/api/sessions/" + sessionID + "/watches"
+
+	rec := httptest.NewRecorder()
+	handleSessionByID(rec, httptest.NewRequest(http.MethodPost, basePath, strings.NewReader(`{"type":"pattern","pattern":"BUILD (SUCCESSFUL|FAILED)"}`)))
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected status 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var rule terminal.WatchRule
+	if err := json.Unmarshal(rec.Body.Bytes(), &rule); err != nil {
+		t.Fatalf("failed to decode rule: %v", err)
+	}
+
+	rec = httptest.NewRecorder()
+	handleSessionByID(rec, httptest.NewRequest(http.MethodGet, basePath, nil))
+	var list listWatchRulesResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &list); err != nil || len(list.Rules) != 1 || list.Rules[0].ID != rule.ID {
+		t.Fatalf("unexpected rule list %s (%v)", rec.Body.String(), err)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/ws/events", handleEventsWebSocket)
+	eventServer := httptest.NewServer(mux)
+	t.Cleanup(eventServer.Close)
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(eventServer.URL, "http")+"/ws/events", nil)
+	if err != nil {
+		t.Fatalf("failed to dial events websocket: %v", err)
+	}
+	t.Cleanup(func() { _ = conn.Close() })
+
+	// The subscription is registered after the upgrade completes
+	time.Sleep(50 * time.Millisecond)
+	if _, err := ptyWriter.Write([]byte("> Task :build\r\nBUILD SUCCESSFUL in 4s\r\n")); err != nil {
+		t.Fatalf("failed to write PTY output: %v", err)
+	}
+
+	_ = conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	var event terminal.SessionEvent
+	if err := conn.ReadJSON(&event); err != nil {
+		t.Fatalf("failed to read event: %v", err)
+	}
+	if event.Type != terminal.SessionEventWatch || event.SessionID != sessionID || event.RuleID != rule.ID || event.Match != "BUILD SUCCESSFUL" {
+		t.Fatalf("unexpected event %+v", event)
+	}
+
+	tests := []struct {
+		method string
+		path   string
+		body   string
+		want   int
+	}{
+		{http.MethodPost, basePath, `{"type":"silence"}`, http.StatusBadRequest},
+		{http.MethodPost, basePath, `not json`, http.StatusBadRequest},
+		{http.MethodGet, "/api/sessions/missing/watches", "", http.StatusNotFound},
+		{http.MethodDelete, basePath + "/" + rule.ID, "", http.StatusNoContent},
+		{http.MethodDelete, basePath + "/" + rule.ID, "", http.StatusNotFound},
+		{http.MethodPut, basePath, "", http.StatusMethodNotAllowed},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		handleSessionByID(rec, httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body)))
+		if rec.Code != tt.want {
+			t.Errorf("%s %s: expected status %d, got %d: %s", tt.method, tt.path, tt.want, rec.Code, rec.Body.String())
+		}
+	}
+}
+
+func TestEventsWebSocketWatchesFiles(t *testing.T) {
+	sessionManager = terminal.NewSessionManager()
+	dir := t.TempDir()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/ws/events", handleEventsWebSocket)
+	eventServer := httptest.NewServer(mux)
+	t.Cleanup(eventServer.Close)
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(eventServer.URL, "http")+"/ws/events", nil)
+	if err != nil {
+		t.Fatalf("failed to dial events websocket: %v", err)
+	}
+	t.Cleanup(func() { _ = conn.Close() })
+
+	readEvent := func() fileWatchEvent {
+		t.Helper()
+		_ = conn.SetReadDeadline(time.Now().Add(3 * time.Second))
+		var event fileWatchEvent
+		if err := conn.ReadJSON(&event); err != nil {
+			t.Fatalf("failed to read event: %v", err)
+		}
+		return event
+	}
+
+	missing := filepath.Join(dir, "missing")
+	if err := conn.WriteJSON(fileWatchRequest{Type: fileWatchMessage, Path: missing}); err != nil {
+		t.Fatalf("failed to send watch request: %v", err)
+	}
+	if event := readEvent(); event.Type != fileEventWatchRejected || event.Path != missing || event.Error == "" {
+		t.Fatalf("unexpected event %+v", event)
+	}
+
+	if err := conn.WriteJSON(fileWatchRequest{Type: fileWatchMessage, Path: dir}); err != nil {
+		t.Fatalf("failed to send watch request: %v", err)
+	}
+	// The watch lists the directory once it starts
+	time.Sleep(200 * time.Millisecond)
+	if err := os.WriteFile(filepath.Join(dir, "artifact.bin"), []byte("built"), 0o644); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+	if event := readEvent(); event.Type != fileEventChanged || event.Path != dir {
+		t.Fatalf("unexpected event %+v", event)
+	}
+}
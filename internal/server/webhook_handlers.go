@@ -0,0 +1,221 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/iwanhae/terminal-hub/cron"
+	"github.com/iwanhae/terminal-hub/terminal"
+	"github.com/iwanhae/terminal-hub/webhook"
+)
+
+// webhooks delivers hub events to registered webhooks, nil when the webhook
+// store could not be opened
+var webhooks *webhook.Dispatcher
+
+// sessionWebhookEvents maps session event types to webhook events
+var sessionWebhookEvents = map[string]string{
+	terminal.SessionEventCreated: webhook.EventSessionCreated,
+	terminal.SessionEventClosed:  webhook.EventSessionClosed,
+	terminal.SessionEventExit:    webhook.EventSessionExited,
+	terminal.SessionEventWatch:   webhook.EventSessionWatch,
+}
+
+// authWebhookEvent is the data of auth.* webhook events
+type authWebhookEvent struct {
+	Username   string `json:"username,omitempty"`
+	IP         string `json:"ip"`
+	Via        string `json:"via,omitempty"`         // "web", "ssh" or "webdav"
+	UserAgent  string `json:"user_agent,omitempty"`  // browser or SSH client version
+	BanSeconds int64  `json:"ban_seconds,omitempty"` // auth.banned: how long the IP is banned
+}
+
+// updateWebhookRequest is the body of PUT /api/webhooks/:id; omitted fields
+// are left unchanged
+type updateWebhookRequest struct {
+	URL         *string   `json:"url,omitempty"`
+	Secret      *string   `json:"secret,omitempty"`
+	Events      *[]string `json:"events,omitempty"`
+	Description *string   `json:"description,omitempty"`
+	Disabled    *bool     `json:"disabled,omitempty"`
+}
+
+// listWebhooksResponse lists the registered webhooks without their secrets
+type listWebhooksResponse struct {
+	Webhooks []webhook.Webhook `json:"webhooks"`
+}
+
+// publishWebhookEvent delivers event to the webhooks subscribed to it
+func publishWebhookEvent(event string, data any) {
+	if webhooks != nil {
+		webhooks.Publish(event, data)
+	}
+}
+
+// publishCronWebhookEvent delivers a finished cron execution, whose event is
+// "succeeded", "failed" or "recovered"
+func publishCronWebhookEvent(job cron.CronJob, event string, result cron.CronExecutionResult) {
+	publishWebhookEvent("cron."+event, cron.NewNotificationPayload(job, event, result))
+}
+
+// forwardSessionWebhooks delivers session events to the webhooks until the
+// bus subscription is closed
+func forwardSessionWebhooks(bus *terminal.EventBus, dispatcher *webhook.Dispatcher) {
+	events, unsubscribe := bus.Subscribe()
+	defer unsubscribe()
+
+	for event := range events {
+		if name, ok := sessionWebhookEvents[event.Type]; ok {
+			dispatcher.Publish(name, event)
+		}
+	}
+}
+
+// handleWebhooks handles GET (list) and POST (create) /api/webhooks. The
+// secret is generated when omitted and only returned by POST.
+func handleWebhooks(w http.ResponseWriter, r *http.Request) {
+	if webhooks == nil {
+		writeError(w, http.StatusServiceUnavailable, errCodeFeatureUnavailable, "Webhooks are unavailable")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		resp := listWebhooksResponse{Webhooks: []webhook.Webhook{}}
+		for _, hook := range webhooks.Store().List() {
+			resp.Webhooks = append(resp.Webhooks, hook.Redacted())
+		}
+		writeWebhookJSON(w, http.StatusOK, resp)
+
+	case http.MethodPost:
+		var hook webhook.Webhook
+		if err := json.NewDecoder(r.Body).Decode(&hook); err != nil {
+			log.Printf("Error decoding request: %v", err)
+			writeError(w, http.StatusBadRequest, errCodeInvalidJSON, "Bad request")
+			return
+		}
+		if hook.Secret == "" {
+			secret, err := webhook.NewSecret()
+			if err != nil {
+				log.Printf("Error generating webhook secret: %v", err)
+				writeError(w, http.StatusInternalServerError, errCodeInternal, "Failed to save webhook")
+				return
+			}
+			hook.Secret = secret
+		}
+		if err := hook.Validate(); err != nil {
+			writeError(w, http.StatusBadRequest, errCodeInvalidRequest, err.Error())
+			return
+		}
+		hook.ID = uuid.New().String()
+		hook.CreatedAt = time.Now().Unix()
+		if err := webhooks.Store().Add(hook); err != nil {
+			log.Printf("Error saving webhook: %v", err)
+			writeError(w, http.StatusInternalServerError, errCodeInternal, "Failed to save webhook")
+			return
+		}
+		log.Printf("Webhook %s created for %s", hook.ID, hook.URL)
+		writeWebhookJSON(w, http.StatusCreated, hook)
+
+	default:
+		writeError(w, http.StatusMethodNotAllowed, errCodeMethodNotAllowed, "Method not allowed")
+	}
+}
+
+// handleWebhookByID handles GET, PUT and DELETE /api/webhooks/:id. Secrets
+// are write-only: responses never include them.
+func handleWebhookByID(w http.ResponseWriter, r *http.Request) {
+	if webhooks == nil {
+		writeError(w, http.StatusServiceUnavailable, errCodeFeatureUnavailable, "Webhooks are unavailable")
+		return
+	}
+	id := strings.TrimPrefix(r.URL.Path, "/api/webhooks/")
+	if id == "" || strings.Contains(id, "/") {
+		writeError(w, http.StatusBadRequest, errCodeInvalidRequest, "Webhook ID is required")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		hook, err := webhooks.Store().Get(id)
+		if err != nil {
+			writeWebhookError(w, err)
+			return
+		}
+		writeWebhookJSON(w, http.StatusOK, hook.Redacted())
+
+	case http.MethodPut:
+		var req updateWebhookRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			log.Printf("Error decoding request: %v", err)
+			writeError(w, http.StatusBadRequest, errCodeInvalidJSON, "Bad request")
+			return
+		}
+		hook, err := webhooks.Store().Get(id)
+		if err != nil {
+			writeWebhookError(w, err)
+			return
+		}
+		if req.URL != nil {
+			hook.URL = *req.URL
+		}
+		if req.Secret != nil {
+			hook.Secret = *req.Secret
+		}
+		if req.Events != nil {
+			hook.Events = *req.Events
+		}
+		if req.Description != nil {
+			hook.Description = *req.Description
+		}
+		if req.Disabled != nil {
+			hook.Disabled = *req.Disabled
+		}
+		if err := hook.Validate(); err != nil {
+			writeError(w, http.StatusBadRequest, errCodeInvalidRequest, err.Error())
+			return
+		}
+		if err := webhooks.Store().Update(hook); err != nil {
+			log.Printf("Error updating webhook: %v", err)
+			writeWebhookError(w, err)
+			return
+		}
+		log.Printf("Webhook %s updated", id)
+		writeWebhookJSON(w, http.StatusOK, hook.Redacted())
+
+	case http.MethodDelete:
+		if err := webhooks.Store().Delete(id); err != nil {
+			log.Printf("Error deleting webhook: %v", err)
+			writeWebhookError(w, err)
+			return
+		}
+		log.Printf("Webhook %s deleted", id)
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		writeError(w, http.StatusMethodNotAllowed, errCodeMethodNotAllowed, "Method not allowed")
+	}
+}
+
+// writeWebhookError maps webhook store errors to status codes. Requests are
+// validated beforehand, so other errors are storage failures.
+func writeWebhookError(w http.ResponseWriter, err error) {
+	if errors.Is(err, webhook.ErrWebhookNotFound) {
+		writeError(w, http.StatusNotFound, errCodeWebhookNotFound, "Webhook not found")
+		return
+	}
+	writeError(w, http.StatusInternalServerError, errCodeInternal, "Failed to save webhook")
+}
+
+func writeWebhookJSON(w http.ResponseWriter, statusCode int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		log.Printf("Error encoding webhook response: %v", err)
+	}
+}
@@ -0,0 +1,172 @@
+package server
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/iwanhae/terminal-hub/terminal"
+	"github.com/iwanhae/terminal-hub/webhook"
+)
+
+func openTestWebhooks(t *testing.T) {
+	t.Helper()
+	store, err := webhook.OpenStore(filepath.Join(t.TempDir(), "webhooks.json"))
+	if err != nil {
+		t.Fatalf("failed to open store: %v", err)
+	}
+	webhooks = webhook.NewDispatcher(store)
+	t.Cleanup(func() { webhooks = nil })
+}
+
+func TestWebhookCRUD(t *testing.T) {
+	openTestWebhooks(t)
+
+	rec := httptest.NewRecorder()
+	handleWebhooks(rec, httptest.NewRequest(http.MethodPost, "/api/webhooks", strings.NewReader(`{"url":"https://example.com/hook","events":["session.*"]}`)))
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("create: expected status 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var created webhook.Webhook
+	if err := json.Unmarshal(rec.Body.Bytes(), &created); err != nil || created.ID == "" {
+		t.Fatalf("create: bad response %q: %v", rec.Body.String(), err)
+	}
+	if len(created.Secret) != 64 {
+		t.Errorf("expected a generated secret to be returned once, got %q", created.Secret)
+	}
+
+	rec = httptest.NewRecorder()
+	handleWebhooks(rec, httptest.NewRequest(http.MethodPost, "/api/webhooks", strings.NewReader(`{"url":"https://example.com/hook","events":["reboot"]}`)))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("unknown event: expected status 400, got %d", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	handleWebhooks(rec, httptest.NewRequest(http.MethodGet, "/api/webhooks", nil))
+	if rec.Code != http.StatusOK || strings.Contains(rec.Body.String(), created.Secret) {
+		t.Fatalf("list: expected redacted webhooks, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var list listWebhooksResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &list); err != nil || len(list.Webhooks) != 1 {
+		t.Fatalf("list: bad response %q: %v", rec.Body.String(), err)
+	}
+
+	rec = httptest.NewRecorder()
+	handleWebhookByID(rec, httptest.NewRequest(http.MethodPut, "/api/webhooks/"+created.ID, strings.NewReader(`{"events":["cron.failed"],"disabled":true}`)))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("update: expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	stored, err := webhooks.Store().Get(created.ID)
+	if err != nil || !stored.Disabled || len(stored.Events) != 1 || stored.Events[0] != "cron.failed" || stored.Secret != created.Secret {
+		t.Fatalf("update: unexpected stored webhook %+v: %v", stored, err)
+	}
+	rec = httptest.NewRecorder()
+	handleWebhookByID(rec, httptest.NewRequest(http.MethodPut, "/api/webhooks/"+created.ID, strings.NewReader(`{"url":"ftp://example.com"}`)))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("invalid update: expected status 400, got %d", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	handleWebhookByID(rec, httptest.NewRequest(http.MethodGet, "/api/webhooks/"+created.ID, nil))
+	if rec.Code != http.StatusOK || strings.Contains(rec.Body.String(), created.Secret) {
+		t.Fatalf("get: expected the redacted webhook, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	handleWebhookByID(rec, httptest.NewRequest(http.MethodDelete, "/api/webhooks/"+created.ID, nil))
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("delete: expected status 204, got %d", rec.Code)
+	}
+	rec = httptest.NewRecorder()
+	handleWebhookByID(rec, httptest.NewRequest(http.MethodDelete, "/api/webhooks/"+created.ID, nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("delete again: expected status 404, got %d", rec.Code)
+	}
+}
+
+func TestWebhooksUnavailable(t *testing.T) {
+	webhooks = nil
+	rec := httptest.NewRecorder()
+	handleWebhooks(rec, httptest.NewRequest(http.MethodGet, "/api/webhooks", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected status 503, got %d", rec.Code)
+	}
+}
+
+func TestForwardSessionWebhooks(t *testing.T) {
+	openTestWebhooks(t)
+	type delivery struct {
+		event string
+		body  []byte
+		valid bool
+	}
+	received := make(chan delivery, 8)
+	endpoint := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		received <- delivery{
+			event: r.Header.Get(webhook.HeaderEvent),
+			body:  body,
+			valid: r.Header.Get(webhook.HeaderSignature) == webhook.Sign("shh", body),
+		}
+	}))
+	defer endpoint.Close()
+	if err := webhooks.Store().Add(webhook.Webhook{ID: "sessions", URL: endpoint.URL, Secret: "shh", Events: []string{"session.*"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	sessionManager = terminal.NewSessionManager()
+	t.Cleanup(func() { _ = sessionManager.CloseAll() })
+	go forwardSessionWebhooks(sessionManager.Events(), webhooks)
+	time.Sleep(50 * time.Millisecond) // let the forwarder subscribe
+
+	ptyReader, ptyWriter, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = ptyWriter.Close()
+		_ = ptyReader.Close()
+	})
+	if _, err := sessionManager.CreateSession(terminal.SessionConfig{
+		ID:         "hooked",
+		Name:       "build",
+		Backend:    terminal.SessionBackendPTY,
+		PTYService: &pipePTYService{reader: ptyReader},
+	}); err != nil {
+		t.Fatalf("failed to create session: %v", err)
+	}
+	if err := sessionManager.Remove("hooked"); err != nil {
+		t.Fatalf("failed to remove session: %v", err)
+	}
+
+	// Deliveries run concurrently, so they may arrive in any order
+	seen := map[string]bool{}
+	for len(seen) < 2 {
+		select {
+		case got := <-received:
+			if !got.valid {
+				t.Fatalf("expected a signed delivery, got %s", got.body)
+			}
+			seen[got.event] = true
+			var payload webhook.Payload
+			if err := json.Unmarshal(got.body, &payload); err != nil {
+				t.Fatalf("invalid payload %s: %v", got.body, err)
+			}
+			data, _ := payload.Data.(map[string]any)
+			if data["session_id"] != "hooked" || data["session_name"] != "build" {
+				t.Errorf("unexpected payload data %s", got.body)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out waiting for deliveries, got %v", seen)
+		}
+	}
+	if !seen[webhook.EventSessionCreated] || !seen[webhook.EventSessionClosed] {
+		t.Errorf("expected created and closed deliveries, got %v", seen)
+	}
+}
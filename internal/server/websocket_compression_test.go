@@ -0,0 +1,44 @@
+package server
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+func TestWebSocketNegotiatesCompression(t *testing.T) {
+	server, sessionID, ptyWriter := createWebSocketHeartbeatTestServer(t)
+
+	dialer := websocket.Dialer{EnableCompression: true}
+	conn, resp, err := dialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/ws/"+sessionID, nil)
+	if err != nil {
+		t.Fatalf("failed to dial websocket: %v", err)
+	}
+	t.Cleanup(func() { _ = conn.Close() })
+
+	if ext := resp.Header.Get("Sec-WebSocket-Extensions"); !strings.Contains(ext, "permessage-deflate") {
+		t.Fatalf("expected permessage-deflate to be negotiated, got %q", ext)
+	}
+
+	output := strings.Repeat("compressible terminal output ", 100)
+	if _, err := ptyWriter.Write([]byte(output)); err != nil {
+		t.Fatalf("failed to write PTY output: %v", err)
+	}
+
+	var received strings.Builder
+	_ = conn.SetReadDeadline(time.Now().Add(3 * time.Second))
+	for received.Len() < len(output) {
+		messageType, data, err := conn.ReadMessage()
+		if err != nil {
+			t.Fatalf("failed to read output: %v", err)
+		}
+		if messageType == websocket.BinaryMessage {
+			received.Write(data)
+		}
+	}
+	if received.String() != output {
+		t.Fatalf("unexpected output %q", received.String())
+	}
+}
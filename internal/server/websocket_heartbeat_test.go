@@ -162,3 +162,44 @@ func TestWebSocketHeartbeatKeepsResponsiveClientConnected(t *testing.T) {
 	default:
 	}
 }
+
+func TestConfigureWebSocketKeepaliveFromEnv(t *testing.T) {
+	configureHeartbeatForTest(t, 5*time.Second, 60*time.Second, 25*time.Second)
+
+	t.Setenv("TERMINAL_HUB_WS_PING_INTERVAL", "10s")
+	t.Setenv("TERMINAL_HUB_WS_PONG_TIMEOUT", "30s")
+	if err := configureWebSocketKeepaliveFromEnv(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if websocketPingPeriod != 10*time.Second || websocketPongWait != 30*time.Second {
+		t.Fatalf("expected 10s/30s, got %s/%s", websocketPingPeriod, websocketPongWait)
+	}
+
+	for _, tc := range []struct{ ping, pong string }{
+		{"30s", "30s"},
+		{"soon", "30s"},
+		{"10s", "-1s"},
+	} {
+		t.Setenv("TERMINAL_HUB_WS_PING_INTERVAL", tc.ping)
+		t.Setenv("TERMINAL_HUB_WS_PONG_TIMEOUT", tc.pong)
+		if err := configureWebSocketKeepaliveFromEnv(); err == nil {
+			t.Fatalf("expected error for ping=%s pong=%s", tc.ping, tc.pong)
+		}
+		if websocketPingPeriod != 10*time.Second || websocketPongWait != 30*time.Second {
+			t.Fatalf("invalid settings must not be applied, got %s/%s", websocketPingPeriod, websocketPongWait)
+		}
+	}
+}
+
+func TestWebSocketRejectsInvalidSince(t *testing.T) {
+	server, sessionID, _ := createWebSocketHeartbeatTestServer(t)
+
+	wsURL := "ws" + strings.TrimPrefix(server.URL, "http") + "/ws/" + sessionID + "?since=-1"
+	_, resp, err := websocket.DefaultDialer.Dial(wsURL, nil)
+	if err == nil {
+		t.Fatal("expected dial to fail")
+	}
+	if resp == nil || resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %v", resp)
+	}
+}
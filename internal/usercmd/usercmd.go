@@ -0,0 +1,236 @@
+// Package usercmd implements "terminal-hub user", which manages the users in
+// the credentials file without a running server, so provisioning tools can
+// set up logins without passing plaintext passwords through environment
+// variables.
+package usercmd
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/iwanhae/terminal-hub/auth"
+)
+
+// command is a "terminal-hub user" subcommand
+type command struct {
+	usage   string // arguments, after the command name
+	summary string
+	run     func(c *cli, args []string) error
+}
+
+var commands = map[string]command{
+	"add":    {"[-password-file FILE] [-must-change] (-password-stdin | -password-hash HASH) USERNAME", "Add a user", (*cli).add},
+	"passwd": {"[-password-file FILE] [-must-change] (-password-stdin | -password-hash HASH) USERNAME", "Change a user's password", (*cli).passwd},
+	"remove": {"[-password-file FILE] USERNAME", "Remove a user", (*cli).remove},
+	"list":   {"[-password-file FILE]", "List the users", (*cli).list},
+}
+
+// usageError reports arguments that do not fit the command's usage
+type usageError struct{}
+
+func (usageError) Error() string { return "invalid arguments" }
+
+// cli runs user commands, reading passwords from in
+type cli struct {
+	in  io.Reader
+	out io.Writer
+}
+
+// Run executes the arguments after "terminal-hub user" and returns the exit
+// status
+func Run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		printUsage(stderr)
+		return 2
+	}
+	name := args[0]
+	cmd, ok := commands[name]
+	if !ok {
+		fmt.Fprintf(stderr, "terminal-hub user: unknown command %q\n", name)
+		printUsage(stderr)
+		return 2
+	}
+
+	c := &cli{in: stdin, out: stdout}
+	if err := cmd.run(c, args[1:]); err != nil {
+		if errors.As(err, new(usageError)) {
+			fmt.Fprintf(stderr, "usage: terminal-hub user %s %s\n", name, cmd.usage)
+			return 2
+		}
+		fmt.Fprintf(stderr, "terminal-hub user %s: %v\n", name, err)
+		return 1
+	}
+	return 0
+}
+
+func printUsage(w io.Writer) {
+	fmt.Fprintln(w, "usage: terminal-hub user COMMAND [ARGS]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Commands:")
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(w, "  %-7s %s\n", name, commands[name].summary)
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "The password file defaults to TERMINAL_HUB_PASSWORD_FILE or ~/.terminal-hub/credentials.json.")
+	fmt.Fprintln(w, "Passwords read with -password-stdin must meet the TERMINAL_HUB_PASSWORD_* policy.")
+	fmt.Fprintln(w, "Restart the server for changes to take effect.")
+}
+
+// newFlags returns a flag set with -password-file
+func newFlags(name string) (*flag.FlagSet, *string) {
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	file := flags.String("password-file", "", "path to the password file")
+	return flags, file
+}
+
+// parseArgs parses args and checks that they hold exactly one username
+func parseArgs(flags *flag.FlagSet, args []string) (string, error) {
+	if err := flags.Parse(args); err != nil || flags.NArg() != 1 {
+		return "", usageError{}
+	}
+	return flags.Arg(0), nil
+}
+
+// passwordFilePath returns the -password-file flag, TERMINAL_HUB_PASSWORD_FILE
+// or the default location, as the server looks them up
+func passwordFilePath(flagValue string) (string, error) {
+	if flagValue != "" {
+		return flagValue, nil
+	}
+	if path := os.Getenv("TERMINAL_HUB_PASSWORD_FILE"); path != "" {
+		return path, nil
+	}
+	return auth.DefaultPasswordFilePath()
+}
+
+// passwordArgs are the parsed arguments of add and passwd
+type passwordArgs struct {
+	path       string // password file
+	username   string
+	hash       string // of the new password
+	mustChange bool   // the user has to change the password after logging in
+}
+
+// parsePasswordArgs parses the arguments of add and passwd. A password read
+// from standard input must meet the password policy; a hash cannot be
+// checked.
+func (c *cli) parsePasswordArgs(name string, args []string) (passwordArgs, error) {
+	var parsed passwordArgs
+	flags, file := newFlags(name)
+	fromStdin := flags.Bool("password-stdin", false, "read the password from the first line of standard input")
+	fromHash := flags.String("password-hash", "", "Argon2id or bcrypt hash of the password")
+	flags.BoolVar(&parsed.mustChange, "must-change", false, "make the user change the password after logging in")
+	var err error
+	if parsed.username, err = parseArgs(flags, args); err != nil {
+		return parsed, err
+	}
+	if *fromStdin == (*fromHash != "") {
+		return parsed, usageError{}
+	}
+	if parsed.path, err = passwordFilePath(*file); err != nil {
+		return parsed, err
+	}
+
+	if *fromHash != "" {
+		parsed.hash = *fromHash
+		return parsed, nil
+	}
+	password, err := readPassword(c.in)
+	if err != nil {
+		return parsed, err
+	}
+	policy, err := auth.GetPasswordPolicyFromEnv()
+	if err != nil {
+		return parsed, err
+	}
+	if err := policy.Validate(password); err != nil {
+		return parsed, err
+	}
+	parsed.hash, err = auth.HashPassword(password)
+	return parsed, err
+}
+
+// readPassword reads a password from the first line of r
+func readPassword(r io.Reader) (string, error) {
+	line, err := bufio.NewReader(r).ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return "", fmt.Errorf("failed to read the password: %w", err)
+	}
+	password := strings.TrimRight(line, "\r\n")
+	if password == "" {
+		return "", errors.New("the password is empty")
+	}
+	return password, nil
+}
+
+func (c *cli) add(args []string) error {
+	parsed, err := c.parsePasswordArgs("add", args)
+	if err != nil {
+		return err
+	}
+	if err := auth.AddUser(parsed.path, parsed.username, parsed.hash, parsed.mustChange); err != nil {
+		return err
+	}
+	fmt.Fprintf(c.out, "Added user %s to %s\n", parsed.username, parsed.path)
+	return nil
+}
+
+func (c *cli) passwd(args []string) error {
+	parsed, err := c.parsePasswordArgs("passwd", args)
+	if err != nil {
+		return err
+	}
+	if err := auth.SetUserPassword(parsed.path, parsed.username, parsed.hash, parsed.mustChange); err != nil {
+		return err
+	}
+	fmt.Fprintf(c.out, "Changed the password of %s in %s\n", parsed.username, parsed.path)
+	return nil
+}
+
+func (c *cli) remove(args []string) error {
+	flags, file := newFlags("remove")
+	username, err := parseArgs(flags, args)
+	if err != nil {
+		return err
+	}
+	path, err := passwordFilePath(*file)
+	if err != nil {
+		return err
+	}
+	if err := auth.RemoveUser(path, username); err != nil {
+		return err
+	}
+	fmt.Fprintf(c.out, "Removed user %s from %s\n", username, path)
+	return nil
+}
+
+func (c *cli) list(args []string) error {
+	flags, file := newFlags("list")
+	if err := flags.Parse(args); err != nil || flags.NArg() != 0 {
+		return usageError{}
+	}
+	path, err := passwordFilePath(*file)
+	if err != nil {
+		return err
+	}
+	users, err := auth.ListUsers(path)
+	if err != nil {
+		return err
+	}
+	for _, user := range users {
+		fmt.Fprintln(c.out, user.Username)
+	}
+	return nil
+}
@@ -0,0 +1,146 @@
+package usercmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/iwanhae/terminal-hub/auth"
+)
+
+// runUser runs "terminal-hub user" with args and stdin, returning the exit
+// status and output
+func runUser(t *testing.T, stdin string, args ...string) (int, string, string) {
+	t.Helper()
+	var stdout, stderr bytes.Buffer
+	code := Run(args, strings.NewReader(stdin), &stdout, &stderr)
+	return code, stdout.String(), stderr.String()
+}
+
+func TestUserCommands(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "credentials.json")
+	t.Setenv("TERMINAL_HUB_PASSWORD_FILE", path)
+
+	if code, _, stderr := runUser(t, "s3cret-pass\n", "add", "-password-stdin", "alice"); code != 0 {
+		t.Fatalf("add alice: exit %d: %s", code, stderr)
+	}
+	bobHash, err := auth.HashPassword("hunter22")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if code, _, stderr := runUser(t, "", "add", "-password-hash", bobHash, "bob"); code != 0 {
+		t.Fatalf("add bob: exit %d: %s", code, stderr)
+	}
+	if code, _, _ := runUser(t, "again-pass\n", "add", "-password-stdin", "alice"); code != 1 {
+		t.Errorf("expected adding an existing user to fail, got exit %d", code)
+	}
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0o600 {
+		t.Errorf("expected a 0600 password file, got %v, %v", info, err)
+	}
+
+	users, err := auth.LoadUsers(path)
+	if err != nil {
+		t.Fatalf("failed to load users: %v", err)
+	}
+	sessions := auth.NewSessionManagerFromUsers(users, 0)
+	if !sessions.ValidateCredentials("alice", "s3cret-pass") || !sessions.ValidateCredentials("bob", "hunter22") {
+		t.Error("expected both users to log in with their passwords")
+	}
+	if sessions.ValidateCredentials("alice", "hunter22") || sessions.ValidateCredentials("carol", "s3cret-pass") {
+		t.Error("expected wrong passwords and unknown users to be rejected")
+	}
+
+	if code, _, stderr := runUser(t, "n3w-password\n", "passwd", "-password-stdin", "alice"); code != 0 {
+		t.Fatalf("passwd alice: exit %d: %s", code, stderr)
+	}
+	if code, _, _ := runUser(t, "x-password\n", "passwd", "-password-stdin", "carol"); code != 1 {
+		t.Errorf("expected changing the password of an unknown user to fail, got exit %d", code)
+	}
+	if code, _, stderr := runUser(t, "", "remove", "bob"); code != 0 {
+		t.Fatalf("remove bob: exit %d: %s", code, stderr)
+	}
+	if code, _, stderr := runUser(t, "", "remove", "alice"); code != 1 || !strings.Contains(stderr, "last user") {
+		t.Errorf("expected removing the last user to fail, got exit %d: %s", code, stderr)
+	}
+
+	code, stdout, _ := runUser(t, "", "list")
+	if code != 0 || stdout != "alice\n" {
+		t.Errorf("expected only alice to be left, got exit %d: %q", code, stdout)
+	}
+	users, _ = auth.LoadUsers(path)
+	if !auth.NewSessionManagerFromUsers(users, 0).ValidateCredentials("alice", "n3w-password") {
+		t.Error("expected alice to log in with the new password")
+	}
+}
+
+func TestUserCommandPasswordPolicy(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "credentials.json")
+	t.Setenv("TERMINAL_HUB_PASSWORD_FILE", path)
+	t.Setenv("TERMINAL_HUB_PASSWORD_MIN_LENGTH", "10")
+	t.Setenv("TERMINAL_HUB_PASSWORD_MIN_CLASSES", "3")
+
+	if code, _, stderr := runUser(t, "Short1!\n", "add", "-password-stdin", "alice"); code != 1 || !strings.Contains(stderr, "at least 10 characters") {
+		t.Errorf("expected a short password to be rejected, got exit %d: %s", code, stderr)
+	}
+	if code, _, stderr := runUser(t, "lowercaseonly\n", "add", "-password-stdin", "alice"); code != 1 || !strings.Contains(stderr, "at least 3 of") {
+		t.Errorf("expected a password of one class to be rejected, got exit %d: %s", code, stderr)
+	}
+	if code, _, stderr := runUser(t, "Temporary-123\n", "add", "-must-change", "-password-stdin", "alice"); code != 0 {
+		t.Fatalf("add alice: exit %d: %s", code, stderr)
+	}
+
+	users, err := auth.LoadUsers(path)
+	if err != nil || len(users) != 1 || !users[0].MustChangePassword {
+		t.Fatalf("expected alice to have to change the password, got %+v, %v", users, err)
+	}
+	sessions := auth.NewSessionManagerFromUsers(users, 0)
+	if !sessions.MustChangePassword("alice") {
+		t.Error("expected the session manager to require a password change")
+	}
+
+	t.Setenv("TERMINAL_HUB_PASSWORD_MIN_CLASSES", "5")
+	if code, _, _ := runUser(t, "Temporary-123\n", "passwd", "-password-stdin", "alice"); code != 1 {
+		t.Errorf("expected an invalid policy to fail, got exit %d", code)
+	}
+}
+
+func TestUserCommandUsage(t *testing.T) {
+	t.Setenv("TERMINAL_HUB_PASSWORD_FILE", filepath.Join(t.TempDir(), "credentials.json"))
+	for _, args := range [][]string{
+		{},
+		{"rename", "alice"},
+		{"add", "alice"},
+		{"add", "-password-stdin", "-password-hash", "$2a$10$x", "alice"},
+		{"remove"},
+	} {
+		if code, _, _ := runUser(t, "pw\n", args...); code != 2 {
+			t.Errorf("%v: expected exit 2, got %d", args, code)
+		}
+	}
+	if code, _, _ := runUser(t, "", "add", "-password-hash", "not-bcrypt", "alice"); code != 1 {
+		t.Errorf("expected a hash that is neither Argon2id nor bcrypt to be rejected, got exit %d", code)
+	}
+	if code, _, _ := runUser(t, "long-enough-pw\n", "add", "-password-stdin", "has space"); code != 1 {
+		t.Errorf("expected a username with a space to be rejected, got exit %d", code)
+	}
+}
+
+func TestLoadUsersMigratesVersion1(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "credentials.json")
+	if err := os.WriteFile(path, []byte(`{"username": "admin", "password": "legacy", "version": 1}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	users, err := auth.LoadUsers(path)
+	if err != nil || len(users) != 1 || users[0].Username != "admin" {
+		t.Fatalf("expected the legacy user, got %+v, %v", users, err)
+	}
+	data, _ := os.ReadFile(path)
+	if strings.Contains(string(data), "legacy") || !strings.Contains(string(data), `"users"`) {
+		t.Errorf("expected the file to be saved hashed in the current version, got %s", data)
+	}
+	if !auth.NewSessionManagerFromUsers(users, 0).ValidateCredentials("admin", "legacy") {
+		t.Error("expected the migrated password to work")
+	}
+}
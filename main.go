@@ -1,9 +1,18 @@
 package main
 
-import "github.com/iwanhae/terminal-hub/internal/server"
+import (
+	"os"
+
+	"github.com/iwanhae/terminal-hub/internal/server"
+	"github.com/iwanhae/terminal-hub/internal/usercmd"
+)
 
 var Version string // Set via ldflags during build
 
 func main() {
+	// "terminal-hub user ..." manages the credentials file instead of serving
+	if len(os.Args) > 1 && os.Args[1] == "user" {
+		os.Exit(usercmd.Run(os.Args[2:], os.Stdin, os.Stdout, os.Stderr))
+	}
 	server.Run()
 }
@@ -0,0 +1,310 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Channel types
+const (
+	ChannelNtfy     = TypeNtfy   // ntfy topic
+	ChannelSlack    = "slack"    // Slack incoming webhook
+	ChannelDiscord  = "discord"  // Discord webhook
+	ChannelTelegram = "telegram" // Telegram bot
+	ChannelEmail    = "email"    // email through the TERMINAL_HUB_SMTP_* server
+)
+
+// Message length limits of the chat services
+const (
+	discordMaxContent  = 2000
+	telegramMaxMessage = 4096
+)
+
+// defaultTelegramAPIURL is the Bot API base URL
+const defaultTelegramAPIURL = "https://api.telegram.org"
+
+// ChannelConfig configures one named channel in the channels file. Which
+// fields are used depends on the type.
+type ChannelConfig struct {
+	Type       string   `json:"type"`                  // "ntfy", "slack", "discord", "telegram" or "email"
+	URL        string   `json:"url,omitempty"`         // ntfy: topic URL
+	Token      string   `json:"token,omitempty"`       // ntfy: optional access token
+	WebhookURL string   `json:"webhook_url,omitempty"` // slack, discord: incoming webhook URL
+	BotToken   string   `json:"bot_token,omitempty"`   // telegram: bot token from @BotFather
+	ChatID     string   `json:"chat_id,omitempty"`     // telegram: chat to post to
+	APIURL     string   `json:"api_url,omitempty"`     // telegram: Bot API base URL, defaults to api.telegram.org
+	To         []string `json:"to,omitempty"`          // email: recipients, sent via the TERMINAL_HUB_SMTP_* settings
+}
+
+// ChannelFactory builds a channel from its configuration
+type ChannelFactory func(config ChannelConfig, client *http.Client) (Channel, error)
+
+var (
+	channelTypesMu sync.RWMutex
+	channelTypes   = map[string]ChannelFactory{
+		ChannelNtfy:     newNtfyChannel,
+		ChannelSlack:    newSlackChannel,
+		ChannelDiscord:  newDiscordChannel,
+		ChannelTelegram: newTelegramChannel,
+		ChannelEmail:    newEmailChannel,
+	}
+)
+
+// RegisterChannelType makes a channel type available to the channels file,
+// replacing any factory registered under the same name
+func RegisterChannelType(channelType string, factory ChannelFactory) {
+	channelTypesMu.Lock()
+	defer channelTypesMu.Unlock()
+	channelTypes[channelType] = factory
+}
+
+// NewChannel builds a channel of a registered type
+func NewChannel(config ChannelConfig, client *http.Client) (Channel, error) {
+	channelTypesMu.RLock()
+	factory, ok := channelTypes[config.Type]
+	channelTypesMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown channel type %q", config.Type)
+	}
+	return factory(config, client)
+}
+
+// Channels are the named channels that cron jobs and watch rules can send
+// notifications to. A nil *Channels has no channels.
+type Channels struct {
+	channels map[string]Channel
+}
+
+// NewChannels builds the named channels
+func NewChannels(configs map[string]ChannelConfig) (*Channels, error) {
+	client := &http.Client{Timeout: sendTimeout}
+	channels := &Channels{channels: make(map[string]Channel, len(configs))}
+	for name, config := range configs {
+		channel, err := NewChannel(config, client)
+		if err != nil {
+			return nil, fmt.Errorf("channel %q: %w", name, err)
+		}
+		channels.channels[name] = channel
+	}
+	return channels, nil
+}
+
+// LoadChannels reads the channels file at path, a JSON object of channel
+// configurations by name. A missing file means no channels.
+func LoadChannels(path string) (*Channels, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &Channels{}, nil
+		}
+		return nil, fmt.Errorf("failed to read notification channels: %w", err)
+	}
+	var configs map[string]ChannelConfig
+	if err := json.Unmarshal(data, &configs); err != nil {
+		return nil, fmt.Errorf("failed to parse notification channels: %w", err)
+	}
+	return NewChannels(configs)
+}
+
+// Names returns the channel names in sorted order
+func (c *Channels) Names() []string {
+	if c == nil {
+		return nil
+	}
+	names := make([]string, 0, len(c.channels))
+	for name := range c.channels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Check returns an error naming the first unknown channel
+func (c *Channels) Check(names []string) error {
+	for _, name := range names {
+		if c == nil || c.channels[name] == nil {
+			return fmt.Errorf("unknown notification channel %q", name)
+		}
+	}
+	return nil
+}
+
+// Send delivers n to the named channels in the background; failures and
+// unknown names are logged
+func (c *Channels) Send(names []string, n Notification) {
+	for _, name := range names {
+		var channel Channel
+		if c != nil {
+			channel = c.channels[name]
+		}
+		if channel == nil {
+			log.Printf("[Notify] Unknown notification channel %q", name)
+			continue
+		}
+		go func(name string, channel Channel) {
+			ctx, cancel := context.WithTimeout(context.Background(), sendTimeout)
+			defer cancel()
+			if err := channel.Send(ctx, n); err != nil {
+				log.Printf("[Notify] Failed to deliver %s notification to channel %s: %v", n.Event, name, err)
+			}
+		}(name, channel)
+	}
+}
+
+// GetChannelsPathFromEnv returns TERMINAL_HUB_NOTIFY_CHANNELS, defaulting to
+// ~/.terminal-hub/notify_channels.json
+func GetChannelsPathFromEnv() string {
+	if path := os.Getenv("TERMINAL_HUB_NOTIFY_CHANNELS"); path != "" {
+		return path
+	}
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "notify_channels.json"
+	}
+	return filepath.Join(homeDir, ".terminal-hub", "notify_channels.json")
+}
+
+// validateWebhookURL checks that raw is an absolute http(s) URL
+func validateWebhookURL(raw string) error {
+	parsed, err := url.Parse(raw)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return fmt.Errorf("invalid webhook URL %q: must be an absolute http(s) URL", raw)
+	}
+	return nil
+}
+
+// postJSON posts body as JSON and checks for a 2xx response
+func postJSON(ctx context.Context, client *http.Client, target string, body any) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// truncate shortens s to at most max bytes, marking the cut
+func truncate(s string, max int) string {
+	if len(s) <= max {
+		return s
+	}
+	return s[:max-3] + "..."
+}
+
+func newNtfyChannel(config ChannelConfig, client *http.Client) (Channel, error) {
+	if err := validateTopicURL(config.URL); err != nil {
+		return nil, err
+	}
+	return NtfyChannel{URL: config.URL, Token: config.Token, Client: client}, nil
+}
+
+// SlackChannel posts notifications to a Slack incoming webhook
+type SlackChannel struct {
+	WebhookURL string
+	Client     *http.Client
+}
+
+func newSlackChannel(config ChannelConfig, client *http.Client) (Channel, error) {
+	if err := validateWebhookURL(config.WebhookURL); err != nil {
+		return nil, err
+	}
+	return SlackChannel{WebhookURL: config.WebhookURL, Client: client}, nil
+}
+
+// Send posts the title in bold followed by the message
+func (c SlackChannel) Send(ctx context.Context, n Notification) error {
+	text := n.Message
+	if n.Title != "" {
+		text = "*" + n.Title + "*\n" + n.Message
+	}
+	return postJSON(ctx, c.Client, c.WebhookURL, map[string]string{"text": text})
+}
+
+// DiscordChannel posts notifications to a Discord webhook
+type DiscordChannel struct {
+	WebhookURL string
+	Client     *http.Client
+}
+
+func newDiscordChannel(config ChannelConfig, client *http.Client) (Channel, error) {
+	if err := validateWebhookURL(config.WebhookURL); err != nil {
+		return nil, err
+	}
+	return DiscordChannel{WebhookURL: config.WebhookURL, Client: client}, nil
+}
+
+// Send posts the title in bold followed by the message
+func (c DiscordChannel) Send(ctx context.Context, n Notification) error {
+	content := n.Message
+	if n.Title != "" {
+		content = "**" + n.Title + "**\n" + n.Message
+	}
+	return postJSON(ctx, c.Client, c.WebhookURL, map[string]string{"content": truncate(content, discordMaxContent)})
+}
+
+// TelegramChannel sends notifications to a chat through a Telegram bot
+type TelegramChannel struct {
+	BotToken string
+	ChatID   string
+	APIURL   string // Bot API base URL
+	Client   *http.Client
+}
+
+func newTelegramChannel(config ChannelConfig, client *http.Client) (Channel, error) {
+	if config.BotToken == "" || config.ChatID == "" {
+		return nil, errors.New("bot_token and chat_id are required")
+	}
+	apiURL := config.APIURL
+	if apiURL == "" {
+		apiURL = defaultTelegramAPIURL
+	} else if err := validateWebhookURL(apiURL); err != nil {
+		return nil, err
+	}
+	return TelegramChannel{BotToken: config.BotToken, ChatID: config.ChatID, APIURL: strings.TrimSuffix(apiURL, "/"), Client: client}, nil
+}
+
+// Send calls the bot's sendMessage method with the title and message
+func (c TelegramChannel) Send(ctx context.Context, n Notification) error {
+	text := n.Message
+	if n.Title != "" {
+		text = n.Title + "\n\n" + n.Message
+	}
+	target := c.APIURL + "/bot" + c.BotToken + "/sendMessage"
+	err := postJSON(ctx, c.Client, target, map[string]string{
+		"chat_id": c.ChatID,
+		"text":    truncate(text, telegramMaxMessage),
+	})
+	if err != nil {
+		// The request URL contains the bot token; keep it out of the logs
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			return urlErr.Err
+		}
+	}
+	return err
+}
@@ -0,0 +1,150 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+type postedJSON struct {
+	path string
+	body map[string]string
+}
+
+func newJSONServer(t *testing.T) (*httptest.Server, chan postedJSON) {
+	t.Helper()
+	posted := make(chan postedJSON, 8)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]string
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("bad JSON body: %v", err)
+		}
+		posted <- postedJSON{path: r.URL.Path, body: body}
+	}))
+	t.Cleanup(server.Close)
+	return server, posted
+}
+
+func TestChatChannelsFormatMessages(t *testing.T) {
+	server, posted := newJSONServer(t)
+	channels, err := NewChannels(map[string]ChannelConfig{
+		"slack":    {Type: ChannelSlack, WebhookURL: server.URL + "/slack"},
+		"discord":  {Type: ChannelDiscord, WebhookURL: server.URL + "/discord"},
+		"telegram": {Type: ChannelTelegram, BotToken: "123:abc", ChatID: "42", APIURL: server.URL},
+	})
+	if err != nil {
+		t.Fatalf("failed to build channels: %v", err)
+	}
+	if got := strings.Join(channels.Names(), ","); got != "discord,slack,telegram" {
+		t.Errorf("expected sorted names, got %q", got)
+	}
+
+	channels.Send([]string{"slack", "discord", "telegram"}, Notification{Title: "Build failed", Message: "exit 1"})
+
+	want := map[string]map[string]string{
+		"/slack":                  {"text": "*Build failed*\nexit 1"},
+		"/discord":                {"content": "**Build failed**\nexit 1"},
+		"/bot123:abc/sendMessage": {"chat_id": "42", "text": "Build failed\n\nexit 1"},
+	}
+	for range want {
+		select {
+		case got := <-posted:
+			expected, ok := want[got.path]
+			if !ok {
+				t.Fatalf("unexpected request to %s", got.path)
+			}
+			for key, value := range expected {
+				if got.body[key] != value {
+					t.Errorf("%s: expected %s=%q, got %q", got.path, key, value, got.body[key])
+				}
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for notifications")
+		}
+	}
+}
+
+func TestDiscordTruncatesLongMessages(t *testing.T) {
+	server, posted := newJSONServer(t)
+	channel := DiscordChannel{WebhookURL: server.URL, Client: http.DefaultClient}
+	if err := channel.Send(context.Background(), Notification{Message: strings.Repeat("x", 3000)}); err != nil {
+		t.Fatalf("send failed: %v", err)
+	}
+	if got := len((<-posted).body["content"]); got != discordMaxContent {
+		t.Errorf("expected content of %d bytes, got %d", discordMaxContent, got)
+	}
+}
+
+func TestLoadChannels(t *testing.T) {
+	dir := t.TempDir()
+
+	channels, err := LoadChannels(filepath.Join(dir, "missing.json"))
+	if err != nil || len(channels.Names()) != 0 {
+		t.Fatalf("missing file: expected no channels, got %v, %v", channels.Names(), err)
+	}
+
+	path := filepath.Join(dir, "channels.json")
+	_ = os.WriteFile(path, []byte(`{"ops":{"type":"slack","webhook_url":"https://hooks.slack.com/services/x"}}`), 0600)
+	channels, err = LoadChannels(path)
+	if err != nil {
+		t.Fatalf("failed to load channels: %v", err)
+	}
+	if err := channels.Check([]string{"ops"}); err != nil {
+		t.Errorf("expected ops to exist: %v", err)
+	}
+	if err := channels.Check([]string{"ops", "pager"}); err == nil {
+		t.Error("expected unknown channel to fail")
+	}
+
+	for _, bad := range []string{
+		`{"ops":{"type":"pager"}}`,
+		`{"ops":{"type":"discord","webhook_url":"not a url"}}`,
+		`{"ops":{"type":"telegram","chat_id":"42"}}`,
+	} {
+		_ = os.WriteFile(path, []byte(bad), 0600)
+		if _, err := LoadChannels(path); err == nil {
+			t.Errorf("expected %s to be rejected", bad)
+		}
+	}
+
+	var nilChannels *Channels
+	if err := nilChannels.Check([]string{"ops"}); err == nil {
+		t.Error("expected nil channels to have no channels")
+	}
+}
+
+type recordingChannel struct {
+	sent chan Notification
+}
+
+func (c recordingChannel) Send(ctx context.Context, n Notification) error {
+	c.sent <- n
+	return nil
+}
+
+func TestRegisterChannelType(t *testing.T) {
+	sent := make(chan Notification, 1)
+	RegisterChannelType("recording", func(config ChannelConfig, client *http.Client) (Channel, error) {
+		return recordingChannel{sent: sent}, nil
+	})
+
+	channels, err := NewChannels(map[string]ChannelConfig{"mine": {Type: "recording"}})
+	if err != nil {
+		t.Fatalf("failed to build channels: %v", err)
+	}
+	channels.Send([]string{"mine"}, Notification{Title: "hello"})
+	select {
+	case n := <-sent:
+		if n.Title != "hello" {
+			t.Errorf("unexpected notification %+v", n)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for the notification")
+	}
+}
@@ -0,0 +1,135 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/mail"
+	"net/smtp"
+	"os"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// SMTPConfig holds the SMTP settings used for email notifications
+type SMTPConfig struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	From     string
+}
+
+// IsConfigured returns true if enough settings are present to send email
+func (c SMTPConfig) IsConfigured() bool {
+	return c.Host != "" && c.From != ""
+}
+
+// GetSMTPConfigFromEnv reads SMTP settings from environment variables
+func GetSMTPConfigFromEnv() SMTPConfig {
+	config := SMTPConfig{
+		Host:     os.Getenv("TERMINAL_HUB_SMTP_HOST"),
+		Port:     587,
+		Username: os.Getenv("TERMINAL_HUB_SMTP_USERNAME"),
+		Password: os.Getenv("TERMINAL_HUB_SMTP_PASSWORD"),
+		From:     os.Getenv("TERMINAL_HUB_SMTP_FROM"),
+	}
+	if port, err := strconv.Atoi(os.Getenv("TERMINAL_HUB_SMTP_PORT")); err == nil && port > 0 {
+		config.Port = port
+	}
+	return config
+}
+
+// GetAdminEmailFromEnv returns the addresses in TERMINAL_HUB_ADMIN_EMAIL
+// (comma-separated) that receive security alerts and cron failures
+func GetAdminEmailFromEnv() ([]string, error) {
+	var addresses []string
+	for _, address := range strings.Split(os.Getenv("TERMINAL_HUB_ADMIN_EMAIL"), ",") {
+		address = strings.TrimSpace(address)
+		if address == "" {
+			continue
+		}
+		if _, err := mail.ParseAddress(address); err != nil {
+			return nil, fmt.Errorf("invalid TERMINAL_HUB_ADMIN_EMAIL address %q: %w", address, err)
+		}
+		addresses = append(addresses, address)
+	}
+	return addresses, nil
+}
+
+// sendMail is swapped out in tests
+var sendMail = smtp.SendMail
+
+// SendEmail sends a plain-text email via SMTP
+func SendEmail(config SMTPConfig, to []string, subject, body string) error {
+	if !config.IsConfigured() {
+		return errors.New("SMTP is not configured")
+	}
+
+	message := "From: " + config.From + "\r\n" +
+		"To: " + strings.Join(to, ", ") + "\r\n" +
+		"Subject: " + encodeSubject(subject) + "\r\n" +
+		"Content-Type: text/plain; charset=UTF-8\r\n" +
+		"\r\n" + strings.ReplaceAll(body, "\n", "\r\n")
+
+	var smtpAuth smtp.Auth
+	if config.Username != "" {
+		smtpAuth = smtp.PlainAuth("", config.Username, config.Password, config.Host)
+	}
+
+	addr := config.Host + ":" + strconv.Itoa(config.Port)
+	return sendMail(addr, smtpAuth, config.From, to, []byte(message))
+}
+
+// encodeSubject makes subject safe for the header: control characters such
+// as CR and LF, which could inject headers, become spaces, and non-ASCII text
+// is Q-encoded
+func encodeSubject(subject string) string {
+	subject = strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) {
+			return ' '
+		}
+		return r
+	}, subject)
+	return mime.QEncoding.Encode("UTF-8", subject)
+}
+
+// EmailChannel emails notifications to fixed recipients
+type EmailChannel struct {
+	SMTP SMTPConfig
+	To   []string
+}
+
+// NewEmailChannel creates an email channel, checking the SMTP settings and
+// recipients
+func NewEmailChannel(config SMTPConfig, to []string) (EmailChannel, error) {
+	if !config.IsConfigured() {
+		return EmailChannel{}, errors.New("SMTP is not configured: set TERMINAL_HUB_SMTP_HOST and TERMINAL_HUB_SMTP_FROM")
+	}
+	if len(to) == 0 {
+		return EmailChannel{}, errors.New("at least one recipient is required")
+	}
+	for _, address := range to {
+		if _, err := mail.ParseAddress(address); err != nil {
+			return EmailChannel{}, fmt.Errorf("invalid email address %q: %w", address, err)
+		}
+	}
+	return EmailChannel{SMTP: config, To: to}, nil
+}
+
+func newEmailChannel(config ChannelConfig, client *http.Client) (Channel, error) {
+	return NewEmailChannel(GetSMTPConfigFromEnv(), config.To)
+}
+
+// Send emails the notification with its title as the subject. The context
+// is not used: net/smtp has no cancellation.
+func (c EmailChannel) Send(ctx context.Context, n Notification) error {
+	subject := "[terminal-hub] " + n.Title
+	if n.Title == "" {
+		subject = "[terminal-hub] " + n.Event
+	}
+	return SendEmail(c.SMTP, c.To, subject, n.Message)
+}
@@ -0,0 +1,103 @@
+package notify
+
+import (
+	"context"
+	"net/smtp"
+	"strings"
+	"testing"
+)
+
+type sentMail struct {
+	addr string
+	from string
+	to   []string
+	msg  string
+}
+
+func stubSendMail(t *testing.T) *[]sentMail {
+	t.Helper()
+	var sent []sentMail
+	original := sendMail
+	sendMail = func(addr string, a smtp.Auth, from string, to []string, msg []byte) error {
+		sent = append(sent, sentMail{addr: addr, from: from, to: to, msg: string(msg)})
+		return nil
+	}
+	t.Cleanup(func() { sendMail = original })
+	return &sent
+}
+
+func TestEmailChannelFromChannelsFile(t *testing.T) {
+	sent := stubSendMail(t)
+	t.Setenv("TERMINAL_HUB_SMTP_HOST", "smtp.example.com")
+	t.Setenv("TERMINAL_HUB_SMTP_PORT", "2525")
+	t.Setenv("TERMINAL_HUB_SMTP_FROM", "hub@example.com")
+
+	channel, err := NewChannel(ChannelConfig{Type: ChannelEmail, To: []string{"admin@example.com"}}, nil)
+	if err != nil {
+		t.Fatalf("failed to build email channel: %v", err)
+	}
+	if err := channel.Send(context.Background(), Notification{Event: EventCronFailed, Title: "Backup failed", Message: "exit 1\ndisk full"}); err != nil {
+		t.Fatalf("send failed: %v", err)
+	}
+
+	if len(*sent) != 1 {
+		t.Fatalf("expected one email, got %d", len(*sent))
+	}
+	mail := (*sent)[0]
+	if mail.addr != "smtp.example.com:2525" || mail.from != "hub@example.com" || strings.Join(mail.to, ",") != "admin@example.com" {
+		t.Errorf("unexpected envelope %+v", mail)
+	}
+	if !strings.Contains(mail.msg, "Subject: [terminal-hub] Backup failed\r\n") || !strings.HasSuffix(mail.msg, "\r\n\r\nexit 1\r\ndisk full") {
+		t.Errorf("unexpected message %q", mail.msg)
+	}
+}
+
+func TestSendEmailSanitizesSubject(t *testing.T) {
+	sent := stubSendMail(t)
+	config := SMTPConfig{Host: "smtp.example.com", Port: 25, From: "hub@example.com"}
+
+	if err := SendEmail(config, []string{"admin@example.com"}, "Job\r\nBcc: victim@example.com", "body"); err != nil {
+		t.Fatalf("send failed: %v", err)
+	}
+	if err := SendEmail(config, []string{"admin@example.com"}, "Sauvegarde échouée", "body"); err != nil {
+		t.Fatalf("send failed: %v", err)
+	}
+
+	if len(*sent) != 2 {
+		t.Fatalf("expected two emails, got %d", len(*sent))
+	}
+	if msg := (*sent)[0].msg; strings.Contains(msg, "\r\nBcc:") || !strings.Contains(msg, "Subject: Job  Bcc: victim@example.com\r\n") {
+		t.Errorf("expected the subject on one line, got %q", msg)
+	}
+	if msg := (*sent)[1].msg; !strings.Contains(msg, "Subject: =?UTF-8?q?Sauvegarde_=C3=A9chou=C3=A9e?=\r\n") {
+		t.Errorf("expected a Q-encoded subject, got %q", msg)
+	}
+}
+
+func TestEmailChannelRequiresSMTPAndRecipients(t *testing.T) {
+	t.Setenv("TERMINAL_HUB_SMTP_HOST", "")
+	if _, err := NewChannel(ChannelConfig{Type: ChannelEmail, To: []string{"admin@example.com"}}, nil); err == nil {
+		t.Error("expected an error without SMTP settings")
+	}
+
+	smtpConfig := SMTPConfig{Host: "smtp.example.com", Port: 587, From: "hub@example.com"}
+	if _, err := NewEmailChannel(smtpConfig, nil); err == nil {
+		t.Error("expected an error without recipients")
+	}
+	if _, err := NewEmailChannel(smtpConfig, []string{"not-an-email"}); err == nil {
+		t.Error("expected an error for an invalid recipient")
+	}
+}
+
+func TestGetAdminEmailFromEnv(t *testing.T) {
+	t.Setenv("TERMINAL_HUB_ADMIN_EMAIL", " admin@example.com, ops@example.com ,")
+	addresses, err := GetAdminEmailFromEnv()
+	if err != nil || strings.Join(addresses, ",") != "admin@example.com,ops@example.com" {
+		t.Errorf("unexpected addresses %v: %v", addresses, err)
+	}
+
+	t.Setenv("TERMINAL_HUB_ADMIN_EMAIL", "admin")
+	if _, err := GetAdminEmailFromEnv(); err == nil {
+		t.Error("expected an error for an invalid address")
+	}
+}
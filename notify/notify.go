@@ -0,0 +1,165 @@
+// Package notify delivers server events such as exited sessions, fired watch
+// rules and failed cron jobs to push notification services, so they reach a
+// phone even when the web UI is in the background.
+package notify
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Event types a subscription can filter on
+const (
+	EventSessionExit   = "session_exit"   // a session's process exited on its own
+	EventSessionWatch  = "session_watch"  // a watch rule fired
+	EventCronFailed    = "cron_failed"    // a cron job execution failed
+	EventCronRecovered = "cron_recovered" // a failing cron job succeeded again
+	EventLoginBanned   = "login_banned"   // an IP was banned after repeated failed logins
+	EventNewDevice     = "new_device"     // a login from a device not seen before
+	EventDiskLow       = "disk_low"       // free space under the data directory fell below the minimum
+)
+
+// Notification priorities, following ntfy's 1-5 scale
+const (
+	PriorityDefault = 3
+	PriorityHigh    = 4
+)
+
+// sendTimeout bounds a single delivery
+const sendTimeout = 10 * time.Second
+
+// Notification is one message delivered to subscriptions
+type Notification struct {
+	Event    string   // one of the Event constants
+	Title    string   // short headline
+	Message  string   // body text
+	Tags     []string // ntfy tags, shown as emoji when they name one
+	Priority int      // 1 (min) to 5 (max); 0 means PriorityDefault
+}
+
+// Channel delivers notifications to one endpoint
+type Channel interface {
+	Send(ctx context.Context, n Notification) error
+}
+
+// ValidateEvents checks that every event name is known. An empty list
+// subscribes to all events.
+func ValidateEvents(events []string) error {
+	for _, event := range events {
+		switch event {
+		case EventSessionExit, EventSessionWatch, EventCronFailed, EventCronRecovered, EventLoginBanned, EventNewDevice, EventDiskLow:
+		default:
+			return fmt.Errorf("unknown event %q: must be %q, %q, %q, %q, %q, %q or %q", event,
+				EventSessionExit, EventSessionWatch, EventCronFailed, EventCronRecovered, EventLoginBanned, EventNewDevice, EventDiskLow)
+		}
+	}
+	return nil
+}
+
+// NtfyChannel publishes notifications to an ntfy topic
+// (https://ntfy.sh or a self-hosted server)
+type NtfyChannel struct {
+	URL    string // topic URL, e.g. https://ntfy.sh/my-topic
+	Token  string // optional access token for protected topics
+	Client *http.Client
+}
+
+// validateTopicURL checks that raw is an absolute http(s) URL with a topic
+func validateTopicURL(raw string) error {
+	parsed, err := url.Parse(raw)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return fmt.Errorf("invalid topic URL %q: must be an absolute http(s) URL", raw)
+	}
+	if strings.Trim(parsed.Path, "/") == "" {
+		return fmt.Errorf("invalid topic URL %q: missing topic", raw)
+	}
+	return nil
+}
+
+// Send posts the notification message to the topic, with the title, tags and
+// priority as headers
+func (c NtfyChannel) Send(ctx context.Context, n Notification) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.URL, strings.NewReader(n.Message))
+	if err != nil {
+		return err
+	}
+	if n.Title != "" {
+		req.Header.Set("Title", n.Title)
+	}
+	if len(n.Tags) > 0 {
+		req.Header.Set("Tags", strings.Join(n.Tags, ","))
+	}
+	priority := n.Priority
+	if priority == 0 {
+		priority = PriorityDefault
+	}
+	req.Header.Set("Priority", fmt.Sprint(priority))
+	if c.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+	}
+
+	client := c.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("ntfy returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// Dispatcher sends notifications to every stored subscription interested in
+// their event
+type Dispatcher struct {
+	store  *Store
+	client *http.Client
+}
+
+// NewDispatcher creates a dispatcher delivering to the store's subscriptions
+func NewDispatcher(store *Store) *Dispatcher {
+	return &Dispatcher{
+		store:  store,
+		client: &http.Client{Timeout: sendTimeout},
+	}
+}
+
+// Store returns the subscriptions the dispatcher delivers to
+func (d *Dispatcher) Store() *Store {
+	return d.store
+}
+
+// Notify delivers n in the background; failures are logged
+func (d *Dispatcher) Notify(n Notification) {
+	for _, sub := range d.store.List() {
+		if !sub.Wants(n.Event) {
+			continue
+		}
+		go func(sub Subscription) {
+			if err := d.send(sub, n); err != nil {
+				log.Printf("[Notify] Failed to deliver %s notification to subscription %s: %v", n.Event, sub.ID, err)
+			}
+		}(sub)
+	}
+}
+
+// send delivers n to one subscription and waits for the result
+func (d *Dispatcher) send(sub Subscription, n Notification) error {
+	channel, err := NewChannel(ChannelConfig{Type: sub.Type, URL: sub.URL, Token: sub.Token}, d.client)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), sendTimeout)
+	defer cancel()
+	return channel.Send(ctx, n)
+}
@@ -0,0 +1,120 @@
+package notify
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+type receivedRequest struct {
+	header http.Header
+	body   string
+}
+
+func newTopicServer(t *testing.T) (*httptest.Server, chan receivedRequest) {
+	t.Helper()
+	received := make(chan receivedRequest, 8)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		received <- receivedRequest{header: r.Header.Clone(), body: string(body)}
+	}))
+	t.Cleanup(server.Close)
+	return server, received
+}
+
+func TestSubscriptionValidate(t *testing.T) {
+	cases := []struct {
+		sub   Subscription
+		valid bool
+	}{
+		{Subscription{Type: TypeNtfy, URL: "https://ntfy.sh/alerts"}, true},
+		{Subscription{Type: TypeNtfy, URL: "https://ntfy.sh/alerts", Events: []string{EventCronFailed}}, true},
+		{Subscription{Type: TypeNtfy, URL: "https://ntfy.sh/"}, false},
+		{Subscription{Type: TypeNtfy, URL: "ftp://ntfy.sh/alerts"}, false},
+		{Subscription{Type: TypeNtfy, URL: "https://ntfy.sh/alerts", Events: []string{"reboot"}}, false},
+		{Subscription{Type: "pager", URL: "https://ntfy.sh/alerts"}, false},
+	}
+	for _, c := range cases {
+		if err := c.sub.Validate(); (err == nil) != c.valid {
+			t.Errorf("Validate(%+v) = %v, want valid=%v", c.sub, err, c.valid)
+		}
+	}
+}
+
+func TestStorePersistsSubscriptions(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "notifications.json")
+	store, err := OpenStore(path)
+	if err != nil {
+		t.Fatalf("failed to open store: %v", err)
+	}
+	if err := store.Add(Subscription{ID: "a", Type: TypeNtfy, URL: "https://ntfy.sh/a", Token: "tk"}); err != nil {
+		t.Fatalf("add failed: %v", err)
+	}
+	if err := store.Add(Subscription{ID: "a", Type: TypeNtfy, URL: "https://ntfy.sh/a"}); err == nil {
+		t.Error("expected duplicate ID to fail")
+	}
+
+	reopened, err := OpenStore(path)
+	if err != nil {
+		t.Fatalf("failed to reopen store: %v", err)
+	}
+	subs := reopened.List()
+	if len(subs) != 1 || subs[0].Token != "tk" {
+		t.Fatalf("expected the stored subscription with its token, got %+v", subs)
+	}
+	if err := reopened.Delete("a"); err != nil {
+		t.Fatalf("delete failed: %v", err)
+	}
+	if err := reopened.Delete("a"); err != ErrSubscriptionNotFound {
+		t.Errorf("expected ErrSubscriptionNotFound, got %v", err)
+	}
+}
+
+func TestDispatcherDeliversToInterestedSubscriptions(t *testing.T) {
+	server, received := newTopicServer(t)
+	store, err := OpenStore(filepath.Join(t.TempDir(), "notifications.json"))
+	if err != nil {
+		t.Fatalf("failed to open store: %v", err)
+	}
+	_ = store.Add(Subscription{ID: "all", Type: TypeNtfy, URL: server.URL + "/all", Token: "secret"})
+	_ = store.Add(Subscription{ID: "cron", Type: TypeNtfy, URL: server.URL + "/cron", Events: []string{EventCronFailed}})
+
+	dispatcher := NewDispatcher(store)
+	dispatcher.Notify(Notification{
+		Event:   EventSessionExit,
+		Title:   "Session exited",
+		Message: "bye",
+		Tags:    []string{"checkered_flag", "exit"},
+	})
+
+	select {
+	case req := <-received:
+		if req.body != "bye" {
+			t.Errorf("expected body %q, got %q", "bye", req.body)
+		}
+		if got := req.header.Get("Title"); got != "Session exited" {
+			t.Errorf("expected title header, got %q", got)
+		}
+		if got := req.header.Get("Tags"); got != "checkered_flag,exit" {
+			t.Errorf("expected tags header, got %q", got)
+		}
+		if got := req.header.Get("Priority"); got != "3" {
+			t.Errorf("expected default priority 3, got %q", got)
+		}
+		if got := req.header.Get("Authorization"); got != "Bearer secret" {
+			t.Errorf("expected bearer token, got %q", got)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for the notification")
+	}
+
+	// The cron-only subscription does not get session events
+	select {
+	case req := <-received:
+		t.Fatalf("unexpected second delivery: %+v", req)
+	case <-time.After(200 * time.Millisecond):
+	}
+}
@@ -0,0 +1,147 @@
+package notify
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+)
+
+// Subscription types
+const (
+	TypeNtfy = "ntfy" // publish to an ntfy topic
+)
+
+// ErrSubscriptionNotFound is returned for unknown subscription IDs
+var ErrSubscriptionNotFound = errors.New("subscription not found")
+
+// Subscription is a registered notification endpoint
+type Subscription struct {
+	ID        string   `json:"id"`
+	Type      string   `json:"type"`             // "ntfy"
+	URL       string   `json:"url"`              // ntfy topic URL
+	Token     string   `json:"token,omitempty"`  // Optional: access token, never returned by the API
+	Events    []string `json:"events,omitempty"` // Optional: events to deliver; empty means all
+	CreatedAt int64    `json:"created_at"`       // unix timestamp
+}
+
+// Validate checks that a subscription is complete and well-formed
+func (s Subscription) Validate() error {
+	switch s.Type {
+	case TypeNtfy:
+		if err := validateTopicURL(s.URL); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("type must be %q", TypeNtfy)
+	}
+	return ValidateEvents(s.Events)
+}
+
+// Wants reports whether the subscription receives event
+func (s Subscription) Wants(event string) bool {
+	return len(s.Events) == 0 || slices.Contains(s.Events, event)
+}
+
+// Redacted returns the subscription without its token
+func (s Subscription) Redacted() Subscription {
+	s.Token = ""
+	return s
+}
+
+// Store persists notification subscriptions in a JSON file
+type Store struct {
+	path          string
+	mu            sync.Mutex
+	subscriptions []Subscription // in creation order
+}
+
+// OpenStore loads the store at path, creating it on first write
+func OpenStore(path string) (*Store, error) {
+	store := &Store{path: path}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return store, nil
+		}
+		return nil, fmt.Errorf("failed to read notification subscriptions: %w", err)
+	}
+	if err := json.Unmarshal(data, &store.subscriptions); err != nil {
+		return nil, fmt.Errorf("failed to parse notification subscriptions: %w", err)
+	}
+	return store, nil
+}
+
+// List returns all subscriptions in creation order
+func (s *Store) List() []Subscription {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Subscription{}, s.subscriptions...)
+}
+
+// Add stores a subscription, which must have a new ID
+func (s *Store) Add(sub Subscription) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.indexLocked(sub.ID) >= 0 {
+		return fmt.Errorf("subscription %q already exists", sub.ID)
+	}
+	s.subscriptions = append(s.subscriptions, sub)
+	return s.saveLocked()
+}
+
+// Delete removes a subscription
+func (s *Store) Delete(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	i := s.indexLocked(id)
+	if i < 0 {
+		return ErrSubscriptionNotFound
+	}
+	s.subscriptions = append(s.subscriptions[:i], s.subscriptions[i+1:]...)
+	return s.saveLocked()
+}
+
+// indexLocked returns the position of a subscription, or -1. Must be called
+// with s.mu held.
+func (s *Store) indexLocked(id string) int {
+	for i, sub := range s.subscriptions {
+		if sub.ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// saveLocked writes the store atomically. Must be called with s.mu held.
+func (s *Store) saveLocked() error {
+	data, err := json.MarshalIndent(s.subscriptions, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0700); err != nil {
+		return fmt.Errorf("failed to create notifications directory: %w", err)
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("failed to write notification subscriptions: %w", err)
+	}
+	return os.Rename(tmp, s.path)
+}
+
+// GetStorePathFromEnv returns TERMINAL_HUB_NOTIFICATIONS, defaulting to
+// ~/.terminal-hub/notifications.json
+func GetStorePathFromEnv() string {
+	if path := os.Getenv("TERMINAL_HUB_NOTIFICATIONS"); path != "" {
+		return path
+	}
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "notifications.json"
+	}
+	return filepath.Join(homeDir, ".terminal-hub", "notifications.json")
+}
@@ -0,0 +1,140 @@
+// Package sysstats samples the host's CPU, memory, disk and load in the
+// background, for the hub's system dashboard.
+package sysstats
+
+import (
+	"errors"
+	"os"
+	"runtime"
+	"sync"
+	"time"
+)
+
+// DefaultInterval is how often the host is sampled
+const DefaultInterval = 5 * time.Second
+
+// ErrUnsupported is returned on hosts whose statistics cannot be read
+var ErrUnsupported = errors.New("system stats are only available on Linux")
+
+// HostStats is one sample of the host
+type HostStats struct {
+	Hostname      string      `json:"hostname"`
+	CPUCount      int         `json:"cpu_count"`
+	CPUPercent    float64     `json:"cpu_percent"`    // busy time of all CPUs since the previous sample, 100 = all of them
+	Load          [3]float64  `json:"load"`           // 1, 5 and 15 minute load averages
+	UptimeSeconds float64     `json:"uptime_seconds"` // since the host booted
+	Memory        MemoryStats `json:"memory"`
+	Disks         []DiskStats `json:"disks"`
+	SampledAt     time.Time   `json:"sampled_at"`
+}
+
+// MemoryStats describes physical memory and swap
+type MemoryStats struct {
+	TotalBytes     uint64 `json:"total_bytes"`
+	AvailableBytes uint64 `json:"available_bytes"` // can be allocated without swapping, including reclaimable caches
+	UsedBytes      uint64 `json:"used_bytes"`      // total minus available
+	SwapTotalBytes uint64 `json:"swap_total_bytes"`
+	SwapUsedBytes  uint64 `json:"swap_used_bytes"`
+}
+
+// DiskStats describes the filesystem holding Path
+type DiskStats struct {
+	Path           string  `json:"path"`
+	TotalBytes     uint64  `json:"total_bytes"`
+	UsedBytes      uint64  `json:"used_bytes"`
+	AvailableBytes uint64  `json:"available_bytes"` // free for unprivileged users
+	UsedPercent    float64 `json:"used_percent"`    // of the space available to unprivileged users
+}
+
+// cpuTimes are the cumulative CPU times of the host, in clock ticks
+type cpuTimes struct {
+	busy  uint64
+	total uint64
+}
+
+// Collector samples the host periodically and keeps the latest sample
+type Collector struct {
+	disks []string // paths whose filesystems are reported
+
+	mu      sync.RWMutex
+	latest  *HostStats
+	prevCPU cpuTimes
+}
+
+// NewCollector returns a collector reporting the filesystems holding disks
+func NewCollector(disks []string) *Collector {
+	return &Collector{disks: disks}
+}
+
+// DefaultDisks returns the paths reported by default: the root filesystem
+// and the user's home directory
+func DefaultDisks() []string {
+	disks := []string{"/"}
+	if home, err := os.UserHomeDir(); err == nil && home != "/" {
+		disks = append(disks, home)
+	}
+	return disks
+}
+
+// GetIntervalFromEnv returns TERMINAL_HUB_STATS_INTERVAL, or 5s. Zero or a
+// negative duration turns sampling off.
+func GetIntervalFromEnv() time.Duration {
+	if val := os.Getenv("TERMINAL_HUB_STATS_INTERVAL"); val != "" {
+		if d, err := time.ParseDuration(val); err == nil {
+			return d
+		}
+	}
+	return DefaultInterval
+}
+
+// Run samples the host right away and then every interval. It returns right
+// away on hosts without statistics.
+func (c *Collector) Run(interval time.Duration) {
+	if interval <= 0 || !supported {
+		return
+	}
+	_ = c.Sample(time.Now())
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for now := range ticker.C {
+		_ = c.Sample(now)
+	}
+}
+
+// Sample reads the host's statistics and makes them the latest sample. CPU
+// usage is measured since the previous sample and is zero for the first.
+func (c *Collector) Sample(now time.Time) error {
+	cpu, err := readCPUTimes()
+	if err != nil {
+		return err
+	}
+	stats := &HostStats{CPUCount: runtime.NumCPU(), SampledAt: now}
+	stats.Hostname, _ = os.Hostname()
+	if stats.Memory, err = readMemory(); err != nil {
+		return err
+	}
+	if stats.Load, err = readLoad(); err != nil {
+		return err
+	}
+	if stats.UptimeSeconds, err = readUptime(); err != nil {
+		return err
+	}
+	stats.Disks = readDisks(c.disks)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.prevCPU.total > 0 && cpu.total > c.prevCPU.total && cpu.busy >= c.prevCPU.busy {
+		stats.CPUPercent = float64(cpu.busy-c.prevCPU.busy) / float64(cpu.total-c.prevCPU.total) * 100
+	}
+	c.prevCPU = cpu
+	c.latest = stats
+	return nil
+}
+
+// Latest returns the latest sample, or nil before the first
+func (c *Collector) Latest() *HostStats {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.latest
+}
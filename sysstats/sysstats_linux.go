@@ -0,0 +1,161 @@
+package sysstats
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"golang.org/x/sys/unix"
+)
+
+const supported = true
+
+// readCPUTimes reads the host's CPU times from the first line of /proc/stat
+func readCPUTimes() (cpuTimes, error) {
+	file, err := os.Open("/proc/stat")
+	if err != nil {
+		return cpuTimes{}, err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	if !scanner.Scan() {
+		return cpuTimes{}, errors.New("empty /proc/stat")
+	}
+	fields := strings.Fields(scanner.Text())
+	if len(fields) < 5 || fields[0] != "cpu" {
+		return cpuTimes{}, errors.New("unexpected /proc/stat format")
+	}
+	// user nice system idle iowait irq softirq steal; guest time is already
+	// counted in user and nice
+	var times cpuTimes
+	for i, field := range fields[1:min(len(fields), 9)] {
+		ticks, err := strconv.ParseUint(field, 10, 64)
+		if err != nil {
+			return cpuTimes{}, fmt.Errorf("unexpected /proc/stat value %q", field)
+		}
+		times.total += ticks
+		if i != 3 && i != 4 { // idle and iowait
+			times.busy += ticks
+		}
+	}
+	return times, nil
+}
+
+// readMemory reads /proc/meminfo
+func readMemory() (MemoryStats, error) {
+	file, err := os.Open("/proc/meminfo")
+	if err != nil {
+		return MemoryStats{}, err
+	}
+	defer file.Close()
+
+	values := make(map[string]uint64)
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		name, rest, ok := strings.Cut(scanner.Text(), ":")
+		if !ok {
+			continue
+		}
+		fields := strings.Fields(rest)
+		if len(fields) == 0 {
+			continue
+		}
+		value, err := strconv.ParseUint(fields[0], 10, 64)
+		if err != nil {
+			continue
+		}
+		if len(fields) > 1 && fields[1] == "kB" {
+			value *= 1024
+		}
+		values[name] = value
+	}
+	if err := scanner.Err(); err != nil {
+		return MemoryStats{}, err
+	}
+
+	memory := MemoryStats{
+		TotalBytes:     values["MemTotal"],
+		AvailableBytes: values["MemAvailable"],
+		SwapTotalBytes: values["SwapTotal"],
+	}
+	if memory.TotalBytes == 0 {
+		return MemoryStats{}, errors.New("unexpected /proc/meminfo format")
+	}
+	if memory.AvailableBytes <= memory.TotalBytes {
+		memory.UsedBytes = memory.TotalBytes - memory.AvailableBytes
+	}
+	if swapFree := values["SwapFree"]; swapFree <= memory.SwapTotalBytes {
+		memory.SwapUsedBytes = memory.SwapTotalBytes - swapFree
+	}
+	return memory, nil
+}
+
+// readLoad reads the load averages from /proc/loadavg
+func readLoad() ([3]float64, error) {
+	var load [3]float64
+	data, err := os.ReadFile("/proc/loadavg")
+	if err != nil {
+		return load, err
+	}
+	fields := strings.Fields(string(data))
+	if len(fields) < 3 {
+		return load, errors.New("unexpected /proc/loadavg format")
+	}
+	for i := range load {
+		if load[i], err = strconv.ParseFloat(fields[i], 64); err != nil {
+			return load, fmt.Errorf("unexpected /proc/loadavg value %q", fields[i])
+		}
+	}
+	return load, nil
+}
+
+// readUptime reads the seconds since boot from /proc/uptime
+func readUptime() (float64, error) {
+	data, err := os.ReadFile("/proc/uptime")
+	if err != nil {
+		return 0, err
+	}
+	fields := strings.Fields(string(data))
+	if len(fields) == 0 {
+		return 0, errors.New("unexpected /proc/uptime format")
+	}
+	return strconv.ParseFloat(fields[0], 64)
+}
+
+// readDisks reports the filesystems holding paths, each once. Paths that
+// cannot be read are left out.
+func readDisks(paths []string) []DiskStats {
+	disks := []DiskStats{}
+	seen := make(map[uint64]bool)
+	for _, path := range paths {
+		var st unix.Stat_t
+		var fs unix.Statfs_t
+		if unix.Stat(path, &st) != nil || unix.Statfs(path, &fs) != nil {
+			continue
+		}
+		if seen[uint64(st.Dev)] {
+			continue
+		}
+		seen[uint64(st.Dev)] = true
+
+		blockSize := uint64(fs.Bsize)
+		disk := DiskStats{
+			Path:           path,
+			TotalBytes:     fs.Blocks * blockSize,
+			AvailableBytes: fs.Bavail * blockSize,
+		}
+		if fs.Bfree <= fs.Blocks {
+			disk.UsedBytes = (fs.Blocks - fs.Bfree) * blockSize
+		}
+		// As df does: used space out of what unprivileged users can fill
+		if usable := disk.UsedBytes + disk.AvailableBytes; usable > 0 {
+			disk.UsedPercent = float64(disk.UsedBytes) / float64(usable) * 100
+		}
+		disks = append(disks, disk)
+	}
+	return disks
+}
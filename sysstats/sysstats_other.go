@@ -0,0 +1,17 @@
+//go:build !linux
+
+package sysstats
+
+const supported = false
+
+// The readers are only implemented for Linux
+
+func readCPUTimes() (cpuTimes, error) { return cpuTimes{}, ErrUnsupported }
+
+func readMemory() (MemoryStats, error) { return MemoryStats{}, ErrUnsupported }
+
+func readLoad() ([3]float64, error) { return [3]float64{}, ErrUnsupported }
+
+func readUptime() (float64, error) { return 0, ErrUnsupported }
+
+func readDisks(paths []string) []DiskStats { return nil }
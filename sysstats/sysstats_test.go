@@ -0,0 +1,54 @@
+package sysstats
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCollectorSample(t *testing.T) {
+	if !supported {
+		t.Skip("system stats are not available on this platform")
+	}
+	c := NewCollector([]string{"/", "/", t.TempDir()})
+	if c.Latest() != nil {
+		t.Fatal("expected no sample before the first")
+	}
+
+	now := time.Now()
+	if err := c.Sample(now); err != nil {
+		t.Fatalf("sample failed: %v", err)
+	}
+	// Burn some CPU so the second sample has busy time to measure
+	for deadline := time.Now().Add(50 * time.Millisecond); time.Now().Before(deadline); {
+	}
+	if err := c.Sample(now.Add(time.Second)); err != nil {
+		t.Fatalf("sample failed: %v", err)
+	}
+
+	stats := c.Latest()
+	if stats == nil || !stats.SampledAt.Equal(now.Add(time.Second)) {
+		t.Fatalf("expected the latest sample, got %+v", stats)
+	}
+	if stats.CPUCount < 1 || stats.CPUPercent < 0 || stats.CPUPercent > 100 {
+		t.Errorf("unexpected CPU stats: %d CPUs, %.1f%%", stats.CPUCount, stats.CPUPercent)
+	}
+	if m := stats.Memory; m.TotalBytes == 0 || m.UsedBytes > m.TotalBytes || m.UsedBytes+m.AvailableBytes != m.TotalBytes {
+		t.Errorf("unexpected memory stats: %+v", m)
+	}
+	if stats.UptimeSeconds <= 0 || stats.Load[0] < 0 {
+		t.Errorf("unexpected uptime %.0f or load %v", stats.UptimeSeconds, stats.Load)
+	}
+	if len(stats.Disks) == 0 || stats.Disks[0].Path != "/" || stats.Disks[0].TotalBytes == 0 {
+		t.Fatalf("expected the root filesystem first, got %+v", stats.Disks)
+	}
+	seen := map[string]bool{}
+	for _, disk := range stats.Disks {
+		if seen[disk.Path] {
+			t.Errorf("expected each path once, got %+v", stats.Disks)
+		}
+		seen[disk.Path] = true
+		if disk.UsedPercent < 0 || disk.UsedPercent > 100 {
+			t.Errorf("unexpected disk usage %+v", disk)
+		}
+	}
+}
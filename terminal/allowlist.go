@@ -0,0 +1,99 @@
+package terminal
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ExecutionAllowlist restricts which shells and working directories sessions
+// and cron jobs may request. An empty list allows any value. Requests that
+// leave the shell or working directory unset use the server defaults, which
+// are not checked.
+type ExecutionAllowlist struct {
+	Shells      []string // absolute shell paths, e.g. /bin/bash
+	Directories []string // working directories must be one of these or below them
+}
+
+// GetExecutionAllowlistFromEnv reads the comma-separated
+// TERMINAL_HUB_ALLOWED_SHELLS and TERMINAL_HUB_ALLOWED_DIRS variables
+func GetExecutionAllowlistFromEnv() ExecutionAllowlist {
+	return ExecutionAllowlist{
+		Shells:      splitAllowlist(os.Getenv("TERMINAL_HUB_ALLOWED_SHELLS")),
+		Directories: splitAllowlist(os.Getenv("TERMINAL_HUB_ALLOWED_DIRS")),
+	}
+}
+
+// splitAllowlist parses a comma-separated list of paths
+func splitAllowlist(value string) []string {
+	var paths []string
+	for _, part := range strings.Split(value, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			paths = append(paths, filepath.Clean(part))
+		}
+	}
+	return paths
+}
+
+// CheckShell returns an error if shell is set and not in the allowlist
+func (a ExecutionAllowlist) CheckShell(shell string) error {
+	if shell == "" || len(a.Shells) == 0 {
+		return nil
+	}
+	if !filepath.IsAbs(shell) {
+		return fmt.Errorf("shell %q must be an absolute path", shell)
+	}
+	cleaned := filepath.Clean(shell)
+	for _, allowed := range a.Shells {
+		if cleaned == allowed {
+			return nil
+		}
+	}
+	return fmt.Errorf("shell %q is not allowed", shell)
+}
+
+// CheckWorkingDirectory returns an error if dir is set and not inside one of
+// the allowed directories. Symlinks are resolved so they cannot be used to
+// escape an allowed directory.
+func (a ExecutionAllowlist) CheckWorkingDirectory(dir string) error {
+	if dir == "" || len(a.Directories) == 0 {
+		return nil
+	}
+	if !filepath.IsAbs(dir) {
+		return fmt.Errorf("working directory %q must be an absolute path", dir)
+	}
+	resolved := resolvePath(dir)
+	for _, allowed := range a.Directories {
+		if isWithinDir(resolved, resolvePath(allowed)) {
+			return nil
+		}
+	}
+	return fmt.Errorf("working directory %q is not allowed", dir)
+}
+
+// Check validates a shell and working directory together
+func (a ExecutionAllowlist) Check(shell, dir string) error {
+	if err := a.CheckShell(shell); err != nil {
+		return err
+	}
+	return a.CheckWorkingDirectory(dir)
+}
+
+// resolvePath cleans path and resolves symlinks when it exists
+func resolvePath(path string) string {
+	path = filepath.Clean(path)
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		return resolved
+	}
+	return path
+}
+
+// isWithinDir reports whether path is dir or below it
+func isWithinDir(path, dir string) bool {
+	rel, err := filepath.Rel(dir, path)
+	if err != nil {
+		return false
+	}
+	return rel == "." || (rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)))
+}
@@ -0,0 +1,61 @@
+package terminal
+
+import (
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ExecutionAllowlist", func() {
+	It("should allow anything when empty", func() {
+		Expect(ExecutionAllowlist{}.Check("/usr/bin/fish", "/etc")).To(Succeed())
+	})
+
+	It("should parse comma-separated environment variables", func() {
+		GinkgoT().Setenv("TERMINAL_HUB_ALLOWED_SHELLS", "/bin/bash, /bin/zsh,")
+		GinkgoT().Setenv("TERMINAL_HUB_ALLOWED_DIRS", "/home/")
+		allowlist := GetExecutionAllowlistFromEnv()
+		Expect(allowlist.Shells).To(Equal([]string{"/bin/bash", "/bin/zsh"}))
+		Expect(allowlist.Directories).To(Equal([]string{"/home"}))
+	})
+
+	It("should only accept listed shells", func() {
+		allowlist := ExecutionAllowlist{Shells: []string{"/bin/bash", "/bin/zsh"}}
+		Expect(allowlist.CheckShell("")).To(Succeed())
+		Expect(allowlist.CheckShell("/bin/zsh")).To(Succeed())
+		Expect(allowlist.CheckShell("/bin/../bin/bash")).To(Succeed())
+		Expect(allowlist.CheckShell("/usr/bin/python3")).To(MatchError(`shell "/usr/bin/python3" is not allowed`))
+		Expect(allowlist.CheckShell("bash")).To(HaveOccurred())
+	})
+
+	It("should only accept directories inside the allowed ones", func() {
+		root := GinkgoT().TempDir()
+		allowed := filepath.Join(root, "home")
+		Expect(os.MkdirAll(filepath.Join(allowed, "user"), 0755)).To(Succeed())
+		allowlist := ExecutionAllowlist{Directories: []string{allowed}}
+
+		Expect(allowlist.CheckWorkingDirectory("")).To(Succeed())
+		Expect(allowlist.CheckWorkingDirectory(allowed)).To(Succeed())
+		Expect(allowlist.CheckWorkingDirectory(filepath.Join(allowed, "user"))).To(Succeed())
+		Expect(allowlist.CheckWorkingDirectory(allowed + "-other")).To(HaveOccurred())
+		Expect(allowlist.CheckWorkingDirectory(filepath.Join(allowed, "..", "etc"))).To(HaveOccurred())
+		Expect(allowlist.CheckWorkingDirectory("relative/dir")).To(HaveOccurred())
+	})
+
+	It("should not let symlinks escape an allowed directory", func() {
+		root := GinkgoT().TempDir()
+		allowed := filepath.Join(root, "home")
+		outside := filepath.Join(root, "outside")
+		Expect(os.MkdirAll(allowed, 0755)).To(Succeed())
+		Expect(os.MkdirAll(outside, 0755)).To(Succeed())
+		link := filepath.Join(allowed, "link")
+		if err := os.Symlink(outside, link); err != nil {
+			Skip("symlinks are not supported: " + err.Error())
+		}
+
+		allowlist := ExecutionAllowlist{Directories: []string{allowed}}
+		Expect(allowlist.CheckWorkingDirectory(link)).To(MatchError(ContainSubstring("is not allowed")))
+	})
+})
@@ -0,0 +1,79 @@
+package terminal
+
+import "time"
+
+// ClientConnection describes the network side of a client
+type ClientConnection struct {
+	RemoteIP  string
+	UserAgent string
+	BytesSent uint64
+}
+
+// ConnectedClient is implemented by clients that report connection details
+type ConnectedClient interface {
+	ConnectionInfo() ClientConnection
+}
+
+// AttachedClient describes a client attached to a session for the session
+// detail endpoint
+type AttachedClient struct {
+	ID          string    `json:"id"` // same ID as in presence messages
+	Name        string    `json:"name,omitempty"`
+	RemoteIP    string    `json:"remote_ip,omitempty"`
+	UserAgent   string    `json:"user_agent,omitempty"`
+	ConnectedAt time.Time `json:"connected_at"`
+	BytesSent   uint64    `json:"bytes_sent"`
+}
+
+// ClientManager is implemented by sessions that can list and disconnect
+// their clients
+type ClientManager interface {
+	AttachedClients() []AttachedClient
+	KickClient(clientID string) error
+}
+
+// AttachedClients returns the attached clients, longest attached first
+func (s *TerminalSession) AttachedClients() []AttachedClient {
+	s.clientsMu.Lock()
+	defer s.clientsMu.Unlock()
+
+	clients := make([]AttachedClient, 0, len(s.presence))
+	for _, client := range s.orderedClients {
+		info, ok := s.presence[client]
+		if !ok {
+			continue
+		}
+		attached := AttachedClient{
+			ID:          info.ID,
+			Name:        info.Name,
+			ConnectedAt: time.Unix(info.AttachedAt, 0).UTC(),
+		}
+		if connected, ok := client.(ConnectedClient); ok {
+			conn := connected.ConnectionInfo()
+			attached.RemoteIP = conn.RemoteIP
+			attached.UserAgent = conn.UserAgent
+			attached.BytesSent = conn.BytesSent
+		}
+		clients = append(clients, attached)
+	}
+	return clients
+}
+
+// KickClient disconnects the client with the given presence ID
+func (s *TerminalSession) KickClient(clientID string) error {
+	s.clientsMu.Lock()
+	var target WebSocketClient
+	for client, info := range s.presence {
+		if info.ID == clientID {
+			target = client
+			break
+		}
+	}
+	s.clientsMu.Unlock()
+
+	if target == nil {
+		return ErrUnknownClient
+	}
+	s.RemoveClient(target)
+	return target.Close()
+}
@@ -0,0 +1,58 @@
+//go:build linux
+
+package terminal
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+// cgroupCPUPeriod is the cpu.max period in microseconds
+const cgroupCPUPeriod = 100000
+
+// applyCgroup creates root/name, writes the memory and CPU limits and moves
+// pid into it
+func applyCgroup(root, name string, pid int, limits ResourceLimits) (func(), error) {
+	dir := filepath.Join(root, name)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create cgroup: %w", err)
+	}
+	remove := func() {
+		if err := os.Remove(dir); err != nil && !os.IsNotExist(err) {
+			log.Printf("Warning: failed to remove cgroup %s: %v", dir, err)
+		}
+	}
+
+	if limits.CgroupMemory > 0 {
+		if err := writeCgroupFile(dir, "memory.max", strconv.FormatInt(limits.CgroupMemory, 10)); err != nil {
+			remove()
+			return nil, err
+		}
+	}
+	if limits.CgroupCPUs > 0 {
+		quota := int64(limits.CgroupCPUs * cgroupCPUPeriod)
+		if quota < 1000 {
+			quota = 1000
+		}
+		if err := writeCgroupFile(dir, "cpu.max", fmt.Sprintf("%d %d", quota, cgroupCPUPeriod)); err != nil {
+			remove()
+			return nil, err
+		}
+	}
+	if err := writeCgroupFile(dir, "cgroup.procs", strconv.Itoa(pid)); err != nil {
+		remove()
+		return nil, err
+	}
+
+	return remove, nil
+}
+
+func writeCgroupFile(dir, name, value string) error {
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(value), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	return nil
+}
@@ -0,0 +1,10 @@
+//go:build !linux
+
+package terminal
+
+import "errors"
+
+// applyCgroup is unsupported outside Linux
+func applyCgroup(root, name string, pid int, limits ResourceLimits) (func(), error) {
+	return nil, errors.New("cgroups are only supported on Linux")
+}
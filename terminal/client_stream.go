@@ -0,0 +1,105 @@
+package terminal
+
+import (
+	"sync"
+	"time"
+)
+
+// terminalReset clears the client's screen before a redraw from history
+var terminalReset = []byte("\x1bc")
+
+// clientStream delivers a session's output to one client from its own
+// goroutine, reading from the shared output ring at the client's own pace.
+// A slow client only delays itself: output written during a slow send is
+// sent as one frame next time, and a client that falls more than the ring
+// size behind is redrawn from history instead.
+type clientStream struct {
+	client WebSocketClient
+	cursor *ringCursor
+
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+// startClientStreamLocked starts streaming output written from now on to
+// client. Must be called with s.outputMu held, so the history the client was
+// sent and the stream line up. onFail is called once if a send fails.
+func (s *TerminalSession) startClientStreamLocked(client WebSocketClient, onFail func()) *clientStream {
+	stream := &clientStream{
+		client: client,
+		cursor: s.output.subscribe(),
+		stop:   make(chan struct{}),
+	}
+	go s.runClientStream(stream, onFail)
+	return stream
+}
+
+// runClientStream sends output until the stream or the ring is closed or a
+// send fails
+func (s *TerminalSession) runClientStream(stream *clientStream, onFail func()) {
+	defer s.output.unsubscribe(stream.cursor)
+
+	batch := time.NewTimer(broadcastBatchDelay)
+	batch.Stop()
+	defer batch.Stop()
+
+	for {
+		select {
+		case <-stream.stop:
+			return
+		case <-s.output.done:
+			return
+		case <-stream.cursor.notify:
+		}
+
+		available := s.output.available(stream.cursor)
+		if available == 0 {
+			continue
+		}
+		// Give the program a moment to write more so small reads are
+		// coalesced into one frame
+		if available < broadcastBatchMaxBytes {
+			batch.Reset(broadcastBatchDelay)
+			select {
+			case <-stream.stop:
+				return
+			case <-batch.C:
+			}
+		}
+
+		data, overflow := s.output.read(stream.cursor)
+		var seq uint64
+		if overflow {
+			data, seq = s.redrawFrame(stream.cursor)
+		}
+		if len(data) == 0 {
+			continue
+		}
+
+		if err := stream.client.Send(data); err != nil {
+			stream.close()
+			onFail()
+			return
+		}
+		if overflow {
+			// The redraw is not part of the output sequence
+			s.sendOutputSeq(stream.client, seq)
+		}
+	}
+}
+
+// redrawFrame returns a reset followed by the history and a repaint of the
+// screen, and moves cursor past it. seq is the sequence number of the output
+// that follows.
+func (s *TerminalSession) redrawFrame(cursor *ringCursor) (frame []byte, seq uint64) {
+	s.outputMu.Lock()
+	defer s.outputMu.Unlock()
+
+	s.output.seek(cursor)
+	return redrawOutput(s.history.GetHistory(), s.screen.repaint()), s.output.position()
+}
+
+// close stops delivery; unsent output is discarded
+func (c *clientStream) close() {
+	c.stopOnce.Do(func() { close(c.stop) })
+}
@@ -0,0 +1,196 @@
+package terminal
+
+import (
+	"bytes"
+	"encoding/base64"
+	"log"
+	"strings"
+)
+
+// Server message types sent to clients as JSON text frames, alongside the
+// binary terminal output
+const (
+	ServerMessageClipboard = "clipboard" // a program set the clipboard via OSC 52
+)
+
+// maxOSC52Length bounds how much of an unterminated OSC 52 sequence is held
+// back waiting for its terminator. Longer sequences pass through unchanged.
+const maxOSC52Length = 1 << 20
+
+var (
+	osc52Prefix          = []byte("\x1b]52;")
+	bracketedPasteOn     = []byte("\x1b[?2004h")
+	bracketedPasteOff    = []byte("\x1b[?2004l")
+	bracketedPasteStart  = "\x1b[200~"
+	bracketedPasteEnd    = "\x1b[201~"
+	stringTerminatorST   = []byte("\x1b\\")
+	stringTerminatorBELL = byte('\a')
+)
+
+// ServerMessage is a structured message sent to WebSocket clients
+type ServerMessage struct {
+	Type      string           `json:"type"`                // "clipboard", "presence", "control", "control_request", "output_seq" or "motd"
+	Data      string           `json:"data,omitempty"`      // clipboard text, or the message of the day
+	Selection string           `json:"selection,omitempty"` // OSC 52 selection, e.g. "c" for the clipboard
+	Presence  *SessionPresence `json:"presence,omitempty"`
+	Control   *SessionControl  `json:"control,omitempty"`
+	Seq       uint64           `json:"seq,omitempty"` // output_seq: sequence number of the next output byte
+}
+
+// MessageSender is implemented by clients that accept structured messages.
+// Clients without it only receive terminal output.
+type MessageSender interface {
+	SendMessage(msg ServerMessage) error
+}
+
+// Paster is implemented by sessions that accept clipboard pastes from clients
+type Paster interface {
+	Paste(text string) error
+}
+
+// osc52Filter removes OSC 52 clipboard sequences from terminal output and
+// decodes them. Sequences split across reads are held back until complete.
+type osc52Filter struct {
+	pending        []byte
+	bracketedPaste bool // the program enabled bracketed paste mode
+}
+
+// Filter returns data without OSC 52 sequences and the clipboard messages
+// they carried. Clipboard queries ("?") are dropped since the browser
+// clipboard cannot be read on the program's behalf.
+func (f *osc52Filter) Filter(data []byte) ([]byte, []ServerMessage) {
+	f.trackBracketedPaste(data)
+	if len(f.pending) == 0 && bytes.IndexByte(data, 0x1b) < 0 {
+		return data, nil
+	}
+
+	buf := data
+	if len(f.pending) > 0 {
+		buf = append(f.pending, data...)
+		f.pending = nil
+	}
+
+	var out []byte
+	var messages []ServerMessage
+	for len(buf) > 0 {
+		start := bytes.Index(buf, osc52Prefix)
+		if start < 0 {
+			// Hold back a trailing partial prefix that the next read may complete
+			keep := partialPrefixLength(buf, osc52Prefix)
+			out = append(out, buf[:len(buf)-keep]...)
+			if keep > 0 {
+				f.pending = append([]byte(nil), buf[len(buf)-keep:]...)
+			}
+			break
+		}
+		out = append(out, buf[:start]...)
+
+		body := buf[start+len(osc52Prefix):]
+		end, terminatorLength := findStringTerminator(body)
+		if end < 0 {
+			if len(buf)-start > maxOSC52Length {
+				out = append(out, buf[start:]...)
+			} else {
+				f.pending = append([]byte(nil), buf[start:]...)
+			}
+			break
+		}
+
+		if msg, ok := parseOSC52(body[:end]); ok {
+			messages = append(messages, msg)
+		}
+		buf = body[end+terminatorLength:]
+	}
+	return out, messages
+}
+
+// trackBracketedPaste records the last bracketed paste mode switch in data
+func (f *osc52Filter) trackBracketedPaste(data []byte) {
+	on := bytes.LastIndex(data, bracketedPasteOn)
+	off := bytes.LastIndex(data, bracketedPasteOff)
+	if on > off {
+		f.bracketedPaste = true
+	} else if off > on {
+		f.bracketedPaste = false
+	}
+}
+
+// findStringTerminator returns the position and length of the BEL or ST
+// ending an OSC sequence, or -1 if it has not arrived yet
+func findStringTerminator(body []byte) (int, int) {
+	bell := bytes.IndexByte(body, stringTerminatorBELL)
+	st := bytes.Index(body, stringTerminatorST)
+	switch {
+	case bell >= 0 && (st < 0 || bell < st):
+		return bell, 1
+	case st >= 0:
+		return st, len(stringTerminatorST)
+	}
+	return -1, 0
+}
+
+// partialPrefixLength returns the length of the longest suffix of buf that
+// is a proper prefix of prefix
+func partialPrefixLength(buf, prefix []byte) int {
+	for n := len(prefix) - 1; n > 0; n-- {
+		if len(buf) >= n && bytes.Equal(buf[len(buf)-n:], prefix[:n]) {
+			return n
+		}
+	}
+	return 0
+}
+
+// parseOSC52 decodes the "selection;base64" body of an OSC 52 sequence
+func parseOSC52(body []byte) (ServerMessage, bool) {
+	selection, payload, ok := strings.Cut(string(body), ";")
+	if !ok || payload == "?" {
+		return ServerMessage{}, false
+	}
+	text, err := base64.StdEncoding.DecodeString(payload)
+	if err != nil {
+		return ServerMessage{}, false
+	}
+	if selection == "" {
+		selection = "s0" // xterm's default when no selection is given
+	}
+	return ServerMessage{
+		Type:      ServerMessageClipboard,
+		Data:      string(text),
+		Selection: selection,
+	}, true
+}
+
+// Paste writes clipboard text from a client to the PTY, wrapping it in
+// bracketed paste markers when the program asked for them. An embedded end
+// marker is removed so pasted text cannot break out of the paste.
+func (s *TerminalSession) Paste(text string) error {
+	s.clipboardMu.Lock()
+	bracketed := s.clipboard.bracketedPaste
+	s.clipboardMu.Unlock()
+
+	if bracketed {
+		text = bracketedPasteStart + strings.ReplaceAll(text, bracketedPasteEnd, "") + bracketedPasteEnd
+	}
+	_, err := s.Write([]byte(text))
+	return err
+}
+
+// sendMessage delivers a structured message to every client that accepts one.
+// The clients are collected under clientsMu and sent to after releasing it,
+// so a slow client does not hold up joins, leaves and other broadcasts.
+func (s *TerminalSession) sendMessage(msg ServerMessage) {
+	s.clientsMu.Lock()
+	senders := make([]MessageSender, 0, len(s.clients))
+	for client := range s.clients {
+		if sender, ok := client.(MessageSender); ok {
+			senders = append(senders, sender)
+		}
+	}
+	s.clientsMu.Unlock()
+
+	for _, sender := range senders {
+		if err := sender.SendMessage(msg); err != nil {
+			log.Printf("Session %s: failed to send %s message: %v", s.id, msg.Type, err)
+		}
+	}
+}
@@ -0,0 +1,128 @@
+package terminal
+
+import (
+	"io"
+	"os"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// messageClient is a MockWebSocketClient that also accepts structured messages
+type messageClient struct {
+	*MockWebSocketClient
+	messages chan ServerMessage
+}
+
+func (c *messageClient) SendMessage(msg ServerMessage) error {
+	c.messages <- msg
+	return nil
+}
+
+// expectOutputSeq consumes the output_seq message a client gets on attach
+func expectOutputSeq(client *messageClient) {
+	var msg ServerMessage
+	ExpectWithOffset(1, client.messages).To(Receive(&msg))
+	ExpectWithOffset(1, msg.Type).To(Equal(ServerMessageOutputSeq))
+}
+
+var _ = Describe("OSC 52 clipboard", func() {
+	Context("osc52Filter", func() {
+		var filter *osc52Filter
+
+		BeforeEach(func() {
+			filter = &osc52Filter{}
+		})
+
+		It("should pass through output without escape sequences", func() {
+			out, messages := filter.Filter([]byte("hello\r\n"))
+			Expect(string(out)).To(Equal("hello\r\n"))
+			Expect(messages).To(BeEmpty())
+		})
+
+		It("should extract sequences terminated by BEL or ST", func() {
+			out, messages := filter.Filter([]byte("a\x1b]52;c;aGVsbG8=\x07b\x1b]52;p;d29ybGQ=\x1b\\c"))
+			Expect(string(out)).To(Equal("abc"))
+			Expect(messages).To(Equal([]ServerMessage{
+				{Type: ServerMessageClipboard, Data: "hello", Selection: "c"},
+				{Type: ServerMessageClipboard, Data: "world", Selection: "p"},
+			}))
+		})
+
+		It("should reassemble sequences split across reads", func() {
+			out, messages := filter.Filter([]byte("before\x1b]5"))
+			Expect(string(out)).To(Equal("before"))
+			Expect(messages).To(BeEmpty())
+
+			out, messages = filter.Filter([]byte("2;c;aGVs"))
+			Expect(out).To(BeEmpty())
+			Expect(messages).To(BeEmpty())
+
+			out, messages = filter.Filter([]byte("bG8=\x07after"))
+			Expect(string(out)).To(Equal("after"))
+			Expect(messages).To(ConsistOf(ServerMessage{Type: ServerMessageClipboard, Data: "hello", Selection: "c"}))
+		})
+
+		It("should drop clipboard queries and leave other escape sequences alone", func() {
+			out, messages := filter.Filter([]byte("\x1b]52;c;?\x07\x1b[31mred\x1b]0;title\x07"))
+			Expect(string(out)).To(Equal("\x1b[31mred\x1b]0;title\x07"))
+			Expect(messages).To(BeEmpty())
+		})
+
+		It("should track bracketed paste mode", func() {
+			filter.Filter([]byte("\x1b[?2004h"))
+			Expect(filter.bracketedPaste).To(BeTrue())
+			filter.Filter([]byte("\x1b[?2004l"))
+			Expect(filter.bracketedPaste).To(BeFalse())
+		})
+	})
+
+	It("should send clipboard messages to clients and strip them from output", func() {
+		ptySvc, err := NewSimulatedPTYService()
+		Expect(err).ToNot(HaveOccurred())
+		DeferCleanup(ptySvc.Close)
+
+		session, err := NewTerminalSession(SessionConfig{
+			ID:          "clipboard-session",
+			HistorySize: 256,
+			PTYService:  ptySvc,
+		})
+		Expect(err).ToNot(HaveOccurred())
+		DeferCleanup(session.Close)
+
+		client := &messageClient{
+			MockWebSocketClient: NewMockWebSocketClient(),
+			messages:            make(chan ServerMessage, 3),
+		}
+		Expect(session.AddClient(client)).To(Succeed())
+		expectOutputSeq(client)
+		var presence ServerMessage
+		Expect(client.messages).To(Receive(&presence))
+		Expect(presence.Type).To(Equal(ServerMessagePresence))
+
+		Expect(ptySvc.SimulateOutput([]byte("copied\x1b]52;c;Y2xpcA==\x07"))).To(Succeed())
+
+		var msg ServerMessage
+		Eventually(client.messages, "2s").Should(Receive(&msg))
+		Expect(msg).To(Equal(ServerMessage{Type: ServerMessageClipboard, Data: "clip", Selection: "c"}))
+		Expect(string(client.Receive(time.Second))).To(Equal("copied"))
+		Expect(string(session.history.GetHistory())).To(Equal("copied"))
+	})
+
+	It("should wrap pastes in bracketed paste markers when enabled", func() {
+		reader, writer, err := os.Pipe()
+		Expect(err).ToNot(HaveOccurred())
+		DeferCleanup(reader.Close)
+
+		session := &TerminalSession{id: "paste-session", ptyFile: writer}
+		Expect(session.Paste("plain")).To(Succeed())
+		session.clipboard.bracketedPaste = true
+		Expect(session.Paste("evil\x1b[201~rm -rf /")).To(Succeed())
+		Expect(writer.Close()).To(Succeed())
+
+		written, err := io.ReadAll(reader)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(written)).To(Equal("plain\x1b[200~evilrm -rf /\x1b[201~"))
+	})
+})
@@ -0,0 +1,54 @@
+package terminal
+
+import (
+	"maps"
+	"slices"
+)
+
+// SessionLaunch is how a session's shell was started, kept so the session
+// can be cloned
+type SessionLaunch struct {
+	Shell   string            `json:"shell,omitempty"`
+	Command string            `json:"command,omitempty"` // typed into the shell once it started
+	EnvVars map[string]string `json:"env_vars,omitempty"`
+}
+
+// Cloner is implemented by sessions that can describe how to start another
+// session like them
+type Cloner interface {
+	CloneConfig(id, name string) SessionConfig
+}
+
+// CloneConfig returns the configuration of a new session with the given ID
+// and name that starts like s: the same shell, working directory,
+// environment variables and startup command, on the same backend, with the
+// same tags, limits and input, resize and exit settings. Clones of adopted
+// tmux sessions start a new shell in the same directory, as their command
+// is unknown.
+func (s *TerminalSession) CloneConfig(id, name string) SessionConfig {
+	metadata := s.GetMetadata()
+	config := SessionConfig{
+		ID:               id,
+		Name:             name,
+		Shell:            s.launch.Shell,
+		WorkingDirectory: metadata.WorkingDirectory,
+		Command:          s.launch.Command,
+		EnvVars:          maps.Clone(s.launch.EnvVars),
+		Backend:          metadata.Backend,
+		HistorySize:      defaultHistorySize,
+		Tags:             slices.Clone(metadata.Tags),
+		InputMode:        metadata.InputMode,
+		ResizePolicy:     metadata.ResizePolicy,
+	}
+	if metadata.Limits != nil {
+		config.Limits = *metadata.Limits
+	}
+	if metadata.SSH != nil {
+		target := *metadata.SSH
+		config.SSH = &target
+	}
+	if metadata.OnExit != nil {
+		config.ExitActions = *metadata.OnExit
+	}
+	return config
+}
@@ -0,0 +1,43 @@
+package terminal
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Session clone", func() {
+	It("should start a clone like the session", func() {
+		manager := NewSessionManager()
+		DeferCleanup(manager.CloseAll)
+		dir := GinkgoT().TempDir()
+		source, err := manager.CreateSession(SessionConfig{
+			ID:               "clone-source",
+			Name:             "api",
+			Shell:            "/bin/sh",
+			WorkingDirectory: dir,
+			Command:          "echo started-$CLONE_VAR",
+			EnvVars:          map[string]string{"CLONE_VAR": "42"},
+			Backend:          SessionBackendPTY,
+			Tags:             []string{"dev"},
+			InputMode:        InputModeSingleWriter,
+		})
+		Expect(err).ToNot(HaveOccurred())
+
+		config := source.(Cloner).CloneConfig("clone-copy", "api (copy)")
+		Expect(config.Shell).To(Equal("/bin/sh"))
+		Expect(config.WorkingDirectory).To(Equal(dir))
+		Expect(config.Command).To(Equal("echo started-$CLONE_VAR"))
+		Expect(config.EnvVars).To(Equal(map[string]string{"CLONE_VAR": "42"}))
+		Expect(config.Tags).To(Equal([]string{"dev"}))
+
+		clone, err := manager.CreateSession(config)
+		Expect(err).ToNot(HaveOccurred())
+		metadata := clone.GetMetadata()
+		Expect(metadata.Name).To(Equal("api (copy)"))
+		Expect(metadata.Backend).To(Equal(SessionBackendPTY))
+		Expect(metadata.InputMode).To(Equal(InputModeSingleWriter))
+		Eventually(func() string {
+			return string(clone.(*TerminalSession).history.GetHistory())
+		}, "5s", "50ms").Should(ContainSubstring("started-42"))
+	})
+})
@@ -0,0 +1,98 @@
+package terminal
+
+import (
+	"errors"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"unicode"
+)
+
+var (
+	// ErrShellBusy is returned when a program other than the shell is in
+	// the foreground of the session, so a command typed in would reach it
+	ErrShellBusy = errors.New("a program is running in the foreground of the session")
+	// ErrChangeDirectoryUnsupported is returned for sessions whose
+	// directory cannot be changed from the hub
+	ErrChangeDirectoryUnsupported = errors.New("changing the directory is not supported for this session")
+)
+
+// DirectoryChanger is implemented by sessions whose shell can be moved to
+// another directory
+type DirectoryChanger interface {
+	ChangeDirectory(dir string) error
+}
+
+// The directory a session's shell is in is tracked in its metadata as
+// CurrentDirectory. Shells that report it with OSC 7, as many do for
+// terminal tabs, update it as soon as they change directory; elsewhere the
+// process sampler reads it from the foreground process.
+
+// parseOSC7 returns the directory of an OSC 7 report, a file URL such as
+// file://host/home/user with special characters percent-encoded
+func parseOSC7(text string) (string, bool) {
+	u, err := url.Parse(text)
+	if err != nil || u.Scheme != "file" || !strings.HasPrefix(u.Path, "/") {
+		return "", false
+	}
+	if strings.ContainsFunc(u.Path, unicode.IsControl) {
+		return "", false
+	}
+	return u.Path, true
+}
+
+// processDirectory returns the working directory of the foreground process
+// group's leader, or of the shell
+func processDirectory(pgrp, shellPID int) (string, bool) {
+	if dir, err := processWorkingDirectory(pgrp); err == nil {
+		return dir, true
+	}
+	if dir, err := processWorkingDirectory(shellPID); err == nil {
+		return dir, true
+	}
+	return "", false
+}
+
+// setCurrentDirectory records the directory the shell is in. An empty dir
+// is ignored.
+func (s *TerminalSession) setCurrentDirectory(dir string) {
+	if dir == "" {
+		return
+	}
+	s.metadataMu.Lock()
+	defer s.metadataMu.Unlock()
+	s.metadata.CurrentDirectory = dir
+}
+
+// ChangeDirectory types a cd to dir at the session's prompt, quoted so the
+// shell takes the path literally. It fails with ErrShellBusy when the shell
+// is not in the foreground; where that cannot be told, the cd is typed
+// anyway. Sessions on remote hosts have no local directories.
+func (s *TerminalSession) ChangeDirectory(dir string) error {
+	if s.backend == SessionBackendSSH {
+		return ErrChangeDirectoryUnsupported
+	}
+	if err := ValidateDirectoryPath(dir); err != nil {
+		return err
+	}
+	if pgrp, shellPID, err := s.foregroundProcess(); err == nil && pgrp != shellPID {
+		return ErrShellBusy
+	}
+	_, err := s.Write([]byte("cd -- " + shellQuote(dir) + "\r"))
+	return err
+}
+
+// ValidateDirectoryPath checks that dir is an absolute path a shell can be
+// sent to
+func ValidateDirectoryPath(dir string) error {
+	if dir == "" {
+		return errors.New("path is required")
+	}
+	if !filepath.IsAbs(dir) {
+		return errors.New("path must be absolute")
+	}
+	if strings.ContainsFunc(dir, unicode.IsControl) {
+		return errors.New("path must not contain control characters")
+	}
+	return nil
+}
@@ -0,0 +1,94 @@
+package terminal
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Working directory tracking", func() {
+	It("should take the directory reported with OSC 7", func() {
+		session := &TerminalSession{
+			history: NewInMemoryHistory(1024),
+			output:  newOutputRing(1024),
+			screen:  newVTScreen(80, 24),
+		}
+		session.metadata.WorkingDirectory = "/srv"
+
+		session.recordOutput([]byte("\x1b]7;file://host/home/me/my%20project\x07$ "))
+		Expect(session.GetMetadata().CurrentDirectory).To(Equal("/home/me/my project"))
+
+		// Split across writes, ended with ST; other reports are ignored
+		session.recordOutput([]byte("\x1b]7;file:///tm"))
+		session.recordOutput([]byte("p\x1b\\\x1b]7;http://host/x\x07\x1b]7;relative\x07"))
+		Expect(session.GetMetadata().CurrentDirectory).To(Equal("/tmp"))
+		Expect(session.currentDirectory()).To(Equal("/tmp"))
+		Expect(session.GetMetadata().WorkingDirectory).To(Equal("/srv"))
+	})
+
+	It("should read the directory of the shell's process", func() {
+		if !processInfoSupported {
+			Skip("process info is not available on this platform")
+		}
+
+		dir, err := filepath.EvalSymlinks(GinkgoT().TempDir())
+		Expect(err).ToNot(HaveOccurred())
+		Expect(os.Mkdir(filepath.Join(dir, "sub"), 0o755)).To(Succeed())
+		session, err := NewTerminalSession(SessionConfig{
+			ID:               "cwd-tracking",
+			Shell:            "/bin/sh",
+			WorkingDirectory: dir,
+			Backend:          SessionBackendPTY,
+			PTYService:       &DefaultPTYService{},
+		})
+		Expect(err).ToNot(HaveOccurred())
+		DeferCleanup(session.Close)
+
+		_, err = session.Write([]byte("cd sub\n"))
+		Expect(err).ToNot(HaveOccurred())
+		Eventually(func() string {
+			table, err := readProcessTable()
+			Expect(err).ToNot(HaveOccurred())
+			session.sampleProcess(table, time.Now())
+			return session.GetMetadata().CurrentDirectory
+		}, "5s", "50ms").Should(Equal(filepath.Join(dir, "sub")))
+	})
+})
+
+var _ = Describe("Changing the directory", func() {
+	It("should type a quoted cd at the prompt", func() {
+		if !processInfoSupported {
+			Skip("process info is not available on this platform")
+		}
+
+		dir, err := filepath.EvalSymlinks(GinkgoT().TempDir())
+		Expect(err).ToNot(HaveOccurred())
+		target := filepath.Join(dir, "it's $HOME")
+		Expect(os.Mkdir(target, 0o755)).To(Succeed())
+		session, err := NewTerminalSession(SessionConfig{
+			ID:         "cwd-change",
+			Shell:      "/bin/sh",
+			Backend:    SessionBackendPTY,
+			PTYService: &DefaultPTYService{},
+		})
+		Expect(err).ToNot(HaveOccurred())
+		DeferCleanup(session.Close)
+
+		Expect(session.ChangeDirectory(target)).To(Succeed())
+		Eventually(session.currentDirectory, "5s", "50ms").Should(Equal(target))
+
+		_, err = session.Write([]byte("sleep 30\n"))
+		Expect(err).ToNot(HaveOccurred())
+		Eventually(func() error { return session.ChangeDirectory(dir) }, "5s", "50ms").Should(MatchError(ErrShellBusy))
+	})
+
+	It("should check paths", func() {
+		Expect(ValidateDirectoryPath("/srv/app")).To(Succeed())
+		Expect(ValidateDirectoryPath("")).To(MatchError("path is required"))
+		Expect(ValidateDirectoryPath("srv")).To(MatchError("path must be absolute"))
+		Expect(ValidateDirectoryPath("/srv\n; rm -rf /")).To(HaveOccurred())
+	})
+})
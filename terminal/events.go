@@ -0,0 +1,112 @@
+package terminal
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/iwanhae/terminal-hub/notify"
+)
+
+// eventSubscriberBuffer is the number of events buffered per subscriber
+// before further events are dropped for it
+const eventSubscriberBuffer = 64
+
+// Session event types
+const (
+	SessionEventCreated = "created" // the session was created
+	SessionEventClosed  = "closed"  // the session was closed through the API
+	SessionEventWatch   = "watch"   // a watch rule fired
+	SessionEventExit    = "exit"    // the session's process exited on its own
+)
+
+// SessionEvent is published on the event bus and streamed on /ws/events
+type SessionEvent struct {
+	Type        string        `json:"type"`
+	SessionID   string        `json:"session_id"`
+	SessionName string        `json:"session_name,omitempty"`
+	RuleID      string        `json:"rule_id,omitempty"`
+	RuleType    WatchRuleType `json:"rule_type,omitempty"`
+	Match       string        `json:"match,omitempty"`        // matched output for pattern rules
+	HistoryFile string        `json:"history_file,omitempty"` // exit: where the history was archived
+	Timestamp   int64         `json:"timestamp"`              // unix timestamp
+}
+
+// Notifies reports whether the event is delivered to push and chat
+// notification channels
+func (e SessionEvent) Notifies() bool {
+	return e.Type == SessionEventWatch || e.Type == SessionEventExit
+}
+
+// Notification describes the event for push and chat notification channels
+func (e SessionEvent) Notification() notify.Notification {
+	name := e.SessionName
+	if name == "" {
+		name = e.SessionID
+	}
+
+	if e.Type == SessionEventExit {
+		return notify.Notification{
+			Event:   notify.EventSessionExit,
+			Title:   fmt.Sprintf("Session %q exited", name),
+			Message: fmt.Sprintf("The process in session %q exited.", name),
+			Tags:    []string{"checkered_flag"},
+		}
+	}
+
+	message := fmt.Sprintf("Watch rule %s fired in session %q.", e.RuleType, name)
+	if e.Match != "" {
+		message = fmt.Sprintf("Session %q printed: %s", name, e.Match)
+	}
+	return notify.Notification{
+		Event:    notify.EventSessionWatch,
+		Title:    fmt.Sprintf("Watch rule fired in %q", name),
+		Message:  message,
+		Tags:     []string{"eyes"},
+		Priority: notify.PriorityHigh,
+	}
+}
+
+// EventBus fans session events out to subscribers
+type EventBus struct {
+	mu          sync.Mutex
+	subscribers map[chan SessionEvent]struct{}
+}
+
+// NewEventBus creates an event bus without subscribers
+func NewEventBus() *EventBus {
+	return &EventBus{subscribers: make(map[chan SessionEvent]struct{})}
+}
+
+// Subscribe returns a channel receiving published events and a function that
+// unsubscribes and closes the channel
+func (b *EventBus) Subscribe() (<-chan SessionEvent, func()) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	ch := make(chan SessionEvent, eventSubscriberBuffer)
+	b.subscribers[ch] = struct{}{}
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			b.mu.Lock()
+			defer b.mu.Unlock()
+			delete(b.subscribers, ch)
+			close(ch)
+		})
+	}
+}
+
+// Publish delivers event to every subscriber without blocking. Subscribers
+// whose buffer is full miss the event.
+func (b *EventBus) Publish(event SessionEvent) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for ch := range b.subscribers {
+		select {
+		case ch <- event:
+		default:
+		}
+	}
+}
@@ -0,0 +1,240 @@
+package terminal
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultExecTimeout is how long Exec waits for a command by default
+	DefaultExecTimeout = 30 * time.Second
+	// MaxExecTimeout is the longest a request may ask Exec to wait
+	MaxExecTimeout = 10 * time.Minute
+
+	maxExecCommandLength = 4096
+	maxExecOutputBytes   = 1 << 20 // output kept beyond this is dropped
+	maxExecOSCSize       = 256     // OSC bodies longer than this cannot be markers
+)
+
+var (
+	// ErrExecBusy is returned when a command is already running through Exec
+	// in the session
+	ErrExecBusy = errors.New("another command is already running in this session")
+	// ErrExecUnsupported is returned for sessions that cannot run commands
+	ErrExecUnsupported = errors.New("running commands is not supported for this session")
+)
+
+// ExecResult is the outcome of a command run with Exec
+type ExecResult struct {
+	Output     string `json:"output"`              // printed text, without escape sequences
+	ExitCode   *int   `json:"exit_code,omitempty"` // as reported by the shell, if it did
+	Finished   bool   `json:"finished"`            // the shell reported the command finished
+	TimedOut   bool   `json:"timed_out,omitempty"`
+	Truncated  bool   `json:"truncated,omitempty"` // output beyond 1 MiB was dropped
+	DurationMs int64  `json:"duration_ms"`
+}
+
+// CommandRunner is implemented by sessions that can run a command at their
+// shell's prompt and capture its output
+type CommandRunner interface {
+	Exec(ctx context.Context, command string, timeout time.Duration) (ExecResult, error)
+}
+
+// ValidateExecCommand checks that command is a single, non-empty line
+func ValidateExecCommand(command string) error {
+	if strings.TrimSpace(command) == "" {
+		return errors.New("command is required")
+	}
+	if len(command) > maxExecCommandLength {
+		return fmt.Errorf("command must be at most %d bytes", maxExecCommandLength)
+	}
+	if strings.ContainsAny(command, "\r\n") {
+		return errors.New("command must be a single line")
+	}
+	return nil
+}
+
+// execRun captures the output of a command run with Exec. The shell marks
+// where the command's output starts and ends with the OSC 133 "C" and "D"
+// sequences of shell integration; output before "C" is dropped once it
+// arrives, so shells that send it leave out the echoed command line.
+type execRun struct {
+	scanState int
+	osc       []byte // body of the OSC sequence being read
+	output    []byte
+	truncated bool
+	exitCode  *int
+	finished  bool
+	done      chan struct{} // closed when the command finished or the session ended
+}
+
+// observe reads output of the session, reporting whether the command finished
+func (e *execRun) observe(data []byte) bool {
+	for _, b := range data {
+		switch e.scanState {
+		case scanGround:
+			switch {
+			case b == 0x1b:
+				e.scanState = scanEscape
+			case b == '\a':
+			case len(e.output) < maxExecOutputBytes:
+				e.output = append(e.output, b)
+			default:
+				e.truncated = true
+			}
+		case scanEscape:
+			switch b {
+			case ']':
+				e.osc = e.osc[:0]
+				e.scanState = scanString
+			case '[':
+				e.scanState = scanCSI
+			case 'P', 'X', '^', '_':
+				// Only OSC bodies are kept, so other strings never match a marker
+				e.osc = append(e.osc[:0], 0)
+				e.scanState = scanString
+			default:
+				e.scanState = scanGround
+			}
+		case scanCSI:
+			if b >= 0x40 && b <= 0x7e {
+				e.scanState = scanGround
+			}
+		case scanString:
+			switch b {
+			case '\a':
+				e.scanState = scanGround
+				if e.endOSC() {
+					return true
+				}
+			case 0x1b:
+				e.scanState = scanStringEsc
+			default:
+				if len(e.osc) < maxExecOSCSize {
+					e.osc = append(e.osc, b)
+				}
+			}
+		case scanStringEsc:
+			if b == '\\' {
+				e.scanState = scanGround
+				if e.endOSC() {
+					return true
+				}
+			} else {
+				e.scanState = scanString
+			}
+		}
+	}
+	return false
+}
+
+// endOSC handles a complete OSC sequence, reporting whether it marked the
+// end of the command
+func (e *execRun) endOSC() bool {
+	body := string(e.osc)
+	switch {
+	case body == "133;C" || strings.HasPrefix(body, "133;C;"):
+		e.output = e.output[:0]
+		e.truncated = false
+	case body == "133;D" || strings.HasPrefix(body, "133;D;"):
+		params := strings.Split(strings.TrimPrefix(body, "133;D"), ";")
+		if len(params) > 1 {
+			if code, err := strconv.Atoi(params[1]); err == nil {
+				e.exitCode = &code
+			}
+		}
+		e.finished = true
+		return true
+	}
+	return false
+}
+
+// result returns what the command printed so far
+func (e *execRun) result() ExecResult {
+	return ExecResult{
+		Output:    string(bytes.ReplaceAll(e.output, []byte("\r\n"), []byte("\n"))),
+		ExitCode:  e.exitCode,
+		Finished:  e.finished,
+		Truncated: e.truncated,
+	}
+}
+
+// Exec types command at the session's prompt and waits until the shell
+// reports that it finished, the timeout passes or ctx is done. The shell
+// must send OSC 133 sequences, as shells with shell integration do;
+// otherwise Exec returns the output printed until the timeout.
+func (s *TerminalSession) Exec(ctx context.Context, command string, timeout time.Duration) (ExecResult, error) {
+	if err := ValidateExecCommand(command); err != nil {
+		return ExecResult{}, err
+	}
+	if timeout <= 0 {
+		timeout = DefaultExecTimeout
+	}
+
+	run := &execRun{done: make(chan struct{})}
+	s.execMu.Lock()
+	if s.execRun != nil {
+		s.execMu.Unlock()
+		return ExecResult{}, ErrExecBusy
+	}
+	s.execRun = run
+	s.execMu.Unlock()
+	defer func() {
+		s.execMu.Lock()
+		if s.execRun == run {
+			s.execRun = nil
+		}
+		s.execMu.Unlock()
+	}()
+
+	start := time.Now()
+	if _, err := s.Write([]byte(command + "\r")); err != nil {
+		return ExecResult{}, err
+	}
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	timedOut := false
+	select {
+	case <-run.done:
+	case <-timer.C:
+		timedOut = true
+	case <-ctx.Done():
+		return ExecResult{}, ctx.Err()
+	}
+
+	s.execMu.Lock()
+	result := run.result()
+	s.execMu.Unlock()
+	result.TimedOut = timedOut && !result.Finished
+	result.DurationMs = time.Since(start).Milliseconds()
+	return result, nil
+}
+
+// observeExec passes output to the command running through Exec
+func (s *TerminalSession) observeExec(data []byte) {
+	s.execMu.Lock()
+	defer s.execMu.Unlock()
+
+	if s.execRun != nil && s.execRun.observe(data) {
+		close(s.execRun.done)
+		s.execRun = nil
+	}
+}
+
+// endExec stops waiting for the command running through Exec, as the
+// session's output has ended
+func (s *TerminalSession) endExec() {
+	s.execMu.Lock()
+	defer s.execMu.Unlock()
+
+	if s.execRun != nil {
+		close(s.execRun.done)
+		s.execRun = nil
+	}
+}
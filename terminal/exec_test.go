@@ -0,0 +1,75 @@
+package terminal
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Session exec", func() {
+	It("should capture output between the OSC 133 markers", func() {
+		run := &execRun{}
+		Expect(run.observe([]byte("$ make\r\n\x1b]133;C\x07\x1b[32mok\x1b[0m\r\n"))).To(BeFalse())
+		// Markers may be split across reads and end with ST
+		Expect(run.observe([]byte("done\r\n\x1b]133;D;"))).To(BeFalse())
+		Expect(run.observe([]byte("2\x1b\\$ "))).To(BeTrue())
+
+		result := run.result()
+		Expect(result.Output).To(Equal("ok\ndone\n"))
+		Expect(result.Finished).To(BeTrue())
+		Expect(*result.ExitCode).To(Equal(2))
+	})
+
+	It("should keep all output when the shell only marks the end", func() {
+		run := &execRun{}
+		Expect(run.observe([]byte("echo hi\r\nhi\r\n\x1bP133;D\x1b\\\x1b]7;file:///\x07"))).To(BeFalse())
+		Expect(run.observe([]byte("\x1b]133;D\x07"))).To(BeTrue())
+
+		result := run.result()
+		Expect(result.Output).To(Equal("echo hi\nhi\n"))
+		Expect(result.ExitCode).To(BeNil())
+	})
+
+	It("should check commands", func() {
+		Expect(ValidateExecCommand("ls -l")).To(Succeed())
+		Expect(ValidateExecCommand(" ")).To(MatchError("command is required"))
+		Expect(ValidateExecCommand("ls\nrm -rf /")).To(MatchError("command must be a single line"))
+		Expect(ExecSessionRequest{Command: "ls", TimeoutSeconds: 601}.Validate()).To(MatchError(ContainSubstring("timeout_seconds")))
+	})
+
+	It("should run a command in a shell session", func() {
+		session, err := NewTerminalSession(SessionConfig{
+			ID:         "exec-pty",
+			Shell:      "/bin/sh",
+			Backend:    SessionBackendPTY,
+			PTYService: &DefaultPTYService{},
+		})
+		Expect(err).ToNot(HaveOccurred())
+		DeferCleanup(session.Close)
+
+		// The command prints the markers itself, as shell integration would
+		result, err := session.Exec(context.Background(), `printf '\033]133;C\007'; echo out-$((40+2)); printf '\033]133;D;%d\007' 3`, 5*time.Second)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result.Finished).To(BeTrue())
+		Expect(result.Output).To(Equal("out-42\n"))
+		Expect(*result.ExitCode).To(Equal(3))
+
+		// Without markers the command runs until the timeout
+		result, err = session.Exec(context.Background(), "echo unmarked", 300*time.Millisecond)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result.Finished).To(BeFalse())
+		Expect(result.TimedOut).To(BeTrue())
+		Expect(result.Output).To(ContainSubstring("unmarked"))
+	})
+
+	It("should run one command at a time", func() {
+		session := &TerminalSession{execRun: &execRun{done: make(chan struct{})}}
+		_, err := session.Exec(context.Background(), "ls", time.Second)
+		Expect(err).To(MatchError(ErrExecBusy))
+
+		session.endExec()
+		Expect(session.execRun).To(BeNil())
+	})
+})
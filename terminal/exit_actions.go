@@ -0,0 +1,143 @@
+package terminal
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"time"
+)
+
+// exitCommandTimeout bounds how long an on-exit command may run
+const exitCommandTimeout = time.Minute
+
+// ExitActions run when a session's process exits on its own. They do not
+// run when the session is deleted or the server shuts down.
+type ExitActions struct {
+	Command        string `json:"command,omitempty"`         // run with /bin/sh -c in the session's working directory
+	ArchiveHistory bool   `json:"archive_history,omitempty"` // write the output history to the archive directory
+	WebhookURL     string `json:"webhook_url,omitempty"`     // receives a JSON POST of the exit event
+}
+
+// IsZero reports whether no action is set
+func (a ExitActions) IsZero() bool {
+	return a == ExitActions{}
+}
+
+// Validate checks the webhook URL
+func (a ExitActions) Validate() error {
+	if a.WebhookURL != "" {
+		parsed, err := url.Parse(a.WebhookURL)
+		if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			return fmt.Errorf("invalid webhook URL %q: must be an absolute http(s) URL", a.WebhookURL)
+		}
+	}
+	return nil
+}
+
+// GetHistoryArchiveDirFromEnv returns TERMINAL_HUB_HISTORY_ARCHIVE_DIR,
+// defaulting to ~/.terminal-hub/history
+func GetHistoryArchiveDirFromEnv() string {
+	if dir := os.Getenv("TERMINAL_HUB_HISTORY_ARCHIVE_DIR"); dir != "" {
+		return dir
+	}
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "history"
+	}
+	return filepath.Join(homeDir, ".terminal-hub", "history")
+}
+
+// checkWriteGuard runs the session's write guard, if any
+func (s *TerminalSession) checkWriteGuard() error {
+	if s.writeGuard == nil {
+		return nil
+	}
+	return s.writeGuard()
+}
+
+// runExitActions publishes the exit event and runs the session's on-exit
+// actions, in order: archive the history, post the webhook, run the command
+func (s *TerminalSession) runExitActions() {
+	metadata := s.GetMetadata()
+	event := SessionEvent{
+		Type:        SessionEventExit,
+		SessionID:   s.id,
+		SessionName: metadata.Name,
+		Timestamp:   time.Now().Unix(),
+	}
+
+	actions := s.exitActions
+	if actions.ArchiveHistory {
+		if err := s.checkWriteGuard(); err != nil {
+			log.Printf("Session %s: not archiving history: %v", s.id, err)
+		} else if path, err := s.archiveHistory(GetHistoryArchiveDirFromEnv()); err != nil {
+			log.Printf("Session %s: failed to archive history: %v", s.id, err)
+		} else {
+			event.HistoryFile = path
+			log.Printf("Session %s: history archived to %s", s.id, path)
+		}
+	}
+
+	if s.onEvent != nil {
+		s.onEvent(event)
+	}
+	if actions.WebhookURL != "" {
+		if err := postEventWebhook(actions.WebhookURL, event); err != nil {
+			log.Printf("Session %s: failed to send exit webhook: %v", s.id, err)
+		}
+	}
+	if actions.Command != "" {
+		if err := s.runExitCommand(actions.Command, metadata, event.HistoryFile); err != nil {
+			log.Printf("Session %s: on-exit command failed: %v", s.id, err)
+		}
+	}
+}
+
+// archiveHistory writes the output history to a new file in dir and returns
+// its path
+func (s *TerminalSession) archiveHistory(dir string) (string, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", err
+	}
+	name := fmt.Sprintf("%s-%s.log", time.Now().UTC().Format("20060102T150405Z"), s.id)
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, s.history.GetHistory(), 0600); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// runExitCommand runs command as the session's account. The session ID,
+// name and archived history file are passed in the environment.
+func (s *TerminalSession) runExitCommand(command string, metadata SessionMetadata, historyFile string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), exitCommandTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "/bin/sh", "-c", command)
+	cmd.Dir = metadata.WorkingDirectory
+	cmd.Env = append(os.Environ(),
+		"TERMINAL_HUB_SESSION_ID="+s.id,
+		"TERMINAL_HUB_SESSION_NAME="+metadata.Name,
+		"TERMINAL_HUB_HISTORY_FILE="+historyFile,
+	)
+	s.runAs.Apply(cmd)
+
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%w: %s", err, truncateForLog(output))
+	}
+	return nil
+}
+
+// truncateForLog shortens command output for a log line
+func truncateForLog(output []byte) string {
+	const max = 512
+	if len(output) > max {
+		return string(output[:max]) + "..."
+	}
+	return string(output)
+}
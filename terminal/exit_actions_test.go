@@ -0,0 +1,104 @@
+package terminal
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Session exit actions", func() {
+	It("should archive history, post the webhook and run the command when the shell exits", func() {
+		archiveDir := GinkgoT().TempDir()
+		GinkgoT().Setenv("TERMINAL_HUB_HISTORY_ARCHIVE_DIR", archiveDir)
+		marker := filepath.Join(GinkgoT().TempDir(), "exited")
+
+		webhookEvents := make(chan SessionEvent, 1)
+		webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var event SessionEvent
+			if err := json.NewDecoder(r.Body).Decode(&event); err == nil {
+				webhookEvents <- event
+			}
+		}))
+		DeferCleanup(webhook.Close)
+
+		sm := NewSessionManager()
+		DeferCleanup(sm.CloseAll)
+		events, unsubscribe := sm.Events().Subscribe()
+		DeferCleanup(unsubscribe)
+
+		sess, err := sm.CreateSession(SessionConfig{
+			ID:         "exit-actions",
+			Name:       "builds",
+			Shell:      "/bin/sh",
+			Backend:    SessionBackendPTY,
+			PTYService: &DefaultPTYService{},
+			ExitActions: ExitActions{
+				Command:        `echo "$TERMINAL_HUB_SESSION_NAME" > ` + marker,
+				ArchiveHistory: true,
+				WebhookURL:     webhook.URL,
+			},
+		})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(sess.GetMetadata().OnExit).ToNot(BeNil())
+
+		_, err = sess.Write([]byte("echo archived-$((1+1)); exit\n"))
+		Expect(err).ToNot(HaveOccurred())
+
+		var event SessionEvent
+		Eventually(events, "5s").Should(Receive(&event))
+		Expect(event.Type).To(Equal(SessionEventCreated))
+		Eventually(events, "5s").Should(Receive(&event))
+		Expect(event.Type).To(Equal(SessionEventExit))
+		Expect(event.SessionName).To(Equal("builds"))
+		Expect(filepath.Dir(event.HistoryFile)).To(Equal(archiveDir))
+		Expect(os.ReadFile(event.HistoryFile)).To(ContainSubstring("archived-2"))
+
+		Eventually(webhookEvents, "5s").Should(Receive(Equal(event)))
+		Eventually(func() (string, error) {
+			data, err := os.ReadFile(marker)
+			return string(data), err
+		}, "5s", "50ms").Should(Equal("builds\n"))
+		Expect(sm.SessionCount()).To(BeZero())
+	})
+
+	It("should skip archiving history while the write guard fails", func() {
+		archiveDir := GinkgoT().TempDir()
+		GinkgoT().Setenv("TERMINAL_HUB_HISTORY_ARCHIVE_DIR", archiveDir)
+
+		sm := NewSessionManager()
+		DeferCleanup(sm.CloseAll)
+		sm.SetWriteGuard(func() error { return errors.New("low disk space") })
+		events, unsubscribe := sm.Events().Subscribe()
+		DeferCleanup(unsubscribe)
+
+		sess, err := sm.CreateSession(SessionConfig{
+			ID:          "exit-guarded",
+			Shell:       "/bin/sh",
+			Backend:     SessionBackendPTY,
+			PTYService:  &DefaultPTYService{},
+			ExitActions: ExitActions{ArchiveHistory: true},
+		})
+		Expect(err).ToNot(HaveOccurred())
+		_, err = sess.Write([]byte("exit\n"))
+		Expect(err).ToNot(HaveOccurred())
+
+		var event SessionEvent
+		Eventually(events, "5s").Should(Receive(&event))
+		Eventually(events, "5s").Should(Receive(&event))
+		Expect(event.Type).To(Equal(SessionEventExit))
+		Expect(event.HistoryFile).To(BeEmpty())
+		Expect(os.ReadDir(archiveDir)).To(BeEmpty())
+	})
+
+	It("should validate the webhook URL", func() {
+		Expect(ExitActions{}.IsZero()).To(BeTrue())
+		Expect(ExitActions{WebhookURL: "ftp://example.com"}.Validate()).To(HaveOccurred())
+		Expect(ExitActions{WebhookURL: "https://example.com/hook"}.Validate()).To(Succeed())
+	})
+})
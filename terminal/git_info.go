@@ -0,0 +1,169 @@
+package terminal
+
+import (
+	"bufio"
+	"context"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// defaultGitSampleInterval is how often git info is refreshed
+const defaultGitSampleInterval = 10 * time.Second
+
+// gitStatusTimeout bounds one git status call, which can be slow in large
+// repositories or on network filesystems
+const gitStatusTimeout = 3 * time.Second
+
+// GitInfo describes the git repository a session's terminal is in, as of the
+// last sample
+type GitInfo struct {
+	Root      string    `json:"root"`               // top-level directory of the work tree
+	Branch    string    `json:"branch,omitempty"`   // empty when the HEAD is detached
+	Commit    string    `json:"commit,omitempty"`   // abbreviated HEAD commit, empty before the first commit
+	Upstream  string    `json:"upstream,omitempty"` // e.g. "origin/main", empty without one
+	Ahead     int       `json:"ahead"`              // commits not on the upstream
+	Behind    int       `json:"behind"`             // upstream commits not on the branch
+	Dirty     bool      `json:"dirty"`              // staged, unstaged or untracked changes
+	SampledAt time.Time `json:"sampled_at"`
+}
+
+// GetGitSampleIntervalFromEnv returns TERMINAL_HUB_GIT_SAMPLE_INTERVAL, or
+// 10s. Zero or a negative duration turns sampling off.
+func GetGitSampleIntervalFromEnv() time.Duration {
+	if val := os.Getenv("TERMINAL_HUB_GIT_SAMPLE_INTERVAL"); val != "" {
+		if d, err := time.ParseDuration(val); err == nil {
+			return d
+		}
+	}
+	return defaultGitSampleInterval
+}
+
+// StartGitSampler refreshes the git info in session metadata every interval.
+// It returns right away when git is not installed.
+func (sm *SessionManager) StartGitSampler(interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+	if _, err := exec.LookPath("git"); err != nil {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		sm.sampleGit()
+	}
+}
+
+// sampleGit refreshes the git info of every session, running git once per
+// directory
+func (sm *SessionManager) sampleGit() {
+	sm.mu.RLock()
+	sessions := make([]*TerminalSession, 0, len(sm.sessions))
+	for _, sess := range sm.sessions {
+		if terminalSess, ok := sess.(*TerminalSession); ok {
+			sessions = append(sessions, terminalSess)
+		}
+	}
+	sm.mu.RUnlock()
+
+	byDir := make(map[string]*GitInfo)
+	for _, sess := range sessions {
+		dir := sess.currentDirectory()
+		info, ok := byDir[dir]
+		if !ok && dir != "" {
+			info = readGitInfo(dir, time.Now())
+			byDir[dir] = info
+		}
+		sess.metadataMu.Lock()
+		sess.metadata.Git = info
+		sess.metadataMu.Unlock()
+	}
+}
+
+// currentDirectory returns the working directory of the program in the
+// foreground of the session, or of its shell. Where the process cannot be
+// inspected it falls back to the directory last tracked, then to the one the
+// session started in; sessions on remote hosts have none.
+func (s *TerminalSession) currentDirectory() string {
+	if s.backend == SessionBackendSSH {
+		return ""
+	}
+	if pgrp, shellPID, err := s.foregroundProcess(); err == nil {
+		if dir, ok := processDirectory(pgrp, shellPID); ok {
+			return dir
+		}
+	}
+	s.metadataMu.RLock()
+	defer s.metadataMu.RUnlock()
+	if s.metadata.CurrentDirectory != "" {
+		return s.metadata.CurrentDirectory
+	}
+	return s.metadata.WorkingDirectory
+}
+
+// readGitInfo runs git status in dir. It returns nil when dir is not in a
+// work tree or git fails.
+func readGitInfo(dir string, now time.Time) *GitInfo {
+	ctx, cancel := context.WithTimeout(context.Background(), gitStatusTimeout)
+	defer cancel()
+
+	root, err := runGit(ctx, dir, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return nil
+	}
+	status, err := runGit(ctx, dir, "status", "--porcelain=v2", "--branch")
+	if err != nil {
+		return nil
+	}
+	info := parseGitStatus(status)
+	info.Root = strings.TrimSpace(root)
+	info.SampledAt = now
+	return info
+}
+
+// runGit runs a read-only git command in dir. Optional locks are turned off
+// so sampling never competes with the user's own git commands for the index.
+func runGit(ctx context.Context, dir string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", append([]string{"-C", dir}, args...)...)
+	cmd.Env = append(os.Environ(), "GIT_OPTIONAL_LOCKS=0", "LC_ALL=C")
+	output, err := cmd.Output()
+	return string(output), err
+}
+
+// parseGitStatus reads the output of git status --porcelain=v2 --branch
+func parseGitStatus(output string) *GitInfo {
+	info := &GitInfo{}
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		line := scanner.Text()
+		header, ok := strings.CutPrefix(line, "# ")
+		if !ok {
+			if line != "" {
+				info.Dirty = true
+			}
+			continue
+		}
+		key, value, _ := strings.Cut(header, " ")
+		switch key {
+		case "branch.oid":
+			if value != "(initial)" && len(value) >= 7 {
+				info.Commit = value[:7]
+			}
+		case "branch.head":
+			if value != "(detached)" {
+				info.Branch = value
+			}
+		case "branch.upstream":
+			info.Upstream = value
+		case "branch.ab":
+			ahead, behind, _ := strings.Cut(value, " ")
+			info.Ahead, _ = strconv.Atoi(strings.TrimPrefix(ahead, "+"))
+			info.Behind, _ = strconv.Atoi(strings.TrimPrefix(behind, "-"))
+		}
+	}
+	return info
+}
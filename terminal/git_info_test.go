@@ -0,0 +1,78 @@
+package terminal
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Git info", func() {
+	It("should parse the branch, upstream and ahead/behind counts", func() {
+		info := parseGitStatus("# branch.oid 0123456789abcdef\n# branch.head feature/x\n# branch.upstream origin/feature/x\n# branch.ab +2 -1\n")
+		Expect(*info).To(Equal(GitInfo{Branch: "feature/x", Commit: "0123456", Upstream: "origin/feature/x", Ahead: 2, Behind: 1}))
+
+		info = parseGitStatus("# branch.oid (initial)\n# branch.head (detached)\n? notes.txt\n")
+		Expect(*info).To(Equal(GitInfo{Dirty: true}))
+	})
+
+	It("should follow the shell into a repository", func() {
+		if _, err := exec.LookPath("git"); err != nil {
+			Skip("git is not installed")
+		}
+		if !processInfoSupported {
+			Skip("process info is not available on this platform")
+		}
+
+		git := func(dir string, args ...string) {
+			cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+			cmd.Env = append(os.Environ(),
+				"GIT_AUTHOR_NAME=test", "GIT_AUTHOR_EMAIL=test@example.com",
+				"GIT_COMMITTER_NAME=test", "GIT_COMMITTER_EMAIL=test@example.com")
+			output, err := cmd.CombinedOutput()
+			Expect(err).ToNot(HaveOccurred(), string(output))
+		}
+		upstream := filepath.Join(GinkgoT().TempDir(), "upstream.git")
+		repo := filepath.Join(GinkgoT().TempDir(), "repo")
+		git(".", "init", "--bare", upstream)
+		git(".", "init", "-b", "feature/x", repo)
+		git(repo, "commit", "--allow-empty", "-m", "first")
+		git(repo, "remote", "add", "origin", upstream)
+		git(repo, "push", "-u", "origin", "feature/x")
+		git(repo, "commit", "--allow-empty", "-m", "second")
+		git(repo, "commit", "--allow-empty", "-m", "third")
+
+		sm := NewSessionManager()
+		DeferCleanup(sm.CloseAll)
+		sess, err := sm.CreateSession(SessionConfig{
+			ID:               "git-info",
+			Shell:            "/bin/sh",
+			WorkingDirectory: GinkgoT().TempDir(),
+			Backend:          SessionBackendPTY,
+			PTYService:       &DefaultPTYService{},
+		})
+		Expect(err).ToNot(HaveOccurred())
+
+		sm.sampleGit()
+		Expect(sess.GetMetadata().Git).To(BeNil())
+
+		_, err = sess.Write([]byte("cd " + repo + " && touch untracked\n"))
+		Expect(err).ToNot(HaveOccurred())
+		sample := func() *GitInfo {
+			sm.sampleGit()
+			return sess.GetMetadata().Git
+		}
+		Eventually(sample, "5s", "50ms").Should(HaveField("Dirty", true))
+
+		info := sample()
+		Expect(info.Branch).To(Equal("feature/x"))
+		Expect(info.Upstream).To(Equal("origin/feature/x"))
+		Expect(info.Ahead).To(Equal(2))
+		Expect(info.Behind).To(Equal(0))
+		root, err := filepath.EvalSymlinks(repo)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(filepath.EvalSymlinks(info.Root)).To(Equal(root))
+	})
+})
@@ -0,0 +1,118 @@
+package terminal
+
+import (
+	"log"
+	"time"
+)
+
+// HandoffSession describes a tmux-backed session handed to a new hub process
+// during an upgrade, which reattaches to the still running tmux session
+type HandoffSession struct {
+	ID               string          `json:"id"`
+	Name             string          `json:"name"`
+	TmuxSession      string          `json:"tmux_session"`
+	Adopted          bool            `json:"adopted,omitempty"`
+	WorkingDirectory string          `json:"working_directory,omitempty"`
+	Tags             []string        `json:"tags,omitempty"`
+	InputMode        string          `json:"input_mode,omitempty"`
+	ResizePolicy     ResizePolicy    `json:"resize_policy"`
+	Limits           *ResourceLimits `json:"limits,omitempty"`
+	OnExit           *ExitActions    `json:"on_exit,omitempty"`
+	Launch           *SessionLaunch  `json:"launch,omitempty"`
+	CreatedAt        time.Time       `json:"created_at"`
+}
+
+// SessionConfig returns the configuration that reattaches to the session
+func (h HandoffSession) SessionConfig() SessionConfig {
+	config := SessionConfig{
+		ID:               h.ID,
+		Name:             h.Name,
+		WorkingDirectory: h.WorkingDirectory,
+		Backend:          SessionBackendTmux,
+		HistorySize:      defaultHistorySize,
+		Tags:             h.Tags,
+		InputMode:        h.InputMode,
+		ResizePolicy:     h.ResizePolicy,
+		Launch:           h.Launch,
+		CreatedAt:        h.CreatedAt,
+	}
+	// Sessions created by the hub are found again by their ID, see
+	// startTmuxSession, and are still killed when deleted
+	if h.Adopted {
+		config.AdoptTmuxSession = h.TmuxSession
+	}
+	if h.Limits != nil {
+		config.Limits = *h.Limits
+	}
+	if h.OnExit != nil {
+		config.ExitActions = *h.OnExit
+	}
+	return config
+}
+
+// HandoffSessions describes the tmux-backed sessions, which another hub
+// process can reattach to with RestoreSessions. Other sessions end with this
+// process.
+func (sm *SessionManager) HandoffSessions() []HandoffSession {
+	sm.mu.RLock()
+	defer sm.mu.RUnlock()
+
+	var handoff []HandoffSession
+	for id, sess := range sm.sessions {
+		terminalSess, ok := sess.(*TerminalSession)
+		if !ok || terminalSess.backend != SessionBackendTmux || terminalSess.tmuxSessionName == "" {
+			continue
+		}
+		metadata := terminalSess.GetMetadata()
+		launch := terminalSess.launch
+		handoff = append(handoff, HandoffSession{
+			ID:               id,
+			Name:             metadata.Name,
+			TmuxSession:      terminalSess.tmuxSessionName,
+			Adopted:          terminalSess.tmuxAdopted,
+			WorkingDirectory: metadata.WorkingDirectory,
+			Tags:             metadata.Tags,
+			InputMode:        metadata.InputMode,
+			ResizePolicy:     metadata.ResizePolicy,
+			Limits:           metadata.Limits,
+			OnExit:           metadata.OnExit,
+			Launch:           &launch,
+			CreatedAt:        metadata.CreatedAt,
+		})
+	}
+	return handoff
+}
+
+// DetachAll closes every session like CloseAll, but leaves tmux sessions
+// running for the process they were handed off to
+func (sm *SessionManager) DetachAll() error {
+	sm.mu.Lock()
+	for _, sess := range sm.sessions {
+		if terminalSess, ok := sess.(*TerminalSession); ok {
+			terminalSess.closeMu.Lock()
+			terminalSess.tmuxHandedOff = true
+			terminalSess.closeMu.Unlock()
+		}
+	}
+	sm.mu.Unlock()
+	return sm.CloseAll()
+}
+
+// RestoreSessions reattaches to sessions handed off by a previous hub
+// process, skipping those whose tmux session has ended since. It returns the
+// number of sessions restored.
+func (sm *SessionManager) RestoreSessions(sessions []HandoffSession) int {
+	restored := 0
+	for _, handoff := range sessions {
+		if err := newTmuxCommand(sm.RunAs(), "has-session", "-t", "="+handoff.TmuxSession).Run(); err != nil {
+			log.Printf("Session %s: tmux session %q ended during the upgrade", handoff.ID, handoff.TmuxSession)
+			continue
+		}
+		if _, err := sm.CreateSession(handoff.SessionConfig()); err != nil {
+			log.Printf("Session %s: failed to reattach after the upgrade: %v", handoff.ID, err)
+			continue
+		}
+		restored++
+	}
+	return restored
+}
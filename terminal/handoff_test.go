@@ -0,0 +1,65 @@
+package terminal
+
+import (
+	"os/exec"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Session handoff", func() {
+	BeforeEach(func() {
+		if _, err := exec.LookPath("tmux"); err != nil {
+			Skip("tmux is not installed")
+		}
+		// Use a private tmux server so tests never touch the user's sessions
+		GinkgoT().Setenv("TMUX_TMPDIR", GinkgoT().TempDir())
+		GinkgoT().Setenv("TMUX", "")
+	})
+
+	It("should reattach to tmux sessions detached by another manager", func() {
+		previous := NewSessionManager()
+		_, err := previous.CreateSession(SessionConfig{
+			ID:      "handoff-test",
+			Name:    "build",
+			Shell:   "/bin/sh",
+			Backend: SessionBackendTmux,
+			Tags:    []string{"ci"},
+		})
+		Expect(err).ToNot(HaveOccurred())
+		_, err = previous.CreateSession(SessionConfig{ID: "handoff-pty", Shell: "/bin/sh", Backend: SessionBackendPTY})
+		Expect(err).ToNot(HaveOccurred())
+		Eventually(func() error {
+			return newTmuxCommand(nil, "has-session", "-t", "=handoff-test").Run()
+		}, "5s", "50ms").Should(Succeed())
+
+		handoff := previous.HandoffSessions()
+		Expect(handoff).To(HaveLen(1))
+		Expect(handoff[0].Name).To(Equal("build"))
+		Expect(handoff[0].Tags).To(Equal([]string{"ci"}))
+		Expect(handoff[0].Launch.Shell).To(Equal("/bin/sh"))
+
+		Expect(previous.DetachAll()).To(Succeed())
+		Expect(previous.SessionCount()).To(BeZero())
+		Expect(newTmuxCommand(nil, "has-session", "-t", "=handoff-test").Run()).To(Succeed())
+
+		next := NewSessionManager()
+		Expect(next.RestoreSessions(handoff)).To(Equal(1))
+		DeferCleanup(next.CloseAll)
+
+		sess, ok := next.Get("handoff-test")
+		Expect(ok).To(BeTrue())
+		metadata := sess.GetMetadata()
+		Expect(metadata.Name).To(Equal("build"))
+		Expect(metadata.CreatedAt).To(BeTemporally("==", handoff[0].CreatedAt))
+		Expect(metadata.Adopted).To(BeFalse())
+		Expect(sess.(*TerminalSession).CloneConfig("handoff-clone", "build").Shell).To(Equal("/bin/sh"))
+	})
+
+	It("should skip sessions whose tmux session has ended", func() {
+		manager := NewSessionManager()
+		restored := manager.RestoreSessions([]HandoffSession{{ID: "gone", TmuxSession: "gone"}})
+		Expect(restored).To(BeZero())
+		Expect(manager.SessionCount()).To(BeZero())
+	})
+})
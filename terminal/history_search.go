@@ -0,0 +1,115 @@
+package terminal
+
+import (
+	"errors"
+	"regexp"
+)
+
+// Bounds for history searches
+const (
+	DefaultHistorySearchLimit = 100
+	MaxHistorySearchLimit     = 1000
+	maxHistorySearchQuery     = 1024
+	maxHistoryMatchContext    = 256 // bytes of the surrounding line returned per match
+)
+
+// HistorySearchOptions configures a scrollback search
+type HistorySearchOptions struct {
+	Query         string
+	Regex         bool // treat Query as an RE2 regular expression instead of literal text
+	CaseSensitive bool
+	Limit         int // maximum matches, 0 = DefaultHistorySearchLimit
+}
+
+// HistoryMatch is a match in the history buffer. Offsets are byte offsets
+// into the raw output, including escape sequences.
+type HistoryMatch struct {
+	Offset int    `json:"offset"`
+	Length int    `json:"length"`
+	Text   string `json:"text"`
+	Line   string `json:"line"` // the output line containing the match, truncated around it
+}
+
+// HistorySearchResult is the result of a scrollback search
+type HistorySearchResult struct {
+	Matches     []HistoryMatch `json:"matches"`
+	Truncated   bool           `json:"truncated"` // more matches exist beyond the limit
+	HistorySize int            `json:"history_size"`
+}
+
+// HistorySearcher is implemented by sessions whose output history can be searched
+type HistorySearcher interface {
+	SearchHistory(opts HistorySearchOptions) (HistorySearchResult, error)
+}
+
+// SearchHistory searches the session's output history
+func (s *TerminalSession) SearchHistory(opts HistorySearchOptions) (HistorySearchResult, error) {
+	return SearchHistory(s.history.GetHistory(), opts)
+}
+
+// SearchHistory finds matches of opts.Query in history
+func SearchHistory(history []byte, opts HistorySearchOptions) (HistorySearchResult, error) {
+	if opts.Query == "" {
+		return HistorySearchResult{}, errors.New("query is required")
+	}
+	if len(opts.Query) > maxHistorySearchQuery {
+		return HistorySearchResult{}, errors.New("query is too long")
+	}
+	limit := opts.Limit
+	if limit <= 0 {
+		limit = DefaultHistorySearchLimit
+	}
+	if limit > MaxHistorySearchLimit {
+		limit = MaxHistorySearchLimit
+	}
+
+	pattern := opts.Query
+	if !opts.Regex {
+		pattern = regexp.QuoteMeta(pattern)
+	}
+	if !opts.CaseSensitive {
+		pattern = "(?i)" + pattern
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return HistorySearchResult{}, err
+	}
+
+	// Ask for one extra match to know whether the result was truncated
+	locations := re.FindAllIndex(history, limit+1)
+	result := HistorySearchResult{
+		Matches:     make([]HistoryMatch, 0, len(locations)),
+		HistorySize: len(history),
+	}
+	for _, loc := range locations {
+		if len(result.Matches) == limit {
+			result.Truncated = true
+			break
+		}
+		if loc[0] == loc[1] {
+			// Empty matches, e.g. from "a*", carry no information
+			continue
+		}
+		result.Matches = append(result.Matches, HistoryMatch{
+			Offset: loc[0],
+			Length: loc[1] - loc[0],
+			Text:   string(history[loc[0]:loc[1]]),
+			Line:   matchLine(history, loc[0], loc[1]),
+		})
+	}
+	return result, nil
+}
+
+// matchLine returns the line around a match, limited to
+// maxHistoryMatchContext bytes on either side
+func matchLine(history []byte, start, end int) string {
+	lineStart := start
+	for lineStart > 0 && start-lineStart < maxHistoryMatchContext && history[lineStart-1] != '\n' {
+		lineStart--
+	}
+	lineEnd := end
+	for lineEnd < len(history) && lineEnd-end < maxHistoryMatchContext && history[lineEnd] != '\n' && history[lineEnd] != '\r' {
+		lineEnd++
+	}
+	return string(history[lineStart:lineEnd])
+}
@@ -0,0 +1,51 @@
+package terminal
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("History search", func() {
+	history := []byte("$ make build\r\nerror: missing file\r\n$ make test\r\nERROR: 2 failed\r\n")
+
+	It("should find case-insensitive literal matches with byte offsets", func() {
+		result, err := SearchHistory(history, HistorySearchOptions{Query: "error"})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result.HistorySize).To(Equal(len(history)))
+		Expect(result.Truncated).To(BeFalse())
+		Expect(result.Matches).To(HaveLen(2))
+
+		first := result.Matches[0]
+		Expect(first.Offset).To(Equal(14))
+		Expect(first.Length).To(Equal(5))
+		Expect(string(history[first.Offset : first.Offset+first.Length])).To(Equal("error"))
+		Expect(first.Line).To(Equal("error: missing file"))
+		Expect(result.Matches[1].Text).To(Equal("ERROR"))
+	})
+
+	It("should honour case sensitivity and treat literals literally", func() {
+		result, err := SearchHistory(history, HistorySearchOptions{Query: "ERROR", CaseSensitive: true})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result.Matches).To(HaveLen(1))
+
+		result, err = SearchHistory(history, HistorySearchOptions{Query: "$ make"})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result.Matches).To(HaveLen(2))
+	})
+
+	It("should support regular expressions and limits", func() {
+		result, err := SearchHistory(history, HistorySearchOptions{Query: `make \w+`, Regex: true, Limit: 1})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result.Matches).To(HaveLen(1))
+		Expect(result.Matches[0].Text).To(Equal("make build"))
+		Expect(result.Truncated).To(BeTrue())
+
+		_, err = SearchHistory(history, HistorySearchOptions{Query: `(`, Regex: true})
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should require a query", func() {
+		_, err := SearchHistory(history, HistorySearchOptions{})
+		Expect(err).To(MatchError("query is required"))
+	})
+})
@@ -0,0 +1,212 @@
+package terminal
+
+import (
+	"errors"
+	"fmt"
+	"log"
+)
+
+// Input modes of a session
+const (
+	InputModeShared       = "shared"        // every client may type (default)
+	InputModeSingleWriter = "single_writer" // only the client holding control may type
+)
+
+// Server message types for single-writer mode
+const (
+	ServerMessageControl        = "control"         // the control holder or input mode changed
+	ServerMessageControlRequest = "control_request" // sent to the holder when another client asks for control
+)
+
+var (
+	// ErrNotController is returned when a client that does not hold control
+	// tries to hand it over
+	ErrNotController = errors.New("client does not hold control")
+	// ErrUnknownClient is returned when control is granted to a client that is
+	// not attached
+	ErrUnknownClient = errors.New("client is not attached to the session")
+)
+
+// SessionControl describes who may type in a session
+type SessionControl struct {
+	Mode         string `json:"mode"`
+	ControllerID string `json:"controller_id,omitempty"` // presence ID of the client holding control
+	RequesterID  string `json:"requester_id,omitempty"`  // for control_request: the client asking
+}
+
+// InputController is implemented by sessions supporting single-writer mode.
+// Clients are identified by the IDs sent in presence messages.
+type InputController interface {
+	AllowInput(client WebSocketClient) bool
+	RequestControl(client WebSocketClient) error
+	GrantControl(client WebSocketClient, toClientID string) error
+	ReleaseControl(client WebSocketClient) error
+}
+
+// ValidateInputMode checks that mode is a known input mode. Empty means shared.
+func ValidateInputMode(mode string) error {
+	switch mode {
+	case "", InputModeShared, InputModeSingleWriter:
+		return nil
+	}
+	return fmt.Errorf("input_mode must be %q or %q", InputModeShared, InputModeSingleWriter)
+}
+
+// AllowInput reports whether client may send input
+func (s *TerminalSession) AllowInput(client WebSocketClient) bool {
+	s.clientsMu.Lock()
+	defer s.clientsMu.Unlock()
+
+	if s.inputMode != InputModeSingleWriter {
+		return true
+	}
+	info, ok := s.presence[client]
+	return ok && info.ID == s.controllerID
+}
+
+// RequestControl asks for control. A client gets it right away when nobody
+// holds it; otherwise the holder is asked to grant it.
+func (s *TerminalSession) RequestControl(client WebSocketClient) error {
+	s.clientsMu.Lock()
+	defer s.clientsMu.Unlock()
+
+	info, ok := s.presence[client]
+	if !ok {
+		return ErrUnknownClient
+	}
+	if s.inputMode != InputModeSingleWriter || s.controllerID == info.ID {
+		return nil
+	}
+	if s.controllerID == "" {
+		s.setControllerLocked(info.ID)
+		return nil
+	}
+
+	for holder, holderInfo := range s.presence {
+		if holderInfo.ID != s.controllerID {
+			continue
+		}
+		if sender, ok := holder.(MessageSender); ok {
+			msg := ServerMessage{Type: ServerMessageControlRequest, Control: s.controlLocked()}
+			msg.Control.RequesterID = info.ID
+			if err := sender.SendMessage(msg); err != nil {
+				log.Printf("Session %s: failed to send control request: %v", s.id, err)
+			}
+		}
+	}
+	return nil
+}
+
+// GrantControl hands control from client, which must hold it, to another
+// attached client
+func (s *TerminalSession) GrantControl(client WebSocketClient, toClientID string) error {
+	s.clientsMu.Lock()
+	defer s.clientsMu.Unlock()
+
+	info, ok := s.presence[client]
+	if !ok || s.inputMode != InputModeSingleWriter || info.ID != s.controllerID {
+		return ErrNotController
+	}
+	for _, other := range s.presence {
+		if other.ID == toClientID {
+			s.setControllerLocked(toClientID)
+			return nil
+		}
+	}
+	return ErrUnknownClient
+}
+
+// ReleaseControl gives up control so the next client to ask gets it
+func (s *TerminalSession) ReleaseControl(client WebSocketClient) error {
+	s.clientsMu.Lock()
+	defer s.clientsMu.Unlock()
+
+	info, ok := s.presence[client]
+	if !ok || s.inputMode != InputModeSingleWriter || info.ID != s.controllerID {
+		return ErrNotController
+	}
+	s.setControllerLocked("")
+	return nil
+}
+
+// setInputMode switches between shared and single-writer input. In
+// single-writer mode control starts with the last typist, or else the
+// longest attached client.
+func (s *TerminalSession) setInputMode(mode string) {
+	if mode == "" {
+		mode = InputModeShared
+	}
+
+	s.clientsMu.Lock()
+	defer s.clientsMu.Unlock()
+
+	if mode == s.inputMode {
+		return
+	}
+	s.inputMode = mode
+	s.metadataMu.Lock()
+	s.metadata.InputMode = mode
+	s.metadataMu.Unlock()
+
+	controller := ""
+	if mode == InputModeSingleWriter {
+		controller = s.lastInputClientID
+		if controller == "" {
+			for _, client := range s.orderedClients {
+				if info, ok := s.presence[client]; ok {
+					controller = info.ID
+					break
+				}
+			}
+		}
+	}
+	s.setControllerLocked(controller)
+}
+
+// attachControlLocked gives control to a newly attached client when nobody
+// holds it, and otherwise tells it who does. Must be called with s.clientsMu
+// held.
+func (s *TerminalSession) attachControlLocked(client WebSocketClient) {
+	if s.inputMode != InputModeSingleWriter {
+		return
+	}
+	info, ok := s.presence[client]
+	if !ok {
+		return
+	}
+	if s.controllerID == "" {
+		s.setControllerLocked(info.ID)
+		return
+	}
+	if sender, ok := client.(MessageSender); ok {
+		if err := sender.SendMessage(ServerMessage{Type: ServerMessageControl, Control: s.controlLocked()}); err != nil {
+			log.Printf("Session %s: failed to send control message: %v", s.id, err)
+		}
+	}
+}
+
+// controlLocked returns the current control state. Must be called with
+// s.clientsMu held.
+func (s *TerminalSession) controlLocked() *SessionControl {
+	mode := s.inputMode
+	if mode == "" {
+		mode = InputModeShared
+	}
+	return &SessionControl{Mode: mode, ControllerID: s.controllerID}
+}
+
+// setControllerLocked changes the control holder and tells every client.
+// Must be called with s.clientsMu held.
+func (s *TerminalSession) setControllerLocked(clientID string) {
+	s.controllerID = clientID
+	msg := ServerMessage{Type: ServerMessageControl, Control: s.controlLocked()}
+	for client := range s.presence {
+		sender, ok := client.(MessageSender)
+		if !ok {
+			continue
+		}
+		if err := sender.SendMessage(msg); err != nil {
+			log.Printf("Session %s: failed to send control message: %v", s.id, err)
+		}
+	}
+}
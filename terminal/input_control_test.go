@@ -0,0 +1,110 @@
+package terminal
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Single-writer input control", func() {
+	var session *TerminalSession
+
+	// latest returns a poller yielding the control state of the most recent
+	// message of the given type a client received
+	latest := func(client *namedMessageClient, messageType string) func() *SessionControl {
+		var last *SessionControl
+		return func() *SessionControl {
+			for {
+				select {
+				case msg := <-client.messages:
+					if msg.Type == messageType {
+						last = msg.Control
+					}
+				default:
+					return last
+				}
+			}
+		}
+	}
+	controlOf := func(client *namedMessageClient) func() *SessionControl {
+		return latest(client, ServerMessageControl)
+	}
+	attach := func(name string) (*namedMessageClient, string) {
+		client := &namedMessageClient{
+			messageClient: &messageClient{
+				MockWebSocketClient: NewMockWebSocketClient(),
+				messages:            make(chan ServerMessage, 32),
+			},
+			name: name,
+		}
+		Expect(session.AddClient(client)).To(Succeed())
+		expectOutputSeq(client.messageClient)
+		var msg ServerMessage
+		Expect(client.messages).To(Receive(&msg))
+		Expect(msg.Type).To(Equal(ServerMessagePresence))
+		return client, msg.Presence.Self
+	}
+
+	BeforeEach(func() {
+		ptySvc, err := NewSimulatedPTYService()
+		Expect(err).ToNot(HaveOccurred())
+		DeferCleanup(ptySvc.Close)
+
+		session, err = NewTerminalSession(SessionConfig{
+			ID:          "control-session",
+			HistorySize: 256,
+			PTYService:  ptySvc,
+			InputMode:   InputModeSingleWriter,
+		})
+		Expect(err).ToNot(HaveOccurred())
+		DeferCleanup(session.Close)
+	})
+
+	It("should let only the holder type and hand control over on request", func() {
+		alice, aliceID := attach("alice")
+		Eventually(controlOf(alice)).Should(HaveField("ControllerID", aliceID))
+		bob, bobID := attach("bob")
+		Eventually(controlOf(bob)).Should(HaveField("ControllerID", aliceID))
+
+		Expect(session.AllowInput(alice)).To(BeTrue())
+		Expect(session.AllowInput(bob)).To(BeFalse())
+
+		Expect(session.RequestControl(bob)).To(Succeed())
+		Eventually(latest(alice, ServerMessageControlRequest)).Should(HaveField("RequesterID", bobID))
+
+		Expect(session.GrantControl(bob, bobID)).To(MatchError(ErrNotController))
+		Expect(session.GrantControl(alice, "nobody")).To(MatchError(ErrUnknownClient))
+		Expect(session.GrantControl(alice, bobID)).To(Succeed())
+		Eventually(controlOf(bob)).Should(HaveField("ControllerID", bobID))
+		Expect(session.AllowInput(alice)).To(BeFalse())
+		Expect(session.AllowInput(bob)).To(BeTrue())
+
+		// Control is free once the holder leaves and goes to the next to ask
+		session.RemoveClient(bob)
+		Eventually(controlOf(alice)).Should(HaveField("ControllerID", BeEmpty()))
+		Expect(session.RequestControl(alice)).To(Succeed())
+		Eventually(controlOf(alice)).Should(HaveField("ControllerID", aliceID))
+	})
+
+	It("should switch between shared and single-writer input", func() {
+		alice, _ := attach("alice")
+		bob, bobID := attach("bob")
+
+		session.setInputMode(InputModeShared)
+		Eventually(controlOf(bob)).Should(HaveField("Mode", InputModeShared))
+		Expect(session.AllowInput(alice)).To(BeTrue())
+		Expect(session.AllowInput(bob)).To(BeTrue())
+		Expect(session.GetMetadata().InputMode).To(Equal(InputModeShared))
+
+		session.NoteInput(bob)
+		session.setInputMode(InputModeSingleWriter)
+		Eventually(controlOf(alice)).Should(Equal(&SessionControl{Mode: InputModeSingleWriter, ControllerID: bobID}))
+		Expect(session.ReleaseControl(alice)).To(MatchError(ErrNotController))
+		Expect(session.ReleaseControl(bob)).To(Succeed())
+	})
+
+	It("should validate input modes", func() {
+		Expect(ValidateInputMode("")).To(Succeed())
+		Expect(ValidateInputMode(InputModeSingleWriter)).To(Succeed())
+		Expect(ValidateInputMode("exclusive")).ToNot(Succeed())
+	})
+})
@@ -0,0 +1,47 @@
+package terminal
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+)
+
+// Resources guarded by LimitError
+const (
+	LimitResourceSessions = "sessions"
+	LimitResourceClients  = "clients"
+)
+
+// LimitError reports that a configured session or client limit was reached
+type LimitError struct {
+	Resource string `json:"resource"` // LimitResourceSessions or LimitResourceClients
+	Limit    int    `json:"limit"`
+}
+
+func (e *LimitError) Error() string {
+	if e.Resource == LimitResourceClients {
+		return fmt.Sprintf("maximum of %d clients per session reached", e.Limit)
+	}
+	return fmt.Sprintf("maximum of %d sessions reached", e.Limit)
+}
+
+// GetMaxSessionsFromEnv returns TERMINAL_HUB_MAX_SESSIONS, or 0 (unlimited)
+func GetMaxSessionsFromEnv() int {
+	return getEnvLimit("TERMINAL_HUB_MAX_SESSIONS")
+}
+
+// GetMaxClientsPerSessionFromEnv returns TERMINAL_HUB_MAX_CLIENTS_PER_SESSION,
+// or 0 (unlimited)
+func GetMaxClientsPerSessionFromEnv() int {
+	return getEnvLimit("TERMINAL_HUB_MAX_CLIENTS_PER_SESSION")
+}
+
+// getEnvLimit reads a non-negative integer limit from the environment
+func getEnvLimit(key string) int {
+	if val := os.Getenv(key); val != "" {
+		if limit, err := strconv.Atoi(val); err == nil && limit > 0 {
+			return limit
+		}
+	}
+	return 0
+}
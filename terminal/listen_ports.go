@@ -0,0 +1,78 @@
+package terminal
+
+import (
+	"errors"
+	"slices"
+)
+
+// ErrListeningPortsUnsupported is returned where the sockets of a session's
+// processes cannot be inspected
+var ErrListeningPortsUnsupported = errors.New("listening ports are only available on Linux")
+
+// ListeningPort is a TCP port a process in a session's terminal accepts
+// connections on
+type ListeningPort struct {
+	Port    int    `json:"port"`
+	Address string `json:"address"` // the IP the socket is bound to, "0.0.0.0" or "::" for all
+	PID     int    `json:"pid"`
+	Command string `json:"command"`
+}
+
+// ListeningPorts returns the TCP ports the session's shell and its
+// descendants listen on, ordered by port
+func (s *TerminalSession) ListeningPorts() ([]ListeningPort, error) {
+	if !processInfoSupported {
+		return nil, ErrListeningPortsUnsupported
+	}
+	_, shellPID, err := s.foregroundProcess()
+	if err != nil {
+		return nil, err
+	}
+	table, err := readProcessTable()
+	if err != nil {
+		return nil, err
+	}
+
+	pids := []int{shellPID}
+	for i := 0; i < len(pids); i++ {
+		pids = append(pids, table.children[pids[i]]...)
+	}
+	ports, err := listeningPortsOf(pids)
+	if err != nil {
+		return nil, err
+	}
+	for i := range ports {
+		ports[i].Command = table.byPID[ports[i].PID].command
+	}
+	return ports, nil
+}
+
+// listeningPortsOf returns the TCP ports the given processes listen on,
+// ordered by port. A socket shared by several processes is reported once.
+func listeningPortsOf(pids []int) ([]ListeningPort, error) {
+	sockets, err := readListeningSockets()
+	if err != nil {
+		return nil, err
+	}
+
+	var ports []ListeningPort
+	seen := make(map[uint64]bool)
+	for _, pid := range pids {
+		for _, inode := range processSocketInodes(pid) {
+			port, ok := sockets[inode]
+			if !ok || seen[inode] {
+				continue
+			}
+			seen[inode] = true
+			port.PID = pid
+			ports = append(ports, port)
+		}
+	}
+	slices.SortFunc(ports, func(a, b ListeningPort) int {
+		if a.Port != b.Port {
+			return a.Port - b.Port
+		}
+		return a.PID - b.PID
+	})
+	return ports, nil
+}
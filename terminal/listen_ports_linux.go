@@ -0,0 +1,95 @@
+package terminal
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/hex"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// tcpListenState is the state of listening sockets in /proc/net/tcp
+const tcpListenState = "0A"
+
+// readListeningSockets reads the listening TCP sockets of the host from
+// /proc/net/tcp and /proc/net/tcp6, keyed by inode
+func readListeningSockets() (map[uint64]ListeningPort, error) {
+	sockets := make(map[uint64]ListeningPort)
+	for _, name := range []string{"/proc/net/tcp", "/proc/net/tcp6"} {
+		file, err := os.Open(name)
+		if err != nil {
+			if name == "/proc/net/tcp6" && os.IsNotExist(err) {
+				// IPv6 is disabled
+				continue
+			}
+			return nil, err
+		}
+		scanner := bufio.NewScanner(file)
+		scanner.Scan() // header
+		for scanner.Scan() {
+			fields := strings.Fields(scanner.Text())
+			if len(fields) < 10 || fields[3] != tcpListenState {
+				continue
+			}
+			address, port, ok := parseProcNetAddress(fields[1])
+			inode, err := strconv.ParseUint(fields[9], 10, 64)
+			if !ok || err != nil {
+				continue
+			}
+			sockets[inode] = ListeningPort{Port: port, Address: address}
+		}
+		err = scanner.Err()
+		_ = file.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+	return sockets, nil
+}
+
+// parseProcNetAddress parses an "ADDRESS:PORT" field of /proc/net/tcp. The
+// address is printed as 32-bit words in host byte order, the port in hex.
+func parseProcNetAddress(field string) (string, int, bool) {
+	hexAddress, hexPort, ok := strings.Cut(field, ":")
+	if !ok {
+		return "", 0, false
+	}
+	port, err := strconv.ParseUint(hexPort, 16, 16)
+	if err != nil {
+		return "", 0, false
+	}
+	words, err := hex.DecodeString(hexAddress)
+	if err != nil || (len(words) != net.IPv4len && len(words) != net.IPv6len) {
+		return "", 0, false
+	}
+	ip := make(net.IP, len(words))
+	for i := 0; i < len(words); i += 4 {
+		binary.NativeEndian.PutUint32(ip[i:], binary.BigEndian.Uint32(words[i:]))
+	}
+	return ip.String(), int(port), true
+}
+
+// processSocketInodes returns the inodes of the sockets a process has open.
+// Processes that exited or belong to other users have none.
+func processSocketInodes(pid int) []uint64 {
+	dir := "/proc/" + strconv.Itoa(pid) + "/fd"
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+	var inodes []uint64
+	for _, entry := range entries {
+		target, err := os.Readlink(dir + "/" + entry.Name())
+		if err != nil {
+			continue
+		}
+		if rest, ok := strings.CutPrefix(target, "socket:["); ok {
+			if inode, err := strconv.ParseUint(strings.TrimSuffix(rest, "]"), 10, 64); err == nil {
+				inodes = append(inodes, inode)
+			}
+		}
+	}
+	return inodes
+}
@@ -0,0 +1,13 @@
+//go:build !linux
+
+package terminal
+
+// readListeningSockets is only implemented for Linux
+func readListeningSockets() (map[uint64]ListeningPort, error) {
+	return nil, ErrListeningPortsUnsupported
+}
+
+// processSocketInodes is only implemented for Linux
+func processSocketInodes(pid int) []uint64 {
+	return nil
+}
@@ -0,0 +1,64 @@
+package terminal
+
+import (
+	"net"
+	"os"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Listening ports", func() {
+	It("should find the TCP ports a process listens on", func() {
+		if !processInfoSupported {
+			Skip("process info is not available on this platform")
+		}
+
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		Expect(err).ToNot(HaveOccurred())
+		DeferCleanup(listener.Close)
+		port := listener.Addr().(*net.TCPAddr).Port
+
+		ports, err := listeningPortsOf([]int{os.Getpid()})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(ports).To(ContainElement(ListeningPort{Port: port, Address: "127.0.0.1", PID: os.Getpid()}))
+
+		// Connections are not listening sockets
+		conn, err := net.Dial("tcp", listener.Addr().String())
+		Expect(err).ToNot(HaveOccurred())
+		DeferCleanup(conn.Close)
+		ports, err = listeningPortsOf([]int{os.Getpid()})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(ports).ToNot(ContainElement(HaveField("Port", conn.LocalAddr().(*net.TCPAddr).Port)))
+
+		if listener6, err := net.Listen("tcp6", "[::1]:0"); err == nil {
+			DeferCleanup(listener6.Close)
+			ports, err = listeningPortsOf([]int{os.Getpid()})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(ports).To(ContainElement(ListeningPort{Port: listener6.Addr().(*net.TCPAddr).Port, Address: "::1", PID: os.Getpid()}))
+		}
+	})
+
+	It("should not report ports of other processes", func() {
+		if !processInfoSupported {
+			Skip("process info is not available on this platform")
+		}
+
+		session, err := NewTerminalSession(SessionConfig{
+			ID:         "listen-ports",
+			Shell:      "/bin/sh",
+			Backend:    SessionBackendPTY,
+			PTYService: &DefaultPTYService{},
+		})
+		Expect(err).ToNot(HaveOccurred())
+		DeferCleanup(session.Close)
+
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		Expect(err).ToNot(HaveOccurred())
+		DeferCleanup(listener.Close)
+
+		ports, err := session.ListeningPorts()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(ports).To(BeEmpty())
+	})
+})
@@ -2,24 +2,124 @@ package terminal
 
 import (
 	"errors"
+	"fmt"
 	"log"
 	"sort"
 	"sync"
+	"time"
+
+	"github.com/iwanhae/terminal-hub/notify"
 )
 
 // SessionManager manages multiple terminal sessions
 type SessionManager struct {
 	sessions map[string]Session
 	mu       sync.RWMutex
+
+	maxSessions          int                // 0 = unlimited
+	maxClientsPerSession int                // 0 = unlimited
+	runAs                *RunAs             // account new sessions run as, nil = the daemon's own
+	sshGateway           *SSHGateway        // opens connections for ssh sessions, nil = ssh disabled
+	events               *EventBus          // receives events raised by sessions
+	prefs                *SessionPrefsStore // persists pinned state and sort order, nil = in memory only
+	channels             *notify.Channels   // named channels watch rules can notify, nil = none
+	writeGuard           func() error       // checked before sessions archive their history, nil = always write
 }
 
-// NewSessionManager creates a new session manager
+// NewSessionManager creates a new session manager without limits
 func NewSessionManager() *SessionManager {
+	return NewSessionManagerWithLimits(0, 0)
+}
+
+// NewSessionManagerWithLimits creates a session manager that refuses to create
+// more than maxSessions sessions or attach more than maxClientsPerSession
+// clients to one session. Zero means unlimited.
+func NewSessionManagerWithLimits(maxSessions, maxClientsPerSession int) *SessionManager {
 	return &SessionManager{
-		sessions: make(map[string]Session),
+		sessions:             make(map[string]Session),
+		maxSessions:          maxSessions,
+		maxClientsPerSession: maxClientsPerSession,
+		events:               NewEventBus(),
+	}
+}
+
+// SetPrefsStore persists pinned state and sort order in store. Sessions
+// created afterwards start with their stored preferences.
+func (sm *SessionManager) SetPrefsStore(store *SessionPrefsStore) {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	sm.prefs = store
+}
+
+// forgetPrefsLocked drops a removed session's stored preferences. Must be
+// called with sm.mu held.
+func (sm *SessionManager) forgetPrefsLocked(sessionID string) {
+	if sm.prefs == nil {
+		return
+	}
+	if err := sm.prefs.Delete(sessionID); err != nil {
+		log.Printf("Session %s: failed to delete preferences: %v", sessionID, err)
 	}
 }
 
+// Events returns the bus on which session events are published
+func (sm *SessionManager) Events() *EventBus {
+	return sm.events
+}
+
+// SetRunAs makes sessions created afterwards run as the given account
+func (sm *SessionManager) SetRunAs(runAs *RunAs) {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	sm.runAs = runAs
+}
+
+// RunAs returns the account new sessions run as, or nil
+func (sm *SessionManager) RunAs() *RunAs {
+	sm.mu.RLock()
+	defer sm.mu.RUnlock()
+	return sm.runAs
+}
+
+// SetNotificationChannels makes the named channels available to the watch
+// rules of sessions created afterwards
+func (sm *SessionManager) SetNotificationChannels(channels *notify.Channels) {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	sm.channels = channels
+}
+
+// SetWriteGuard makes sessions created afterwards skip archiving their
+// history while guard returns an error
+func (sm *SessionManager) SetWriteGuard(guard func() error) {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	sm.writeGuard = guard
+}
+
+// SetSSHGateway enables the ssh backend using gateway
+func (sm *SessionManager) SetSSHGateway(gateway *SSHGateway) {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	sm.sshGateway = gateway
+}
+
+// SSHGateway returns the gateway used by ssh sessions, or nil
+func (sm *SessionManager) SSHGateway() *SSHGateway {
+	sm.mu.RLock()
+	defer sm.mu.RUnlock()
+	return sm.sshGateway
+}
+
+// checkSessionLimitLocked returns a LimitError when no more sessions may be
+// created. Must be called with sm.mu held.
+func (sm *SessionManager) checkSessionLimitLocked() error {
+	if sm.maxSessions > 0 && len(sm.sessions) >= sm.maxSessions {
+		return &LimitError{Resource: LimitResourceSessions, Limit: sm.maxSessions}
+	}
+	return nil
+}
+
 // GetOrCreate retrieves an existing session or creates a new one
 func (sm *SessionManager) GetOrCreate(sessionID string) (Session, error) {
 	sm.mu.Lock()
@@ -30,15 +130,22 @@ func (sm *SessionManager) GetOrCreate(sessionID string) (Session, error) {
 		return sess, nil
 	}
 
+	if err := sm.checkSessionLimitLocked(); err != nil {
+		return nil, err
+	}
+
 	// Create new session
 	sess, err := NewTerminalSession(SessionConfig{
-		ID: sessionID,
+		ID:         sessionID,
+		MaxClients: sm.maxClientsPerSession,
+		RunAs:      sm.runAs,
 	})
 	if err != nil {
 		return nil, err
 	}
 
 	sm.sessions[sessionID] = sess
+	sm.publishLifecycleEvent(SessionEventCreated, sess)
 	return sess, nil
 }
 
@@ -67,9 +174,21 @@ func (sm *SessionManager) Remove(sessionID string) error {
 	}
 
 	delete(sm.sessions, sessionID)
+	sm.forgetPrefsLocked(sessionID)
+	sm.publishLifecycleEvent(SessionEventClosed, sess)
 	return nil
 }
 
+// publishLifecycleEvent publishes a created or closed event for sess
+func (sm *SessionManager) publishLifecycleEvent(eventType string, sess Session) {
+	sm.events.Publish(SessionEvent{
+		Type:        eventType,
+		SessionID:   sess.ID(),
+		SessionName: sess.GetMetadata().Name,
+		Timestamp:   time.Now().Unix(),
+	})
+}
+
 // CloseAll closes all sessions
 func (sm *SessionManager) CloseAll() error {
 	sm.mu.Lock()
@@ -136,16 +255,49 @@ func (sm *SessionManager) CreateSession(config SessionConfig) (Session, error) {
 	if _, ok := sm.sessions[config.ID]; ok {
 		return nil, errors.New("session already exists")
 	}
+	if err := sm.checkSessionLimitLocked(); err != nil {
+		return nil, err
+	}
+	if config.MaxClients == 0 {
+		config.MaxClients = sm.maxClientsPerSession
+	}
+	if config.RunAs == nil {
+		config.RunAs = sm.runAs
+	}
+	if config.SSHGateway == nil {
+		config.SSHGateway = sm.sshGateway
+	}
+	if config.OnEvent == nil {
+		config.OnEvent = sm.events.Publish
+	}
+	if config.Channels == nil {
+		config.Channels = sm.channels
+	}
+	if config.WriteGuard == nil {
+		config.WriteGuard = sm.writeGuard
+	}
+	if prefs, ok := sm.prefs.Get(config.ID); ok {
+		config.Prefs = prefs
+	} else {
+		config.Prefs.SortOrder = sm.nextSortOrderLocked()
+	}
 
 	sessionID := config.ID
 	config.OnExit = func(id string) {
 		sm.mu.Lock()
-		defer sm.mu.Unlock()
-		if sess, ok := sm.sessions[id]; ok {
+		sess, ok := sm.sessions[id]
+		if ok {
 			_ = sess.Close() // resources already cleaned up, errors expected and ignored
 			delete(sm.sessions, id)
+			sm.forgetPrefsLocked(id)
 			log.Printf("Session %s: removed after process exit", id)
 		}
+		sm.mu.Unlock()
+
+		// Actions may take a while, so they run without holding the lock
+		if terminalSess, isTerminal := sess.(*TerminalSession); ok && isTerminal {
+			terminalSess.runExitActions()
+		}
 	}
 
 	// Create new session
@@ -155,6 +307,7 @@ func (sm *SessionManager) CreateSession(config SessionConfig) (Session, error) {
 	}
 
 	sm.sessions[sessionID] = sess
+	sm.publishLifecycleEvent(SessionEventCreated, sess)
 	return sess, nil
 }
 
@@ -176,3 +329,154 @@ func (sm *SessionManager) UpdateSessionName(sessionID string, name string) error
 
 	return errors.New("session is not a TerminalSession")
 }
+
+// UpdateSessionTags replaces a session's tags
+func (sm *SessionManager) UpdateSessionTags(sessionID string, tags []string) error {
+	normalized, err := NormalizeTags(tags)
+	if err != nil {
+		return err
+	}
+
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+
+	sess, ok := sm.sessions[sessionID]
+	if !ok {
+		return errors.New("session not found")
+	}
+
+	if terminalSess, ok := sess.(*TerminalSession); ok {
+		terminalSess.updateTags(normalized)
+		return nil
+	}
+
+	return errors.New("session is not a TerminalSession")
+}
+
+// SetSessionInputMode switches a session between shared and single-writer input
+func (sm *SessionManager) SetSessionInputMode(sessionID, mode string) error {
+	if err := ValidateInputMode(mode); err != nil {
+		return err
+	}
+
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+
+	sess, ok := sm.sessions[sessionID]
+	if !ok {
+		return ErrSessionNotFound
+	}
+	terminalSess, ok := sess.(*TerminalSession)
+	if !ok {
+		return errors.New("session is not a TerminalSession")
+	}
+	terminalSess.setInputMode(mode)
+	return nil
+}
+
+// SetSessionResizePolicy changes how a session's terminal size follows its
+// clients
+func (sm *SessionManager) SetSessionResizePolicy(sessionID string, policy ResizePolicy) error {
+	if err := ValidateResizePolicy(policy); err != nil {
+		return err
+	}
+
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+
+	sess, ok := sm.sessions[sessionID]
+	if !ok {
+		return ErrSessionNotFound
+	}
+	terminalSess, ok := sess.(*TerminalSession)
+	if !ok {
+		return errors.New("session is not a TerminalSession")
+	}
+	terminalSess.setResizePolicy(policy)
+	return nil
+}
+
+// ErrSessionNotFound is returned when a listed session does not exist
+var ErrSessionNotFound = errors.New("session not found")
+
+// SetSessionPinned pins or unpins a session
+func (sm *SessionManager) SetSessionPinned(sessionID string, pinned bool) error {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+
+	sess, ok := sm.sessions[sessionID]
+	if !ok {
+		return ErrSessionNotFound
+	}
+	terminalSess, ok := sess.(*TerminalSession)
+	if !ok {
+		return errors.New("session is not a TerminalSession")
+	}
+
+	metadata := terminalSess.GetMetadata()
+	prefs := SessionPrefs{Pinned: pinned, SortOrder: metadata.SortOrder}
+	terminalSess.updatePrefs(prefs)
+	return sm.savePrefsLocked(map[string]SessionPrefs{sessionID: prefs})
+}
+
+// ReorderSessions gives the listed sessions sort orders 0..n-1 and moves the
+// remaining sessions behind them, keeping their current relative order
+func (sm *SessionManager) ReorderSessions(sessionIDs []string) error {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+
+	listed := make(map[string]bool, len(sessionIDs))
+	for _, id := range sessionIDs {
+		if listed[id] {
+			return fmt.Errorf("session %s is listed more than once", id)
+		}
+		if _, ok := sm.sessions[id]; !ok {
+			return fmt.Errorf("%w: %s", ErrSessionNotFound, id)
+		}
+		listed[id] = true
+	}
+
+	rest := make([]SessionInfo, 0, len(sm.sessions))
+	for id, sess := range sm.sessions {
+		if !listed[id] {
+			rest = append(rest, SessionInfo{ID: id, Metadata: sess.GetMetadata()})
+		}
+	}
+	order := append([]string(nil), sessionIDs...)
+	for _, info := range (SessionFilter{}).Apply(rest) {
+		order = append(order, info.ID)
+	}
+
+	updated := make(map[string]SessionPrefs, len(order))
+	for i, id := range order {
+		terminalSess, ok := sm.sessions[id].(*TerminalSession)
+		if !ok {
+			continue
+		}
+		prefs := SessionPrefs{Pinned: terminalSess.GetMetadata().Pinned, SortOrder: i}
+		terminalSess.updatePrefs(prefs)
+		updated[id] = prefs
+	}
+	return sm.savePrefsLocked(updated)
+}
+
+// nextSortOrderLocked returns a sort order placing a new session after all
+// existing ones. Must be called with sm.mu held.
+func (sm *SessionManager) nextSortOrderLocked() int {
+	next := 0
+	for _, sess := range sm.sessions {
+		if order := sess.GetMetadata().SortOrder; order >= next {
+			next = order + 1
+		}
+	}
+	return next
+}
+
+// savePrefsLocked persists preferences when a store is configured. Must be
+// called with sm.mu held.
+func (sm *SessionManager) savePrefsLocked(prefs map[string]SessionPrefs) error {
+	if sm.prefs == nil {
+		return nil
+	}
+	return sm.prefs.Set(prefs)
+}
@@ -0,0 +1,57 @@
+package terminal
+
+import "log"
+
+// ServerMessageOutputSeq tells a client the sequence number of the next
+// output byte it will receive
+const ServerMessageOutputSeq = "output_seq"
+
+// ResumingClient is implemented by clients reconnecting to a session. A
+// client counts the output bytes it receives from the last output_seq
+// message on, and reconnects with that count to receive only what it missed.
+type ResumingClient interface {
+	// ResumeFrom returns the sequence number of the first output byte the
+	// client has not seen, and false for a fresh client
+	ResumeFrom() (seq uint64, ok bool)
+}
+
+// replayFrame returns the output to send a newly attached client before it
+// is streamed live output written after head. hist holds the output ending
+// at head, and repaint draws the screen it leaves. A new client gets the
+// history, for its scrollback, and the repaint, as the history may start in
+// the middle of a full-screen program's drawing. A resuming client gets only
+// the bytes it missed; when those are no longer in history, or the sequence
+// is from another server run, its screen is reset and redrawn.
+func replayFrame(client WebSocketClient, hist, repaint []byte, head uint64) []byte {
+	if resuming, ok := client.(ResumingClient); ok {
+		if since, ok := resuming.ResumeFrom(); ok {
+			if since <= head && head-since <= uint64(len(hist)) {
+				return hist[uint64(len(hist))-(head-since):]
+			}
+			return redrawOutput(hist, repaint)
+		}
+	}
+	if len(hist) == 0 {
+		return nil
+	}
+	return append(append([]byte(nil), hist...), repaint...)
+}
+
+// redrawOutput returns a reset followed by hist and repaint
+func redrawOutput(hist, repaint []byte) []byte {
+	frame := make([]byte, 0, len(terminalReset)+len(hist)+len(repaint))
+	frame = append(frame, terminalReset...)
+	frame = append(frame, hist...)
+	return append(frame, repaint...)
+}
+
+// sendOutputSeq tells client the sequence number of the output that follows
+func (s *TerminalSession) sendOutputSeq(client WebSocketClient, seq uint64) {
+	sender, ok := client.(MessageSender)
+	if !ok {
+		return
+	}
+	if err := sender.SendMessage(ServerMessage{Type: ServerMessageOutputSeq, Seq: seq}); err != nil {
+		log.Printf("Session %s: failed to send output sequence: %v", s.id, err)
+	}
+}
@@ -0,0 +1,72 @@
+package terminal
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// resumingClient reconnects with a known output sequence number
+type resumingClient struct {
+	*messageClient
+	since uint64
+}
+
+func (c *resumingClient) ResumeFrom() (uint64, bool) {
+	return c.since, true
+}
+
+var _ = Describe("Output replay", func() {
+	It("should replay only the output a resuming client missed", func() {
+		ptySvc, err := NewSimulatedPTYService()
+		Expect(err).ToNot(HaveOccurred())
+		DeferCleanup(ptySvc.Close)
+
+		session, err := NewTerminalSession(SessionConfig{
+			ID:          "replay-session",
+			HistorySize: 256,
+			PTYService:  ptySvc,
+		})
+		Expect(err).ToNot(HaveOccurred())
+		DeferCleanup(session.Close)
+
+		Expect(ptySvc.SimulateOutput([]byte("hello"))).To(Succeed())
+		Eventually(session.history.GetHistory).Should(Equal([]byte("hello")))
+		Expect(ptySvc.SimulateOutput([]byte("world"))).To(Succeed())
+		Eventually(session.history.GetHistory).Should(Equal([]byte("helloworld")))
+
+		client := &resumingClient{
+			messageClient: &messageClient{
+				MockWebSocketClient: NewMockWebSocketClient(),
+				messages:            make(chan ServerMessage, 8),
+			},
+			since: 5,
+		}
+		Expect(session.AddClient(client)).To(Succeed())
+		Expect(client.sendChan).To(Receive(Equal([]byte("world"))))
+		var msg ServerMessage
+		Expect(client.messages).To(Receive(&msg))
+		Expect(msg).To(Equal(ServerMessage{Type: ServerMessageOutputSeq, Seq: 10}))
+
+		// Live output continues the sequence
+		Expect(ptySvc.SimulateOutput([]byte("!"))).To(Succeed())
+		Eventually(client.sendChan, "2s").Should(Receive(Equal([]byte("!"))))
+	})
+
+	It("should choose what to replay from the sequence number", func() {
+		hist := []byte("helloworld")
+		resume := func(since uint64) WebSocketClient {
+			return &resumingClient{messageClient: &messageClient{MockWebSocketClient: NewMockWebSocketClient()}, since: since}
+		}
+
+		repaint := []byte("<repaint>")
+
+		Expect(replayFrame(NewMockWebSocketClient(), hist, repaint, 20)).To(Equal([]byte("helloworld<repaint>")))
+		Expect(replayFrame(NewMockWebSocketClient(), nil, repaint, 0)).To(BeEmpty())
+		Expect(replayFrame(resume(15), hist, repaint, 20)).To(Equal([]byte("world")))
+		Expect(replayFrame(resume(20), hist, repaint, 20)).To(BeEmpty())
+		Expect(replayFrame(resume(10), hist, repaint, 20)).To(Equal(hist))
+		// Too old for history, or from before a restart
+		Expect(replayFrame(resume(5), hist, repaint, 20)).To(Equal([]byte("\x1bchelloworld<repaint>")))
+		Expect(replayFrame(resume(25), hist, repaint, 20)).To(Equal([]byte("\x1bchelloworld<repaint>")))
+	})
+})
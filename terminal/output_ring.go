@@ -0,0 +1,135 @@
+package terminal
+
+import (
+	"sync"
+)
+
+// outputRingSize is how much recent output a session keeps for clients that
+// have not sent it yet. A client further behind is redrawn from history.
+const outputRingSize = 256 * 1024
+
+// outputRing is a fixed-size buffer of PTY output shared by all clients of a
+// session. Each client reads from its own cursor, so output is stored once
+// no matter how many clients are attached.
+type outputRing struct {
+	mu      sync.Mutex
+	buf     []byte
+	head    uint64 // total bytes ever written; buf holds the last len(buf) of them
+	cursors map[*ringCursor]struct{}
+	done    chan struct{} // closed when the ring is closed
+	closed  bool
+}
+
+// ringCursor is one client's read position in an outputRing
+type ringCursor struct {
+	next   uint64        // offset of the next byte to read
+	notify chan struct{} // receives a value when output is written
+}
+
+func newOutputRing(size int) *outputRing {
+	return &outputRing{
+		buf:     make([]byte, size),
+		cursors: make(map[*ringCursor]struct{}),
+		done:    make(chan struct{}),
+	}
+}
+
+// write appends p and wakes the readers. Writes after close are dropped.
+func (r *outputRing) write(p []byte) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.closed || len(p) == 0 {
+		return
+	}
+	if len(p) > len(r.buf) {
+		r.head += uint64(len(p) - len(r.buf))
+		p = p[len(p)-len(r.buf):]
+	}
+	start := int(r.head % uint64(len(r.buf)))
+	n := copy(r.buf[start:], p)
+	copy(r.buf, p[n:])
+	r.head += uint64(len(p))
+
+	for cursor := range r.cursors {
+		select {
+		case cursor.notify <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// subscribe returns a cursor at the current end of the output
+func (r *outputRing) subscribe() *ringCursor {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	cursor := &ringCursor{next: r.head, notify: make(chan struct{}, 1)}
+	r.cursors[cursor] = struct{}{}
+	return cursor
+}
+
+// unsubscribe stops waking cursor
+func (r *outputRing) unsubscribe(cursor *ringCursor) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.cursors, cursor)
+}
+
+// position returns the sequence number of the next byte to be written
+func (r *outputRing) position() uint64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.head
+}
+
+// available returns how many bytes cursor has not read yet
+func (r *outputRing) available(cursor *ringCursor) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return int(r.head - cursor.next)
+}
+
+// read returns the output cursor has not read yet and advances it. If the
+// cursor fell more than the ring size behind, the output it missed is gone:
+// read moves it to the end and reports overflow.
+func (r *outputRing) read(cursor *ringCursor) (data []byte, overflow bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	pending := r.head - cursor.next
+	if pending == 0 {
+		return nil, false
+	}
+	if pending > uint64(len(r.buf)) {
+		cursor.next = r.head
+		return nil, true
+	}
+
+	// The frame is handed to the client, which may keep it, so it cannot
+	// share memory with the ring
+	data = make([]byte, pending)
+	start := int(cursor.next % uint64(len(r.buf)))
+	n := copy(data, r.buf[start:])
+	copy(data[n:], r.buf)
+	cursor.next = r.head
+	return data, false
+}
+
+// seek moves cursor to the end of the output
+func (r *outputRing) seek(cursor *ringCursor) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	cursor.next = r.head
+}
+
+// close stops the ring; readers waiting on done return
+func (r *outputRing) close() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if !r.closed {
+		r.closed = true
+		close(r.done)
+	}
+}
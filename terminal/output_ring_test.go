@@ -0,0 +1,148 @@
+package terminal
+
+import (
+	"bytes"
+	"sync"
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("outputRing", func() {
+	It("should let each cursor read what was written since it last read", func() {
+		ring := newOutputRing(8)
+		early := ring.subscribe()
+		ring.write([]byte("abc"))
+		late := ring.subscribe()
+		ring.write([]byte("defg"))
+
+		Expect(early.notify).To(Receive())
+		data, overflow := ring.read(early)
+		Expect(overflow).To(BeFalse())
+		Expect(string(data)).To(Equal("abcdefg"))
+
+		data, _ = ring.read(late)
+		Expect(string(data)).To(Equal("defg"))
+
+		// Wrap around the end of the buffer
+		ring.write([]byte("hijkl"))
+		data, _ = ring.read(early)
+		Expect(string(data)).To(Equal("hijkl"))
+		Expect(ring.available(late)).To(Equal(5))
+	})
+
+	It("should report overflow to cursors that fell too far behind", func() {
+		ring := newOutputRing(8)
+		cursor := ring.subscribe()
+		ring.write([]byte("0123456789"))
+
+		data, overflow := ring.read(cursor)
+		Expect(overflow).To(BeTrue())
+		Expect(data).To(BeEmpty())
+		Expect(ring.available(cursor)).To(BeZero())
+
+		ring.write([]byte("ab"))
+		data, overflow = ring.read(cursor)
+		Expect(overflow).To(BeFalse())
+		Expect(string(data)).To(Equal("ab"))
+	})
+
+	It("should drop writes after close", func() {
+		ring := newOutputRing(8)
+		cursor := ring.subscribe()
+		ring.close()
+		ring.close()
+		ring.write([]byte("late"))
+
+		Expect(ring.done).To(BeClosed())
+		Expect(ring.available(cursor)).To(BeZero())
+	})
+})
+
+const (
+	benchmarkClients   = 4
+	benchmarkChunkSize = 512
+	benchmarkRound     = 64 // reads between client sends, well within the ring size
+)
+
+// discardSend stands in for a client send
+var discardSend = func(data []byte) { _ = data }
+
+// BenchmarkBroadcastRing measures the broadcast path: each PTY read is
+// written once to history and the shared ring, and each client reads from
+// its own cursor
+func BenchmarkBroadcastRing(b *testing.B) {
+	session := &TerminalSession{
+		id:      "bench-ring",
+		history: NewInMemoryHistory(defaultHistorySize),
+		clients: make(map[WebSocketClient]*clientStream),
+		output:  newOutputRing(outputRingSize),
+		screen:  newVTScreen(80, 24),
+	}
+	cursors := make([]*ringCursor, benchmarkClients)
+	for i := range cursors {
+		cursors[i] = session.output.subscribe()
+	}
+	readBuf := bytes.Repeat([]byte("x"), benchmarkChunkSize)
+
+	b.SetBytes(benchmarkChunkSize)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 1; i <= b.N; i++ {
+		session.recordOutput(readBuf)
+		if i%benchmarkRound == 0 || i == b.N {
+			for _, cursor := range cursors {
+				data, _ := session.output.read(cursor)
+				discardSend(data)
+			}
+		}
+	}
+}
+
+// BenchmarkBroadcastChannel measures the previous broadcast path for
+// comparison: each PTY read is copied and sent over a channel to a loop that
+// writes history and appends it to a pending buffer per client
+func BenchmarkBroadcastChannel(b *testing.B) {
+	history := NewInMemoryHistory(defaultHistorySize)
+	broadcast := make(chan []byte, 256)
+	processed := make(chan struct{}, 256)
+	pending := make([][]byte, benchmarkClients)
+	var mu sync.Mutex
+
+	go func() {
+		for data := range broadcast {
+			mu.Lock()
+			_, _ = history.Write(data)
+			for i := range pending {
+				pending[i] = append(pending[i], data...)
+			}
+			mu.Unlock()
+			processed <- struct{}{}
+		}
+	}()
+	defer close(broadcast)
+	readBuf := bytes.Repeat([]byte("x"), benchmarkChunkSize)
+
+	b.SetBytes(benchmarkChunkSize)
+	b.ReportAllocs()
+	b.ResetTimer()
+	sent := 0
+	for i := 1; i <= b.N; i++ {
+		data := make([]byte, benchmarkChunkSize)
+		copy(data, readBuf)
+		broadcast <- data
+		sent++
+		if i%benchmarkRound == 0 || i == b.N {
+			for ; sent > 0; sent-- {
+				<-processed
+			}
+			mu.Lock()
+			for j := range pending {
+				discardSend(pending[j])
+				pending[j] = nil
+			}
+			mu.Unlock()
+		}
+	}
+}
@@ -0,0 +1,134 @@
+package terminal
+
+import (
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ServerMessagePresence lists the clients attached to a session
+const ServerMessagePresence = "presence"
+
+// maxClientNameLength bounds the display name a client may choose
+const maxClientNameLength = 64
+
+// PresenceClient describes one attached client
+type PresenceClient struct {
+	ID         string `json:"id"`
+	Name       string `json:"name,omitempty"`
+	AttachedAt int64  `json:"attached_at"` // unix timestamp
+}
+
+// SessionPresence is sent to every client when someone attaches, detaches or
+// a different client starts typing
+type SessionPresence struct {
+	Self              string           `json:"self"` // ID of the receiving client
+	Clients           []PresenceClient `json:"clients"`
+	LastInputClientID string           `json:"last_input_client_id,omitempty"`
+	LastInputAt       int64            `json:"last_input_at,omitempty"` // unix timestamp
+}
+
+// NamedClient is implemented by clients that have a display name
+type NamedClient interface {
+	ClientName() string
+}
+
+// InputTracker is implemented by sessions that report who typed last
+type InputTracker interface {
+	NoteInput(client WebSocketClient)
+}
+
+// SanitizeClientName trims a client-chosen display name to printable
+// characters and a bounded length
+func SanitizeClientName(name string) string {
+	runes := make([]rune, 0, len(name))
+	for _, r := range name {
+		if r < 0x20 || r == 0x7f {
+			continue
+		}
+		runes = append(runes, r)
+		if len(runes) == maxClientNameLength {
+			break
+		}
+	}
+	return string(runes)
+}
+
+// addPresenceLocked records a newly attached client. Must be called with
+// s.clientsMu held.
+func (s *TerminalSession) addPresenceLocked(client WebSocketClient) {
+	if s.presence == nil {
+		s.presence = make(map[WebSocketClient]PresenceClient)
+	}
+	info := PresenceClient{ID: uuid.New().String(), AttachedAt: time.Now().Unix()}
+	if named, ok := client.(NamedClient); ok {
+		info.Name = SanitizeClientName(named.ClientName())
+	}
+	s.presence[client] = info
+}
+
+// removePresenceLocked forgets a detached client and tells the others. Must
+// be called with s.clientsMu held.
+func (s *TerminalSession) removePresenceLocked(client WebSocketClient) {
+	info, ok := s.presence[client]
+	if !ok {
+		return
+	}
+	delete(s.presence, client)
+	if s.lastInputClientID == info.ID {
+		s.lastInputClientID = ""
+	}
+	s.broadcastPresenceLocked()
+	if s.controllerID == info.ID {
+		s.setControllerLocked("")
+	}
+}
+
+// NoteInput records that client sent input, telling everyone when the
+// typist changes
+func (s *TerminalSession) NoteInput(client WebSocketClient) {
+	s.clientsMu.Lock()
+	defer s.clientsMu.Unlock()
+
+	info, ok := s.presence[client]
+	if !ok {
+		return
+	}
+	changed := s.lastInputClientID != info.ID
+	s.lastInputClientID = info.ID
+	s.lastInputAt = time.Now()
+	if changed {
+		s.broadcastPresenceLocked()
+	}
+}
+
+// broadcastPresenceLocked sends the current presence to every client that
+// accepts structured messages. Must be called with s.clientsMu held.
+func (s *TerminalSession) broadcastPresenceLocked() {
+	// Clients are listed in the order they attached
+	clients := make([]PresenceClient, 0, len(s.presence))
+	for _, client := range s.orderedClients {
+		if info, ok := s.presence[client]; ok {
+			clients = append(clients, info)
+		}
+	}
+
+	base := SessionPresence{Clients: clients}
+	if s.lastInputClientID != "" {
+		base.LastInputClientID = s.lastInputClientID
+		base.LastInputAt = s.lastInputAt.Unix()
+	}
+
+	for client, info := range s.presence {
+		sender, ok := client.(MessageSender)
+		if !ok {
+			continue
+		}
+		presence := base
+		presence.Self = info.ID
+		if err := sender.SendMessage(ServerMessage{Type: ServerMessagePresence, Presence: &presence}); err != nil {
+			log.Printf("Session %s: failed to send presence message: %v", s.id, err)
+		}
+	}
+}
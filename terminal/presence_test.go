@@ -0,0 +1,85 @@
+package terminal
+
+import (
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// namedMessageClient is a messageClient with a display name
+type namedMessageClient struct {
+	*messageClient
+	name string
+}
+
+func (c *namedMessageClient) ClientName() string {
+	return c.name
+}
+
+var _ = Describe("Session presence", func() {
+	newClient := func(name string) *namedMessageClient {
+		return &namedMessageClient{
+			messageClient: &messageClient{
+				MockWebSocketClient: NewMockWebSocketClient(),
+				messages:            make(chan ServerMessage, 8),
+			},
+			name: name,
+		}
+	}
+	nextPresence := func(client *namedMessageClient) SessionPresence {
+		var msg ServerMessage
+		Eventually(client.messages, "2s").Should(Receive(&msg))
+		Expect(msg.Type).To(Equal(ServerMessagePresence))
+		Expect(msg.Presence).ToNot(BeNil())
+		return *msg.Presence
+	}
+
+	It("should tell clients who is attached and who typed last", func() {
+		ptySvc, err := NewSimulatedPTYService()
+		Expect(err).ToNot(HaveOccurred())
+		DeferCleanup(ptySvc.Close)
+
+		session, err := NewTerminalSession(SessionConfig{
+			ID:          "presence-session",
+			HistorySize: 256,
+			PTYService:  ptySvc,
+		})
+		Expect(err).ToNot(HaveOccurred())
+		DeferCleanup(session.Close)
+
+		alice := newClient("alice")
+		bob := newClient("bob\x1b[31m")
+		Expect(session.AddClient(alice)).To(Succeed())
+		expectOutputSeq(alice.messageClient)
+		aliceID := nextPresence(alice).Self
+
+		Expect(session.AddClient(bob)).To(Succeed())
+		expectOutputSeq(bob.messageClient)
+		presence := nextPresence(alice)
+		Expect(presence.Self).To(Equal(aliceID))
+		Expect(presence.Clients).To(HaveLen(2))
+		Expect(presence.Clients[0].Name).To(Equal("alice"))
+		Expect(presence.Clients[1].Name).To(Equal("bob[31m"))
+		bobID := nextPresence(bob).Self
+		Expect(bobID).ToNot(Equal(aliceID))
+
+		session.NoteInput(bob)
+		Expect(nextPresence(alice).LastInputClientID).To(Equal(bobID))
+		Expect(nextPresence(bob).LastInputClientID).To(Equal(bobID))
+
+		// Only a change of typist is announced
+		session.NoteInput(bob)
+		Consistently(alice.messages, "100ms").ShouldNot(Receive())
+
+		session.RemoveClient(bob)
+		presence = nextPresence(alice)
+		Expect(presence.Clients).To(ConsistOf(HaveField("ID", aliceID)))
+		Expect(presence.LastInputClientID).To(BeEmpty())
+	})
+
+	It("should bound client names", func() {
+		Expect(SanitizeClientName(strings.Repeat("é", 100))).To(Equal(strings.Repeat("é", maxClientNameLength)))
+		Expect(SanitizeClientName("a\nb\x7f")).To(Equal("ab"))
+	})
+})
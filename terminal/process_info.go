@@ -0,0 +1,154 @@
+package terminal
+
+import (
+	"os"
+	"time"
+)
+
+// defaultProcessSampleInterval is how often process info is refreshed
+const defaultProcessSampleInterval = 5 * time.Second
+
+// ProcessInfo describes the processes running in a session's terminal, as
+// of the last sample
+type ProcessInfo struct {
+	PID               int           `json:"pid"` // the session's shell
+	ForegroundPID     int           `json:"foreground_pid,omitempty"`
+	ForegroundCommand string        `json:"foreground_command,omitempty"` // the program a user would reach with Ctrl-C
+	CPUPercent        float64       `json:"cpu_percent"`                  // shell and descendants, 100 = one core
+	RSSBytes          uint64        `json:"rss_bytes"`                    // shell and descendants
+	Children          []ProcessNode `json:"children,omitempty"`
+	SampledAt         time.Time     `json:"sampled_at"`
+}
+
+// ProcessNode is one process in a session's process tree
+type ProcessNode struct {
+	PID      int           `json:"pid"`
+	Command  string        `json:"command"`
+	RSSBytes uint64        `json:"rss_bytes"`
+	Children []ProcessNode `json:"children,omitempty"`
+}
+
+// processStat is what a sample needs to know about one process
+type processStat struct {
+	pid      int
+	ppid     int
+	command  string
+	cpuTicks uint64 // user and system time in clock ticks
+	rssBytes uint64
+}
+
+// processTable is a snapshot of the host's processes
+type processTable struct {
+	byPID    map[int]processStat
+	children map[int][]int
+}
+
+// processSample is what the previous sample of a session left for the
+// next one to compute CPU usage
+type processSample struct {
+	shellPID int
+	cpuTicks uint64
+	at       time.Time
+}
+
+// GetProcessSampleIntervalFromEnv returns TERMINAL_HUB_PROCESS_SAMPLE_INTERVAL,
+// or 5s. Zero or a negative duration turns sampling off.
+func GetProcessSampleIntervalFromEnv() time.Duration {
+	if val := os.Getenv("TERMINAL_HUB_PROCESS_SAMPLE_INTERVAL"); val != "" {
+		if d, err := time.ParseDuration(val); err == nil {
+			return d
+		}
+	}
+	return defaultProcessSampleInterval
+}
+
+// StartProcessSampler refreshes the process info in session metadata every
+// interval. It returns right away on hosts without process information.
+func (sm *SessionManager) StartProcessSampler(interval time.Duration) {
+	if interval <= 0 || !processInfoSupported {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		sm.sampleProcesses()
+	}
+}
+
+// sampleProcesses refreshes the process info of every session from one
+// snapshot of the host's processes
+func (sm *SessionManager) sampleProcesses() {
+	table, err := readProcessTable()
+	if err != nil {
+		return
+	}
+
+	sm.mu.RLock()
+	sessions := make([]*TerminalSession, 0, len(sm.sessions))
+	for _, sess := range sm.sessions {
+		if terminalSess, ok := sess.(*TerminalSession); ok {
+			sessions = append(sessions, terminalSess)
+		}
+	}
+	sm.mu.RUnlock()
+
+	for _, sess := range sessions {
+		sess.sampleProcess(table, time.Now())
+	}
+}
+
+// sampleProcess records the process info of the session from table
+func (s *TerminalSession) sampleProcess(table *processTable, now time.Time) {
+	pgrp, shellPID, err := s.foregroundProcess()
+	if err != nil {
+		return
+	}
+	if dir, ok := processDirectory(pgrp, shellPID); ok {
+		s.setCurrentDirectory(dir)
+	}
+	shell, ok := table.byPID[shellPID]
+	if !ok {
+		return
+	}
+
+	info := &ProcessInfo{PID: shellPID, RSSBytes: shell.rssBytes, SampledAt: now}
+	cpuTicks := shell.cpuTicks
+	var walk func(pid int) []ProcessNode
+	walk = func(pid int) []ProcessNode {
+		var nodes []ProcessNode
+		for _, childPID := range table.children[pid] {
+			child := table.byPID[childPID]
+			cpuTicks += child.cpuTicks
+			info.RSSBytes += child.rssBytes
+			nodes = append(nodes, ProcessNode{
+				PID:      child.pid,
+				Command:  child.command,
+				RSSBytes: child.rssBytes,
+				Children: walk(childPID),
+			})
+		}
+		return nodes
+	}
+	info.Children = walk(shellPID)
+
+	if leader, ok := table.byPID[pgrp]; ok {
+		info.ForegroundPID = pgrp
+		info.ForegroundCommand = leader.command
+	}
+
+	s.processMu.Lock()
+	prev := s.lastProcessSample
+	s.lastProcessSample = processSample{shellPID: shellPID, cpuTicks: cpuTicks, at: now}
+	s.processMu.Unlock()
+	if prev.shellPID == shellPID && now.After(prev.at) && cpuTicks >= prev.cpuTicks {
+		// Exited children take their CPU time with them, so usage can only be
+		// estimated while the tree is stable; a shrinking total reads as zero
+		elapsed := now.Sub(prev.at).Seconds()
+		info.CPUPercent = float64(cpuTicks-prev.cpuTicks) / clockTicksPerSecond / elapsed * 100
+	}
+
+	s.metadataMu.Lock()
+	s.metadata.Process = info
+	s.metadataMu.Unlock()
+}
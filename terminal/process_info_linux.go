@@ -0,0 +1,83 @@
+package terminal
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+const processInfoSupported = true
+
+// clockTicksPerSecond is the unit of CPU times in /proc. It is 100 on every
+// Linux platform Go supports.
+const clockTicksPerSecond = 100
+
+// readProcessTable reads every process from /proc
+func readProcessTable() (*processTable, error) {
+	entries, err := os.ReadDir("/proc")
+	if err != nil {
+		return nil, err
+	}
+	pageSize := uint64(os.Getpagesize())
+
+	table := &processTable{
+		byPID:    make(map[int]processStat),
+		children: make(map[int][]int),
+	}
+	for _, entry := range entries {
+		pid, err := strconv.Atoi(entry.Name())
+		if err != nil {
+			continue
+		}
+		stat, err := readProcessStat(pid, pageSize)
+		if err != nil {
+			// Processes exit while the table is read
+			continue
+		}
+		table.byPID[pid] = stat
+		table.children[stat.ppid] = append(table.children[stat.ppid], pid)
+	}
+	return table, nil
+}
+
+// readProcessStat reads one process from /proc/<pid>/stat and its command
+// line
+func readProcessStat(pid int, pageSize uint64) (processStat, error) {
+	data, err := os.ReadFile("/proc/" + strconv.Itoa(pid) + "/stat")
+	if err != nil {
+		return processStat{}, err
+	}
+	stat := string(data)
+	start, end := strings.IndexByte(stat, '('), strings.LastIndexByte(stat, ')')
+	if start < 0 || end < start {
+		return processStat{}, fmt.Errorf("unexpected /proc/%d/stat format", pid)
+	}
+	// Fields after the command name, starting with field 3 (state)
+	fields := strings.Fields(stat[end+1:])
+	if len(fields) < 22 {
+		return processStat{}, fmt.Errorf("unexpected /proc/%d/stat format", pid)
+	}
+	ppid, _ := strconv.Atoi(fields[1])
+	utime, _ := strconv.ParseUint(fields[11], 10, 64)
+	stime, _ := strconv.ParseUint(fields[12], 10, 64)
+	rssPages, _ := strconv.ParseUint(fields[21], 10, 64)
+
+	command := stat[start+1 : end]
+	if cmdline, err := os.ReadFile("/proc/" + strconv.Itoa(pid) + "/cmdline"); err == nil && len(cmdline) > 0 {
+		command = strings.TrimSpace(strings.ReplaceAll(string(cmdline), "\x00", " "))
+	}
+
+	return processStat{
+		pid:      pid,
+		ppid:     ppid,
+		command:  command,
+		cpuTicks: utime + stime,
+		rssBytes: rssPages * pageSize,
+	}, nil
+}
+
+// processWorkingDirectory reads the current directory of a process
+func processWorkingDirectory(pid int) (string, error) {
+	return os.Readlink("/proc/" + strconv.Itoa(pid) + "/cwd")
+}
@@ -0,0 +1,19 @@
+//go:build !linux
+
+package terminal
+
+import "errors"
+
+const processInfoSupported = false
+
+const clockTicksPerSecond = 100
+
+// readProcessTable is only implemented for Linux
+func readProcessTable() (*processTable, error) {
+	return nil, errors.New("process info is only available on Linux")
+}
+
+// processWorkingDirectory is only implemented for Linux
+func processWorkingDirectory(pid int) (string, error) {
+	return "", errors.New("process info is only available on Linux")
+}
@@ -0,0 +1,43 @@
+package terminal
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Process info", func() {
+	It("should report the shell, its children and the foreground command", func() {
+		if !processInfoSupported {
+			Skip("process info is not available on this platform")
+		}
+
+		session, err := NewTerminalSession(SessionConfig{
+			ID:         "process-info",
+			Shell:      "/bin/sh",
+			Backend:    SessionBackendPTY,
+			PTYService: &DefaultPTYService{},
+		})
+		Expect(err).ToNot(HaveOccurred())
+		DeferCleanup(session.Close)
+
+		_, err = session.Write([]byte("sleep 30\n"))
+		Expect(err).ToNot(HaveOccurred())
+
+		sample := func() *ProcessInfo {
+			table, err := readProcessTable()
+			Expect(err).ToNot(HaveOccurred())
+			session.sampleProcess(table, time.Now())
+			return session.GetMetadata().Process
+		}
+		Eventually(sample, "5s", "50ms").Should(HaveField("ForegroundCommand", "sleep 30"))
+
+		info := sample()
+		Expect(info.PID).To(Equal(session.cmd.Process.Pid))
+		Expect(info.RSSBytes).To(BeNumerically(">", 0))
+		Expect(info.CPUPercent).To(BeNumerically(">=", 0))
+		Expect(info.Children).To(ContainElement(HaveField("Command", "sleep 30")))
+		Expect(info.ForegroundPID).To(Equal(info.Children[0].PID))
+	})
+})
@@ -0,0 +1,167 @@
+package terminal
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// MaxSessionNameLength is the most characters in a session name
+const MaxSessionNameLength = 128
+
+// envVarName matches the names shells accept for environment variables
+var envVarName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// ValidateSessionName checks that name is not blank, is at most
+// MaxSessionNameLength characters and has no control characters
+func ValidateSessionName(name string) error {
+	if strings.TrimSpace(name) == "" {
+		return errors.New("name is required")
+	}
+	if !utf8.ValidString(name) {
+		return errors.New("name must be valid UTF-8")
+	}
+	if utf8.RuneCountInString(name) > MaxSessionNameLength {
+		return fmt.Errorf("name must be at most %d characters", MaxSessionNameLength)
+	}
+	if strings.IndexFunc(name, unicode.IsControl) >= 0 {
+		return errors.New("name must not contain control characters")
+	}
+	return nil
+}
+
+// ValidateEnvVars checks that environment variable names are letters, digits
+// and '_' not starting with a digit, and that values have no NUL byte
+func ValidateEnvVars(envVars map[string]string) error {
+	for name, value := range envVars {
+		if !envVarName.MatchString(name) {
+			return fmt.Errorf("invalid environment variable name %q: use letters, digits and '_', not starting with a digit", name)
+		}
+		if strings.IndexByte(value, 0) >= 0 {
+			return fmt.Errorf("environment variable %s must not contain a NUL byte", name)
+		}
+	}
+	return nil
+}
+
+// NormalizeBackend returns backend lower-cased, with tmux for empty
+func NormalizeBackend(backend SessionBackend) (SessionBackend, error) {
+	backend = SessionBackend(strings.ToLower(strings.TrimSpace(string(backend))))
+	switch backend {
+	case "":
+		return SessionBackendTmux, nil
+	case SessionBackendTmux, SessionBackendScreen, SessionBackendPTY, SessionBackendSSH:
+		return backend, nil
+	}
+	return "", errors.New(`backend must be "tmux", "screen", "pty" or "ssh"`)
+}
+
+// Validate checks the fields of the request that do not depend on the host
+func (r CreateSessionRequest) Validate() error {
+	if err := ValidateSessionName(r.Name); err != nil {
+		return err
+	}
+	if err := ValidateEnvVars(r.EnvVars); err != nil {
+		return err
+	}
+	backend, err := NormalizeBackend(r.Backend)
+	if err != nil {
+		return err
+	}
+	if backend == SessionBackendSSH {
+		if r.SSH == nil {
+			return errors.New("ssh target is required for the ssh backend")
+		}
+		if err := r.SSH.Validate(); err != nil {
+			return err
+		}
+	}
+	if _, err := NormalizeTags(r.Tags); err != nil {
+		return err
+	}
+	if err := ValidateInputMode(r.InputMode); err != nil {
+		return err
+	}
+	if r.ResizePolicy != nil {
+		if err := ValidateResizePolicy(*r.ResizePolicy); err != nil {
+			return err
+		}
+	}
+	if r.OnExit != nil {
+		if err := r.OnExit.Validate(); err != nil {
+			return err
+		}
+	}
+	if r.Limits != nil {
+		return r.Limits.Validate()
+	}
+	return nil
+}
+
+// Validate checks that the request changes something and that its fields
+// are valid
+func (r UpdateSessionRequest) Validate() error {
+	if r.Name == "" && r.Tags == nil && r.Pinned == nil && r.InputMode == "" && r.ResizePolicy == nil {
+		return errors.New("name, tags, pinned, input_mode or resize_policy is required")
+	}
+	if r.Name != "" {
+		if err := ValidateSessionName(r.Name); err != nil {
+			return err
+		}
+	}
+	if err := ValidateInputMode(r.InputMode); err != nil {
+		return err
+	}
+	if r.ResizePolicy != nil {
+		if err := ValidateResizePolicy(*r.ResizePolicy); err != nil {
+			return err
+		}
+	}
+	if r.Tags != nil {
+		if _, err := NormalizeTags(*r.Tags); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Validate checks the name, if given
+func (r CloneSessionRequest) Validate() error {
+	if r.Name != "" {
+		return ValidateSessionName(r.Name)
+	}
+	return nil
+}
+
+// Validate checks that the path is absolute
+func (r ChangeDirectoryRequest) Validate() error {
+	return ValidateDirectoryPath(r.Path)
+}
+
+// Validate checks that the request names a tmux session and, if given, a
+// valid session name
+func (r AdoptSessionRequest) Validate() error {
+	if r.TmuxSession == "" {
+		return errors.New("tmux_session is required")
+	}
+	if r.Name != "" {
+		return ValidateSessionName(r.Name)
+	}
+	return nil
+}
+
+// Validate checks that the request has a single-line command and a timeout
+// of at most MaxExecTimeout
+func (r ExecSessionRequest) Validate() error {
+	if err := ValidateExecCommand(r.Command); err != nil {
+		return err
+	}
+	if maxSeconds := int(MaxExecTimeout / time.Second); r.TimeoutSeconds < 0 || r.TimeoutSeconds > maxSeconds {
+		return fmt.Errorf("timeout_seconds must be between 1 and %d", maxSeconds)
+	}
+	return nil
+}
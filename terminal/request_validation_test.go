@@ -0,0 +1,48 @@
+package terminal
+
+import (
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Request validation", func() {
+	It("should check session names", func() {
+		Expect(ValidateSessionName("build server ✓")).To(Succeed())
+		Expect(ValidateSessionName(strings.Repeat("é", MaxSessionNameLength))).To(Succeed())
+
+		Expect(ValidateSessionName("  ")).To(MatchError("name is required"))
+		Expect(ValidateSessionName(strings.Repeat("a", MaxSessionNameLength+1))).To(MatchError(ContainSubstring("at most 128")))
+		Expect(ValidateSessionName("bad\x1b[31m")).To(MatchError(ContainSubstring("control characters")))
+		Expect(ValidateSessionName("bad\xff")).To(MatchError(ContainSubstring("UTF-8")))
+	})
+
+	It("should check environment variables", func() {
+		Expect(ValidateEnvVars(map[string]string{"PATH": "/bin", "_x1": ""})).To(Succeed())
+
+		Expect(ValidateEnvVars(map[string]string{"1X": "a"})).To(MatchError(ContainSubstring(`"1X"`)))
+		Expect(ValidateEnvVars(map[string]string{"A=B": "a"})).To(HaveOccurred())
+		Expect(ValidateEnvVars(map[string]string{"A": "a\x00b"})).To(MatchError(ContainSubstring("NUL")))
+	})
+
+	It("should validate create requests", func() {
+		Expect(CreateSessionRequest{Name: "dev", Backend: " PTY "}.Validate()).To(Succeed())
+
+		Expect(CreateSessionRequest{}.Validate()).To(MatchError("name is required"))
+		Expect(CreateSessionRequest{Name: "dev", Backend: "docker"}.Validate()).To(MatchError(ContainSubstring("backend must be")))
+		Expect(CreateSessionRequest{Name: "dev", Backend: SessionBackendSSH}.Validate()).To(MatchError(ContainSubstring("ssh target is required")))
+		Expect(CreateSessionRequest{Name: "dev", EnvVars: map[string]string{"bad-name": "x"}}.Validate()).To(HaveOccurred())
+		Expect(CreateSessionRequest{Name: "dev", InputMode: "exclusive"}.Validate()).To(HaveOccurred())
+	})
+
+	It("should validate update and adopt requests", func() {
+		pinned := true
+		Expect(UpdateSessionRequest{Pinned: &pinned}.Validate()).To(Succeed())
+		Expect(UpdateSessionRequest{}.Validate()).To(MatchError(ContainSubstring("is required")))
+		Expect(UpdateSessionRequest{Name: "tab\there"}.Validate()).To(HaveOccurred())
+
+		Expect(AdoptSessionRequest{TmuxSession: "main"}.Validate()).To(Succeed())
+		Expect(AdoptSessionRequest{}.Validate()).To(MatchError("tmux_session is required"))
+	})
+})
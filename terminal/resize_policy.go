@@ -0,0 +1,135 @@
+package terminal
+
+import (
+	"fmt"
+	"log"
+)
+
+// Resize policy modes decide how a session's terminal size follows the
+// sizes its clients report
+const (
+	ResizePolicyLatest        = "latest"         // the most recent resize wins (default)
+	ResizePolicyPrimary       = "primary"        // only the longest attached client resizes
+	ResizePolicyLargestCommon = "largest_common" // the largest size that fits every client
+	ResizePolicyFixed         = "fixed"          // a fixed size, client resizes are ignored
+)
+
+// maxTerminalDimension bounds fixed sizes
+const maxTerminalDimension = 1000
+
+// ResizePolicy is how a session picks its terminal size
+type ResizePolicy struct {
+	Mode string `json:"mode"`
+	Cols int    `json:"cols,omitempty"` // fixed mode only
+	Rows int    `json:"rows,omitempty"` // fixed mode only
+}
+
+// ValidateResizePolicy checks that policy has a known mode, and a size for
+// the fixed mode. An empty mode means latest.
+func ValidateResizePolicy(policy ResizePolicy) error {
+	switch policy.Mode {
+	case "", ResizePolicyLatest, ResizePolicyPrimary, ResizePolicyLargestCommon:
+		if policy.Cols != 0 || policy.Rows != 0 {
+			return fmt.Errorf("cols and rows are only allowed for the %q resize policy", ResizePolicyFixed)
+		}
+		return nil
+	case ResizePolicyFixed:
+		if policy.Cols < 1 || policy.Cols > maxTerminalDimension || policy.Rows < 1 || policy.Rows > maxTerminalDimension {
+			return fmt.Errorf("the %q resize policy needs cols and rows between 1 and %d", ResizePolicyFixed, maxTerminalDimension)
+		}
+		return nil
+	}
+	return fmt.Errorf("resize policy mode must be %q, %q, %q or %q",
+		ResizePolicyLatest, ResizePolicyPrimary, ResizePolicyLargestCommon, ResizePolicyFixed)
+}
+
+// termSize is a terminal size in cells
+type termSize struct {
+	cols, rows int
+}
+
+// setResizePolicy switches the resize policy and applies the size it picks
+func (s *TerminalSession) setResizePolicy(policy ResizePolicy) {
+	if policy.Mode == "" {
+		policy.Mode = ResizePolicyLatest
+	}
+
+	s.clientsMu.Lock()
+	defer s.clientsMu.Unlock()
+
+	s.resizePolicy = policy
+	s.metadataMu.Lock()
+	s.metadata.ResizePolicy = policy
+	s.metadataMu.Unlock()
+
+	if size, ok := s.policySizeLocked(nil); ok {
+		if err := s.applySizeLocked(size, false); err != nil {
+			log.Printf("Session %s: error applying resize policy: %v", s.id, err)
+		}
+	}
+}
+
+// policySizeLocked returns the size the resize policy picks after client
+// reported its size, or after the attached clients changed when client is
+// nil. It returns false when the size should stay as it is, which is always
+// the case for the latest policy once clients change. Must be called with
+// s.clientsMu held.
+func (s *TerminalSession) policySizeLocked(client WebSocketClient) (termSize, bool) {
+	switch s.resizePolicy.Mode {
+	case ResizePolicyFixed:
+		return termSize{s.resizePolicy.Cols, s.resizePolicy.Rows}, true
+	case ResizePolicyPrimary:
+		if len(s.orderedClients) == 0 {
+			return termSize{}, false
+		}
+		primary := s.orderedClients[0]
+		if client != nil && client != primary {
+			return termSize{}, false
+		}
+		size, ok := s.clientSizes[primary]
+		return size, ok
+	case ResizePolicyLargestCommon:
+		var common termSize
+		found := false
+		for _, c := range s.orderedClients {
+			size, ok := s.clientSizes[c]
+			if !ok {
+				continue
+			}
+			if !found || size.cols < common.cols {
+				common.cols = size.cols
+			}
+			if !found || size.rows < common.rows {
+				common.rows = size.rows
+			}
+			found = true
+		}
+		return common, found
+	default:
+		return termSize{}, false
+	}
+}
+
+// applySizeLocked resizes the PTY. When the size is unchanged and redraw
+// is set, the program is signalled to redraw instead, which a reloaded page
+// relies on. Must be called with s.clientsMu held.
+func (s *TerminalSession) applySizeLocked(size termSize, redraw bool) error {
+	s.termSizeMu.Lock()
+	changed := s.termCols != size.cols || s.termRows != size.rows
+	s.termCols = size.cols
+	s.termRows = size.rows
+	s.termSizeMu.Unlock()
+	if changed {
+		s.outputMu.Lock()
+		s.screen.resize(size.cols, size.rows)
+		s.outputMu.Unlock()
+	}
+
+	if !changed {
+		if redraw {
+			return sendSignalToProcess(s.cmd)
+		}
+		return nil
+	}
+	return s.ptySvc.SetSize(s.ptyFile, size.cols, size.rows)
+}
@@ -0,0 +1,118 @@
+package terminal
+
+import (
+	"os"
+	"sync"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// sizeRecordingPTYService remembers the last size set on the PTY
+type sizeRecordingPTYService struct {
+	*SimulatedPTYService
+	mu   sync.Mutex
+	size termSize
+	sets int
+}
+
+func (s *sizeRecordingPTYService) SetSize(_ *os.File, cols, rows int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.size = termSize{cols, rows}
+	s.sets++
+	return nil
+}
+
+func (s *sizeRecordingPTYService) lastSize() termSize {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.size
+}
+
+func (s *sizeRecordingPTYService) setCount() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.sets
+}
+
+var _ = Describe("Resize policies", func() {
+	var (
+		ptySvc  *sizeRecordingPTYService
+		session *TerminalSession
+		first   *MockWebSocketClient
+		second  *MockWebSocketClient
+	)
+
+	start := func(policy ResizePolicy) {
+		simulated, err := NewSimulatedPTYService()
+		Expect(err).ToNot(HaveOccurred())
+		DeferCleanup(simulated.Close)
+		ptySvc = &sizeRecordingPTYService{SimulatedPTYService: simulated}
+
+		session, err = NewTerminalSession(SessionConfig{
+			ID:           "resize-session",
+			HistorySize:  256,
+			PTYService:   ptySvc,
+			ResizePolicy: policy,
+		})
+		Expect(err).ToNot(HaveOccurred())
+		DeferCleanup(session.Close)
+
+		first, second = NewMockWebSocketClient(), NewMockWebSocketClient()
+		Expect(session.AddClient(first)).To(Succeed())
+		Expect(session.AddClient(second)).To(Succeed())
+	}
+
+	It("should let the latest resize win by default", func() {
+		start(ResizePolicy{})
+		Expect(session.Resize(first, 120, 40)).To(Succeed())
+		Expect(session.Resize(second, 80, 24)).To(Succeed())
+		Expect(ptySvc.lastSize()).To(Equal(termSize{80, 24}))
+		Expect(session.GetMetadata().ResizePolicy.Mode).To(Equal(ResizePolicyLatest))
+
+		// Repeating the same size does not resize again
+		sets := ptySvc.setCount()
+		Expect(session.Resize(second, 80, 24)).To(Succeed())
+		Expect(ptySvc.setCount()).To(Equal(sets))
+	})
+
+	It("should follow only the primary client", func() {
+		start(ResizePolicy{Mode: ResizePolicyPrimary})
+		Expect(session.Resize(first, 120, 40)).To(Succeed())
+		Expect(session.Resize(second, 80, 24)).To(Succeed())
+		Expect(ptySvc.lastSize()).To(Equal(termSize{120, 40}))
+
+		session.RemoveClient(first)
+		Expect(ptySvc.lastSize()).To(Equal(termSize{80, 24}))
+	})
+
+	It("should fit every client with the largest common size", func() {
+		start(ResizePolicy{Mode: ResizePolicyLargestCommon})
+		Expect(session.Resize(first, 120, 30)).To(Succeed())
+		Expect(session.Resize(second, 100, 50)).To(Succeed())
+		Expect(ptySvc.lastSize()).To(Equal(termSize{100, 30}))
+
+		session.RemoveClient(second)
+		Expect(ptySvc.lastSize()).To(Equal(termSize{120, 30}))
+	})
+
+	It("should keep a fixed size and switch policies at runtime", func() {
+		start(ResizePolicy{Mode: ResizePolicyFixed, Cols: 132, Rows: 43})
+		Expect(ptySvc.lastSize()).To(Equal(termSize{132, 43}))
+		Expect(session.Resize(first, 80, 24)).To(Succeed())
+		Expect(ptySvc.lastSize()).To(Equal(termSize{132, 43}))
+
+		session.setResizePolicy(ResizePolicy{Mode: ResizePolicyLargestCommon})
+		Expect(ptySvc.lastSize()).To(Equal(termSize{80, 24}))
+		Expect(session.GetMetadata().ResizePolicy).To(Equal(ResizePolicy{Mode: ResizePolicyLargestCommon}))
+	})
+
+	It("should validate resize policies", func() {
+		Expect(ValidateResizePolicy(ResizePolicy{})).To(Succeed())
+		Expect(ValidateResizePolicy(ResizePolicy{Mode: ResizePolicyFixed, Cols: 80, Rows: 24})).To(Succeed())
+		Expect(ValidateResizePolicy(ResizePolicy{Mode: ResizePolicyFixed})).ToNot(Succeed())
+		Expect(ValidateResizePolicy(ResizePolicy{Mode: ResizePolicyPrimary, Cols: 80})).ToNot(Succeed())
+		Expect(ValidateResizePolicy(ResizePolicy{Mode: "smallest"})).ToNot(Succeed())
+	})
+})
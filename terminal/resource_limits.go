@@ -0,0 +1,122 @@
+package terminal
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// Bounds for ResourceLimits.Nice
+const (
+	minNice = 0
+	maxNice = 19
+)
+
+// ResourceLimits are OS-level limits applied to a spawned shell or command
+// and inherited by everything it starts
+type ResourceLimits struct {
+	CPUSeconds   int     `json:"cpu_seconds,omitempty"`    // CPU time limit (ulimit -t)
+	MemoryBytes  int64   `json:"memory_bytes,omitempty"`   // virtual memory limit (ulimit -v)
+	MaxOpenFiles int     `json:"max_open_files,omitempty"` // open file descriptor limit (ulimit -n)
+	Nice         int     `json:"nice,omitempty"`           // scheduling niceness, 0-19
+	Cgroup       bool    `json:"cgroup,omitempty"`         // Linux: also run in a cgroup v2 enforcing the limits below
+	CgroupMemory int64   `json:"cgroup_memory,omitempty"`  // cgroup memory.max in bytes
+	CgroupCPUs   float64 `json:"cgroup_cpus,omitempty"`    // cgroup cpu.max as a number of CPUs, e.g. 0.5
+}
+
+// IsZero reports whether no limit is set
+func (l ResourceLimits) IsZero() bool {
+	return l == ResourceLimits{}
+}
+
+// Validate checks that every limit is within range
+func (l ResourceLimits) Validate() error {
+	if l.CPUSeconds < 0 {
+		return errors.New("cpu_seconds must not be negative")
+	}
+	if l.MemoryBytes < 0 {
+		return errors.New("memory_bytes must not be negative")
+	}
+	if l.MemoryBytes > 0 && l.MemoryBytes < 1024 {
+		return errors.New("memory_bytes must be at least 1024")
+	}
+	if l.MaxOpenFiles < 0 {
+		return errors.New("max_open_files must not be negative")
+	}
+	if l.Nice < minNice || l.Nice > maxNice {
+		return fmt.Errorf("nice must be between %d and %d", minNice, maxNice)
+	}
+	if l.CgroupMemory < 0 || l.CgroupCPUs < 0 {
+		return errors.New("cgroup limits must not be negative")
+	}
+	if !l.Cgroup && (l.CgroupMemory > 0 || l.CgroupCPUs > 0) {
+		return errors.New("cgroup_memory and cgroup_cpus require cgroup")
+	}
+	return nil
+}
+
+// hasUlimits reports whether the limits need the ulimit/nice wrapper
+func (l ResourceLimits) hasUlimits() bool {
+	return l.CPUSeconds > 0 || l.MemoryBytes > 0 || l.MaxOpenFiles > 0 || l.Nice > 0
+}
+
+// WrapCommand returns the program and arguments that run name with args
+// under the ulimit and nice settings. The wrapper execs the target, so the
+// returned command keeps the same process ID as the target would have.
+func (l ResourceLimits) WrapCommand(name string, args ...string) (string, []string) {
+	if !l.hasUlimits() {
+		return name, args
+	}
+
+	var script []string
+	if l.CPUSeconds > 0 {
+		script = append(script, "ulimit -t "+strconv.Itoa(l.CPUSeconds))
+	}
+	if l.MemoryBytes > 0 {
+		script = append(script, "ulimit -v "+strconv.FormatInt(l.MemoryBytes/1024, 10))
+	}
+	if l.MaxOpenFiles > 0 {
+		script = append(script, "ulimit -n "+strconv.Itoa(l.MaxOpenFiles))
+	}
+	target := `exec "$0" "$@"`
+	if l.Nice > 0 {
+		target = `exec nice -n ` + strconv.Itoa(l.Nice) + ` "$0" "$@"`
+	}
+	script = append(script, target)
+
+	return "/bin/sh", append([]string{"-c", strings.Join(script, " && "), name}, args...)
+}
+
+// LimitedPTYService is implemented by PTY services that can start a shell
+// under resource limits
+type LimitedPTYService interface {
+	StartWithLimits(shell string, workingDir string, envVars map[string]string, limits ResourceLimits) (*os.File, *exec.Cmd, error)
+}
+
+// getCgroupRoot returns the cgroup v2 directory under which per-session and
+// per-job cgroups are created
+func getCgroupRoot() string {
+	if root := os.Getenv("TERMINAL_HUB_CGROUP_ROOT"); root != "" {
+		return root
+	}
+	return "/sys/fs/cgroup/terminal-hub"
+}
+
+// ApplyCgroup moves a started process into its own cgroup when limits.Cgroup
+// is set. The returned cleanup removes the cgroup once the process has exited.
+// Failures are logged and leave the process running without the cgroup.
+func ApplyCgroup(name string, pid int, limits ResourceLimits) func() {
+	if !limits.Cgroup {
+		return func() {}
+	}
+	cleanup, err := applyCgroup(getCgroupRoot(), name, pid, limits)
+	if err != nil {
+		log.Printf("Warning: failed to apply cgroup limits for %s: %v", name, err)
+		return func() {}
+	}
+	return cleanup
+}
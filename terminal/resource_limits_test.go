@@ -0,0 +1,62 @@
+package terminal
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ResourceLimits", func() {
+	It("should validate ranges", func() {
+		Expect(ResourceLimits{}.Validate()).To(Succeed())
+		Expect(ResourceLimits{CPUSeconds: 10, MemoryBytes: 1 << 30, MaxOpenFiles: 256, Nice: 10}.Validate()).To(Succeed())
+		Expect(ResourceLimits{Nice: 20}.Validate()).ToNot(Succeed())
+		Expect(ResourceLimits{Nice: -5}.Validate()).ToNot(Succeed())
+		Expect(ResourceLimits{CPUSeconds: -1}.Validate()).ToNot(Succeed())
+		Expect(ResourceLimits{MemoryBytes: 10}.Validate()).ToNot(Succeed())
+		Expect(ResourceLimits{CgroupMemory: 1 << 20}.Validate()).To(MatchError("cgroup_memory and cgroup_cpus require cgroup"))
+	})
+
+	It("should leave commands untouched without ulimits", func() {
+		name, args := ResourceLimits{Cgroup: true}.WrapCommand("/bin/bash", "-l")
+		Expect(name).To(Equal("/bin/bash"))
+		Expect(args).To(Equal([]string{"-l"}))
+	})
+
+	It("should apply ulimits to the wrapped command", func() {
+		if runtime.GOOS == "windows" {
+			Skip("ulimit is not available on Windows")
+		}
+		name, args := ResourceLimits{MaxOpenFiles: 64, CPUSeconds: 30}.WrapCommand("/bin/sh", "-c", "ulimit -n; ulimit -t")
+		output, err := exec.Command(name, args...).Output()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(strings.Fields(string(output))).To(Equal([]string{"64", "30"}))
+	})
+
+	It("should write cgroup limits and the process ID", func() {
+		if runtime.GOOS != "linux" {
+			Skip("cgroups are only supported on Linux")
+		}
+		root := GinkgoT().TempDir()
+
+		cleanup, err := applyCgroup(root, "session-test", 4242, ResourceLimits{Cgroup: true, CgroupMemory: 1 << 20, CgroupCPUs: 0.5})
+		Expect(err).ToNot(HaveOccurred())
+
+		dir := filepath.Join(root, "session-test")
+		Expect(os.ReadFile(filepath.Join(dir, "memory.max"))).To(BeEquivalentTo("1048576"))
+		Expect(os.ReadFile(filepath.Join(dir, "cpu.max"))).To(BeEquivalentTo("50000 100000"))
+		Expect(os.ReadFile(filepath.Join(dir, "cgroup.procs"))).To(BeEquivalentTo("4242"))
+
+		// A real cgroup directory only holds kernel files; clear ours so it can be removed
+		for _, name := range []string{"memory.max", "cpu.max", "cgroup.procs"} {
+			Expect(os.Remove(filepath.Join(dir, name))).To(Succeed())
+		}
+		cleanup()
+		Expect(dir).ToNot(BeADirectory())
+	})
+})
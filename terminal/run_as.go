@@ -0,0 +1,141 @@
+package terminal
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"os/user"
+	"strconv"
+)
+
+// RunAs is the unprivileged account sessions and cron jobs are started as,
+// so exposing the hub does not hand out the daemon's own account. Switching
+// accounts requires the daemon to run as root or with CAP_SETUID/CAP_SETGID.
+type RunAs struct {
+	Username string   `json:"username,omitempty"`
+	UID      uint32   `json:"uid"`
+	GID      uint32   `json:"gid"`
+	Groups   []uint32 `json:"groups,omitempty"` // supplementary groups
+	Home     string   `json:"home,omitempty"`   // HOME and default working directory
+}
+
+// GetRunAsFromEnv reads TERMINAL_HUB_RUN_AS_USER (name or numeric UID) and the
+// optional TERMINAL_HUB_RUN_AS_GROUP and TERMINAL_HUB_RUN_AS_HOME overrides.
+// It returns nil when no user is configured.
+func GetRunAsFromEnv() (*RunAs, error) {
+	userSpec := os.Getenv("TERMINAL_HUB_RUN_AS_USER")
+	if userSpec == "" {
+		return nil, nil
+	}
+	return LookupRunAs(userSpec, os.Getenv("TERMINAL_HUB_RUN_AS_GROUP"), os.Getenv("TERMINAL_HUB_RUN_AS_HOME"))
+}
+
+// LookupRunAs resolves a user name or UID, and optionally a group name or GID
+// and home directory, into a RunAs. A numeric UID without a passwd entry is
+// accepted as long as the group is given too.
+func LookupRunAs(userSpec, groupSpec, home string) (*RunAs, error) {
+	if !runAsSupported {
+		return nil, fmt.Errorf("running sessions as another user is not supported on this platform")
+	}
+
+	runAs := &RunAs{}
+	if u, err := lookupUser(userSpec); err == nil {
+		uid, err := strconv.ParseUint(u.Uid, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid uid %q for user %q", u.Uid, userSpec)
+		}
+		gid, err := strconv.ParseUint(u.Gid, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid gid %q for user %q", u.Gid, userSpec)
+		}
+		runAs.Username = u.Username
+		runAs.UID = uint32(uid)
+		runAs.GID = uint32(gid)
+		runAs.Home = u.HomeDir
+		if groupIDs, err := u.GroupIds(); err == nil {
+			for _, id := range groupIDs {
+				if gid, err := strconv.ParseUint(id, 10, 32); err == nil {
+					runAs.Groups = append(runAs.Groups, uint32(gid))
+				}
+			}
+		}
+	} else {
+		uid, parseErr := strconv.ParseUint(userSpec, 10, 32)
+		if parseErr != nil || groupSpec == "" {
+			return nil, fmt.Errorf("unknown user %q: %w", userSpec, err)
+		}
+		runAs.UID = uint32(uid)
+	}
+
+	if groupSpec != "" {
+		gid, err := lookupGroupID(groupSpec)
+		if err != nil {
+			return nil, err
+		}
+		runAs.GID = gid
+	}
+	if home != "" {
+		runAs.Home = home
+	}
+	if runAs.UID == 0 {
+		return nil, fmt.Errorf("refusing to run sessions as root")
+	}
+	return runAs, nil
+}
+
+// lookupUser finds a user by name, falling back to a numeric UID
+func lookupUser(spec string) (*user.User, error) {
+	u, err := user.Lookup(spec)
+	if err == nil {
+		return u, nil
+	}
+	if _, parseErr := strconv.ParseUint(spec, 10, 32); parseErr == nil {
+		return user.LookupId(spec)
+	}
+	return nil, err
+}
+
+// lookupGroupID resolves a group name or numeric GID
+func lookupGroupID(spec string) (uint32, error) {
+	if gid, err := strconv.ParseUint(spec, 10, 32); err == nil {
+		return uint32(gid), nil
+	}
+	g, err := user.LookupGroup(spec)
+	if err != nil {
+		return 0, fmt.Errorf("unknown group %q: %w", spec, err)
+	}
+	gid, err := strconv.ParseUint(g.Gid, 10, 32)
+	if err != nil {
+		return 0, fmt.Errorf("invalid gid %q for group %q", g.Gid, spec)
+	}
+	return uint32(gid), nil
+}
+
+// Env returns the identity variables for the account
+func (r *RunAs) Env() []string {
+	var env []string
+	if r.Home != "" {
+		env = append(env, "HOME="+r.Home)
+	}
+	if r.Username != "" {
+		env = append(env, "USER="+r.Username, "LOGNAME="+r.Username)
+	}
+	return env
+}
+
+// Apply makes cmd start as the account: it sets the process credentials,
+// overrides HOME, USER and LOGNAME in cmd.Env and starts in the home
+// directory when no working directory is set. A nil RunAs leaves cmd as is.
+func (r *RunAs) Apply(cmd *exec.Cmd) {
+	if r == nil {
+		return
+	}
+	setCredential(cmd, r)
+	if cmd.Env == nil {
+		cmd.Env = os.Environ()
+	}
+	cmd.Env = append(cmd.Env, r.Env()...)
+	if cmd.Dir == "" && r.Home != "" {
+		cmd.Dir = r.Home
+	}
+}
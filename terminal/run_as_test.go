@@ -0,0 +1,81 @@
+//go:build !windows
+
+package terminal
+
+import (
+	"os"
+	"os/exec"
+	"os/user"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("RunAs", func() {
+	It("should be disabled without TERMINAL_HUB_RUN_AS_USER", func() {
+		GinkgoT().Setenv("TERMINAL_HUB_RUN_AS_USER", "")
+		runAs, err := GetRunAsFromEnv()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(runAs).To(BeNil())
+	})
+
+	It("should accept numeric IDs with an explicit group and home", func() {
+		runAs, err := LookupRunAs("54321", "54322", "/srv/sandbox")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(runAs.UID).To(BeEquivalentTo(54321))
+		Expect(runAs.GID).To(BeEquivalentTo(54322))
+		Expect(runAs.Home).To(Equal("/srv/sandbox"))
+	})
+
+	It("should reject unknown users and root", func() {
+		_, err := LookupRunAs("no-such-user-terminal-hub", "", "")
+		Expect(err).To(HaveOccurred())
+		_, err = LookupRunAs("54321", "", "")
+		Expect(err).To(HaveOccurred())
+		_, err = LookupRunAs("0", "0", "")
+		Expect(err).To(MatchError("refusing to run sessions as root"))
+	})
+
+	It("should resolve named users from the passwd database", func() {
+		if _, err := user.Lookup("nobody"); err != nil {
+			Skip("no nobody user on this system")
+		}
+		runAs, err := LookupRunAs("nobody", "", "")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(runAs.Username).To(Equal("nobody"))
+		Expect(runAs.UID).ToNot(BeZero())
+	})
+
+	It("should set credentials, identity variables and the home directory", func() {
+		runAs := &RunAs{Username: "sandbox", UID: 54321, GID: 54322, Home: "/srv/sandbox"}
+		cmd := exec.Command("/bin/true")
+		cmd.Env = []string{"HOME=/root", "PATH=/usr/bin"}
+		runAs.Apply(cmd)
+
+		credential := cmd.SysProcAttr.Credential
+		Expect(credential).ToNot(BeNil())
+		Expect(credential.Uid).To(BeEquivalentTo(54321))
+		Expect(credential.Gid).To(BeEquivalentTo(54322))
+		Expect(cmd.Dir).To(Equal("/srv/sandbox"))
+		Expect(cmd.Env).To(HaveExactElements("HOME=/root", "PATH=/usr/bin", "HOME=/srv/sandbox", "USER=sandbox", "LOGNAME=sandbox"))
+
+		var nilRunAs *RunAs
+		untouched := exec.Command("/bin/true")
+		nilRunAs.Apply(untouched)
+		Expect(untouched.SysProcAttr).To(BeNil())
+	})
+
+	It("should start processes as the configured account", func() {
+		if os.Geteuid() != 0 {
+			Skip("switching users requires root")
+		}
+		runAs := &RunAs{UID: 54321, GID: 54322, Home: os.TempDir()}
+		cmd := exec.Command("/bin/sh", "-c", "id -u; id -g")
+		runAs.Apply(cmd)
+
+		output, err := cmd.Output()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(strings.Fields(string(output))).To(Equal([]string{"54321", "54322"}))
+	})
+})
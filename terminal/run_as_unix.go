@@ -0,0 +1,22 @@
+//go:build !windows
+
+package terminal
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+const runAsSupported = true
+
+// setCredential makes cmd start with the account's UID, GID and groups
+func setCredential(cmd *exec.Cmd, r *RunAs) {
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	cmd.SysProcAttr.Credential = &syscall.Credential{
+		Uid:    r.UID,
+		Gid:    r.GID,
+		Groups: r.Groups,
+	}
+}
@@ -0,0 +1,13 @@
+//go:build windows
+
+package terminal
+
+import (
+	"os/exec"
+)
+
+// Process credentials cannot be switched on Windows
+const runAsSupported = false
+
+// setCredential is a no-op on Windows; LookupRunAs never returns a RunAs here
+func setCredential(cmd *exec.Cmd, r *RunAs) {}
@@ -0,0 +1,60 @@
+package terminal
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os/exec"
+
+	"github.com/creack/pty"
+)
+
+var errScreenUnavailable = errors.New("screen executable not found")
+
+// startScreenSession starts or reattaches to a GNU screen session named
+// after the session ID. Like tmux, screen keeps the shell running when the
+// attached client goes away.
+func startScreenSession(config SessionConfig) (sessionStartResult, error) {
+	if _, err := exec.LookPath("screen"); err != nil {
+		return sessionStartResult{}, errScreenUnavailable
+	}
+
+	// -x -RR attaches to the session if it exists, sharing it with other
+	// displays, and creates it otherwise
+	sessionName := sanitizeTmuxSessionName(config.ID)
+	args := []string{"-x", "-RR", "-S", sessionName}
+
+	// The screen daemon owns the shell, so only ulimit/nice limits apply
+	shell, shellArgs := config.Limits.WrapCommand(config.Shell)
+	args = append(args, shell)
+	args = append(args, shellArgs...)
+	if config.Limits.Cgroup {
+		log.Printf("Session %s: cgroup limits are not supported with the screen backend", config.ID)
+	}
+
+	cmd := exec.Command("screen", args...)
+	if config.WorkingDirectory != "" {
+		cmd.Dir = config.WorkingDirectory
+	}
+	cmd.Env = buildCommandEnv(config.EnvVars)
+	config.RunAs.Apply(cmd)
+
+	ptmx, err := pty.Start(cmd)
+	if err != nil {
+		return sessionStartResult{}, fmt.Errorf("failed to start screen session: %w", err)
+	}
+
+	return sessionStartResult{
+		ptmx:              ptmx,
+		cmd:               cmd,
+		backend:           SessionBackendScreen,
+		screenSessionName: sessionName,
+	}, nil
+}
+
+// killScreenSession ends a screen session and every window in it
+func killScreenSession(runAs *RunAs, sessionName string) error {
+	cmd := exec.Command("screen", "-S", sessionName, "-X", "quit")
+	runAs.Apply(cmd)
+	return cmd.Run()
+}
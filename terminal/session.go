@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"io"
 	"log"
+	"maps"
 	"os"
 	"os/exec"
 	"strings"
@@ -13,10 +14,20 @@ import (
 	"unicode"
 
 	"github.com/creack/pty"
+	"github.com/iwanhae/terminal-hub/notify"
 )
 
 const defaultHistorySize = 4096
 
+// ptyReadBufferSize is the most output read from the PTY at once
+const ptyReadBufferSize = 32 * 1024
+
+// Output batching in client streams
+const (
+	broadcastBatchDelay    = 5 * time.Millisecond // how long to wait for more output before sending
+	broadcastBatchMaxBytes = 32 * 1024            // send right away once this much is pending
+)
+
 var errTmuxUnavailable = errors.New("tmux executable not found")
 
 // InMemoryHistory implements HistoryProvider with an in-memory buffer
@@ -39,9 +50,10 @@ func (h *InMemoryHistory) Write(p []byte) (n int, err error) {
 	h.mu.Lock()
 	defer h.mu.Unlock()
 
-	// If new data is larger than size, just take the last 'size' bytes of it
+	// If new data is larger than size, just take the last 'size' bytes of it.
+	// p is copied since callers may reuse it.
 	if len(p) > h.size {
-		h.buffer = p[len(p)-h.size:]
+		h.buffer = append(h.buffer[:0], p[len(p)-h.size:]...)
 		return len(p), nil
 	}
 
@@ -74,8 +86,15 @@ type TerminalSession struct {
 	ptySvc  PTYService
 	backend SessionBackend
 
-	// tmux-specific state
-	tmuxSessionName string
+	// tmux- and screen-specific state
+	tmuxSessionName   string
+	tmuxAdopted       bool // attached to a pre-existing tmux session, which outlives this session
+	tmuxHandedOff     bool // detached for another hub process, which keeps the tmux session
+	screenSessionName string
+	runAs             *RunAs // account tmux and screen commands run as, nil = the daemon's own
+
+	// How the shell was started, for CloneConfig
+	launch SessionLaunch
 
 	// Metadata
 	metadata   SessionMetadata
@@ -87,20 +106,58 @@ type TerminalSession struct {
 	termSizeMu sync.RWMutex
 
 	// Clients management
-	clients        map[WebSocketClient]bool
+	clients        map[WebSocketClient]*clientStream
 	clientsMu      sync.Mutex
-	broadcast      chan []byte
 	orderedClients []WebSocketClient
+	maxClients     int // 0 = unlimited
+
+	// PTY output shared by all clients. outputMu keeps history, the screen
+	// and the ring in step so a client's history snapshot and stream line up.
+	output   *outputRing
+	screen   *vtScreen // the output rendered, to repaint attaching clients
+	outputMu sync.Mutex
+
+	// Directory last reported by the shell with OSC 7, guarded by outputMu
+	reportedDirectory string
+
+	// Presence of attached clients, guarded by clientsMu
+	presence          map[WebSocketClient]PresenceClient
+	lastInputClientID string
+	lastInputAt       time.Time
 
-	// Session rate limiting
-	outputRateLimit   chan struct{}
-	rateLimitMu       sync.Mutex
-	lastRateLimitWarn time.Time
+	// Single-writer input control, guarded by clientsMu
+	inputMode    string
+	controllerID string // presence ID of the client allowed to type, "" = nobody
+
+	// Sizes reported by clients and how they set the PTY size, guarded by
+	// clientsMu
+	clientSizes  map[WebSocketClient]termSize
+	resizePolicy ResizePolicy
+
+	// CPU time seen by the last process sample
+	lastProcessSample processSample
+	processMu         sync.Mutex
+
+	// OSC 52 clipboard sequences are taken out of the output stream
+	clipboard   osc52Filter
+	clipboardMu sync.Mutex
+
+	// Watch rules and the event callback and channels they report to
+	watcher  *sessionWatcher
+	onEvent  func(SessionEvent) // nil if not set
+	channels *notify.Channels   // named channels watch rules can notify, nil = none
+
+	// The command run through Exec, capturing output until it finishes
+	execRun *execRun // nil if none
+	execMu  sync.Mutex
 
 	// Lifecycle
-	closed  bool
-	closeMu sync.RWMutex
-	onExit  func() // bound callback, nil if not set
+	closed        bool
+	closeMu       sync.RWMutex
+	onExit        func() // bound callback, nil if not set
+	exitActions   ExitActions
+	writeGuard    func() error // non-nil error pauses archiving history, nil = always write
+	cgroupCleanup func()       // removes the session cgroup, nil if none
 }
 
 // SessionConfig holds configuration for creating a new session
@@ -114,15 +171,33 @@ type SessionConfig struct {
 	Backend          SessionBackend
 	HistorySize      int
 	PTYService       PTYService
+	MaxClients       int                    // Maximum attached clients, 0 = unlimited
+	Limits           ResourceLimits         // OS-level limits for the shell
+	RunAs            *RunAs                 // Account the shell runs as, nil = the daemon's own
+	SSH              *SSHTarget             // Remote host for the ssh backend
+	SSHGateway       *SSHGateway            // Opens connections for the ssh backend
+	AdoptTmuxSession string                 // Existing tmux session to attach to instead of creating one
 	OnExit           func(sessionID string) // Called when the underlying process exits naturally
+	OnEvent          func(SessionEvent)     // Receives events raised by the session, e.g. fired watch rules
+	Channels         *notify.Channels       // Named channels watch rules can notify
+	Tags             []string               // Normalized tags, see NormalizeTags
+	Prefs            SessionPrefs           // Pinned state and custom sort order
+	InputMode        string                 // "shared" (default) or "single_writer"
+	ResizePolicy     ResizePolicy           // How the terminal size follows clients, latest by default
+	ExitActions      ExitActions            // Run by the manager when the process exits on its own
+	WriteGuard       func() error           // Checked before archiving history; an error skips the archive
+	CreatedAt        time.Time              // Creation time of a restored session, now when zero
+	Launch           *SessionLaunch         // How a restored session's shell was first started, nil = from this config
 }
 
 type sessionStartResult struct {
-	ptmx            *os.File
-	cmd             *exec.Cmd
-	backend         SessionBackend
-	backendFallback string
-	tmuxSessionName string
+	ptmx              *os.File
+	cmd               *exec.Cmd
+	backend           SessionBackend
+	backendFallback   string
+	tmuxSessionName   string
+	screenSessionName string
+	ptySvc            PTYService // replaces the configured PTY service, e.g. for ssh
 }
 
 // NewTerminalSession creates a new terminal session
@@ -144,39 +219,96 @@ func NewTerminalSession(config SessionConfig) (*TerminalSession, error) {
 
 	ptySvc := config.PTYService
 	if ptySvc == nil {
-		ptySvc = &DefaultPTYService{}
+		ptySvc = &DefaultPTYService{RunAs: config.RunAs}
 	}
 
 	startResult, err := startSessionProcess(config, ptySvc)
 	if err != nil {
 		return nil, err
 	}
+	if startResult.ptySvc != nil {
+		ptySvc = startResult.ptySvc
+	}
+
+	inputMode := config.InputMode
+	if inputMode == "" {
+		inputMode = InputModeShared
+	}
+	resizePolicy := config.ResizePolicy
+	if resizePolicy.Mode == "" {
+		resizePolicy.Mode = ResizePolicyLatest
+	}
 
 	now := time.Now()
+	createdAt := config.CreatedAt
+	if createdAt.IsZero() {
+		createdAt = now
+	}
 	session := &TerminalSession{
-		id:              config.ID,
-		ptyFile:         startResult.ptmx,
-		cmd:             startResult.cmd,
-		history:         NewInMemoryHistory(config.HistorySize),
-		ptySvc:          ptySvc,
-		backend:         startResult.backend,
-		tmuxSessionName: startResult.tmuxSessionName,
+		id:                config.ID,
+		ptyFile:           startResult.ptmx,
+		cmd:               startResult.cmd,
+		history:           NewInMemoryHistory(config.HistorySize),
+		ptySvc:            ptySvc,
+		backend:           startResult.backend,
+		tmuxSessionName:   startResult.tmuxSessionName,
+		screenSessionName: startResult.screenSessionName,
+		tmuxAdopted:       config.AdoptTmuxSession != "",
+		runAs:             config.RunAs,
 		metadata: SessionMetadata{
 			Name:             config.Name,
-			CreatedAt:        now,
+			CreatedAt:        createdAt,
 			LastActivityAt:   now,
 			ClientCount:      0,
 			WorkingDirectory: config.WorkingDirectory,
 			Backend:          startResult.backend,
 			BackendFallback:  startResult.backendFallback,
+			TmuxSession:      startResult.tmuxSessionName,
+			Adopted:          config.AdoptTmuxSession != "",
+			Tags:             config.Tags,
+			InputMode:        inputMode,
+			ResizePolicy:     resizePolicy,
+			Pinned:           config.Prefs.Pinned,
+			SortOrder:        config.Prefs.SortOrder,
 		},
-		termCols:        80, // Default size
-		termRows:        24,
-		clients:         make(map[WebSocketClient]bool),
-		broadcast:       make(chan []byte, 256),
-		orderedClients:  make([]WebSocketClient, 0),
-		closed:          false,
-		outputRateLimit: make(chan struct{}, 500), // Max 500 messages per second
+		termCols:       80, // Default size
+		termRows:       24,
+		clients:        make(map[WebSocketClient]*clientStream),
+		presence:       make(map[WebSocketClient]PresenceClient),
+		inputMode:      inputMode,
+		clientSizes:    make(map[WebSocketClient]termSize),
+		resizePolicy:   resizePolicy,
+		output:         newOutputRing(outputRingSize),
+		screen:         newVTScreen(80, 24),
+		orderedClients: make([]WebSocketClient, 0),
+		maxClients:     config.MaxClients,
+		closed:         false,
+		onEvent:        config.OnEvent,
+		channels:       config.Channels,
+		exitActions:    config.ExitActions,
+		writeGuard:     config.WriteGuard,
+	}
+	session.watcher = newSessionWatcher(session.fireWatchRule)
+
+	session.launch = SessionLaunch{Shell: config.Shell, Command: config.Command, EnvVars: maps.Clone(config.EnvVars)}
+	if config.Launch != nil {
+		session.launch = *config.Launch
+	}
+
+	if !config.Limits.IsZero() {
+		limits := config.Limits
+		session.metadata.Limits = &limits
+	}
+	if !config.ExitActions.IsZero() {
+		actions := config.ExitActions
+		session.metadata.OnExit = &actions
+	}
+	if startResult.backend == SessionBackendSSH {
+		target := *config.SSH
+		session.metadata.SSH = &target
+	}
+	if startResult.backend == SessionBackendPTY && startResult.cmd != nil && startResult.cmd.Process != nil {
+		session.cgroupCleanup = ApplyCgroup("session-"+sanitizeTmuxSessionName(config.ID), startResult.cmd.Process.Pid, config.Limits)
 	}
 
 	if config.OnExit != nil {
@@ -185,12 +317,17 @@ func NewTerminalSession(config SessionConfig) (*TerminalSession, error) {
 		session.onExit = func() { cb(sessionID) }
 	}
 
+	if resizePolicy.Mode == ResizePolicyFixed {
+		session.clientsMu.Lock()
+		if err := session.applySizeLocked(termSize{resizePolicy.Cols, resizePolicy.Rows}, false); err != nil {
+			log.Printf("Session %s: error applying fixed size: %v", config.ID, err)
+		}
+		session.clientsMu.Unlock()
+	}
+
 	// Start PTY reader goroutine
 	go session.readPTY()
 
-	// Start broadcaster goroutine
-	go session.broadcastLoop()
-
 	// Execute initial command if provided
 	if config.Command != "" {
 		go func() {
@@ -206,7 +343,9 @@ func NewTerminalSession(config SessionConfig) (*TerminalSession, error) {
 
 func resolveRequestedBackend(config SessionConfig) SessionBackend {
 	backend := SessionBackend(strings.ToLower(strings.TrimSpace(string(config.Backend))))
-	if backend != SessionBackendPTY && backend != SessionBackendTmux {
+	switch backend {
+	case SessionBackendPTY, SessionBackendTmux, SessionBackendScreen, SessionBackendSSH:
+	default:
 		backend = ""
 	}
 
@@ -223,24 +362,52 @@ func resolveRequestedBackend(config SessionConfig) SessionBackend {
 
 func startSessionProcess(config SessionConfig, ptySvc PTYService) (sessionStartResult, error) {
 	backend := resolveRequestedBackend(config)
-	if backend == SessionBackendTmux {
-		startResult, err := startTmuxSession(config)
-		if err == nil {
-			return startResult, nil
+	if backend == SessionBackendSSH {
+		return startSSHSession(config)
+	}
+	if config.AdoptTmuxSession != "" {
+		// There is nothing to fall back to when attaching to an existing session
+		return startTmuxSession(config)
+	}
+	if backend == SessionBackendTmux || backend == SessionBackendScreen {
+		// Fall back along tmux -> screen -> pty, reporting why the
+		// requested backend could not be used
+		chain := []SessionBackend{backend}
+		if backend == SessionBackendTmux {
+			chain = append(chain, SessionBackendScreen)
+		}
+
+		var fallbackReason string
+		for i, candidate := range chain {
+			var startResult sessionStartResult
+			var err error
+			if candidate == SessionBackendTmux {
+				startResult, err = startTmuxSession(config)
+			} else {
+				startResult, err = startScreenSession(config)
+			}
+			if err == nil {
+				startResult.backendFallback = fallbackReason
+				return startResult, nil
+			}
+
+			if fallbackReason == "" {
+				fallbackReason = backendFallbackReason(candidate, err)
+			}
+			next := SessionBackendPTY
+			if i+1 < len(chain) {
+				next = chain[i+1]
+			}
+			log.Printf(
+				"Session %s: failed to initialize %s backend (%v), falling back to %s",
+				config.ID,
+				candidate,
+				err,
+				next,
+			)
 		}
 
-		fallbackReason := tmuxFallbackReason(err)
-		log.Printf(
-			"Session %s: failed to initialize tmux backend (%v), falling back to pty",
-			config.ID,
-			err,
-		)
-
-		ptmx, cmd, ptyErr := ptySvc.StartWithConfig(
-			config.Shell,
-			config.WorkingDirectory,
-			config.EnvVars,
-		)
+		ptmx, cmd, ptyErr := startPTY(config, ptySvc)
 		if ptyErr != nil {
 			return sessionStartResult{}, ptyErr
 		}
@@ -253,7 +420,7 @@ func startSessionProcess(config SessionConfig, ptySvc PTYService) (sessionStartR
 		}, nil
 	}
 
-	ptmx, cmd, err := ptySvc.StartWithConfig(config.Shell, config.WorkingDirectory, config.EnvVars)
+	ptmx, cmd, err := startPTY(config, ptySvc)
 	if err != nil {
 		return sessionStartResult{}, err
 	}
@@ -265,25 +432,54 @@ func startSessionProcess(config SessionConfig, ptySvc PTYService) (sessionStartR
 	}, nil
 }
 
+// startPTY starts the configured shell, under resource limits when the PTY
+// service supports them
+func startPTY(config SessionConfig, ptySvc PTYService) (*os.File, *exec.Cmd, error) {
+	if !config.Limits.IsZero() {
+		if limited, ok := ptySvc.(LimitedPTYService); ok {
+			return limited.StartWithLimits(config.Shell, config.WorkingDirectory, config.EnvVars, config.Limits)
+		}
+		log.Printf("Session %s: PTY service does not support resource limits, ignoring them", config.ID)
+	}
+	return ptySvc.StartWithConfig(config.Shell, config.WorkingDirectory, config.EnvVars)
+}
+
 func startTmuxSession(config SessionConfig) (sessionStartResult, error) {
 	if _, err := exec.LookPath("tmux"); err != nil {
 		return sessionStartResult{}, errTmuxUnavailable
 	}
 
 	sessionName := sanitizeTmuxSessionName(config.ID)
-	args := []string{"new-session", "-A", "-s", sessionName}
-	if config.WorkingDirectory != "" {
-		args = append(args, "-c", config.WorkingDirectory)
-	}
+	var args []string
+	if config.AdoptTmuxSession != "" {
+		// "=" makes tmux match the session name exactly rather than by prefix
+		sessionName = config.AdoptTmuxSession
+		if err := newTmuxCommand(config.RunAs, "has-session", "-t", "="+sessionName).Run(); err != nil {
+			return sessionStartResult{}, fmt.Errorf("tmux session %q not found", sessionName)
+		}
+		args = []string{"attach-session", "-t", "=" + sessionName}
+	} else {
+		args = []string{"new-session", "-A", "-s", sessionName}
+		if config.WorkingDirectory != "" {
+			args = append(args, "-c", config.WorkingDirectory)
+		}
 
-	// Ensure newly created tmux sessions start in the configured shell.
-	args = append(args, config.Shell)
+		// Ensure newly created tmux sessions start in the configured shell.
+		// The tmux server owns the shell, so only ulimit/nice limits apply.
+		shell, shellArgs := config.Limits.WrapCommand(config.Shell)
+		args = append(args, shell)
+		args = append(args, shellArgs...)
+		if config.Limits.Cgroup {
+			log.Printf("Session %s: cgroup limits are not supported with the tmux backend", config.ID)
+		}
+	}
 
 	cmd := exec.Command("tmux", args...)
 	if config.WorkingDirectory != "" {
 		cmd.Dir = config.WorkingDirectory
 	}
 	cmd.Env = buildCommandEnv(config.EnvVars)
+	config.RunAs.Apply(cmd)
 
 	ptmx, err := pty.Start(cmd)
 	if err != nil {
@@ -298,6 +494,14 @@ func startTmuxSession(config SessionConfig) (sessionStartResult, error) {
 	}, nil
 }
 
+// newTmuxCommand builds a tmux command that talks to the tmux server of the
+// account sessions run as, since each user has their own server socket
+func newTmuxCommand(runAs *RunAs, args ...string) *exec.Cmd {
+	cmd := exec.Command("tmux", args...)
+	runAs.Apply(cmd)
+	return cmd
+}
+
 func sanitizeTmuxSessionName(sessionID string) string {
 	trimmed := strings.TrimSpace(sessionID)
 	if trimmed == "" {
@@ -321,11 +525,11 @@ func sanitizeTmuxSessionName(sessionID string) string {
 	return name
 }
 
-func tmuxFallbackReason(err error) string {
-	if errors.Is(err, errTmuxUnavailable) {
-		return "tmux_not_found"
+func backendFallbackReason(backend SessionBackend, err error) string {
+	if errors.Is(err, errTmuxUnavailable) || errors.Is(err, errScreenUnavailable) {
+		return string(backend) + "_not_found"
 	}
-	return "tmux_start_failed"
+	return string(backend) + "_start_failed"
 }
 
 func buildCommandEnv(envVars map[string]string) []string {
@@ -370,8 +574,31 @@ func (s *TerminalSession) AddClient(client WebSocketClient) error {
 	s.clientsMu.Lock()
 	defer s.clientsMu.Unlock()
 
-	s.clients[client] = true
+	if err := s.checkClientLimitLocked(); err != nil {
+		return err
+	}
+
+	// Send history to new client, or what it missed when resuming, then
+	// stream output written after it
+	s.outputMu.Lock()
+	head := s.output.position()
+	if replay := replayFrame(client, s.history.GetHistory(), s.screen.repaint(), head); len(replay) > 0 {
+		if err := client.Send(replay); err != nil {
+			log.Printf("Error sending history to client: %v", err)
+		}
+	}
+	s.sendOutputSeq(client, head)
+	s.clients[client] = s.startClientStreamLocked(client, func() {
+		s.RemoveClient(client)
+		if err := client.Close(); err != nil {
+			log.Printf("Error closing client after send failure: %v", err)
+		}
+		log.Printf("Session %s: Removed slow/unresponsive client", s.id)
+	})
+	s.outputMu.Unlock()
+
 	s.orderedClients = append(s.orderedClients, client)
+	s.addPresenceLocked(client)
 
 	// Update metadata
 	s.metadataMu.Lock()
@@ -379,13 +606,8 @@ func (s *TerminalSession) AddClient(client WebSocketClient) error {
 	s.metadata.LastActivityAt = time.Now()
 	s.metadataMu.Unlock()
 
-	// Send history to new client
-	hist := s.history.GetHistory()
-	if len(hist) > 0 {
-		if err := client.Send(hist); err != nil {
-			log.Printf("Error sending history to client: %v", err)
-		}
-	}
+	s.broadcastPresenceLocked()
+	s.attachControlLocked(client)
 
 	// Send SIGWINCH to trigger redraw for applications like htop
 	// Platform-specific: Unix systems send SIGWINCH, Windows is a no-op
@@ -394,20 +616,38 @@ func (s *TerminalSession) AddClient(client WebSocketClient) error {
 	return nil
 }
 
+// CanAddClient reports whether another client may attach, returning a
+// LimitError when the session is full
+func (s *TerminalSession) CanAddClient() error {
+	s.clientsMu.Lock()
+	defer s.clientsMu.Unlock()
+	return s.checkClientLimitLocked()
+}
+
+// checkClientLimitLocked must be called with s.clientsMu held
+func (s *TerminalSession) checkClientLimitLocked() error {
+	if s.maxClients > 0 && len(s.clients) >= s.maxClients {
+		return &LimitError{Resource: LimitResourceClients, Limit: s.maxClients}
+	}
+	return nil
+}
+
 // RemoveClient removes a WebSocket client from the session
 func (s *TerminalSession) RemoveClient(client WebSocketClient) {
 	s.clientsMu.Lock()
 	defer s.clientsMu.Unlock()
 
-	if _, ok := s.clients[client]; !ok {
+	stream, ok := s.clients[client]
+	if !ok {
 		return
 	}
 
+	stream.close()
 	delete(s.clients, client)
+	delete(s.clientSizes, client)
+	s.removePresenceLocked(client)
 
 	// Remove from ordered clients
-	isPrimary := len(s.orderedClients) > 0 && s.orderedClients[0] == client
-
 	for i, c := range s.orderedClients {
 		if c == client {
 			s.orderedClients = append(s.orderedClients[:i], s.orderedClients[i+1:]...)
@@ -421,13 +661,10 @@ func (s *TerminalSession) RemoveClient(client WebSocketClient) {
 	s.metadata.LastActivityAt = time.Now()
 	s.metadataMu.Unlock()
 
-	// If the primary client changed, resize the PTY to the current dimensions
-	if isPrimary && len(s.orderedClients) > 0 {
-		s.termSizeMu.RLock()
-		cols, rows := s.termCols, s.termRows
-		s.termSizeMu.RUnlock()
-		if err := s.ptySvc.SetSize(s.ptyFile, cols, rows); err != nil {
-			log.Printf("Error resizing PTY after primary client change: %v", err)
+	// The primary client or the smallest client may have left
+	if size, ok := s.policySizeLocked(nil); ok {
+		if err := s.applySizeLocked(size, false); err != nil {
+			log.Printf("Error resizing PTY after client left: %v", err)
 		}
 	}
 }
@@ -449,7 +686,8 @@ func (s *TerminalSession) Write(data []byte) (int, error) {
 	return s.ptyFile.Write(data)
 }
 
-// Resize resizes the terminal PTY
+// Resize records the size a client reports and resizes the PTY as the
+// session's resize policy decides
 func (s *TerminalSession) Resize(client WebSocketClient, cols, rows int) error {
 	s.closeMu.RLock()
 	defer s.closeMu.RUnlock()
@@ -461,24 +699,19 @@ func (s *TerminalSession) Resize(client WebSocketClient, cols, rows int) error {
 	s.clientsMu.Lock()
 	defer s.clientsMu.Unlock()
 
-	// Store the terminal dimensions
-	s.termSizeMu.Lock()
-	changed := s.termCols != cols || s.termRows != rows
-	s.termCols = cols
-	s.termRows = rows
-	s.termSizeMu.Unlock()
-
-	if !changed {
-		// Force redraw by toggling size slightly if it's the same
-		// This ensures SIGWINCH is sent even if the terminal size hasn't changed
-		// which often happens on page refresh.
-		if err := s.ptySvc.SetSize(s.ptyFile, cols, rows+1); err != nil {
-			log.Printf("Error forcing PTY resize: %v", err)
-		}
-		return s.ptySvc.SetSize(s.ptyFile, cols, rows)
+	// Remember the client's size; the resize policy decides the PTY size
+	reported := termSize{cols, rows}
+	if _, ok := s.clients[client]; ok {
+		s.clientSizes[client] = reported
 	}
-
-	return s.ptySvc.SetSize(s.ptyFile, cols, rows)
+	if s.resizePolicy.Mode == ResizePolicyLatest {
+		return s.applySizeLocked(reported, true)
+	}
+	size, ok := s.policySizeLocked(client)
+	if !ok {
+		return nil
+	}
+	return s.applySizeLocked(size, true)
 }
 
 // Close closes the terminal session and cleanup resources
@@ -494,7 +727,8 @@ func (s *TerminalSession) Close() error {
 
 	// Close all clients
 	s.clientsMu.Lock()
-	for client := range s.clients {
+	for client, stream := range s.clients {
+		stream.close()
 		if err := client.Close(); err != nil {
 			log.Printf("Error closing client: %v", err)
 		}
@@ -514,16 +748,33 @@ func (s *TerminalSession) Close() error {
 		if err := s.cmd.Process.Kill(); err != nil {
 			log.Printf("Error killing shell process: %v", err)
 		}
+		if s.cgroupCleanup != nil {
+			// The cgroup can only be removed once the shell has been reaped
+			process, cleanup := s.cmd.Process, s.cgroupCleanup
+			go func() {
+				_, _ = process.Wait()
+				cleanup()
+			}()
+		}
 	}
 
-	if s.backend == SessionBackendTmux && s.tmuxSessionName != "" {
-		killCmd := exec.Command("tmux", "kill-session", "-t", s.tmuxSessionName)
+	s.watcher.close()
+
+	// Adopted tmux sessions were started outside terminal-hub and keep running,
+	// as do those handed off to another hub process
+	if s.backend == SessionBackendTmux && s.tmuxSessionName != "" && !s.tmuxAdopted && !s.tmuxHandedOff {
+		killCmd := newTmuxCommand(s.runAs, "kill-session", "-t", s.tmuxSessionName)
 		if err := killCmd.Run(); err != nil {
 			log.Printf("Error killing tmux session %q: %v", s.tmuxSessionName, err)
 		}
 	}
+	if s.backend == SessionBackendScreen && s.screenSessionName != "" {
+		if err := killScreenSession(s.runAs, s.screenSessionName); err != nil {
+			log.Printf("Error killing screen session %q: %v", s.screenSessionName, err)
+		}
+	}
 
-	close(s.broadcast)
+	s.output.close()
 
 	return nil
 }
@@ -549,9 +800,24 @@ func (s *TerminalSession) updateName(name string) {
 	s.metadata.Name = name
 }
 
+// updatePrefs replaces the session's pinned state and sort order
+func (s *TerminalSession) updatePrefs(prefs SessionPrefs) {
+	s.metadataMu.Lock()
+	defer s.metadataMu.Unlock()
+	s.metadata.Pinned = prefs.Pinned
+	s.metadata.SortOrder = prefs.SortOrder
+}
+
+// updateTags replaces the session's tags
+func (s *TerminalSession) updateTags(tags []string) {
+	s.metadataMu.Lock()
+	defer s.metadataMu.Unlock()
+	s.metadata.Tags = tags
+}
+
 // readPTY continuously reads from PTY and broadcasts to clients
 func (s *TerminalSession) readPTY() {
-	buf := make([]byte, 1024)
+	buf := make([]byte, ptyReadBufferSize)
 	for {
 		s.closeMu.RLock()
 		closed := s.closed
@@ -573,99 +839,56 @@ func (s *TerminalSession) readPTY() {
 				log.Printf("Session %s: PTY read error: %v", s.id, err)
 			}
 
+			s.endExec()
 			if !alreadyClosed && s.onExit != nil {
 				go s.onExit()
 			}
 			return
 		}
 
-		data := make([]byte, n)
-		copy(data, buf[:n])
-
-		// Rate limiting: only allow up to 500 messages per second
-		select {
-		case s.outputRateLimit <- struct{}{}:
-		default:
-			// Rate limit exceeded, log warning periodically
-			s.rateLimitMu.Lock()
-			if time.Since(s.lastRateLimitWarn) > 5*time.Second {
-				log.Printf("Session %s: Output rate limit exceeded, dropping messages", s.id)
-				s.lastRateLimitWarn = time.Now()
-			}
-			s.rateLimitMu.Unlock()
-			continue
+		// The filter and the writes below copy what they keep, so the read
+		// buffer is reused without a per-read allocation
+		s.clipboardMu.Lock()
+		data, messages := s.clipboard.Filter(buf[:n])
+		s.clipboardMu.Unlock()
+		for _, msg := range messages {
+			s.sendMessage(msg)
 		}
-
-		// Save to history
-		if _, err := s.history.Write(data); err != nil {
-			log.Printf("Error writing to history: %v", err)
-		}
-
-		// Broadcast to all clients - hold lock to prevent race with Close()
-		s.closeMu.Lock()
-		closed = s.closed
-		if !closed {
-			// BLOCKING send to broadcast channel
-			// This will block reading from PTY if the channel is full (backpressure)
-			// The broadcast channel is consumed by broadcastLoop, which handles
-			// sending to clients with timeouts.
-			s.broadcast <- data
+		if len(data) == 0 {
+			continue
 		}
-		s.closeMu.Unlock()
+		s.watcher.observe(data)
+		s.observeExec(data)
+		s.recordOutput(data)
 	}
 }
 
-// broadcastLoop broadcasts PTY output to all connected clients
-func (s *TerminalSession) broadcastLoop() {
-	// Use a ticker to periodically release the rate limiter
-	ticker := time.NewTicker(10 * time.Millisecond)
-	defer ticker.Stop()
-
-	for {
-		select {
-		case data, ok := <-s.broadcast:
-			if !ok {
-				// Channel closed, exit loop
-				return
-			}
-
-			s.clientsMu.Lock()
-			for client := range s.clients {
-				if err := client.Send(data); err != nil {
-					// If send fails, close and remove the client
-					if closeErr := client.Close(); closeErr != nil {
-						log.Printf("Error closing client after send failure: %v", closeErr)
-					}
-					delete(s.clients, client)
-					log.Printf("Session %s: Removed slow/unresponsive client", s.id)
-				}
-			}
-			s.clientsMu.Unlock()
-
-			// Release one token from rate limit (acts as refill)
-			select {
-			case <-s.outputRateLimit:
-			default:
-			}
+// recordOutput appends output to the history, the screen and the ring, waking
+// the client streams. Writes after Close are dropped by the ring.
+func (s *TerminalSession) recordOutput(data []byte) {
+	s.outputMu.Lock()
+	defer s.outputMu.Unlock()
 
-		case <-ticker.C:
-			// Continuously release rate limit tokens to allow normal throughput
-			for i := 0; i < 5; i++ { // Release 5 tokens every 10ms = 500/sec
-				select {
-				case <-s.outputRateLimit:
-				default:
-				}
-			}
-		}
+	if _, err := s.history.Write(data); err != nil {
+		log.Printf("Error writing to history: %v", err)
+	}
+	_, _ = s.screen.Write(data)
+	s.output.write(data)
+	if dir := s.screen.directory; dir != s.reportedDirectory {
+		s.reportedDirectory = dir
+		s.setCurrentDirectory(dir)
 	}
 }
 
 // DefaultPTYService implements PTYService using creack/pty
-type DefaultPTYService struct{}
+type DefaultPTYService struct {
+	RunAs *RunAs // account shells are started as, nil = the daemon's own
+}
 
 // Start starts a new shell with PTY
 func (d *DefaultPTYService) Start(shell string) (*os.File, error) {
 	cmd := exec.Command(shell)
+	d.RunAs.Apply(cmd)
 	return pty.Start(cmd)
 }
 
@@ -679,6 +902,7 @@ func (d *DefaultPTYService) StartWithConfig(shell string, workingDir string, env
 	}
 
 	cmd.Env = buildCommandEnv(envVars)
+	d.RunAs.Apply(cmd)
 
 	// Start with PTY
 	ptmx, err := pty.Start(cmd)
@@ -689,6 +913,26 @@ func (d *DefaultPTYService) StartWithConfig(shell string, workingDir string, env
 	return ptmx, cmd, nil
 }
 
+// StartWithLimits starts a new shell with PTY under the given resource limits
+func (d *DefaultPTYService) StartWithLimits(shell string, workingDir string, envVars map[string]string, limits ResourceLimits) (*os.File, *exec.Cmd, error) {
+	name, args := limits.WrapCommand(shell)
+	cmd := exec.Command(name, args...)
+
+	if workingDir != "" {
+		cmd.Dir = workingDir
+	}
+
+	cmd.Env = buildCommandEnv(envVars)
+	d.RunAs.Apply(cmd)
+
+	ptmx, err := pty.Start(cmd)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return ptmx, cmd, nil
+}
+
 // SetSize sets the PTY window size
 func (d *DefaultPTYService) SetSize(file *os.File, cols, rows int) error {
 	return pty.Setsize(file, &pty.Winsize{
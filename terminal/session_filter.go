@@ -0,0 +1,220 @@
+package terminal
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Tag limits
+const (
+	MaxSessionTags = 16
+)
+
+// Page sizes of session listings
+const (
+	DefaultSessionPageLimit = 100
+	MaxSessionPageLimit     = 1000
+)
+
+var validTag = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.:/-]{0,31}$`)
+
+// Session states accepted by SessionFilter
+const (
+	SessionStateAttached = "attached" // at least one client is connected
+	SessionStateDetached = "detached" // no client is connected
+)
+
+// Sort keys accepted by SessionFilter
+const (
+	SessionSortCustom       = "custom" // pinned first, then sort_order, then oldest first
+	SessionSortCreatedAt    = "created_at"
+	SessionSortLastActivity = "last_activity_at"
+	SessionSortName         = "name"
+)
+
+// NormalizeTags validates tags and returns them sorted without duplicates
+func NormalizeTags(tags []string) ([]string, error) {
+	seen := make(map[string]bool, len(tags))
+	normalized := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		tag = strings.TrimSpace(tag)
+		if !validTag.MatchString(tag) {
+			return nil, fmt.Errorf("invalid tag %q: tags are 1-32 letters, digits, '_', '.', ':', '/' or '-', starting with a letter or digit", tag)
+		}
+		if !seen[tag] {
+			seen[tag] = true
+			normalized = append(normalized, tag)
+		}
+	}
+	if len(normalized) > MaxSessionTags {
+		return nil, fmt.Errorf("a session can have at most %d tags", MaxSessionTags)
+	}
+	sort.Strings(normalized)
+	return normalized, nil
+}
+
+// SessionFilter selects and orders sessions in listings
+type SessionFilter struct {
+	Tags       []string // sessions must have every tag
+	Name       string   // case-insensitive substring of the session name
+	State      string   // "attached", "detached" or empty for both
+	Sort       string   // "custom" (default), "created_at", "last_activity_at" or "name"
+	Descending bool
+}
+
+// Validate checks the filter's state and sort key
+func (f SessionFilter) Validate() error {
+	switch f.State {
+	case "", SessionStateAttached, SessionStateDetached:
+	default:
+		return fmt.Errorf("state must be %q or %q", SessionStateAttached, SessionStateDetached)
+	}
+	switch f.Sort {
+	case "", SessionSortCustom, SessionSortCreatedAt, SessionSortLastActivity, SessionSortName:
+	default:
+		return fmt.Errorf("sort must be %q, %q, %q or %q", SessionSortCustom, SessionSortCreatedAt, SessionSortLastActivity, SessionSortName)
+	}
+	return nil
+}
+
+// Apply returns the sessions matching the filter in the requested order
+func (f SessionFilter) Apply(infos []SessionInfo) []SessionInfo {
+	name := strings.ToLower(f.Name)
+	matched := make([]SessionInfo, 0, len(infos))
+	for _, info := range infos {
+		if name != "" && !strings.Contains(strings.ToLower(info.Metadata.Name), name) {
+			continue
+		}
+		if f.State == SessionStateAttached && info.Metadata.ClientCount == 0 ||
+			f.State == SessionStateDetached && info.Metadata.ClientCount > 0 {
+			continue
+		}
+		if !hasAllTags(info.Metadata.Tags, f.Tags) {
+			continue
+		}
+		matched = append(matched, info)
+	}
+
+	less := func(a, b SessionMetadata) bool {
+		switch f.Sort {
+		case SessionSortLastActivity:
+			return a.LastActivityAt.Before(b.LastActivityAt)
+		case SessionSortName:
+			return strings.ToLower(a.Name) < strings.ToLower(b.Name)
+		case SessionSortCreatedAt:
+			return a.CreatedAt.Before(b.CreatedAt)
+		default:
+			if a.Pinned != b.Pinned {
+				return a.Pinned
+			}
+			if a.SortOrder != b.SortOrder {
+				return a.SortOrder < b.SortOrder
+			}
+			return a.CreatedAt.Before(b.CreatedAt)
+		}
+	}
+	sort.SliceStable(matched, func(i, j int) bool {
+		if f.Descending {
+			return less(matched[j].Metadata, matched[i].Metadata)
+		}
+		return less(matched[i].Metadata, matched[j].Metadata)
+	})
+	return matched
+}
+
+// hasAllTags reports whether tags contains every wanted tag
+func hasAllTags(tags, wanted []string) bool {
+	for _, want := range wanted {
+		found := false
+		for _, tag := range tags {
+			if tag == want {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// SessionPage is one page of a session listing
+type SessionPage struct {
+	Sessions []SessionInfo `json:"sessions"`
+	Total    int           `json:"total"` // sessions matching the filters
+	Limit    int           `json:"limit"`
+	Offset   int           `json:"offset"`
+}
+
+// PageSessions returns limit sessions of infos starting at offset. A zero
+// limit means DefaultSessionPageLimit.
+func PageSessions(infos []SessionInfo, limit, offset int) (SessionPage, error) {
+	if limit == 0 {
+		limit = DefaultSessionPageLimit
+	}
+	if limit < 0 || limit > MaxSessionPageLimit {
+		return SessionPage{}, fmt.Errorf("limit must be between 1 and %d", MaxSessionPageLimit)
+	}
+	if offset < 0 {
+		return SessionPage{}, fmt.Errorf("offset must not be negative")
+	}
+	page := SessionPage{Sessions: []SessionInfo{}, Total: len(infos), Limit: limit, Offset: offset}
+	if offset < len(infos) {
+		page.Sessions = infos[offset:min(offset+limit, len(infos))]
+	}
+	return page, nil
+}
+
+// sessionMetadataFields are the JSON names of SessionMetadata's fields
+var sessionMetadataFields = func() map[string]bool {
+	fields := make(map[string]bool)
+	t := reflect.TypeOf(SessionMetadata{})
+	for i := 0; i < t.NumField(); i++ {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		fields[name] = true
+	}
+	return fields
+}()
+
+// ValidateSessionFields checks that fields name SessionMetadata fields by
+// their JSON names
+func ValidateSessionFields(fields []string) error {
+	for _, field := range fields {
+		if !sessionMetadataFields[field] {
+			return fmt.Errorf("unknown session field %q", field)
+		}
+	}
+	return nil
+}
+
+// SelectSessionFields returns infos with only the given metadata fields, as
+// JSON objects of the same shape. The id is always kept.
+func SelectSessionFields(infos []SessionInfo, fields []string) ([]map[string]any, error) {
+	if err := ValidateSessionFields(fields); err != nil {
+		return nil, err
+	}
+	selected := make([]map[string]any, 0, len(infos))
+	for _, info := range infos {
+		data, err := json.Marshal(info.Metadata)
+		if err != nil {
+			return nil, err
+		}
+		var all map[string]json.RawMessage
+		if err := json.Unmarshal(data, &all); err != nil {
+			return nil, err
+		}
+		metadata := make(map[string]json.RawMessage, len(fields))
+		for _, field := range fields {
+			if value, ok := all[field]; ok {
+				metadata[field] = value
+			}
+		}
+		selected = append(selected, map[string]any{"id": info.ID, "metadata": metadata})
+	}
+	return selected, nil
+}
@@ -0,0 +1,145 @@
+package terminal
+
+import (
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Session tags and filters", func() {
+	It("should normalize tags", func() {
+		tags, err := NormalizeTags([]string{" web ", "prod", "web", "team:infra"})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(tags).To(Equal([]string{"prod", "team:infra", "web"}))
+
+		_, err = NormalizeTags([]string{"has space"})
+		Expect(err).To(HaveOccurred())
+		_, err = NormalizeTags([]string{""})
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should page sessions", func() {
+		infos := []SessionInfo{{ID: "1"}, {ID: "2"}, {ID: "3"}}
+
+		page, err := PageSessions(infos, 2, 1)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(page.Total).To(Equal(3))
+		Expect(page.Sessions).To(HaveLen(2))
+		Expect(page.Sessions[0].ID).To(Equal("2"))
+
+		page, err = PageSessions(infos, 0, 5)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(page.Limit).To(Equal(DefaultSessionPageLimit))
+		Expect(page.Sessions).To(BeEmpty())
+
+		_, err = PageSessions(infos, MaxSessionPageLimit+1, 0)
+		Expect(err).To(HaveOccurred())
+		_, err = PageSessions(infos, 1, -1)
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should select metadata fields", func() {
+		selected, err := SelectSessionFields([]SessionInfo{{ID: "1", Metadata: SessionMetadata{Name: "dev", ClientCount: 2}}}, []string{"name", "tags"})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(selected).To(HaveLen(1))
+		Expect(selected[0]["id"]).To(Equal("1"))
+		Expect(selected[0]["metadata"]).To(HaveLen(1)) // tags is omitted when empty
+
+		_, err = SelectSessionFields(nil, []string{"secret"})
+		Expect(err).To(MatchError(ContainSubstring(`"secret"`)))
+	})
+
+	It("should filter by tags, name and state and sort", func() {
+		now := time.Now()
+		infos := []SessionInfo{
+			{ID: "1", Metadata: SessionMetadata{Name: "API server", CreatedAt: now.Add(-3 * time.Hour), LastActivityAt: now, Tags: []string{"prod", "web"}, ClientCount: 1}},
+			{ID: "2", Metadata: SessionMetadata{Name: "web logs", CreatedAt: now.Add(-2 * time.Hour), LastActivityAt: now.Add(-time.Hour), Tags: []string{"web"}}},
+			{ID: "3", Metadata: SessionMetadata{Name: "scratch", CreatedAt: now.Add(-time.Hour), LastActivityAt: now.Add(-2 * time.Hour)}},
+		}
+		ids := func(infos []SessionInfo) []string {
+			var result []string
+			for _, info := range infos {
+				result = append(result, info.ID)
+			}
+			return result
+		}
+
+		Expect(ids(SessionFilter{Tags: []string{"web"}}.Apply(infos))).To(Equal([]string{"1", "2"}))
+		Expect(ids(SessionFilter{Tags: []string{"web", "prod"}}.Apply(infos))).To(Equal([]string{"1"}))
+		Expect(ids(SessionFilter{Name: "WEB"}.Apply(infos))).To(Equal([]string{"2"}))
+		Expect(ids(SessionFilter{State: SessionStateDetached}.Apply(infos))).To(Equal([]string{"2", "3"}))
+		Expect(ids(SessionFilter{Descending: true}.Apply(infos))).To(Equal([]string{"3", "2", "1"}))
+		Expect(ids(SessionFilter{Sort: SessionSortLastActivity}.Apply(infos))).To(Equal([]string{"3", "2", "1"}))
+		Expect(ids(SessionFilter{Sort: SessionSortName}.Apply(infos))).To(Equal([]string{"1", "3", "2"}))
+
+		infos[1].Metadata.Pinned = true
+		infos[0].Metadata.SortOrder = 2
+		infos[2].Metadata.SortOrder = 1
+		Expect(ids(SessionFilter{}.Apply(infos))).To(Equal([]string{"2", "3", "1"}))
+		Expect(ids(SessionFilter{Sort: SessionSortCreatedAt}.Apply(infos))).To(Equal([]string{"1", "2", "3"}))
+
+		Expect(SessionFilter{State: "sleeping"}.Validate()).To(HaveOccurred())
+		Expect(SessionFilter{Sort: "size"}.Validate()).To(HaveOccurred())
+	})
+
+	It("should update tags through the manager", func() {
+		manager := NewSessionManager()
+		DeferCleanup(manager.CloseAll)
+		_, err := manager.CreateSession(SessionConfig{ID: "tagged", PTYService: &MockPTYService{}, Tags: []string{"a"}})
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(manager.UpdateSessionTags("tagged", []string{"b", "a", "b"})).To(Succeed())
+		sess, _ := manager.Get("tagged")
+		Expect(sess.GetMetadata().Tags).To(Equal([]string{"a", "b"}))
+		Expect(manager.UpdateSessionTags("tagged", []string{"bad tag"})).ToNot(Succeed())
+		Expect(manager.UpdateSessionTags("missing", nil)).To(MatchError("session not found"))
+	})
+
+	It("should pin and reorder sessions and persist the order", func() {
+		path := filepath.Join(GinkgoT().TempDir(), "prefs.json")
+		store, err := OpenSessionPrefsStore(path)
+		Expect(err).ToNot(HaveOccurred())
+
+		manager := NewSessionManager()
+		DeferCleanup(manager.CloseAll)
+		manager.SetPrefsStore(store)
+		for _, id := range []string{"a", "b", "c"} {
+			_, err := manager.CreateSession(SessionConfig{ID: id, PTYService: &MockPTYService{}})
+			Expect(err).ToNot(HaveOccurred())
+		}
+		order := func() []string {
+			var result []string
+			for _, info := range (SessionFilter{}).Apply(manager.ListSessionsInfo()) {
+				result = append(result, info.ID)
+			}
+			return result
+		}
+		Expect(order()).To(Equal([]string{"a", "b", "c"}))
+
+		Expect(manager.ReorderSessions([]string{"c", "a"})).To(Succeed())
+		Expect(order()).To(Equal([]string{"c", "a", "b"}))
+		Expect(manager.SetSessionPinned("b", true)).To(Succeed())
+		Expect(order()).To(Equal([]string{"b", "c", "a"}))
+
+		Expect(manager.ReorderSessions([]string{"a", "a"})).ToNot(Succeed())
+		Expect(manager.ReorderSessions([]string{"missing"})).To(MatchError(ErrSessionNotFound))
+		Expect(manager.SetSessionPinned("missing", true)).To(MatchError(ErrSessionNotFound))
+
+		reopened, err := OpenSessionPrefsStore(path)
+		Expect(err).ToNot(HaveOccurred())
+		prefs, ok := reopened.Get("b")
+		Expect(ok).To(BeTrue())
+		Expect(prefs).To(Equal(SessionPrefs{Pinned: true, SortOrder: 2}))
+		prefs, ok = reopened.Get("c")
+		Expect(ok).To(BeTrue())
+		Expect(prefs).To(Equal(SessionPrefs{SortOrder: 0}))
+
+		Expect(manager.Remove("b")).To(Succeed())
+		reopened, err = OpenSessionPrefsStore(path)
+		Expect(err).ToNot(HaveOccurred())
+		_, ok = reopened.Get("b")
+		Expect(ok).To(BeFalse())
+	})
+})
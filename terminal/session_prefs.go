@@ -0,0 +1,101 @@
+package terminal
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// SessionPrefs are user-chosen display preferences for a session
+type SessionPrefs struct {
+	Pinned    bool `json:"pinned"`
+	SortOrder int  `json:"sort_order"`
+}
+
+// SessionPrefsStore persists session preferences in a JSON file so every
+// device sees the same order
+type SessionPrefsStore struct {
+	path  string
+	mu    sync.Mutex
+	prefs map[string]SessionPrefs // by session ID
+}
+
+// OpenSessionPrefsStore loads the store at path, creating it on first write
+func OpenSessionPrefsStore(path string) (*SessionPrefsStore, error) {
+	store := &SessionPrefsStore{path: path, prefs: make(map[string]SessionPrefs)}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return store, nil
+		}
+		return nil, fmt.Errorf("failed to read session preferences: %w", err)
+	}
+	if err := json.Unmarshal(data, &store.prefs); err != nil {
+		return nil, fmt.Errorf("failed to parse session preferences: %w", err)
+	}
+	return store, nil
+}
+
+// Get returns the preferences stored for a session. A nil store has none.
+func (s *SessionPrefsStore) Get(sessionID string) (SessionPrefs, bool) {
+	if s == nil {
+		return SessionPrefs{}, false
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	prefs, ok := s.prefs[sessionID]
+	return prefs, ok
+}
+
+// Set stores the preferences of several sessions at once
+func (s *SessionPrefsStore) Set(prefs map[string]SessionPrefs) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for id, p := range prefs {
+		s.prefs[id] = p
+	}
+	return s.saveLocked()
+}
+
+// Delete forgets a session's preferences
+func (s *SessionPrefsStore) Delete(sessionID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.prefs[sessionID]; !ok {
+		return nil
+	}
+	delete(s.prefs, sessionID)
+	return s.saveLocked()
+}
+
+// saveLocked writes the store atomically. Must be called with s.mu held.
+func (s *SessionPrefsStore) saveLocked() error {
+	data, err := json.MarshalIndent(s.prefs, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0700); err != nil {
+		return fmt.Errorf("failed to create session preferences directory: %w", err)
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("failed to write session preferences: %w", err)
+	}
+	return os.Rename(tmp, s.path)
+}
+
+// GetSessionPrefsPathFromEnv returns TERMINAL_HUB_SESSION_PREFS, defaulting
+// to ~/.terminal-hub/session_prefs.json
+func GetSessionPrefsPathFromEnv() string {
+	if path := os.Getenv("TERMINAL_HUB_SESSION_PREFS"); path != "" {
+		return path
+	}
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "session_prefs.json"
+	}
+	return filepath.Join(homeDir, ".terminal-hub", "session_prefs.json")
+}
@@ -0,0 +1,188 @@
+package terminal
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// ErrTemplateNotFound is returned for unknown template IDs
+var ErrTemplateNotFound = errors.New("template not found")
+
+// SessionTemplate is a saved session configuration. Templates marked
+// autostart are created as sessions when the server starts.
+type SessionTemplate struct {
+	ID               string            `json:"id"`
+	Name             string            `json:"name"`                        // Required: name of sessions created from it
+	WorkingDirectory string            `json:"working_directory,omitempty"` // Optional: Initial working directory
+	Command          string            `json:"command,omitempty"`           // Optional: Initial command to run
+	EnvVars          map[string]string `json:"env_vars,omitempty"`          // Optional: Environment variables
+	ShellPath        string            `json:"shell_path,omitempty"`        // Optional: Custom shell path
+	Backend          SessionBackend    `json:"backend,omitempty"`           // Optional: "tmux" (default), "screen" or "pty"
+	Tags             []string          `json:"tags,omitempty"`              // Optional: Tags for grouping and filtering
+	InputMode        string            `json:"input_mode,omitempty"`        // Optional: "shared" (default) or "single_writer"
+	Autostart        bool              `json:"autostart"`                   // Create a session from the template at startup
+}
+
+// Normalize validates the template and normalizes its backend and tags
+func (t *SessionTemplate) Normalize() error {
+	if err := ValidateSessionName(t.Name); err != nil {
+		return err
+	}
+	if err := ValidateEnvVars(t.EnvVars); err != nil {
+		return err
+	}
+	t.Backend = SessionBackend(strings.ToLower(strings.TrimSpace(string(t.Backend))))
+	switch t.Backend {
+	case "":
+		t.Backend = SessionBackendTmux
+	case SessionBackendTmux, SessionBackendScreen, SessionBackendPTY:
+	default:
+		return errors.New(`backend must be "tmux", "screen" or "pty"`)
+	}
+	tags, err := NormalizeTags(t.Tags)
+	if err != nil {
+		return err
+	}
+	t.Tags = tags
+	return ValidateInputMode(t.InputMode)
+}
+
+// SessionConfig returns the configuration of a session created from the
+// template
+func (t SessionTemplate) SessionConfig(sessionID string) SessionConfig {
+	return SessionConfig{
+		ID:               sessionID,
+		Name:             t.Name,
+		WorkingDirectory: t.WorkingDirectory,
+		Command:          t.Command,
+		EnvVars:          t.EnvVars,
+		Shell:            t.ShellPath,
+		Backend:          t.Backend,
+		HistorySize:      4096,
+		Tags:             t.Tags,
+		InputMode:        t.InputMode,
+	}
+}
+
+// SessionTemplateStore persists session templates in a JSON file
+type SessionTemplateStore struct {
+	path      string
+	mu        sync.Mutex
+	templates []SessionTemplate // in creation order
+}
+
+// OpenSessionTemplateStore loads the store at path, creating it on first
+// write
+func OpenSessionTemplateStore(path string) (*SessionTemplateStore, error) {
+	store := &SessionTemplateStore{path: path}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return store, nil
+		}
+		return nil, fmt.Errorf("failed to read session templates: %w", err)
+	}
+	if err := json.Unmarshal(data, &store.templates); err != nil {
+		return nil, fmt.Errorf("failed to parse session templates: %w", err)
+	}
+	return store, nil
+}
+
+// List returns all templates in creation order
+func (s *SessionTemplateStore) List() []SessionTemplate {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]SessionTemplate{}, s.templates...)
+}
+
+// Autostart returns the templates to create sessions from at startup
+func (s *SessionTemplateStore) Autostart() []SessionTemplate {
+	var autostart []SessionTemplate
+	for _, t := range s.List() {
+		if t.Autostart {
+			autostart = append(autostart, t)
+		}
+	}
+	return autostart
+}
+
+// Create adds a template, which must have a new ID
+func (s *SessionTemplateStore) Create(t SessionTemplate) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.indexLocked(t.ID) >= 0 {
+		return fmt.Errorf("template %q already exists", t.ID)
+	}
+	s.templates = append(s.templates, t)
+	return s.saveLocked()
+}
+
+// Update replaces the template with the same ID
+func (s *SessionTemplateStore) Update(t SessionTemplate) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	i := s.indexLocked(t.ID)
+	if i < 0 {
+		return ErrTemplateNotFound
+	}
+	s.templates[i] = t
+	return s.saveLocked()
+}
+
+// Delete removes a template
+func (s *SessionTemplateStore) Delete(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	i := s.indexLocked(id)
+	if i < 0 {
+		return ErrTemplateNotFound
+	}
+	s.templates = append(s.templates[:i], s.templates[i+1:]...)
+	return s.saveLocked()
+}
+
+// indexLocked returns the position of a template, or -1. Must be called
+// with s.mu held.
+func (s *SessionTemplateStore) indexLocked(id string) int {
+	for i, t := range s.templates {
+		if t.ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// saveLocked writes the store atomically. Must be called with s.mu held.
+func (s *SessionTemplateStore) saveLocked() error {
+	data, err := json.MarshalIndent(s.templates, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0700); err != nil {
+		return fmt.Errorf("failed to create session templates directory: %w", err)
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("failed to write session templates: %w", err)
+	}
+	return os.Rename(tmp, s.path)
+}
+
+// GetSessionTemplatesPathFromEnv returns TERMINAL_HUB_SESSION_TEMPLATES,
+// defaulting to ~/.terminal-hub/session_templates.json
+func GetSessionTemplatesPathFromEnv() string {
+	if path := os.Getenv("TERMINAL_HUB_SESSION_TEMPLATES"); path != "" {
+		return path
+	}
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "session_templates.json"
+	}
+	return filepath.Join(homeDir, ".terminal-hub", "session_templates.json")
+}
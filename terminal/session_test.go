@@ -1,9 +1,13 @@
 package terminal
 
 import (
+	"errors"
+	"fmt"
 	"io"
 	"os"
 	"os/exec"
+	"path/filepath"
+	"strings"
 	"sync"
 	"testing"
 	"time"
@@ -241,6 +245,46 @@ var _ = Describe("SessionManager", func() {
 	})
 })
 
+var _ = Describe("Session limits", func() {
+	It("should refuse sessions beyond the maximum", func() {
+		manager := NewSessionManagerWithLimits(1, 0)
+		defer manager.CloseAll()
+
+		_, err := manager.CreateSession(SessionConfig{ID: "limit-1", PTYService: &TrackingPTYService{}})
+		Expect(err).ToNot(HaveOccurred())
+
+		_, err = manager.CreateSession(SessionConfig{ID: "limit-2", PTYService: &TrackingPTYService{}})
+		var limitErr *LimitError
+		Expect(errors.As(err, &limitErr)).To(BeTrue())
+		Expect(limitErr.Resource).To(Equal(LimitResourceSessions))
+		Expect(limitErr.Limit).To(Equal(1))
+		Expect(manager.SessionCount()).To(Equal(1))
+	})
+
+	It("should refuse clients beyond the per-session maximum", func() {
+		manager := NewSessionManagerWithLimits(0, 2)
+		defer manager.CloseAll()
+
+		sess, err := manager.CreateSession(SessionConfig{ID: "client-limit", PTYService: &TrackingPTYService{}})
+		Expect(err).ToNot(HaveOccurred())
+
+		first, second := NewMockWebSocketClient(), NewMockWebSocketClient()
+		Expect(sess.AddClient(first)).To(Succeed())
+		Expect(sess.AddClient(second)).To(Succeed())
+
+		err = sess.AddClient(NewMockWebSocketClient())
+		var limitErr *LimitError
+		Expect(errors.As(err, &limitErr)).To(BeTrue())
+		Expect(limitErr.Resource).To(Equal(LimitResourceClients))
+		Expect(sess.(*TerminalSession).CanAddClient()).To(HaveOccurred())
+
+		// A slot frees up when a client leaves
+		sess.RemoveClient(first)
+		Expect(sess.(*TerminalSession).CanAddClient()).To(Succeed())
+		Expect(sess.AddClient(NewMockWebSocketClient())).To(Succeed())
+	})
+})
+
 var _ = Describe("Session backends", func() {
 	Context("When selecting a backend", func() {
 		It("should default to PTY when a custom PTY service is provided", func() {
@@ -284,6 +328,51 @@ var _ = Describe("Session backends", func() {
 			Expect(metadata.BackendFallback).To(Equal("tmux_not_found"))
 			Expect(ptySvc.startWithConfigCalled).To(BeTrue())
 		})
+
+		It("should fall back to screen when tmux is unavailable", func() {
+			// A stand-in screen that just runs a shell
+			binDir := GinkgoT().TempDir()
+			script := "#!/bin/sh\nexec /bin/sh\n"
+			Expect(os.WriteFile(filepath.Join(binDir, "screen"), []byte(script), 0755)).To(Succeed())
+			GinkgoT().Setenv("PATH", binDir)
+
+			ptySvc := &TrackingPTYService{}
+			session, err := NewTerminalSession(SessionConfig{
+				ID:          "backend-screen-fallback",
+				Name:        "backend-screen-fallback",
+				HistorySize: 64,
+				Backend:     SessionBackendTmux,
+				PTYService:  ptySvc,
+			})
+			Expect(err).ToNot(HaveOccurred())
+			defer session.Close()
+
+			metadata := session.GetMetadata()
+			Expect(metadata.Backend).To(Equal(SessionBackendScreen))
+			Expect(metadata.BackendFallback).To(Equal("tmux_not_found"))
+			Expect(ptySvc.startWithConfigCalled).To(BeFalse())
+			Expect(session.screenSessionName).To(Equal("backend-screen-fallback"))
+		})
+
+		It("should fall back to PTY when screen is requested but unavailable", func() {
+			GinkgoT().Setenv("PATH", "")
+
+			ptySvc := &TrackingPTYService{}
+			session, err := NewTerminalSession(SessionConfig{
+				ID:          "backend-screen-pty-fallback",
+				Name:        "backend-screen-pty-fallback",
+				HistorySize: 64,
+				Backend:     SessionBackendScreen,
+				PTYService:  ptySvc,
+			})
+			Expect(err).ToNot(HaveOccurred())
+			defer session.Close()
+
+			metadata := session.GetMetadata()
+			Expect(metadata.Backend).To(Equal(SessionBackendPTY))
+			Expect(metadata.BackendFallback).To(Equal("screen_not_found"))
+			Expect(ptySvc.startWithConfigCalled).To(BeTrue())
+		})
 	})
 })
 
@@ -394,8 +483,9 @@ var _ = Describe("TerminalSession Race Conditions", func() {
 				},
 				termCols:       80,
 				termRows:       24,
-				clients:        make(map[WebSocketClient]bool),
-				broadcast:      make(chan []byte, 256),
+				clients:        make(map[WebSocketClient]*clientStream),
+				output:         newOutputRing(outputRingSize),
+				screen:         newVTScreen(80, 24),
 				orderedClients: make([]WebSocketClient, 0),
 				closed:         false,
 			}
@@ -427,10 +517,7 @@ var _ = Describe("TerminalSession Race Conditions", func() {
 				// Try to trigger the race by checking and sending
 				session.closeMu.Lock()
 				if !session.closed {
-					select {
-					case session.broadcast <- []byte("test"):
-					default:
-					}
+					session.recordOutput([]byte("test"))
 				}
 				session.closeMu.Unlock()
 
@@ -465,8 +552,9 @@ var _ = Describe("TerminalSession Race Conditions", func() {
 				},
 				termCols:       80,
 				termRows:       24,
-				clients:        make(map[WebSocketClient]bool),
-				broadcast:      make(chan []byte, 256),
+				clients:        make(map[WebSocketClient]*clientStream),
+				output:         newOutputRing(outputRingSize),
+				screen:         newVTScreen(80, 24),
 				orderedClients: make([]WebSocketClient, 0),
 				closed:         false,
 			}
@@ -522,8 +610,9 @@ var _ = Describe("TerminalSession Race Conditions", func() {
 					},
 					termCols:       80,
 					termRows:       24,
-					clients:        make(map[WebSocketClient]bool),
-					broadcast:      make(chan []byte, 256),
+					clients:        make(map[WebSocketClient]*clientStream),
+					output:         newOutputRing(outputRingSize),
+					screen:         newVTScreen(80, 24),
 					orderedClients: make([]WebSocketClient, 0),
 					closed:         false,
 				}
@@ -550,7 +639,32 @@ var _ = Describe("TerminalSession Race Conditions", func() {
 	})
 })
 
-var _ = Describe("Rate Limiting DoS Protection", func() {
+// blockingClient holds every send until release is closed, like a client on
+// a slow link
+type blockingClient struct {
+	*MockWebSocketClient
+	sending chan struct{} // receives a value when a send starts
+	release chan struct{}
+	sent    chan []byte
+}
+
+func newBlockingClient() *blockingClient {
+	return &blockingClient{
+		MockWebSocketClient: NewMockWebSocketClient(),
+		sending:             make(chan struct{}, 10000),
+		release:             make(chan struct{}),
+		sent:                make(chan []byte, 10000),
+	}
+}
+
+func (c *blockingClient) Send(data []byte) error {
+	c.sending <- struct{}{}
+	<-c.release
+	c.sent <- data
+	return nil
+}
+
+var _ = Describe("Output Flow Control", func() {
 	Context("When session produces excessive output", func() {
 		It("should not hang server on continuous output like 'cat /dev/random'", func() {
 			// Create a simulated PTY service
@@ -568,17 +682,16 @@ var _ = Describe("Rate Limiting DoS Protection", func() {
 					LastActivityAt: time.Now(),
 					ClientCount:    0,
 				},
-				termCols:        80,
-				termRows:        24,
-				clients:         make(map[WebSocketClient]bool),
-				broadcast:       make(chan []byte, 256),
-				orderedClients:  make([]WebSocketClient, 0),
-				closed:          false,
-				outputRateLimit: make(chan struct{}, 500),
+				termCols:       80,
+				termRows:       24,
+				clients:        make(map[WebSocketClient]*clientStream),
+				output:         newOutputRing(outputRingSize),
+				screen:         newVTScreen(80, 24),
+				orderedClients: make([]WebSocketClient, 0),
+				closed:         false,
 			}
 
 			go session.readPTY()
-			go session.broadcastLoop()
 
 			// Create a slow client that doesn't read from the channel
 			slowClient := &MockWebSocketClient{
@@ -626,120 +739,123 @@ var _ = Describe("Rate Limiting DoS Protection", func() {
 			ptySvc.Close()
 		})
 
-		It("should drop messages when rate limit is exceeded", func() {
+		It("should deliver all output to fast clients and history while a slow client lags", func() {
 			ptySvc, err := NewSimulatedPTYService()
 			Expect(err).ToNot(HaveOccurred())
 
 			session := &TerminalSession{
-				id:      "test-rate-limit",
+				id:      "test-flow-control",
 				ptyFile: ptySvc.ptyReader,
-				history: NewInMemoryHistory(4096),
+				history: NewInMemoryHistory(64 * 1024),
 				ptySvc:  ptySvc,
-				metadata: SessionMetadata{
-					Name:           "rate-limit-test",
-					CreatedAt:      time.Now(),
-					LastActivityAt: time.Now(),
-					ClientCount:    0,
-				},
-				termCols:        80,
-				termRows:        24,
-				clients:         make(map[WebSocketClient]bool),
-				broadcast:       make(chan []byte, 256),
-				orderedClients:  make([]WebSocketClient, 0),
-				closed:          false,
-				outputRateLimit: make(chan struct{}, 500),
+				clients: make(map[WebSocketClient]*clientStream),
+				output:  newOutputRing(outputRingSize),
+				screen:  newVTScreen(80, 24),
 			}
+			DeferCleanup(ptySvc.Close)
+			DeferCleanup(session.Close)
 
 			go session.readPTY()
-			go session.broadcastLoop()
-
-			client := NewMockWebSocketClient()
-			session.AddClient(client)
 
-			// Fill the rate limit bucket
-			for i := 0; i < 500; i++ {
-				session.outputRateLimit <- struct{}{}
-			}
+			slowClient := newBlockingClient()
+			Expect(session.AddClient(slowClient)).To(Succeed())
+			fastClient := &MockWebSocketClient{sendChan: make(chan []byte, 10000)}
+			Expect(session.AddClient(fastClient)).To(Succeed())
 
-			// Simulate output faster than rate limit
-			// These should trigger the rate limit
-			for i := 0; i < 100; i++ {
-				ptySvc.SimulateOutput([]byte("test data "))
-				time.Sleep(100 * time.Microsecond)
+			var expected strings.Builder
+			for i := 0; i < 1000; i++ {
+				line := fmt.Sprintf("line %d\n", i)
+				expected.WriteString(line)
+				Expect(ptySvc.SimulateOutput([]byte(line))).To(Succeed())
 			}
 
-			// Wait a bit
-			time.Sleep(20 * time.Millisecond)
-
-			// The client should receive some messages but not all (rate limited)
-			// The exact number depends on timing, but should be reasonable
-			receivedCount := len(client.sendChan)
-			// With rate limit of 500/sec and 100 messages over ~10ms,
-			// we should receive less than all messages
-			Expect(receivedCount).To(BeNumerically("<", 100), "Rate limiting should drop some messages")
-
-			session.Close()
-			ptySvc.Close()
+			var received strings.Builder
+			Eventually(func() string {
+				for len(fastClient.sendChan) > 0 {
+					received.Write(<-fastClient.sendChan)
+				}
+				return received.String()
+			}, 2*time.Second).Should(Equal(expected.String()))
+			Expect(string(session.history.GetHistory())).To(Equal(expected.String()))
+
+			// The slow client catches up with fewer, larger frames
+			close(slowClient.release)
+			var slowReceived strings.Builder
+			frames := 0
+			Eventually(func() string {
+				for len(slowClient.sent) > 0 {
+					slowReceived.Write(<-slowClient.sent)
+					frames++
+				}
+				return slowReceived.String()
+			}, 2*time.Second).Should(Equal(expected.String()))
+			Expect(frames).To(BeNumerically("<", 1000))
+			Expect(slowClient.IsClosed()).To(BeFalse())
 		})
 
-		It("should recover and continue after rate limit subsides", func() {
-			ptySvc, err := NewSimulatedPTYService()
-			Expect(err).ToNot(HaveOccurred())
-
+		It("should redraw a client that falls too far behind from history", func() {
 			session := &TerminalSession{
-				id:      "test-rate-limit-recovery",
-				ptyFile: ptySvc.ptyReader,
-				history: NewInMemoryHistory(4096),
-				ptySvc:  ptySvc,
-				metadata: SessionMetadata{
-					Name:           "rate-limit-recovery-test",
-					CreatedAt:      time.Now(),
-					LastActivityAt: time.Now(),
-					ClientCount:    0,
-				},
-				termCols:        80,
-				termRows:        24,
-				clients:         make(map[WebSocketClient]bool),
-				broadcast:       make(chan []byte, 256),
-				orderedClients:  make([]WebSocketClient, 0),
-				closed:          false,
-				outputRateLimit: make(chan struct{}, 500),
+				id:      "test-redraw",
+				history: NewInMemoryHistory(16),
+				clients: make(map[WebSocketClient]*clientStream),
+				output:  newOutputRing(64),
+				screen:  newVTScreen(80, 24),
 			}
+			DeferCleanup(session.Close)
 
-			go session.readPTY()
-			go session.broadcastLoop()
+			client := newBlockingClient()
+			Expect(session.AddClient(client)).To(Succeed())
 
-			// Use a client with a large buffer to avoid being removed during burst
-			client := &MockWebSocketClient{
-				sendChan: make(chan []byte, 1000),
-				closed:   false,
-			}
-			session.AddClient(client)
+			session.recordOutput([]byte("first"))
+			Eventually(func() int { return len(client.sending) }).Should(Equal(1))
 
-			// Burst of output to trigger rate limiting
-			for i := 0; i < 600; i++ {
-				ptySvc.SimulateOutput([]byte("burst "))
-				time.Sleep(1 * time.Microsecond)
-			}
+			session.recordOutput(make([]byte, 64))
+			session.recordOutput([]byte("latest screen"))
 
-			// Wait for rate limit to recover
-			time.Sleep(50 * time.Millisecond)
+			close(client.release)
+			Eventually(client.sent).Should(Receive(Equal([]byte("first"))))
+			// The history is followed by a repaint of the screen
+			var redraw []byte
+			Eventually(client.sent).Should(Receive(&redraw))
+			Expect(string(redraw)).To(HavePrefix("\x1bc\x00\x00\x00latest screen\x1b[?1049l"))
+			Expect(string(redraw)).To(ContainSubstring("\x1b[1;1Hfirstlatest screen"))
+		})
+	})
+})
 
-			// Clear the channel
-			for len(client.sendChan) > 0 {
-				<-client.sendChan
-			}
+var _ = Describe("Output batching", func() {
+	var session *TerminalSession
 
-			// Send normal output
-			ptySvc.SimulateOutput([]byte("normal output "))
+	BeforeEach(func() {
+		session = &TerminalSession{
+			id:      "test-batching",
+			history: NewInMemoryHistory(4096),
+			clients: make(map[WebSocketClient]*clientStream),
+			output:  newOutputRing(outputRingSize),
+			screen:  newVTScreen(80, 24),
+		}
+		DeferCleanup(session.Close)
+	})
 
-			// Verify we can receive normal output after rate limiting subsides
-			received := client.Receive(1 * time.Second)
-			Expect(received).NotTo(BeNil(), "Should receive data after rate limit recovers")
-			Expect(string(received)).To(Equal("normal output "))
+	It("should coalesce reads that arrive close together into one frame", func() {
+		client := NewMockWebSocketClient()
+		Expect(session.AddClient(client)).To(Succeed())
 
-			session.Close()
-			ptySvc.Close()
-		})
+		for _, chunk := range []string{"a", "b", "c"} {
+			session.recordOutput([]byte(chunk))
+		}
+		Expect(string(client.Receive(time.Second))).To(Equal("abc"))
+
+		session.recordOutput([]byte("d"))
+		Expect(string(client.Receive(time.Second))).To(Equal("d"))
+	})
+
+	It("should send large output without waiting", func() {
+		client := NewMockWebSocketClient()
+		Expect(session.AddClient(client)).To(Succeed())
+
+		large := make([]byte, broadcastBatchMaxBytes)
+		session.recordOutput(large)
+		Expect(client.Receive(broadcastBatchDelay / 2)).To(HaveLen(broadcastBatchMaxBytes))
 	})
 })
@@ -0,0 +1,97 @@
+package terminal
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// Signals that may be sent to a session
+const (
+	SignalInterrupt = "SIGINT"
+	SignalTerminate = "SIGTERM"
+	SignalKill      = "SIGKILL"
+)
+
+// ErrSignalUnsupported is returned for sessions whose backend cannot be
+// signalled
+var ErrSignalUnsupported = errors.New("signals are not supported for this session")
+
+// Signaler is implemented by sessions that can signal the program running
+// in the terminal
+type Signaler interface {
+	Signal(name string) error
+}
+
+// ValidateSignal checks that name is a signal sessions accept
+func ValidateSignal(name string) error {
+	switch name {
+	case SignalInterrupt, SignalTerminate, SignalKill:
+		return nil
+	}
+	return fmt.Errorf("signal must be %q, %q or %q", SignalInterrupt, SignalTerminate, SignalKill)
+}
+
+// Signal sends a signal to the terminal's foreground process group, the
+// program a user would reach with Ctrl-C. Unlike typing Ctrl-C it works when
+// the program has turned off the terminal's signal keys or stopped reading.
+func (s *TerminalSession) Signal(name string) error {
+	if err := ValidateSignal(name); err != nil {
+		return err
+	}
+
+	if s.backend == SessionBackendSSH {
+		remote, ok := s.ptySvc.(*sshPTYService)
+		if !ok {
+			return ErrSignalUnsupported
+		}
+		return remote.session.Signal(ssh.Signal(strings.TrimPrefix(name, "SIG")))
+	}
+
+	pgrp, _, err := s.foregroundProcess()
+	if err != nil {
+		return err
+	}
+	return signalProcessGroup(pgrp, name)
+}
+
+// foregroundProcess returns the foreground process group of the session's
+// terminal and the PID of the shell it started with
+func (s *TerminalSession) foregroundProcess() (pgrp int, shellPID int, err error) {
+	s.closeMu.RLock()
+	defer s.closeMu.RUnlock()
+	if s.closed {
+		return 0, 0, io.ErrClosedPipe
+	}
+
+	switch s.backend {
+	case SessionBackendPTY:
+		if s.cmd == nil || s.cmd.Process == nil {
+			return 0, 0, ErrSignalUnsupported
+		}
+		pgrp, err := terminalForegroundGroup(s.ptyFile)
+		return pgrp, s.cmd.Process.Pid, err
+	case SessionBackendTmux:
+		// The PTY held here belongs to the tmux client, so look at the
+		// shell in the active pane instead
+		if s.tmuxSessionName == "" {
+			return 0, 0, ErrSignalUnsupported
+		}
+		output, err := s.runTmux("display-message", "-p", "-t", s.tmuxSessionName, "#{pane_pid}")
+		if err != nil {
+			return 0, 0, err
+		}
+		shellPID, err := strconv.Atoi(strings.TrimSpace(output))
+		if err != nil {
+			return 0, 0, fmt.Errorf("unexpected tmux pane PID %q", strings.TrimSpace(output))
+		}
+		pgrp, err := processForegroundGroup(shellPID)
+		return pgrp, shellPID, err
+	default:
+		return 0, 0, ErrSignalUnsupported
+	}
+}
@@ -0,0 +1,72 @@
+package terminal
+
+import (
+	"os/exec"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Session signals", func() {
+	// interruptSleep starts a long sleep in the session, interrupts it with
+	// SIGINT and checks the shell takes commands again
+	interruptSleep := func(session *TerminalSession) {
+		_, err := session.Write([]byte("sleep 30; echo slept\n"))
+		Expect(err).ToNot(HaveOccurred())
+		// Wait until sleep rather than the shell is in the foreground
+		Eventually(func() bool {
+			pgrp, shellPID, err := session.foregroundProcess()
+			return err == nil && pgrp != shellPID
+		}, "5s", "50ms").Should(BeTrue())
+		Expect(session.Signal(SignalInterrupt)).To(Succeed())
+
+		_, err = session.Write([]byte("echo done-$((40+2))\n"))
+		Expect(err).ToNot(HaveOccurred())
+		Eventually(func() string {
+			return string(session.history.GetHistory())
+		}, "5s", "50ms").Should(ContainSubstring("done-42"))
+		Expect(strings.Count(string(session.history.GetHistory()), "slept")).To(Equal(1)) // the echoed command only
+	}
+
+	It("should interrupt the foreground program of a PTY session", func() {
+		session, err := NewTerminalSession(SessionConfig{
+			ID:         "signal-pty",
+			Shell:      "/bin/sh",
+			Backend:    SessionBackendPTY,
+			PTYService: &DefaultPTYService{},
+		})
+		Expect(err).ToNot(HaveOccurred())
+		DeferCleanup(session.Close)
+
+		interruptSleep(session)
+	})
+
+	It("should interrupt the program in the active tmux pane", func() {
+		if _, err := exec.LookPath("tmux"); err != nil {
+			Skip("tmux is not installed")
+		}
+		GinkgoT().Setenv("TMUX_TMPDIR", GinkgoT().TempDir())
+		GinkgoT().Setenv("TMUX", "")
+
+		session, err := NewTerminalSession(SessionConfig{
+			ID:      "signal-tmux",
+			Shell:   "/bin/sh",
+			Backend: SessionBackendTmux,
+		})
+		Expect(err).ToNot(HaveOccurred())
+		DeferCleanup(session.Close)
+		Eventually(func() error {
+			_, err := session.ListWindows()
+			return err
+		}, "5s", "50ms").Should(Succeed())
+
+		interruptSleep(session)
+	})
+
+	It("should only accept known signals", func() {
+		Expect(ValidateSignal(SignalKill)).To(Succeed())
+		Expect(ValidateSignal("SIGHUP")).ToNot(Succeed())
+		Expect(ValidateSignal("int")).ToNot(Succeed())
+	})
+})
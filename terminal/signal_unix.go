@@ -0,0 +1,73 @@
+//go:build !windows
+
+package terminal
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+var signalsByName = map[string]syscall.Signal{
+	SignalInterrupt: syscall.SIGINT,
+	SignalTerminate: syscall.SIGTERM,
+	SignalKill:      syscall.SIGKILL,
+}
+
+// signalProcessGroup sends the named signal to every process in pgrp
+func signalProcessGroup(pgrp int, name string) error {
+	if pgrp <= 0 {
+		return fmt.Errorf("no foreground process")
+	}
+	return syscall.Kill(-pgrp, signalsByName[name])
+}
+
+// terminalForegroundGroup returns the foreground process group of the
+// terminal whose master side is file
+func terminalForegroundGroup(file *os.File) (int, error) {
+	if file == nil {
+		return 0, ErrSignalUnsupported
+	}
+	conn, err := file.SyscallConn()
+	if err != nil {
+		return 0, err
+	}
+
+	pgrp := 0
+	var ioctlErr error
+	if err := conn.Control(func(fd uintptr) {
+		pgrp, ioctlErr = unix.IoctlGetInt(int(fd), unix.TIOCGPGRP)
+	}); err != nil {
+		return 0, err
+	}
+	if ioctlErr != nil {
+		return 0, fmt.Errorf("failed to find foreground process: %w", ioctlErr)
+	}
+	return pgrp, nil
+}
+
+// processForegroundGroup returns the foreground process group of the
+// terminal pid runs in. A terminal can only be asked directly by processes it
+// controls, so this goes through /proc, or ps where there is no /proc.
+func processForegroundGroup(pid int) (int, error) {
+	if stat, err := os.ReadFile("/proc/" + strconv.Itoa(pid) + "/stat"); err == nil {
+		// The command name may contain spaces, so fields are counted from
+		// its closing parenthesis: state, ppid, pgrp, session, tty_nr, tpgid
+		fields := strings.Fields(string(stat[strings.LastIndexByte(string(stat), ')')+1:]))
+		if len(fields) < 6 {
+			return 0, fmt.Errorf("unexpected /proc/%d/stat format", pid)
+		}
+		return strconv.Atoi(fields[5])
+	}
+
+	output, err := exec.Command("ps", "-o", "tpgid=", "-p", strconv.Itoa(pid)).Output()
+	if err != nil {
+		return 0, fmt.Errorf("failed to find foreground process of %d: %w", pid, err)
+	}
+	return strconv.Atoi(strings.TrimSpace(string(output)))
+}
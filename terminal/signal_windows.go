@@ -0,0 +1,20 @@
+//go:build windows
+
+package terminal
+
+import "os"
+
+// signalProcessGroup is not available on Windows
+func signalProcessGroup(pgrp int, name string) error {
+	return ErrSignalUnsupported
+}
+
+// terminalForegroundGroup is not available on Windows
+func terminalForegroundGroup(file *os.File) (int, error) {
+	return 0, ErrSignalUnsupported
+}
+
+// processForegroundGroup is not available on Windows
+func processForegroundGroup(pid int) (int, error) {
+	return 0, ErrSignalUnsupported
+}
@@ -0,0 +1,114 @@
+package terminal
+
+import "strings"
+
+// CellStyle is how a character cell is drawn. Colors are palette indexes,
+// "0" to "255", or "#rrggbb"; empty for the terminal's default.
+type CellStyle struct {
+	Fg            string `json:"fg,omitempty"`
+	Bg            string `json:"bg,omitempty"`
+	Bold          bool   `json:"bold,omitempty"`
+	Dim           bool   `json:"dim,omitempty"`
+	Italic        bool   `json:"italic,omitempty"`
+	Underline     bool   `json:"underline,omitempty"`
+	Blink         bool   `json:"blink,omitempty"`
+	Inverse       bool   `json:"inverse,omitempty"`
+	Hidden        bool   `json:"hidden,omitempty"`
+	Strikethrough bool   `json:"strikethrough,omitempty"`
+}
+
+// ScreenSpan is a run of cells of a line drawn with the same non-default
+// style. Start and Length count columns, in which wide characters take two.
+type ScreenSpan struct {
+	Start  int `json:"start"`
+	Length int `json:"length"`
+	CellStyle
+}
+
+// ScreenLine is a row of the screen
+type ScreenLine struct {
+	Text  string       `json:"text"` // without trailing blanks
+	Spans []ScreenSpan `json:"spans,omitempty"`
+}
+
+// ScreenCursor is the cursor position, from 0
+type ScreenCursor struct {
+	Row     int  `json:"row"`
+	Col     int  `json:"col"`
+	Visible bool `json:"visible"`
+}
+
+// ScreenSnapshot is the screen of a session as a terminal would show it
+type ScreenSnapshot struct {
+	Cols      int          `json:"cols"`
+	Rows      int          `json:"rows"`
+	Title     string       `json:"title,omitempty"` // set by the program with OSC 0 or 2
+	AltScreen bool         `json:"alt_screen"`      // a full-screen program such as vim is showing
+	Cursor    ScreenCursor `json:"cursor"`
+	Lines     []ScreenLine `json:"lines"`
+}
+
+// Text returns the screen's lines as plain text
+func (s ScreenSnapshot) Text() string {
+	var b strings.Builder
+	for _, line := range s.Lines {
+		b.WriteString(line.Text)
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+// Snapshotter is implemented by sessions that can render their screen
+type Snapshotter interface {
+	Snapshot() ScreenSnapshot
+}
+
+// Snapshot returns the session's screen, rendered from all of its output by
+// a terminal emulator that follows the session's size
+func (s *TerminalSession) Snapshot() ScreenSnapshot {
+	s.outputMu.Lock()
+	defer s.outputMu.Unlock()
+	return s.screen.snapshot()
+}
+
+// RenderScreen returns the screen of a cols by rows terminal after output
+func RenderScreen(output []byte, cols, rows int) ScreenSnapshot {
+	screen := newVTScreen(cols, rows)
+	_, _ = screen.Write(output)
+	return screen.snapshot()
+}
+
+// snapshot returns the screen's current contents
+func (s *vtScreen) snapshot() ScreenSnapshot {
+	snapshot := ScreenSnapshot{
+		Cols:      s.cols,
+		Rows:      s.rows,
+		Title:     s.title,
+		AltScreen: s.altActive,
+		Cursor:    ScreenCursor{Row: s.y, Col: s.x, Visible: !s.cursorHidden},
+		Lines:     make([]ScreenLine, s.rows),
+	}
+	for y, cells := range s.lines {
+		var text strings.Builder
+		var spans []ScreenSpan
+		for x, cell := range cells {
+			if !cell.cont {
+				if cell.ch == "" {
+					text.WriteByte(' ')
+				} else {
+					text.WriteString(cell.ch)
+				}
+			}
+			if cell.style == (CellStyle{}) {
+				continue
+			}
+			if n := len(spans); n > 0 && spans[n-1].Start+spans[n-1].Length == x && spans[n-1].CellStyle == cell.style {
+				spans[n-1].Length++
+				continue
+			}
+			spans = append(spans, ScreenSpan{Start: x, Length: 1, CellStyle: cell.style})
+		}
+		snapshot.Lines[y] = ScreenLine{Text: strings.TrimRight(text.String(), " "), Spans: spans}
+	}
+	return snapshot
+}
@@ -0,0 +1,103 @@
+package terminal
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("RenderScreen", func() {
+	It("should render text, line wrapping and cursor movement", func() {
+		snapshot := RenderScreen([]byte("hello\r\nworld\x1b[1;3Hxy\x1b[3;1H0123456789ab"), 10, 4)
+
+		Expect(snapshot.Cols).To(Equal(10))
+		Expect(snapshot.Rows).To(Equal(4))
+		Expect(snapshot.Text()).To(Equal("hexyo\nworld\n0123456789\nab\n"))
+		Expect(snapshot.Cursor).To(Equal(ScreenCursor{Row: 3, Col: 2, Visible: true}))
+	})
+
+	It("should scroll and erase", func() {
+		snapshot := RenderScreen([]byte("one\r\ntwo\r\nthree\r\nfour\x1b[1;2H\x1b[K\x1b[3;1H\x1b[2K"), 8, 3)
+		Expect(snapshot.Text()).To(Equal("t\nthree\n\n"))
+	})
+
+	It("should keep colors and attributes as spans", func() {
+		snapshot := RenderScreen([]byte("a\x1b[1;31mbc\x1b[0m d\x1b[38;5;200me\x1b[48;2;1;2;3mf\x1b[m"), 10, 1)
+
+		Expect(snapshot.Lines[0].Text).To(Equal("abc def"))
+		Expect(snapshot.Lines[0].Spans).To(Equal([]ScreenSpan{
+			{Start: 1, Length: 2, CellStyle: CellStyle{Fg: "1", Bold: true}},
+			{Start: 5, Length: 1, CellStyle: CellStyle{Fg: "200"}},
+			{Start: 6, Length: 1, CellStyle: CellStyle{Fg: "200", Bg: "#010203"}},
+		}))
+	})
+
+	It("should show the alternate screen until the program leaves it", func() {
+		output := []byte("$ vim\r\n\x1b]2;vim file\x07\x1b[?1049h\x1b[?25l\x1b[Hediting")
+		snapshot := RenderScreen(output, 10, 2)
+		Expect(snapshot.AltScreen).To(BeTrue())
+		Expect(snapshot.Title).To(Equal("vim file"))
+		Expect(snapshot.Cursor.Visible).To(BeFalse())
+		Expect(snapshot.Text()).To(Equal("editing\n\n"))
+
+		snapshot = RenderScreen(append(output, "\x1b[?1049l\x1b[?25h$ "...), 10, 2)
+		Expect(snapshot.AltScreen).To(BeFalse())
+		Expect(snapshot.Text()).To(Equal("$ vim\n$\n"))
+		Expect(snapshot.Cursor).To(Equal(ScreenCursor{Row: 1, Col: 2, Visible: true}))
+	})
+
+	It("should give wide characters two columns", func() {
+		snapshot := RenderScreen([]byte("a한b"), 6, 1)
+		Expect(snapshot.Lines[0].Text).To(Equal("a한b"))
+		Expect(snapshot.Cursor.Col).To(Equal(4))
+
+		// Overwriting half of a wide character blanks the other half
+		snapshot = RenderScreen([]byte("a한b\x1b[1;3Hx"), 6, 1)
+		Expect(snapshot.Lines[0].Text).To(Equal("a xb"))
+	})
+
+	It("should survive truncated and malformed sequences", func() {
+		snapshot := RenderScreen([]byte("\x1b[2;1;99;99;99Hok\x1b[38;2m\x1b]0;\xff\x1b\\\xe2\x82x\x1b["), 4, 2)
+		Expect(snapshot.Lines[1].Text).To(Equal("ok�x"))
+	})
+
+	It("should repaint a screen to the same snapshot", func() {
+		for _, output := range []string{
+			"$ ls\r\n\x1b[1;32mbin\x1b[0m  \x1b[48;2;1;2;3mdocs\x1b[m\r\n$ \x1b[4mtyping",
+			"$ vim\r\n\x1b]2;vim file\x07\x1b[?1049h\x1b[?25l\x1b[2;3r\x1b[H\x1b[7m~ \x1b[27m한 file\x1b[2;4H",
+		} {
+			screen := newVTScreen(12, 4)
+			_, _ = screen.Write([]byte(output))
+
+			repainted := newVTScreen(12, 4)
+			_, _ = repainted.Write([]byte("stale\r\nscreen"))
+			_, _ = repainted.Write(screen.repaint())
+			Expect(repainted.snapshot()).To(Equal(screen.snapshot()))
+
+			// Output after the repaint lands where it would have
+			_, _ = screen.Write([]byte("\x1b[?1049lx\x1b[1Ly"))
+			_, _ = repainted.Write([]byte("\x1b[?1049lx\x1b[1Ly"))
+			Expect(repainted.snapshot()).To(Equal(screen.snapshot()))
+		}
+	})
+
+	It("should not repaint in the middle of an escape sequence", func() {
+		screen := newVTScreen(10, 2)
+		_, _ = screen.Write([]byte("ok\x1b[3"))
+		Expect(screen.repaint()).To(BeNil())
+		_, _ = screen.Write([]byte("1m"))
+		Expect(screen.repaint()).NotTo(BeNil())
+	})
+
+	It("should keep the lines by the cursor when resized", func() {
+		screen := newVTScreen(10, 4)
+		_, _ = screen.Write([]byte("one\r\ntwo\r\nthree\r\nfour"))
+
+		screen.resize(3, 2)
+		snapshot := screen.snapshot()
+		Expect(snapshot.Text()).To(Equal("thr\nfou\n"))
+		Expect(snapshot.Cursor).To(Equal(ScreenCursor{Row: 1, Col: 2, Visible: true}))
+
+		screen.resize(6, 3)
+		Expect(screen.snapshot().Text()).To(Equal("thr\nfou\n\n"))
+	})
+})
@@ -0,0 +1,522 @@
+package terminal
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// Host key policies for hosts missing from known_hosts
+const (
+	// SSHHostKeyPolicyStrict refuses hosts that are not in known_hosts
+	SSHHostKeyPolicyStrict = "strict"
+	// SSHHostKeyPolicyAcceptNew records the key of a host seen for the first
+	// time; changed keys are still refused
+	SSHHostKeyPolicyAcceptNew = "accept-new"
+)
+
+// SSHCredentialKeyPrefix marks a key_ref naming a key in the credential store
+// rather than a file in the key directory
+const SSHCredentialKeyPrefix = "credential:"
+
+const (
+	defaultSSHPort        = 22
+	defaultSSHDialTimeout = 10 * time.Second
+)
+
+// SSHTarget is the remote host an SSH session connects to
+type SSHTarget struct {
+	Host   string `json:"host"`
+	Port   int    `json:"port,omitempty"` // default 22
+	User   string `json:"user"`
+	KeyRef string `json:"key_ref"` // key file name in the key directory, or "credential:<name>"
+}
+
+// Validate checks that the target is complete
+func (t SSHTarget) Validate() error {
+	if t.Host == "" {
+		return errors.New("ssh host is required")
+	}
+	if strings.ContainsAny(t.Host, " /@") {
+		return fmt.Errorf("invalid ssh host %q", t.Host)
+	}
+	if t.Port < 0 || t.Port > 65535 {
+		return errors.New("ssh port must be between 1 and 65535")
+	}
+	if t.User == "" {
+		return errors.New("ssh user is required")
+	}
+	return validateKeyRef(t.KeyRef)
+}
+
+// Address returns host:port
+func (t SSHTarget) Address() string {
+	port := t.Port
+	if port == 0 {
+		port = defaultSSHPort
+	}
+	return net.JoinHostPort(t.Host, strconv.Itoa(port))
+}
+
+// validateKeyRef rejects key names that could escape the key directory
+func validateKeyRef(ref string) error {
+	if ref == "" {
+		return errors.New("ssh key_ref is required")
+	}
+	if strings.ContainsAny(ref, `/\`) || strings.HasPrefix(ref, ".") {
+		return fmt.Errorf("invalid ssh key_ref %q", ref)
+	}
+	return nil
+}
+
+// SSHConfig configures the SSH gateway
+type SSHConfig struct {
+	KeyDir         string // private keys referenced by SSHTarget.KeyRef
+	KnownHostsFile string // managed known_hosts store
+	HostKeyPolicy  string // SSHHostKeyPolicyStrict or SSHHostKeyPolicyAcceptNew
+	DialTimeout    time.Duration
+}
+
+// GetSSHConfigFromEnv reads TERMINAL_HUB_SSH_DIR (default ~/.terminal-hub/ssh,
+// holding keys/ and known_hosts) and TERMINAL_HUB_SSH_HOST_KEY_POLICY
+func GetSSHConfigFromEnv() SSHConfig {
+	dir := os.Getenv("TERMINAL_HUB_SSH_DIR")
+	if dir == "" {
+		if homeDir, err := os.UserHomeDir(); err == nil {
+			dir = filepath.Join(homeDir, ".terminal-hub", "ssh")
+		} else {
+			dir = "ssh"
+		}
+	}
+
+	policy := SSHHostKeyPolicyStrict
+	if os.Getenv("TERMINAL_HUB_SSH_HOST_KEY_POLICY") == SSHHostKeyPolicyAcceptNew {
+		policy = SSHHostKeyPolicyAcceptNew
+	}
+
+	return SSHConfig{
+		KeyDir:         filepath.Join(dir, "keys"),
+		KnownHostsFile: filepath.Join(dir, "known_hosts"),
+		HostKeyPolicy:  policy,
+		DialTimeout:    defaultSSHDialTimeout,
+	}
+}
+
+// SSHKeyLookup returns the private key stored under a credential name
+type SSHKeyLookup func(name string) ([]byte, error)
+
+// SSHGateway opens SSH connections to remote hosts using keys from its key
+// directory or credential store, verifying host keys against its
+// known_hosts store
+type SSHGateway struct {
+	config     SSHConfig
+	knownHosts *KnownHosts
+
+	mu        sync.RWMutex
+	keyLookup SSHKeyLookup // resolves "credential:" key refs, nil = unsupported
+}
+
+// NewSSHGateway creates a gateway from config
+func NewSSHGateway(config SSHConfig) *SSHGateway {
+	if config.DialTimeout <= 0 {
+		config.DialTimeout = defaultSSHDialTimeout
+	}
+	return &SSHGateway{
+		config:     config,
+		knownHosts: NewKnownHosts(config.KnownHostsFile, config.HostKeyPolicy),
+	}
+}
+
+// KnownHosts returns the gateway's known_hosts store
+func (g *SSHGateway) KnownHosts() *KnownHosts {
+	return g.knownHosts
+}
+
+// SetKeyLookup enables key_ref values of the form "credential:<name>"
+func (g *SSHGateway) SetKeyLookup(lookup SSHKeyLookup) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.keyLookup = lookup
+}
+
+// ListKeys returns the names of the private keys available as key_ref
+func (g *SSHGateway) ListKeys() ([]string, error) {
+	entries, err := os.ReadDir(g.config.KeyDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []string{}, nil
+		}
+		return nil, err
+	}
+	keys := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		if entry.IsDir() || validateKeyRef(entry.Name()) != nil || strings.HasSuffix(entry.Name(), ".pub") {
+			continue
+		}
+		keys = append(keys, entry.Name())
+	}
+	return keys, nil
+}
+
+// loadSigner reads the private key named ref
+func (g *SSHGateway) loadSigner(ref string) (ssh.Signer, error) {
+	if err := validateKeyRef(ref); err != nil {
+		return nil, err
+	}
+	data, err := g.readKey(ref)
+	if err != nil {
+		return nil, err
+	}
+	signer, err := ssh.ParsePrivateKey(data)
+	if err != nil {
+		var passphraseErr *ssh.PassphraseMissingError
+		if errors.As(err, &passphraseErr) {
+			return nil, fmt.Errorf("ssh key %q is passphrase protected, which is not supported", ref)
+		}
+		return nil, fmt.Errorf("failed to parse ssh key %q: %w", ref, err)
+	}
+	return signer, nil
+}
+
+// readKey returns the private key named by ref
+func (g *SSHGateway) readKey(ref string) ([]byte, error) {
+	if name, ok := strings.CutPrefix(ref, SSHCredentialKeyPrefix); ok {
+		g.mu.RLock()
+		lookup := g.keyLookup
+		g.mu.RUnlock()
+		if lookup == nil {
+			return nil, errors.New("the credential store is not configured")
+		}
+		return lookup(name)
+	}
+
+	data, err := os.ReadFile(filepath.Join(g.config.KeyDir, ref))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("ssh key %q not found", ref)
+		}
+		return nil, err
+	}
+	return data, nil
+}
+
+// Dial connects and authenticates to target
+func (g *SSHGateway) Dial(target SSHTarget) (*ssh.Client, error) {
+	if err := target.Validate(); err != nil {
+		return nil, err
+	}
+	signer, err := g.loadSigner(target.KeyRef)
+	if err != nil {
+		return nil, err
+	}
+	client, err := ssh.Dial("tcp", target.Address(), &ssh.ClientConfig{
+		User:            target.User,
+		Auth:            []ssh.AuthMethod{ssh.PublicKeys(signer)},
+		HostKeyCallback: g.knownHosts.Check,
+		Timeout:         g.config.DialTimeout,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to %s: %w", target.Address(), err)
+	}
+	return client, nil
+}
+
+// KnownHost is one entry of the known_hosts store
+type KnownHost struct {
+	Hosts       []string `json:"hosts"`
+	KeyType     string   `json:"key_type"`
+	Fingerprint string   `json:"fingerprint"` // SHA256 fingerprint
+}
+
+// KnownHosts is a known_hosts file managed by terminal-hub
+type KnownHosts struct {
+	path   string
+	policy string
+	mu     sync.Mutex
+}
+
+// NewKnownHosts creates a store backed by path
+func NewKnownHosts(path, policy string) *KnownHosts {
+	return &KnownHosts{path: path, policy: policy}
+}
+
+// Check verifies a host key, recording it when the host is unknown and the
+// policy is SSHHostKeyPolicyAcceptNew. It is an ssh.HostKeyCallback.
+func (k *KnownHosts) Check(hostname string, remote net.Addr, key ssh.PublicKey) error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	if err := k.ensureFileLocked(); err != nil {
+		return err
+	}
+	callback, err := knownhosts.New(k.path)
+	if err != nil {
+		return fmt.Errorf("failed to read known_hosts: %w", err)
+	}
+
+	err = callback(hostname, remote, key)
+	var keyErr *knownhosts.KeyError
+	if !errors.As(err, &keyErr) {
+		return err
+	}
+	if len(keyErr.Want) > 0 {
+		return fmt.Errorf("host key for %s does not match known_hosts (%s %s): possible man-in-the-middle attack",
+			hostname, key.Type(), ssh.FingerprintSHA256(key))
+	}
+	if k.policy != SSHHostKeyPolicyAcceptNew {
+		return fmt.Errorf("host %s is not in known_hosts (%s %s)", hostname, key.Type(), ssh.FingerprintSHA256(key))
+	}
+
+	line := knownhosts.Line([]string{knownhosts.Normalize(hostname)}, key)
+	if err := k.appendLocked(line); err != nil {
+		return err
+	}
+	log.Printf("SSH: added host key for %s (%s %s)", hostname, key.Type(), ssh.FingerprintSHA256(key))
+	return nil
+}
+
+// List returns the entries of the store
+func (k *KnownHosts) List() ([]KnownHost, error) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	data, err := os.ReadFile(k.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []KnownHost{}, nil
+		}
+		return nil, err
+	}
+
+	hosts := make([]KnownHost, 0)
+	for rest := data; len(rest) > 0; {
+		_, entryHosts, key, _, next, err := ssh.ParseKnownHosts(rest)
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, fmt.Errorf("failed to parse known_hosts: %w", err)
+		}
+		hosts = append(hosts, KnownHost{
+			Hosts:       entryHosts,
+			KeyType:     key.Type(),
+			Fingerprint: ssh.FingerprintSHA256(key),
+		})
+		rest = next
+	}
+	return hosts, nil
+}
+
+// Remove deletes every entry for host ("example.com" or "[example.com]:2222")
+func (k *KnownHosts) Remove(host string) error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	data, err := os.ReadFile(k.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return errors.New("host not found")
+		}
+		return err
+	}
+
+	normalized := knownhosts.Normalize(host)
+	var kept bytes.Buffer
+	removed := false
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if knownHostsLineMatches(line, normalized) {
+			removed = true
+			continue
+		}
+		kept.WriteString(line + "\n")
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	if !removed {
+		return errors.New("host not found")
+	}
+	return os.WriteFile(k.path, kept.Bytes(), 0600)
+}
+
+// knownHostsLineMatches reports whether a known_hosts line lists host
+func knownHostsLineMatches(line, host string) bool {
+	fields := strings.Fields(line)
+	if len(fields) < 2 || strings.HasPrefix(fields[0], "#") {
+		return false
+	}
+	patterns := fields[0]
+	if strings.HasPrefix(patterns, "@") && len(fields) > 2 {
+		patterns = fields[1]
+	}
+	for _, pattern := range strings.Split(patterns, ",") {
+		if pattern == host {
+			return true
+		}
+	}
+	return false
+}
+
+// ensureFileLocked creates an empty known_hosts file if there is none
+func (k *KnownHosts) ensureFileLocked() error {
+	if err := os.MkdirAll(filepath.Dir(k.path), 0700); err != nil {
+		return fmt.Errorf("failed to create known_hosts directory: %w", err)
+	}
+	file, err := os.OpenFile(k.path, os.O_CREATE|os.O_RDONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to create known_hosts: %w", err)
+	}
+	return file.Close()
+}
+
+// appendLocked adds a line to the known_hosts file
+func (k *KnownHosts) appendLocked(line string) error {
+	file, err := os.OpenFile(k.path, os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	_, err = file.WriteString(line + "\n")
+	return err
+}
+
+// sshPTYService resizes the remote PTY of an SSH session. Sessions started
+// this way never start local shells.
+type sshPTYService struct {
+	session *ssh.Session
+}
+
+func (s *sshPTYService) Start(cmd string) (*os.File, error) {
+	return nil, errors.New("ssh sessions cannot start local shells")
+}
+
+func (s *sshPTYService) StartWithConfig(shell string, workingDir string, envVars map[string]string) (*os.File, *exec.Cmd, error) {
+	return nil, nil, errors.New("ssh sessions cannot start local shells")
+}
+
+// SetSize forwards the window size to the remote PTY
+func (s *sshPTYService) SetSize(file *os.File, cols, rows int) error {
+	return s.session.WindowChange(rows, cols)
+}
+
+// startSSHSession connects to config.SSH and starts a remote login shell.
+// The session talks to the remote shell through one end of a local socket
+// pair, so it can be read and written like a PTY.
+func startSSHSession(config SessionConfig) (sessionStartResult, error) {
+	if config.SSH == nil {
+		return sessionStartResult{}, errors.New("ssh target is required for the ssh backend")
+	}
+	if config.SSHGateway == nil {
+		return sessionStartResult{}, errors.New("ssh backend is not configured")
+	}
+
+	client, err := config.SSHGateway.Dial(*config.SSH)
+	if err != nil {
+		return sessionStartResult{}, err
+	}
+	remote, err := startRemoteShell(client, config)
+	if err != nil {
+		client.Close()
+		return sessionStartResult{}, err
+	}
+
+	local, pumpEnd, err := socketPair()
+	if err != nil {
+		remote.session.Close()
+		client.Close()
+		return sessionStartResult{}, err
+	}
+
+	// Remote output -> session; the session sees EOF once the remote shell exits
+	go func() {
+		_, _ = io.Copy(pumpEnd, remote.stdout)
+		pumpEnd.Close()
+	}()
+	// Session input -> remote; ends when the session closes its end
+	go func() {
+		_, _ = io.Copy(remote.stdin, pumpEnd)
+		remote.session.Close()
+		client.Close()
+	}()
+
+	return sessionStartResult{
+		ptmx:    local,
+		backend: SessionBackendSSH,
+		ptySvc:  &sshPTYService{session: remote.session},
+	}, nil
+}
+
+type remoteShell struct {
+	session *ssh.Session
+	stdin   io.Writer
+	stdout  io.Reader
+}
+
+// startRemoteShell requests a PTY and starts the login shell, first changing
+// to config.WorkingDirectory on the remote host if set
+func startRemoteShell(client *ssh.Client, config SessionConfig) (*remoteShell, error) {
+	session, err := client.NewSession()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open ssh session: %w", err)
+	}
+
+	stdin, err := session.StdinPipe()
+	if err != nil {
+		session.Close()
+		return nil, err
+	}
+	stdout, err := session.StdoutPipe()
+	if err != nil {
+		session.Close()
+		return nil, err
+	}
+	session.Stderr = io.Discard // the PTY merges stderr into stdout
+
+	// Most servers only accept a few variables (AcceptEnv); ignore refusals
+	for key, value := range config.EnvVars {
+		_ = session.Setenv(key, value)
+	}
+
+	modes := ssh.TerminalModes{
+		ssh.ECHO:          1,
+		ssh.TTY_OP_ISPEED: 38400,
+		ssh.TTY_OP_OSPEED: 38400,
+	}
+	if err := session.RequestPty("xterm-256color", 24, 80, modes); err != nil {
+		session.Close()
+		return nil, fmt.Errorf("failed to request remote pty: %w", err)
+	}
+
+	if config.WorkingDirectory != "" {
+		err = session.Start("cd " + shellQuote(config.WorkingDirectory) + ` && exec "${SHELL:-/bin/sh}" -l`)
+	} else {
+		err = session.Shell()
+	}
+	if err != nil {
+		session.Close()
+		return nil, fmt.Errorf("failed to start remote shell: %w", err)
+	}
+
+	return &remoteShell{session: session, stdin: stdin, stdout: stdout}, nil
+}
+
+// shellQuote quotes s for a POSIX shell
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -0,0 +1,215 @@
+//go:build !windows
+
+package terminal
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/pem"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"golang.org/x/crypto/ssh"
+)
+
+// testSSHServer is an SSH server whose shell echoes its input
+type testSSHServer struct {
+	listener      net.Listener
+	windowChanges chan [2]uint32 // cols, rows
+}
+
+func newTestSSHServer(hostKey ssh.Signer, clientKey ssh.PublicKey) *testSSHServer {
+	config := &ssh.ServerConfig{
+		PublicKeyCallback: func(conn ssh.ConnMetadata, key ssh.PublicKey) (*ssh.Permissions, error) {
+			if string(key.Marshal()) == string(clientKey.Marshal()) {
+				return nil, nil
+			}
+			return nil, io.EOF
+		},
+	}
+	config.AddHostKey(hostKey)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	Expect(err).ToNot(HaveOccurred())
+	server := &testSSHServer{listener: listener, windowChanges: make(chan [2]uint32, 10)}
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go server.serve(conn, config)
+		}
+	}()
+	return server
+}
+
+func (s *testSSHServer) serve(conn net.Conn, config *ssh.ServerConfig) {
+	_, channels, requests, err := ssh.NewServerConn(conn, config)
+	if err != nil {
+		return
+	}
+	go ssh.DiscardRequests(requests)
+	for newChannel := range channels {
+		channel, channelRequests, err := newChannel.Accept()
+		if err != nil {
+			continue
+		}
+		go func() {
+			for req := range channelRequests {
+				switch req.Type {
+				case "shell":
+					go func() {
+						_, _ = io.Copy(channel, channel)
+					}()
+				case "window-change":
+					s.windowChanges <- [2]uint32{binary.BigEndian.Uint32(req.Payload), binary.BigEndian.Uint32(req.Payload[4:])}
+				}
+				if req.WantReply {
+					_ = req.Reply(true, nil)
+				}
+			}
+		}()
+	}
+}
+
+func (s *testSSHServer) target(keyRef string) SSHTarget {
+	host, port, _ := net.SplitHostPort(s.listener.Addr().String())
+	portNum, _ := strconv.Atoi(port)
+	return SSHTarget{Host: host, Port: portNum, User: "tester", KeyRef: keyRef}
+}
+
+func newTestSigner() (ssh.Signer, ed25519.PrivateKey) {
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	Expect(err).ToNot(HaveOccurred())
+	signer, err := ssh.NewSignerFromKey(key)
+	Expect(err).ToNot(HaveOccurred())
+	return signer, key
+}
+
+var _ = Describe("SSH backend", func() {
+	var (
+		dir     string
+		config  SSHConfig
+		hostKey ssh.Signer
+		server  *testSSHServer
+	)
+
+	BeforeEach(func() {
+		dir = GinkgoT().TempDir()
+		config = SSHConfig{
+			KeyDir:         filepath.Join(dir, "keys"),
+			KnownHostsFile: filepath.Join(dir, "known_hosts"),
+			HostKeyPolicy:  SSHHostKeyPolicyAcceptNew,
+		}
+
+		clientSigner, clientKey := newTestSigner()
+		block, err := ssh.MarshalPrivateKey(clientKey, "")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(os.MkdirAll(config.KeyDir, 0700)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(config.KeyDir, "id_test"), pem.EncodeToMemory(block), 0600)).To(Succeed())
+
+		hostKey, _ = newTestSigner()
+		server = newTestSSHServer(hostKey, clientSigner.PublicKey())
+		DeferCleanup(server.listener.Close)
+	})
+
+	It("should validate targets", func() {
+		Expect(SSHTarget{Host: "example.com", User: "me", KeyRef: "id_ed25519"}.Validate()).To(Succeed())
+		Expect(SSHTarget{Host: "example.com", User: "me", KeyRef: "../id"}.Validate()).ToNot(Succeed())
+		Expect(SSHTarget{Host: "example.com", KeyRef: "id"}.Validate()).To(MatchError("ssh user is required"))
+		Expect(SSHTarget{Host: "example.com", User: "me", KeyRef: "id"}.Address()).To(Equal("example.com:22"))
+	})
+
+	It("should refuse unknown hosts under the strict policy", func() {
+		config.HostKeyPolicy = SSHHostKeyPolicyStrict
+		_, err := NewSSHGateway(config).Dial(server.target("id_test"))
+		Expect(err).To(MatchError(ContainSubstring("is not in known_hosts")))
+	})
+
+	It("should record new host keys and refuse changed ones", func() {
+		gateway := NewSSHGateway(config)
+		client, err := gateway.Dial(server.target("id_test"))
+		Expect(err).ToNot(HaveOccurred())
+		client.Close()
+
+		hosts, err := gateway.KnownHosts().List()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(hosts).To(HaveLen(1))
+		Expect(hosts[0].Fingerprint).To(Equal(ssh.FingerprintSHA256(hostKey.PublicKey())))
+
+		// Record the first host key for a second server that presents another key
+		otherKey, _ := newTestSigner()
+		other := newTestSSHServer(otherKey, hostKey.PublicKey())
+		defer other.listener.Close()
+		otherTarget := other.target("id_test")
+		Expect(gateway.KnownHosts().Check(net.JoinHostPort(otherTarget.Host, strconv.Itoa(otherTarget.Port)), other.listener.Addr(), hostKey.PublicKey())).To(Succeed())
+		_, err = gateway.Dial(otherTarget)
+		Expect(err).To(MatchError(ContainSubstring("does not match known_hosts")))
+
+		Expect(gateway.KnownHosts().Remove(otherTarget.Address())).To(Succeed())
+		Expect(gateway.KnownHosts().Remove(otherTarget.Address())).To(MatchError("host not found"))
+	})
+
+	It("should load keys from the credential store", func() {
+		keyPEM, err := os.ReadFile(filepath.Join(config.KeyDir, "id_test"))
+		Expect(err).ToNot(HaveOccurred())
+		gateway := NewSSHGateway(config)
+
+		_, err = gateway.Dial(server.target(SSHCredentialKeyPrefix + "deploy"))
+		Expect(err).To(MatchError("the credential store is not configured"))
+
+		gateway.SetKeyLookup(func(name string) ([]byte, error) {
+			Expect(name).To(Equal("deploy"))
+			return keyPEM, nil
+		})
+		client, err := gateway.Dial(server.target(SSHCredentialKeyPrefix + "deploy"))
+		Expect(err).ToNot(HaveOccurred())
+		client.Close()
+	})
+
+	It("should report missing keys", func() {
+		_, err := NewSSHGateway(config).Dial(server.target("id_missing"))
+		Expect(err).To(MatchError(`ssh key "id_missing" not found`))
+		Expect(NewSSHGateway(config).ListKeys()).To(Equal([]string{"id_test"}))
+	})
+
+	It("should run a terminal session on the remote host", func() {
+		target := server.target("id_test")
+		session, err := NewTerminalSession(SessionConfig{
+			ID:         "ssh-session",
+			Backend:    SessionBackendSSH,
+			SSH:        &target,
+			SSHGateway: NewSSHGateway(config),
+		})
+		Expect(err).ToNot(HaveOccurred())
+		defer session.Close()
+
+		metadata := session.GetMetadata()
+		Expect(metadata.Backend).To(Equal(SessionBackendSSH))
+		Expect(metadata.SSH.Host).To(Equal(target.Host))
+
+		_, err = session.Write([]byte("hello remote"))
+		Expect(err).ToNot(HaveOccurred())
+		Eventually(func() string {
+			return string(session.history.GetHistory())
+		}, 5*time.Second).Should(ContainSubstring("hello remote"))
+
+		Expect(session.Resize(nil, 120, 40)).To(Succeed())
+		Eventually(server.windowChanges, 5*time.Second).Should(Receive(Equal([2]uint32{120, 40})))
+	})
+
+	It("should fail without a gateway", func() {
+		target := server.target("id_test")
+		_, err := NewTerminalSession(SessionConfig{ID: "ssh-missing", Backend: SessionBackendSSH, SSH: &target})
+		Expect(err).To(MatchError("ssh backend is not configured"))
+	})
+})
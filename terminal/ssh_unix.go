@@ -0,0 +1,27 @@
+//go:build !windows
+
+package terminal
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+)
+
+// socketPair returns the two connected ends of a Unix socket pair. The ends
+// are non-blocking so closing one interrupts pending reads.
+func socketPair() (*os.File, *os.File, error) {
+	fds, err := syscall.Socketpair(syscall.AF_UNIX, syscall.SOCK_STREAM, 0)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create socket pair: %w", err)
+	}
+	for _, fd := range fds {
+		syscall.CloseOnExec(fd)
+		if err := syscall.SetNonblock(fd, true); err != nil {
+			syscall.Close(fds[0])
+			syscall.Close(fds[1])
+			return nil, nil, err
+		}
+	}
+	return os.NewFile(uintptr(fds[0]), "ssh-session"), os.NewFile(uintptr(fds[1]), "ssh-pump"), nil
+}
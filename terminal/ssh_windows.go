@@ -0,0 +1,13 @@
+//go:build windows
+
+package terminal
+
+import (
+	"errors"
+	"os"
+)
+
+// socketPair is not available on Windows, so the SSH backend is unsupported
+func socketPair() (*os.File, *os.File, error) {
+	return nil, nil, errors.New("the ssh backend is not supported on Windows")
+}
@@ -0,0 +1,334 @@
+package terminal
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// ErrNotTmuxSession is returned by window and pane operations on sessions
+// that do not use the tmux backend
+var ErrNotTmuxSession = errors.New("session does not use the tmux backend")
+
+const maxTmuxWindowNameLength = 64
+
+var tmuxPaneIDPattern = regexp.MustCompile(`^%[0-9]+$`)
+
+// Pane directions accepted by SelectPane, mapped to tmux select-pane arguments
+var tmuxPaneDirections = map[string][]string{
+	"left":     {"-L"},
+	"right":    {"-R"},
+	"up":       {"-U"},
+	"down":     {"-D"},
+	"next":     {"-t", ":.+"},
+	"previous": {"-t", ":.-"},
+}
+
+// TmuxPane is a pane of a tmux window
+type TmuxPane struct {
+	ID      string `json:"id"` // tmux pane ID, e.g. "%3"
+	Index   int    `json:"index"`
+	Active  bool   `json:"active"`
+	Command string `json:"command"` // current foreground command
+	Width   int    `json:"width"`
+	Height  int    `json:"height"`
+}
+
+// TmuxWindow is a window of a tmux session
+type TmuxWindow struct {
+	ID     string     `json:"id"` // tmux window ID, e.g. "@1"
+	Index  int        `json:"index"`
+	Name   string     `json:"name"`
+	Active bool       `json:"active"`
+	Panes  []TmuxPane `json:"panes"`
+}
+
+// HostTmuxSession is a tmux session running on the host
+type HostTmuxSession struct {
+	Name      string    `json:"name"`
+	Windows   int       `json:"windows"`
+	Attached  int       `json:"attached"` // number of attached tmux clients
+	CreatedAt time.Time `json:"created_at"`
+	Managed   bool      `json:"managed"` // already attached to a terminal-hub session
+}
+
+// ListHostTmuxSessions returns the tmux sessions of runAs's tmux server, or
+// of the daemon's own account when runAs is nil. It returns an empty list
+// when tmux is not installed or no server is running.
+func ListHostTmuxSessions(runAs *RunAs) ([]HostTmuxSession, error) {
+	sessions := make([]HostTmuxSession, 0)
+	if _, err := exec.LookPath("tmux"); err != nil {
+		return sessions, nil
+	}
+
+	output, err := newTmuxCommand(runAs, "list-sessions", "-F",
+		"#{session_created}\t#{session_windows}\t#{session_attached}\t#{session_name}").CombinedOutput()
+	if err != nil {
+		message := strings.TrimSpace(string(output))
+		if strings.Contains(message, "no server running") || strings.Contains(message, "error connecting") {
+			return sessions, nil
+		}
+		return nil, fmt.Errorf("tmux list-sessions failed: %s", message)
+	}
+
+	for _, line := range splitTmuxLines(string(output)) {
+		fields := strings.SplitN(line, "\t", 4)
+		if len(fields) != 4 {
+			continue
+		}
+		created, _ := strconv.ParseInt(fields[0], 10, 64)
+		windows, _ := strconv.Atoi(fields[1])
+		attached, _ := strconv.Atoi(fields[2])
+		sessions = append(sessions, HostTmuxSession{
+			Name:      fields[3],
+			Windows:   windows,
+			Attached:  attached,
+			CreatedAt: time.Unix(created, 0),
+		})
+	}
+	return sessions, nil
+}
+
+// TmuxWindowManager is implemented by sessions whose windows and panes can
+// be managed through tmux
+type TmuxWindowManager interface {
+	ListWindows() ([]TmuxWindow, error)
+	CreateWindow(name string) (TmuxWindow, error)
+	SelectWindow(index int) error
+	KillWindow(index int) error
+	SelectPane(target string) error
+}
+
+// ListWindows returns the session's tmux windows with their panes
+func (s *TerminalSession) ListWindows() ([]TmuxWindow, error) {
+	name, err := s.tmuxTarget()
+	if err != nil {
+		return nil, err
+	}
+
+	windowOutput, err := s.runTmux("list-windows", "-t", name, "-F",
+		"#{window_index}\t#{window_id}\t#{window_active}\t#{window_name}")
+	if err != nil {
+		return nil, err
+	}
+	paneOutput, err := s.runTmux("list-panes", "-s", "-t", name, "-F",
+		"#{window_index}\t#{pane_index}\t#{pane_id}\t#{pane_active}\t#{pane_width}\t#{pane_height}\t#{pane_current_command}")
+	if err != nil {
+		return nil, err
+	}
+
+	windows := make([]TmuxWindow, 0)
+	byIndex := make(map[int]int) // window index -> position in windows
+	for _, line := range splitTmuxLines(windowOutput) {
+		fields := strings.SplitN(line, "\t", 4)
+		if len(fields) != 4 {
+			continue
+		}
+		index, err := strconv.Atoi(fields[0])
+		if err != nil {
+			continue
+		}
+		byIndex[index] = len(windows)
+		windows = append(windows, TmuxWindow{
+			ID:     fields[1],
+			Index:  index,
+			Active: fields[2] == "1",
+			Name:   fields[3],
+			Panes:  []TmuxPane{},
+		})
+	}
+
+	for _, line := range splitTmuxLines(paneOutput) {
+		fields := strings.SplitN(line, "\t", 7)
+		if len(fields) != 7 {
+			continue
+		}
+		windowIndex, err := strconv.Atoi(fields[0])
+		pos, ok := byIndex[windowIndex]
+		if err != nil || !ok {
+			continue
+		}
+		paneIndex, _ := strconv.Atoi(fields[1])
+		width, _ := strconv.Atoi(fields[4])
+		height, _ := strconv.Atoi(fields[5])
+		windows[pos].Panes = append(windows[pos].Panes, TmuxPane{
+			ID:      fields[2],
+			Index:   paneIndex,
+			Active:  fields[3] == "1",
+			Width:   width,
+			Height:  height,
+			Command: fields[6],
+		})
+	}
+	return windows, nil
+}
+
+// CreateWindow opens a new window in the current pane's directory and
+// switches to it. An empty name lets tmux name the window after its command.
+func (s *TerminalSession) CreateWindow(windowName string) (TmuxWindow, error) {
+	if err := validateTmuxWindowName(windowName); err != nil {
+		return TmuxWindow{}, err
+	}
+	name, err := s.tmuxTarget()
+	if err != nil {
+		return TmuxWindow{}, err
+	}
+
+	args := []string{"new-window", "-t", name + ":", "-c", "#{pane_current_path}", "-P", "-F", "#{window_index}"}
+	if windowName != "" {
+		args = append(args, "-n", windowName)
+	}
+	output, err := s.runTmux(args...)
+	if err != nil {
+		return TmuxWindow{}, err
+	}
+	index, err := strconv.Atoi(strings.TrimSpace(output))
+	if err != nil {
+		return TmuxWindow{}, fmt.Errorf("unexpected tmux output %q", output)
+	}
+
+	windows, err := s.ListWindows()
+	if err != nil {
+		return TmuxWindow{}, err
+	}
+	for _, window := range windows {
+		if window.Index == index {
+			return window, nil
+		}
+	}
+	return TmuxWindow{}, errors.New("window not found")
+}
+
+// SelectWindow switches the session to the window with the given index
+func (s *TerminalSession) SelectWindow(index int) error {
+	name, err := s.tmuxTarget()
+	if err != nil {
+		return err
+	}
+	if err := s.checkWindowExists(index); err != nil {
+		return err
+	}
+	_, err = s.runTmux("select-window", "-t", name+":"+strconv.Itoa(index))
+	return err
+}
+
+// KillWindow closes the window with the given index. Closing the last
+// window ends the session.
+func (s *TerminalSession) KillWindow(index int) error {
+	name, err := s.tmuxTarget()
+	if err != nil {
+		return err
+	}
+	if err := s.checkWindowExists(index); err != nil {
+		return err
+	}
+	_, err = s.runTmux("kill-window", "-t", name+":"+strconv.Itoa(index))
+	return err
+}
+
+// SelectPane switches the active pane of the current window. target is a
+// direction (left, right, up, down, next, previous) or a pane ID such as "%3".
+func (s *TerminalSession) SelectPane(target string) error {
+	name, err := s.tmuxTarget()
+	if err != nil {
+		return err
+	}
+
+	if direction, ok := tmuxPaneDirections[target]; ok {
+		args := []string{"select-pane"}
+		if direction[0] == "-t" {
+			args = append(args, "-t", name+direction[1])
+		} else {
+			args = append(args, "-t", name+":", direction[0])
+		}
+		_, err := s.runTmux(args...)
+		return err
+	}
+
+	if !tmuxPaneIDPattern.MatchString(target) {
+		return fmt.Errorf("invalid pane %q: use left, right, up, down, next, previous or a pane ID", target)
+	}
+	windows, err := s.ListWindows()
+	if err != nil {
+		return err
+	}
+	for _, window := range windows {
+		for _, pane := range window.Panes {
+			if pane.ID != target {
+				continue
+			}
+			if _, err := s.runTmux("select-window", "-t", name+":"+strconv.Itoa(window.Index)); err != nil {
+				return err
+			}
+			_, err := s.runTmux("select-pane", "-t", target)
+			return err
+		}
+	}
+	return errors.New("pane not found")
+}
+
+// tmuxTarget returns the tmux session name, or ErrNotTmuxSession
+func (s *TerminalSession) tmuxTarget() (string, error) {
+	s.closeMu.RLock()
+	defer s.closeMu.RUnlock()
+
+	if s.closed {
+		return "", errors.New("session is closed")
+	}
+	if s.backend != SessionBackendTmux || s.tmuxSessionName == "" {
+		return "", ErrNotTmuxSession
+	}
+	return s.tmuxSessionName, nil
+}
+
+// checkWindowExists returns a "window not found" error for unknown indexes
+func (s *TerminalSession) checkWindowExists(index int) error {
+	windows, err := s.ListWindows()
+	if err != nil {
+		return err
+	}
+	for _, window := range windows {
+		if window.Index == index {
+			return nil
+		}
+	}
+	return errors.New("window not found")
+}
+
+// runTmux runs a tmux command against the session's tmux server
+func (s *TerminalSession) runTmux(args ...string) (string, error) {
+	output, err := newTmuxCommand(s.runAs, args...).CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("tmux %s failed: %s", args[0], strings.TrimSpace(string(output)))
+	}
+	return string(output), nil
+}
+
+// splitTmuxLines splits tmux output into non-empty lines
+func splitTmuxLines(output string) []string {
+	var lines []string
+	for _, line := range strings.Split(output, "\n") {
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+// validateTmuxWindowName rejects names that tmux would mangle
+func validateTmuxWindowName(name string) error {
+	if len(name) > maxTmuxWindowNameLength {
+		return fmt.Errorf("window name must be at most %d characters", maxTmuxWindowNameLength)
+	}
+	for _, char := range name {
+		if unicode.IsControl(char) {
+			return errors.New("window name must not contain control characters")
+		}
+	}
+	return nil
+}
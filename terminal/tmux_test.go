@@ -0,0 +1,167 @@
+package terminal
+
+import (
+	"os/exec"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("tmux windows and panes", func() {
+	var session *TerminalSession
+
+	BeforeEach(func() {
+		if _, err := exec.LookPath("tmux"); err != nil {
+			Skip("tmux is not installed")
+		}
+		// Use a private tmux server so tests never touch the user's sessions
+		GinkgoT().Setenv("TMUX_TMPDIR", GinkgoT().TempDir())
+		GinkgoT().Setenv("TMUX", "")
+
+		var err error
+		session, err = NewTerminalSession(SessionConfig{
+			ID:      "tmux-windows-test",
+			Shell:   "/bin/sh",
+			Backend: SessionBackendTmux,
+		})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(session.GetMetadata().Backend).To(Equal(SessionBackendTmux))
+		DeferCleanup(session.Close)
+
+		// The tmux client attaches asynchronously
+		Eventually(func() error {
+			_, err := session.ListWindows()
+			return err
+		}, "5s", "50ms").Should(Succeed())
+	})
+
+	It("should list, create, select and kill windows", func() {
+		windows, err := session.ListWindows()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(windows).To(HaveLen(1))
+		Expect(windows[0].Active).To(BeTrue())
+		Expect(windows[0].Panes).To(HaveLen(1))
+		first := windows[0].Index
+
+		created, err := session.CreateWindow("logs")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(created.Name).To(Equal("logs"))
+		Expect(created.Active).To(BeTrue())
+
+		Expect(session.SelectWindow(first)).To(Succeed())
+		windows, err = session.ListWindows()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(windows).To(HaveLen(2))
+		for _, window := range windows {
+			Expect(window.Active).To(Equal(window.Index == first))
+		}
+
+		Expect(session.KillWindow(created.Index)).To(Succeed())
+		Expect(session.KillWindow(created.Index)).To(MatchError("window not found"))
+		Expect(session.ListWindows()).To(HaveLen(1))
+	})
+
+	It("should select panes by direction or ID", func() {
+		windows, err := session.ListWindows()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(session.runTmux("split-window", "-t", session.tmuxSessionName+":")).Error().ToNot(HaveOccurred())
+
+		Expect(session.SelectPane(windows[0].Panes[0].ID)).To(Succeed())
+		windows, err = session.ListWindows()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(windows[0].Panes).To(HaveLen(2))
+		Expect(windows[0].Panes[0].Active).To(BeTrue())
+
+		Expect(session.SelectPane("next")).To(Succeed())
+		windows, err = session.ListWindows()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(windows[0].Panes[1].Active).To(BeTrue())
+
+		Expect(session.SelectPane("%99999")).To(MatchError("pane not found"))
+		Expect(session.SelectPane("sideways")).To(HaveOccurred())
+	})
+
+	It("should reject window names with control characters", func() {
+		_, err := session.CreateWindow("bad\nname")
+		Expect(err).To(HaveOccurred())
+	})
+})
+
+var _ = Describe("tmux windows on other backends", func() {
+	It("should report that the session is not a tmux session", func() {
+		session, err := NewTerminalSession(SessionConfig{
+			ID:         "pty-windows-test",
+			PTYService: &TrackingPTYService{},
+		})
+		Expect(err).ToNot(HaveOccurred())
+		defer session.Close()
+
+		_, err = session.ListWindows()
+		Expect(err).To(MatchError(ErrNotTmuxSession))
+		Expect(session.SelectPane("left")).To(MatchError(ErrNotTmuxSession))
+	})
+})
+
+var _ = Describe("host tmux sessions", func() {
+	BeforeEach(func() {
+		if _, err := exec.LookPath("tmux"); err != nil {
+			Skip("tmux is not installed")
+		}
+		GinkgoT().Setenv("TMUX_TMPDIR", GinkgoT().TempDir())
+		GinkgoT().Setenv("TMUX", "")
+		DeferCleanup(func() {
+			_ = exec.Command("tmux", "kill-server").Run()
+		})
+	})
+
+	It("should return an empty list when no tmux server is running", func() {
+		sessions, err := ListHostTmuxSessions(nil)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(sessions).To(BeEmpty())
+	})
+
+	It("should list and adopt an existing session without killing it on close", func() {
+		Expect(exec.Command("tmux", "new-session", "-d", "-s", "work", "/bin/sh").Run()).To(Succeed())
+
+		sessions, err := ListHostTmuxSessions(nil)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(sessions).To(HaveLen(1))
+		Expect(sessions[0].Name).To(Equal("work"))
+		Expect(sessions[0].Windows).To(Equal(1))
+		Expect(sessions[0].CreatedAt.IsZero()).To(BeFalse())
+
+		session, err := NewTerminalSession(SessionConfig{
+			ID:               "adopt-test",
+			Name:             "work",
+			Backend:          SessionBackendTmux,
+			AdoptTmuxSession: "work",
+		})
+		Expect(err).ToNot(HaveOccurred())
+		metadata := session.GetMetadata()
+		Expect(metadata.Backend).To(Equal(SessionBackendTmux))
+		Expect(metadata.TmuxSession).To(Equal("work"))
+		Expect(metadata.Adopted).To(BeTrue())
+
+		Eventually(func() int {
+			sessions, _ := ListHostTmuxSessions(nil)
+			if len(sessions) == 0 {
+				return 0
+			}
+			return sessions[0].Attached
+		}, "5s", "50ms").Should(Equal(1))
+
+		Expect(session.Close()).To(Succeed())
+		Expect(exec.Command("tmux", "has-session", "-t", "=work").Run()).To(Succeed())
+	})
+
+	It("should fail to adopt a session that does not exist", func() {
+		Expect(exec.Command("tmux", "new-session", "-d", "-s", "other", "/bin/sh").Run()).To(Succeed())
+
+		_, err := NewTerminalSession(SessionConfig{
+			ID:               "adopt-missing",
+			Backend:          SessionBackendTmux,
+			AdoptTmuxSession: "missing",
+		})
+		Expect(err).To(HaveOccurred())
+	})
+})
@@ -14,6 +14,10 @@ const (
 	SessionBackendPTY SessionBackend = "pty"
 	// SessionBackendTmux runs the session via tmux for robust reconnect behavior.
 	SessionBackendTmux SessionBackend = "tmux"
+	// SessionBackendScreen runs the session via GNU screen on hosts without tmux.
+	SessionBackendScreen SessionBackend = "screen"
+	// SessionBackendSSH connects to a shell on a remote host over SSH.
+	SessionBackendSSH SessionBackend = "ssh"
 )
 
 // HistoryProvider defines the interface for terminal output history storage
@@ -49,21 +53,36 @@ type Session interface {
 
 // ClientMessage represents a message from a WebSocket client
 type ClientMessage struct {
-	Type string `json:"type"` // "input" or "resize"
-	Data string `json:"data,omitempty"`
-	Cols int    `json:"cols,omitempty"`
-	Rows int    `json:"rows,omitempty"`
+	Type     string `json:"type"` // "input", "resize", "paste", "signal", "request_control", "grant_control" or "release_control"
+	Data     string `json:"data,omitempty"`
+	Cols     int    `json:"cols,omitempty"`
+	Rows     int    `json:"rows,omitempty"`
+	ClientID string `json:"client_id,omitempty"` // grant_control: the client receiving control
+	Signal   string `json:"signal,omitempty"`    // signal: "SIGINT", "SIGTERM" or "SIGKILL"
 }
 
 // SessionMetadata holds runtime information about a session
 type SessionMetadata struct {
-	Name             string         `json:"name"`
-	CreatedAt        time.Time      `json:"created_at"`
-	LastActivityAt   time.Time      `json:"last_activity_at"`
-	ClientCount      int            `json:"client_count"`
-	WorkingDirectory string         `json:"working_directory,omitempty"`
-	Backend          SessionBackend `json:"backend"`
-	BackendFallback  string         `json:"backend_fallback,omitempty"`
+	Name             string          `json:"name"`
+	CreatedAt        time.Time       `json:"created_at"`
+	LastActivityAt   time.Time       `json:"last_activity_at"`
+	ClientCount      int             `json:"client_count"`
+	WorkingDirectory string          `json:"working_directory,omitempty"`
+	CurrentDirectory string          `json:"current_directory,omitempty"` // where the shell is now, see cwd.go
+	Backend          SessionBackend  `json:"backend"`
+	BackendFallback  string          `json:"backend_fallback,omitempty"`
+	Limits           *ResourceLimits `json:"limits,omitempty"`
+	SSH              *SSHTarget      `json:"ssh,omitempty"`
+	TmuxSession      string          `json:"tmux_session,omitempty"` // tmux session name for the tmux backend
+	Adopted          bool            `json:"adopted,omitempty"`      // attached to a tmux session started outside terminal-hub
+	Tags             []string        `json:"tags,omitempty"`
+	InputMode        string          `json:"input_mode"` // "shared" or "single_writer"
+	ResizePolicy     ResizePolicy    `json:"resize_policy"`
+	Process          *ProcessInfo    `json:"process,omitempty"` // sampled periodically, see StartProcessSampler
+	Git              *GitInfo        `json:"git,omitempty"`     // sampled periodically, see StartGitSampler
+	OnExit           *ExitActions    `json:"on_exit,omitempty"`
+	Pinned           bool            `json:"pinned"`
+	SortOrder        int             `json:"sort_order"` // position chosen by the user, lower first
 }
 
 // CreateSessionRequest represents a request to create a new session
@@ -73,12 +92,58 @@ type CreateSessionRequest struct {
 	Command          string            `json:"command,omitempty"`           // Optional: Initial command to run
 	EnvVars          map[string]string `json:"env_vars,omitempty"`          // Optional: Environment variables
 	ShellPath        string            `json:"shell_path,omitempty"`        // Optional: Custom shell path
-	Backend          SessionBackend    `json:"backend,omitempty"`           // Optional: Session backend ("tmux" or "pty")
+	Backend          SessionBackend    `json:"backend,omitempty"`           // Optional: Session backend ("tmux", "screen", "pty" or "ssh")
+	Limits           *ResourceLimits   `json:"limits,omitempty"`            // Optional: OS-level resource limits for the shell
+	SSH              *SSHTarget        `json:"ssh,omitempty"`               // Required for the ssh backend: remote host to connect to
+	Tags             []string          `json:"tags,omitempty"`              // Optional: Tags for grouping and filtering
+	InputMode        string            `json:"input_mode,omitempty"`        // Optional: "shared" (default) or "single_writer"
+	ResizePolicy     *ResizePolicy     `json:"resize_policy,omitempty"`     // Optional: How the terminal size follows clients, latest by default
+	OnExit           *ExitActions      `json:"on_exit,omitempty"`           // Optional: Actions run when the shell exits on its own
+}
+
+// CloneSessionRequest represents a request to start a new session like an
+// existing one
+type CloneSessionRequest struct {
+	Name string `json:"name,omitempty"` // Optional: Defaults to the session's name with " (copy)"
+}
+
+// ChangeDirectoryRequest represents a request to move a session's shell to
+// another directory
+type ChangeDirectoryRequest struct {
+	Path string `json:"path"` // Required: Absolute path of the directory
+}
+
+// AdoptSessionRequest represents a request to attach to an existing tmux session
+type AdoptSessionRequest struct {
+	TmuxSession string `json:"tmux_session"`   // Required: Name of the tmux session
+	Name        string `json:"name,omitempty"` // Optional: User-friendly name, defaults to the tmux session name
 }
 
 // UpdateSessionRequest represents a request to update a session
 type UpdateSessionRequest struct {
-	Name string `json:"name"` // Required: New session name
+	Name         string        `json:"name,omitempty"`          // Optional: New session name
+	Tags         *[]string     `json:"tags,omitempty"`          // Optional: Replaces the session's tags
+	Pinned       *bool         `json:"pinned,omitempty"`        // Optional: Pins or unpins the session
+	InputMode    string        `json:"input_mode,omitempty"`    // Optional: "shared" or "single_writer"
+	ResizePolicy *ResizePolicy `json:"resize_policy,omitempty"` // Optional: Replaces the resize policy
+}
+
+// ReorderSessionsRequest sets the custom order of sessions. Listed sessions
+// come first in the given order, followed by the rest in their current order.
+type ReorderSessionsRequest struct {
+	SessionIDs []string `json:"session_ids"`
+}
+
+// SignalSessionRequest represents a request to signal a session's
+// foreground program
+type SignalSessionRequest struct {
+	Signal string `json:"signal"` // "SIGINT", "SIGTERM" or "SIGKILL"
+}
+
+// ExecSessionRequest represents a request to run a command in a session
+type ExecSessionRequest struct {
+	Command        string `json:"command"`                   // Required: a single line typed at the shell's prompt
+	TimeoutSeconds int    `json:"timeout_seconds,omitempty"` // Optional: how long to wait for it to finish, default 30
 }
 
 // SessionInfo represents information about a session for API responses
@@ -87,6 +152,13 @@ type SessionInfo struct {
 	Metadata SessionMetadata `json:"metadata"`
 }
 
+// SessionDetail represents a session with its attached clients
+type SessionDetail struct {
+	ID       string           `json:"id"`
+	Metadata SessionMetadata  `json:"metadata"`
+	Clients  []AttachedClient `json:"clients"`
+}
+
 // CreateSessionResponse represents the response when creating a session
 type CreateSessionResponse struct {
 	ID       string          `json:"id"`
@@ -0,0 +1,899 @@
+package terminal
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/width"
+)
+
+// Bounds of a vtScreen, so a bogus size or escape sequence cannot make the
+// parser allocate without limit
+const (
+	maxVTCols     = 1000
+	maxVTRows     = 500
+	maxVTParams   = 32
+	maxVTOSCSize  = 4096
+	maxVTCellSize = 32 // bytes of a character with its combining marks
+)
+
+// Parser states of a vtScreen
+const (
+	vtGround       = iota
+	vtEscape       // after ESC
+	vtEscapeSkip   // after ESC and an intermediate, such as ESC ( for charsets; the next byte is ignored
+	vtCSI          // in a control sequence, ESC [
+	vtOSC          // in an operating system command, ESC ]
+	vtOSCEscape    // ESC seen in an OSC, which is ST when followed by \
+	vtString       // in a DCS, SOS, PM or APC string, which is ignored
+	vtStringEscape // ESC seen in an ignored string
+)
+
+// vtCell is a character cell of a vtScreen
+type vtCell struct {
+	ch    string // the character and any combining marks; empty for a blank cell
+	cont  bool   // right half of a wide character
+	style CellStyle
+}
+
+// vtCursor is a cursor position and style saved by DECSC or mode 1049
+type vtCursor struct {
+	x, y  int
+	style CellStyle
+}
+
+// vtScreen is a VT100/xterm screen emulator, just enough of one to render
+// the current screen of a session from its output history. It has no
+// scrollback, and ignores input-related modes and replies.
+type vtScreen struct {
+	cols, rows int
+	lines      [][]vtCell // the active screen
+	main       [][]vtCell // the normal screen while the alternate one is active
+	altActive  bool
+
+	x, y         int
+	wrapPending  bool // the last character filled the last column; the next one wraps
+	style        CellStyle
+	saved        vtCursor
+	savedMain    vtCursor // saved by mode 1049
+	top, bottom  int      // scrolling region
+	cursorHidden bool
+	noAutowrap   bool
+	title        string
+	directory    string // reported by the shell with OSC 7, kept by resets
+	lastChar     string // repeated by REP
+
+	state   int
+	pending []byte // bytes of an incomplete UTF-8 character
+	seq     []byte // parameters and intermediates of a control sequence
+	osc     []byte
+}
+
+// newVTScreen returns a blank screen of the given size
+func newVTScreen(cols, rows int) *vtScreen {
+	cols = min(max(cols, 1), maxVTCols)
+	rows = min(max(rows, 1), maxVTRows)
+	s := &vtScreen{cols: cols, rows: rows}
+	s.reset()
+	return s
+}
+
+// reset returns the screen to its initial state
+func (s *vtScreen) reset() {
+	s.lines = s.blankLines(s.rows)
+	s.main = nil
+	s.altActive = false
+	s.x, s.y = 0, 0
+	s.wrapPending = false
+	s.style = CellStyle{}
+	s.saved = vtCursor{}
+	s.savedMain = vtCursor{}
+	s.top, s.bottom = 0, s.rows-1
+	s.cursorHidden = false
+	s.noAutowrap = false
+	s.title = ""
+	s.lastChar = ""
+}
+
+func (s *vtScreen) blankLine() []vtCell {
+	line := make([]vtCell, s.cols)
+	if s.style.Bg != "" {
+		for i := range line {
+			line[i].style.Bg = s.style.Bg
+		}
+	}
+	return line
+}
+
+func (s *vtScreen) blankLines(n int) [][]vtCell {
+	lines := make([][]vtCell, n)
+	for i := range lines {
+		lines[i] = s.blankLine()
+	}
+	return lines
+}
+
+// Write feeds terminal output to the screen. It never fails.
+func (s *vtScreen) Write(p []byte) (int, error) {
+	for _, b := range p {
+		s.feed(b)
+	}
+	return len(p), nil
+}
+
+func (s *vtScreen) feed(b byte) {
+	switch s.state {
+	case vtGround:
+		if len(s.pending) > 0 || b >= 0x80 {
+			s.feedUTF8(b)
+			return
+		}
+		if b < 0x20 || b == 0x7f {
+			s.control(b)
+			return
+		}
+		s.print(string(rune(b)))
+	case vtEscape:
+		s.escape(b)
+	case vtEscapeSkip:
+		s.state = vtGround
+	case vtCSI:
+		switch {
+		case b == 0x1b:
+			s.state = vtEscape
+		case b < 0x20:
+			s.control(b)
+		case b >= 0x40 && b <= 0x7e:
+			s.state = vtGround
+			s.csi(b)
+		case len(s.seq) < 4*maxVTParams:
+			s.seq = append(s.seq, b)
+		}
+	case vtOSC:
+		switch b {
+		case 0x07:
+			s.state = vtGround
+			s.oscDone()
+		case 0x1b:
+			s.state = vtOSCEscape
+		default:
+			if len(s.osc) < maxVTOSCSize {
+				s.osc = append(s.osc, b)
+			}
+		}
+	case vtOSCEscape:
+		s.state = vtGround
+		s.oscDone()
+		if b != '\\' {
+			s.feed(0x1b)
+			s.feed(b)
+		}
+	case vtString:
+		switch b {
+		case 0x07:
+			s.state = vtGround
+		case 0x1b:
+			s.state = vtStringEscape
+		}
+	case vtStringEscape:
+		s.state = vtString
+		if b == '\\' {
+			s.state = vtGround
+		}
+	}
+}
+
+// feedUTF8 collects the bytes of a multi-byte character. Invalid sequences
+// print as U+FFFD.
+func (s *vtScreen) feedUTF8(b byte) {
+	if len(s.pending) > 0 && (b < 0x80 || b >= 0xc0) {
+		// A new character before the last one was complete
+		s.pending = s.pending[:0]
+		s.print(string(utf8.RuneError))
+		s.feed(b)
+		return
+	}
+	s.pending = append(s.pending, b)
+	if !utf8.FullRune(s.pending) {
+		return
+	}
+	r, _ := utf8.DecodeRune(s.pending)
+	s.pending = s.pending[:0]
+	s.print(string(r))
+}
+
+// control executes a C0 control character
+func (s *vtScreen) control(b byte) {
+	switch b {
+	case '\b':
+		if s.x > 0 {
+			s.x--
+		}
+		s.wrapPending = false
+	case '\t':
+		s.x = min((s.x/8+1)*8, s.cols-1)
+		s.wrapPending = false
+	case '\n', '\v', '\f':
+		s.lineFeed()
+	case '\r':
+		s.x = 0
+		s.wrapPending = false
+	case 0x1b:
+		s.state = vtEscape
+	}
+}
+
+func (s *vtScreen) escape(b byte) {
+	s.state = vtGround
+	switch b {
+	case '[':
+		s.state = vtCSI
+		s.seq = s.seq[:0]
+	case ']':
+		s.state = vtOSC
+		s.osc = s.osc[:0]
+	case 'P', 'X', '^', '_':
+		s.state = vtString
+	case '(', ')', '*', '+', '-', '.', '/', '#', '%', ' ':
+		s.state = vtEscapeSkip
+	case '7':
+		s.saveCursor()
+	case '8':
+		s.restoreCursor()
+	case 'D':
+		s.lineFeed()
+	case 'E':
+		s.x = 0
+		s.lineFeed()
+	case 'M':
+		s.reverseIndex()
+	case 'c':
+		s.reset()
+	}
+}
+
+// oscDone handles a complete OSC; window titles and the working directory
+// reported by the shell are kept
+func (s *vtScreen) oscDone() {
+	command, text, ok := strings.Cut(string(s.osc), ";")
+	switch {
+	case ok && (command == "0" || command == "2"):
+		s.title = strings.Map(func(r rune) rune {
+			if unicode.IsControl(r) {
+				return -1
+			}
+			return r
+		}, strings.ToValidUTF8(text, string(utf8.RuneError)))
+	case ok && command == "7":
+		if dir, ok := parseOSC7(text); ok {
+			s.directory = dir
+		}
+	}
+}
+
+// charWidth returns the number of columns r takes: 0 for combining marks,
+// 2 for wide East Asian characters and most emoji
+func charWidth(r rune) int {
+	if unicode.In(r, unicode.Mn, unicode.Me) || r == 0x200b || r == 0x200d {
+		return 0
+	}
+	switch width.LookupRune(r).Kind() {
+	case width.EastAsianWide, width.EastAsianFullwidth:
+		return 2
+	}
+	return 1
+}
+
+// print puts a character at the cursor and advances it
+func (s *vtScreen) print(ch string) {
+	r, _ := utf8.DecodeRuneInString(ch)
+	w := charWidth(r)
+	if w == 0 {
+		// Combine with the character before the cursor
+		x := s.x
+		if !s.wrapPending && x > 0 {
+			x--
+		}
+		if x < s.cols && s.lines[s.y][x].cont && x > 0 {
+			x--
+		}
+		if cell := &s.lines[s.y][x]; cell.ch != "" && len(cell.ch) < maxVTCellSize {
+			cell.ch += ch
+		}
+		return
+	}
+
+	if s.wrapPending || (w == 2 && s.x == s.cols-1) {
+		if s.noAutowrap {
+			if w == 2 {
+				return
+			}
+		} else {
+			s.x = 0
+			s.lineFeed()
+		}
+		s.wrapPending = false
+	}
+	if w > s.cols {
+		return
+	}
+
+	s.clearWide(s.y, s.x)
+	s.lines[s.y][s.x] = vtCell{ch: ch, style: s.style}
+	if w == 2 {
+		s.clearWide(s.y, s.x+1)
+		s.lines[s.y][s.x+1] = vtCell{cont: true, style: s.style}
+	}
+	s.lastChar = ch
+
+	if s.x+w >= s.cols {
+		s.x = s.cols - 1
+		s.wrapPending = !s.noAutowrap
+		return
+	}
+	s.x += w
+}
+
+// clearWide blanks the other half of a wide character at (y, x), which is
+// about to be overwritten
+func (s *vtScreen) clearWide(y, x int) {
+	line := s.lines[y]
+	if line[x].cont && x > 0 {
+		line[x-1] = vtCell{style: line[x-1].style}
+	}
+	if x+1 < s.cols && line[x+1].cont {
+		line[x+1] = vtCell{style: line[x+1].style}
+	}
+}
+
+func (s *vtScreen) lineFeed() {
+	s.wrapPending = false
+	switch {
+	case s.y == s.bottom:
+		s.scrollUp(s.top, s.bottom, 1)
+	case s.y < s.rows-1:
+		s.y++
+	}
+}
+
+func (s *vtScreen) reverseIndex() {
+	s.wrapPending = false
+	switch {
+	case s.y == s.top:
+		s.scrollDown(s.top, s.bottom, 1)
+	case s.y > 0:
+		s.y--
+	}
+}
+
+// scrollUp moves lines top to bottom up by n, blanking the lines at the bottom
+func (s *vtScreen) scrollUp(top, bottom, n int) {
+	n = min(n, bottom-top+1)
+	copy(s.lines[top:bottom+1], s.lines[top+n:bottom+1])
+	for i := bottom - n + 1; i <= bottom; i++ {
+		s.lines[i] = s.blankLine()
+	}
+}
+
+// scrollDown moves lines top to bottom down by n, blanking the lines at the top
+func (s *vtScreen) scrollDown(top, bottom, n int) {
+	n = min(n, bottom-top+1)
+	copy(s.lines[top+n:bottom+1], s.lines[top:bottom+1-n])
+	for i := top; i < top+n; i++ {
+		s.lines[i] = s.blankLine()
+	}
+}
+
+// eraseCells blanks the cells from x0 up to x1 of line y
+func (s *vtScreen) eraseCells(y, x0, x1 int) {
+	x0, x1 = max(x0, 0), min(x1, s.cols)
+	if x0 >= x1 {
+		return
+	}
+	s.clearWide(y, x0)
+	s.clearWide(y, x1-1)
+	blank := vtCell{style: CellStyle{Bg: s.style.Bg}}
+	for x := x0; x < x1; x++ {
+		s.lines[y][x] = blank
+	}
+}
+
+func (s *vtScreen) saveCursor() {
+	s.saved = vtCursor{x: s.x, y: s.y, style: s.style}
+}
+
+func (s *vtScreen) restoreCursor() {
+	s.x, s.y, s.style = min(s.saved.x, s.cols-1), min(s.saved.y, s.rows-1), s.saved.style
+	s.wrapPending = false
+}
+
+// setAltScreen switches between the normal and the alternate screen
+func (s *vtScreen) setAltScreen(on bool) {
+	if on == s.altActive {
+		return
+	}
+	s.altActive = on
+	if on {
+		s.main = s.lines
+		s.lines = s.blankLines(s.rows)
+		return
+	}
+	s.lines = s.main
+	s.main = nil
+}
+
+// vtParams are the parameters of a control sequence. Each holds its
+// colon-separated sub-parameters; missing numbers are 0.
+type vtParams [][]int
+
+// get returns parameter i, or def when it is missing or 0
+func (p vtParams) get(i, def int) int {
+	if i >= len(p) || p[i][0] == 0 {
+		return def
+	}
+	return p[i][0]
+}
+
+// parseVTParams splits a control sequence's parameter bytes
+func parseVTParams(raw string) vtParams {
+	var params vtParams
+	for i, field := range strings.Split(raw, ";") {
+		if i == maxVTParams {
+			break
+		}
+		var sub []int
+		for _, part := range strings.Split(field, ":") {
+			n, _ := strconv.Atoi(part)
+			sub = append(sub, min(n, 65535))
+		}
+		params = append(params, sub)
+	}
+	return params
+}
+
+// csi executes a control sequence ending in final
+func (s *vtScreen) csi(final byte) {
+	raw := string(s.seq)
+	var private byte
+	if raw != "" && strings.IndexByte("<=>?", raw[0]) >= 0 {
+		private, raw = raw[0], raw[1:]
+	}
+	if strings.IndexFunc(raw, func(r rune) bool { return r < 0x30 }) >= 0 {
+		// Sequences with intermediates, such as DECSCUSR, change nothing shown
+		return
+	}
+	p := parseVTParams(raw)
+
+	if private == '?' {
+		if final == 'h' || final == 'l' {
+			for _, mode := range p {
+				s.setPrivateMode(mode[0], final == 'h')
+			}
+		}
+		return
+	}
+	if private != 0 {
+		return
+	}
+
+	n := p.get(0, 1)
+	switch final {
+	case 'A':
+		s.moveTo(s.x, s.clampUp(s.y-n))
+	case 'B', 'e':
+		s.moveTo(s.x, s.clampDown(s.y+n))
+	case 'C', 'a':
+		s.moveTo(s.x+n, s.y)
+	case 'D':
+		s.moveTo(s.x-n, s.y)
+	case 'E':
+		s.moveTo(0, s.clampDown(s.y+n))
+	case 'F':
+		s.moveTo(0, s.clampUp(s.y-n))
+	case 'G', '`':
+		s.moveTo(n-1, s.y)
+	case 'd':
+		s.moveTo(s.x, n-1)
+	case 'H', 'f':
+		s.moveTo(p.get(1, 1)-1, n-1)
+	case 'J':
+		s.eraseDisplay(p.get(0, 0))
+	case 'K':
+		switch p.get(0, 0) {
+		case 0:
+			s.eraseCells(s.y, s.x, s.cols)
+		case 1:
+			s.eraseCells(s.y, 0, s.x+1)
+		case 2:
+			s.eraseCells(s.y, 0, s.cols)
+		}
+	case 'X':
+		s.eraseCells(s.y, s.x, s.x+n)
+	case '@':
+		s.insertCells(n)
+	case 'P':
+		s.deleteCells(n)
+	case 'L':
+		if s.y >= s.top && s.y <= s.bottom {
+			s.scrollDown(s.y, s.bottom, n)
+			s.x = 0
+		}
+	case 'M':
+		if s.y >= s.top && s.y <= s.bottom {
+			s.scrollUp(s.y, s.bottom, n)
+			s.x = 0
+		}
+	case 'S':
+		s.scrollUp(s.top, s.bottom, n)
+	case 'T':
+		if len(p) <= 1 {
+			s.scrollDown(s.top, s.bottom, n)
+		}
+	case 'b':
+		if s.lastChar != "" {
+			for i := 0; i < min(n, s.cols*s.rows); i++ {
+				s.print(s.lastChar)
+			}
+		}
+	case 'r':
+		top, bottom := p.get(0, 1)-1, min(p.get(1, s.rows), s.rows)-1
+		if top < bottom {
+			s.top, s.bottom = top, bottom
+			s.moveTo(0, 0)
+		}
+	case 's':
+		s.saveCursor()
+	case 'u':
+		s.restoreCursor()
+	case 'm':
+		s.sgr(p)
+	}
+}
+
+// clampUp limits an upward move to the top margin when starting below it
+func (s *vtScreen) clampUp(y int) int {
+	if s.y >= s.top {
+		return max(y, s.top)
+	}
+	return y
+}
+
+// clampDown limits a downward move to the bottom margin when starting above it
+func (s *vtScreen) clampDown(y int) int {
+	if s.y <= s.bottom {
+		return min(y, s.bottom)
+	}
+	return y
+}
+
+func (s *vtScreen) moveTo(x, y int) {
+	s.x = min(max(x, 0), s.cols-1)
+	s.y = min(max(y, 0), s.rows-1)
+	s.wrapPending = false
+}
+
+func (s *vtScreen) eraseDisplay(mode int) {
+	switch mode {
+	case 0:
+		s.eraseCells(s.y, s.x, s.cols)
+		for y := s.y + 1; y < s.rows; y++ {
+			s.eraseCells(y, 0, s.cols)
+		}
+	case 1:
+		for y := 0; y < s.y; y++ {
+			s.eraseCells(y, 0, s.cols)
+		}
+		s.eraseCells(s.y, 0, s.x+1)
+	case 2, 3:
+		for y := 0; y < s.rows; y++ {
+			s.eraseCells(y, 0, s.cols)
+		}
+	}
+}
+
+func (s *vtScreen) insertCells(n int) {
+	line := s.lines[s.y]
+	n = min(n, s.cols-s.x)
+	s.clearWide(s.y, s.x)
+	copy(line[s.x+n:], line[s.x:s.cols-n])
+	s.eraseCells(s.y, s.x, s.x+n)
+	// A wide character pushed into the last column lost its right half
+	if r, _ := utf8.DecodeRuneInString(line[s.cols-1].ch); line[s.cols-1].ch != "" && charWidth(r) == 2 {
+		line[s.cols-1] = vtCell{style: line[s.cols-1].style}
+	}
+}
+
+func (s *vtScreen) deleteCells(n int) {
+	line := s.lines[s.y]
+	n = min(n, s.cols-s.x)
+	s.clearWide(s.y, s.x)
+	s.clearWide(s.y, s.x+n-1)
+	copy(line[s.x:], line[s.x+n:])
+	s.eraseCells(s.y, s.cols-n, s.cols)
+}
+
+func (s *vtScreen) setPrivateMode(mode int, on bool) {
+	switch mode {
+	case 7:
+		s.noAutowrap = !on
+	case 25:
+		s.cursorHidden = !on
+	case 47, 1047:
+		s.setAltScreen(on)
+	case 1049:
+		if on {
+			s.savedMain = vtCursor{x: s.x, y: s.y, style: s.style}
+			s.setAltScreen(true)
+			return
+		}
+		s.setAltScreen(false)
+		s.x, s.y, s.style = s.savedMain.x, s.savedMain.y, s.savedMain.style
+		s.wrapPending = false
+	}
+}
+
+// sgr applies Select Graphic Rendition parameters to the current style
+func (s *vtScreen) sgr(p vtParams) {
+	if len(p) == 0 {
+		s.style = CellStyle{}
+		return
+	}
+	for i := 0; i < len(p); i++ {
+		switch code := p[i][0]; {
+		case code == 0:
+			s.style = CellStyle{}
+		case code == 1:
+			s.style.Bold = true
+		case code == 2:
+			s.style.Dim = true
+		case code == 3:
+			s.style.Italic = true
+		case code == 4:
+			s.style.Underline = len(p[i]) == 1 || p[i][1] != 0
+		case code == 5 || code == 6:
+			s.style.Blink = true
+		case code == 7:
+			s.style.Inverse = true
+		case code == 8:
+			s.style.Hidden = true
+		case code == 9:
+			s.style.Strikethrough = true
+		case code == 21:
+			s.style.Underline = true
+		case code == 22:
+			s.style.Bold, s.style.Dim = false, false
+		case code == 23:
+			s.style.Italic = false
+		case code == 24:
+			s.style.Underline = false
+		case code == 25:
+			s.style.Blink = false
+		case code == 27:
+			s.style.Inverse = false
+		case code == 28:
+			s.style.Hidden = false
+		case code == 29:
+			s.style.Strikethrough = false
+		case code >= 30 && code <= 37:
+			s.style.Fg = strconv.Itoa(code - 30)
+		case code == 39:
+			s.style.Fg = ""
+		case code >= 40 && code <= 47:
+			s.style.Bg = strconv.Itoa(code - 40)
+		case code == 49:
+			s.style.Bg = ""
+		case code >= 90 && code <= 97:
+			s.style.Fg = strconv.Itoa(code - 90 + 8)
+		case code >= 100 && code <= 107:
+			s.style.Bg = strconv.Itoa(code - 100 + 8)
+		case code == 38 || code == 48 || code == 58:
+			var color string
+			color, i = extendedColor(p, i)
+			switch code {
+			case 38:
+				s.style.Fg = color
+			case 48:
+				s.style.Bg = color
+			}
+		}
+	}
+}
+
+// extendedColor parses the color of SGR 38, 48 or 58 at p[i], given as
+// 38;5;N or 38;2;R;G;B, or with colons in one parameter. It returns the
+// color and the index of the last parameter used.
+func extendedColor(p vtParams, i int) (string, int) {
+	var args []int
+	if len(p[i]) > 1 {
+		args = p[i][1:]
+	}
+	last := i
+	if len(args) == 0 {
+		// Semicolon form: the arguments are the following parameters
+		for j := i + 1; j < len(p) && j <= i+4; j++ {
+			args = append(args, p[j][0])
+		}
+		switch {
+		case len(args) >= 2 && args[0] == 5:
+			last = i + 2
+		case len(args) >= 4 && args[0] == 2:
+			last = i + 4
+		default:
+			return "", len(p)
+		}
+	} else if len(args) >= 5 && args[0] == 2 {
+		// 38:2:colorspace:R:G:B
+		args = append(args[:1], args[2:]...)
+	}
+
+	switch {
+	case len(args) >= 2 && args[0] == 5:
+		return strconv.Itoa(min(args[1], 255)), last
+	case len(args) >= 4 && args[0] == 2:
+		return fmt.Sprintf("#%02x%02x%02x", min(args[1], 255), min(args[2], 255), min(args[3], 255)), last
+	}
+	return "", last
+}
+
+// resize changes the screen's size, keeping text at the top left. Lines
+// above the cursor are dropped when rows shrink, so it stays on screen.
+func (s *vtScreen) resize(cols, rows int) {
+	cols = min(max(cols, 1), maxVTCols)
+	rows = min(max(rows, 1), maxVTRows)
+	if cols == s.cols && rows == s.rows {
+		return
+	}
+
+	resizeLines := func(lines [][]vtCell, cursorY int) ([][]vtCell, int) {
+		drop := max(cursorY-(rows-1), 0)
+		lines = lines[drop:]
+		resized := make([][]vtCell, rows)
+		for y := range resized {
+			line := make([]vtCell, cols)
+			if y < len(lines) {
+				copy(line, lines[y])
+				// A wide character cut in half by the new width
+				if cols < len(lines[y]) && lines[y][cols].cont {
+					line[cols-1] = vtCell{style: line[cols-1].style}
+				}
+			}
+			resized[y] = line
+		}
+		return resized, drop
+	}
+
+	var drop int
+	s.lines, drop = resizeLines(s.lines, s.y)
+	s.y -= drop
+	if s.altActive {
+		s.main, drop = resizeLines(s.main, s.savedMain.y)
+		s.savedMain.y -= drop
+	}
+	s.cols, s.rows = cols, rows
+	s.top, s.bottom = 0, rows-1
+	s.moveTo(s.x, s.y)
+	s.saved.x, s.saved.y = min(s.saved.x, cols-1), min(s.saved.y, rows-1)
+	s.savedMain.x, s.savedMain.y = min(s.savedMain.x, cols-1), min(s.savedMain.y, rows-1)
+}
+
+// repaint returns output that draws the screen, with its modes and cursor,
+// on a terminal of the same size whatever that terminal showed before. It
+// returns nil in the middle of an escape sequence or character, which the
+// output that follows would complete.
+func (s *vtScreen) repaint() []byte {
+	if s.state != vtGround || len(s.pending) > 0 {
+		return nil
+	}
+	var b strings.Builder
+	// Leave any alternate screen and scrolling region, then draw the normal
+	// screen
+	b.WriteString("\x1b[?1049l\x1b[r\x1b[0m\x1b[H\x1b[2J")
+	if s.altActive {
+		writeVTLines(&b, s.main)
+		writeVTCursor(&b, s.savedMain)
+		b.WriteString("\x1b[?1049h\x1b[0m\x1b[H\x1b[2J")
+	}
+	writeVTLines(&b, s.lines)
+
+	writeVTCursor(&b, s.saved)
+	b.WriteString("\x1b7")
+	if s.top != 0 || s.bottom != s.rows-1 {
+		fmt.Fprintf(&b, "\x1b[%d;%dr", s.top+1, s.bottom+1)
+	}
+	writeVTCursor(&b, vtCursor{x: s.x, y: s.y, style: s.style})
+	if s.cursorHidden {
+		b.WriteString("\x1b[?25l")
+	} else {
+		b.WriteString("\x1b[?25h")
+	}
+	if s.noAutowrap {
+		b.WriteString("\x1b[?7l")
+	} else {
+		b.WriteString("\x1b[?7h")
+	}
+	if s.title != "" {
+		fmt.Fprintf(&b, "\x1b]2;%s\x07", s.title)
+	}
+	return []byte(b.String())
+}
+
+// writeVTLines draws lines on a cleared screen, leaving the style reset
+func writeVTLines(b *strings.Builder, lines [][]vtCell) {
+	for y, line := range lines {
+		end := len(line)
+		for end > 0 && line[end-1] == (vtCell{}) {
+			end--
+		}
+		if end == 0 {
+			continue
+		}
+		fmt.Fprintf(b, "\x1b[%d;1H", y+1)
+		var style CellStyle
+		for _, cell := range line[:end] {
+			if cell.cont {
+				continue
+			}
+			if cell.style != style {
+				b.WriteString(cell.style.sgr())
+				style = cell.style
+			}
+			if cell.ch == "" {
+				b.WriteByte(' ')
+			} else {
+				b.WriteString(cell.ch)
+			}
+		}
+		if style != (CellStyle{}) {
+			b.WriteString("\x1b[0m")
+		}
+	}
+}
+
+// writeVTCursor moves the cursor and sets the current style
+func writeVTCursor(b *strings.Builder, cursor vtCursor) {
+	fmt.Fprintf(b, "\x1b[%d;%dH%s", cursor.y+1, cursor.x+1, cursor.style.sgr())
+}
+
+// sgr returns the SGR sequence that selects the style from any other
+func (c CellStyle) sgr() string {
+	codes := []string{"0"}
+	for _, attr := range []struct {
+		on   bool
+		code string
+	}{
+		{c.Bold, "1"}, {c.Dim, "2"}, {c.Italic, "3"}, {c.Underline, "4"}, {c.Blink, "5"},
+		{c.Inverse, "7"}, {c.Hidden, "8"}, {c.Strikethrough, "9"},
+	} {
+		if attr.on {
+			codes = append(codes, attr.code)
+		}
+	}
+	if c.Fg != "" {
+		codes = append(codes, sgrColor(c.Fg, 30, 90, "38"))
+	}
+	if c.Bg != "" {
+		codes = append(codes, sgrColor(c.Bg, 40, 100, "48"))
+	}
+	return "\x1b[" + strings.Join(codes, ";") + "m"
+}
+
+// sgrColor returns the SGR parameters selecting color, with base and
+// bright the codes of palette colors 0 and 8 and extended the code of
+// 256-color and RGB colors
+func sgrColor(color string, base, bright int, extended string) string {
+	var r, g, bl int
+	if _, err := fmt.Sscanf(color, "#%02x%02x%02x", &r, &g, &bl); err == nil {
+		return fmt.Sprintf("%s;2;%d;%d;%d", extended, r, g, bl)
+	}
+	n, _ := strconv.Atoi(color)
+	switch {
+	case n < 8:
+		return strconv.Itoa(base + n)
+	case n < 16:
+		return strconv.Itoa(bright + n - 8)
+	}
+	return fmt.Sprintf("%s;5;%d", extended, n)
+}
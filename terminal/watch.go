@@ -0,0 +1,440 @@
+package terminal
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// WatchRuleType selects what a watch rule reacts to
+type WatchRuleType string
+
+const (
+	// WatchRulePattern fires when output matches a regular expression
+	WatchRulePattern WatchRuleType = "pattern"
+	// WatchRuleSilence fires once the session has produced no output for a while
+	WatchRuleSilence WatchRuleType = "silence"
+	// WatchRuleBell fires when a program rings the terminal bell
+	WatchRuleBell WatchRuleType = "bell"
+)
+
+const (
+	// MaxWatchRules is the maximum number of watch rules per session
+	MaxWatchRules = 32
+
+	maxWatchPatternLength     = 1024
+	watchPatternTail          = 256         // bytes of earlier output kept so matches split across reads are found
+	watchRuleCooldown         = time.Second // minimum time between events of one rule
+	watchSilenceCheckInterval = time.Second
+)
+
+// eventWebhookClient posts session events to webhooks
+var eventWebhookClient = &http.Client{Timeout: 10 * time.Second}
+
+// WatchRule describes a condition on a session's output that raises an event
+type WatchRule struct {
+	ID             string        `json:"id"`
+	Type           WatchRuleType `json:"type"`
+	Pattern        string        `json:"pattern,omitempty"`         // RE2 regular expression, for pattern rules
+	SilenceSeconds int           `json:"silence_seconds,omitempty"` // quiet period, for silence rules
+	WebhookURL     string        `json:"webhook_url,omitempty"`     // optional: receives a JSON POST of the SessionEvent
+	Channels       []string      `json:"channels,omitempty"`        // optional: names of channels in the notification channels file
+	Once           bool          `json:"once,omitempty"`            // remove the rule after it fires
+	CreatedAt      int64         `json:"created_at"`                // unix timestamp
+}
+
+// Validate checks that a watch rule is complete and well-formed
+func (r WatchRule) Validate() error {
+	switch r.Type {
+	case WatchRulePattern:
+		if r.Pattern == "" {
+			return errors.New("pattern is required for pattern rules")
+		}
+		if len(r.Pattern) > maxWatchPatternLength {
+			return fmt.Errorf("pattern must be at most %d characters", maxWatchPatternLength)
+		}
+		if _, err := regexp.Compile(r.Pattern); err != nil {
+			return fmt.Errorf("invalid pattern: %w", err)
+		}
+	case WatchRuleSilence:
+		if r.SilenceSeconds <= 0 {
+			return errors.New("silence_seconds must be positive for silence rules")
+		}
+	case WatchRuleBell:
+	default:
+		return fmt.Errorf("type must be %q, %q or %q", WatchRulePattern, WatchRuleSilence, WatchRuleBell)
+	}
+
+	if r.WebhookURL != "" {
+		parsed, err := url.Parse(r.WebhookURL)
+		if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			return fmt.Errorf("invalid webhook URL %q: must be an absolute http(s) URL", r.WebhookURL)
+		}
+	}
+	return nil
+}
+
+// WatchRuleManager is implemented by sessions that support watch rules
+type WatchRuleManager interface {
+	AddWatchRule(rule WatchRule) (WatchRule, error)
+	ListWatchRules() []WatchRule
+	RemoveWatchRule(id string) error
+}
+
+// watchState is a rule with its compiled pattern and firing state
+type watchState struct {
+	rule         WatchRule
+	re           *regexp.Regexp
+	armedAt      time.Time // when the rule was added, so silence counts from then at the earliest
+	lastFired    time.Time
+	silenceFired bool // fired for the current quiet period
+}
+
+// watchFiring is a rule that fired, with the output that matched
+type watchFiring struct {
+	rule  WatchRule
+	match string
+}
+
+// escape sequence parser states used to find plain text and bells in output
+const (
+	scanGround = iota
+	scanEscape
+	scanCSI
+	scanString    // OSC, DCS, SOS, PM or APC body
+	scanStringEsc // ESC inside a string, possibly starting ST
+)
+
+// sessionWatcher evaluates watch rules against a session's output
+type sessionWatcher struct {
+	mu         sync.Mutex
+	rules      []*watchState
+	tail       []byte // plain text of recent output
+	scanState  int
+	lastOutput time.Time
+	stop       chan struct{}
+	checking   bool // the silence checker is running
+
+	fire func(rule WatchRule, match string)
+}
+
+func newSessionWatcher(fire func(rule WatchRule, match string)) *sessionWatcher {
+	return &sessionWatcher{
+		lastOutput: time.Now(),
+		stop:       make(chan struct{}),
+		fire:       fire,
+	}
+}
+
+// add registers a rule, assigning its ID
+func (w *sessionWatcher) add(rule WatchRule) (WatchRule, error) {
+	if err := rule.Validate(); err != nil {
+		return WatchRule{}, err
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if len(w.rules) >= MaxWatchRules {
+		return WatchRule{}, fmt.Errorf("a session can have at most %d watch rules", MaxWatchRules)
+	}
+	now := time.Now()
+	rule.ID = uuid.New().String()
+	rule.CreatedAt = now.Unix()
+	state := &watchState{rule: rule, armedAt: now}
+	if rule.Type == WatchRulePattern {
+		state.re = regexp.MustCompile(rule.Pattern)
+	}
+	w.rules = append(w.rules, state)
+
+	if rule.Type == WatchRuleSilence && !w.checking {
+		w.checking = true
+		go w.silenceLoop()
+	}
+	return rule, nil
+}
+
+// list returns the rules in the order they were added
+func (w *sessionWatcher) list() []WatchRule {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	rules := make([]WatchRule, 0, len(w.rules))
+	for _, state := range w.rules {
+		rules = append(rules, state.rule)
+	}
+	return rules
+}
+
+// remove deletes a rule by ID
+func (w *sessionWatcher) remove(id string) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if !w.removeLocked(id) {
+		return errors.New("watch rule not found")
+	}
+	return nil
+}
+
+// removeLocked deletes a rule by ID, reporting whether it existed. Must be
+// called with w.mu held.
+func (w *sessionWatcher) removeLocked(id string) bool {
+	for i, state := range w.rules {
+		if state.rule.ID == id {
+			w.rules = append(w.rules[:i], w.rules[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// observe evaluates pattern and bell rules against a chunk of output and
+// re-arms silence rules
+func (w *sessionWatcher) observe(data []byte) {
+	if w == nil {
+		return
+	}
+	w.mu.Lock()
+	now := time.Now()
+	w.lastOutput = now
+	if len(w.rules) == 0 {
+		w.mu.Unlock()
+		return
+	}
+
+	text, bell := w.scan(data)
+	buf := append(w.tail, text...)
+	tailLength := len(w.tail)
+
+	var fired []watchFiring
+	for _, state := range w.rules {
+		match := ""
+		switch state.rule.Type {
+		case WatchRuleSilence:
+			state.silenceFired = false
+			continue
+		case WatchRuleBell:
+			if !bell {
+				continue
+			}
+		case WatchRulePattern:
+			// Only report matches that include new output
+			found := false
+			for _, loc := range state.re.FindAllIndex(buf, -1) {
+				if loc[1] > tailLength && loc[1] > loc[0] {
+					match = string(buf[loc[0]:loc[1]])
+					found = true
+					break
+				}
+			}
+			if !found {
+				continue
+			}
+		}
+		if now.Sub(state.lastFired) < watchRuleCooldown {
+			continue
+		}
+		state.lastFired = now
+		fired = append(fired, watchFiring{rule: state.rule, match: match})
+	}
+
+	if len(buf) > watchPatternTail {
+		buf = buf[len(buf)-watchPatternTail:]
+	}
+	w.tail = append([]byte(nil), buf...)
+	w.finishFiringLocked(fired)
+	w.mu.Unlock()
+
+	for _, f := range fired {
+		w.fire(f.rule, f.match)
+	}
+}
+
+// finishFiringLocked drops one-shot rules that fired. Must be called with
+// w.mu held.
+func (w *sessionWatcher) finishFiringLocked(fired []watchFiring) {
+	for _, f := range fired {
+		if f.rule.Once {
+			w.removeLocked(f.rule.ID)
+		}
+	}
+}
+
+// scan returns the printable text of data with escape sequences removed and
+// whether it rang the bell. BEL terminating an OSC sequence is not a bell.
+// Parser state carries over between calls.
+func (w *sessionWatcher) scan(data []byte) ([]byte, bool) {
+	text := make([]byte, 0, len(data))
+	bell := false
+	for _, b := range data {
+		switch w.scanState {
+		case scanGround:
+			switch {
+			case b == 0x1b:
+				w.scanState = scanEscape
+			case b == '\a':
+				bell = true
+			default:
+				text = append(text, b)
+			}
+		case scanEscape:
+			switch b {
+			case '[':
+				w.scanState = scanCSI
+			case ']', 'P', 'X', '^', '_':
+				w.scanState = scanString
+			default:
+				w.scanState = scanGround
+			}
+		case scanCSI:
+			if b >= 0x40 && b <= 0x7e {
+				w.scanState = scanGround
+			}
+		case scanString:
+			switch b {
+			case '\a':
+				w.scanState = scanGround
+			case 0x1b:
+				w.scanState = scanStringEsc
+			}
+		case scanStringEsc:
+			if b == '\\' {
+				w.scanState = scanGround
+			} else {
+				w.scanState = scanString
+			}
+		}
+	}
+	return text, bell
+}
+
+// checkSilence fires silence rules whose quiet period has elapsed
+func (w *sessionWatcher) checkSilence(now time.Time) {
+	w.mu.Lock()
+	var fired []watchFiring
+	for _, state := range w.rules {
+		if state.rule.Type != WatchRuleSilence || state.silenceFired {
+			continue
+		}
+		quietSince := w.lastOutput
+		if state.armedAt.After(quietSince) {
+			quietSince = state.armedAt
+		}
+		if now.Sub(quietSince) < time.Duration(state.rule.SilenceSeconds)*time.Second {
+			continue
+		}
+		state.silenceFired = true
+		state.lastFired = now
+		fired = append(fired, watchFiring{rule: state.rule})
+	}
+	w.finishFiringLocked(fired)
+	w.mu.Unlock()
+
+	for _, f := range fired {
+		w.fire(f.rule, f.match)
+	}
+}
+
+// silenceLoop periodically checks silence rules until the watcher is closed
+func (w *sessionWatcher) silenceLoop() {
+	ticker := time.NewTicker(watchSilenceCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case now := <-ticker.C:
+			w.checkSilence(now)
+		case <-w.stop:
+			return
+		}
+	}
+}
+
+// close stops the silence checker
+func (w *sessionWatcher) close() {
+	if w == nil {
+		return
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	select {
+	case <-w.stop:
+	default:
+		close(w.stop)
+	}
+}
+
+// AddWatchRule adds a watch rule to the session
+func (s *TerminalSession) AddWatchRule(rule WatchRule) (WatchRule, error) {
+	if err := s.channels.Check(rule.Channels); err != nil {
+		return WatchRule{}, err
+	}
+	return s.watcher.add(rule)
+}
+
+// ListWatchRules returns the session's watch rules
+func (s *TerminalSession) ListWatchRules() []WatchRule {
+	return s.watcher.list()
+}
+
+// RemoveWatchRule removes a watch rule from the session
+func (s *TerminalSession) RemoveWatchRule(id string) error {
+	return s.watcher.remove(id)
+}
+
+// fireWatchRule publishes the event for a fired rule and posts it to the
+// rule's webhook and channels
+func (s *TerminalSession) fireWatchRule(rule WatchRule, match string) {
+	event := SessionEvent{
+		Type:        SessionEventWatch,
+		SessionID:   s.id,
+		SessionName: s.GetMetadata().Name,
+		RuleID:      rule.ID,
+		RuleType:    rule.Type,
+		Match:       match,
+		Timestamp:   time.Now().Unix(),
+	}
+	if s.onEvent != nil {
+		s.onEvent(event)
+	}
+	if rule.WebhookURL != "" {
+		go func() {
+			if err := postEventWebhook(rule.WebhookURL, event); err != nil {
+				log.Printf("Session %s: failed to send watch webhook for rule %s: %v", s.id, rule.ID, err)
+			}
+		}()
+	}
+	if len(rule.Channels) > 0 {
+		s.channels.Send(rule.Channels, event.Notification())
+	}
+}
+
+// postEventWebhook posts the event as JSON to the webhook URL
+func postEventWebhook(webhookURL string, event SessionEvent) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	resp, err := eventWebhookClient.Post(webhookURL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+	return nil
+}
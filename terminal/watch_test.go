@@ -0,0 +1,173 @@
+package terminal
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"time"
+
+	"github.com/iwanhae/terminal-hub/notify"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Watch rules", func() {
+	var (
+		watcher *sessionWatcher
+		mu      sync.Mutex
+		fired   []watchFiring
+	)
+
+	firedRules := func() []watchFiring {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]watchFiring(nil), fired...)
+	}
+
+	BeforeEach(func() {
+		fired = nil
+		watcher = newSessionWatcher(func(rule WatchRule, match string) {
+			mu.Lock()
+			defer mu.Unlock()
+			fired = append(fired, watchFiring{rule: rule, match: match})
+		})
+		DeferCleanup(watcher.close)
+	})
+
+	It("should validate rules", func() {
+		Expect(WatchRule{Type: WatchRulePattern}.Validate()).To(MatchError("pattern is required for pattern rules"))
+		Expect(WatchRule{Type: WatchRulePattern, Pattern: "("}.Validate()).To(HaveOccurred())
+		Expect(WatchRule{Type: WatchRuleSilence}.Validate()).To(HaveOccurred())
+		Expect(WatchRule{Type: "unknown"}.Validate()).To(HaveOccurred())
+		Expect(WatchRule{Type: WatchRuleBell, WebhookURL: "ftp://example.com"}.Validate()).To(HaveOccurred())
+		Expect(WatchRule{Type: WatchRuleBell, WebhookURL: "https://example.com/hook"}.Validate()).To(Succeed())
+	})
+
+	It("should match patterns split across reads and ignore escape sequences", func() {
+		rule, err := watcher.add(WatchRule{Type: WatchRulePattern, Pattern: "BUILD SUCCESSFUL"})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(rule.ID).ToNot(BeEmpty())
+
+		watcher.observe([]byte("\x1b[32mBUILD SUC"))
+		Expect(firedRules()).To(BeEmpty())
+		watcher.observe([]byte("CESS\x1b[0mFUL in 3s\r\n"))
+		Expect(firedRules()).To(HaveLen(1))
+		Expect(firedRules()[0].match).To(Equal("BUILD SUCCESSFUL"))
+
+		// The match stays in the tail but must not fire again
+		time.Sleep(watchRuleCooldown)
+		watcher.observe([]byte("more output\r\n"))
+		Expect(firedRules()).To(HaveLen(1))
+	})
+
+	It("should fire bell rules for BEL but not for OSC terminators", func() {
+		_, err := watcher.add(WatchRule{Type: WatchRuleBell, Once: true})
+		Expect(err).ToNot(HaveOccurred())
+
+		watcher.observe([]byte("\x1b]0;window title\a"))
+		Expect(firedRules()).To(BeEmpty())
+		watcher.observe([]byte("done\a"))
+		Expect(firedRules()).To(HaveLen(1))
+		Expect(watcher.list()).To(BeEmpty())
+	})
+
+	It("should fire silence rules once per quiet period", func() {
+		_, err := watcher.add(WatchRule{Type: WatchRuleSilence, SilenceSeconds: 60})
+		Expect(err).ToNot(HaveOccurred())
+
+		now := time.Now()
+		watcher.checkSilence(now.Add(30 * time.Second))
+		Expect(firedRules()).To(BeEmpty())
+		watcher.checkSilence(now.Add(61 * time.Second))
+		watcher.checkSilence(now.Add(120 * time.Second))
+		Expect(firedRules()).To(HaveLen(1))
+
+		watcher.observe([]byte("output"))
+		watcher.checkSilence(time.Now().Add(61 * time.Second))
+		Expect(firedRules()).To(HaveLen(2))
+	})
+
+	It("should remove rules and enforce the per-session limit", func() {
+		rule, err := watcher.add(WatchRule{Type: WatchRuleBell})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(watcher.remove(rule.ID)).To(Succeed())
+		Expect(watcher.remove(rule.ID)).To(MatchError("watch rule not found"))
+
+		for i := 0; i < MaxWatchRules; i++ {
+			_, err := watcher.add(WatchRule{Type: WatchRuleBell})
+			Expect(err).ToNot(HaveOccurred())
+		}
+		_, err = watcher.add(WatchRule{Type: WatchRuleBell})
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should publish events for sessions created by the manager", func() {
+		ptySvc, err := NewSimulatedPTYService()
+		Expect(err).ToNot(HaveOccurred())
+		DeferCleanup(ptySvc.Close)
+
+		manager := NewSessionManager()
+		DeferCleanup(manager.CloseAll)
+		events, unsubscribe := manager.Events().Subscribe()
+		DeferCleanup(unsubscribe)
+
+		sess, err := manager.CreateSession(SessionConfig{ID: "watched", Name: "build", PTYService: ptySvc})
+		Expect(err).ToNot(HaveOccurred())
+		var event SessionEvent
+		Eventually(events).Should(Receive(&event))
+		Expect(event.Type).To(Equal(SessionEventCreated))
+		Expect(event.SessionName).To(Equal("build"))
+		rule, err := sess.(WatchRuleManager).AddWatchRule(WatchRule{Type: WatchRulePattern, Pattern: `tests? passed`})
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(ptySvc.SimulateOutput([]byte("12 tests passed\r\n"))).To(Succeed())
+		Eventually(events, "2s").Should(Receive(&event))
+		Expect(event.Type).To(Equal(SessionEventWatch))
+		Expect(event.SessionID).To(Equal("watched"))
+		Expect(event.SessionName).To(Equal("build"))
+		Expect(event.RuleID).To(Equal(rule.ID))
+		Expect(event.Match).To(Equal("tests passed"))
+
+		Expect(manager.Remove("watched")).To(Succeed())
+		Eventually(events).Should(Receive(&event))
+		Expect(event.Type).To(Equal(SessionEventClosed))
+		Expect(event.SessionID).To(Equal("watched"))
+	})
+
+	It("should send notifications to the rule's channels", func() {
+		posted := make(chan string, 4)
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var body map[string]string
+			_ = json.NewDecoder(r.Body).Decode(&body)
+			posted <- body["text"]
+		}))
+		DeferCleanup(server.Close)
+		channels, err := notify.NewChannels(map[string]notify.ChannelConfig{
+			"team": {Type: notify.ChannelSlack, WebhookURL: server.URL},
+		})
+		Expect(err).ToNot(HaveOccurred())
+
+		ptySvc, err := NewSimulatedPTYService()
+		Expect(err).ToNot(HaveOccurred())
+		DeferCleanup(ptySvc.Close)
+		manager := NewSessionManager()
+		DeferCleanup(manager.CloseAll)
+		manager.SetNotificationChannels(channels)
+
+		sess, err := manager.CreateSession(SessionConfig{ID: "notified", Name: "deploy", PTYService: ptySvc})
+		Expect(err).ToNot(HaveOccurred())
+		watches := sess.(WatchRuleManager)
+		_, err = watches.AddWatchRule(WatchRule{Type: WatchRulePattern, Pattern: "FAILED", Channels: []string{"pager"}})
+		Expect(err).To(MatchError(ContainSubstring(`unknown notification channel "pager"`)))
+		_, err = watches.AddWatchRule(WatchRule{Type: WatchRulePattern, Pattern: "FAILED", Channels: []string{"team"}})
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(ptySvc.SimulateOutput([]byte("deploy FAILED\r\n"))).To(Succeed())
+		var text string
+		Eventually(posted, "2s").Should(Receive(&text))
+		Expect(text).To(ContainSubstring(`Watch rule fired in "deploy"`))
+		Expect(text).To(ContainSubstring("FAILED"))
+	})
+})
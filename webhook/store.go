@@ -0,0 +1,135 @@
+package webhook
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// ErrWebhookNotFound is returned for unknown webhook IDs
+var ErrWebhookNotFound = errors.New("webhook not found")
+
+// Store persists webhooks in a JSON file
+type Store struct {
+	path     string
+	mu       sync.Mutex
+	webhooks []Webhook // in creation order
+}
+
+// OpenStore loads the store at path, creating it on first write
+func OpenStore(path string) (*Store, error) {
+	store := &Store{path: path}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return store, nil
+		}
+		return nil, fmt.Errorf("failed to read webhooks: %w", err)
+	}
+	if err := json.Unmarshal(data, &store.webhooks); err != nil {
+		return nil, fmt.Errorf("failed to parse webhooks: %w", err)
+	}
+	return store, nil
+}
+
+// List returns all webhooks in creation order
+func (s *Store) List() []Webhook {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Webhook{}, s.webhooks...)
+}
+
+// Get returns a webhook by ID
+func (s *Store) Get(id string) (Webhook, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	i := s.indexLocked(id)
+	if i < 0 {
+		return Webhook{}, ErrWebhookNotFound
+	}
+	return s.webhooks[i], nil
+}
+
+// Add stores a webhook, which must have a new ID
+func (s *Store) Add(hook Webhook) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.indexLocked(hook.ID) >= 0 {
+		return fmt.Errorf("webhook %q already exists", hook.ID)
+	}
+	s.webhooks = append(s.webhooks, hook)
+	return s.saveLocked()
+}
+
+// Update replaces a stored webhook with the same ID
+func (s *Store) Update(hook Webhook) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	i := s.indexLocked(hook.ID)
+	if i < 0 {
+		return ErrWebhookNotFound
+	}
+	previous := s.webhooks[i]
+	s.webhooks[i] = hook
+	if err := s.saveLocked(); err != nil {
+		s.webhooks[i] = previous
+		return err
+	}
+	return nil
+}
+
+// Delete removes a webhook
+func (s *Store) Delete(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	i := s.indexLocked(id)
+	if i < 0 {
+		return ErrWebhookNotFound
+	}
+	s.webhooks = append(s.webhooks[:i], s.webhooks[i+1:]...)
+	return s.saveLocked()
+}
+
+// indexLocked returns the position of a webhook, or -1. Must be called with
+// s.mu held.
+func (s *Store) indexLocked(id string) int {
+	for i, hook := range s.webhooks {
+		if hook.ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// saveLocked writes the store atomically. Must be called with s.mu held.
+func (s *Store) saveLocked() error {
+	data, err := json.MarshalIndent(s.webhooks, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0700); err != nil {
+		return fmt.Errorf("failed to create webhooks directory: %w", err)
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("failed to write webhooks: %w", err)
+	}
+	return os.Rename(tmp, s.path)
+}
+
+// GetStorePathFromEnv returns TERMINAL_HUB_WEBHOOKS, defaulting to
+// ~/.terminal-hub/webhooks.json
+func GetStorePathFromEnv() string {
+	if path := os.Getenv("TERMINAL_HUB_WEBHOOKS"); path != "" {
+		return path
+	}
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "webhooks.json"
+	}
+	return filepath.Join(homeDir, ".terminal-hub", "webhooks.json")
+}
@@ -0,0 +1,238 @@
+// Package webhook posts hub events such as created sessions, cron executions
+// and logins to registered HTTP endpoints, so integrations can react to them
+// without polling the API. Every payload is signed with the webhook's secret
+// and failed deliveries are retried with exponential backoff.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Events a webhook can subscribe to
+const (
+	EventSessionCreated  = "session.created"       // a session was created
+	EventSessionClosed   = "session.closed"        // a session was closed through the API
+	EventSessionExited   = "session.exited"        // a session's process exited on its own
+	EventSessionWatch    = "session.watch"         // a watch rule fired
+	EventCronSucceeded   = "cron.succeeded"        // a cron job execution succeeded
+	EventCronFailed      = "cron.failed"           // a cron job execution failed
+	EventCronRecovered   = "cron.recovered"        // a failing cron job succeeded again
+	EventAuthLogin       = "auth.login"            // a user logged in
+	EventAuthLoginFailed = "auth.login_failed"     // a login was refused
+	EventAuthLogout      = "auth.logout"           // a user logged out
+	EventAuthBanned      = "auth.banned"           // an IP was banned after repeated failed logins
+	EventAuthNewDevice   = "auth.new_device"       // a login from a device not seen before
+	EventDiskLow         = "system.disk_low"       // free space under the data directory fell below the minimum
+	EventDiskRecovered   = "system.disk_recovered" // free space under the data directory is back above the minimum
+)
+
+// Events lists every event, in the order they are documented
+var Events = []string{
+	EventSessionCreated, EventSessionClosed, EventSessionExited, EventSessionWatch,
+	EventCronSucceeded, EventCronFailed, EventCronRecovered,
+	EventAuthLogin, EventAuthLoginFailed, EventAuthLogout, EventAuthBanned, EventAuthNewDevice,
+	EventDiskLow, EventDiskRecovered,
+}
+
+// Request headers sent with every delivery
+const (
+	HeaderEvent     = "X-Terminal-Hub-Event"         // the event name
+	HeaderDelivery  = "X-Terminal-Hub-Delivery"      // the delivery ID, the same across retries
+	HeaderSignature = "X-Terminal-Hub-Signature-256" // "sha256=" and the hex HMAC of the body
+)
+
+// Delivery defaults
+const (
+	sendTimeout           = 10 * time.Second
+	defaultMaxAttempts    = 5
+	defaultInitialBackoff = time.Second
+	maxBackoff            = time.Minute
+)
+
+// Webhook is a registered endpoint receiving hub events
+type Webhook struct {
+	ID          string   `json:"id"`
+	URL         string   `json:"url"`                   // endpoint receiving POSTed payloads
+	Secret      string   `json:"secret,omitempty"`      // HMAC key, only returned when the webhook is created
+	Events      []string `json:"events,omitempty"`      // Optional: event names or patterns like "cron.*"; empty means all
+	Description string   `json:"description,omitempty"` // Optional
+	Disabled    bool     `json:"disabled,omitempty"`    // paused webhooks receive nothing
+	CreatedAt   int64    `json:"created_at"`            // unix timestamp
+}
+
+// Validate checks that a webhook is complete and well-formed
+func (h Webhook) Validate() error {
+	parsed, err := url.Parse(h.URL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return fmt.Errorf("invalid URL %q: must be an absolute http(s) URL", h.URL)
+	}
+	if h.Secret == "" {
+		return fmt.Errorf("secret is required")
+	}
+	return ValidateEvents(h.Events)
+}
+
+// Wants reports whether the webhook receives event
+func (h Webhook) Wants(event string) bool {
+	if h.Disabled {
+		return false
+	}
+	return len(h.Events) == 0 || slices.ContainsFunc(h.Events, func(pattern string) bool {
+		return matchEvent(pattern, event)
+	})
+}
+
+// Redacted returns the webhook without its secret
+func (h Webhook) Redacted() Webhook {
+	h.Secret = ""
+	return h
+}
+
+// matchEvent reports whether pattern, an event name, "*" or a group such as
+// "session.*", covers event
+func matchEvent(pattern, event string) bool {
+	if pattern == "*" || pattern == event {
+		return true
+	}
+	group, ok := strings.CutSuffix(pattern, "*")
+	return ok && strings.HasSuffix(group, ".") && strings.HasPrefix(event, group)
+}
+
+// ValidateEvents checks that every pattern covers at least one known event.
+// An empty list subscribes to all events.
+func ValidateEvents(patterns []string) error {
+	for _, pattern := range patterns {
+		if !slices.ContainsFunc(Events, func(event string) bool { return matchEvent(pattern, event) }) {
+			return fmt.Errorf("unknown event %q: must be \"*\", a group such as \"session.*\", \"cron.*\" or \"auth.*\", or one of %s",
+				pattern, strings.Join(Events, ", "))
+		}
+	}
+	return nil
+}
+
+// NewSecret returns a random secret for signing payloads
+func NewSecret() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// Sign returns the signature header value of body: "sha256=" followed by the
+// hex HMAC-SHA256 of the body keyed with secret
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Payload is the JSON body posted for an event
+type Payload struct {
+	ID        string `json:"id"`        // delivery ID
+	Event     string `json:"event"`     // one of the Event constants
+	Timestamp int64  `json:"timestamp"` // unix timestamp
+	Data      any    `json:"data"`      // event details, e.g. the session or cron execution
+}
+
+// Dispatcher delivers events to every stored webhook interested in them
+type Dispatcher struct {
+	store          *Store
+	client         *http.Client
+	maxAttempts    int
+	initialBackoff time.Duration // delay before the first retry, doubled for each further one
+}
+
+// NewDispatcher creates a dispatcher delivering to the store's webhooks
+func NewDispatcher(store *Store) *Dispatcher {
+	return &Dispatcher{
+		store:          store,
+		client:         &http.Client{Timeout: sendTimeout},
+		maxAttempts:    defaultMaxAttempts,
+		initialBackoff: defaultInitialBackoff,
+	}
+}
+
+// Store returns the webhooks the dispatcher delivers to
+func (d *Dispatcher) Store() *Store {
+	return d.store
+}
+
+// Publish delivers event with its data to every interested webhook in the
+// background. Failed deliveries are retried with backoff, then logged.
+func (d *Dispatcher) Publish(event string, data any) {
+	now := time.Now().Unix()
+	for _, hook := range d.store.List() {
+		if !hook.Wants(event) {
+			continue
+		}
+		payload := Payload{ID: uuid.New().String(), Event: event, Timestamp: now, Data: data}
+		body, err := json.Marshal(payload)
+		if err != nil {
+			log.Printf("[Webhook] Failed to encode %s payload: %v", event, err)
+			return
+		}
+		go d.deliver(hook, payload, body)
+	}
+}
+
+// deliver posts body to the webhook until it is accepted, the webhook refuses
+// it or the attempts run out
+func (d *Dispatcher) deliver(hook Webhook, payload Payload, body []byte) {
+	backoff := d.initialBackoff
+	for attempt := 1; ; attempt++ {
+		retry, err := d.send(hook, payload, body)
+		if err == nil {
+			return
+		}
+		if !retry || attempt >= d.maxAttempts {
+			log.Printf("[Webhook] Failed to deliver %s to webhook %s after %d attempt(s): %v", payload.Event, hook.ID, attempt, err)
+			return
+		}
+		time.Sleep(backoff)
+		backoff = min(backoff*2, maxBackoff)
+	}
+}
+
+// send posts body once. It reports whether a failure is worth retrying:
+// network errors, timeouts, rate limits and server errors are.
+func (d *Dispatcher) send(hook Webhook, payload Payload, body []byte) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), sendTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, hook.URL, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "terminal-hub-webhook")
+	req.Header.Set(HeaderEvent, payload.Event)
+	req.Header.Set(HeaderDelivery, payload.ID)
+	req.Header.Set(HeaderSignature, Sign(hook.Secret, body))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return true, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		retry := resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusRequestTimeout
+		return retry, fmt.Errorf("endpoint returned status %d", resp.StatusCode)
+	}
+	return false, nil
+}
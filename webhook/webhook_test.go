@@ -0,0 +1,200 @@
+package webhook
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestWebhookValidate(t *testing.T) {
+	cases := []struct {
+		hook  Webhook
+		valid bool
+	}{
+		{Webhook{URL: "https://example.com/hook", Secret: "s"}, true},
+		{Webhook{URL: "https://example.com/hook", Secret: "s", Events: []string{"*", "cron.*", EventAuthLogin}}, true},
+		{Webhook{URL: "https://example.com/hook"}, false},
+		{Webhook{URL: "ftp://example.com/hook", Secret: "s"}, false},
+		{Webhook{URL: "https://example.com/hook", Secret: "s", Events: []string{"reboot"}}, false},
+		{Webhook{URL: "https://example.com/hook", Secret: "s", Events: []string{"billing.*"}}, false},
+		{Webhook{URL: "https://example.com/hook", Secret: "s", Events: []string{"session*"}}, false},
+	}
+	for _, c := range cases {
+		if err := c.hook.Validate(); (err == nil) != c.valid {
+			t.Errorf("Validate(%+v) = %v, want valid=%v", c.hook, err, c.valid)
+		}
+	}
+}
+
+func TestWebhookWants(t *testing.T) {
+	hook := Webhook{Events: []string{"session.*", EventCronFailed}}
+	for event, want := range map[string]bool{
+		EventSessionCreated: true,
+		EventSessionWatch:   true,
+		EventCronFailed:     true,
+		EventCronSucceeded:  false,
+		EventAuthLogin:      false,
+	} {
+		if got := hook.Wants(event); got != want {
+			t.Errorf("Wants(%q) = %v, want %v", event, got, want)
+		}
+	}
+	if !(Webhook{}).Wants(EventAuthBanned) {
+		t.Error("expected a webhook without events to want everything")
+	}
+	if (Webhook{Disabled: true}).Wants(EventAuthBanned) {
+		t.Error("expected a disabled webhook to want nothing")
+	}
+}
+
+func TestStorePersistsWebhooks(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "webhooks.json")
+	store, err := OpenStore(path)
+	if err != nil {
+		t.Fatalf("failed to open store: %v", err)
+	}
+	if err := store.Add(Webhook{ID: "a", URL: "https://example.com/a", Secret: "s"}); err != nil {
+		t.Fatalf("add failed: %v", err)
+	}
+	if err := store.Add(Webhook{ID: "a", URL: "https://example.com/a"}); err == nil {
+		t.Error("expected duplicate ID to fail")
+	}
+	if err := store.Update(Webhook{ID: "a", URL: "https://example.com/b", Secret: "s"}); err != nil {
+		t.Fatalf("update failed: %v", err)
+	}
+
+	reopened, err := OpenStore(path)
+	if err != nil {
+		t.Fatalf("failed to reopen store: %v", err)
+	}
+	hook, err := reopened.Get("a")
+	if err != nil || hook.URL != "https://example.com/b" || hook.Secret != "s" {
+		t.Fatalf("expected the updated webhook with its secret, got %+v, %v", hook, err)
+	}
+	if err := reopened.Delete("a"); err != nil {
+		t.Fatalf("delete failed: %v", err)
+	}
+	if err := reopened.Delete("a"); err != ErrWebhookNotFound {
+		t.Errorf("expected ErrWebhookNotFound, got %v", err)
+	}
+	if err := reopened.Update(Webhook{ID: "a"}); err != ErrWebhookNotFound {
+		t.Errorf("expected ErrWebhookNotFound, got %v", err)
+	}
+}
+
+type receivedDelivery struct {
+	header http.Header
+	body   []byte
+}
+
+func newTestDispatcher(t *testing.T, hooks ...Webhook) *Dispatcher {
+	t.Helper()
+	store, err := OpenStore(filepath.Join(t.TempDir(), "webhooks.json"))
+	if err != nil {
+		t.Fatalf("failed to open store: %v", err)
+	}
+	for _, hook := range hooks {
+		if err := store.Add(hook); err != nil {
+			t.Fatalf("add failed: %v", err)
+		}
+	}
+	dispatcher := NewDispatcher(store)
+	dispatcher.initialBackoff = time.Millisecond
+	return dispatcher
+}
+
+func TestDispatcherSignsPayloads(t *testing.T) {
+	received := make(chan receivedDelivery, 4)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		received <- receivedDelivery{header: r.Header.Clone(), body: body}
+	}))
+	defer server.Close()
+
+	dispatcher := newTestDispatcher(t,
+		Webhook{ID: "sessions", URL: server.URL, Secret: "shh", Events: []string{"session.*"}},
+		Webhook{ID: "cron", URL: server.URL, Secret: "shh", Events: []string{"cron.*"}},
+	)
+	dispatcher.Publish(EventSessionCreated, map[string]string{"session_id": "s1"})
+
+	var delivery receivedDelivery
+	select {
+	case delivery = <-received:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for the delivery")
+	}
+	if got := delivery.header.Get(HeaderSignature); got != Sign("shh", delivery.body) {
+		t.Errorf("signature %q does not match the body", got)
+	}
+	if delivery.header.Get(HeaderEvent) != EventSessionCreated || delivery.header.Get("Content-Type") != "application/json" {
+		t.Errorf("unexpected headers %v", delivery.header)
+	}
+	var payload struct {
+		ID    string            `json:"id"`
+		Event string            `json:"event"`
+		Data  map[string]string `json:"data"`
+	}
+	if err := json.Unmarshal(delivery.body, &payload); err != nil {
+		t.Fatalf("invalid payload %s: %v", delivery.body, err)
+	}
+	if payload.Event != EventSessionCreated || payload.Data["session_id"] != "s1" || payload.ID != delivery.header.Get(HeaderDelivery) {
+		t.Errorf("unexpected payload %s", delivery.body)
+	}
+
+	select {
+	case extra := <-received:
+		t.Errorf("expected only the session webhook to be called, got %s", extra.body)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestDispatcherRetriesFailedDeliveries(t *testing.T) {
+	var attempts atomic.Int32
+	deliveries := make(chan string, 8)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		deliveries <- r.Header.Get(HeaderDelivery)
+		switch {
+		case r.URL.Path == "/gone":
+			w.WriteHeader(http.StatusGone)
+		case attempts.Add(1) < 3:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	dispatcher := newTestDispatcher(t, Webhook{ID: "flaky", URL: server.URL + "/flaky", Secret: "s"})
+	dispatcher.Publish(EventCronFailed, nil)
+
+	var ids []string
+	for len(ids) < 3 {
+		select {
+		case id := <-deliveries:
+			ids = append(ids, id)
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out after %d attempts", len(ids))
+		}
+	}
+	if ids[0] != ids[1] || ids[1] != ids[2] {
+		t.Errorf("expected retries to keep the delivery ID, got %v", ids)
+	}
+	select {
+	case <-deliveries:
+		t.Error("expected no attempt after the delivery succeeded")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	// Client errors other than timeouts and rate limits are not retried
+	dispatcher = newTestDispatcher(t, Webhook{ID: "gone", URL: server.URL + "/gone", Secret: "s"})
+	dispatcher.Publish(EventCronFailed, nil)
+	<-deliveries
+	select {
+	case <-deliveries:
+		t.Error("expected a refused delivery not to be retried")
+	case <-time.After(100 * time.Millisecond):
+	}
+}